@@ -176,15 +176,29 @@ func (c *MockGCECloud) DeleteGroup(g *cloudinstances.CloudInstanceGroup) error {
 
 // DeleteInstance deletes a GCE instance
 func (c *MockGCECloud) DeleteInstance(i *cloudinstances.CloudInstance) error {
-	return nil
-	//	return recreateCloudInstance(c, i)
+	return gce.DeleteCloudInstance(c, i)
 }
 
 func (c *MockGCECloud) DeregisterInstance(i *cloudinstances.CloudInstance) error {
 	return nil
 }
 
-// DetachInstance is not implemented yet. It needs to cause a cloud instance to no longer be counted against the group's size limits.
+// DetachInstance implements fi.Cloud::DetachInstance
 func (c *MockGCECloud) DetachInstance(i *cloudinstances.CloudInstance) error {
-	return nil
+	return gce.DetachCloudInstance(c, i)
+}
+
+// GetGroupTemplate implements fi.InstanceTemplateCloud::GetGroupTemplate
+func (c *MockGCECloud) GetGroupTemplate(g *cloudinstances.CloudInstanceGroup) (string, error) {
+	return gce.GetCloudGroupTemplate(c, g)
+}
+
+// GetInstanceTemplate implements fi.InstanceTemplateCloud::GetInstanceTemplate
+func (c *MockGCECloud) GetInstanceTemplate(i *cloudinstances.CloudInstance) (string, error) {
+	return gce.GetCloudInstanceTemplate(c, i)
+}
+
+// SetGroupTemplate implements fi.InstanceTemplateCloud::SetGroupTemplate
+func (c *MockGCECloud) SetGroupTemplate(g *cloudinstances.CloudInstanceGroup, template string) error {
+	return gce.SetCloudGroupTemplate(c, g, template)
 }
@@ -42,7 +42,8 @@ type MockClient struct {
 	instanceGroupManagerClient *instanceGroupManagerClient
 	targetPoolClient           *targetPoolClient
 
-	diskClient *diskClient
+	diskClient     *diskClient
+	instanceClient *instanceClient
 }
 
 var _ gce.ComputeClient = &MockClient{}
@@ -68,7 +69,8 @@ func NewMockClient(project string) *MockClient {
 		instanceGroupManagerClient: newInstanceGroupManagerClient(),
 		targetPoolClient:           newTargetPoolClient(),
 
-		diskClient: newDiskClient(),
+		diskClient:     newDiskClient(),
+		instanceClient: newInstanceClient(),
 	}
 }
 
@@ -93,6 +95,7 @@ func (c *MockClient) AllResources() map[string]interface{} {
 		c.targetPoolClient.All,
 		c.diskClient.All,
 		c.backendServiceClient.All,
+		c.instanceClient.All,
 	}
 	for _, f := range fs {
 		m := f()
@@ -158,8 +161,15 @@ func (c *MockClient) Routers() gce.RouterClient {
 }
 
 func (c *MockClient) Instances() gce.InstanceClient {
-	// Not implemented.
-	return nil
+	return c.instanceClient
+}
+
+// AddManagedInstance creates an instance managed by an InstanceGroupManager.
+func (c *MockClient) AddManagedInstance(project, zone, igmName string, instance *compute.Instance) error {
+	if _, err := c.instanceClient.Insert(project, zone, instance); err != nil {
+		return err
+	}
+	return c.instanceGroupManagerClient.addManagedInstance(project, zone, igmName, instance.SelfLink)
 }
 
 func (c *MockClient) InstanceTemplates() gce.InstanceTemplateClient {
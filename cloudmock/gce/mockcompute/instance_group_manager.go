@@ -28,6 +28,8 @@ import (
 type instanceGroupManagerClient struct {
 	// instanceGroupManagers are instanceGroupManagers keyed by project, zone, and name.
 	instanceGroupManagers map[string]map[string]map[string]*compute.InstanceGroupManager
+	// managedInstances are the URLs of the instances managed by instanceGroupManagers, keyed by the instanceGroupManager URL.
+	managedInstances map[string][]string
 	sync.Mutex
 }
 
@@ -36,6 +38,7 @@ var _ gce.InstanceGroupManagerClient = &instanceGroupManagerClient{}
 func newInstanceGroupManagerClient() *instanceGroupManagerClient {
 	return &instanceGroupManagerClient{
 		instanceGroupManagers: map[string]map[string]map[string]*compute.InstanceGroupManager{},
+		managedInstances:      map[string][]string{},
 	}
 }
 
@@ -92,6 +95,10 @@ func (c *instanceGroupManagerClient) Delete(project, zone, name string) (*comput
 func (c *instanceGroupManagerClient) Get(project, zone, name string) (*compute.InstanceGroupManager, error) {
 	c.Lock()
 	defer c.Unlock()
+	return c.get(project, zone, name)
+}
+
+func (c *instanceGroupManagerClient) get(project, zone, name string) (*compute.InstanceGroupManager, error) {
 	zones, ok := c.instanceGroupManagers[project]
 	if !ok {
 		return nil, notFoundError()
@@ -126,22 +133,83 @@ func (c *instanceGroupManagerClient) List(ctx context.Context, project, zone str
 }
 
 func (c *instanceGroupManagerClient) ListManagedInstances(ctx context.Context, project, zone, name string) ([]*compute.ManagedInstance, error) {
+	c.Lock()
+	defer c.Unlock()
+	igm, err := c.get(project, zone, name)
+	if err != nil {
+		return nil, err
+	}
 	var instances []*compute.ManagedInstance
+	for _, u := range c.managedInstances[igm.SelfLink] {
+		instances = append(instances, &compute.ManagedInstance{
+			Instance: u,
+			Version: &compute.ManagedInstanceVersion{
+				InstanceTemplate: igm.InstanceTemplate,
+			},
+		})
+	}
 	return instances, nil
 }
 
+// addManagedInstance makes an instance managed by an instanceGroupManager.
+func (c *instanceGroupManagerClient) addManagedInstance(project, zone, name, instanceURL string) error {
+	c.Lock()
+	defer c.Unlock()
+	igm, err := c.get(project, zone, name)
+	if err != nil {
+		return err
+	}
+	c.managedInstances[igm.SelfLink] = append(c.managedInstances[igm.SelfLink], instanceURL)
+	return nil
+}
+
 func (c *instanceGroupManagerClient) RecreateInstances(project, zone, name, id string) (*compute.Operation, error) {
 	return doneOperation(), nil
 }
 
+func (c *instanceGroupManagerClient) AbandonInstances(project, zone, name, id string) (*compute.Operation, error) {
+	c.Lock()
+	defer c.Unlock()
+	igm, err := c.get(project, zone, name)
+	if err != nil {
+		return nil, err
+	}
+	var managed []string
+	for _, u := range c.managedInstances[igm.SelfLink] {
+		if u != id {
+			managed = append(managed, u)
+		}
+	}
+	if len(managed) == len(c.managedInstances[igm.SelfLink]) {
+		return nil, notFoundError()
+	}
+	c.managedInstances[igm.SelfLink] = managed
+	igm.TargetSize--
+	return doneOperation(), nil
+}
+
 func (c *instanceGroupManagerClient) SetTargetPools(project, zone, name string, targetPools []string) (*compute.Operation, error) {
 	return doneOperation(), nil
 }
 
 func (c *instanceGroupManagerClient) SetInstanceTemplate(project, zone, name, instanceTemplateURL string) (*compute.Operation, error) {
+	c.Lock()
+	defer c.Unlock()
+	igm, err := c.get(project, zone, name)
+	if err != nil {
+		return nil, err
+	}
+	igm.InstanceTemplate = instanceTemplateURL
 	return doneOperation(), nil
 }
 
 func (c *instanceGroupManagerClient) Resize(project, zone, name string, newSize int64) (*compute.Operation, error) {
+	c.Lock()
+	defer c.Unlock()
+	igm, err := c.get(project, zone, name)
+	if err != nil {
+		return nil, err
+	}
+	igm.TargetSize = newSize
 	return doneOperation(), nil
 }
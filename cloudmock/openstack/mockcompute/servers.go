@@ -117,6 +117,14 @@ type Server struct {
 	Tags []string `json:"tags,omitempty"`
 }
 
+type serverUpdateRequest struct {
+	Server servers.UpdateOpts `json:"server"`
+}
+
+type metadataRequest struct {
+	Metadata map[string]string `json:"metadata"`
+}
+
 type Networks struct {
 	Port string `json:"port,omitempty"`
 }
@@ -131,6 +139,7 @@ func (m *MockClient) mockServers() {
 		w.Header().Add("Content-Type", "application/json")
 
 		serverID := re.ReplaceAllString(r.URL.Path, "")
+		parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
 		switch r.Method {
 		case http.MethodGet:
 			if serverID == "detail" {
@@ -138,8 +147,14 @@ func (m *MockClient) mockServers() {
 				m.listServers(w, r.Form)
 			}
 			m.getServer(w, serverID)
+		case http.MethodPut:
+			m.updateServer(w, r, serverID)
 		case http.MethodPost:
-			m.createServer(w, r)
+			if len(parts) == 3 && parts[2] == "metadata" {
+				m.updateServerMetadata(w, r, parts[1])
+			} else {
+				m.createServer(w, r)
+			}
 		case http.MethodDelete:
 			m.deleteServer(w, serverID)
 		default:
@@ -252,3 +267,68 @@ func (m *MockClient) createServer(w http.ResponseWriter, r *http.Request) {
 		panic("failed to write body")
 	}
 }
+
+func (m *MockClient) updateServer(w http.ResponseWriter, r *http.Request, serverID string) {
+	var update serverUpdateRequest
+	err := json.NewDecoder(r.Body).Decode(&update)
+	if err != nil {
+		panic("error decoding update server request")
+	}
+
+	server, ok := m.servers[serverID]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if update.Server.Name != "" {
+		server.Name = update.Server.Name
+	}
+	m.servers[serverID] = server
+
+	resp := serverGetResponse{
+		Server: server,
+	}
+	respB, err := json.Marshal(resp)
+	if err != nil {
+		panic(fmt.Sprintf("failed to marshal %+v", resp))
+	}
+	_, err = w.Write(respB)
+	if err != nil {
+		panic("failed to write body")
+	}
+}
+
+func (m *MockClient) updateServerMetadata(w http.ResponseWriter, r *http.Request, serverID string) {
+	var update metadataRequest
+	err := json.NewDecoder(r.Body).Decode(&update)
+	if err != nil {
+		panic("error decoding update server metadata request")
+	}
+
+	server, ok := m.servers[serverID]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	metadata := make(map[string]string)
+	for k, v := range server.Metadata {
+		metadata[k] = v
+	}
+	for k, v := range update.Metadata {
+		metadata[k] = v
+	}
+	server.Metadata = metadata
+	m.servers[serverID] = server
+
+	resp := metadataRequest{
+		Metadata: metadata,
+	}
+	respB, err := json.Marshal(resp)
+	if err != nil {
+		panic(fmt.Sprintf("failed to marshal %+v", resp))
+	}
+	_, err = w.Write(respB)
+	if err != nil {
+		panic("failed to write body")
+	}
+}
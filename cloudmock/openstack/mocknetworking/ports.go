@@ -240,7 +240,23 @@ func (m *MockClient) updatePort(w http.ResponseWriter, r *http.Request) {
 	if deviceID != nil {
 		port.DeviceID = fi.ValueOf(deviceID)
 	}
+	name := update.Port.Name
+	if name != nil {
+		port.Name = fi.ValueOf(name)
+	}
 	m.ports[portID] = port
 
 	w.WriteHeader(http.StatusOK)
+
+	resp := portGetResponse{
+		Port: port,
+	}
+	respB, err := json.Marshal(resp)
+	if err != nil {
+		panic(fmt.Sprintf("failed to marshal %+v", resp))
+	}
+	_, err = w.Write(respB)
+	if err != nil {
+		panic("failed to write body")
+	}
 }
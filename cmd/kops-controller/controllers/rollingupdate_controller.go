@@ -0,0 +1,443 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog/v2"
+	"k8s.io/kops/cmd/kops-controller/pkg/config"
+	"k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/pkg/client/simple"
+	"k8s.io/kops/pkg/client/simple/vfsclientset"
+	"k8s.io/kops/pkg/cloudinstances"
+	"k8s.io/kops/pkg/clusterlock"
+	"k8s.io/kops/pkg/instancegroups"
+	"k8s.io/kops/pkg/validation"
+	"k8s.io/kops/upup/pkg/fi/cloudup"
+	"k8s.io/kops/util/pkg/vfs"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	// needsUpdateAnnotation marks a node whose instance should be replaced, as set by channels.
+	needsUpdateAnnotation = "kops.k8s.io/needs-update"
+
+	// requestPollInterval is how often we check for a rolling update requested through the ConfigMap.
+	requestPollInterval = time.Minute
+
+	// retryInterval is how long we wait after a rolling update stops before starting another for the same nodes.
+	retryInterval = 10 * time.Minute
+)
+
+// NewRollingUpdateReconciler is the constructor for a RollingUpdateReconciler
+func NewRollingUpdateReconciler(mgr manager.Manager, opt *config.Options) (*RollingUpdateReconciler, error) {
+	r := &RollingUpdateReconciler{
+		clusterName: opt.ClusterName,
+		client:      mgr.GetClient(),
+		log:         ctrl.Log.WithName("controllers").WithName("RollingUpdate"),
+		recorder:    mgr.GetEventRecorderFor("kops-controller"),
+		restConfig:  mgr.GetConfig(),
+		configMapID: types.NamespacedName{
+			Namespace: instancegroups.InClusterConfigMapNamespace,
+			Name:      instancegroups.InClusterConfigMapName,
+		},
+	}
+
+	k8sClient, err := kubernetes.NewForConfig(mgr.GetConfig())
+	if err != nil {
+		return nil, fmt.Errorf("error building kubernetes client: %v", err)
+	}
+	r.k8sClient = k8sClient
+
+	// The state store holds the configuration of each cluster under a path named for the cluster
+	configBase := strings.TrimSuffix(opt.ConfigBase, "/")
+	registryBase := strings.TrimSuffix(configBase, "/"+opt.ClusterName)
+	if registryBase == configBase {
+		return nil, fmt.Errorf("cannot determine state store from configBase %q", opt.ConfigBase)
+	}
+	basePath, err := vfs.Context.BuildVfsPath(registryBase)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse state store %q: %v", registryBase, err)
+	}
+	r.clientset = vfsclientset.NewVFSClientset(basePath)
+
+	return r, nil
+}
+
+// RollingUpdateReconciler performs rolling updates from within the cluster.
+// It replaces the instances of nodes annotated as needing update, and performs rolling updates requested
+// through a ConfigMap, recording progress in that ConfigMap and as events.
+type RollingUpdateReconciler struct {
+	// clusterName identifies the kOps cluster
+	clusterName string
+
+	// client is the controller-runtime client
+	client client.Client
+
+	// log is a logr
+	log logr.Logger
+
+	// recorder publishes the progress of rolling updates as events
+	recorder record.EventRecorder
+
+	// k8sClient is a client-go client, used for draining nodes and validating the cluster
+	k8sClient kubernetes.Interface
+
+	// clientset reads the cluster and instance groups from the state store
+	clientset simple.Clientset
+
+	// restConfig is the configuration of k8sClient, used for probing the apiservers when validating the cluster
+	restConfig *rest.Config
+
+	// configMapID identifies the ConfigMap through which rolling updates are requested and reported
+	configMapID types.NamespacedName
+}
+
+// +kubebuilder:rbac:groups=,resources=nodes,verbs=get;list;watch;patch;delete
+// +kubebuilder:rbac:groups=,resources=configmaps,namespace=kube-system,resourceNames=kops-rolling-update,verbs=get;update
+// +kubebuilder:rbac:groups=,resources=configmaps,namespace=kube-system,verbs=create
+// +kubebuilder:rbac:groups=apps,resources=deployments;statefulsets,verbs=get
+// +kubebuilder:rbac:groups=policy,resources=poddisruptionbudgets,verbs=get;list
+
+// Reconcile starts, or resumes, a rolling update if one is requested or any node needs update.
+// It does not return until the rolling update stops.
+func (r *RollingUpdateReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	_ = r.log.WithValues("rollingupdatecontroller", req.NamespacedName)
+
+	var request *instancegroups.RollingUpdateRequest
+	var previous *instancegroups.RollingUpdateProgress
+	configMap, err := r.k8sClient.CoreV1().ConfigMaps(r.configMapID.Namespace).Get(ctx, r.configMapID.Name, metav1.GetOptions{})
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			return ctrl.Result{}, fmt.Errorf("error reading ConfigMap %s: %v", r.configMapID, err)
+		}
+	} else {
+		request, previous, err = instancegroups.ParseInClusterConfigMap(configMap)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	nodes := &corev1.NodeList{}
+	if err := r.client.List(ctx, nodes); err != nil {
+		return ctrl.Result{}, fmt.Errorf("error listing nodes: %v", err)
+	}
+	var needUpdate []string
+	for i := range nodes.Items {
+		if _, ok := nodes.Items[i].Annotations[needsUpdateAnnotation]; ok {
+			needUpdate = append(needUpdate, nodes.Items[i].Name)
+		}
+	}
+
+	var progress *instancegroups.RollingUpdateProgress
+	switch {
+	case previous != nil && !previous.IsComplete() && previous.Error == "":
+		// The rolling update was interrupted, most likely because kops-controller moved to another control plane node
+		klog.Infof("resuming the rolling update started at %s", previous.StartTime.Format(time.RFC3339))
+		progress = previous
+
+	case request != nil:
+		klog.Infof("starting the rolling update requested at %s", request.RequestTime.Format(time.RFC3339))
+		progress = request.NewProgress(r.clusterName)
+
+	case len(needUpdate) != 0:
+		// Don't repeatedly update nodes that the last rolling update failed to, or chose not to, replace
+		if previous != nil {
+			if wait := time.Until(previous.UpdateTime.Add(retryInterval)); wait > 0 {
+				return ctrl.Result{RequeueAfter: wait}, nil
+			}
+		}
+		sort.Strings(needUpdate)
+		klog.Infof("starting a rolling update, as nodes need update: %s", strings.Join(needUpdate, ", "))
+		progress = (&instancegroups.RollingUpdateRequest{}).NewProgress(r.clusterName)
+
+	default:
+		return ctrl.Result{RequeueAfter: requestPollInterval}, nil
+	}
+	if previous != nil && progress != previous {
+		progress.KnownGoodTemplates = previous.KnownGoodTemplates
+	}
+
+	cluster, err := r.clientset.GetCluster(ctx, r.clusterName)
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("error reading cluster %q: %v", r.clusterName, err)
+	}
+
+	// Don't replace instances while kops rolling-update, rotate-ca or apply is changing the same cluster
+	lock, err := clusterlock.AcquireForCluster(ctx, cluster, "kops-controller rolling-update")
+	if err != nil {
+		var held *clusterlock.HeldError
+		if errors.As(err, &held) {
+			klog.Infof("not starting the rolling update yet: %v", err)
+			return ctrl.Result{RequeueAfter: requestPollInterval}, nil
+		}
+		return ctrl.Result{}, err
+	}
+	defer func() {
+		if err := lock.Release(context.Background()); err != nil {
+			klog.Warningf("error releasing cluster lock: %v", err)
+		}
+	}()
+	ctx, cancel := lock.WithContext(ctx)
+	defer cancel()
+	ctx = vfsclientset.WithRevisionAuthor(ctx, lock.Holder())
+
+	if err := r.rollingUpdate(ctx, cluster, progress, nodes.Items); err != nil {
+		klog.Warningf("rolling update failed: %v", err)
+		return ctrl.Result{RequeueAfter: retryInterval}, nil
+	}
+	return ctrl.Result{RequeueAfter: requestPollInterval}, nil
+}
+
+func (r *RollingUpdateReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	// All changes map to a single request, as we update the whole cluster
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("rollingupdate").
+		Watches(&corev1.Node{}, handler.EnqueueRequestsFromMapFunc(func(ctx context.Context, obj client.Object) []reconcile.Request {
+			return []reconcile.Request{{NamespacedName: r.configMapID}}
+		})).
+		Complete(r)
+}
+
+// rollingUpdate performs a rolling update, publishing its progress.
+func (r *RollingUpdateReconciler) rollingUpdate(ctx context.Context, cluster *kops.Cluster, progress *instancegroups.RollingUpdateProgress, nodes []corev1.Node) error {
+	recorder, err := instancegroups.NewProgressRecorder(ctx, nil, nil, progress)
+	if err != nil {
+		return err
+	}
+	recorder.Observe(func(progress instancegroups.RollingUpdateProgress, event instancegroups.ProgressEvent) {
+		r.publishProgress(ctx, &progress, event)
+	})
+	started := recorder.Progress()
+	r.publishProgress(ctx, &started, instancegroups.ProgressEvent{
+		Reason:  "RollingUpdateStarted",
+		Message: fmt.Sprintf("Rolling update started at %s", started.StartTime.Format(time.RFC3339)),
+	})
+
+	d, groups, list, err := r.buildRollingUpdate(ctx, cluster, progress, nodes)
+	if err != nil {
+		recorder.Finished(err)
+		return err
+	}
+	d.Progress = recorder
+
+	return d.RollingUpdate(groups, list)
+}
+
+// buildRollingUpdate loads the instances of the cluster, returning a rolling update of them
+// with the same defaults as kops rolling-update cluster.
+func (r *RollingUpdateReconciler) buildRollingUpdate(ctx context.Context, cluster *kops.Cluster, progress *instancegroups.RollingUpdateProgress, nodes []corev1.Node) (*instancegroups.RollingUpdateCluster, map[string]*cloudinstances.CloudInstanceGroup, *kops.InstanceGroupList, error) {
+	list, err := r.clientset.InstanceGroupsFor(cluster).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("error listing instance groups: %v", err)
+	}
+
+	instanceGroups, err := filterInstanceGroups(list, progress.InstanceGroups, progress.InstanceGroupRoles)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	cloud, err := cloudup.BuildCloud(cluster)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	groups, err := cloud.GetCloudGroups(cluster, instanceGroups, false, nodes)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	keyStore, err := r.clientset.KeyStore(cluster)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	clusterValidator, err := validation.NewClusterValidator(cluster, cloud, list, r.restConfig, r.k8sClient, keyStore, true)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("cannot create cluster validator: %v", err)
+	}
+
+	pausePath, err := instancegroups.RollingUpdatePausePath(cluster)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	d := &instancegroups.RollingUpdateCluster{
+		Clientset:               r.clientset,
+		Ctx:                     ctx,
+		Cluster:                 cluster,
+		Cloud:                   cloud,
+		MasterInterval:          15 * time.Second,
+		NodeInterval:            15 * time.Second,
+		BastionInterval:         15 * time.Second,
+		Force:                   progress.Force,
+		K8sClient:               r.k8sClient,
+		ClusterValidator:        clusterValidator,
+		FailOnDrainError:        true,
+		FailOnValidate:          true,
+		ClusterName:             r.clusterName,
+		PostDrainDelay:          5 * time.Second,
+		ValidationTimeout:       15 * time.Minute,
+		ValidateTickDuration:    30 * time.Second,
+		ValidateSuccessDuration: 10 * time.Second,
+		ValidateCount:           2,
+		DrainTimeout:            15 * time.Minute,
+		PausePath:               pausePath,
+		// There is no one to resume a rolling update stopped outside of a maintenance window
+		WaitForMaintenanceWindow: true,
+		// kops-controller is not granted the permissions to create cloud groups
+		DisableBlueGreen: true,
+		// The commands of exec hooks are not available in the kops-controller container
+		DisableExecHooks: true,
+	}
+	d.Options.InitDefaults()
+
+	// When a cluster only has a single apiserver, we can't drain after deregistering it
+	controlPlaneCount := int32(0)
+	for i := range list.Items {
+		ig := &list.Items[i]
+		if ig.Spec.Role == kops.InstanceGroupRoleControlPlane || ig.Spec.Role == kops.InstanceGroupRoleAPIServer {
+			minSize := int32(1)
+			if ig.Spec.MinSize != nil {
+				minSize = *ig.Spec.MinSize
+			}
+			controlPlaneCount += minSize
+		}
+	}
+	if controlPlaneCount <= 1 {
+		d.Options.DeregisterControlPlaneNodes = false
+	}
+
+	if err := d.AdjustNeedUpdate(groups); err != nil {
+		return nil, nil, nil, err
+	}
+
+	return d, groups, list, nil
+}
+
+// filterInstanceGroups returns the instance groups that are named, and are of the roles, or all if none are specified.
+func filterInstanceGroups(list *kops.InstanceGroupList, names []string, roles []string) ([]*kops.InstanceGroup, error) {
+	var instanceGroups []*kops.InstanceGroup
+	for i := range list.Items {
+		instanceGroups = append(instanceGroups, &list.Items[i])
+	}
+
+	if len(names) != 0 {
+		var filtered []*kops.InstanceGroup
+		for _, name := range names {
+			var found *kops.InstanceGroup
+			for _, ig := range instanceGroups {
+				if ig.ObjectMeta.Name == name {
+					found = ig
+					break
+				}
+			}
+			if found == nil {
+				return nil, fmt.Errorf("InstanceGroup %q not found", name)
+			}
+			filtered = append(filtered, found)
+		}
+		instanceGroups = filtered
+	}
+
+	if len(roles) != 0 {
+		var filtered []*kops.InstanceGroup
+		for _, role := range roles {
+			s, found := kops.ParseInstanceGroupRole(role, true)
+			if !found {
+				return nil, fmt.Errorf("invalid instance group role %q", role)
+			}
+			for _, ig := range instanceGroups {
+				if ig.Spec.Role == s {
+					filtered = append(filtered, ig)
+				}
+			}
+		}
+		instanceGroups = filtered
+	}
+
+	return instanceGroups, nil
+}
+
+// publishProgress records the progress in the ConfigMap, and reports the change as an event
+// on the node it concerns, or else on the ConfigMap.
+func (r *RollingUpdateReconciler) publishProgress(ctx context.Context, progress *instancegroups.RollingUpdateProgress, event instancegroups.ProgressEvent) {
+	configMap, err := r.writeProgress(ctx, progress)
+	if err != nil {
+		klog.Warningf("error recording rolling update progress: %v", err)
+	}
+
+	eventType := corev1.EventTypeNormal
+	if event.Warning {
+		eventType = corev1.EventTypeWarning
+	}
+	if event.Node != "" {
+		ref := &corev1.ObjectReference{
+			Kind: "Node",
+			Name: event.Node,
+			UID:  types.UID(event.Node),
+		}
+		r.recorder.Event(ref, eventType, event.Reason, event.Message)
+	} else if configMap != nil {
+		r.recorder.Event(configMap, eventType, event.Reason, event.Message)
+	}
+}
+
+// writeProgress records the progress in the ConfigMap, creating it if it does not exist.
+func (r *RollingUpdateReconciler) writeProgress(ctx context.Context, progress *instancegroups.RollingUpdateProgress) (*corev1.ConfigMap, error) {
+	configMaps := r.k8sClient.CoreV1().ConfigMaps(r.configMapID.Namespace)
+
+	configMap, err := configMaps.Get(ctx, r.configMapID.Name, metav1.GetOptions{})
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			return nil, fmt.Errorf("error reading ConfigMap %s: %v", r.configMapID, err)
+		}
+		configMap = &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: r.configMapID.Namespace,
+				Name:      r.configMapID.Name,
+			},
+		}
+		if err := instancegroups.SetInClusterProgress(configMap, progress); err != nil {
+			return nil, err
+		}
+		return configMaps.Create(ctx, configMap, metav1.CreateOptions{})
+	}
+
+	if err := instancegroups.SetInClusterProgress(configMap, progress); err != nil {
+		return nil, err
+	}
+	return configMaps.Update(ctx, configMap, metav1.UpdateOptions{})
+}
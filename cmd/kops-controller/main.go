@@ -194,6 +194,19 @@ func main() {
 		os.Exit(1)
 	}
 
+	if opt.RollingUpdate != nil && opt.RollingUpdate.Enabled {
+		setupLog.Info("enabling rolling update controller")
+		rollingUpdateController, err := controllers.NewRollingUpdateReconciler(mgr, &opt)
+		if err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "RollingUpdateController")
+			os.Exit(1)
+		}
+		if err := rollingUpdateController.SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "RollingUpdateController")
+			os.Exit(1)
+		}
+	}
+
 	// +kubebuilder:scaffold:builder
 
 	setupLog.Info("starting manager")
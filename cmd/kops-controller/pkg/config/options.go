@@ -37,6 +37,9 @@ type Options struct {
 
 	// Discovery configures options relating to discovery, particularly for gossip mode.
 	Discovery *DiscoveryOptions `json:"discovery,omitempty"`
+
+	// RollingUpdate configures rolling updates performed from within the cluster.
+	RollingUpdate *RollingUpdateOptions `json:"rollingUpdate,omitempty"`
 }
 
 func (o *Options) PopulateDefaults() {
@@ -78,3 +81,9 @@ type DiscoveryOptions struct {
 	// Enabled specifies whether support for discovery population is enabled.
 	Enabled bool `json:"enabled"`
 }
+
+// RollingUpdateOptions configures rolling updates performed by kops-controller.
+type RollingUpdateOptions struct {
+	// Enabled specifies whether kops-controller replaces the instances of nodes that need update.
+	Enabled bool `json:"enabled"`
+}
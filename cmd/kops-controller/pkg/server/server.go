@@ -261,14 +261,21 @@ func (s *Server) bootstrap(w http.ResponseWriter, r *http.Request) {
 }
 
 func (s *Server) issueCert(ctx context.Context, name string, pubKey string, id *bootstrap.VerifyResult, validHours uint32, keypairIDs map[string]string) (string, error) {
+	// Nodes send RSA keys as "RSA PUBLIC KEY" blocks and other keys as "PUBLIC KEY" blocks, both in PKIX form.
 	block, _ := pem.Decode([]byte(pubKey))
-	if block.Type != "RSA PUBLIC KEY" {
+	if block == nil {
+		return "", fmt.Errorf("unable to decode key")
+	}
+	if block.Type != "RSA PUBLIC KEY" && block.Type != "PUBLIC KEY" {
 		return "", fmt.Errorf("unexpected key type %q", block.Type)
 	}
 	key, err := x509.ParsePKIXPublicKey(block.Bytes)
 	if err != nil {
 		return "", fmt.Errorf("parsing key: %v", err)
 	}
+	if pki.KeyAlgorithmOf(key) == "" {
+		return "", fmt.Errorf("unsupported key type %T", key)
+	}
 
 	issueReq := &pki.IssueCertRequest{
 		Signer:    fi.CertificateIDCA,
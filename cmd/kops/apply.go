@@ -0,0 +1,729 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/klog/v2"
+	"k8s.io/kops/cmd/kops/util"
+	kopsapi "k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/pkg/client/simple"
+	"k8s.io/kops/pkg/client/simple/vfsclientset"
+	"k8s.io/kops/pkg/clusterlock"
+	"k8s.io/kops/pkg/diff"
+	"k8s.io/kops/pkg/kopscodecs"
+	"k8s.io/kops/upup/pkg/fi"
+	"k8s.io/kops/upup/pkg/fi/cloudup"
+	"k8s.io/kops/util/pkg/text"
+	"k8s.io/kops/util/pkg/vfs"
+	"k8s.io/kubectl/pkg/util/i18n"
+	"k8s.io/kubectl/pkg/util/templates"
+)
+
+var (
+	applyLong = templates.LongDesc(i18n.T(`
+	Create or update the Cluster, InstanceGroup, SSHCredential and Keyset objects in files or
+	directories, so that the state store matches them. A directory stands for the YAML and JSON
+	files directly inside it. Objects other than a Cluster must have the kops.k8s.io/cluster
+	label, unless the files contain a single Cluster.
+
+	With --prune, the instance groups of each Cluster in the files that are not themselves in
+	the files are deleted from the state store. Their cloud resources are deleted by the next
+	kops update cluster, which terminates their instances without draining them, as kops delete
+	instancegroup does.
+
+	With --dry-run, the changes to each object are shown, and nothing is written.
+
+	As with any other change to the cluster spec, run kops update cluster afterwards to apply
+	the changes to the cloud resources.`))
+
+	applyExample = templates.Examples(i18n.T(`
+	# Apply the objects in a directory
+	kops apply -f clusters/k8s-cluster.example.com/
+
+	# Show the changes to each object without writing them
+	kops apply -f clusters/k8s-cluster.example.com/ --dry-run
+
+	# Also delete the instance groups that are not in the directory
+	kops apply -f clusters/k8s-cluster.example.com/ --prune`))
+
+	applyShort = i18n.T(`Create or update cluster resources from files.`)
+)
+
+// ApplyOptions is the options for the command
+type ApplyOptions struct {
+	// Filenames is a list of files or directories containing the objects to apply.
+	Filenames []string
+	// Prune deletes the instance groups of each applied cluster that are not in the files from the state store.
+	Prune bool
+	// DryRun shows the changes to each object instead of making them.
+	DryRun bool
+}
+
+// NewCmdApply returns a new apply command
+func NewCmdApply(f *util.Factory, out io.Writer) *cobra.Command {
+	options := &ApplyOptions{}
+
+	cmd := &cobra.Command{
+		Use:     "apply {-f FILENAME}...",
+		Short:   applyShort,
+		Long:    applyLong,
+		Example: applyExample,
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return RunApply(cmd.Context(), f, out, options)
+		},
+	}
+
+	cmd.Flags().StringSliceVarP(&options.Filenames, "filename", "f", options.Filenames, "A list of one or more files or directories separated by a comma.")
+	cmd.MarkFlagRequired("filename")
+	cmd.Flags().BoolVar(&options.Prune, "prune", options.Prune, "Delete the instance groups of each Cluster in the files that are not in the files from the state store; kops update cluster then deletes their cloud resources")
+	cmd.Flags().BoolVar(&options.DryRun, "dry-run", options.DryRun, "Show the changes to each object without making them")
+
+	return cmd
+}
+
+// manifestObject is an object decoded from a manifest file.
+type manifestObject struct {
+	filename string
+	object   runtime.Object
+	gvk      *schema.GroupVersionKind
+}
+
+// expandManifestFilenames replaces each local directory in filenames with the YAML and JSON files directly inside it.
+func expandManifestFilenames(filenames []string) ([]string, error) {
+	var expanded []string
+	for _, filename := range filenames {
+		if filename != "-" {
+			if info, err := os.Stat(filename); err == nil && info.IsDir() {
+				entries, err := os.ReadDir(filename)
+				if err != nil {
+					return nil, fmt.Errorf("error reading directory %q: %v", filename, err)
+				}
+				for _, entry := range entries {
+					if entry.IsDir() {
+						continue
+					}
+					switch filepath.Ext(entry.Name()) {
+					case ".yaml", ".yml", ".json":
+						expanded = append(expanded, filepath.Join(filename, entry.Name()))
+					}
+				}
+				continue
+			}
+		}
+		expanded = append(expanded, filename)
+	}
+	return expanded, nil
+}
+
+// readManifestObjects decodes the objects in files, where "-" is stdin and a local directory stands for
+// the YAML and JSON files directly inside it.
+func readManifestObjects(filenames []string) ([]*manifestObject, error) {
+	filenames, err := expandManifestFilenames(filenames)
+	if err != nil {
+		return nil, err
+	}
+
+	var objects []*manifestObject
+	for _, filename := range filenames {
+		var contents []byte
+		if filename == "-" {
+			contents, err = ConsumeStdin()
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			contents, err = vfs.Context.ReadFile(filename)
+			if err != nil {
+				return nil, fmt.Errorf("error reading file %q: %v", filename, err)
+			}
+		}
+
+		for _, section := range text.SplitContentToSections(contents) {
+			o, gvk, err := kopscodecs.Decode(section, nil)
+			if err != nil {
+				return nil, fmt.Errorf("error parsing file %q: %v", filename, err)
+			}
+			objects = append(objects, &manifestObject{filename: filename, object: o, gvk: gvk})
+		}
+	}
+	return objects, nil
+}
+
+// applyManifest is the objects to apply to a single cluster.
+type applyManifest struct {
+	// cluster is nil if the files do not contain the Cluster
+	cluster        *kopsapi.Cluster
+	instanceGroups []*kopsapi.InstanceGroup
+	sshCredentials []*kopsapi.SSHCredential
+	keysets        []*kopsapi.Keyset
+}
+
+// groupApplyObjects groups the objects by the cluster they belong to.
+func groupApplyObjects(objects []*manifestObject) (map[string]*applyManifest, error) {
+	manifests := make(map[string]*applyManifest)
+	manifestFor := func(clusterName string) *applyManifest {
+		manifest := manifests[clusterName]
+		if manifest == nil {
+			manifest = &applyManifest{}
+			manifests[clusterName] = manifest
+		}
+		return manifest
+	}
+
+	var clusterNames []string
+	for _, o := range objects {
+		if cluster, ok := o.object.(*kopsapi.Cluster); ok {
+			clusterNames = append(clusterNames, cluster.ObjectMeta.Name)
+		}
+	}
+
+	// clusterNameFor returns the cluster that an object belongs to, from its label or the only Cluster in the files
+	clusterNameFor := func(o *manifestObject, meta *metav1.ObjectMeta) (string, error) {
+		clusterName := meta.Labels[kopsapi.LabelClusterName]
+		if clusterName != "" {
+			return clusterName, nil
+		}
+		if len(clusterNames) == 1 {
+			if meta.Labels == nil {
+				meta.Labels = make(map[string]string)
+			}
+			meta.Labels[kopsapi.LabelClusterName] = clusterNames[0]
+			return clusterNames[0], nil
+		}
+		return "", fmt.Errorf("must specify %q label with cluster name for %s %q in %q", kopsapi.LabelClusterName, o.gvk.Kind, meta.Name, o.filename)
+	}
+
+	seen := make(map[string]bool)
+	for _, o := range objects {
+		var key string
+		switch v := o.object.(type) {
+		case *kopsapi.Cluster:
+			key = "Cluster/" + v.ObjectMeta.Name
+			manifestFor(v.ObjectMeta.Name).cluster = v
+
+		case *kopsapi.InstanceGroup:
+			clusterName, err := clusterNameFor(o, &v.ObjectMeta)
+			if err != nil {
+				return nil, err
+			}
+			key = "InstanceGroup/" + clusterName + "/" + v.ObjectMeta.Name
+			manifest := manifestFor(clusterName)
+			manifest.instanceGroups = append(manifest.instanceGroups, v)
+
+		case *kopsapi.SSHCredential:
+			clusterName, err := clusterNameFor(o, &v.ObjectMeta)
+			if err != nil {
+				return nil, err
+			}
+			if v.Spec.PublicKey == "" {
+				return nil, fmt.Errorf("spec.PublicKey is required for SSHCredential %q in %q", v.ObjectMeta.Name, o.filename)
+			}
+			key = "SSHCredential/" + clusterName + "/" + v.ObjectMeta.Name
+			manifest := manifestFor(clusterName)
+			manifest.sshCredentials = append(manifest.sshCredentials, v)
+
+		case *kopsapi.Keyset:
+			clusterName, err := clusterNameFor(o, &v.ObjectMeta)
+			if err != nil {
+				return nil, err
+			}
+			if v.Spec.Type != kopsapi.SecretTypeKeypair {
+				return nil, fmt.Errorf("keyset %q in %q has type %q, only %q is supported", v.ObjectMeta.Name, o.filename, v.Spec.Type, kopsapi.SecretTypeKeypair)
+			}
+			if fi.FindPrimary(v) == nil {
+				return nil, fmt.Errorf("keyset %q in %q has no trusted key to use as its primary", v.ObjectMeta.Name, o.filename)
+			}
+			key = "Keyset/" + clusterName + "/" + v.ObjectMeta.Name
+			manifest := manifestFor(clusterName)
+			manifest.keysets = append(manifest.keysets, v)
+
+		default:
+			klog.V(2).Infof("Type of object was %T", v)
+			return nil, fmt.Errorf("unhandled kind %q in %q", o.gvk, o.filename)
+		}
+
+		if seen[key] {
+			return nil, fmt.Errorf("files contain %s more than once", key)
+		}
+		seen[key] = true
+	}
+
+	for _, manifest := range manifests {
+		sort.Slice(manifest.instanceGroups, func(i, j int) bool {
+			return manifest.instanceGroups[i].ObjectMeta.Name < manifest.instanceGroups[j].ObjectMeta.Name
+		})
+	}
+
+	return manifests, nil
+}
+
+// applyAction is what applying an object did, or would do in a dry run.
+type applyAction string
+
+const (
+	applyActionCreated    applyAction = "created"
+	applyActionConfigured applyAction = "configured"
+	applyActionUnchanged  applyAction = "unchanged"
+	applyActionPruned     applyAction = "pruned"
+)
+
+// applier applies the objects of a single cluster.
+type applier struct {
+	clientset simple.Clientset
+	out       io.Writer
+	dryRun    bool
+	// changed is set once an object is not unchanged
+	changed bool
+	// pruned is set once an instance group is pruned
+	pruned bool
+}
+
+// report writes the summary of applying an object and, in a dry run, the changes to it.
+func (a *applier) report(kind string, name string, action applyAction, current, target runtime.Object) error {
+	if action != applyActionUnchanged {
+		a.changed = true
+	}
+
+	if a.dryRun && action != applyActionUnchanged {
+		var currentYAML, targetYAML string
+		var err error
+		if current != nil {
+			if currentYAML, err = specYAML(current); err != nil {
+				return err
+			}
+		}
+		if target != nil {
+			if targetYAML, err = specYAML(target); err != nil {
+				return err
+			}
+		}
+		fmt.Fprintf(a.out, "%s", diff.FormatDiff(currentYAML, targetYAML))
+	}
+
+	suffix := ""
+	if a.dryRun {
+		suffix = " (dry run)"
+	}
+	fmt.Fprintf(a.out, "%s/%s %s%s\n", strings.ToLower(kind), name, action, suffix)
+	return nil
+}
+
+func RunApply(ctx context.Context, f *util.Factory, out io.Writer, options *ApplyOptions) error {
+	objects, err := readManifestObjects(options.Filenames)
+	if err != nil {
+		return err
+	}
+
+	manifests, err := groupApplyObjects(objects)
+	if err != nil {
+		return err
+	}
+
+	clientset, err := f.KopsClient()
+	if err != nil {
+		return err
+	}
+
+	var clusterNames []string
+	for clusterName := range manifests {
+		clusterNames = append(clusterNames, clusterName)
+	}
+	sort.Strings(clusterNames)
+
+	a := &applier{
+		clientset: clientset,
+		out:       out,
+		dryRun:    options.DryRun,
+	}
+	for _, clusterName := range clusterNames {
+		if err := a.applyCluster(ctx, clusterName, manifests[clusterName], options.Prune); err != nil {
+			return err
+		}
+	}
+
+	if !options.DryRun && a.changed {
+		fmt.Fprintf(out, "\nTo deploy these changes, run:\n")
+		for _, clusterName := range clusterNames {
+			fmt.Fprintf(out, "  kops update cluster --name %s --yes\n", clusterName)
+		}
+		if a.pruned {
+			fmt.Fprintf(out, "\nThis also deletes the cloud resources of the pruned instance groups, terminating their instances without draining them.\n")
+		}
+	}
+	return nil
+}
+
+// applyCluster applies the objects of a cluster, creating the cluster first if needed.
+func (a *applier) applyCluster(ctx context.Context, clusterName string, manifest *applyManifest, prune bool) error {
+	cluster, err := a.clientset.GetCluster(ctx, clusterName)
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			return fmt.Errorf("error fetching cluster %q: %v", clusterName, err)
+		}
+		cluster = nil
+	}
+
+	if cluster == nil && manifest.cluster == nil {
+		return fmt.Errorf("cluster %q not found", clusterName)
+	}
+
+	if cluster != nil && !a.dryRun {
+		lock, err := clusterlock.AcquireForCluster(ctx, cluster, "apply")
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if err := lock.Release(context.Background()); err != nil {
+				klog.Warningf("error releasing cluster lock: %v", err)
+			}
+		}()
+
+		var cancel context.CancelFunc
+		ctx, cancel = lock.WithContext(ctx)
+		defer cancel()
+		ctx = vfsclientset.WithRevisionAuthor(ctx, lock.Holder())
+	}
+
+	// A cluster that does not exist yet has no other objects either
+	created := cluster == nil
+
+	if manifest.cluster != nil {
+		cluster, err = a.applyClusterObject(ctx, cluster, manifest.cluster)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, ig := range manifest.instanceGroups {
+		if err := a.applyInstanceGroup(ctx, cluster, created, ig); err != nil {
+			return err
+		}
+	}
+
+	for _, sshCredential := range manifest.sshCredentials {
+		if err := a.applySSHCredential(ctx, cluster, created, sshCredential); err != nil {
+			return err
+		}
+	}
+
+	for _, keyset := range manifest.keysets {
+		if err := a.applyKeyset(ctx, cluster, created, keyset); err != nil {
+			return err
+		}
+	}
+
+	if prune && manifest.cluster != nil && !created {
+		if err := a.pruneInstanceGroups(ctx, cluster, manifest.instanceGroups); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// applyClusterObject creates or updates the cluster, and returns the cluster that the other objects belong to.
+func (a *applier) applyClusterObject(ctx context.Context, current *kopsapi.Cluster, v *kopsapi.Cluster) (*kopsapi.Cluster, error) {
+	clusterName := v.ObjectMeta.Name
+
+	if current == nil {
+		target := v.DeepCopy()
+		target.ObjectMeta.ResourceVersion = ""
+		if err := a.report("Cluster", clusterName, applyActionCreated, nil, target); err != nil {
+			return nil, err
+		}
+		if a.dryRun {
+			return target, nil
+		}
+
+		cloud, err := cloudup.BuildCloud(target)
+		if err != nil {
+			return nil, err
+		}
+		if err := cloudup.PerformAssignments(target, cloud); err != nil {
+			return nil, fmt.Errorf("error populating configuration: %v", err)
+		}
+		created, err := a.clientset.CreateCluster(ctx, target)
+		if err != nil {
+			return nil, fmt.Errorf("error creating cluster: %v", err)
+		}
+		return created, nil
+	}
+
+	target := current.DeepCopy()
+	target.ObjectMeta.Labels = v.ObjectMeta.Labels
+	target.ObjectMeta.Annotations = v.ObjectMeta.Annotations
+	target.Spec = v.Spec
+	if target.Spec.ConfigBase == "" {
+		target.Spec.ConfigBase = current.Spec.ConfigBase
+	}
+
+	if apiequality.Semantic.DeepEqual(current.ObjectMeta, target.ObjectMeta) && apiequality.Semantic.DeepEqual(current.Spec, target.Spec) {
+		return current, a.report("Cluster", clusterName, applyActionUnchanged, current, target)
+	}
+	if err := a.report("Cluster", clusterName, applyActionConfigured, current, target); err != nil {
+		return nil, err
+	}
+	if a.dryRun {
+		return target, nil
+	}
+
+	cloud, err := cloudup.BuildCloud(target)
+	if err != nil {
+		return nil, err
+	}
+	status, err := cloud.FindClusterStatus(current)
+	if err != nil {
+		return nil, err
+	}
+	updated, err := a.clientset.UpdateCluster(ctx, target, status)
+	if err != nil {
+		if errors.IsConflict(err) {
+			return nil, fmt.Errorf("cluster %q was modified during the apply; please try again", clusterName)
+		}
+		return nil, fmt.Errorf("error updating cluster: %v", err)
+	}
+	return updated, nil
+}
+
+func (a *applier) applyInstanceGroup(ctx context.Context, cluster *kopsapi.Cluster, clusterCreated bool, v *kopsapi.InstanceGroup) error {
+	name := v.ObjectMeta.Name
+
+	var current *kopsapi.InstanceGroup
+	if !clusterCreated {
+		ig, err := a.clientset.InstanceGroupsFor(cluster).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			if !errors.IsNotFound(err) {
+				return fmt.Errorf("error reading InstanceGroup %q: %v", name, err)
+			}
+		} else {
+			current = ig
+		}
+	}
+
+	if current == nil {
+		target := v.DeepCopy()
+		target.ObjectMeta.ResourceVersion = ""
+		if err := a.report("InstanceGroup", name, applyActionCreated, nil, target); err != nil {
+			return err
+		}
+		if a.dryRun {
+			return nil
+		}
+		if _, err := a.clientset.InstanceGroupsFor(cluster).Create(ctx, target, metav1.CreateOptions{}); err != nil {
+			return fmt.Errorf("error creating InstanceGroup %q: %v", name, err)
+		}
+		return nil
+	}
+
+	target := current.DeepCopy()
+	target.ObjectMeta.Labels = v.ObjectMeta.Labels
+	target.ObjectMeta.Annotations = v.ObjectMeta.Annotations
+	target.Spec = v.Spec
+
+	if apiequality.Semantic.DeepEqual(current.ObjectMeta, target.ObjectMeta) && apiequality.Semantic.DeepEqual(current.Spec, target.Spec) {
+		return a.report("InstanceGroup", name, applyActionUnchanged, current, target)
+	}
+	if err := a.report("InstanceGroup", name, applyActionConfigured, current, target); err != nil {
+		return err
+	}
+	if a.dryRun {
+		return nil
+	}
+	if _, err := a.clientset.InstanceGroupsFor(cluster).Update(ctx, target, metav1.UpdateOptions{}); err != nil {
+		if errors.IsConflict(err) {
+			return fmt.Errorf("InstanceGroup %q was modified during the apply; please try again", name)
+		}
+		return fmt.Errorf("error updating InstanceGroup %q: %v", name, err)
+	}
+	return nil
+}
+
+// applySSHCredential adds the SSH public key, unless it has been added already. SSH public keys are
+// identified by their fingerprint, so an SSHCredential is never configured.
+func (a *applier) applySSHCredential(ctx context.Context, cluster *kopsapi.Cluster, clusterCreated bool, v *kopsapi.SSHCredential) error {
+	name := v.ObjectMeta.Name
+
+	if !clusterCreated {
+		sshCredentialStore, err := a.clientset.SSHCredentialStore(cluster)
+		if err != nil {
+			return err
+		}
+		existing, err := sshCredentialStore.FindSSHPublicKeys()
+		if err != nil {
+			return fmt.Errorf("error reading SSH public keys: %v", err)
+		}
+		for _, sshCredential := range existing {
+			if strings.TrimSpace(sshCredential.Spec.PublicKey) == strings.TrimSpace(v.Spec.PublicKey) {
+				return a.report("SSHCredential", name, applyActionUnchanged, nil, nil)
+			}
+		}
+	}
+
+	target := v.DeepCopy()
+	target.ObjectMeta.ResourceVersion = ""
+	if err := a.report("SSHCredential", name, applyActionCreated, nil, target); err != nil {
+		return err
+	}
+	if a.dryRun {
+		return nil
+	}
+	sshCredentialStore, err := a.clientset.SSHCredentialStore(cluster)
+	if err != nil {
+		return err
+	}
+	if err := sshCredentialStore.AddSSHPublicKey(ctx, []byte(v.Spec.PublicKey)); err != nil {
+		return fmt.Errorf("error adding SSHCredential %q: %v", name, err)
+	}
+	return nil
+}
+
+func (a *applier) applyKeyset(ctx context.Context, cluster *kopsapi.Cluster, clusterCreated bool, v *kopsapi.Keyset) error {
+	name := v.ObjectMeta.Name
+
+	keyset, err := fi.ParseKeyset(v)
+	if err != nil {
+		return err
+	}
+	target, err := keyset.ToAPIObject(name)
+	if err != nil {
+		return err
+	}
+
+	var current *kopsapi.Keyset
+	if !clusterCreated {
+		keyStore, err := a.clientset.KeyStore(cluster)
+		if err != nil {
+			return err
+		}
+		existing, err := keyStore.FindKeyset(ctx, name)
+		if err != nil {
+			return fmt.Errorf("error reading keyset %q: %v", name, err)
+		}
+		if existing != nil {
+			current, err = existing.ToAPIObject(name)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	action := applyActionCreated
+	if current != nil {
+		if apiequality.Semantic.DeepEqual(current.Spec, target.Spec) {
+			return a.report("Keyset", name, applyActionUnchanged, nil, nil)
+		}
+		action = applyActionConfigured
+	}
+	// Only the public material of the keys is shown
+	if err := a.report("Keyset", name, action, withoutPrivateMaterial(current), withoutPrivateMaterial(target)); err != nil {
+		return err
+	}
+	if a.dryRun {
+		return nil
+	}
+	keyStore, err := a.clientset.KeyStore(cluster)
+	if err != nil {
+		return err
+	}
+	if err := keyStore.StoreKeyset(ctx, name, keyset); err != nil {
+		return fmt.Errorf("error storing keyset %q: %v", name, err)
+	}
+	return nil
+}
+
+// withoutPrivateMaterial returns a copy of the keyset without the private keys, or nil if keyset is nil.
+func withoutPrivateMaterial(keyset *kopsapi.Keyset) runtime.Object {
+	if keyset == nil {
+		return nil
+	}
+	keyset = keyset.DeepCopy()
+	for i := range keyset.Spec.Keys {
+		keyset.Spec.Keys[i].PrivateMaterial = nil
+	}
+	return keyset
+}
+
+// pruneInstanceGroups deletes the instance groups of the cluster that are not in keep from the state store,
+// recording them so that the next update of the cluster deletes their cloud resources.
+func (a *applier) pruneInstanceGroups(ctx context.Context, cluster *kopsapi.Cluster, keep []*kopsapi.InstanceGroup) error {
+	list, err := a.clientset.InstanceGroupsFor(cluster).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("error listing InstanceGroups: %v", err)
+	}
+
+	names := make(map[string]bool)
+	remainingControlPlane := false
+	for _, ig := range keep {
+		names[ig.ObjectMeta.Name] = true
+		if ig.Spec.Role == kopsapi.InstanceGroupRoleControlPlane {
+			remainingControlPlane = true
+		}
+	}
+
+	var pruned []*kopsapi.InstanceGroup
+	for i := range list.Items {
+		ig := &list.Items[i]
+		if !names[ig.ObjectMeta.Name] {
+			pruned = append(pruned, ig)
+		}
+	}
+	if len(pruned) == 0 {
+		return nil
+	}
+
+	for _, ig := range pruned {
+		if ig.Spec.Role == kopsapi.InstanceGroupRoleControlPlane && !remainingControlPlane {
+			return fmt.Errorf("cannot prune InstanceGroup %q: the files do not contain a control plane instance group", ig.ObjectMeta.Name)
+		}
+	}
+
+	sort.Slice(pruned, func(i, j int) bool {
+		return pruned[i].ObjectMeta.Name < pruned[j].ObjectMeta.Name
+	})
+	for _, ig := range pruned {
+		if err := a.report("InstanceGroup", ig.ObjectMeta.Name, applyActionPruned, ig, nil); err != nil {
+			return err
+		}
+		a.pruned = true
+		if a.dryRun {
+			continue
+		}
+
+		if err := cloudup.RecordPrunedInstanceGroup(ctx, cluster, ig); err != nil {
+			return err
+		}
+		if err := a.clientset.InstanceGroupsFor(cluster).Delete(ctx, ig.ObjectMeta.Name, metav1.DeleteOptions{}); err != nil {
+			return fmt.Errorf("error deleting InstanceGroup %q: %v", ig.ObjectMeta.Name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,246 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kopsapi "k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/pkg/client/simple/vfsclientset"
+	"k8s.io/kops/pkg/testutils/testcontext"
+	"k8s.io/kops/upup/pkg/fi/cloudup"
+	"k8s.io/kops/util/pkg/vfs"
+)
+
+func TestExpandManifestFilenames(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"cluster.yaml", "nodes.yml", "keyset.json", "README.md"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0o644); err != nil {
+			t.Fatalf("error writing file: %v", err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(dir, "nested.yaml"), 0o755); err != nil {
+		t.Fatalf("error creating directory: %v", err)
+	}
+
+	actual, err := expandManifestFilenames([]string{"-", dir, "s3://bucket/cluster.yaml"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []string{
+		"-",
+		filepath.Join(dir, "cluster.yaml"),
+		filepath.Join(dir, "keyset.json"),
+		filepath.Join(dir, "nodes.yml"),
+		"s3://bucket/cluster.yaml",
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %v, got %v", expected, actual)
+	}
+}
+
+func applyTestObjects(t *testing.T, manifests ...string) []*manifestObject {
+	path := filepath.Join(t.TempDir(), "manifest.yaml")
+	if err := os.WriteFile(path, []byte(strings.Join(manifests, "\n---\n")), 0o644); err != nil {
+		t.Fatalf("error writing manifest: %v", err)
+	}
+	objects, err := readManifestObjects([]string{path})
+	if err != nil {
+		t.Fatalf("error reading manifest: %v", err)
+	}
+	return objects
+}
+
+const applyTestSSHCredential = `apiVersion: kops.k8s.io/v1alpha2
+kind: SSHCredential
+metadata:
+  name: admin
+spec:
+  publicKey: ssh-rsa AAAAB3NzaC1yc2EAAAADAQABAAABAQ== admin
+`
+
+func applyTestKeyset(keysetType string) string {
+	return `apiVersion: kops.k8s.io/v1alpha2
+kind: Keyset
+metadata:
+  name: kubernetes-ca
+spec:
+  type: ` + keysetType + `
+  primaryID: "1"
+  keys:
+  - id: "1"
+`
+}
+
+func TestGroupApplyObjects(t *testing.T) {
+	otherCluster := strings.ReplaceAll(diffTestCluster, "minimal.example.com", "other.example.com")
+
+	t.Run("single cluster", func(t *testing.T) {
+		objects := applyTestObjects(t,
+			diffTestInstanceGroup("nodes-b", ""),
+			diffTestCluster,
+			diffTestInstanceGroup("nodes-a", "minimal.example.com"),
+			applyTestSSHCredential,
+			applyTestKeyset("Keypair"),
+		)
+		manifests, err := groupApplyObjects(objects)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(manifests) != 1 {
+			t.Fatalf("expected a single cluster, got %d", len(manifests))
+		}
+		manifest := manifests["minimal.example.com"]
+		if manifest == nil || manifest.cluster == nil {
+			t.Fatalf("expected the Cluster of minimal.example.com, got %v", manifests)
+		}
+		var names []string
+		for _, ig := range manifest.instanceGroups {
+			names = append(names, ig.ObjectMeta.Name)
+			if ig.ObjectMeta.Labels[kopsapi.LabelClusterName] != "minimal.example.com" {
+				t.Errorf("expected InstanceGroup %q to be labelled with its cluster, got %v", ig.ObjectMeta.Name, ig.ObjectMeta.Labels)
+			}
+		}
+		if !reflect.DeepEqual(names, []string{"nodes-a", "nodes-b"}) {
+			t.Errorf("expected instance groups sorted by name, got %v", names)
+		}
+		if len(manifest.sshCredentials) != 1 || len(manifest.keysets) != 1 {
+			t.Errorf("expected an SSHCredential and a Keyset, got %d and %d", len(manifest.sshCredentials), len(manifest.keysets))
+		}
+	})
+
+	t.Run("objects of an existing cluster", func(t *testing.T) {
+		objects := applyTestObjects(t, diffTestInstanceGroup("nodes", "minimal.example.com"))
+		manifests, err := groupApplyObjects(objects)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		manifest := manifests["minimal.example.com"]
+		if manifest == nil || manifest.cluster != nil || len(manifest.instanceGroups) != 1 {
+			t.Errorf("expected only an InstanceGroup of minimal.example.com, got %v", manifests)
+		}
+	})
+
+	grid := []struct {
+		name        string
+		manifests   []string
+		expectedErr string
+	}{
+		{
+			name:        "label required with several clusters",
+			manifests:   []string{diffTestCluster, otherCluster, diffTestInstanceGroup("nodes", "")},
+			expectedErr: "must specify \"kops.k8s.io/cluster\" label with cluster name for InstanceGroup \"nodes\"",
+		},
+		{
+			name:        "label required without a cluster",
+			manifests:   []string{applyTestSSHCredential},
+			expectedErr: "must specify \"kops.k8s.io/cluster\" label with cluster name for SSHCredential \"admin\"",
+		},
+		{
+			name:        "duplicate instance group",
+			manifests:   []string{diffTestCluster, diffTestInstanceGroup("nodes", ""), diffTestInstanceGroup("nodes", "minimal.example.com")},
+			expectedErr: "files contain InstanceGroup/minimal.example.com/nodes more than once",
+		},
+		{
+			name:        "secret keyset",
+			manifests:   []string{diffTestCluster, applyTestKeyset("Secret")},
+			expectedErr: "keyset \"kubernetes-ca\"",
+		},
+	}
+	for _, g := range grid {
+		t.Run(g.name, func(t *testing.T) {
+			_, err := groupApplyObjects(applyTestObjects(t, g.manifests...))
+			if err == nil || !strings.Contains(err.Error(), g.expectedErr) {
+				t.Fatalf("expected error containing %q, got %v", g.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestPruneInstanceGroups(t *testing.T) {
+	ctx := testcontext.ForTest(t)
+	vfs.Context.ResetMemfsContext(true)
+
+	basePath, err := vfs.Context.BuildVfsPath("memfs://tests")
+	if err != nil {
+		t.Fatalf("error building state store path: %v", err)
+	}
+	clientset := vfsclientset.NewVFSClientset(basePath)
+	cluster := &kopsapi.Cluster{}
+	cluster.ObjectMeta.Name = "minimal.example.com"
+	cluster.Spec.ConfigBase = "memfs://tests/minimal.example.com"
+
+	var keep []*kopsapi.InstanceGroup
+	for _, ig := range []struct {
+		name string
+		role kopsapi.InstanceGroupRole
+	}{
+		{"control-plane", kopsapi.InstanceGroupRoleControlPlane},
+		{"nodes-a", kopsapi.InstanceGroupRoleNode},
+		{"nodes-b", kopsapi.InstanceGroupRoleNode},
+	} {
+		group := &kopsapi.InstanceGroup{}
+		group.ObjectMeta.Name = ig.name
+		group.Spec.Role = ig.role
+		group.Spec.Subnets = []string{"us-test-1a"}
+		if _, err := clientset.InstanceGroupsFor(cluster).Create(ctx, group, metav1.CreateOptions{}); err != nil {
+			t.Fatalf("error creating InstanceGroup %q: %v", ig.name, err)
+		}
+		if ig.name != "nodes-b" {
+			keep = append(keep, group)
+		}
+	}
+
+	var out bytes.Buffer
+	a := &applier{
+		clientset: clientset,
+		out:       &out,
+	}
+	if err := a.pruneInstanceGroups(ctx, cluster, keep); err != nil {
+		t.Fatalf("error pruning InstanceGroups: %v", err)
+	}
+
+	if out.String() != "instancegroup/nodes-b pruned\n" {
+		t.Errorf("unexpected output %q", out.String())
+	}
+	for _, name := range []string{"control-plane", "nodes-a", "nodes-b"} {
+		ig, err := clientset.InstanceGroupsFor(cluster).Get(ctx, name, metav1.GetOptions{})
+		if name == "nodes-b" {
+			if !errors.IsNotFound(err) {
+				t.Errorf("expected InstanceGroup %q to be deleted, got %v, %v", name, ig, err)
+			}
+		} else if err != nil {
+			t.Errorf("error getting InstanceGroup %q: %v", name, err)
+		}
+	}
+
+	// The cloud resources are left to the next update of the cluster
+	pruned, err := cloudup.ReadPrunedInstanceGroups(ctx, cluster)
+	if err != nil {
+		t.Fatalf("error reading pruned InstanceGroups: %v", err)
+	}
+	if len(pruned) != 1 || pruned[0].ObjectMeta.Name != "nodes-b" {
+		t.Errorf("expected InstanceGroup \"nodes-b\" to be recorded as pruned, got %v", pruned)
+	}
+}
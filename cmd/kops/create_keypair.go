@@ -81,6 +81,7 @@ type CreateKeypairOptions struct {
 	PrivateKeyPath string
 	CertPath       string
 	Primary        bool
+	KeyAlgorithm   string
 }
 
 func rotatableKeysetFilter(name string, _ *fi.Keyset) bool {
@@ -125,6 +126,10 @@ func NewCmdCreateKeypair(f *util.Factory, out io.Writer) *cobra.Command {
 				}
 			}
 
+			if options.KeyAlgorithm != "" && (options.PrivateKeyPath != "" || options.CertPath != "") {
+				return fmt.Errorf("cannot specify --key-algorithm with --key or --cert")
+			}
+
 			return nil
 		},
 		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
@@ -138,6 +143,8 @@ func NewCmdCreateKeypair(f *util.Factory, out io.Writer) *cobra.Command {
 	cmd.Flags().StringVar(&options.CertPath, "cert", options.CertPath, "Path to CA certificate")
 	cmd.Flags().StringVar(&options.PrivateKeyPath, "key", options.PrivateKeyPath, "Path to CA private key")
 	cmd.Flags().BoolVar(&options.Primary, "primary", options.Primary, "Make the keypair the one used to issue certificates")
+	cmd.Flags().StringVar(&options.KeyAlgorithm, "key-algorithm", options.KeyAlgorithm, "Algorithm of the generated private key: RSA, ECDSA-P256, ECDSA-P384 or Ed25519. Defaults to the algorithm configured for the keyset in the cluster spec")
+	cmd.RegisterFlagCompletionFunc("key-algorithm", completeKeyAlgorithm)
 
 	return cmd
 }
@@ -164,7 +171,8 @@ func RunCreateKeypair(ctx context.Context, f *util.Factory, out io.Writer, optio
 	}
 
 	if options.Keyset != "all" {
-		return createKeypair(ctx, out, options, options.Keyset, keyStore)
+		_, err := createKeypair(ctx, out, options, cluster, options.Keyset, keyStore)
+		return err
 	}
 
 	keysets, err := keyStore.ListKeysets()
@@ -174,7 +182,7 @@ func RunCreateKeypair(ctx context.Context, f *util.Factory, out io.Writer, optio
 
 	for name := range keysets {
 		if rotatableKeysetFilter(name, nil) {
-			if err := createKeypair(ctx, out, options, name, keyStore); err != nil {
+			if _, err := createKeypair(ctx, out, options, cluster, name, keyStore); err != nil {
 				return fmt.Errorf("creating keypair for %s: %v", name, err)
 			}
 		}
@@ -183,28 +191,38 @@ func RunCreateKeypair(ctx context.Context, f *util.Factory, out io.Writer, optio
 	return nil
 }
 
-func createKeypair(ctx context.Context, out io.Writer, options *CreateKeypairOptions, name string, keyStore fi.CAStore) error {
+func createKeypair(ctx context.Context, out io.Writer, options *CreateKeypairOptions, cluster *kopsapi.Cluster, name string, keyStore fi.CAStore) (*fi.KeysetItem, error) {
 	var err error
 	var privateKey *pki.PrivateKey
 	if options.PrivateKeyPath != "" {
 		options.PrivateKeyPath = utils.ExpandPath(options.PrivateKeyPath)
 		privateKeyBytes, err := os.ReadFile(options.PrivateKeyPath)
 		if err != nil {
-			return fmt.Errorf("error reading user provided private key %q: %v", options.PrivateKeyPath, err)
+			return nil, fmt.Errorf("error reading user provided private key %q: %v", options.PrivateKeyPath, err)
 		}
 
 		privateKey, err = pki.ParsePEMPrivateKey(privateKeyBytes)
 		if err != nil {
-			return fmt.Errorf("error loading private key %q: %v", privateKeyBytes, err)
+			return nil, fmt.Errorf("error loading private key %q: %v", privateKeyBytes, err)
 		}
 	}
 
 	var cert *pki.Certificate
 	if options.CertPath == "" {
 		if privateKey == nil {
-			privateKey, err = pki.GeneratePrivateKey()
+			keyAlgorithm := options.KeyAlgorithm
+			if keyAlgorithm == "" {
+				keyAlgorithm = cluster.Spec.PKI.KeyAlgorithmForKeyset(name)
+			}
+			if name == "service-account" && keyAlgorithm == string(pki.KeyAlgorithmEd25519) {
+				return nil, fmt.Errorf("Ed25519 keys cannot be used to sign service account tokens")
+			}
+			if kopsapi.IsEtcdCAKeyset(name) && keyAlgorithm == string(pki.KeyAlgorithmEd25519) {
+				return nil, fmt.Errorf("Ed25519 keys cannot be used for the CAs of etcd-manager")
+			}
+			privateKey, err = pki.GeneratePrivateKeyWithAlgorithm(pki.KeyAlgorithm(keyAlgorithm))
 			if err != nil {
-				return fmt.Errorf("error generating private key: %v", err)
+				return nil, fmt.Errorf("error generating private key: %v", err)
 			}
 		}
 
@@ -217,18 +235,18 @@ func createKeypair(ctx context.Context, out io.Writer, options *CreateKeypairOpt
 		}
 		cert, _, _, err = pki.IssueCert(ctx, &req, nil)
 		if err != nil {
-			return fmt.Errorf("error issuing certificate: %v", err)
+			return nil, fmt.Errorf("error issuing certificate: %v", err)
 		}
 	} else {
 		options.CertPath = utils.ExpandPath(options.CertPath)
 		certBytes, err := os.ReadFile(options.CertPath)
 		if err != nil {
-			return fmt.Errorf("error reading user provided cert %q: %v", options.CertPath, err)
+			return nil, fmt.Errorf("error reading user provided cert %q: %v", options.CertPath, err)
 		}
 
 		cert, err = pki.ParsePEMCertificate(certBytes)
 		if err != nil {
-			return fmt.Errorf("error loading certificate %q: %v", options.CertPath, err)
+			return nil, fmt.Errorf("error loading certificate %q: %v", options.CertPath, err)
 		}
 	}
 
@@ -237,24 +255,24 @@ func createKeypair(ctx context.Context, out io.Writer, options *CreateKeypairOpt
 	if os.IsNotExist(err) || (err == nil && keyset == nil) {
 		if options.Primary {
 			if keyset, err = fi.NewKeyset(cert, privateKey); err != nil {
-				return err
+				return nil, err
 			}
 		} else {
-			return fmt.Errorf("the first keypair added to a keyset must be primary")
+			return nil, fmt.Errorf("the first keypair added to a keyset must be primary")
 		}
 		item = keyset.Primary
 	} else if err != nil {
-		return fmt.Errorf("reading existing keyset: %v", err)
+		return nil, fmt.Errorf("reading existing keyset: %v", err)
 	} else {
 		item, err = keyset.AddItem(cert, privateKey, options.Primary)
 	}
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	err = keyStore.StoreKeyset(ctx, name, keyset)
 	if err != nil {
-		return fmt.Errorf("error storing user provided keys %q %q: %v", options.CertPath, options.PrivateKeyPath, err)
+		return nil, fmt.Errorf("error storing user provided keys %q %q: %v", options.CertPath, options.PrivateKeyPath, err)
 	}
 
 	if options.CertPath != "" {
@@ -264,7 +282,7 @@ func createKeypair(ctx context.Context, out io.Writer, options *CreateKeypairOpt
 		fmt.Fprintf(out, "using user provided private key: %v\n", options.PrivateKeyPath)
 	}
 	fmt.Fprintf(out, "Created %s %s\n", name, item.Id)
-	return nil
+	return item, nil
 }
 
 func completeKeyset(ctx context.Context, cluster *kopsapi.Cluster, clientSet simple.Clientset, args []string, filter func(name string, keyset *fi.Keyset) bool) (keyset *fi.Keyset, keyStore fi.CAStore, completions []string, directive cobra.ShellCompDirective) {
@@ -335,3 +353,11 @@ func completeCreateKeypair(ctx context.Context, f commandutils.Factory, options
 	}
 	return flags, cobra.ShellCompDirectiveNoFileComp
 }
+
+func completeKeyAlgorithm(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	var algorithms []string
+	for _, algorithm := range pki.SupportedKeyAlgorithms {
+		algorithms = append(algorithms, string(algorithm))
+	}
+	return algorithms, cobra.ShellCompDirectiveNoFileComp
+}
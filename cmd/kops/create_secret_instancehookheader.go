@@ -0,0 +1,147 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	utilvalidation "k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/kops/pkg/commands/commandutils"
+	"k8s.io/kops/pkg/instancegroups"
+
+	"k8s.io/kops/cmd/kops/util"
+	"k8s.io/kops/upup/pkg/fi"
+	"k8s.io/kubectl/pkg/util/i18n"
+	"k8s.io/kubectl/pkg/util/templates"
+)
+
+var (
+	createSecretInstanceHookHeaderLong = templates.LongDesc(i18n.T(`
+	Create a new secret holding the value of a header of instance hook webhooks,
+	and store it in the state store.
+	Used by the headerSecrets of the webhooks of instanceHooks in the rollingUpdate
+	of the cluster or an instance group, so that the value is not stored in the spec.`))
+
+	createSecretInstanceHookHeaderExample = templates.Examples(i18n.T(`
+	# Create a secret named hooks-token holding the value of a header.
+	kops create secret instancehookheader --secret-name hooks-token -f /path/to/header \
+		--name k8s-cluster.example.com --state s3://my-state-store
+
+	# Create the secret via stdin.
+	echo "Bearer my-token" | kops create secret instancehookheader --secret-name hooks-token -f - \
+		--name k8s-cluster.example.com --state s3://my-state-store
+
+	# Replace an existing secret.
+	kops create secret instancehookheader --secret-name hooks-token -f /path/to/header --force \
+		--name k8s-cluster.example.com --state s3://my-state-store
+	`))
+
+	createSecretInstanceHookHeaderShort = i18n.T(`Create a header of instance hook webhooks.`)
+)
+
+type CreateSecretInstanceHookHeaderOptions struct {
+	ClusterName    string
+	SecretName     string
+	HeaderFilePath string
+	Force          bool
+}
+
+func NewCmdCreateSecretInstanceHookHeader(f *util.Factory, out io.Writer) *cobra.Command {
+	options := &CreateSecretInstanceHookHeaderOptions{}
+
+	cmd := &cobra.Command{
+		Use:               "instancehookheader [CLUSTER]",
+		Short:             createSecretInstanceHookHeaderShort,
+		Long:              createSecretInstanceHookHeaderLong,
+		Example:           createSecretInstanceHookHeaderExample,
+		Args:              rootCommand.clusterNameArgs(&options.ClusterName),
+		ValidArgsFunction: commandutils.CompleteClusterName(f, true, false),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return RunCreateSecretInstanceHookHeader(cmd.Context(), f, out, options)
+		},
+	}
+
+	cmd.Flags().StringVar(&options.SecretName, "secret-name", "", "Name of the secret, as referenced by headerSecrets")
+	cmd.MarkFlagRequired("secret-name")
+	cmd.Flags().StringVarP(&options.HeaderFilePath, "filename", "f", "", "Path to the file holding the value of the header")
+	cmd.MarkFlagRequired("filename")
+	cmd.Flags().BoolVar(&options.Force, "force", options.Force, "Force replace the secret if it already exists")
+
+	return cmd
+}
+
+func RunCreateSecretInstanceHookHeader(ctx context.Context, f commandutils.Factory, out io.Writer, options *CreateSecretInstanceHookHeaderOptions) error {
+	if errs := utilvalidation.IsDNS1123Subdomain(options.SecretName); len(errs) != 0 {
+		return fmt.Errorf("invalid --secret-name %q: %s", options.SecretName, strings.Join(errs, "; "))
+	}
+
+	var data []byte
+	var err error
+	if options.HeaderFilePath == "-" {
+		data, err = ConsumeStdin()
+		if err != nil {
+			return fmt.Errorf("reading header from stdin: %v", err)
+		}
+	} else {
+		data, err = os.ReadFile(options.HeaderFilePath)
+		if err != nil {
+			return fmt.Errorf("reading header file %v: %v", options.HeaderFilePath, err)
+		}
+	}
+	if len(strings.TrimSpace(string(data))) == 0 {
+		return fmt.Errorf("the value of the header is empty")
+	}
+
+	cluster, err := GetCluster(ctx, f, options.ClusterName)
+	if err != nil {
+		return err
+	}
+
+	clientset, err := f.KopsClient()
+	if err != nil {
+		return err
+	}
+
+	secretStore, err := clientset.SecretStore(cluster)
+	if err != nil {
+		return err
+	}
+
+	secret := &fi.Secret{Data: data}
+	name := instancegroups.HookHeaderSecretName(options.SecretName)
+	if !options.Force {
+		_, created, err := secretStore.GetOrCreateSecret(ctx, name, secret)
+		if err != nil {
+			return fmt.Errorf("adding %s secret: %v", options.SecretName, err)
+		}
+		if !created {
+			return fmt.Errorf("failed to create the %s secret as it already exists. Pass the `--force` flag to replace an existing secret", options.SecretName)
+		}
+	} else {
+		_, err := secretStore.ReplaceSecret(name, secret)
+		if err != nil {
+			return fmt.Errorf("updating %s secret: %v", options.SecretName, err)
+		}
+	}
+
+	return nil
+}
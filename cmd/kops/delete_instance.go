@@ -30,6 +30,7 @@ import (
 	"k8s.io/cli-runtime/pkg/genericclioptions"
 	"k8s.io/client-go/kubernetes"
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
+	"k8s.io/client-go/rest"
 	"k8s.io/kops/cmd/kops/util"
 	kopsapi "k8s.io/kops/pkg/apis/kops"
 	"k8s.io/kops/pkg/cloudinstances"
@@ -70,6 +71,9 @@ type DeleteInstanceOptions struct {
 	InstanceID string
 
 	Surge bool
+
+	// AllowSpecExecHooks runs the instance hooks of the cluster and instance group specs that run a command.
+	AllowSpecExecHooks bool
 }
 
 func (o *DeleteInstanceOptions) initDefaults() {
@@ -139,6 +143,7 @@ func NewCmdDeleteInstance(f *util.Factory, out io.Writer) *cobra.Command {
 
 	cmd.Flags().BoolVar(&options.CloudOnly, "cloudonly", options.CloudOnly, "Perform deletion update without confirming progress with Kubernetes")
 	cmd.Flags().BoolVar(&options.Surge, "surge", options.Surge, "Surge by detaching the node from the ASG before deletion")
+	cmd.Flags().BoolVar(&options.AllowSpecExecHooks, "allow-spec-exec-hooks", options.AllowSpecExecHooks, "Run the instance hooks of the cluster and instance group specs that run a command on this machine")
 
 	cmd.Flags().DurationVar(&options.ValidationTimeout, "validation-timeout", options.ValidationTimeout, "Maximum time to wait for a cluster to validate")
 	cmd.Flags().Int32Var(&options.ValidateCount, "validate-count", options.ValidateCount, "Number of times that a cluster needs to be validated after single node update")
@@ -165,9 +170,9 @@ func RunDeleteInstance(ctx context.Context, f *util.Factory, out io.Writer, opti
 
 	var nodes []v1.Node
 	var k8sClient kubernetes.Interface
-	var host string
+	var restConfig *rest.Config
 	if !options.CloudOnly {
-		k8sClient, host, nodes, err = getNodes(ctx, cluster, true)
+		k8sClient, restConfig, nodes, err = getNodes(ctx, cluster, true)
 		if err != nil {
 			return err
 		}
@@ -237,11 +242,16 @@ func RunDeleteInstance(ctx context.Context, f *util.Factory, out io.Writer, opti
 		// TODO should we expose this to the UI?
 		ValidateTickDuration:    30 * time.Second,
 		ValidateSuccessDuration: 10 * time.Second,
+		AllowSpecExecHooks:      options.AllowSpecExecHooks,
 	}
 
 	var clusterValidator validation.ClusterValidator
 	if !options.CloudOnly {
-		clusterValidator, err = validation.NewClusterValidator(cluster, cloud, list, host, k8sClient)
+		keyStore, err := clientSet.KeyStore(cluster)
+		if err != nil {
+			return err
+		}
+		clusterValidator, err = validation.NewClusterValidator(cluster, cloud, list, restConfig, k8sClient, keyStore, false)
 		if err != nil {
 			return fmt.Errorf("cannot create cluster validator: %v", err)
 		}
@@ -251,7 +261,7 @@ func RunDeleteInstance(ctx context.Context, f *util.Factory, out io.Writer, opti
 	return d.UpdateSingleInstance(cloudMember, options.Surge)
 }
 
-func getNodes(ctx context.Context, cluster *kopsapi.Cluster, verbose bool) (kubernetes.Interface, string, []v1.Node, error) {
+func getNodes(ctx context.Context, cluster *kopsapi.Cluster, verbose bool) (kubernetes.Interface, *rest.Config, []v1.Node, error) {
 	var nodes []v1.Node
 	var k8sClient kubernetes.Interface
 
@@ -261,12 +271,12 @@ func getNodes(ctx context.Context, cluster *kopsapi.Cluster, verbose bool) (kube
 
 	config, err := clientGetter.ToRESTConfig()
 	if err != nil {
-		return nil, "", nil, fmt.Errorf("cannot load kubecfg settings for %q: %v", contextName, err)
+		return nil, nil, nil, fmt.Errorf("cannot load kubecfg settings for %q: %v", contextName, err)
 	}
 
 	k8sClient, err = kubernetes.NewForConfig(config)
 	if err != nil {
-		return nil, "", nil, fmt.Errorf("cannot build kube client for %q: %v", contextName, err)
+		return nil, nil, nil, fmt.Errorf("cannot build kube client for %q: %v", contextName, err)
 	}
 
 	nodeList, err := k8sClient.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
@@ -275,13 +285,13 @@ func getNodes(ctx context.Context, cluster *kopsapi.Cluster, verbose bool) (kube
 			fmt.Fprintf(os.Stderr, "Unable to reach the kubernetes API.\n")
 			fmt.Fprintf(os.Stderr, "Use --cloudonly to do a deletion without confirming progress with the k8s API\n\n")
 		}
-		return nil, "", nil, fmt.Errorf("listing nodes in cluster: %v", err)
+		return nil, nil, nil, fmt.Errorf("listing nodes in cluster: %v", err)
 	}
 
 	if nodeList != nil {
 		nodes = nodeList.Items
 	}
-	return k8sClient, config.Host, nodes, nil
+	return k8sClient, config, nodes, nil
 }
 
 func deleteNodeMatch(cloudMember *cloudinstances.CloudInstance, options *DeleteInstanceOptions) bool {
@@ -0,0 +1,102 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/spf13/cobra"
+	"k8s.io/kops/cmd/kops/util"
+	"k8s.io/kops/pkg/clusterlock"
+	"k8s.io/kops/pkg/commands/commandutils"
+	"k8s.io/kubectl/pkg/util/i18n"
+	"k8s.io/kubectl/pkg/util/templates"
+)
+
+var (
+	deleteLockLong = templates.LongDesc(i18n.T(`
+	Delete the lock on a cluster, for example if a kops update or rolling-update was interrupted
+	and its lock has not yet expired. An expired lock can be deleted without --force.
+
+	Deleting a lock that is still held allows another operation to run concurrently with its holder.`))
+
+	deleteLockExample = templates.Examples(i18n.T(`
+	# Delete a stuck lock on a cluster
+	kops delete lock k8s-cluster.example.com --force
+	`))
+
+	deleteLockShort = i18n.T(`Delete the lock on a cluster.`)
+)
+
+type DeleteLockOptions struct {
+	ClusterName string
+	Force       bool
+}
+
+func NewCmdDeleteLock(f *util.Factory, out io.Writer) *cobra.Command {
+	options := &DeleteLockOptions{}
+
+	cmd := &cobra.Command{
+		Use:               "lock [CLUSTER]",
+		Short:             deleteLockShort,
+		Long:              deleteLockLong,
+		Example:           deleteLockExample,
+		Args:              rootCommand.clusterNameArgs(&options.ClusterName),
+		ValidArgsFunction: commandutils.CompleteClusterName(f, true, false),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return RunDeleteLock(cmd.Context(), f, out, options)
+		},
+	}
+
+	cmd.Flags().BoolVar(&options.Force, "force", options.Force, "Delete the lock even if it has not expired")
+
+	return cmd
+}
+
+func RunDeleteLock(ctx context.Context, f *util.Factory, out io.Writer, options *DeleteLockOptions) error {
+	cluster, err := GetCluster(ctx, f, options.ClusterName)
+	if err != nil {
+		return err
+	}
+
+	lockPath, err := clusterlock.LockPath(cluster)
+	if err != nil {
+		return err
+	}
+
+	lease, err := clusterlock.Read(ctx, lockPath)
+	if err != nil {
+		return err
+	}
+	if lease == nil || lease.Holder == "" {
+		fmt.Fprintf(out, "No lock held on cluster %q\n", cluster.ObjectMeta.Name)
+		return nil
+	}
+
+	if lease.IsHeld(time.Now()) && !options.Force {
+		return fmt.Errorf("lock is held by %s (%s) until %s; use --force to delete it anyway", lease.Holder, lease.Operation, lease.ExpireTime.Format(time.RFC3339))
+	}
+
+	if err := clusterlock.Delete(ctx, lockPath); err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "Deleted lock held by %s (%s)\n", lease.Holder, lease.Operation)
+	return nil
+}
@@ -0,0 +1,380 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/spf13/cobra"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/kops/cmd/kops/util"
+	kopsapi "k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/pkg/assets"
+	"k8s.io/kops/pkg/client/simple"
+	"k8s.io/kops/pkg/diff"
+	"k8s.io/kops/upup/pkg/fi"
+	"k8s.io/kops/upup/pkg/fi/cloudup"
+	"k8s.io/kops/upup/pkg/fi/fitasks"
+	"k8s.io/kubectl/pkg/util/i18n"
+	"k8s.io/kubectl/pkg/util/templates"
+)
+
+var (
+	diffClusterLong = templates.LongDesc(i18n.T(`
+	Show the changes that replacing a cluster and its instance groups with the objects in a
+	manifest would make to the state store. The manifest holds a Cluster and any number of
+	InstanceGroups, e.g. as written by kops get all -o yaml.
+
+	Objects are compared after they have been completed with their default values, so a change
+	that only sets a field to its default value is not shown. Instance groups that are in the
+	state store but not in the manifest are not changed.
+
+	With --full, the changes to the completed cluster spec and to the nodeup configuration of
+	each instance group are shown too.`))
+
+	diffClusterExample = templates.Examples(i18n.T(`
+	# Show the changes that a manifest would make to a cluster
+	kops diff cluster -f new-cluster.yaml
+
+	# Also show the changes to the completed cluster spec and nodeup configuration
+	kops diff cluster -f new-cluster.yaml --full`))
+
+	diffClusterShort = i18n.T(`Show the differences between a cluster manifest and the state store.`)
+)
+
+type DiffClusterOptions struct {
+	// Filenames is a list of files containing the Cluster and InstanceGroups to compare.
+	Filenames []string
+	// Full also compares the completed cluster spec and the nodeup configuration of the instance groups.
+	Full bool
+}
+
+func NewCmdDiffCluster(f *util.Factory, out io.Writer) *cobra.Command {
+	options := &DiffClusterOptions{}
+
+	cmd := &cobra.Command{
+		Use:               "cluster {-f FILENAME}...",
+		Short:             diffClusterShort,
+		Long:              diffClusterLong,
+		Example:           diffClusterExample,
+		Args:              cobra.NoArgs,
+		ValidArgsFunction: cobra.NoFileCompletions,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return RunDiffCluster(cmd.Context(), f, out, options)
+		},
+	}
+
+	cmd.Flags().StringSliceVarP(&options.Filenames, "filename", "f", options.Filenames, "A list of one or more files or directories separated by a comma.")
+	cmd.MarkFlagRequired("filename")
+	cmd.Flags().BoolVar(&options.Full, "full", options.Full, "Also show the changes to the completed cluster spec and to the nodeup configuration of each instance group")
+
+	return cmd
+}
+
+// clusterManifest is a Cluster and its InstanceGroups, as read from manifest files.
+type clusterManifest struct {
+	cluster *kopsapi.Cluster
+	// instanceGroups are sorted by name
+	instanceGroups []*kopsapi.InstanceGroup
+}
+
+// readClusterManifest reads a single Cluster and its InstanceGroups from files, where "-" is stdin and a local
+// directory stands for the YAML and JSON files directly inside it.
+func readClusterManifest(filenames []string) (*clusterManifest, error) {
+	objects, err := readManifestObjects(filenames)
+	if err != nil {
+		return nil, err
+	}
+
+	manifest := &clusterManifest{}
+	for _, o := range objects {
+		switch v := o.object.(type) {
+		case *kopsapi.Cluster:
+			if manifest.cluster != nil {
+				return nil, fmt.Errorf("found Cluster %q in %q, but the manifest already contains Cluster %q", v.ObjectMeta.Name, o.filename, manifest.cluster.ObjectMeta.Name)
+			}
+			manifest.cluster = v
+		case *kopsapi.InstanceGroup:
+			manifest.instanceGroups = append(manifest.instanceGroups, v)
+		default:
+			return nil, fmt.Errorf("unhandled kind %q in %q", o.gvk, o.filename)
+		}
+	}
+
+	if manifest.cluster == nil {
+		return nil, fmt.Errorf("manifest does not contain a Cluster")
+	}
+
+	seen := map[string]bool{}
+	for _, ig := range manifest.instanceGroups {
+		clusterName := ig.ObjectMeta.Labels[kopsapi.LabelClusterName]
+		if clusterName != "" && clusterName != manifest.cluster.ObjectMeta.Name {
+			return nil, fmt.Errorf("InstanceGroup %q belongs to cluster %q, not %q", ig.ObjectMeta.Name, clusterName, manifest.cluster.ObjectMeta.Name)
+		}
+		if seen[ig.ObjectMeta.Name] {
+			return nil, fmt.Errorf("manifest contains InstanceGroup %q more than once", ig.ObjectMeta.Name)
+		}
+		seen[ig.ObjectMeta.Name] = true
+	}
+	sort.Slice(manifest.instanceGroups, func(i, j int) bool {
+		return manifest.instanceGroups[i].ObjectMeta.Name < manifest.instanceGroups[j].ObjectMeta.Name
+	})
+
+	return manifest, nil
+}
+
+// clusterModel is a cluster and its instance groups, completed with their default values.
+type clusterModel struct {
+	cluster        *kopsapi.Cluster
+	instanceGroups map[string]*kopsapi.InstanceGroup
+	// nodeupConfigs is the nodeup configuration of each instance group; it is only built for a full model.
+	nodeupConfigs map[string]string
+}
+
+// buildClusterModel completes the cluster and instance groups. A full model is built by a dry run of
+// the cloudup model, so it also holds the nodeup configuration of the instance groups.
+func buildClusterModel(ctx context.Context, clientset simple.Clientset, cluster *kopsapi.Cluster, instanceGroups []*kopsapi.InstanceGroup, full bool) (*clusterModel, error) {
+	cluster = cluster.DeepCopy()
+	instanceGroups = deepCopyInstanceGroups(instanceGroups)
+
+	cloud, err := cloudup.BuildCloud(cluster)
+	if err != nil {
+		return nil, err
+	}
+
+	model := &clusterModel{
+		instanceGroups: make(map[string]*kopsapi.InstanceGroup),
+		nodeupConfigs:  make(map[string]string),
+	}
+
+	if full {
+		runTasksOptions := fi.RunTasksOptions{}
+		runTasksOptions.InitCloudupDefaults()
+
+		applyCmd := &cloudup.ApplyClusterCmd{
+			Cloud:           cloud,
+			Clientset:       clientset,
+			Cluster:         cluster,
+			InstanceGroups:  instanceGroups,
+			DryRun:          true,
+			Quiet:           true,
+			RunTasksOptions: &runTasksOptions,
+			TargetName:      cloudup.TargetDryRun,
+		}
+		if err := applyCmd.Run(ctx); err != nil {
+			return nil, err
+		}
+
+		model.cluster = applyCmd.Cluster
+		for _, ig := range applyCmd.InstanceGroups {
+			model.instanceGroups[ig.ObjectMeta.Name] = ig
+
+			task, ok := applyCmd.TaskMap["ManagedFile/nodeupconfig-"+ig.ObjectMeta.Name].(*fitasks.ManagedFile)
+			if !ok {
+				continue
+			}
+			config, err := fi.ResourceAsString(task.Contents)
+			if err != nil {
+				return nil, fmt.Errorf("error building nodeup config for InstanceGroup %q: %w", ig.ObjectMeta.Name, err)
+			}
+			model.nodeupConfigs[ig.ObjectMeta.Name] = config
+		}
+		return model, nil
+	}
+
+	if err := cloudup.PerformAssignments(cluster, cloud); err != nil {
+		return nil, fmt.Errorf("error populating configuration: %v", err)
+	}
+
+	assetBuilder := assets.NewAssetBuilder(cluster.Spec.Assets, cluster.Spec.KubernetesVersion, false)
+	fullCluster, err := cloudup.PopulateClusterSpec(ctx, clientset, cluster, cloud, assetBuilder)
+	if err != nil {
+		return nil, fmt.Errorf("error populating cluster spec: %w", err)
+	}
+	model.cluster = fullCluster
+
+	channel, err := cloudup.ChannelForCluster(fullCluster)
+	if err != nil {
+		return nil, err
+	}
+	for _, ig := range instanceGroups {
+		fullGroup, err := cloudup.PopulateInstanceGroupSpec(fullCluster, ig, cloud, channel)
+		if err != nil {
+			return nil, fmt.Errorf("error populating instance group spec: %w", err)
+		}
+		model.instanceGroups[ig.ObjectMeta.Name] = fullGroup
+	}
+
+	return model, nil
+}
+
+func RunDiffCluster(ctx context.Context, f *util.Factory, out io.Writer, options *DiffClusterOptions) error {
+	manifest, err := readClusterManifest(options.Filenames)
+	if err != nil {
+		return err
+	}
+
+	clientset, err := f.KopsClient()
+	if err != nil {
+		return err
+	}
+
+	clusterName := manifest.cluster.ObjectMeta.Name
+	cluster, err := clientset.GetCluster(ctx, clusterName)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return fmt.Errorf("cluster %q not found", clusterName)
+		}
+		return fmt.Errorf("error fetching cluster %q: %v", clusterName, err)
+	}
+
+	list, err := clientset.InstanceGroupsFor(cluster).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	var instanceGroups []*kopsapi.InstanceGroup
+	current := make(map[string]*kopsapi.InstanceGroup)
+	for i := range list.Items {
+		ig := &list.Items[i]
+		instanceGroups = append(instanceGroups, ig)
+		current[ig.ObjectMeta.Name] = ig
+	}
+
+	// The manifest replaces the specs of the objects in the state store
+	updated := cluster.DeepCopy()
+	updated.Spec = manifest.cluster.Spec
+	if updated.Spec.ConfigBase == "" {
+		updated.Spec.ConfigBase = cluster.Spec.ConfigBase
+	}
+
+	replaced := make(map[string]*kopsapi.InstanceGroup)
+	for _, ig := range manifest.instanceGroups {
+		if currentIG := current[ig.ObjectMeta.Name]; currentIG != nil {
+			updatedIG := currentIG.DeepCopy()
+			updatedIG.Spec = ig.Spec
+			replaced[ig.ObjectMeta.Name] = updatedIG
+		} else {
+			createdIG := ig.DeepCopy()
+			createdIG.ObjectMeta.ResourceVersion = ""
+			replaced[ig.ObjectMeta.Name] = createdIG
+		}
+	}
+	var updatedInstanceGroups []*kopsapi.InstanceGroup
+	for _, ig := range instanceGroups {
+		if replacedIG := replaced[ig.ObjectMeta.Name]; replacedIG != nil {
+			ig = replacedIG
+		}
+		updatedInstanceGroups = append(updatedInstanceGroups, ig)
+	}
+	for _, ig := range manifest.instanceGroups {
+		if current[ig.ObjectMeta.Name] == nil {
+			updatedInstanceGroups = append(updatedInstanceGroups, replaced[ig.ObjectMeta.Name])
+		}
+	}
+
+	currentModel, err := buildClusterModel(ctx, clientset, cluster, instanceGroups, options.Full)
+	if err != nil {
+		return fmt.Errorf("error completing cluster %q from the state store: %w", clusterName, err)
+	}
+	updatedModel, err := buildClusterModel(ctx, clientset, updated, updatedInstanceGroups, options.Full)
+	if err != nil {
+		return fmt.Errorf("error completing cluster %q from the manifest: %w", clusterName, err)
+	}
+
+	changed := false
+
+	// A change is only shown if it remains once the defaults are applied
+	if !apiequality.Semantic.DeepEqual(cluster.Spec, updated.Spec) && !apiequality.Semantic.DeepEqual(currentModel.cluster.Spec, updatedModel.cluster.Spec) {
+		if _, err := writeSpecDiff(out, "Cluster", clusterName, cluster, updated); err != nil {
+			return err
+		}
+		changed = true
+	}
+
+	for _, ig := range manifest.instanceGroups {
+		name := ig.ObjectMeta.Name
+		currentIG := current[name]
+		if currentIG == nil {
+			targetYAML, err := specYAML(replaced[name])
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(out, "Will create InstanceGroup %q:\n%s\n", name, targetYAML)
+			changed = true
+			continue
+		}
+		if !apiequality.Semantic.DeepEqual(currentIG.Spec, replaced[name].Spec) && !apiequality.Semantic.DeepEqual(currentModel.instanceGroups[name].Spec, updatedModel.instanceGroups[name].Spec) {
+			if _, err := writeSpecDiff(out, "InstanceGroup", name, currentIG, replaced[name]); err != nil {
+				return err
+			}
+			changed = true
+		}
+	}
+
+	if options.Full {
+		wrote, err := writeSpecDiff(out, "completed Cluster", clusterName, currentModel.cluster, updatedModel.cluster)
+		if err != nil {
+			return err
+		}
+		changed = changed || wrote
+
+		var names []string
+		for name := range updatedModel.nodeupConfigs {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			currentConfig, found := currentModel.nodeupConfigs[name]
+			updatedConfig := updatedModel.nodeupConfigs[name]
+			if !found {
+				fmt.Fprintf(out, "Will create nodeup config of InstanceGroup %q:\n%s\n", name, updatedConfig)
+				changed = true
+			} else if currentConfig != updatedConfig {
+				fmt.Fprintf(out, "Will modify nodeup config of InstanceGroup %q:\n%s\n", name, diff.FormatDiff(currentConfig, updatedConfig))
+				changed = true
+			}
+		}
+	}
+
+	if !changed {
+		fmt.Fprintf(out, "No changes to cluster %q\n", clusterName)
+	}
+	return nil
+}
+
+// writeSpecDiff writes the differences between the YAML of current and updated, and returns whether there are any.
+func writeSpecDiff(out io.Writer, kind string, name string, current, updated runtime.Object) (bool, error) {
+	currentYAML, err := specYAML(current)
+	if err != nil {
+		return false, err
+	}
+	updatedYAML, err := specYAML(updated)
+	if err != nil {
+		return false, err
+	}
+	if currentYAML == updatedYAML {
+		return false, nil
+	}
+	fmt.Fprintf(out, "Will modify %s %q:\n%s\n", kind, name, diff.FormatDiff(currentYAML, updatedYAML))
+	return true, nil
+}
@@ -0,0 +1,229 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/kops/cmd/kops/util"
+	kopsapi "k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/pkg/kopscodecs"
+	"k8s.io/kops/pkg/testutils"
+	"k8s.io/kops/pkg/testutils/testcontext"
+	"k8s.io/kops/upup/pkg/fi"
+)
+
+const diffTestCluster = `apiVersion: kops.k8s.io/v1alpha2
+kind: Cluster
+metadata:
+  name: minimal.example.com
+spec:
+  kubernetesVersion: v1.26.0
+`
+
+func diffTestInstanceGroup(name string, clusterName string) string {
+	return `apiVersion: kops.k8s.io/v1alpha2
+kind: InstanceGroup
+metadata:
+  name: ` + name + `
+  labels:
+    kops.k8s.io/cluster: ` + clusterName + `
+spec:
+  role: Node
+`
+}
+
+func TestReadClusterManifest(t *testing.T) {
+	grid := []struct {
+		name           string
+		files          []string
+		instanceGroups []string
+		expectedErr    string
+	}{
+		{
+			name: "cluster and instance groups",
+			files: []string{
+				diffTestCluster + "\n---\n" + diffTestInstanceGroup("nodes-b", "minimal.example.com") + "\n---\n" + diffTestInstanceGroup("nodes-a", "minimal.example.com"),
+			},
+			instanceGroups: []string{"nodes-a", "nodes-b"},
+		},
+		{
+			name: "multiple files",
+			files: []string{
+				diffTestInstanceGroup("nodes", "minimal.example.com"),
+				diffTestCluster,
+			},
+			instanceGroups: []string{"nodes"},
+		},
+		{
+			name:        "no cluster",
+			files:       []string{diffTestInstanceGroup("nodes", "minimal.example.com")},
+			expectedErr: "manifest does not contain a Cluster",
+		},
+		{
+			name:        "two clusters",
+			files:       []string{diffTestCluster, diffTestCluster},
+			expectedErr: "manifest already contains Cluster \"minimal.example.com\"",
+		},
+		{
+			name:        "instance group of another cluster",
+			files:       []string{diffTestCluster + "\n---\n" + diffTestInstanceGroup("nodes", "other.example.com")},
+			expectedErr: "InstanceGroup \"nodes\" belongs to cluster \"other.example.com\"",
+		},
+		{
+			name:        "duplicate instance group",
+			files:       []string{diffTestCluster + "\n---\n" + diffTestInstanceGroup("nodes", "") + "\n---\n" + diffTestInstanceGroup("nodes", "")},
+			expectedErr: "manifest contains InstanceGroup \"nodes\" more than once",
+		},
+	}
+	for _, g := range grid {
+		t.Run(g.name, func(t *testing.T) {
+			dir := t.TempDir()
+			var filenames []string
+			for i, contents := range g.files {
+				filename := filepath.Join(dir, "manifest-"+string(rune('a'+i))+".yaml")
+				if err := os.WriteFile(filename, []byte(contents), 0o644); err != nil {
+					t.Fatalf("error writing manifest: %v", err)
+				}
+				filenames = append(filenames, filename)
+			}
+
+			manifest, err := readClusterManifest(filenames)
+			if g.expectedErr != "" {
+				if err == nil || !strings.Contains(err.Error(), g.expectedErr) {
+					t.Fatalf("expected error containing %q, got %v", g.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if manifest.cluster.ObjectMeta.Name != "minimal.example.com" {
+				t.Errorf("unexpected cluster %q", manifest.cluster.ObjectMeta.Name)
+			}
+			var names []string
+			for _, ig := range manifest.instanceGroups {
+				names = append(names, ig.ObjectMeta.Name)
+			}
+			if strings.Join(names, ",") != strings.Join(g.instanceGroups, ",") {
+				t.Errorf("expected instance groups %v, got %v", g.instanceGroups, names)
+			}
+		})
+	}
+}
+
+func TestRunDiffCluster(t *testing.T) {
+	t.Setenv("SKIP_REGION_CHECK", "1")
+	ctx := testcontext.ForTest(t)
+
+	testutils.NewIntegrationTestHarness(t).SetupMockAWS()
+
+	factoryOptions := &util.FactoryOptions{}
+	factoryOptions.RegistryPath = "memfs://tests"
+	factory := util.NewFactory(factoryOptions)
+	clientset, err := factory.KopsClient()
+	if err != nil {
+		t.Fatalf("could not create clientset: %v", err)
+	}
+
+	cluster := testutils.BuildMinimalCluster("minimal.example.com")
+	nodes := testutils.BuildMinimalNodeInstanceGroup("nodes", "subnet-us-test-1a")
+	cluster, err = clientset.CreateCluster(ctx, cluster)
+	if err != nil {
+		t.Fatalf("could not create cluster: %v", err)
+	}
+	if _, err := clientset.InstanceGroupsFor(cluster).Create(ctx, &nodes, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("could not create instance group: %v", err)
+	}
+
+	grid := []struct {
+		name     string
+		mutate   func(cluster *kopsapi.Cluster, ig *kopsapi.InstanceGroup)
+		expected []string
+	}{
+		{
+			name:     "unchanged",
+			mutate:   func(cluster *kopsapi.Cluster, ig *kopsapi.InstanceGroup) {},
+			expected: []string{"No changes to cluster \"minimal.example.com\""},
+		},
+		{
+			name: "cluster field set to its default",
+			mutate: func(cluster *kopsapi.Cluster, ig *kopsapi.InstanceGroup) {
+				cluster.Spec.Kubelet = &kopsapi.KubeletConfigSpec{LogLevel: fi.PtrTo(int32(2))}
+			},
+			expected: []string{"No changes to cluster \"minimal.example.com\""},
+		},
+		{
+			name: "cluster field changed",
+			mutate: func(cluster *kopsapi.Cluster, ig *kopsapi.InstanceGroup) {
+				cluster.Spec.Kubelet = &kopsapi.KubeletConfigSpec{LogLevel: fi.PtrTo(int32(4))}
+			},
+			expected: []string{"Will modify Cluster \"minimal.example.com\"", "logLevel: 4"},
+		},
+		{
+			name: "instance group field changed",
+			mutate: func(cluster *kopsapi.Cluster, ig *kopsapi.InstanceGroup) {
+				ig.Spec.MaxSize = fi.PtrTo(int32(5))
+			},
+			expected: []string{"Will modify InstanceGroup \"nodes\"", "maxSize: 5"},
+		},
+	}
+	for _, g := range grid {
+		t.Run(g.name, func(t *testing.T) {
+			manifestCluster, err := clientset.GetCluster(ctx, cluster.ObjectMeta.Name)
+			if err != nil {
+				t.Fatalf("could not get cluster: %v", err)
+			}
+			manifestIG, err := clientset.InstanceGroupsFor(cluster).Get(ctx, "nodes", metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("could not get instance group: %v", err)
+			}
+			g.mutate(manifestCluster, manifestIG)
+
+			var manifest []string
+			for _, o := range []runtime.Object{manifestCluster, manifestIG} {
+				b, err := kopscodecs.ToVersionedYamlWithVersion(o, schema.GroupVersion{Group: "kops.k8s.io", Version: "v1alpha2"})
+				if err != nil {
+					t.Fatalf("error serializing manifest: %v", err)
+				}
+				manifest = append(manifest, string(b))
+			}
+			filename := filepath.Join(t.TempDir(), "manifest.yaml")
+			if err := os.WriteFile(filename, []byte(strings.Join(manifest, "\n---\n")), 0o644); err != nil {
+				t.Fatalf("error writing manifest: %v", err)
+			}
+
+			var out bytes.Buffer
+			if err := RunDiffCluster(ctx, factory, &out, &DiffClusterOptions{Filenames: []string{filename}}); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for _, expected := range g.expected {
+				if !strings.Contains(out.String(), expected) {
+					t.Errorf("expected output to contain %q, got %q", expected, out.String())
+				}
+			}
+		})
+	}
+}
@@ -27,6 +27,7 @@ import (
 	"strings"
 
 	"github.com/spf13/cobra"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/kops/cmd/kops/util"
 	api "k8s.io/kops/pkg/apis/kops"
 	"k8s.io/kops/pkg/apis/kops/validation"
@@ -128,7 +129,7 @@ func RunEditCluster(ctx context.Context, f *util.Factory, out io.Writer, options
 
 		failure, err := updateCluster(ctx, clientset, oldCluster, newCluster, instanceGroups)
 		if err != nil {
-			return err
+			return editConflictError(err, "cluster", oldCluster.ObjectMeta.Name)
 		}
 		if failure != "" {
 			return fmt.Errorf("%s", failure)
@@ -211,6 +212,9 @@ func RunEditCluster(ctx context.Context, f *util.Factory, out io.Writer, options
 			continue
 		}
 
+		// The edit is based on the version we read, regardless of what is in the edited file
+		newCluster.ObjectMeta.ResourceVersion = oldCluster.ObjectMeta.ResourceVersion
+
 		extraFields, err := edit.HasExtraFields(string(edited))
 		if err != nil {
 			results = editResults{
@@ -234,7 +238,7 @@ func RunEditCluster(ctx context.Context, f *util.Factory, out io.Writer, options
 
 		failure, err := updateCluster(ctx, clientset, oldCluster, newCluster, instanceGroups)
 		if err != nil {
-			return preservedFile(err, file, out)
+			return preservedFile(editConflictError(err, "cluster", oldCluster.ObjectMeta.Name), file, out)
 		}
 		if failure != "" {
 			results = editResults{
@@ -359,6 +363,15 @@ func hasLines(r io.Reader) (bool, error) {
 	return false, nil
 }
 
+// editConflictError replaces a Conflict error, caused by a concurrent change to the object
+// being edited, with a message explaining how to recover.
+func editConflictError(err error, kind string, name string) error {
+	if apierrors.IsConflict(err) {
+		return fmt.Errorf("%s %q was modified by someone else while it was being edited; run kops edit again to apply your changes to the latest version", kind, name)
+	}
+	return err
+}
+
 // preservedFile writes out a message about the provided file if it exists to the
 // provided output stream when an error happens. Used to notify the user where
 // their updates were preserved.
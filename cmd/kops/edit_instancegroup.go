@@ -156,7 +156,7 @@ func RunEditInstanceGroup(ctx context.Context, f *util.Factory, out io.Writer, o
 
 		failure, err := updateInstanceGroup(ctx, clientset, channel, cluster, newGroup)
 		if err != nil {
-			return err
+			return editConflictError(err, "InstanceGroup", groupName)
 		}
 		if failure != "" {
 			return fmt.Errorf("%s", failure)
@@ -239,6 +239,9 @@ func RunEditInstanceGroup(ctx context.Context, f *util.Factory, out io.Writer, o
 			continue
 		}
 
+		// The edit is based on the version we read, regardless of what is in the edited file
+		newGroup.ObjectMeta.ResourceVersion = oldGroup.ObjectMeta.ResourceVersion
+
 		extraFields, err := edit.HasExtraFields(string(edited))
 		if err != nil {
 			results = editResults{
@@ -262,7 +265,7 @@ func RunEditInstanceGroup(ctx context.Context, f *util.Factory, out io.Writer, o
 
 		failure, err := updateInstanceGroup(ctx, clientset, channel, cluster, newGroup)
 		if err != nil {
-			return preservedFile(err, file, out)
+			return preservedFile(editConflictError(err, "InstanceGroup", groupName), file, out)
 		}
 		if failure != "" {
 			results = editResults{
@@ -79,6 +79,7 @@ func TestEditInstanceGroup(t *testing.T) {
 		t.Fatalf("could not get instance group: %v", err)
 	}
 	storedIG.CreationTimestamp = MagicTimestamp
+	storedIG.ResourceVersion = ""
 	actualYAMLBytes, err := kopscodecs.ToVersionedYamlWithVersion(storedIG, schema.GroupVersion{Group: "kops.k8s.io", Version: "v1alpha2"})
 	if err != nil {
 		t.Fatalf("unexpected error serializing Addon: %v", err)
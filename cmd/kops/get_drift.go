@@ -0,0 +1,213 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"k8s.io/kops/cmd/kops/util"
+	"k8s.io/kops/pkg/commands/commandutils"
+	"k8s.io/kops/pkg/pretty"
+	"k8s.io/kops/upup/pkg/fi"
+	"k8s.io/kops/upup/pkg/fi/cloudup"
+	"k8s.io/kops/upup/pkg/fi/fitasks"
+	"k8s.io/kops/util/pkg/tables"
+	"k8s.io/kubectl/pkg/util/i18n"
+	"k8s.io/kubectl/pkg/util/templates"
+	"sigs.k8s.io/yaml"
+)
+
+var (
+	getDriftLong = pretty.LongDesc(i18n.T(`
+	Display the cloud resources of a cluster whose actual state differs from the
+	state kOps expects, e.g. a security group that was edited in the cloud console.
+
+	Each drifted resource is classified as one of:
+
+	* ConsoleEdit: the resource exists, but some of its fields differ from the values
+	  that were applied.
+	* MissingResource: the resource does not exist, although it was created by ` + pretty.Bash("kops update cluster") + ` before.
+	* ExtraResource: a resource owned by the cluster that kOps would delete.
+
+	Changes to the cluster spec that were not applied yet, including resources that
+	are new to it, are not drift; ` + pretty.Bash("kops update cluster") + ` shows them. A field
+	that was both edited in the cloud and changed in the cluster spec is not reported.
+	Edited and missing resources are only reported once the cluster has been updated
+	with ` + pretty.Bash("--yes") + ` by this version of kOps, which records the resources it applied.
+
+	The command exits with status 2 if drift is found.`))
+
+	getDriftExample = templates.Examples(i18n.T(`
+	# Display the drift of a cluster.
+	kops get drift k8s-cluster.example.com
+
+	# Check a cluster for drift from a cron job.
+	kops get drift k8s-cluster.example.com -o json > drift.json
+	`))
+
+	getDriftShort = i18n.T(`Display cloud resources that differ from the cluster spec.`)
+)
+
+type GetDriftOptions struct {
+	*GetOptions
+}
+
+func NewCmdGetDrift(f *util.Factory, out io.Writer, getOptions *GetOptions) *cobra.Command {
+	options := GetDriftOptions{
+		GetOptions: getOptions,
+	}
+
+	cmd := &cobra.Command{
+		Use:               "drift [CLUSTER]",
+		Short:             getDriftShort,
+		Long:              getDriftLong,
+		Example:           getDriftExample,
+		Args:              rootCommand.clusterNameArgs(&options.ClusterName),
+		ValidArgsFunction: commandutils.CompleteClusterName(f, true, false),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			drift, err := RunGetDrift(cmd.Context(), f, out, &options)
+			if err != nil {
+				return err
+			}
+			return driftFound(drift)
+		},
+	}
+
+	return cmd
+}
+
+func RunGetDrift(ctx context.Context, f *util.Factory, out io.Writer, options *GetDriftOptions) (*fi.Drift, error) {
+	cluster, err := GetCluster(ctx, f, options.ClusterName)
+	if err != nil {
+		return nil, err
+	}
+
+	clientset, err := f.KopsClient()
+	if err != nil {
+		return nil, err
+	}
+
+	cloud, err := cloudup.BuildCloud(cluster)
+	if err != nil {
+		return nil, err
+	}
+
+	runTasksOptions := fi.RunTasksOptions{}
+	runTasksOptions.InitCloudupDefaults()
+
+	applyCmd := &cloudup.ApplyClusterCmd{
+		Cloud:           cloud,
+		Clientset:       clientset,
+		Cluster:         cluster,
+		DryRun:          true,
+		Quiet:           true,
+		RunTasksOptions: &runTasksOptions,
+		TargetName:      cloudup.TargetDryRun,
+	}
+	if err := applyCmd.Run(ctx); err != nil {
+		return nil, err
+	}
+
+	applied, err := cloudup.ReadAppliedResources(ctx, cluster)
+	if err != nil {
+		return nil, err
+	}
+
+	target := applyCmd.Target.(*fi.CloudupDryRunTarget)
+	drift, err := target.Drift(applyCmd.TaskMap, applied, isCloudResourceTask)
+	if err != nil {
+		return nil, err
+	}
+
+	switch options.Output {
+	case OutputTable:
+		if len(drift.Resources) == 0 {
+			fmt.Fprintf(out, "No drift found\n")
+			return drift, nil
+		}
+		return drift, driftOutputTable(drift.Resources, out)
+	case OutputYaml:
+		y, err := yaml.Marshal(drift)
+		if err != nil {
+			return nil, fmt.Errorf("unable to marshal YAML: %v", err)
+		}
+		if _, err := out.Write(y); err != nil {
+			return nil, fmt.Errorf("error writing to output: %v", err)
+		}
+	case OutputJSON:
+		j, err := json.MarshalIndent(drift, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("unable to marshal JSON: %v", err)
+		}
+		if _, err := out.Write(j); err != nil {
+			return nil, fmt.Errorf("error writing to output: %v", err)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported output format: %q", options.Output)
+	}
+
+	return drift, nil
+}
+
+// driftFound returns an error if drift was found, which makes the command exit with status 2
+// so that it can be used from cron jobs.
+func driftFound(drift *fi.Drift) error {
+	if len(drift.Resources) == 0 {
+		return nil
+	}
+	return &exitCodeError{
+		code: 2,
+		err:  fmt.Errorf("found drift in %d resources", len(drift.Resources)),
+	}
+}
+
+// isCloudResourceTask is false for the tasks that manage the state store rather than the cloud.
+func isCloudResourceTask(task fi.CloudupTask) bool {
+	switch task.(type) {
+	case *fitasks.Keypair, *fitasks.ManagedFile, *fitasks.MirrorKeystore, *fitasks.MirrorSecrets, *fitasks.Secret:
+		return false
+	default:
+		return true
+	}
+}
+
+func driftOutputTable(resources []*fi.DriftedResource, out io.Writer) error {
+	t := &tables.Table{}
+	t.AddColumn("TYPE", func(r *fi.DriftedResource) string {
+		return r.Type
+	})
+	t.AddColumn("NAME", func(r *fi.DriftedResource) string {
+		return r.Name
+	})
+	t.AddColumn("CLASS", func(r *fi.DriftedResource) string {
+		return string(r.Class)
+	})
+	t.AddColumn("FIELDS", func(r *fi.DriftedResource) string {
+		var fields []string
+		for _, field := range r.Fields {
+			fields = append(fields, field.Field)
+		}
+		return strings.Join(fields, ",")
+	})
+
+	return t.Render(resources, out, "TYPE", "NAME", "CLASS", "FIELDS")
+}
@@ -0,0 +1,48 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	"k8s.io/kops/upup/pkg/fi"
+)
+
+func TestDriftFoundExitCode(t *testing.T) {
+	if err := driftFound(&fi.Drift{}); err != nil {
+		t.Errorf("unexpected error without drift: %v", err)
+	}
+
+	err := driftFound(&fi.Drift{
+		Resources: []*fi.DriftedResource{
+			{Key: "SecurityGroup/nodes", Type: "SecurityGroup", Name: "nodes", Class: fi.DriftClassConsoleEdit},
+		},
+	})
+	if err == nil {
+		t.Fatalf("expected an error with drift")
+	}
+	if code := exitCode(err); code != 2 {
+		t.Errorf("expected exit status 2 with drift, got %d", code)
+	}
+	if code := exitCode(fmt.Errorf("wrapped: %w", err)); code != 2 {
+		t.Errorf("expected exit status 2 with wrapped drift error, got %d", code)
+	}
+	if code := exitCode(fmt.Errorf("failed")); code != 1 {
+		t.Errorf("expected exit status 1 for other errors, got %d", code)
+	}
+}
@@ -0,0 +1,201 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/spf13/cobra"
+	"k8s.io/kops/cmd/kops/util"
+	"k8s.io/kops/pkg/client/simple/vfsclientset"
+	"k8s.io/kops/pkg/commands/commandutils"
+	"k8s.io/kops/util/pkg/tables"
+	"k8s.io/kubectl/pkg/util/i18n"
+	"k8s.io/kubectl/pkg/util/templates"
+	"sigs.k8s.io/yaml"
+)
+
+var (
+	getHistoryShort = i18n.T(`Get the revision history of a resource.`)
+
+	getHistoryClusterLong = templates.LongDesc(i18n.T(`
+	Display the revision history of the cluster and its instance groups.
+	Every change kOps writes to the state store is recorded, with who made it,
+	when, the version of kOps used, and the difference from the previous version.
+
+	Specify --revision to show the full change made by a single revision.`))
+
+	getHistoryClusterExample = templates.Examples(i18n.T(`
+	# List the changes made to a cluster
+	kops get history cluster k8s-cluster.example.com
+
+	# Show what changed in revision 3
+	kops get history cluster k8s-cluster.example.com --revision 3
+
+	# Get the full history as YAML
+	kops get history cluster k8s-cluster.example.com -o yaml`))
+
+	getHistoryClusterShort = i18n.T(`Get the revision history of a cluster.`)
+)
+
+type GetHistoryClusterOptions struct {
+	*GetOptions
+
+	// Revision selects a single revision to display, if non-zero.
+	Revision int
+}
+
+func NewCmdGetHistory(f *util.Factory, out io.Writer, getOptions *GetOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "history",
+		Short: getHistoryShort,
+	}
+
+	// create subcommands
+	cmd.AddCommand(NewCmdGetHistoryCluster(f, out, getOptions))
+
+	return cmd
+}
+
+func NewCmdGetHistoryCluster(f *util.Factory, out io.Writer, getOptions *GetOptions) *cobra.Command {
+	options := GetHistoryClusterOptions{
+		GetOptions: getOptions,
+	}
+	cmd := &cobra.Command{
+		Use:               "cluster [CLUSTER]",
+		Short:             getHistoryClusterShort,
+		Long:              getHistoryClusterLong,
+		Example:           getHistoryClusterExample,
+		Args:              rootCommand.clusterNameArgs(&options.ClusterName),
+		ValidArgsFunction: commandutils.CompleteClusterName(f, true, false),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return RunGetHistoryCluster(cmd.Context(), f, out, &options)
+		},
+	}
+
+	cmd.Flags().IntVar(&options.Revision, "revision", options.Revision, "Show only the specified revision, including the change it made")
+
+	return cmd
+}
+
+func RunGetHistoryCluster(ctx context.Context, f *util.Factory, out io.Writer, options *GetHistoryClusterOptions) error {
+	cluster, err := GetCluster(ctx, f, options.ClusterName)
+	if err != nil {
+		return err
+	}
+
+	clientset, err := f.KopsClient()
+	if err != nil {
+		return err
+	}
+
+	configBase, err := clientset.ConfigBaseFor(cluster)
+	if err != nil {
+		return err
+	}
+
+	revisions, err := vfsclientset.ListRevisions(ctx, configBase)
+	if err != nil {
+		return err
+	}
+
+	if options.Revision != 0 {
+		var selected []*vfsclientset.Revision
+		for _, rev := range revisions {
+			if rev.Revision == options.Revision {
+				selected = append(selected, rev)
+			}
+		}
+		if len(selected) == 0 {
+			return fmt.Errorf("revision %d not found in the history of cluster %q", options.Revision, cluster.ObjectMeta.Name)
+		}
+		revisions = selected
+	}
+	if revisions == nil {
+		revisions = []*vfsclientset.Revision{}
+	}
+
+	switch options.Output {
+	case OutputTable:
+		if len(revisions) == 0 {
+			fmt.Fprintf(out, "No history recorded for cluster %q\n", cluster.ObjectMeta.Name)
+			return nil
+		}
+		t := &tables.Table{}
+		t.AddColumn("REVISION", func(r *vfsclientset.Revision) string {
+			return strconv.Itoa(r.Revision)
+		})
+		t.AddColumn("TIMESTAMP", func(r *vfsclientset.Revision) string {
+			return r.Timestamp.Format(time.RFC3339)
+		})
+		t.AddColumn("USER", func(r *vfsclientset.Revision) string {
+			return r.User
+		})
+		t.AddColumn("KOPS VERSION", func(r *vfsclientset.Revision) string {
+			return r.KopsVersion
+		})
+		t.AddColumn("OPERATION", func(r *vfsclientset.Revision) string {
+			return string(r.Operation)
+		})
+		t.AddColumn("KIND", func(r *vfsclientset.Revision) string {
+			return r.Kind
+		})
+		t.AddColumn("NAME", func(r *vfsclientset.Revision) string {
+			return r.Name
+		})
+		if err := t.Render(revisions, out, "REVISION", "TIMESTAMP", "USER", "KOPS VERSION", "OPERATION", "KIND", "NAME"); err != nil {
+			return err
+		}
+		if options.Revision != 0 {
+			rev := revisions[0]
+			fmt.Fprintf(out, "\n")
+			switch {
+			case rev.Diff != "":
+				fmt.Fprintf(out, "%s", rev.Diff)
+			case rev.Object != "":
+				fmt.Fprintf(out, "%s", rev.Object)
+			}
+		}
+		return nil
+
+	case OutputYaml:
+		y, err := yaml.Marshal(revisions)
+		if err != nil {
+			return fmt.Errorf("unable to marshal YAML: %v", err)
+		}
+		if _, err := out.Write(y); err != nil {
+			return fmt.Errorf("error writing to output: %v", err)
+		}
+	case OutputJSON:
+		j, err := json.Marshal(revisions)
+		if err != nil {
+			return fmt.Errorf("unable to marshal JSON: %v", err)
+		}
+		if _, err := out.Write(j); err != nil {
+			return fmt.Errorf("error writing to output: %v", err)
+		}
+	default:
+		return fmt.Errorf("unknown output format: %q", options.Output)
+	}
+
+	return nil
+}
@@ -29,6 +29,7 @@ import (
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/kops/cmd/kops/util"
 	"k8s.io/kops/pkg/commands/commandutils"
+	"k8s.io/kops/pkg/pki"
 	"k8s.io/kops/upup/pkg/fi"
 	"k8s.io/kops/util/pkg/tables"
 	"k8s.io/kubectl/pkg/util/i18n"
@@ -95,6 +96,7 @@ type keypairItem struct {
 	IsCA              bool       `json:"isCA,omitempty"`
 	NotBefore         *time.Time `json:"notBefore,omitempty"`
 	NotAfter          *time.Time `json:"notAfter,omitempty"`
+	KeyAlgorithm      string     `json:"keyAlgorithm,omitempty"`
 	KeyLength         *int       `json:"keyLength,omitempty"`
 	HasPrivateKey     bool       `json:"hasPrivateKey,omitempty"`
 }
@@ -152,6 +154,7 @@ func listKeypairs(keyStore fi.CAStore, names []string, includeDistrusted bool) (
 						sort.Strings(alternateNames)
 						keypair.AlternateNames = alternateNames
 					}
+					keypair.KeyAlgorithm = string(pki.KeyAlgorithmOf(cert.PublicKey))
 					if rsaKey, ok := cert.PublicKey.(*rsa.PublicKey); ok {
 						keypair.KeyLength = fi.PtrTo(rsaKey.N.BitLen())
 					}
@@ -216,6 +219,9 @@ func RunGetKeypairs(ctx context.Context, f commandutils.Factory, out io.Writer,
 			}
 			return ""
 		})
+		t.AddColumn("ALGORITHM", func(i *keypairItem) string {
+			return i.KeyAlgorithm
+		})
 		t.AddColumn("PRIMARY", func(i *keypairItem) string {
 			if i.IsPrimary {
 				return "*"
@@ -228,7 +234,7 @@ func RunGetKeypairs(ctx context.Context, f commandutils.Factory, out io.Writer,
 			}
 			return ""
 		})
-		columnNames := []string{"NAME", "ID", "ISSUED", "EXPIRES"}
+		columnNames := []string{"NAME", "ID", "ISSUED", "EXPIRES", "ALGORITHM"}
 		if options.Distrusted {
 			columnNames = append(columnNames, "DISTRUSTED")
 		}
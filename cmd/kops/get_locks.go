@@ -0,0 +1,152 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/spf13/cobra"
+	"k8s.io/kops/cmd/kops/util"
+	"k8s.io/kops/pkg/clusterlock"
+	"k8s.io/kops/pkg/commands/commandutils"
+	"k8s.io/kops/util/pkg/tables"
+	"k8s.io/kubectl/pkg/util/i18n"
+	"k8s.io/kubectl/pkg/util/templates"
+	"sigs.k8s.io/yaml"
+)
+
+var (
+	getLocksLong = templates.LongDesc(i18n.T(`
+	Display the lock that kOps holds on a cluster while it is being updated or rolling-updated.
+	A lock that is no longer being renewed by its holder is shown as expired.`))
+
+	getLocksExample = templates.Examples(i18n.T(`
+	# Show who is currently updating a cluster
+	kops get locks k8s-cluster.example.com`))
+
+	getLocksShort = i18n.T(`Get the locks held on a cluster.`)
+)
+
+type GetLocksOptions struct {
+	*GetOptions
+}
+
+func NewCmdGetLocks(f *util.Factory, out io.Writer, getOptions *GetOptions) *cobra.Command {
+	options := GetLocksOptions{
+		GetOptions: getOptions,
+	}
+	cmd := &cobra.Command{
+		Use:               "locks [CLUSTER]",
+		Aliases:           []string{"lock"},
+		Short:             getLocksShort,
+		Long:              getLocksLong,
+		Example:           getLocksExample,
+		Args:              rootCommand.clusterNameArgs(&options.ClusterName),
+		ValidArgsFunction: commandutils.CompleteClusterName(f, true, false),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return RunGetLocks(cmd.Context(), f, out, &options)
+		},
+	}
+
+	return cmd
+}
+
+// LockItem is the output of kops get locks.
+type LockItem struct {
+	Cluster string `json:"cluster"`
+	clusterlock.Lease
+	Expired bool `json:"expired"`
+}
+
+func RunGetLocks(ctx context.Context, f *util.Factory, out io.Writer, options *GetLocksOptions) error {
+	cluster, err := GetCluster(ctx, f, options.ClusterName)
+	if err != nil {
+		return err
+	}
+
+	lockPath, err := clusterlock.LockPath(cluster)
+	if err != nil {
+		return err
+	}
+
+	lease, err := clusterlock.Read(ctx, lockPath)
+	if err != nil {
+		return err
+	}
+
+	items := []*LockItem{}
+	if lease != nil && lease.Holder != "" {
+		items = append(items, &LockItem{
+			Cluster: cluster.ObjectMeta.Name,
+			Lease:   *lease,
+			Expired: !lease.IsHeld(time.Now()),
+		})
+	}
+
+	switch options.Output {
+	case OutputTable:
+		if len(items) == 0 {
+			fmt.Fprintf(out, "No locks held on cluster %q\n", cluster.ObjectMeta.Name)
+			return nil
+		}
+		t := &tables.Table{}
+		t.AddColumn("CLUSTER", func(i *LockItem) string {
+			return i.Cluster
+		})
+		t.AddColumn("HOLDER", func(i *LockItem) string {
+			return i.Holder
+		})
+		t.AddColumn("OPERATION", func(i *LockItem) string {
+			return i.Operation
+		})
+		t.AddColumn("ACQUIRED", func(i *LockItem) string {
+			return i.AcquireTime.Format(time.RFC3339)
+		})
+		t.AddColumn("EXPIRES", func(i *LockItem) string {
+			if i.Expired {
+				return "expired"
+			}
+			return i.ExpireTime.Format(time.RFC3339)
+		})
+		return t.Render(items, out, "CLUSTER", "HOLDER", "OPERATION", "ACQUIRED", "EXPIRES")
+
+	case OutputYaml:
+		y, err := yaml.Marshal(items)
+		if err != nil {
+			return fmt.Errorf("unable to marshal YAML: %v", err)
+		}
+		if _, err := out.Write(y); err != nil {
+			return fmt.Errorf("error writing to output: %v", err)
+		}
+	case OutputJSON:
+		j, err := json.Marshal(items)
+		if err != nil {
+			return fmt.Errorf("unable to marshal JSON: %v", err)
+		}
+		if _, err := out.Write(j); err != nil {
+			return fmt.Errorf("error writing to output: %v", err)
+		}
+	default:
+		return fmt.Errorf("unknown output format: %q", options.Output)
+	}
+
+	return nil
+}
@@ -0,0 +1,239 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"k8s.io/kops/cmd/kops/util"
+	"k8s.io/kops/pkg/clusterlock"
+	"k8s.io/kops/pkg/commands/commandutils"
+	"k8s.io/kops/pkg/instancegroups"
+	"k8s.io/kops/util/pkg/tables"
+	"k8s.io/kubectl/pkg/util/i18n"
+	"k8s.io/kubectl/pkg/util/templates"
+	"sigs.k8s.io/yaml"
+)
+
+var (
+	getRollingUpdateLong = templates.LongDesc(i18n.T(`
+	Display the progress of the last rolling update of a cluster: the instance groups that have been updated,
+	the instances being replaced or detached for surging, and the result of the last validation of the cluster.
+	A rolling update that stopped without completing can be continued with kops rolling-update cluster --resume.`))
+
+	getRollingUpdateExample = templates.Examples(i18n.T(`
+	# Show the progress of the last rolling update of a cluster
+	kops get rolling-update k8s-cluster.example.com`))
+
+	getRollingUpdateShort = i18n.T(`Get the progress of the last rolling update of a cluster.`)
+)
+
+type GetRollingUpdateOptions struct {
+	*GetOptions
+}
+
+func NewCmdGetRollingUpdate(f *util.Factory, out io.Writer, getOptions *GetOptions) *cobra.Command {
+	options := GetRollingUpdateOptions{
+		GetOptions: getOptions,
+	}
+	cmd := &cobra.Command{
+		Use:               "rolling-update [CLUSTER]",
+		Aliases:           []string{"rolling-updates"},
+		Short:             getRollingUpdateShort,
+		Long:              getRollingUpdateLong,
+		Example:           getRollingUpdateExample,
+		Args:              rootCommand.clusterNameArgs(&options.ClusterName),
+		ValidArgsFunction: commandutils.CompleteClusterName(f, true, false),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return RunGetRollingUpdate(cmd.Context(), f, out, &options)
+		},
+	}
+
+	return cmd
+}
+
+// RollingUpdateItem is the output of kops get rolling-update.
+type RollingUpdateItem struct {
+	instancegroups.RollingUpdateProgress
+	// Status is one of Running, Completed, Failed or Interrupted.
+	Status string `json:"status"`
+}
+
+// rollingUpdateInstanceItem is a row of the table of instances in kops get rolling-update.
+type rollingUpdateInstanceItem struct {
+	instancegroups.InstanceProgress
+	State string
+}
+
+func RunGetRollingUpdate(ctx context.Context, f *util.Factory, out io.Writer, options *GetRollingUpdateOptions) error {
+	cluster, err := GetCluster(ctx, f, options.ClusterName)
+	if err != nil {
+		return err
+	}
+
+	progressPath, err := instancegroups.RollingUpdateProgressPath(cluster)
+	if err != nil {
+		return err
+	}
+	progress, err := instancegroups.ReadRollingUpdateProgress(ctx, progressPath)
+	if err != nil {
+		return err
+	}
+
+	items := []*RollingUpdateItem{}
+	if progress != nil {
+		item := &RollingUpdateItem{
+			RollingUpdateProgress: *progress,
+		}
+		switch {
+		case progress.IsComplete():
+			item.Status = "Completed"
+		case progress.Error != "":
+			item.Status = "Failed"
+		default:
+			item.Status = "Interrupted"
+			lockPath, err := clusterlock.LockPath(cluster)
+			if err != nil {
+				return err
+			}
+			lease, err := clusterlock.Read(ctx, lockPath)
+			if err != nil {
+				return err
+			}
+			if lease != nil && lease.IsHeld(time.Now()) && lease.Operation == "rolling-update cluster" {
+				item.Status = "Running"
+			}
+		}
+		items = append(items, item)
+	}
+
+	switch options.Output {
+	case OutputTable:
+		if len(items) == 0 {
+			fmt.Fprintf(out, "No rolling update recorded for cluster %q\n", cluster.ObjectMeta.Name)
+			return nil
+		}
+		return printRollingUpdateTable(out, items[0])
+
+	case OutputYaml:
+		y, err := yaml.Marshal(items)
+		if err != nil {
+			return fmt.Errorf("unable to marshal YAML: %v", err)
+		}
+		if _, err := out.Write(y); err != nil {
+			return fmt.Errorf("error writing to output: %v", err)
+		}
+	case OutputJSON:
+		j, err := json.Marshal(items)
+		if err != nil {
+			return fmt.Errorf("unable to marshal JSON: %v", err)
+		}
+		if _, err := out.Write(j); err != nil {
+			return fmt.Errorf("error writing to output: %v", err)
+		}
+	default:
+		return fmt.Errorf("unknown output format: %q", options.Output)
+	}
+
+	return nil
+}
+
+func printRollingUpdateTable(out io.Writer, item *RollingUpdateItem) error {
+	t := &tables.Table{}
+	t.AddColumn("CLUSTER", func(i *RollingUpdateItem) string {
+		return i.Cluster
+	})
+	t.AddColumn("STATUS", func(i *RollingUpdateItem) string {
+		return i.Status
+	})
+	t.AddColumn("STARTED", func(i *RollingUpdateItem) string {
+		return i.StartTime.Format(time.RFC3339)
+	})
+	t.AddColumn("UPDATED", func(i *RollingUpdateItem) string {
+		return i.UpdateTime.Format(time.RFC3339)
+	})
+	t.AddColumn("COMPLETED-GROUPS", func(i *RollingUpdateItem) string {
+		return strings.Join(i.CompletedGroups, ",")
+	})
+	t.AddColumn("LAST-VALIDATION", func(i *RollingUpdateItem) string {
+		v := i.LastValidation
+		switch {
+		case v == nil:
+			return ""
+		case v.Error != "":
+			return fmt.Sprintf("Error at %s", v.Time.Format(time.RFC3339))
+		case !v.Succeeded:
+			return fmt.Sprintf("Failed at %s", v.Time.Format(time.RFC3339))
+		default:
+			return fmt.Sprintf("Succeeded at %s", v.Time.Format(time.RFC3339))
+		}
+	})
+	if err := t.Render([]*RollingUpdateItem{item}, out, "CLUSTER", "STATUS", "STARTED", "UPDATED", "COMPLETED-GROUPS", "LAST-VALIDATION"); err != nil {
+		return err
+	}
+
+	if item.Error != "" {
+		fmt.Fprintf(out, "\nError: %s\n", item.Error)
+	}
+	if v := item.LastValidation; v != nil {
+		for _, failure := range v.Failures {
+			fmt.Fprintf(out, "\nValidation failure: %s", failure)
+		}
+		if v.Error != "" {
+			fmt.Fprintf(out, "\nValidation error: %s", v.Error)
+		}
+		if len(v.Failures) != 0 || v.Error != "" {
+			fmt.Fprintf(out, "\n")
+		}
+	}
+
+	var instances []*rollingUpdateInstanceItem
+	for _, i := range item.InFlight {
+		instances = append(instances, &rollingUpdateInstanceItem{InstanceProgress: i, State: "Replacing"})
+	}
+	for _, i := range item.Detached {
+		instances = append(instances, &rollingUpdateInstanceItem{InstanceProgress: i, State: "Detached"})
+	}
+	if len(instances) == 0 {
+		return nil
+	}
+
+	fmt.Fprintf(out, "\n")
+	it := &tables.Table{}
+	it.AddColumn("ID", func(i *rollingUpdateInstanceItem) string {
+		return i.ID
+	})
+	it.AddColumn("INSTANCE-GROUP", func(i *rollingUpdateInstanceItem) string {
+		return i.InstanceGroup
+	})
+	it.AddColumn("NODE", func(i *rollingUpdateInstanceItem) string {
+		return i.Node
+	})
+	it.AddColumn("STATE", func(i *rollingUpdateInstanceItem) string {
+		return i.State
+	})
+	it.AddColumn("SINCE", func(i *rollingUpdateInstanceItem) string {
+		return i.Time.Format(time.RFC3339)
+	})
+	return it.Render(instances, out, "ID", "INSTANCE-GROUP", "NODE", "STATE", "SINCE")
+}
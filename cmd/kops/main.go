@@ -16,9 +16,21 @@ limitations under the License.
 
 package main // import "k8s.io/kops/cmd/kops"
 
-import "context"
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+)
 
 func main() {
-	ctx := context.Background()
+	// Cancel the context on interrupt, so that long-running operations can stop cleanly.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		// Restore the default handling once the context is cancelled, so that a second interrupt exits immediately.
+		<-ctx.Done()
+		stop()
+	}()
 	Execute(ctx)
 }
@@ -151,6 +151,9 @@ func RunReplace(ctx context.Context, f *util.Factory, out io.Writer, c *ReplaceO
 					} else {
 						_, err = clientset.UpdateCluster(ctx, v, status)
 						if err != nil {
+							if errors.IsConflict(err) {
+								return fmt.Errorf("cluster %q has been modified since resourceVersion %q was read; get the latest version and try again", clusterName, v.ObjectMeta.ResourceVersion)
+							}
 							return fmt.Errorf("error replacing cluster: %v", err)
 						}
 					}
@@ -190,6 +193,9 @@ func RunReplace(ctx context.Context, f *util.Factory, out io.Writer, c *ReplaceO
 				default:
 					_, err = clientset.InstanceGroupsFor(cluster).Update(ctx, v, metav1.UpdateOptions{})
 					if err != nil {
+						if errors.IsConflict(err) {
+							return fmt.Errorf("instanceGroup %q has been modified since resourceVersion %q was read; get the latest version and try again", igName, v.ObjectMeta.ResourceVersion)
+						}
 						return fmt.Errorf("error replacing instanceGroup: %v", err)
 					}
 				}
@@ -0,0 +1,299 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/spf13/cobra"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/klog/v2"
+	"k8s.io/kops/cmd/kops/util"
+	kopsapi "k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/pkg/client/simple/vfsclientset"
+	"k8s.io/kops/pkg/clusterlock"
+	"k8s.io/kops/pkg/commands/commandutils"
+	"k8s.io/kops/pkg/diff"
+	"k8s.io/kops/pkg/kopscodecs"
+	"k8s.io/kops/upup/pkg/fi/cloudup"
+	"k8s.io/kubectl/pkg/util/i18n"
+	"k8s.io/kubectl/pkg/util/templates"
+)
+
+var (
+	rollbackClusterLong = templates.LongDesc(i18n.T(`
+	Restore the specs of the cluster and its instance groups to how they were at an earlier revision
+	of the history shown by kops get history cluster. The changes that will be made are shown first;
+	specify --yes to write them to the state store.
+
+	The rollback is itself recorded as a new revision. Instance groups that were created after the
+	revision are not deleted; use kops delete instancegroup to remove them.
+
+	As with any other change to the cluster spec, run kops update cluster afterwards to apply it
+	to the cloud resources.`))
+
+	rollbackClusterExample = templates.Examples(i18n.T(`
+	# Show the changes to roll back a cluster to revision 3
+	kops rollback cluster k8s-cluster.example.com --to-revision 3
+
+	# Roll back the cluster to revision 3
+	kops rollback cluster k8s-cluster.example.com --to-revision 3 --yes
+	kops update cluster k8s-cluster.example.com --yes`))
+
+	rollbackClusterShort = i18n.T(`Roll back a cluster to an earlier revision.`)
+)
+
+type RollbackClusterOptions struct {
+	ClusterName string
+	ToRevision  int
+	Yes         bool
+}
+
+func NewCmdRollbackCluster(f *util.Factory, out io.Writer) *cobra.Command {
+	options := &RollbackClusterOptions{}
+
+	cmd := &cobra.Command{
+		Use:               "cluster [CLUSTER]",
+		Short:             rollbackClusterShort,
+		Long:              rollbackClusterLong,
+		Example:           rollbackClusterExample,
+		Args:              rootCommand.clusterNameArgs(&options.ClusterName),
+		ValidArgsFunction: commandutils.CompleteClusterName(f, true, false),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return RunRollbackCluster(cmd.Context(), f, out, options)
+		},
+	}
+
+	cmd.Flags().IntVar(&options.ToRevision, "to-revision", options.ToRevision, "Revision to roll back to, as shown by kops get history cluster")
+	cmd.Flags().BoolVarP(&options.Yes, "yes", "y", options.Yes, "Write the changes to the state store, without --yes rollback is in dry run mode")
+
+	return cmd
+}
+
+// rollbackChange is a change to an object needed to restore it to its spec at a revision.
+type rollbackChange struct {
+	kind string
+	name string
+	// current is the object in the state store, or nil if it does not exist
+	current runtime.Object
+	// target is the object to write
+	target runtime.Object
+}
+
+func RunRollbackCluster(ctx context.Context, f *util.Factory, out io.Writer, options *RollbackClusterOptions) error {
+	if options.ToRevision <= 0 {
+		return fmt.Errorf("--to-revision is required")
+	}
+
+	cluster, err := GetCluster(ctx, f, options.ClusterName)
+	if err != nil {
+		return err
+	}
+
+	clientset, err := f.KopsClient()
+	if err != nil {
+		return err
+	}
+
+	if options.Yes {
+		lock, err := clusterlock.AcquireForCluster(ctx, cluster, "rollback cluster")
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if err := lock.Release(context.Background()); err != nil {
+				klog.Warningf("error releasing cluster lock: %v", err)
+			}
+		}()
+		ctx = vfsclientset.WithRevisionAuthor(ctx, lock.Holder())
+	}
+
+	configBase, err := clientset.ConfigBaseFor(cluster)
+	if err != nil {
+		return err
+	}
+
+	revisions, err := vfsclientset.ListRevisions(ctx, configBase)
+	if err != nil {
+		return err
+	}
+
+	objects, err := vfsclientset.ObjectsAtRevision(revisions, options.ToRevision)
+	if err != nil {
+		return fmt.Errorf("cannot roll back cluster %q: %w", cluster.ObjectMeta.Name, err)
+	}
+
+	// Roll back the cluster before the instance groups, which are validated against it
+	var keys []vfsclientset.RevisionObject
+	for key := range objects {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].Kind != keys[j].Kind {
+			return keys[i].Kind == "Cluster"
+		}
+		return keys[i].Name < keys[j].Name
+	})
+
+	var changes []*rollbackChange
+	for _, key := range keys {
+		rev := objects[key]
+
+		var target runtime.Object
+		if rev != nil {
+			target, _, err = kopscodecs.Decode([]byte(rev.Object), nil)
+			if err != nil {
+				return fmt.Errorf("error parsing %s %q from revision %d: %w", rev.Kind, rev.Name, rev.Revision, err)
+			}
+		}
+
+		switch key.Kind {
+		case "Cluster":
+			if key.Name != cluster.ObjectMeta.Name || target == nil {
+				continue
+			}
+			targetCluster, ok := target.(*kopsapi.Cluster)
+			if !ok {
+				return fmt.Errorf("revision %d of cluster %q has unexpected type %T", rev.Revision, key.Name, target)
+			}
+			updated := cluster.DeepCopy()
+			updated.Spec = targetCluster.Spec
+			if updated.Spec.ConfigBase == "" {
+				updated.Spec.ConfigBase = cluster.Spec.ConfigBase
+			}
+			if !apiequality.Semantic.DeepEqual(cluster.Spec, updated.Spec) {
+				changes = append(changes, &rollbackChange{kind: key.Kind, name: key.Name, current: cluster, target: updated})
+			}
+
+		case "InstanceGroup":
+			current, err := clientset.InstanceGroupsFor(cluster).Get(ctx, key.Name, metav1.GetOptions{})
+			if err != nil {
+				if !errors.IsNotFound(err) {
+					return fmt.Errorf("error reading InstanceGroup %q: %w", key.Name, err)
+				}
+				current = nil
+			}
+			if target == nil {
+				if current != nil {
+					fmt.Fprintf(out, "InstanceGroup %q did not exist at revision %d; delete it with kops delete instancegroup if it is no longer needed\n", key.Name, options.ToRevision)
+				}
+				continue
+			}
+			targetIG, ok := target.(*kopsapi.InstanceGroup)
+			if !ok {
+				return fmt.Errorf("revision %d of InstanceGroup %q has unexpected type %T", rev.Revision, key.Name, target)
+			}
+			if current == nil {
+				targetIG.ObjectMeta.ResourceVersion = ""
+				changes = append(changes, &rollbackChange{kind: key.Kind, name: key.Name, target: targetIG})
+				continue
+			}
+			updated := current.DeepCopy()
+			updated.Spec = targetIG.Spec
+			if !apiequality.Semantic.DeepEqual(current.Spec, updated.Spec) {
+				changes = append(changes, &rollbackChange{kind: key.Kind, name: key.Name, current: current, target: updated})
+			}
+
+		default:
+			klog.Warningf("ignoring %s %q in revision history", key.Kind, key.Name)
+		}
+	}
+
+	if len(changes) == 0 {
+		fmt.Fprintf(out, "Cluster %q already matches revision %d\n", cluster.ObjectMeta.Name, options.ToRevision)
+		return nil
+	}
+
+	for _, change := range changes {
+		targetYAML, err := specYAML(change.target)
+		if err != nil {
+			return err
+		}
+		if change.current == nil {
+			fmt.Fprintf(out, "Will create %s %q:\n%s\n", change.kind, change.name, targetYAML)
+			continue
+		}
+		currentYAML, err := specYAML(change.current)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Will modify %s %q:\n%s\n", change.kind, change.name, diff.FormatDiff(currentYAML, targetYAML))
+	}
+
+	if !options.Yes {
+		fmt.Fprintf(out, "Must specify --yes to roll back\n")
+		return nil
+	}
+
+	for _, change := range changes {
+		switch target := change.target.(type) {
+		case *kopsapi.Cluster:
+			cloud, err := cloudup.BuildCloud(target)
+			if err != nil {
+				return err
+			}
+			status, err := cloud.FindClusterStatus(target)
+			if err != nil {
+				return err
+			}
+			if _, err := clientset.UpdateCluster(ctx, target, status); err != nil {
+				if errors.IsConflict(err) {
+					return fmt.Errorf("cluster %q was modified during the rollback; please try again", change.name)
+				}
+				return fmt.Errorf("error rolling back cluster %q: %w", change.name, err)
+			}
+		case *kopsapi.InstanceGroup:
+			if change.current == nil {
+				_, err = clientset.InstanceGroupsFor(cluster).Create(ctx, target, metav1.CreateOptions{})
+			} else {
+				_, err = clientset.InstanceGroupsFor(cluster).Update(ctx, target, metav1.UpdateOptions{})
+			}
+			if err != nil {
+				if errors.IsConflict(err) {
+					return fmt.Errorf("InstanceGroup %q was modified during the rollback; please try again", change.name)
+				}
+				return fmt.Errorf("error rolling back InstanceGroup %q: %w", change.name, err)
+			}
+		}
+	}
+
+	fmt.Fprintf(out, "Rolled back cluster %q to revision %d\n", cluster.ObjectMeta.Name, options.ToRevision)
+	fmt.Fprintf(out, "Run kops update cluster to apply the changes to the cloud resources\n")
+	return nil
+}
+
+// specYAML serializes the object for display, without the fields derived from the state store.
+func specYAML(o runtime.Object) (string, error) {
+	o = o.DeepCopyObject()
+	switch v := o.(type) {
+	case *kopsapi.Cluster:
+		v.ObjectMeta.ResourceVersion = ""
+	case *kopsapi.InstanceGroup:
+		v.ObjectMeta.ResourceVersion = ""
+	}
+	b, err := kopscodecs.ToVersionedYaml(o)
+	if err != nil {
+		return "", fmt.Errorf("error serializing object: %w", err)
+	}
+	return string(b), nil
+}
@@ -31,6 +31,8 @@ func NewCmdRollingUpdate(f *util.Factory, out io.Writer) *cobra.Command {
 
 	// create subcommands
 	cmd.AddCommand(NewCmdRollingUpdateCluster(f, out))
+	cmd.AddCommand(NewCmdRollingUpdatePause(f, out))
+	cmd.AddCommand(NewCmdRollingUpdateUnpause(f, out))
 
 	return cmd
 }
@@ -18,9 +18,11 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
+	"os/signal"
 	"strconv"
 	"strings"
 	"time"
@@ -33,9 +35,13 @@ import (
 	"k8s.io/cli-runtime/pkg/genericclioptions"
 	"k8s.io/client-go/kubernetes"
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
+	"k8s.io/klog/v2"
 	"k8s.io/kops/cmd/kops/util"
+	"k8s.io/kops/pkg/acls"
 	kopsapi "k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/pkg/client/simple/vfsclientset"
 	"k8s.io/kops/pkg/cloudinstances"
+	"k8s.io/kops/pkg/clusterlock"
 	"k8s.io/kops/pkg/commands/commandutils"
 	"k8s.io/kops/pkg/instancegroups"
 	"k8s.io/kops/pkg/pretty"
@@ -44,6 +50,7 @@ import (
 	"k8s.io/kops/util/pkg/tables"
 	"k8s.io/kubectl/pkg/util/i18n"
 	"k8s.io/kubectl/pkg/util/templates"
+	"sigs.k8s.io/yaml"
 )
 
 var (
@@ -60,12 +67,31 @@ var (
 
 	Note: terraform users will need to run all of the following commands from the same directory
 	` + pretty.Bash("kops update cluster --target=terraform") + ` then ` + pretty.Bash("terraform plan") + ` then
-	` + pretty.Bash("terraform apply") + ` prior to running ` + pretty.Bash("kops rolling-update cluster") + `.`))
+	` + pretty.Bash("terraform apply") + ` prior to running ` + pretty.Bash("kops rolling-update cluster") + `.
+
+	The progress of a rolling update is recorded in the state store. If a rolling update is interrupted,
+	run it again with ` + pretty.Bash("--resume") + ` to continue it with the same options, skipping the instance groups
+	that have already been updated. Use ` + pretty.Bash("kops get rolling-update") + ` to see its progress.
+
+	With ` + pretty.Bash("--in-cluster") + `, the rolling update is instead requested from kops-controller, which performs it
+	from within the cluster and reports its progress in the ` + pretty.Bash("kube-system/kops-rolling-update") + ` ConfigMap
+	and as events. This requires the cluster to have been updated with the ` + pretty.Bash("InClusterRollingUpdate") + ` feature flag,
+	which is only supported on AWS.
+
+	Instances are only replaced during the maintenance windows of their instance group, if it has any. Outside of them,
+	the rolling update stops, so that it can be resumed during the next window, or with
+	` + pretty.Bash("--wait-for-maintenance-window") + `, waits for it. A running rolling update can be paused with
+	` + pretty.Bash("kops rolling-update pause") + ` or by sending SIGUSR1 to kops, upon which it finishes the instances it is
+	replacing and holds until it is unpaused.`))
 
 	rollingupdateExample = templates.Examples(i18n.T(`
 		# Preview a rolling update.
 		kops rolling-update cluster
 
+		# Print the batches of instances a rolling update would replace, the pods it would evict from them
+		# and its estimated duration, as JSON.
+		kops rolling-update cluster --plan -o json
+
 		# Update the currently selected kOps cluster with defaults.
 		# Nodes will be drained and the cluster will be validated between node replacement.
 		kops rolling-update cluster --yes
@@ -86,6 +112,15 @@ var (
 		# Update only the "nodes-1a" instance group of the k8s-cluster.example.com kOps cluster.
 		kops rolling-update cluster k8s-cluster.example.com --yes \
 		  --instance-group nodes-1a
+
+		# Continue an interrupted rolling update of the k8s-cluster.example.com kOps cluster.
+		kops rolling-update cluster k8s-cluster.example.com --yes --resume
+
+		# Ask kops-controller to perform the rolling update from within the cluster.
+		kops rolling-update cluster k8s-cluster.example.com --yes --in-cluster
+
+		# Update the k8s-cluster.example.com kOps cluster, waiting for the maintenance windows of its instance groups.
+		kops rolling-update cluster k8s-cluster.example.com --yes --wait-for-maintenance-window
 		`))
 
 	rollingupdateShort = i18n.T(`Rolling update a cluster.`)
@@ -141,6 +176,31 @@ type RollingUpdateOptions struct {
 	// if not specified, all instance groups will be updated
 	InstanceGroupRoles []string
 
+	// Resume continues the last rolling update, if it did not complete.
+	Resume bool
+
+	// InCluster requests the rolling update from kops-controller, instead of performing it.
+	InCluster bool
+
+	// WaitForMaintenanceWindow waits for the next maintenance window of an instance group, rather than stopping.
+	WaitForMaintenanceWindow bool
+
+	// InstanceHookExec are commands, run with sh -c, around the replacement of each instance.
+	InstanceHookExec []string
+	// InstanceHookWebhooks are URLs that are sent the event around the replacement of each instance.
+	InstanceHookWebhooks []string
+	// InstanceHookPhases are the phases at which the hooks are run, or all phases if empty.
+	InstanceHookPhases []string
+	// InstanceHookFailurePolicy is what to do when a hook fails.
+	InstanceHookFailurePolicy string
+	// AllowSpecExecHooks runs the instance hooks of the cluster and instance group specs that run a command.
+	AllowSpecExecHooks bool
+
+	// Plan prints the predicted schedule of the rolling update instead of a summary of the instance groups.
+	Plan bool
+	// Output is the format of the plan.
+	Output string
+
 	// TODO: Move more/all above options to RollingUpdateOptions
 	instancegroups.RollingUpdateOptions
 }
@@ -156,6 +216,13 @@ func (o *RollingUpdateOptions) InitDefaults() {
 	o.NodeInterval = 15 * time.Second
 	o.BastionInterval = 15 * time.Second
 	o.Interactive = false
+	o.Resume = false
+	o.InCluster = false
+	o.WaitForMaintenanceWindow = false
+	o.InstanceHookFailurePolicy = string(kopsapi.RollingUpdateHookFailurePolicyAbort)
+	o.AllowSpecExecHooks = false
+	o.Plan = false
+	o.Output = OutputTable
 
 	o.PostDrainDelay = 5 * time.Second
 	o.ValidationTimeout = 15 * time.Minute
@@ -186,6 +253,10 @@ func NewCmdRollingUpdateCluster(f *util.Factory, out io.Writer) *cobra.Command {
 	for _, r := range kopsapi.AllInstanceGroupRoles {
 		allRoles = append(allRoles, r.ToLowerString())
 	}
+	allHookPhases := make([]string, 0, len(kopsapi.AllRollingUpdateHookPhases))
+	for _, p := range kopsapi.AllRollingUpdateHookPhases {
+		allHookPhases = append(allHookPhases, string(p))
+	}
 
 	cmd.Flags().BoolVarP(&options.Yes, "yes", "y", options.Yes, "Perform rolling update immediately; without --yes rolling-update executes a dry-run")
 	cmd.Flags().BoolVar(&options.Force, "force", options.Force, "Force rolling update, even if no changes")
@@ -206,6 +277,26 @@ func NewCmdRollingUpdateCluster(f *util.Factory, out io.Writer) *cobra.Command {
 		return sets.NewString(allRoles...).Delete(options.InstanceGroupRoles...).List(), cobra.ShellCompDirectiveNoFileComp
 	})
 
+	cmd.Flags().BoolVar(&options.Resume, "resume", options.Resume, "Continue the last rolling update, if it did not complete, with the options it was started with")
+	cmd.Flags().BoolVar(&options.InCluster, "in-cluster", options.InCluster, "Request the rolling update from kops-controller, which performs it from within the cluster")
+	cmd.Flags().BoolVar(&options.WaitForMaintenanceWindow, "wait-for-maintenance-window", options.WaitForMaintenanceWindow, "Wait for the next maintenance window of an instance group, rather than stopping, when it is outside of them")
+	cmd.Flags().StringArrayVar(&options.InstanceHookExec, "instance-hook-exec", options.InstanceHookExec, "Command to run with sh -c around the replacement of each instance, which is passed the instance on stdin and in KOPS_ environment variables")
+	cmd.Flags().StringArrayVar(&options.InstanceHookWebhooks, "instance-hook-webhook", options.InstanceHookWebhooks, "URL to POST the instance to around the replacement of each instance")
+	cmd.Flags().StringSliceVar(&options.InstanceHookPhases, "instance-hook-phase", options.InstanceHookPhases, "Phases at which to run the instance hooks ("+strings.Join(allHookPhases, ",")+"; defaults to all if not specified)")
+	cmd.RegisterFlagCompletionFunc("instance-hook-phase", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return sets.NewString(allHookPhases...).Delete(options.InstanceHookPhases...).List(), cobra.ShellCompDirectiveNoFileComp
+	})
+	cmd.Flags().StringVar(&options.InstanceHookFailurePolicy, "instance-hook-failure-policy", options.InstanceHookFailurePolicy, "What to do when an instance hook fails (Abort, Block or Ignore)")
+	cmd.RegisterFlagCompletionFunc("instance-hook-failure-policy", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{string(kopsapi.RollingUpdateHookFailurePolicyAbort), string(kopsapi.RollingUpdateHookFailurePolicyBlock), string(kopsapi.RollingUpdateHookFailurePolicyIgnore)}, cobra.ShellCompDirectiveNoFileComp
+	})
+	cmd.Flags().BoolVar(&options.AllowSpecExecHooks, "allow-spec-exec-hooks", options.AllowSpecExecHooks, "Run the instance hooks of the cluster and instance group specs that run a command on this machine")
+	cmd.Flags().BoolVar(&options.Plan, "plan", options.Plan, "Print the instances that will be replaced in each batch, the pods that will be evicted from them, the PodDisruptionBudgets that would block and the estimated duration, without updating anything")
+	cmd.Flags().StringVarP(&options.Output, "output", "o", options.Output, "Output format of the plan. One of json|yaml|table.")
+	cmd.RegisterFlagCompletionFunc("output", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{OutputTable, OutputJSON, OutputYaml}, cobra.ShellCompDirectiveNoFileComp
+	})
+
 	cmd.Flags().BoolVar(&options.FailOnDrainError, "fail-on-drain-error", true, "Fail if draining a node fails")
 	cmd.Flags().BoolVar(&options.FailOnValidate, "fail-on-validate-error", true, "Fail if the cluster fails to validate")
 
@@ -225,6 +316,42 @@ func NewCmdRollingUpdateCluster(f *util.Factory, out io.Writer) *cobra.Command {
 }
 
 func RunRollingUpdateCluster(ctx context.Context, f *util.Factory, out io.Writer, options *RollingUpdateOptions) error {
+	if options.Plan {
+		if options.Yes {
+			return fmt.Errorf("--plan cannot be used with --yes")
+		}
+		switch options.Output {
+		case OutputTable, OutputJSON, OutputYaml:
+		default:
+			return fmt.Errorf("unknown output format: %q", options.Output)
+		}
+	}
+	if options.InCluster {
+		if options.CloudOnly {
+			return fmt.Errorf("--in-cluster cannot be used with --cloudonly")
+		}
+		if options.Resume {
+			return fmt.Errorf("--in-cluster cannot be used with --resume; kops-controller resumes interrupted rolling updates itself")
+		}
+		if options.Interactive {
+			return fmt.Errorf("--in-cluster cannot be used with --interactive")
+		}
+		if options.WaitForMaintenanceWindow {
+			return fmt.Errorf("--in-cluster cannot be used with --wait-for-maintenance-window; kops-controller always waits for maintenance windows")
+		}
+		if len(options.InstanceHookExec) != 0 || len(options.InstanceHookWebhooks) != 0 {
+			return fmt.Errorf("--in-cluster cannot be used with --instance-hook-exec or --instance-hook-webhook; configure instanceHooks in the rollingUpdate of the cluster instead")
+		}
+		if options.AllowSpecExecHooks {
+			return fmt.Errorf("--in-cluster cannot be used with --allow-spec-exec-hooks; kops-controller does not run instance hooks that run a command")
+		}
+	}
+
+	instanceHooks, err := buildInstanceHooks(options)
+	if err != nil {
+		return err
+	}
+
 	clientset, err := f.KopsClient()
 	if err != nil {
 		return err
@@ -234,6 +361,72 @@ func RunRollingUpdateCluster(ctx context.Context, f *util.Factory, out io.Writer
 	if err != nil {
 		return err
 	}
+	if options.InCluster && cluster.Spec.GetCloudProvider() != kopsapi.CloudProviderAWS {
+		return fmt.Errorf("--in-cluster is not supported on %s", cluster.Spec.GetCloudProvider())
+	}
+
+	if options.Yes {
+		lock, err := clusterlock.AcquireForCluster(ctx, cluster, "rolling-update cluster")
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if err := lock.Release(context.Background()); err != nil {
+				klog.Warningf("error releasing cluster lock: %v", err)
+			}
+		}()
+
+		var cancel context.CancelFunc
+		ctx, cancel = lock.WithContext(ctx)
+		defer cancel()
+		ctx = vfsclientset.WithRevisionAuthor(ctx, lock.Holder())
+	}
+
+	progressPath, err := instancegroups.RollingUpdateProgressPath(cluster)
+	if err != nil {
+		return err
+	}
+	previous, err := instancegroups.ReadRollingUpdateProgress(ctx, progressPath)
+	if err != nil {
+		return err
+	}
+	pausePath, err := instancegroups.RollingUpdatePausePath(cluster)
+	if err != nil {
+		return err
+	}
+	progress := previous
+	if options.Resume {
+		if previous == nil {
+			return fmt.Errorf("no rolling update of cluster %q to resume", cluster.ObjectMeta.Name)
+		}
+		if previous.IsComplete() {
+			return fmt.Errorf("the last rolling update of cluster %q completed at %s; there is nothing to resume", cluster.ObjectMeta.Name, previous.CompletionTime.Format(time.RFC3339))
+		}
+		options.Force = options.Force || previous.Force
+		options.CloudOnly = options.CloudOnly || previous.CloudOnly
+		if len(options.InstanceGroups) == 0 {
+			options.InstanceGroups = previous.InstanceGroups
+		}
+		if len(options.InstanceGroupRoles) == 0 {
+			options.InstanceGroupRoles = previous.InstanceGroupRoles
+		}
+		fmt.Fprintf(out, "Resuming the rolling update started at %s; %d instance groups already updated\n", previous.StartTime.Format(time.RFC3339), len(previous.CompletedGroups))
+	} else {
+		if previous != nil && !previous.IsComplete() {
+			fmt.Fprintf(out, "The rolling update started at %s did not complete; starting a new rolling update. Use --resume to continue it instead.\n", previous.StartTime.Format(time.RFC3339))
+		}
+		progress = &instancegroups.RollingUpdateProgress{
+			Cluster:            cluster.ObjectMeta.Name,
+			StartTime:          time.Now().UTC(),
+			Force:              options.Force,
+			CloudOnly:          options.CloudOnly,
+			InstanceGroups:     options.InstanceGroups,
+			InstanceGroupRoles: options.InstanceGroupRoles,
+		}
+		if previous != nil {
+			progress.KnownGoodTemplates = previous.KnownGoodTemplates
+		}
+	}
 
 	contextName := cluster.ObjectMeta.Name
 	clientGetter := genericclioptions.NewConfigFlags(true)
@@ -368,6 +561,11 @@ func RunRollingUpdateCluster(ctx context.Context, f *util.Factory, out io.Writer
 
 		// TODO: Move more of the passthrough options here, instead of duplicating them.
 		Options: options.RollingUpdateOptions,
+
+		PausePath:                pausePath,
+		WaitForMaintenanceWindow: options.WaitForMaintenanceWindow,
+		InstanceHooks:            instanceHooks,
+		AllowSpecExecHooks:       options.AllowSpecExecHooks,
 	}
 
 	err = d.AdjustNeedUpdate(groups)
@@ -375,6 +573,19 @@ func RunRollingUpdateCluster(ctx context.Context, f *util.Factory, out io.Writer
 		return err
 	}
 
+	if options.Plan {
+		// The progress is only read, to skip the instance groups a resumed rolling update would skip
+		d.Progress, err = instancegroups.NewProgressRecorder(ctx, nil, nil, progress)
+		if err != nil {
+			return err
+		}
+		plan, err := d.Plan(groups)
+		if err != nil {
+			return err
+		}
+		return writeRollingUpdatePlan(plan, options.Output, out)
+	}
+
 	{
 		t := &tables.Table{}
 		t.AddColumn("NAME", func(r *cloudinstances.CloudInstanceGroup) string {
@@ -434,28 +645,211 @@ func RunRollingUpdateCluster(ctx context.Context, f *util.Factory, out io.Writer
 		}
 	}
 
-	if !needUpdate && !options.Force {
-		fmt.Printf("\nNo rolling-update required.\n")
+	if !options.Yes {
+		if !needUpdate && !options.Force {
+			fmt.Printf("\nNo rolling-update required.\n")
+			return nil
+		}
+		fmt.Printf("\nMust specify --yes to rolling-update.\n")
 		return nil
 	}
 
-	if !options.Yes {
-		fmt.Printf("\nMust specify --yes to rolling-update.\n")
+	if options.InCluster {
+		if !needUpdate && !options.Force {
+			fmt.Printf("\nNo rolling-update required.\n")
+			return nil
+		}
+		request := &instancegroups.RollingUpdateRequest{
+			RequestTime:        time.Now().UTC(),
+			Force:              options.Force,
+			InstanceGroups:     options.InstanceGroups,
+			InstanceGroupRoles: options.InstanceGroupRoles,
+		}
+		if err := instancegroups.RequestInClusterRollingUpdate(ctx, k8sClient, request); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "\nRequested rolling update from kops-controller; its progress is reported in the %s/%s ConfigMap and as events.\n", instancegroups.InClusterConfigMapNamespace, instancegroups.InClusterConfigMapName)
+		return nil
+	}
+
+	acl, err := acls.GetACL(ctx, progressPath, cluster)
+	if err != nil {
+		return err
+	}
+	d.Progress, err = instancegroups.NewProgressRecorder(ctx, progressPath, acl, progress)
+	if err != nil {
+		return err
+	}
+
+	if !needUpdate && !options.Force {
+		fmt.Printf("\nNo rolling-update required.\n")
+		d.Progress.Finished(nil)
 		return nil
 	}
 
 	var clusterValidator validation.ClusterValidator
 	if !options.CloudOnly {
-		clusterValidator, err = validation.NewClusterValidator(cluster, cloud, list, config.Host, k8sClient)
+		keyStore, err := clientset.KeyStore(cluster)
+		if err != nil {
+			return err
+		}
+		clusterValidator, err = validation.NewClusterValidator(cluster, cloud, list, config, k8sClient, keyStore, false)
 		if err != nil {
 			return fmt.Errorf("cannot create cluster validator: %v", err)
 		}
 	}
 	d.ClusterValidator = clusterValidator
 
+	stopPauseSignal := pauseOnSignal(d)
+	defer stopPauseSignal()
+
 	return d.RollingUpdate(groups, list)
 }
 
+// rollingUpdatePlanRow is a row of the table of a rolling update plan: an instance, with its batch.
+type rollingUpdatePlanRow struct {
+	group    *instancegroups.InstanceGroupPlan
+	batch    int
+	blocking []string
+	instance *instancegroups.InstancePlan
+}
+
+func writeRollingUpdatePlan(plan *instancegroups.RollingUpdatePlan, output string, out io.Writer) error {
+	switch output {
+	case OutputYaml:
+		y, err := yaml.Marshal(plan)
+		if err != nil {
+			return fmt.Errorf("unable to marshal YAML: %v", err)
+		}
+		if _, err := out.Write(y); err != nil {
+			return fmt.Errorf("error writing to output: %v", err)
+		}
+		return nil
+	case OutputJSON:
+		j, err := json.Marshal(plan)
+		if err != nil {
+			return fmt.Errorf("unable to marshal JSON: %v", err)
+		}
+		if _, err := out.Write(j); err != nil {
+			return fmt.Errorf("error writing to output: %v", err)
+		}
+		return nil
+	}
+
+	if len(plan.InstanceGroups) == 0 {
+		fmt.Fprintf(out, "No rolling-update required.\n")
+		return nil
+	}
+
+	groups := &tables.Table{}
+	groups.AddColumn("NAME", func(g *instancegroups.InstanceGroupPlan) string {
+		return g.Name
+	})
+	groups.AddColumn("ROLE", func(g *instancegroups.InstanceGroupPlan) string {
+		return string(g.Role)
+	})
+	groups.AddColumn("STRATEGY", func(g *instancegroups.InstanceGroupPlan) string {
+		return string(g.Strategy)
+	})
+	groups.AddColumn("MAXSURGE", func(g *instancegroups.InstanceGroupPlan) string {
+		return strconv.Itoa(g.MaxSurge)
+	})
+	groups.AddColumn("CONCURRENCY", func(g *instancegroups.InstanceGroupPlan) string {
+		return strconv.Itoa(g.MaxConcurrency)
+	})
+	groups.AddColumn("BATCHES", func(g *instancegroups.InstanceGroupPlan) string {
+		return strconv.Itoa(len(g.Batches))
+	})
+	groups.AddColumn("DURATION", func(g *instancegroups.InstanceGroupPlan) string {
+		return g.EstimatedDuration.Duration.String()
+	})
+	groups.AddColumn("NOTE", func(g *instancegroups.InstanceGroupPlan) string {
+		return g.Note
+	})
+	if err := groups.Render(plan.InstanceGroups, out, "NAME", "ROLE", "STRATEGY", "MAXSURGE", "CONCURRENCY", "BATCHES", "DURATION", "NOTE"); err != nil {
+		return err
+	}
+
+	var rows []*rollingUpdatePlanRow
+	for _, g := range plan.InstanceGroups {
+		for i, batch := range g.Batches {
+			for _, instance := range batch.Instances {
+				rows = append(rows, &rollingUpdatePlanRow{
+					group:    g,
+					batch:    i + 1,
+					blocking: batch.BlockingPodDisruptionBudgets,
+					instance: instance,
+				})
+			}
+		}
+	}
+
+	instances := &tables.Table{}
+	instances.AddColumn("INSTANCEGROUP", func(r *rollingUpdatePlanRow) string {
+		return r.group.Name
+	})
+	instances.AddColumn("BATCH", func(r *rollingUpdatePlanRow) string {
+		return strconv.Itoa(r.batch)
+	})
+	instances.AddColumn("INSTANCE", func(r *rollingUpdatePlanRow) string {
+		return r.instance.ID
+	})
+	instances.AddColumn("NODE", func(r *rollingUpdatePlanRow) string {
+		return r.instance.Node
+	})
+	instances.AddColumn("SURGE", func(r *rollingUpdatePlanRow) string {
+		return strconv.FormatBool(r.instance.Detached)
+	})
+	instances.AddColumn("EVICTIONS", func(r *rollingUpdatePlanRow) string {
+		return strings.Join(r.instance.Evictions, ",")
+	})
+	instances.AddColumn("BLOCKING-PDBS", func(r *rollingUpdatePlanRow) string {
+		return strings.Join(r.blocking, ",")
+	})
+	if len(rows) != 0 {
+		fmt.Fprintf(out, "\n")
+		if err := instances.Render(rows, out, "INSTANCEGROUP", "BATCH", "INSTANCE", "NODE", "SURGE", "EVICTIONS", "BLOCKING-PDBS"); err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprintf(out, "\nEstimated duration: %s, not including the time to drain nodes and for their replacements to join the cluster.\n", plan.EstimatedDuration.Duration)
+	return nil
+}
+
+// pauseOnSignal pauses the rolling update when kops receives pauseSignal, and unpauses it when it receives it again.
+// The returned function stops handling the signal.
+func pauseOnSignal(d *instancegroups.RollingUpdateCluster) func() {
+	if pauseSignal == nil {
+		return func() {}
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, pauseSignal)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-signals:
+				paused := !d.Paused.Load()
+				d.Paused.Store(paused)
+				if paused {
+					klog.Infof("Pausing the rolling update once the instances being replaced are finished; send %v again to unpause it", pauseSignal)
+				} else {
+					klog.Infof("Unpausing the rolling update")
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		signal.Stop(signals)
+		close(done)
+	}
+}
+
 func completeInstanceGroup(f commandutils.Factory, selectedInstanceGroups *[]string, selectedInstanceGroupRoles *[]string) func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 		ctx := cmd.Context()
@@ -490,3 +884,51 @@ func completeInstanceGroup(f commandutils.Factory, selectedInstanceGroups *[]str
 		return igs, cobra.ShellCompDirectiveNoFileComp
 	}
 }
+
+// buildInstanceHooks returns the instance hooks configured by the flags.
+func buildInstanceHooks(options *RollingUpdateOptions) ([]kopsapi.RollingUpdateHook, error) {
+	var phases []kopsapi.RollingUpdateHookPhase
+	for _, phase := range options.InstanceHookPhases {
+		found := false
+		for _, p := range kopsapi.AllRollingUpdateHookPhases {
+			if strings.EqualFold(phase, string(p)) {
+				phases = append(phases, p)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("invalid --instance-hook-phase %q", phase)
+		}
+	}
+
+	var failurePolicy kopsapi.RollingUpdateHookFailurePolicy
+	switch policy := kopsapi.RollingUpdateHookFailurePolicy(options.InstanceHookFailurePolicy); policy {
+	case kopsapi.RollingUpdateHookFailurePolicyAbort, kopsapi.RollingUpdateHookFailurePolicyBlock, kopsapi.RollingUpdateHookFailurePolicyIgnore:
+		failurePolicy = policy
+	default:
+		return nil, fmt.Errorf("invalid --instance-hook-failure-policy %q", options.InstanceHookFailurePolicy)
+	}
+
+	var hooks []kopsapi.RollingUpdateHook
+	for i, command := range options.InstanceHookExec {
+		hooks = append(hooks, kopsapi.RollingUpdateHook{
+			Name:          fmt.Sprintf("instance-hook-exec-%d", i+1),
+			Phases:        phases,
+			Exec:          &kopsapi.ExecRollingUpdateHook{Command: []string{"sh", "-c", command}},
+			FailurePolicy: failurePolicy,
+		})
+	}
+	for i, url := range options.InstanceHookWebhooks {
+		if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+			return nil, fmt.Errorf("invalid --instance-hook-webhook %q: must be an http or https URL", url)
+		}
+		hooks = append(hooks, kopsapi.RollingUpdateHook{
+			Name:          fmt.Sprintf("instance-hook-webhook-%d", i+1),
+			Phases:        phases,
+			Webhook:       &kopsapi.WebhookRollingUpdateHook{URL: url},
+			FailurePolicy: failurePolicy,
+		})
+	}
+	return hooks, nil
+}
@@ -0,0 +1,89 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+	"k8s.io/kops/cmd/kops/util"
+	"k8s.io/kops/pkg/acls"
+	"k8s.io/kops/pkg/commands/commandutils"
+	"k8s.io/kops/pkg/instancegroups"
+	"k8s.io/kubectl/pkg/util/i18n"
+	"k8s.io/kubectl/pkg/util/templates"
+)
+
+var (
+	rollingUpdatePauseLong = templates.LongDesc(i18n.T(`
+	Pause the rolling updates of a cluster. A running rolling update, whether performed by kops or by kops-controller,
+	finishes the instances it is replacing and then holds until the cluster is unpaused with kops rolling-update unpause.
+
+	A rolling update performed by kops can also be paused and unpaused by sending SIGUSR1 to the kops process.`))
+
+	rollingUpdatePauseExample = templates.Examples(i18n.T(`
+	# Pause the rolling update of a cluster
+	kops rolling-update pause k8s-cluster.example.com
+	`))
+
+	rollingUpdatePauseShort = i18n.T(`Pause the rolling updates of a cluster.`)
+)
+
+type RollingUpdatePauseOptions struct {
+	ClusterName string
+}
+
+func NewCmdRollingUpdatePause(f *util.Factory, out io.Writer) *cobra.Command {
+	options := &RollingUpdatePauseOptions{}
+
+	cmd := &cobra.Command{
+		Use:               "pause [CLUSTER]",
+		Short:             rollingUpdatePauseShort,
+		Long:              rollingUpdatePauseLong,
+		Example:           rollingUpdatePauseExample,
+		Args:              rootCommand.clusterNameArgs(&options.ClusterName),
+		ValidArgsFunction: commandutils.CompleteClusterName(f, true, false),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return RunRollingUpdatePause(cmd.Context(), f, out, options)
+		},
+	}
+
+	return cmd
+}
+
+func RunRollingUpdatePause(ctx context.Context, f *util.Factory, out io.Writer, options *RollingUpdatePauseOptions) error {
+	cluster, err := GetCluster(ctx, f, options.ClusterName)
+	if err != nil {
+		return err
+	}
+
+	pausePath, err := instancegroups.RollingUpdatePausePath(cluster)
+	if err != nil {
+		return err
+	}
+	acl, err := acls.GetACL(ctx, pausePath, cluster)
+	if err != nil {
+		return err
+	}
+	if err := instancegroups.PauseRollingUpdate(ctx, pausePath, acl); err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "Paused rolling updates of cluster %q; a running rolling update will hold once the instances it is replacing are finished\n", cluster.ObjectMeta.Name)
+	return nil
+}
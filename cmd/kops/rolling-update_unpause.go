@@ -0,0 +1,82 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+	"k8s.io/kops/cmd/kops/util"
+	"k8s.io/kops/pkg/commands/commandutils"
+	"k8s.io/kops/pkg/instancegroups"
+	"k8s.io/kubectl/pkg/util/i18n"
+	"k8s.io/kubectl/pkg/util/templates"
+)
+
+var (
+	rollingUpdateUnpauseLong = templates.LongDesc(i18n.T(`
+	Unpause the rolling updates of a cluster that were paused with kops rolling-update pause.
+	A rolling update that is holding continues with the next instance.`))
+
+	rollingUpdateUnpauseExample = templates.Examples(i18n.T(`
+	# Continue the paused rolling update of a cluster
+	kops rolling-update unpause k8s-cluster.example.com
+	`))
+
+	rollingUpdateUnpauseShort = i18n.T(`Unpause the rolling updates of a cluster.`)
+)
+
+type RollingUpdateUnpauseOptions struct {
+	ClusterName string
+}
+
+func NewCmdRollingUpdateUnpause(f *util.Factory, out io.Writer) *cobra.Command {
+	options := &RollingUpdateUnpauseOptions{}
+
+	cmd := &cobra.Command{
+		Use:               "unpause [CLUSTER]",
+		Short:             rollingUpdateUnpauseShort,
+		Long:              rollingUpdateUnpauseLong,
+		Example:           rollingUpdateUnpauseExample,
+		Args:              rootCommand.clusterNameArgs(&options.ClusterName),
+		ValidArgsFunction: commandutils.CompleteClusterName(f, true, false),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return RunRollingUpdateUnpause(cmd.Context(), f, out, options)
+		},
+	}
+
+	return cmd
+}
+
+func RunRollingUpdateUnpause(ctx context.Context, f *util.Factory, out io.Writer, options *RollingUpdateUnpauseOptions) error {
+	cluster, err := GetCluster(ctx, f, options.ClusterName)
+	if err != nil {
+		return err
+	}
+
+	pausePath, err := instancegroups.RollingUpdatePausePath(cluster)
+	if err != nil {
+		return err
+	}
+	if err := instancegroups.UnpauseRollingUpdate(ctx, pausePath); err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "Unpaused rolling updates of cluster %q\n", cluster.ObjectMeta.Name)
+	return nil
+}
@@ -0,0 +1,458 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"k8s.io/klog/v2"
+	"k8s.io/kops/cmd/kops/util"
+	"k8s.io/kops/pkg/acls"
+	kopsapi "k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/pkg/carotation"
+	"k8s.io/kops/pkg/client/simple/vfsclientset"
+	"k8s.io/kops/pkg/clusterlock"
+	"k8s.io/kops/pkg/commands/commandutils"
+	"k8s.io/kops/pkg/kubeconfig"
+	"k8s.io/kops/upup/pkg/fi"
+	"k8s.io/kops/util/pkg/tables"
+	"k8s.io/kubectl/pkg/util/i18n"
+	"k8s.io/kubectl/pkg/util/templates"
+)
+
+var (
+	rotateCALong = templates.LongDesc(i18n.T(`
+	Rotate the keypairs of a keyset, running each step of the procedure described in
+	the documentation on rotating secrets in order:
+
+	* add-secondary: add a new secondary keypair to the keyset.
+	* roll-secondary: update the cluster and roll the control plane and nodes, so that the new keypair is trusted.
+	* promote: promote the new keypair to primary.
+	* export-kubeconfig: export a kubeconfig that trusts the new keypair.
+	* roll-primary: update the cluster and roll it again, so that everything is issued by the new keypair.
+	* distrust: distrust the keypairs the rotation replaced, then update and roll the cluster to remove them from trust stores.
+
+	The progress of the rotation is recorded in the state store. If the command is interrupted,
+	or a phase fails, run it again to resume from the first phase that did not complete.
+	Use --phase to run a single phase, for example to distribute the exported kubeconfig
+	before promoting the new keypairs.
+
+	If the keyset is specified as "all", each rotatable keyset is rotated.
+	`))
+
+	rotateCAExample = templates.Examples(i18n.T(`
+	# Show the progress of a rotation of the cluster CA.
+	kops rotate ca kubernetes-ca --name k8s-cluster.example.com
+
+	# Run all the remaining phases of a rotation of the cluster CA.
+	kops rotate ca kubernetes-ca --name k8s-cluster.example.com --yes
+
+	# Rotate all rotatable keysets one phase at a time.
+	kops rotate ca all --phase add-secondary --yes
+	kops rotate ca all --phase roll-secondary --yes
+	`))
+
+	rotateCAShort = i18n.T(`Rotate the keypairs of a keyset.`)
+)
+
+type RotateCAOptions struct {
+	ClusterName string
+	Keyset      string
+
+	// Phase is the single phase to run; if not specified, all remaining phases are run.
+	Phase string
+	Yes   bool
+
+	// KeyAlgorithm is the algorithm of the new private keys.
+	KeyAlgorithm string
+
+	// Admin is the lifetime of the admin credential in the exported kubeconfig; if zero, none is exported.
+	Admin time.Duration
+
+	// RollingUpdate are the options of the rolling updates of the cluster.
+	RollingUpdate RollingUpdateOptions
+}
+
+func (o *RotateCAOptions) InitDefaults() {
+	o.RollingUpdate.InitDefaults()
+	o.RollingUpdate.FailOnDrainError = true
+}
+
+// NewCmdRotateCA returns a rotate ca command.
+func NewCmdRotateCA(f *util.Factory, out io.Writer) *cobra.Command {
+	options := &RotateCAOptions{}
+	options.InitDefaults()
+
+	cmd := &cobra.Command{
+		Use:     "ca {KEYSET | all}",
+		Short:   rotateCAShort,
+		Long:    rotateCALong,
+		Example: rotateCAExample,
+		Args: func(cmd *cobra.Command, args []string) error {
+			options.ClusterName = rootCommand.ClusterName(true)
+
+			if options.ClusterName == "" {
+				return fmt.Errorf("--name is required")
+			}
+
+			if len(args) == 0 {
+				return fmt.Errorf("must specify name of keyset to rotate")
+			}
+			if len(args) != 1 {
+				return fmt.Errorf("can only rotate one keyset at a time")
+			}
+
+			options.Keyset = args[0]
+
+			return nil
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return completeRotateCA(cmd.Context(), f, args)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return RunRotateCA(cmd.Context(), f, out, options)
+		},
+	}
+
+	cmd.Flags().StringVar(&options.Phase, "phase", options.Phase, "Phase of the rotation to run: "+strings.Join(carotation.PhaseNames(), ", ")+". Defaults to all remaining phases")
+	cmd.RegisterFlagCompletionFunc("phase", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return carotation.PhaseNames(), cobra.ShellCompDirectiveNoFileComp
+	})
+	cmd.Flags().BoolVarP(&options.Yes, "yes", "y", options.Yes, "Run the phases; without --yes only the progress of the rotation is shown")
+	cmd.Flags().StringVar(&options.KeyAlgorithm, "key-algorithm", options.KeyAlgorithm, "Algorithm of the new private keys: RSA, ECDSA-P256, ECDSA-P384 or Ed25519. Defaults to the algorithm configured for each keyset in the cluster spec")
+	cmd.RegisterFlagCompletionFunc("key-algorithm", completeKeyAlgorithm)
+	cmd.Flags().DurationVar(&options.Admin, "admin", options.Admin, "Also export a cluster admin user credential with the specified lifetime when exporting the kubeconfig")
+	cmd.Flags().Lookup("admin").NoOptDefVal = kubeconfig.DefaultKubecfgAdminLifetime.String()
+
+	cmd.Flags().BoolVar(&options.RollingUpdate.CloudOnly, "cloudonly", options.RollingUpdate.CloudOnly, "Perform the rolling updates without confirming progress with Kubernetes")
+	cmd.Flags().DurationVar(&options.RollingUpdate.ValidationTimeout, "validation-timeout", options.RollingUpdate.ValidationTimeout, "Maximum time to wait for a cluster to validate")
+	cmd.Flags().DurationVar(&options.RollingUpdate.DrainTimeout, "drain-timeout", options.RollingUpdate.DrainTimeout, "Maximum time to wait for a node to drain")
+	cmd.Flags().DurationVar(&options.RollingUpdate.ControlPlaneInterval, "control-plane-interval", options.RollingUpdate.ControlPlaneInterval, "Time to wait between restarting control plane nodes")
+	cmd.Flags().DurationVar(&options.RollingUpdate.NodeInterval, "node-interval", options.RollingUpdate.NodeInterval, "Time to wait between restarting worker nodes")
+	cmd.Flags().BoolVar(&options.RollingUpdate.FailOnValidate, "fail-on-validate-error", options.RollingUpdate.FailOnValidate, "Fail if the cluster fails to validate")
+
+	return cmd
+}
+
+// RunRotateCA runs the phases of a rotation of a keyset.
+func RunRotateCA(ctx context.Context, f *util.Factory, out io.Writer, options *RotateCAOptions) error {
+	if !rotatableKeysetFilter(options.Keyset, nil) {
+		return fmt.Errorf("rotating %q is not supported", options.Keyset)
+	}
+
+	cluster, err := GetCluster(ctx, f, options.ClusterName)
+	if err != nil {
+		return err
+	}
+
+	if options.Yes {
+		// The update and rolling update run by the phases reuse the lock
+		lock, err := clusterlock.AcquireForCluster(ctx, cluster, "rotate ca")
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if err := lock.Release(context.Background()); err != nil {
+				klog.Warningf("error releasing cluster lock: %v", err)
+			}
+		}()
+
+		var cancel context.CancelFunc
+		ctx, cancel = lock.WithContext(ctx)
+		defer cancel()
+		ctx = vfsclientset.WithRevisionAuthor(ctx, lock.Holder())
+	}
+
+	progressPath, err := carotation.ProgressPath(cluster, options.Keyset)
+	if err != nil {
+		return err
+	}
+	progress, err := carotation.Read(ctx, progressPath)
+	if err != nil {
+		return err
+	}
+	if progress == nil {
+		progress = carotation.NewProgress(options.Keyset, time.Now())
+	}
+
+	var phases []carotation.Phase
+	if options.Phase != "" {
+		phase, err := carotation.ParsePhase(options.Phase)
+		if err != nil {
+			return err
+		}
+		if err := progress.CheckNextPhase(phase); err != nil {
+			return err
+		}
+		phases = append(phases, phase)
+	} else {
+		for _, phase := range carotation.Phases {
+			if !progress.IsCompleted(phase) {
+				phases = append(phases, phase)
+			}
+		}
+	}
+
+	if !options.Yes {
+		if err := printRotationProgress(out, progress); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "\nMust specify --yes to run phases: %s\n", joinPhases(phases))
+		return nil
+	}
+
+	acl, err := acls.GetACL(ctx, progressPath, cluster)
+	if err != nil {
+		return err
+	}
+	saveProgress := func() error {
+		return carotation.Write(ctx, progressPath, progress, acl)
+	}
+
+	for _, phase := range phases {
+		fmt.Fprintf(out, "\nRunning phase %q of the rotation of %s\n", phase, options.Keyset)
+		if err := runRotateCAPhase(ctx, f, out, options, cluster, progress, phase, saveProgress); err != nil {
+			return fmt.Errorf("phase %q of the rotation of %s failed; run kops rotate ca again to resume: %w", phase, options.Keyset, err)
+		}
+		progress.MarkCompleted(phase, time.Now())
+		if err := saveProgress(); err != nil {
+			return err
+		}
+	}
+
+	if progress.NextPhase() != "" {
+		fmt.Fprintf(out, "\nNext phase of the rotation of %s is %q\n", options.Keyset, progress.NextPhase())
+		return nil
+	}
+
+	if err := carotation.Delete(ctx, progressPath); err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "\nRotation of %s is complete\n", options.Keyset)
+	return nil
+}
+
+func runRotateCAPhase(ctx context.Context, f *util.Factory, out io.Writer, options *RotateCAOptions, cluster *kopsapi.Cluster, progress *carotation.Progress, phase carotation.Phase, saveProgress func() error) error {
+	clientset, err := f.KopsClient()
+	if err != nil {
+		return err
+	}
+
+	keyStore, err := clientset.KeyStore(cluster)
+	if err != nil {
+		return fmt.Errorf("getting keystore: %v", err)
+	}
+
+	switch phase {
+	case carotation.PhaseAddSecondary:
+		return addSecondaryKeypairs(ctx, out, options, cluster, keyStore, progress, saveProgress)
+
+	case carotation.PhaseRollSecondary, carotation.PhaseRollPrimary:
+		return updateAndRollCluster(ctx, f, out, options)
+
+	case carotation.PhasePromote:
+		for _, name := range progress.KeysetNames() {
+			if err := promoteKeypair(ctx, out, name, progress.NewKeypairs[name], keyStore); err != nil {
+				return fmt.Errorf("promoting keypair for %s: %v", name, err)
+			}
+		}
+		return nil
+
+	case carotation.PhaseExportKubeconfig:
+		return exportRotatedKubeconfig(ctx, f, out, options)
+
+	case carotation.PhaseDistrust:
+		if err := distrustPreviousPrimaries(ctx, out, keyStore, progress); err != nil {
+			return err
+		}
+		if err := updateAndRollCluster(ctx, f, out, options); err != nil {
+			return err
+		}
+		return exportRotatedKubeconfig(ctx, f, out, options)
+
+	default:
+		return fmt.Errorf("unknown phase %q", phase)
+	}
+}
+
+// addSecondaryKeypairs adds a new keypair to each keyset being rotated, recording it as soon as it is created
+// so that a resumed rotation does not add another.
+func addSecondaryKeypairs(ctx context.Context, out io.Writer, options *RotateCAOptions, cluster *kopsapi.Cluster, keyStore fi.CAStore, progress *carotation.Progress, saveProgress func() error) error {
+	names := []string{options.Keyset}
+	if options.Keyset == "all" {
+		keysets, err := keyStore.ListKeysets()
+		if err != nil {
+			return fmt.Errorf("listing keysets: %v", err)
+		}
+		names = nil
+		for name := range keysets {
+			if rotatableKeysetFilter(name, nil) {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+	}
+
+	if progress.PreviousPrimaries == nil {
+		progress.PreviousPrimaries = make(map[string]string)
+	}
+	if progress.NewKeypairs == nil {
+		progress.NewKeypairs = make(map[string]string)
+	}
+
+	for _, name := range names {
+		if id, found := progress.NewKeypairs[name]; found {
+			fmt.Fprintf(out, "Already created %s %s\n", name, id)
+			continue
+		}
+
+		keyset, err := keyStore.FindKeyset(ctx, name)
+		if err != nil {
+			return fmt.Errorf("reading keyset %s: %v", name, err)
+		} else if keyset == nil || keyset.Primary == nil {
+			return fmt.Errorf("keyset %s not found", name)
+		}
+
+		createOptions := &CreateKeypairOptions{
+			ClusterName:  options.ClusterName,
+			Keyset:       name,
+			KeyAlgorithm: options.KeyAlgorithm,
+		}
+		item, err := createKeypair(ctx, out, createOptions, cluster, name, keyStore)
+		if err != nil {
+			return fmt.Errorf("creating keypair for %s: %v", name, err)
+		}
+
+		progress.PreviousPrimaries[name] = keyset.Primary.Id
+		progress.NewKeypairs[name] = item.Id
+		if err := saveProgress(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// distrustPreviousPrimaries distrusts the keypair each rotated keyset had as its primary before the rotation,
+// leaving any other keypair the keyset trusts alone.
+func distrustPreviousPrimaries(ctx context.Context, out io.Writer, keyStore fi.CAStore, progress *carotation.Progress) error {
+	for _, name := range progress.KeysetNames() {
+		id := progress.PreviousPrimaries[name]
+		if id == "" {
+			klog.Infof("No previous primary recorded for %s.", name)
+			continue
+		}
+		if err := distrustKeypair(ctx, out, name, []string{id}, keyStore); err != nil {
+			return fmt.Errorf("distrusting keypair for %s: %v", name, err)
+		}
+	}
+	return nil
+}
+
+// updateAndRollCluster applies the keysets to the cluster, and replaces all of its instances,
+// control plane first.
+func updateAndRollCluster(ctx context.Context, f *util.Factory, out io.Writer, options *RotateCAOptions) error {
+	updateOptions := &UpdateClusterOptions{}
+	updateOptions.InitDefaults()
+	updateOptions.ClusterName = options.ClusterName
+	updateOptions.Yes = true
+	updateOptions.CreateKubecfg = false
+	if _, err := RunUpdateCluster(ctx, f, out, updateOptions); err != nil {
+		return fmt.Errorf("updating cluster: %w", err)
+	}
+
+	rollingUpdateOptions := options.RollingUpdate
+	rollingUpdateOptions.ClusterName = options.ClusterName
+	rollingUpdateOptions.Yes = true
+	rollingUpdateOptions.Force = true
+	if err := RunRollingUpdateCluster(ctx, f, out, &rollingUpdateOptions); err != nil {
+		return fmt.Errorf("rolling update of cluster: %w", err)
+	}
+	return nil
+}
+
+// exportRotatedKubeconfig exports a kubeconfig with the trusted keypairs of the cluster CA,
+// if the cluster CA is being rotated.
+func exportRotatedKubeconfig(ctx context.Context, f *util.Factory, out io.Writer, options *RotateCAOptions) error {
+	if options.Keyset != "all" && options.Keyset != fi.CertificateIDCA {
+		fmt.Fprintf(out, "Keyset %s is not used in kubeconfig; not exporting\n", options.Keyset)
+		return nil
+	}
+
+	exportOptions := &ExportKubeconfigOptions{
+		ClusterName: options.ClusterName,
+		admin:       options.Admin,
+	}
+	if err := RunExportKubeconfig(ctx, f, out, exportOptions, nil); err != nil {
+		return fmt.Errorf("exporting kubeconfig: %w", err)
+	}
+	fmt.Fprintf(out, "Exported kubeconfig; distribute its certificate-authority-data to all clients of the cluster\n")
+	return nil
+}
+
+func printRotationProgress(out io.Writer, progress *carotation.Progress) error {
+	completed := make(map[carotation.Phase]time.Time)
+	for _, c := range progress.Completed {
+		completed[c.Phase] = c.Time
+	}
+	next := progress.NextPhase()
+
+	t := &tables.Table{}
+	t.AddColumn("PHASE", func(phase carotation.Phase) string {
+		return string(phase)
+	})
+	t.AddColumn("STATUS", func(phase carotation.Phase) string {
+		if ts, found := completed[phase]; found {
+			return "Completed " + ts.Local().Format(time.RFC3339)
+		}
+		if phase == next {
+			return "Next"
+		}
+		return "Pending"
+	})
+	return t.Render(carotation.Phases, out, "PHASE", "STATUS")
+}
+
+func joinPhases(phases []carotation.Phase) string {
+	var names []string
+	for _, phase := range phases {
+		names = append(names, string(phase))
+	}
+	return strings.Join(names, ", ")
+}
+
+func completeRotateCA(ctx context.Context, f commandutils.Factory, args []string) ([]string, cobra.ShellCompDirective) {
+	commandutils.ConfigureKlogForCompletion()
+
+	cluster, clientSet, completions, directive := GetClusterForCompletion(ctx, f, nil)
+	if cluster == nil {
+		return completions, directive
+	}
+
+	keyset, _, completions, directive := completeKeyset(ctx, cluster, clientSet, args, rotatableKeysetFilter)
+	if keyset == nil {
+		return completions, directive
+	}
+
+	return commandutils.CompletionError("too many arguments", nil)
+}
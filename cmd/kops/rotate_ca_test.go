@@ -0,0 +1,84 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"crypto/x509/pkix"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	kopsapi "k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/pkg/carotation"
+	"k8s.io/kops/pkg/pki"
+	"k8s.io/kops/pkg/testutils/testcontext"
+	"k8s.io/kops/upup/pkg/fi"
+	"k8s.io/kops/util/pkg/vfs"
+)
+
+func TestDistrustPreviousPrimaries(t *testing.T) {
+	ctx := testcontext.ForTest(t)
+
+	now := time.Now().UnixNano()
+	issueCA := func(serial int64) (*pki.Certificate, *pki.PrivateKey) {
+		cert, key, _, err := pki.IssueCert(ctx, &pki.IssueCertRequest{
+			Type:         "ca",
+			Subject:      pkix.Name{CommonName: "kubernetes-ca"},
+			KeyAlgorithm: pki.KeyAlgorithmECDSAP256,
+			Serial:       pki.BuildPKISerial(serial),
+		}, nil)
+		require.NoError(t, err)
+		return cert, key
+	}
+
+	// The keyset trusts an older keypair that was not distrusted after an earlier rotation.
+	olderCert, olderKey := issueCA(now - 3000)
+	keyset, err := fi.NewKeyset(olderCert, olderKey)
+	require.NoError(t, err)
+	older := keyset.Primary
+
+	previousCert, previousKey := issueCA(now - 2000)
+	previous, err := keyset.AddItem(previousCert, previousKey, true)
+	require.NoError(t, err)
+
+	newCert, newKey := issueCA(now - 1000)
+	newPrimary, err := keyset.AddItem(newCert, newKey, true)
+	require.NoError(t, err)
+
+	cluster := &kopsapi.Cluster{}
+	cluster.Name = "minimal.example.com"
+	keyStore := fi.NewVFSCAStore(cluster, vfs.NewMemFSPath(vfs.NewMemFSContext(), "pki"))
+	require.NoError(t, keyStore.StoreKeyset(ctx, "kubernetes-ca", keyset))
+
+	progress := carotation.NewProgress("kubernetes-ca", time.Now())
+	progress.PreviousPrimaries = map[string]string{"kubernetes-ca": previous.Id}
+	progress.NewKeypairs = map[string]string{"kubernetes-ca": newPrimary.Id, "service-account": "1"}
+
+	var out bytes.Buffer
+	require.NoError(t, distrustPreviousPrimaries(ctx, &out, keyStore, progress))
+	assert.Equal(t, "Distrusted kubernetes-ca "+previous.Id+"\n", out.String())
+
+	stored, err := keyStore.FindKeyset(ctx, "kubernetes-ca")
+	require.NoError(t, err)
+	require.Len(t, stored.Items, 3)
+	assert.Nil(t, stored.Items[older.Id].DistrustTimestamp, "older keypair")
+	assert.NotNil(t, stored.Items[previous.Id].DistrustTimestamp, "previous primary")
+	assert.Nil(t, stored.Items[newPrimary.Id].DistrustTimestamp, "new primary")
+	assert.Equal(t, newPrimary.Id, stored.Primary.Id)
+}
@@ -35,6 +35,7 @@ func NewCmdToolbox(f commandutils.Factory, out io.Writer) *cobra.Command {
 	cmd.AddCommand(NewCmdToolboxDump(f, out))
 	cmd.AddCommand(NewCmdToolboxTemplate(f, out))
 	cmd.AddCommand(NewCmdToolboxInstanceSelector(f, out))
+	cmd.AddCommand(NewCmdToolboxRotateStateEncryption(f, out))
 	cmd.AddCommand(NewCmdToolboxAddons(out))
 
 	return cmd
@@ -0,0 +1,230 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"k8s.io/kops/pkg/acls"
+	"k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/pkg/commands/commandutils"
+	"k8s.io/kops/pkg/stateencryption"
+	"k8s.io/kops/upup/pkg/fi"
+	"k8s.io/kops/util/pkg/vfs"
+	"k8s.io/kubectl/pkg/util/i18n"
+	"k8s.io/kubectl/pkg/util/templates"
+)
+
+var (
+	toolboxRotateStateEncryptionLong = templates.LongDesc(i18n.T(`
+	Encrypt the key store and secret store of a cluster, or change the key that protects them.
+
+	Each store is encrypted with its own data key, which is kept in the store wrapped by the key
+	identified by --key-uri. The first time this command is run for a cluster, it generates the data
+	keys and re-writes the existing keys and secrets encrypted. Subsequent runs re-wrap the data keys
+	with the specified key; the stored keys and secrets are not re-written.
+
+	The key that previously wrapped the data keys must still be available to re-wrap them.
+	Anything that reads the stores, including nodes and kops-controller, must be able to use the key,
+	so it must be a cloud KMS key. Only AWS KMS keys are currently supported. The next update of the
+	cluster grants kms:Decrypt on the key to the IAM roles of the instances that read the stores,
+	so it should be run right after this command.`))
+
+	toolboxRotateStateEncryptionExample = templates.Examples(i18n.T(`
+	# Encrypt the key and secret stores with an AWS KMS key
+	kops toolbox rotate-state-encryption k8s-cluster.example.com \
+		--key-uri awskms:///arn:aws:kms:us-east-1:123456789012:key/1234abcd-12ab-34cd-56ef-1234567890ab --yes
+	`))
+
+	toolboxRotateStateEncryptionShort = i18n.T(`Encrypt the key and secret stores, or rotate their key.`)
+)
+
+type ToolboxRotateStateEncryptionOptions struct {
+	ClusterName string
+	KeyURI      string
+	Yes         bool
+}
+
+func NewCmdToolboxRotateStateEncryption(f commandutils.Factory, out io.Writer) *cobra.Command {
+	options := &ToolboxRotateStateEncryptionOptions{}
+
+	cmd := &cobra.Command{
+		Use:               "rotate-state-encryption [CLUSTER]",
+		Short:             toolboxRotateStateEncryptionShort,
+		Long:              toolboxRotateStateEncryptionLong,
+		Example:           toolboxRotateStateEncryptionExample,
+		Args:              rootCommand.clusterNameArgs(&options.ClusterName),
+		ValidArgsFunction: commandutils.CompleteClusterName(f, true, false),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return RunToolboxRotateStateEncryption(cmd.Context(), f, out, options)
+		},
+	}
+
+	cmd.Flags().StringVar(&options.KeyURI, "key-uri", options.KeyURI, "URI of the key that wraps the data keys, e.g. awskms:///<key ARN>")
+	cmd.Flags().BoolVarP(&options.Yes, "yes", "y", options.Yes, "Apply the changes, without --yes only the planned changes are shown")
+
+	return cmd
+}
+
+func RunToolboxRotateStateEncryption(ctx context.Context, f commandutils.Factory, out io.Writer, options *ToolboxRotateStateEncryptionOptions) error {
+	if options.KeyURI == "" {
+		return fmt.Errorf("--key-uri is required")
+	}
+
+	wrapper, err := stateencryption.NewKeyWrapper(options.KeyURI)
+	if err != nil {
+		return err
+	}
+
+	clientset, err := f.KopsClient()
+	if err != nil {
+		return err
+	}
+
+	cluster, err := clientset.GetCluster(ctx, options.ClusterName)
+	if err != nil {
+		return err
+	}
+	if cluster == nil {
+		return fmt.Errorf("cluster not found %q", options.ClusterName)
+	}
+	if err := checkStateEncryptionKey(cluster, options.KeyURI); err != nil {
+		return err
+	}
+
+	keyStore, err := clientset.KeyStore(cluster)
+	if err != nil {
+		return err
+	}
+	secretStore, err := clientset.SecretStore(cluster)
+	if err != nil {
+		return err
+	}
+
+	stores := []struct {
+		description string
+		store       interface{}
+		rewrite     func() error
+	}{
+		{
+			description: "key store",
+			store:       keyStore,
+			rewrite: func() error {
+				keysets, err := keyStore.ListKeysets()
+				if err != nil {
+					return err
+				}
+				for name, keyset := range keysets {
+					if err := keyStore.StoreKeyset(ctx, name, keyset); err != nil {
+						return fmt.Errorf("error encrypting keyset %q: %w", name, err)
+					}
+				}
+				return nil
+			},
+		},
+		{
+			description: "secret store",
+			store:       secretStore,
+			rewrite: func() error {
+				names, err := secretStore.ListSecrets()
+				if err != nil {
+					return err
+				}
+				for _, name := range names {
+					secret, err := secretStore.FindSecret(name)
+					if err != nil {
+						return err
+					}
+					if secret == nil {
+						continue
+					}
+					if _, err := secretStore.ReplaceSecret(name, secret); err != nil {
+						return fmt.Errorf("error encrypting secret %q: %w", name, err)
+					}
+				}
+				return nil
+			},
+		},
+	}
+
+	for _, s := range stores {
+		hasVFSPath, ok := s.store.(fi.HasVFSPath)
+		if !ok {
+			return fmt.Errorf("the %s of this cluster does not support encryption", s.description)
+		}
+		basedir := hasVFSPath.VFSPath()
+
+		envelope, err := stateencryption.ReadEnvelope(ctx, basedir)
+		if err != nil {
+			return err
+		}
+
+		if !options.Yes {
+			if envelope == nil {
+				fmt.Fprintf(out, "Will encrypt the %s %s with %s\n", s.description, basedir, wrapper.URI())
+			} else {
+				fmt.Fprintf(out, "Will re-wrap the data key of the %s %s from %s to %s\n", s.description, basedir, envelope.KeyURI, wrapper.URI())
+			}
+			continue
+		}
+
+		acl, err := envelopeACL(ctx, basedir, cluster)
+		if err != nil {
+			return err
+		}
+		if envelope == nil {
+			if err := stateencryption.Enable(ctx, basedir, wrapper, acl); err != nil {
+				return err
+			}
+			if err := s.rewrite(); err != nil {
+				return err
+			}
+			fmt.Fprintf(out, "Encrypted the %s %s with %s\n", s.description, basedir, wrapper.URI())
+		} else {
+			if err := stateencryption.Rewrap(ctx, basedir, wrapper, acl); err != nil {
+				return err
+			}
+			fmt.Fprintf(out, "Re-wrapped the data key of the %s %s with %s\n", s.description, basedir, wrapper.URI())
+		}
+	}
+
+	if !options.Yes {
+		fmt.Fprintf(out, "\nMust specify --yes to apply changes\n")
+	} else {
+		fmt.Fprintf(out, "\nRun 'kops update cluster --yes' to allow the instances of the cluster to use the key\n")
+	}
+	return nil
+}
+
+// checkStateEncryptionKey checks that nodes and kops-controller of the cluster can use the key to read the stores.
+func checkStateEncryptionKey(cluster *kops.Cluster, keyURI string) error {
+	if stateencryption.IsLocalKeyURI(keyURI) {
+		return fmt.Errorf("nodes and kops-controller cannot use the local key %q to read the key and secret stores; use a cloud KMS key", keyURI)
+	}
+	if strings.HasPrefix(keyURI, stateencryption.AWSKMSScheme+":") && cluster.Spec.GetCloudProvider() != kops.CloudProviderAWS {
+		return fmt.Errorf("AWS KMS keys can only be used to encrypt the state of AWS clusters, but cluster %q uses %s", cluster.ObjectMeta.Name, cluster.Spec.GetCloudProvider())
+	}
+	return nil
+}
+
+func envelopeACL(ctx context.Context, basedir vfs.Path, cluster *kops.Cluster) (vfs.ACL, error) {
+	return acls.GetACL(ctx, basedir.Join(stateencryption.EnvelopeFileName), cluster)
+}
@@ -19,6 +19,7 @@ package main
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
@@ -27,11 +28,14 @@ import (
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/klog/v2"
 	"k8s.io/kops/cmd/kops/util"
 	"k8s.io/kops/pkg/apis/kops"
 	"k8s.io/kops/pkg/assets"
+	"k8s.io/kops/pkg/client/simple/vfsclientset"
+	"k8s.io/kops/pkg/clusterlock"
 	"k8s.io/kops/pkg/commands/commandutils"
 	"k8s.io/kops/pkg/kubeconfig"
 	"k8s.io/kops/upup/pkg/fi"
@@ -40,6 +44,7 @@ import (
 	"k8s.io/kops/upup/pkg/kutil"
 	"k8s.io/kubectl/pkg/util/i18n"
 	"k8s.io/kubectl/pkg/util/templates"
+	"sigs.k8s.io/yaml"
 )
 
 var (
@@ -54,6 +59,9 @@ var (
 	updateClusterExample = templates.Examples(i18n.T(`
 	# After the cluster has been edited or upgraded, update the cloud resources with:
 	kops update cluster k8s-cluster.example.com --yes --state=s3://my-state-store --yes
+
+	# Print the changes that would be made as JSON, without applying them:
+	kops update cluster k8s-cluster.example.com --state=s3://my-state-store -o json
 	`))
 
 	updateClusterShort = i18n.T("Update a cluster.")
@@ -81,6 +89,14 @@ type UpdateClusterOptions struct {
 	// LifecycleOverrides is a slice of taskName=lifecycle name values.  This slice is used
 	// to populate the LifecycleOverrides struct member in ApplyClusterCmd struct.
 	LifecycleOverrides []string
+
+	// Output is the format in which to print the planned changes of a dry run, instead of the text report.
+	Output string
+
+	// OutPlan is the path of a file to which the planned changes of a dry run are saved.
+	OutPlan string
+	// Plan is the path of a saved plan; the update is refused if the changes to be made differ from it.
+	Plan string
 }
 
 func (o *UpdateClusterOptions) InitDefaults() {
@@ -92,7 +108,7 @@ func (o *UpdateClusterOptions) InitDefaults() {
 	// By default we export a kubecfg, but it doesn't have a static/eternal credential in it any more.
 	o.CreateKubecfg = true
 
-	o.RunTasksOptions.InitDefaults()
+	o.RunTasksOptions.InitCloudupDefaults()
 }
 
 func NewCmdUpdateCluster(f *util.Factory, out io.Writer) *cobra.Command {
@@ -125,10 +141,19 @@ func NewCmdUpdateCluster(f *util.Factory, out io.Writer) *cobra.Command {
 	cmd.RegisterFlagCompletionFunc("user", completeKubecfgUser)
 	cmd.Flags().BoolVar(&options.internal, "internal", options.internal, "Use the cluster's internal DNS name. Implies --create-kube-config")
 	cmd.Flags().BoolVar(&options.AllowKopsDowngrade, "allow-kops-downgrade", options.AllowKopsDowngrade, "Allow an older version of kOps to update the cluster than last used")
+	cmd.Flags().IntVar(&options.RunTasksOptions.MaxConcurrency, "max-concurrency", options.RunTasksOptions.MaxConcurrency, "Maximum number of tasks to run concurrently (0 for no limit)")
 	cmd.Flags().StringVar(&options.Phase, "phase", options.Phase, "Subset of tasks to run: "+strings.Join(cloudup.Phases.List(), ", "))
 	cmd.RegisterFlagCompletionFunc("phase", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 		return cloudup.Phases.List(), cobra.ShellCompDirectiveNoFileComp
 	})
+	cmd.Flags().StringVarP(&options.Output, "output", "o", options.Output, "Output format for the planned changes of a dry run. One of json or yaml")
+	cmd.RegisterFlagCompletionFunc("output", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{OutputJSON, OutputYaml}, cobra.ShellCompDirectiveNoFileComp
+	})
+	cmd.Flags().StringVar(&options.OutPlan, "out-plan", options.OutPlan, "Path to save the planned changes of a dry run, for use with --plan")
+	cmd.MarkFlagFilename("out-plan")
+	cmd.Flags().StringVar(&options.Plan, "plan", options.Plan, "Path of a plan saved with --out-plan; the update is refused if the changes to be made differ from the plan")
+	cmd.MarkFlagFilename("plan")
 	cmd.Flags().StringSliceVar(&options.LifecycleOverrides, "lifecycle-overrides", options.LifecycleOverrides, "comma separated list of phase overrides, example: SecurityGroups=Ignore,InternetGateway=ExistsAndWarnIfChanges")
 	viper.BindPFlag("lifecycle-overrides", cmd.Flags().Lookup("lifecycle-overrides"))
 	viper.BindEnv("lifecycle-overrides", "KOPS_LIFECYCLE_OVERRIDES")
@@ -189,6 +214,23 @@ func RunUpdateCluster(ctx context.Context, f *util.Factory, out io.Writer, c *Up
 		targetName = cloudup.TargetDryRun
 	}
 
+	switch c.Output {
+	case "":
+	case OutputJSON, OutputYaml:
+		if !isDryrun {
+			return nil, fmt.Errorf("--output can only be used for a dry run")
+		}
+	default:
+		return nil, fmt.Errorf("unsupported output format: %q", c.Output)
+	}
+
+	if c.OutPlan != "" && !isDryrun {
+		return nil, fmt.Errorf("--out-plan can only be used for a dry run")
+	}
+	if c.Plan != "" && c.Target != cloudup.TargetDirect {
+		return nil, fmt.Errorf("--plan can only be used with the %q target", cloudup.TargetDirect)
+	}
+
 	if c.OutDir == "" {
 		if c.Target == cloudup.TargetTerraform {
 			c.OutDir = "out/terraform"
@@ -202,6 +244,23 @@ func RunUpdateCluster(ctx context.Context, f *util.Factory, out io.Writer, c *Up
 		return results, err
 	}
 
+	if !isDryrun {
+		lock, err := clusterlock.AcquireForCluster(ctx, cluster, "update cluster")
+		if err != nil {
+			return results, err
+		}
+		defer func() {
+			if err := lock.Release(context.Background()); err != nil {
+				klog.Warningf("error releasing cluster lock: %v", err)
+			}
+		}()
+
+		var cancel context.CancelFunc
+		ctx, cancel = lock.WithContext(ctx)
+		defer cancel()
+		ctx = vfsclientset.WithRevisionAuthor(ctx, lock.Holder())
+	}
+
 	clientset, err := f.KopsClient()
 	if err != nil {
 		return results, err
@@ -276,11 +335,65 @@ func RunUpdateCluster(ctx context.Context, f *util.Factory, out io.Writer, c *Up
 		return nil, err
 	}
 
+	// When working with a saved plan, fingerprint the configuration before it is populated
+	var instanceGroups []*kops.InstanceGroup
+	var planFingerprint string
+	if c.OutPlan != "" || c.Plan != "" {
+		list, err := clientset.InstanceGroupsFor(cluster).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, err
+		}
+		for i := range list.Items {
+			instanceGroups = append(instanceGroups, &list.Items[i])
+		}
+
+		planFingerprint, err = cloudup.ComputePlanFingerprint(cluster, instanceGroups, keyStore, secretStore, f.KopsStateStore())
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if c.Plan != "" {
+		savedPlan, err := cloudup.ReadSavedPlan(c.Plan)
+		if err != nil {
+			return nil, err
+		}
+
+		verifyCmd := &cloudup.ApplyClusterCmd{
+			Cloud:              cloud,
+			Clientset:          clientset,
+			Cluster:            cluster.DeepCopy(),
+			InstanceGroups:     deepCopyInstanceGroups(instanceGroups),
+			DryRun:             true,
+			Quiet:              true,
+			AllowKopsDowngrade: c.AllowKopsDowngrade,
+			RunTasksOptions:    &c.RunTasksOptions,
+			OutDir:             c.OutDir,
+			Phase:              phase,
+			TargetName:         cloudup.TargetDryRun,
+			LifecycleOverrides: lifecycleOverrideMap,
+		}
+		if err := verifyCmd.Run(ctx); err != nil {
+			return results, err
+		}
+
+		currentPlan, err := cloudup.BuildSavedPlan(verifyCmd.Target.(*fi.CloudupDryRunTarget), verifyCmd.TaskMap, cluster.ObjectMeta.Name, f.KopsStateStore(), planFingerprint)
+		if err != nil {
+			return results, err
+		}
+		if err := savedPlan.Verify(currentPlan); err != nil {
+			return results, fmt.Errorf("refusing to apply plan %q: %w", c.Plan, err)
+		}
+		klog.Infof("Changes to be made match plan %q", c.Plan)
+	}
+
 	applyCmd := &cloudup.ApplyClusterCmd{
 		Cloud:              cloud,
 		Clientset:          clientset,
 		Cluster:            cluster,
+		InstanceGroups:     instanceGroups,
 		DryRun:             isDryrun,
+		Quiet:              c.Output != "",
 		AllowKopsDowngrade: c.AllowKopsDowngrade,
 		RunTasksOptions:    &c.RunTasksOptions,
 		OutDir:             c.OutDir,
@@ -302,6 +415,19 @@ func RunUpdateCluster(ctx context.Context, f *util.Factory, out io.Writer, c *Up
 
 	if isDryrun && !c.GetAssets {
 		target := applyCmd.Target.(*fi.CloudupDryRunTarget)
+		if c.OutPlan != "" {
+			savedPlan, err := cloudup.BuildSavedPlan(target, applyCmd.TaskMap, cluster.ObjectMeta.Name, f.KopsStateStore(), planFingerprint)
+			if err != nil {
+				return results, err
+			}
+			if err := cloudup.WriteSavedPlan(c.OutPlan, savedPlan); err != nil {
+				return results, err
+			}
+			klog.Infof("Plan saved to %q; apply it with: kops update cluster %s --plan %s --yes", c.OutPlan, cluster.ObjectMeta.Name, c.OutPlan)
+		}
+		if c.Output != "" {
+			return results, printPlan(target, applyCmd.TaskMap, c.Output, out)
+		}
 		if target.HasChanges() {
 			fmt.Fprintf(out, "Must specify --yes to apply changes\n")
 		} else {
@@ -410,6 +536,44 @@ func RunUpdateCluster(ctx context.Context, f *util.Factory, out io.Writer, c *Up
 	return results, nil
 }
 
+func deepCopyInstanceGroups(instanceGroups []*kops.InstanceGroup) []*kops.InstanceGroup {
+	var copies []*kops.InstanceGroup
+	for _, ig := range instanceGroups {
+		copies = append(copies, ig.DeepCopy())
+	}
+	return copies
+}
+
+// printPlan writes the changes recorded by the dry run target in a machine-readable format.
+func printPlan(target *fi.CloudupDryRunTarget, taskMap map[string]fi.CloudupTask, output string, out io.Writer) error {
+	plan, err := target.Plan(taskMap)
+	if err != nil {
+		return err
+	}
+
+	switch output {
+	case OutputYaml:
+		y, err := yaml.Marshal(plan)
+		if err != nil {
+			return fmt.Errorf("unable to marshal YAML: %v", err)
+		}
+		if _, err := out.Write(y); err != nil {
+			return fmt.Errorf("error writing to output: %v", err)
+		}
+	case OutputJSON:
+		j, err := json.MarshalIndent(plan, "", "  ")
+		if err != nil {
+			return fmt.Errorf("unable to marshal JSON: %v", err)
+		}
+		if _, err := out.Write(j); err != nil {
+			return fmt.Errorf("error writing to output: %v", err)
+		}
+	default:
+		return fmt.Errorf("unsupported output format: %q", output)
+	}
+	return nil
+}
+
 func parseLifecycle(lifecycle string) (fi.Lifecycle, error) {
 	if v, ok := fi.LifecycleNameMap[lifecycle]; ok {
 		return v, nil
@@ -85,9 +85,9 @@ func (f *Factory) KopsClient() (simple.Clientset, error) {
 			return nil, field.Required(field.NewPath("State Store"), STATE_ERROR)
 		}
 
-		// We recognize a `k8s` scheme; this might change in future so we won't document it yet
+		// We recognize a `k8s` scheme with no namespace as the kops API server; this might change in future so we won't document it yet
 		// In practice nobody is going to hit this accidentally, so I don't think we need a feature flag.
-		if strings.HasPrefix(registryPath, "k8s://") {
+		if isKopsServerPath(registryPath) {
 			loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
 
 			configOverrides := &clientcmd.ConfigOverrides{}
@@ -138,6 +138,20 @@ func (f *Factory) KopsClient() (simple.Clientset, error) {
 	return f.clientset, nil
 }
 
+// isKopsServerPath returns true if the state store is the kops API server, i.e. k8s:// or k8s://<context>.
+// A k8s:// path that names a namespace, k8s://<context>/<namespace>/, is a state store kept in that namespace.
+func isKopsServerPath(registryPath string) bool {
+	if !strings.HasPrefix(registryPath, "k8s://") {
+		return false
+	}
+	u, err := url.Parse(registryPath)
+	if err != nil {
+		// Report the error when we build the client
+		return true
+	}
+	return strings.Trim(u.Path, "/") == ""
+}
+
 // KopsStateStore returns the configured KOPS_STATE_STORE in use
 func (f *Factory) KopsStateStore() string {
 	return f.options.RegistryPath
@@ -0,0 +1,39 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"testing"
+)
+
+func TestIsKopsServerPath(t *testing.T) {
+	grid := map[string]bool{
+		"k8s://":                              true,
+		"k8s://my-context":                    true,
+		"k8s://my-context/":                   true,
+		"k8s://my-context/kops-system/":       false,
+		"k8s:///kops-system":                  false,
+		"s3://my-bucket/":                     false,
+		"memfs://tests":                       false,
+		"k8s://my-context/kops-system/prefix": false,
+	}
+	for registryPath, expected := range grid {
+		if actual := isKopsServerPath(registryPath); actual != expected {
+			t.Errorf("isKopsServerPath(%q): expected %v, got %v", registryPath, expected, actual)
+		}
+	}
+}
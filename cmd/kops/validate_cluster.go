@@ -21,7 +21,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"net"
+	"net/http"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
@@ -30,6 +33,8 @@ import (
 	"k8s.io/kubectl/pkg/util/i18n"
 	"k8s.io/kubectl/pkg/util/templates"
 
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/spf13/cobra"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -51,12 +56,23 @@ var (
 		2. All worker nodes are running and have "Ready" status.
 		3. All control plane nodes have the expected pods.
 		4. All pods with a critical priority are running and have "Ready" status.
+		5. All etcd clusters have a ready member on each of their control plane nodes, and a quorum.
+		6. The apiserver on each control plane node has a ready pod, and the apiserver answering on the cluster's
+		   endpoint reports it is ready on its /readyz endpoint. Rolling updates run by kops-controller instead query
+		   /readyz on each control plane node, including its check of the local member of the main etcd cluster.
+		7. No certificate in the keystore has expired. Those expiring within 30 days are reported as warnings.
+		8. The kubelet of each node is within the supported version skew of the cluster's Kubernetes version.
+		   Other version differences are reported as warnings.
 		`))
 
 	validateClusterExample = templates.Examples(i18n.T(`
 	# Validate the cluster set as the current context of the kube config.
 	# Kops will try for 10 minutes to validate the cluster 3 times.
-	kops validate cluster --wait 10m --count 3`))
+	kops validate cluster --wait 10m --count 3
+
+	# Validate the cluster every minute until interrupted, logging changes in its health
+	# and exposing the results as Prometheus metrics on port 9090.
+	kops validate cluster --watch --watch-interval 1m --metrics-addr :9090`))
 
 	validateClusterShort = i18n.T(`Validate a kOps cluster.`)
 )
@@ -67,10 +83,17 @@ type ValidateClusterOptions struct {
 	wait        time.Duration
 	count       int
 	kubeconfig  string
+
+	// watch validates the cluster repeatedly, every watchInterval, until interrupted.
+	watch         bool
+	watchInterval time.Duration
+	// metricsAddr is the address on which to serve Prometheus metrics while watching.
+	metricsAddr string
 }
 
 func (o *ValidateClusterOptions) InitDefaults() {
 	o.output = OutputTable
+	o.watchInterval = 30 * time.Second
 }
 
 func NewCmdValidateCluster(f *util.Factory, out io.Writer) *cobra.Command {
@@ -85,6 +108,13 @@ func NewCmdValidateCluster(f *util.Factory, out io.Writer) *cobra.Command {
 		Args:              rootCommand.clusterNameArgs(&options.ClusterName),
 		ValidArgsFunction: commandutils.CompleteClusterName(f, true, false),
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if options.watch {
+				return RunValidateClusterWatch(cmd.Context(), f, out, options)
+			}
+			if options.metricsAddr != "" {
+				return fmt.Errorf("--metrics-addr requires --watch")
+			}
+
 			result, err := RunValidateCluster(cmd.Context(), f, out, options)
 			if err != nil {
 				return fmt.Errorf("validation failed: %v", err)
@@ -106,71 +136,22 @@ func NewCmdValidateCluster(f *util.Factory, out io.Writer) *cobra.Command {
 	cmd.Flags().DurationVar(&options.wait, "wait", options.wait, "Amount of time to wait for the cluster to become ready")
 	cmd.Flags().IntVar(&options.count, "count", options.count, "Number of consecutive successful validations required")
 	cmd.Flags().StringVar(&options.kubeconfig, "kubeconfig", "", "Path to the kubeconfig file")
+	cmd.Flags().BoolVar(&options.watch, "watch", options.watch, "Validate the cluster repeatedly until interrupted, logging only changes in the result")
+	cmd.Flags().DurationVar(&options.watchInterval, "watch-interval", options.watchInterval, "Interval between validations when watching")
+	cmd.Flags().StringVar(&options.metricsAddr, "metrics-addr", options.metricsAddr, "Address on which to expose Prometheus metrics of the validation results when watching, such as :9090")
 
 	return cmd
 }
 
 func RunValidateCluster(ctx context.Context, f *util.Factory, out io.Writer, options *ValidateClusterOptions) (*validation.ValidationCluster, error) {
-	clientSet, err := f.KopsClient()
-	if err != nil {
-		return nil, err
-	}
-
-	cluster, err := GetCluster(ctx, f, options.ClusterName)
+	cluster, instanceGroups, validator, err := newClusterValidator(ctx, f, out, options)
 	if err != nil {
 		return nil, err
 	}
 
-	cloud, err := cloudup.BuildCloud(cluster)
-	if err != nil {
-		return nil, err
-	}
-
-	list, err := clientSet.InstanceGroupsFor(cluster).List(ctx, metav1.ListOptions{})
-	if err != nil {
-		return nil, fmt.Errorf("cannot get InstanceGroups for %q: %v", cluster.ObjectMeta.Name, err)
-	}
-
-	if options.output == OutputTable {
-		fmt.Fprintf(out, "Validating cluster %v\n\n", cluster.ObjectMeta.Name)
-	}
-
-	var instanceGroups []kopsapi.InstanceGroup
-	for _, ig := range list.Items {
-		instanceGroups = append(instanceGroups, ig)
-		klog.V(2).Infof("instance group: %#v\n\n", ig.Spec)
-	}
-
-	if len(instanceGroups) == 0 {
-		return nil, fmt.Errorf("no InstanceGroup objects found")
-	}
-
-	// TODO: Refactor into util.Factory
-	contextName := cluster.ObjectMeta.Name
-	configLoadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
-	if options.kubeconfig != "" {
-		configLoadingRules.ExplicitPath = options.kubeconfig
-	}
-	config, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
-		configLoadingRules,
-		&clientcmd.ConfigOverrides{CurrentContext: contextName}).ClientConfig()
-	if err != nil {
-		return nil, fmt.Errorf("cannot load kubecfg settings for %q: %v", contextName, err)
-	}
-
-	k8sClient, err := kubernetes.NewForConfig(config)
-	if err != nil {
-		return nil, fmt.Errorf("cannot build kubernetes api client for %q: %v", contextName, err)
-	}
-
 	timeout := time.Now().Add(options.wait)
 	pollInterval := 10 * time.Second
 
-	validator, err := validation.NewClusterValidator(cluster, cloud, list, config.Host, k8sClient)
-	if err != nil {
-		return nil, fmt.Errorf("unexpected error creating validatior: %v", err)
-	}
-
 	consecutive := 0
 	for {
 		if options.wait > 0 && time.Now().After(timeout) {
@@ -240,6 +221,195 @@ func RunValidateCluster(ctx context.Context, f *util.Factory, out io.Writer, opt
 	}
 }
 
+// newClusterValidator builds the validator of the cluster named by options, returning the cluster and its instance groups.
+func newClusterValidator(ctx context.Context, f *util.Factory, out io.Writer, options *ValidateClusterOptions) (*kopsapi.Cluster, []kopsapi.InstanceGroup, validation.ClusterValidator, error) {
+	clientSet, err := f.KopsClient()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	cluster, err := GetCluster(ctx, f, options.ClusterName)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	cloud, err := cloudup.BuildCloud(cluster)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	list, err := clientSet.InstanceGroupsFor(cluster).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("cannot get InstanceGroups for %q: %v", cluster.ObjectMeta.Name, err)
+	}
+
+	if options.output == OutputTable {
+		fmt.Fprintf(out, "Validating cluster %v\n\n", cluster.ObjectMeta.Name)
+	}
+
+	var instanceGroups []kopsapi.InstanceGroup
+	for _, ig := range list.Items {
+		instanceGroups = append(instanceGroups, ig)
+		klog.V(2).Infof("instance group: %#v\n\n", ig.Spec)
+	}
+
+	if len(instanceGroups) == 0 {
+		return nil, nil, nil, fmt.Errorf("no InstanceGroup objects found")
+	}
+
+	// TODO: Refactor into util.Factory
+	contextName := cluster.ObjectMeta.Name
+	configLoadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	if options.kubeconfig != "" {
+		configLoadingRules.ExplicitPath = options.kubeconfig
+	}
+	config, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+		configLoadingRules,
+		&clientcmd.ConfigOverrides{CurrentContext: contextName}).ClientConfig()
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("cannot load kubecfg settings for %q: %v", contextName, err)
+	}
+
+	k8sClient, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("cannot build kubernetes api client for %q: %v", contextName, err)
+	}
+
+	keyStore, err := clientSet.KeyStore(cluster)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	validator, err := validation.NewClusterValidator(cluster, cloud, list, config, k8sClient, keyStore, false)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("unexpected error creating validatior: %v", err)
+	}
+
+	return cluster, instanceGroups, validator, nil
+}
+
+// RunValidateClusterWatch validates the cluster every watch interval until ctx is done, reading the cluster and its
+// instance groups again each time. It logs only the changes in the result of each validation and, if a metrics
+// address is set, serves the results as Prometheus metrics.
+func RunValidateClusterWatch(ctx context.Context, f *util.Factory, out io.Writer, options *ValidateClusterOptions) error {
+	if options.watchInterval <= 0 {
+		return fmt.Errorf("--watch-interval must be positive")
+	}
+
+	cluster, instanceGroups, validator, err := newClusterValidator(ctx, f, out, options)
+	if err != nil {
+		return err
+	}
+
+	registry := prometheus.NewRegistry()
+	metrics, err := validation.NewMetrics(registry)
+	if err != nil {
+		return fmt.Errorf("error registering metrics: %v", err)
+	}
+
+	if options.metricsAddr != "" {
+		listener, err := net.Listen("tcp", options.metricsAddr)
+		if err != nil {
+			return fmt.Errorf("cannot listen for metrics on %q: %v", options.metricsAddr, err)
+		}
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+		server := &http.Server{
+			Handler:           mux,
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+		go func() {
+			if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+				klog.Errorf("error serving metrics: %v", err)
+			}
+		}()
+		defer server.Close()
+		klog.Infof("serving metrics of cluster %q on %s", cluster.ObjectMeta.Name, listener.Addr())
+	}
+
+	var previous *validation.ValidationCluster
+	var previousErr error
+	for first := true; ; first = false {
+		if !first {
+			// The cluster and its instance groups may have changed since the last validation
+			_, instanceGroups, validator, err = newClusterValidator(ctx, f, io.Discard, options)
+		}
+		start := time.Now()
+		var result *validation.ValidationCluster
+		if err == nil {
+			result, err = validator.Validate()
+		}
+		metrics.Record(result, err, time.Since(start), instanceGroups)
+
+		for _, transition := range validationTransitions(previous, previousErr, result, err) {
+			klog.Info(transition)
+		}
+		if err == nil {
+			previous = result
+		}
+		previousErr = err
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(options.watchInterval):
+		}
+	}
+}
+
+// validationTransitions describes the changes from the previous validation, which returned previous or previousErr,
+// to the current one, which returned result or err. The first validation is compared with a healthy cluster.
+func validationTransitions(previous *validation.ValidationCluster, previousErr error, result *validation.ValidationCluster, err error) []string {
+	var transitions []string
+	if err != nil {
+		if previousErr == nil || previousErr.Error() != err.Error() {
+			transitions = append(transitions, fmt.Sprintf("unexpected error during validation: %v", err))
+		}
+		return transitions
+	}
+	if previousErr != nil {
+		transitions = append(transitions, "validation succeeded again")
+	}
+
+	// Failures are matched by what failed, so that a failure whose message changes, such as a count of ready
+	// members, is not reported as resolved and new again
+	failureKey := func(failure *validation.ValidationError) string {
+		return failure.Kind + "/" + failure.Name
+	}
+	previousFailures := map[string]*validation.ValidationError{}
+	if previous != nil {
+		for _, failure := range previous.Failures {
+			previousFailures[failureKey(failure)] = failure
+		}
+	}
+	failures := map[string]bool{}
+	for _, failure := range result.Failures {
+		key := failureKey(failure)
+		failures[key] = true
+		if previousFailures[key] == nil {
+			transitions = append(transitions, fmt.Sprintf("new failure: %s: %s", key, failure.Message))
+		}
+	}
+	if previous != nil {
+		for _, failure := range previous.Failures {
+			if key := failureKey(failure); !failures[key] {
+				transitions = append(transitions, fmt.Sprintf("resolved failure: %s: %s", key, failure.Message))
+			}
+		}
+	}
+
+	wasHealthy := previous == nil || len(previous.Failures) == 0
+	switch {
+	case wasHealthy && len(result.Failures) != 0:
+		transitions = append(transitions, "cluster is not healthy")
+	case !wasHealthy && len(result.Failures) == 0:
+		transitions = append(transitions, "cluster is healthy")
+	case previous == nil:
+		transitions = append(transitions, "cluster is healthy")
+	}
+	return transitions
+}
+
 func validateClusterOutputTable(result *validation.ValidationCluster, cluster *kopsapi.Cluster, instanceGroups []kopsapi.InstanceGroup, out io.Writer) error {
 	t := &tables.Table{}
 	t.AddColumn("NAME", func(c kopsapi.InstanceGroup) string {
@@ -287,6 +457,42 @@ func validateClusterOutputTable(result *validation.ValidationCluster, cluster *k
 		}
 	}
 
+	if len(result.HealthChecks) != 0 {
+		healthChecksTable := &tables.Table{}
+		healthChecksTable.AddColumn("NAME", func(r *validation.HealthCheckResult) string {
+			return r.Name
+		})
+		healthChecksTable.AddColumn("TYPE", func(r *validation.HealthCheckResult) string {
+			return r.Type
+		})
+		healthChecksTable.AddColumn("HEALTHY", func(r *validation.HealthCheckResult) string {
+			return strconv.FormatBool(r.Healthy)
+		})
+
+		fmt.Fprintln(out, "\nHEALTH CHECKS")
+		if err := healthChecksTable.Render(result.HealthChecks, out, "NAME", "TYPE", "HEALTHY"); err != nil {
+			return fmt.Errorf("error rendering health checks table: %v", err)
+		}
+	}
+
+	if len(result.Warnings) != 0 {
+		warningsTable := &tables.Table{}
+		warningsTable.AddColumn("KIND", func(e *validation.ValidationError) string {
+			return e.Kind
+		})
+		warningsTable.AddColumn("NAME", func(e *validation.ValidationError) string {
+			return e.Name
+		})
+		warningsTable.AddColumn("MESSAGE", func(e *validation.ValidationError) string {
+			return e.Message
+		})
+
+		fmt.Fprintln(out, "\nVALIDATION WARNINGS")
+		if err := warningsTable.Render(result.Warnings, out, "KIND", "NAME", "MESSAGE"); err != nil {
+			return fmt.Errorf("error rendering warnings table: %v", err)
+		}
+	}
+
 	if len(result.Failures) != 0 {
 		failuresTable := &tables.Table{}
 		failuresTable.AddColumn("KIND", func(e *validation.ValidationError) string {
@@ -0,0 +1,124 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"k8s.io/kops/pkg/validation"
+)
+
+func TestValidationTransitions(t *testing.T) {
+	healthy := &validation.ValidationCluster{}
+	nodeNotReady := &validation.ValidationCluster{
+		Failures: []*validation.ValidationError{
+			{Kind: "Node", Name: "node-a", Message: "node \"node-a\" of role \"node\" is not ready"},
+		},
+	}
+	etcdQuorum := &validation.ValidationCluster{
+		Failures: []*validation.ValidationError{
+			{Kind: "EtcdQuorum", Name: "main", Message: "etcd cluster \"main\" has 1 of 3 members ready, fewer than the quorum of 2"},
+		},
+	}
+	etcdQuorumChanged := &validation.ValidationCluster{
+		Failures: []*validation.ValidationError{
+			{Kind: "EtcdQuorum", Name: "main", Message: "etcd cluster \"main\" has 0 of 3 members ready, fewer than the quorum of 2"},
+		},
+	}
+	listErr := fmt.Errorf("error listing nodes")
+
+	grid := []struct {
+		name        string
+		previous    *validation.ValidationCluster
+		previousErr error
+		result      *validation.ValidationCluster
+		err         error
+		expected    []string
+	}{
+		{
+			name:     "first validation",
+			result:   healthy,
+			expected: []string{"cluster is healthy"},
+		},
+		{
+			name:     "unchanged",
+			previous: nodeNotReady,
+			result:   nodeNotReady,
+		},
+		{
+			name:     "becomes unhealthy",
+			previous: healthy,
+			result:   nodeNotReady,
+			expected: []string{
+				"new failure: Node/node-a: node \"node-a\" of role \"node\" is not ready",
+				"cluster is not healthy",
+			},
+		},
+		{
+			name:     "failure changes",
+			previous: nodeNotReady,
+			result:   etcdQuorum,
+			expected: []string{
+				"new failure: EtcdQuorum/main: etcd cluster \"main\" has 1 of 3 members ready, fewer than the quorum of 2",
+				"resolved failure: Node/node-a: node \"node-a\" of role \"node\" is not ready",
+			},
+		},
+		{
+			name:     "failure message changes",
+			previous: etcdQuorum,
+			result:   etcdQuorumChanged,
+		},
+		{
+			name:     "becomes healthy",
+			previous: etcdQuorum,
+			result:   healthy,
+			expected: []string{
+				"resolved failure: EtcdQuorum/main: etcd cluster \"main\" has 1 of 3 members ready, fewer than the quorum of 2",
+				"cluster is healthy",
+			},
+		},
+		{
+			name:     "error",
+			previous: healthy,
+			err:      listErr,
+			expected: []string{"unexpected error during validation: error listing nodes"},
+		},
+		{
+			name:        "same error",
+			previous:    healthy,
+			previousErr: listErr,
+			err:         listErr,
+		},
+		{
+			name:        "recovers from error",
+			previous:    healthy,
+			previousErr: listErr,
+			result:      healthy,
+			expected:    []string{"validation succeeded again"},
+		},
+	}
+	for _, g := range grid {
+		t.Run(g.name, func(t *testing.T) {
+			actual := validationTransitions(g.previous, g.previousErr, g.result, g.err)
+			if !reflect.DeepEqual(actual, g.expected) {
+				t.Errorf("expected %q, got %q", g.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,179 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kops
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+var weekdays = map[string]time.Weekday{
+	"sun":       time.Sunday,
+	"sunday":    time.Sunday,
+	"mon":       time.Monday,
+	"monday":    time.Monday,
+	"tue":       time.Tuesday,
+	"tuesday":   time.Tuesday,
+	"wed":       time.Wednesday,
+	"wednesday": time.Wednesday,
+	"thu":       time.Thursday,
+	"thursday":  time.Thursday,
+	"fri":       time.Friday,
+	"friday":    time.Friday,
+	"sat":       time.Saturday,
+	"saturday":  time.Saturday,
+}
+
+// ParseWeekday parses a day of the week, such as "Sat" or "Saturday".
+func ParseWeekday(s string) (time.Weekday, error) {
+	day, found := weekdays[strings.ToLower(s)]
+	if !found {
+		return 0, fmt.Errorf("unknown day of the week %q", s)
+	}
+	return day, nil
+}
+
+// ParseTimeOfDay parses a time of day as HH:MM, returning the hour and minute.
+func ParseTimeOfDay(s string) (int, int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, 0, fmt.Errorf("time of day %q is not of the form HH:MM", s)
+	}
+	return t.Hour(), t.Minute(), nil
+}
+
+// maintenanceWindowSchedule is the parsed form of a MaintenanceWindow.
+type maintenanceWindowSchedule struct {
+	days                [7]bool
+	startHour, startMin int
+	endHour, endMin     int
+	endsOnFollowingDay  bool
+	location            *time.Location
+}
+
+func (w *MaintenanceWindow) schedule() (*maintenanceWindowSchedule, error) {
+	s := &maintenanceWindowSchedule{}
+	if len(w.Days) == 0 {
+		for i := range s.days {
+			s.days[i] = true
+		}
+	}
+	for _, d := range w.Days {
+		day, err := ParseWeekday(d)
+		if err != nil {
+			return nil, err
+		}
+		s.days[day] = true
+	}
+
+	var err error
+	if s.startHour, s.startMin, err = ParseTimeOfDay(w.Start); err != nil {
+		return nil, err
+	}
+	if s.endHour, s.endMin, err = ParseTimeOfDay(w.End); err != nil {
+		return nil, err
+	}
+	s.endsOnFollowingDay = s.endHour*60+s.endMin <= s.startHour*60+s.startMin
+
+	s.location = time.UTC
+	if w.TimeZone != "" {
+		if s.location, err = time.LoadLocation(w.TimeZone); err != nil {
+			return nil, fmt.Errorf("unknown time zone %q: %w", w.TimeZone, err)
+		}
+	}
+	return s, nil
+}
+
+// occurrence returns the start and end of the window starting on the given day, if it starts on that day.
+func (s *maintenanceWindowSchedule) occurrence(year int, month time.Month, day int) (time.Time, time.Time, bool) {
+	start := time.Date(year, month, day, s.startHour, s.startMin, 0, 0, s.location)
+	if !s.days[start.Weekday()] {
+		return time.Time{}, time.Time{}, false
+	}
+	endDay := day
+	if s.endsOnFollowingDay {
+		endDay++
+	}
+	end := time.Date(year, month, endDay, s.endHour, s.endMin, 0, 0, s.location)
+	return start, end, true
+}
+
+// Validate returns an error if the window cannot be parsed.
+func (w *MaintenanceWindow) Validate() error {
+	_, err := w.schedule()
+	return err
+}
+
+// IsOpen returns whether the window is open at t, and if so, when it closes.
+func (w *MaintenanceWindow) IsOpen(t time.Time) (bool, time.Time, error) {
+	s, err := w.schedule()
+	if err != nil {
+		return false, time.Time{}, err
+	}
+	local := t.In(s.location)
+	// A window that started yesterday may still be open.
+	for _, offset := range []int{0, -1} {
+		start, end, found := s.occurrence(local.Year(), local.Month(), local.Day()+offset)
+		if found && !t.Before(start) && t.Before(end) {
+			return true, end, nil
+		}
+	}
+	return false, time.Time{}, nil
+}
+
+// NextStart returns when the window next opens after t.
+func (w *MaintenanceWindow) NextStart(t time.Time) (time.Time, error) {
+	s, err := w.schedule()
+	if err != nil {
+		return time.Time{}, err
+	}
+	local := t.In(s.location)
+	for offset := 0; offset <= 7; offset++ {
+		start, _, found := s.occurrence(local.Year(), local.Month(), local.Day()+offset)
+		if found && start.After(t) {
+			return start, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("maintenance window has no days")
+}
+
+// MaintenanceWindowsOpen returns whether any of the windows is open at t, which is always the case if there are none.
+// If none is open, it also returns when the first of them next opens.
+func MaintenanceWindowsOpen(windows []MaintenanceWindow, t time.Time) (bool, time.Time, error) {
+	if len(windows) == 0 {
+		return true, time.Time{}, nil
+	}
+	var next time.Time
+	for i := range windows {
+		open, _, err := windows[i].IsOpen(t)
+		if err != nil {
+			return false, time.Time{}, err
+		}
+		if open {
+			return true, time.Time{}, nil
+		}
+		start, err := windows[i].NextStart(t)
+		if err != nil {
+			return false, time.Time{}, err
+		}
+		if next.IsZero() || start.Before(next) {
+			next = start
+		}
+	}
+	return false, next, nil
+}
@@ -0,0 +1,153 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kops
+
+import (
+	"testing"
+	"time"
+)
+
+func mustParseTime(t *testing.T, s string) time.Time {
+	v, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		t.Fatalf("error parsing time %q: %v", s, err)
+	}
+	return v
+}
+
+func Test_MaintenanceWindowIsOpen(t *testing.T) {
+	grid := []struct {
+		Window    MaintenanceWindow
+		Time      string
+		Open      bool
+		Closes    string
+		NextStart string
+	}{
+		{
+			// Saturday, inside the window
+			Window: MaintenanceWindow{Days: []string{"Sat"}, Start: "02:00", End: "06:00"},
+			Time:   "2023-03-04T03:00:00Z",
+			Open:   true,
+			Closes: "2023-03-04T06:00:00Z",
+		},
+		{
+			// Saturday, after the window
+			Window:    MaintenanceWindow{Days: []string{"saturday"}, Start: "02:00", End: "06:00"},
+			Time:      "2023-03-04T06:00:00Z",
+			NextStart: "2023-03-11T02:00:00Z",
+		},
+		{
+			// Friday, before the window
+			Window:    MaintenanceWindow{Days: []string{"Sat", "Sun"}, Start: "02:00", End: "06:00"},
+			Time:      "2023-03-03T23:00:00Z",
+			NextStart: "2023-03-04T02:00:00Z",
+		},
+		{
+			// Window starting on Saturday and ending on Sunday
+			Window: MaintenanceWindow{Days: []string{"Sat"}, Start: "22:00", End: "04:00"},
+			Time:   "2023-03-05T01:00:00Z",
+			Open:   true,
+			Closes: "2023-03-05T04:00:00Z",
+		},
+		{
+			// Every day, in another time zone
+			Window: MaintenanceWindow{Start: "01:00", End: "03:00", TimeZone: "Europe/Berlin"},
+			Time:   "2023-03-01T00:30:00Z",
+			Open:   true,
+			Closes: "2023-03-01T02:00:00Z",
+		},
+		{
+			Window:    MaintenanceWindow{Start: "01:00", End: "03:00", TimeZone: "Europe/Berlin"},
+			Time:      "2023-03-01T02:30:00Z",
+			NextStart: "2023-03-02T00:00:00Z",
+		},
+	}
+	for _, g := range grid {
+		now := mustParseTime(t, g.Time)
+		open, closes, err := g.Window.IsOpen(now)
+		if err != nil {
+			t.Errorf("unexpected error for %+v at %s: %v", g.Window, g.Time, err)
+			continue
+		}
+		if open != g.Open {
+			t.Errorf("expected open=%v for %+v at %s, got %v", g.Open, g.Window, g.Time, open)
+			continue
+		}
+		if open {
+			if !closes.Equal(mustParseTime(t, g.Closes)) {
+				t.Errorf("expected %+v at %s to close at %s, got %s", g.Window, g.Time, g.Closes, closes.UTC().Format(time.RFC3339))
+			}
+			continue
+		}
+		next, err := g.Window.NextStart(now)
+		if err != nil {
+			t.Errorf("unexpected error for %+v at %s: %v", g.Window, g.Time, err)
+			continue
+		}
+		if !next.Equal(mustParseTime(t, g.NextStart)) {
+			t.Errorf("expected %+v at %s to next open at %s, got %s", g.Window, g.Time, g.NextStart, next.UTC().Format(time.RFC3339))
+		}
+	}
+}
+
+func Test_MaintenanceWindowsOpen(t *testing.T) {
+	now := mustParseTime(t, "2023-03-01T12:00:00Z") // a Wednesday
+
+	open, _, err := MaintenanceWindowsOpen(nil, now)
+	if err != nil || !open {
+		t.Errorf("expected no windows to be open, got %v, %v", open, err)
+	}
+
+	windows := []MaintenanceWindow{
+		{Days: []string{"Sat"}, Start: "02:00", End: "06:00"},
+		{Days: []string{"Thu"}, Start: "20:00", End: "22:00"},
+	}
+	open, next, err := MaintenanceWindowsOpen(windows, now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if open {
+		t.Errorf("expected windows to be closed")
+	}
+	if expected := mustParseTime(t, "2023-03-02T20:00:00Z"); !next.Equal(expected) {
+		t.Errorf("expected windows to next open at %s, got %s", expected, next)
+	}
+
+	windows = append(windows, MaintenanceWindow{Days: []string{"Wed"}, Start: "11:00", End: "13:00"})
+	open, _, err = MaintenanceWindowsOpen(windows, now)
+	if err != nil || !open {
+		t.Errorf("expected windows to be open, got %v, %v", open, err)
+	}
+}
+
+func Test_MaintenanceWindowValidate(t *testing.T) {
+	grid := []MaintenanceWindow{
+		{Days: []string{"Someday"}, Start: "02:00", End: "06:00"},
+		{Start: "2am", End: "06:00"},
+		{Start: "02:00", End: "24:30"},
+		{Start: "02:00", End: "06:00", TimeZone: "Nowhere/Special"},
+	}
+	for _, w := range grid {
+		if err := w.Validate(); err == nil {
+			t.Errorf("expected error validating %+v", w)
+		}
+	}
+	w := MaintenanceWindow{Days: []string{"MON", "Friday"}, Start: "22:30", End: "01:00", TimeZone: "America/New_York"}
+	if err := w.Validate(); err != nil {
+		t.Errorf("unexpected error validating %+v: %v", w, err)
+	}
+}
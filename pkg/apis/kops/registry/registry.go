@@ -31,6 +31,20 @@ const (
 	PathClusterCompleted = "cluster-completed.spec"
 	// PathKopsVersionUpdated is the path for the version of kops last used to apply the cluster.
 	PathKopsVersionUpdated = "kops-version.txt"
+	// PathAppliedResources is the path for the record of the tasks last applied to the cloud of the cluster.
+	PathAppliedResources = "applied-resources"
+	// PathPrunedInstanceGroups is the directory holding the instance groups pruned by kops apply whose cloud resources are yet to be deleted.
+	PathPrunedInstanceGroups = "pruned-instancegroups"
+	// PathLock is the path for the lease that locks the cluster during mutating operations.
+	PathLock = "lock"
+	// PathHistory is the directory holding the revision log of changes to the cluster and instance groups.
+	PathHistory = "history"
+	// PathRotation is the directory holding the progress of keyset rotations.
+	PathRotation = "rotation"
+	// PathRollingUpdate is the path for the progress of the last rolling update of the cluster.
+	PathRollingUpdate = "rolling-update"
+	// PathRollingUpdatePaused is the path for the flag that pauses rolling updates of the cluster.
+	PathRollingUpdatePaused = "rolling-update-paused"
 )
 
 func ConfigBase(c *api.Cluster) (vfs.Path, error) {
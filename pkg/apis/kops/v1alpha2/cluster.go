@@ -99,6 +99,8 @@ type ClusterSpec struct {
 	SecretStore string `json:"secretStore,omitempty"`
 	// KeyStore is the VFS path to where SSL keys and certificates are stored
 	KeyStore string `json:"keyStore,omitempty"`
+	// PKI configures the private keys that kOps generates for the keysets of the cluster.
+	PKI *PKISpec `json:"pki,omitempty"`
 	// ConfigStore is the VFS path to where the configuration (Cluster, InstanceGroups etc) is stored
 	ConfigStore string `json:"configStore,omitempty"`
 	// DNSZone is the DNS zone we should use when configuring DNS
@@ -232,6 +234,8 @@ type ClusterSpec struct {
 	SysctlParameters []string `json:"sysctlParameters,omitempty"`
 	// RollingUpdate defines the default rolling-update settings for instance groups
 	RollingUpdate *RollingUpdate `json:"rollingUpdate,omitempty"`
+	// HealthChecks are checks, beyond the readiness of nodes and system pods, that must pass for the cluster to validate.
+	HealthChecks []ClusterHealthCheck `json:"healthChecks,omitempty"`
 	// ClusterAutoscaler defines the cluaster autoscaler configuration.
 	ClusterAutoscaler *ClusterAutoscalerConfig `json:"clusterAutoscaler,omitempty"`
 	// WarmPool defines the default warm pool settings for instance groups (AWS only).
@@ -258,6 +262,22 @@ type KarpenterConfig struct {
 	Enabled bool `json:"enabled,omitempty"`
 }
 
+// PKISpec configures the private keys that kOps generates for the keysets of the cluster.
+type PKISpec struct {
+	// KeyAlgorithm is the algorithm of the private keys that are generated, unless overridden for a keyset.
+	// One of RSA (the default), ECDSA-P256, ECDSA-P384 or Ed25519.
+	// Changing it does not replace existing keys; they keep their algorithm until they are rotated.
+	KeyAlgorithm string `json:"keyAlgorithm,omitempty"`
+	// Keysets configures individual keysets, by the name of the keyset.
+	Keysets map[string]PKIKeysetSpec `json:"keysets,omitempty"`
+}
+
+// PKIKeysetSpec configures the private keys that kOps generates for a keyset.
+type PKIKeysetSpec struct {
+	// KeyAlgorithm is the algorithm of the private keys that are generated for the keyset.
+	KeyAlgorithm string `json:"keyAlgorithm,omitempty"`
+}
+
 // ServiceAccountIssuerDiscoveryConfig configures an OIDC Issuer.
 type ServiceAccountIssuerDiscoveryConfig struct {
 	// DiscoveryStore is the VFS path to where OIDC Issuer Discovery metadata is stored.
@@ -844,13 +864,189 @@ type RollingUpdate struct {
 	// desired machines (for example 10%).
 	// The absolute number is calculated from a percentage by rounding up.
 	// Has no effect on instance groups with role "Master".
-	// Defaults to 1 on AWS, 0 otherwise.
+	// Defaults to 1 on AWS, GCE, Azure and OpenStack, 0 otherwise.
 	// Example: when this is set to 30%, the InstanceGroup can be scaled
 	// up immediately when the rolling update starts, such that the total
 	// number of old and new nodes do not exceed 130% of desired
 	// nodes.
 	// +optional
 	MaxSurge *intstr.IntOrString `json:"maxSurge,omitempty"`
+	// MaintenanceWindows are the recurring windows of time during which instances may be replaced.
+	// Outside of them, a rolling update waits for, or stops at, the next window before draining
+	// and terminating another instance. Instances already being replaced when a window ends are finished.
+	// The windows of an instance group replace, rather than add to, those of the cluster.
+	// Defaults to no restriction.
+	// +optional
+	MaintenanceWindows []MaintenanceWindow `json:"maintenanceWindows,omitempty"`
+	// InstanceHooks are run at points in the replacement of each instance, such as before it is drained.
+	// The hooks of an instance group replace, rather than add to, those of the cluster.
+	// +optional
+	InstanceHooks []RollingUpdateHook `json:"instanceHooks,omitempty"`
+	// Strategy is how the instances of an instance group are replaced: RollingUpdate replaces
+	// them a few at a time, while BlueGreen launches all the replacements from a temporary copy
+	// of the cloud group, validates them, drains the old instances, then moves the replacements
+	// into the cloud group, rolling back if they fail to validate. BlueGreen only applies to
+	// instance groups with role "Node" on AWS.
+	// Defaults to RollingUpdate.
+	// +optional
+	Strategy RollingUpdateStrategy `json:"strategy,omitempty"`
+	// Drain configures how the node of an instance is drained before the instance is terminated.
+	// Each drain setting of an instance group that is not set defaults to that of the cluster.
+	// +optional
+	Drain *RollingUpdateDrain `json:"drain,omitempty"`
+	// OnFailure is what a rolling update does when the cluster fails validation after instances of the group
+	// were replaced: Stop leaves the group as it is, while Rollback returns the group to the template version
+	// it last updated to successfully, replaces the instances created from the failing version, and stops.
+	// Rollback only applies to clouds with versioned instance templates, such as AWS and GCE.
+	// Defaults to Stop.
+	// +optional
+	OnFailure RollingUpdateFailurePolicy `json:"onFailure,omitempty"`
+}
+
+// RollingUpdateStrategy is how the instances of an instance group are replaced.
+type RollingUpdateStrategy string
+
+// RollingUpdateFailurePolicy is what a rolling update does when the cluster fails validation.
+type RollingUpdateFailurePolicy string
+
+// RollingUpdateDrain configures how the node of an instance is drained during a rolling update.
+type RollingUpdateDrain struct {
+	// SkipPodSelector is a label selector of the pods that are left on the node rather than evicted,
+	// such as "app=cache". Pods of DaemonSets are never evicted.
+	// +optional
+	SkipPodSelector string `json:"skipPodSelector,omitempty"`
+	// GracePeriodSeconds overrides the termination grace period of the evicted pods.
+	// Defaults to the termination grace period of each pod.
+	// +optional
+	GracePeriodSeconds *int64 `json:"gracePeriodSeconds,omitempty"`
+	// ForceUnmanagedPods evicts pods that are not managed by a controller, and so are not recreated elsewhere.
+	// If false, draining fails when the node has such pods.
+	// Defaults to true.
+	// +optional
+	ForceUnmanagedPods *bool `json:"forceUnmanagedPods,omitempty"`
+	// DeleteEmptyDirData evicts pods using emptyDir volumes, whose data is lost.
+	// If false, draining fails when the node has such pods.
+	// Defaults to true.
+	// +optional
+	DeleteEmptyDirData *bool `json:"deleteEmptyDirData,omitempty"`
+	// DisableEvictionAfter is how long pods are evicted, respecting their PodDisruptionBudgets, before the
+	// remaining pods are deleted regardless of their PodDisruptionBudgets.
+	// Defaults to never deleting them.
+	// +optional
+	DisableEvictionAfter *metav1.Duration `json:"disableEvictionAfter,omitempty"`
+	// PriorityNamespaces are namespaces whose pods are evicted, one namespace after the other,
+	// before the pods of other namespaces.
+	// +optional
+	PriorityNamespaces []string `json:"priorityNamespaces,omitempty"`
+}
+
+// MaintenanceWindow is a recurring window of time during which instances may be replaced.
+type MaintenanceWindow struct {
+	// Days are the days of the week on which the window starts, such as "Sat" or "Sunday".
+	// Defaults to every day.
+	Days []string `json:"days,omitempty"`
+	// Start is the time of day at which the window starts, as HH:MM.
+	Start string `json:"start"`
+	// End is the time of day at which the window ends, as HH:MM.
+	// If it is not after Start, the window ends on the following day.
+	End string `json:"end"`
+	// TimeZone is the IANA name of the time zone of Start and End, such as "Europe/Berlin".
+	// Defaults to UTC.
+	TimeZone string `json:"timeZone,omitempty"`
+}
+
+// RollingUpdateHookPhase is a point in the replacement of an instance at which a hook is run.
+type RollingUpdateHookPhase string
+
+// RollingUpdateHookFailurePolicy is what a rolling update does when a hook fails.
+type RollingUpdateHookFailurePolicy string
+
+// RollingUpdateHook is run at points in the replacement of each instance during a rolling update.
+// Exactly one of Exec or Webhook must be set.
+type RollingUpdateHook struct {
+	// Name identifies the hook in logs and errors.
+	Name string `json:"name"`
+	// Phases are the points in the replacement of an instance at which the hook is run:
+	// BeforeDrain, AfterDrain, AfterTerminate or AfterValidate. Defaults to all of them.
+	Phases []RollingUpdateHookPhase `json:"phases,omitempty"`
+	// Exec runs a command on the machine performing the rolling update.
+	// It is only run if the rolling update is given --allow-spec-exec-hooks.
+	Exec *ExecRollingUpdateHook `json:"exec,omitempty"`
+	// Webhook sends an HTTP POST request.
+	Webhook *WebhookRollingUpdateHook `json:"webhook,omitempty"`
+	// FailurePolicy is what the rolling update does when the hook fails: Abort, Block or Ignore.
+	// Defaults to Abort.
+	FailurePolicy RollingUpdateHookFailurePolicy `json:"failurePolicy,omitempty"`
+	// Timeout is the maximum time the hook may take. Defaults to 5 minutes.
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+}
+
+// ExecRollingUpdateHook runs a command. The phase, instance ID, node name and instance group are passed
+// in the KOPS_HOOK_PHASE, KOPS_INSTANCE_ID, KOPS_NODE_NAME and KOPS_INSTANCE_GROUP environment variables,
+// and as a JSON object on standard input. The hook fails if the command exits with a non-zero status.
+type ExecRollingUpdateHook struct {
+	// Command is the command to run, followed by its arguments.
+	Command []string `json:"command"`
+}
+
+// WebhookRollingUpdateHook sends the phase, instance ID, node name and instance group as a JSON object
+// in an HTTP POST request. The hook fails if the response does not have a 2xx status.
+type WebhookRollingUpdateHook struct {
+	// URL is the http or https URL the request is sent to.
+	URL string `json:"url"`
+	// HeaderSecrets are headers added to the request, such as for authentication, by name.
+	// Each value is the name of the secret holding the value of the header, as created by
+	// kops create secret instancehookheader.
+	HeaderSecrets map[string]string `json:"headerSecrets,omitempty"`
+}
+
+// ClusterHealthCheck is a check, beyond the readiness of nodes and system pods, that must pass for the cluster to validate.
+// Exactly one of its checks must be set.
+type ClusterHealthCheck struct {
+	// Name identifies the check in validation results.
+	Name string `json:"name"`
+	// Workload requires a Deployment or StatefulSet to be fully available.
+	Workload *WorkloadHealthCheck `json:"workload,omitempty"`
+	// PodDisruptionBudget requires PodDisruptionBudgets to allow at least one disruption.
+	PodDisruptionBudget *PodDisruptionBudgetHealthCheck `json:"podDisruptionBudget,omitempty"`
+	// HTTP requires an HTTP endpoint to return a successful status.
+	HTTP *HTTPHealthCheck `json:"http,omitempty"`
+	// Pods requires the pods matching a label selector to be ready.
+	Pods *PodsHealthCheck `json:"pods,omitempty"`
+}
+
+// WorkloadHealthCheck requires a Deployment or StatefulSet to be fully available.
+type WorkloadHealthCheck struct {
+	// Kind is the kind of the workload: Deployment or StatefulSet.
+	Kind string `json:"kind"`
+	// Namespace is the namespace of the workload.
+	Namespace string `json:"namespace"`
+	// Name is the name of the workload.
+	Name string `json:"name"`
+}
+
+// PodDisruptionBudgetHealthCheck requires PodDisruptionBudgets to allow at least one disruption.
+type PodDisruptionBudgetHealthCheck struct {
+	// Namespace restricts the check to PodDisruptionBudgets in the namespace, or all namespaces if empty.
+	Namespace string `json:"namespace,omitempty"`
+	// Name restricts the check to the named PodDisruptionBudget, or all in the namespace if empty.
+	Name string `json:"name,omitempty"`
+}
+
+// HTTPHealthCheck requires an HTTP endpoint to return a successful status.
+type HTTPHealthCheck struct {
+	// URL is the http or https URL that is requested, from where the cluster is validated.
+	URL string `json:"url"`
+}
+
+// PodsHealthCheck requires the pods matching a label selector to be ready.
+type PodsHealthCheck struct {
+	// Namespace restricts the check to pods in the namespace, or all namespaces if empty.
+	Namespace string `json:"namespace,omitempty"`
+	// Selector is the label selector of the pods, such as app=kafka.
+	Selector string `json:"selector"`
+	// MinReady is the number of pods that must match the selector and be ready. Defaults to 1.
+	MinReady *int32 `json:"minReady,omitempty"`
 }
 
 type PackagesConfig struct {
@@ -264,6 +264,16 @@ func RegisterConversions(s *runtime.Scheme) error {
 	}); err != nil {
 		return err
 	}
+	if err := s.AddGeneratedConversionFunc((*ClusterHealthCheck)(nil), (*kops.ClusterHealthCheck)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha2_ClusterHealthCheck_To_kops_ClusterHealthCheck(a.(*ClusterHealthCheck), b.(*kops.ClusterHealthCheck), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*kops.ClusterHealthCheck)(nil), (*ClusterHealthCheck)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_kops_ClusterHealthCheck_To_v1alpha2_ClusterHealthCheck(a.(*kops.ClusterHealthCheck), b.(*ClusterHealthCheck), scope)
+	}); err != nil {
+		return err
+	}
 	if err := s.AddGeneratedConversionFunc((*ClusterList)(nil), (*kops.ClusterList)(nil), func(a, b interface{}, scope conversion.Scope) error {
 		return Convert_v1alpha2_ClusterList_To_kops_ClusterList(a.(*ClusterList), b.(*kops.ClusterList), scope)
 	}); err != nil {
@@ -424,6 +434,16 @@ func RegisterConversions(s *runtime.Scheme) error {
 	}); err != nil {
 		return err
 	}
+	if err := s.AddGeneratedConversionFunc((*ExecRollingUpdateHook)(nil), (*kops.ExecRollingUpdateHook)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha2_ExecRollingUpdateHook_To_kops_ExecRollingUpdateHook(a.(*ExecRollingUpdateHook), b.(*kops.ExecRollingUpdateHook), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*kops.ExecRollingUpdateHook)(nil), (*ExecRollingUpdateHook)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_kops_ExecRollingUpdateHook_To_v1alpha2_ExecRollingUpdateHook(a.(*kops.ExecRollingUpdateHook), b.(*ExecRollingUpdateHook), scope)
+	}); err != nil {
+		return err
+	}
 	if err := s.AddGeneratedConversionFunc((*ExternalNetworkingSpec)(nil), (*kops.ExternalNetworkingSpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
 		return Convert_v1alpha2_ExternalNetworkingSpec_To_kops_ExternalNetworkingSpec(a.(*ExternalNetworkingSpec), b.(*kops.ExternalNetworkingSpec), scope)
 	}); err != nil {
@@ -484,6 +504,16 @@ func RegisterConversions(s *runtime.Scheme) error {
 	}); err != nil {
 		return err
 	}
+	if err := s.AddGeneratedConversionFunc((*HTTPHealthCheck)(nil), (*kops.HTTPHealthCheck)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha2_HTTPHealthCheck_To_kops_HTTPHealthCheck(a.(*HTTPHealthCheck), b.(*kops.HTTPHealthCheck), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*kops.HTTPHealthCheck)(nil), (*HTTPHealthCheck)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_kops_HTTPHealthCheck_To_v1alpha2_HTTPHealthCheck(a.(*kops.HTTPHealthCheck), b.(*HTTPHealthCheck), scope)
+	}); err != nil {
+		return err
+	}
 	if err := s.AddGeneratedConversionFunc((*HTTPProxy)(nil), (*kops.HTTPProxy)(nil), func(a, b interface{}, scope conversion.Scope) error {
 		return Convert_v1alpha2_HTTPProxy_To_kops_HTTPProxy(a.(*HTTPProxy), b.(*kops.HTTPProxy), scope)
 	}); err != nil {
@@ -774,6 +804,16 @@ func RegisterConversions(s *runtime.Scheme) error {
 	}); err != nil {
 		return err
 	}
+	if err := s.AddGeneratedConversionFunc((*MaintenanceWindow)(nil), (*kops.MaintenanceWindow)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha2_MaintenanceWindow_To_kops_MaintenanceWindow(a.(*MaintenanceWindow), b.(*kops.MaintenanceWindow), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*kops.MaintenanceWindow)(nil), (*MaintenanceWindow)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_kops_MaintenanceWindow_To_v1alpha2_MaintenanceWindow(a.(*kops.MaintenanceWindow), b.(*MaintenanceWindow), scope)
+	}); err != nil {
+		return err
+	}
 	if err := s.AddGeneratedConversionFunc((*MetricsServerConfig)(nil), (*kops.MetricsServerConfig)(nil), func(a, b interface{}, scope conversion.Scope) error {
 		return Convert_v1alpha2_MetricsServerConfig_To_kops_MetricsServerConfig(a.(*MetricsServerConfig), b.(*kops.MetricsServerConfig), scope)
 	}); err != nil {
@@ -964,6 +1004,26 @@ func RegisterConversions(s *runtime.Scheme) error {
 	}); err != nil {
 		return err
 	}
+	if err := s.AddGeneratedConversionFunc((*PKIKeysetSpec)(nil), (*kops.PKIKeysetSpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha2_PKIKeysetSpec_To_kops_PKIKeysetSpec(a.(*PKIKeysetSpec), b.(*kops.PKIKeysetSpec), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*kops.PKIKeysetSpec)(nil), (*PKIKeysetSpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_kops_PKIKeysetSpec_To_v1alpha2_PKIKeysetSpec(a.(*kops.PKIKeysetSpec), b.(*PKIKeysetSpec), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*PKISpec)(nil), (*kops.PKISpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha2_PKISpec_To_kops_PKISpec(a.(*PKISpec), b.(*kops.PKISpec), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*kops.PKISpec)(nil), (*PKISpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_kops_PKISpec_To_v1alpha2_PKISpec(a.(*kops.PKISpec), b.(*PKISpec), scope)
+	}); err != nil {
+		return err
+	}
 	if err := s.AddGeneratedConversionFunc((*PackagesConfig)(nil), (*kops.PackagesConfig)(nil), func(a, b interface{}, scope conversion.Scope) error {
 		return Convert_v1alpha2_PackagesConfig_To_kops_PackagesConfig(a.(*PackagesConfig), b.(*kops.PackagesConfig), scope)
 	}); err != nil {
@@ -974,6 +1034,16 @@ func RegisterConversions(s *runtime.Scheme) error {
 	}); err != nil {
 		return err
 	}
+	if err := s.AddGeneratedConversionFunc((*PodDisruptionBudgetHealthCheck)(nil), (*kops.PodDisruptionBudgetHealthCheck)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha2_PodDisruptionBudgetHealthCheck_To_kops_PodDisruptionBudgetHealthCheck(a.(*PodDisruptionBudgetHealthCheck), b.(*kops.PodDisruptionBudgetHealthCheck), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*kops.PodDisruptionBudgetHealthCheck)(nil), (*PodDisruptionBudgetHealthCheck)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_kops_PodDisruptionBudgetHealthCheck_To_v1alpha2_PodDisruptionBudgetHealthCheck(a.(*kops.PodDisruptionBudgetHealthCheck), b.(*PodDisruptionBudgetHealthCheck), scope)
+	}); err != nil {
+		return err
+	}
 	if err := s.AddGeneratedConversionFunc((*PodIdentityWebhookSpec)(nil), (*kops.PodIdentityWebhookSpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
 		return Convert_v1alpha2_PodIdentityWebhookSpec_To_kops_PodIdentityWebhookSpec(a.(*PodIdentityWebhookSpec), b.(*kops.PodIdentityWebhookSpec), scope)
 	}); err != nil {
@@ -984,6 +1054,16 @@ func RegisterConversions(s *runtime.Scheme) error {
 	}); err != nil {
 		return err
 	}
+	if err := s.AddGeneratedConversionFunc((*PodsHealthCheck)(nil), (*kops.PodsHealthCheck)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha2_PodsHealthCheck_To_kops_PodsHealthCheck(a.(*PodsHealthCheck), b.(*kops.PodsHealthCheck), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*kops.PodsHealthCheck)(nil), (*PodsHealthCheck)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_kops_PodsHealthCheck_To_v1alpha2_PodsHealthCheck(a.(*kops.PodsHealthCheck), b.(*PodsHealthCheck), scope)
+	}); err != nil {
+		return err
+	}
 	if err := s.AddGeneratedConversionFunc((*RBACAuthorizationSpec)(nil), (*kops.RBACAuthorizationSpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
 		return Convert_v1alpha2_RBACAuthorizationSpec_To_kops_RBACAuthorizationSpec(a.(*RBACAuthorizationSpec), b.(*kops.RBACAuthorizationSpec), scope)
 	}); err != nil {
@@ -1004,6 +1084,26 @@ func RegisterConversions(s *runtime.Scheme) error {
 	}); err != nil {
 		return err
 	}
+	if err := s.AddGeneratedConversionFunc((*RollingUpdateDrain)(nil), (*kops.RollingUpdateDrain)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha2_RollingUpdateDrain_To_kops_RollingUpdateDrain(a.(*RollingUpdateDrain), b.(*kops.RollingUpdateDrain), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*kops.RollingUpdateDrain)(nil), (*RollingUpdateDrain)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_kops_RollingUpdateDrain_To_v1alpha2_RollingUpdateDrain(a.(*kops.RollingUpdateDrain), b.(*RollingUpdateDrain), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*RollingUpdateHook)(nil), (*kops.RollingUpdateHook)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha2_RollingUpdateHook_To_kops_RollingUpdateHook(a.(*RollingUpdateHook), b.(*kops.RollingUpdateHook), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*kops.RollingUpdateHook)(nil), (*RollingUpdateHook)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_kops_RollingUpdateHook_To_v1alpha2_RollingUpdateHook(a.(*kops.RollingUpdateHook), b.(*RollingUpdateHook), scope)
+	}); err != nil {
+		return err
+	}
 	if err := s.AddGeneratedConversionFunc((*RomanaNetworkingSpec)(nil), (*kops.RomanaNetworkingSpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
 		return Convert_v1alpha2_RomanaNetworkingSpec_To_kops_RomanaNetworkingSpec(a.(*RomanaNetworkingSpec), b.(*kops.RomanaNetworkingSpec), scope)
 	}); err != nil {
@@ -1164,6 +1264,26 @@ func RegisterConversions(s *runtime.Scheme) error {
 	}); err != nil {
 		return err
 	}
+	if err := s.AddGeneratedConversionFunc((*WebhookRollingUpdateHook)(nil), (*kops.WebhookRollingUpdateHook)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha2_WebhookRollingUpdateHook_To_kops_WebhookRollingUpdateHook(a.(*WebhookRollingUpdateHook), b.(*kops.WebhookRollingUpdateHook), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*kops.WebhookRollingUpdateHook)(nil), (*WebhookRollingUpdateHook)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_kops_WebhookRollingUpdateHook_To_v1alpha2_WebhookRollingUpdateHook(a.(*kops.WebhookRollingUpdateHook), b.(*WebhookRollingUpdateHook), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*WorkloadHealthCheck)(nil), (*kops.WorkloadHealthCheck)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha2_WorkloadHealthCheck_To_kops_WorkloadHealthCheck(a.(*WorkloadHealthCheck), b.(*kops.WorkloadHealthCheck), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*kops.WorkloadHealthCheck)(nil), (*WorkloadHealthCheck)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_kops_WorkloadHealthCheck_To_v1alpha2_WorkloadHealthCheck(a.(*kops.WorkloadHealthCheck), b.(*WorkloadHealthCheck), scope)
+	}); err != nil {
+		return err
+	}
 	if err := s.AddConversionFunc((*kops.CanalNetworkingSpec)(nil), (*CanalNetworkingSpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
 		return Convert_kops_CanalNetworkingSpec_To_v1alpha2_CanalNetworkingSpec(a.(*kops.CanalNetworkingSpec), b.(*CanalNetworkingSpec), scope)
 	}); err != nil {
@@ -2285,6 +2405,98 @@ func Convert_kops_ClusterAutoscalerConfig_To_v1alpha2_ClusterAutoscalerConfig(in
 	return autoConvert_kops_ClusterAutoscalerConfig_To_v1alpha2_ClusterAutoscalerConfig(in, out, s)
 }
 
+func autoConvert_v1alpha2_ClusterHealthCheck_To_kops_ClusterHealthCheck(in *ClusterHealthCheck, out *kops.ClusterHealthCheck, s conversion.Scope) error {
+	out.Name = in.Name
+	if in.Workload != nil {
+		in, out := &in.Workload, &out.Workload
+		*out = new(kops.WorkloadHealthCheck)
+		if err := Convert_v1alpha2_WorkloadHealthCheck_To_kops_WorkloadHealthCheck(*in, *out, s); err != nil {
+			return err
+		}
+	} else {
+		out.Workload = nil
+	}
+	if in.PodDisruptionBudget != nil {
+		in, out := &in.PodDisruptionBudget, &out.PodDisruptionBudget
+		*out = new(kops.PodDisruptionBudgetHealthCheck)
+		if err := Convert_v1alpha2_PodDisruptionBudgetHealthCheck_To_kops_PodDisruptionBudgetHealthCheck(*in, *out, s); err != nil {
+			return err
+		}
+	} else {
+		out.PodDisruptionBudget = nil
+	}
+	if in.HTTP != nil {
+		in, out := &in.HTTP, &out.HTTP
+		*out = new(kops.HTTPHealthCheck)
+		if err := Convert_v1alpha2_HTTPHealthCheck_To_kops_HTTPHealthCheck(*in, *out, s); err != nil {
+			return err
+		}
+	} else {
+		out.HTTP = nil
+	}
+	if in.Pods != nil {
+		in, out := &in.Pods, &out.Pods
+		*out = new(kops.PodsHealthCheck)
+		if err := Convert_v1alpha2_PodsHealthCheck_To_kops_PodsHealthCheck(*in, *out, s); err != nil {
+			return err
+		}
+	} else {
+		out.Pods = nil
+	}
+	return nil
+}
+
+// Convert_v1alpha2_ClusterHealthCheck_To_kops_ClusterHealthCheck is an autogenerated conversion function.
+func Convert_v1alpha2_ClusterHealthCheck_To_kops_ClusterHealthCheck(in *ClusterHealthCheck, out *kops.ClusterHealthCheck, s conversion.Scope) error {
+	return autoConvert_v1alpha2_ClusterHealthCheck_To_kops_ClusterHealthCheck(in, out, s)
+}
+
+func autoConvert_kops_ClusterHealthCheck_To_v1alpha2_ClusterHealthCheck(in *kops.ClusterHealthCheck, out *ClusterHealthCheck, s conversion.Scope) error {
+	out.Name = in.Name
+	if in.Workload != nil {
+		in, out := &in.Workload, &out.Workload
+		*out = new(WorkloadHealthCheck)
+		if err := Convert_kops_WorkloadHealthCheck_To_v1alpha2_WorkloadHealthCheck(*in, *out, s); err != nil {
+			return err
+		}
+	} else {
+		out.Workload = nil
+	}
+	if in.PodDisruptionBudget != nil {
+		in, out := &in.PodDisruptionBudget, &out.PodDisruptionBudget
+		*out = new(PodDisruptionBudgetHealthCheck)
+		if err := Convert_kops_PodDisruptionBudgetHealthCheck_To_v1alpha2_PodDisruptionBudgetHealthCheck(*in, *out, s); err != nil {
+			return err
+		}
+	} else {
+		out.PodDisruptionBudget = nil
+	}
+	if in.HTTP != nil {
+		in, out := &in.HTTP, &out.HTTP
+		*out = new(HTTPHealthCheck)
+		if err := Convert_kops_HTTPHealthCheck_To_v1alpha2_HTTPHealthCheck(*in, *out, s); err != nil {
+			return err
+		}
+	} else {
+		out.HTTP = nil
+	}
+	if in.Pods != nil {
+		in, out := &in.Pods, &out.Pods
+		*out = new(PodsHealthCheck)
+		if err := Convert_kops_PodsHealthCheck_To_v1alpha2_PodsHealthCheck(*in, *out, s); err != nil {
+			return err
+		}
+	} else {
+		out.Pods = nil
+	}
+	return nil
+}
+
+// Convert_kops_ClusterHealthCheck_To_v1alpha2_ClusterHealthCheck is an autogenerated conversion function.
+func Convert_kops_ClusterHealthCheck_To_v1alpha2_ClusterHealthCheck(in *kops.ClusterHealthCheck, out *ClusterHealthCheck, s conversion.Scope) error {
+	return autoConvert_kops_ClusterHealthCheck_To_v1alpha2_ClusterHealthCheck(in, out, s)
+}
+
 func autoConvert_v1alpha2_ClusterList_To_kops_ClusterList(in *ClusterList, out *kops.ClusterList, s conversion.Scope) error {
 	out.ListMeta = in.ListMeta
 	if in.Items != nil {
@@ -2364,6 +2576,15 @@ func autoConvert_v1alpha2_ClusterSpec_To_kops_ClusterSpec(in *ClusterSpec, out *
 	// INFO: in.Topology opted out of conversion generation
 	out.SecretStore = in.SecretStore
 	out.KeyStore = in.KeyStore
+	if in.PKI != nil {
+		in, out := &in.PKI, &out.PKI
+		*out = new(kops.PKISpec)
+		if err := Convert_v1alpha2_PKISpec_To_kops_PKISpec(*in, *out, s); err != nil {
+			return err
+		}
+	} else {
+		out.PKI = nil
+	}
 	out.ConfigStore = in.ConfigStore
 	out.DNSZone = in.DNSZone
 	if in.DNSControllerGossipConfig != nil {
@@ -2644,6 +2865,17 @@ func autoConvert_v1alpha2_ClusterSpec_To_kops_ClusterSpec(in *ClusterSpec, out *
 	} else {
 		out.RollingUpdate = nil
 	}
+	if in.HealthChecks != nil {
+		in, out := &in.HealthChecks, &out.HealthChecks
+		*out = make([]kops.ClusterHealthCheck, len(*in))
+		for i := range *in {
+			if err := Convert_v1alpha2_ClusterHealthCheck_To_kops_ClusterHealthCheck(&(*in)[i], &(*out)[i], s); err != nil {
+				return err
+			}
+		}
+	} else {
+		out.HealthChecks = nil
+	}
 	if in.ClusterAutoscaler != nil {
 		in, out := &in.ClusterAutoscaler, &out.ClusterAutoscaler
 		*out = new(kops.ClusterAutoscalerConfig)
@@ -2713,6 +2945,15 @@ func autoConvert_kops_ClusterSpec_To_v1alpha2_ClusterSpec(in *kops.ClusterSpec,
 	out.KubernetesVersion = in.KubernetesVersion
 	out.SecretStore = in.SecretStore
 	out.KeyStore = in.KeyStore
+	if in.PKI != nil {
+		in, out := &in.PKI, &out.PKI
+		*out = new(PKISpec)
+		if err := Convert_kops_PKISpec_To_v1alpha2_PKISpec(*in, *out, s); err != nil {
+			return err
+		}
+	} else {
+		out.PKI = nil
+	}
 	out.ConfigStore = in.ConfigStore
 	out.DNSZone = in.DNSZone
 	if in.DNSControllerGossipConfig != nil {
@@ -2981,6 +3222,17 @@ func autoConvert_kops_ClusterSpec_To_v1alpha2_ClusterSpec(in *kops.ClusterSpec,
 	} else {
 		out.RollingUpdate = nil
 	}
+	if in.HealthChecks != nil {
+		in, out := &in.HealthChecks, &out.HealthChecks
+		*out = make([]ClusterHealthCheck, len(*in))
+		for i := range *in {
+			if err := Convert_kops_ClusterHealthCheck_To_v1alpha2_ClusterHealthCheck(&(*in)[i], &(*out)[i], s); err != nil {
+				return err
+			}
+		}
+	} else {
+		out.HealthChecks = nil
+	}
 	if in.ClusterAutoscaler != nil {
 		in, out := &in.ClusterAutoscaler, &out.ClusterAutoscaler
 		*out = new(ClusterAutoscalerConfig)
@@ -3676,6 +3928,26 @@ func Convert_kops_ExecContainerAction_To_v1alpha2_ExecContainerAction(in *kops.E
 	return autoConvert_kops_ExecContainerAction_To_v1alpha2_ExecContainerAction(in, out, s)
 }
 
+func autoConvert_v1alpha2_ExecRollingUpdateHook_To_kops_ExecRollingUpdateHook(in *ExecRollingUpdateHook, out *kops.ExecRollingUpdateHook, s conversion.Scope) error {
+	out.Command = in.Command
+	return nil
+}
+
+// Convert_v1alpha2_ExecRollingUpdateHook_To_kops_ExecRollingUpdateHook is an autogenerated conversion function.
+func Convert_v1alpha2_ExecRollingUpdateHook_To_kops_ExecRollingUpdateHook(in *ExecRollingUpdateHook, out *kops.ExecRollingUpdateHook, s conversion.Scope) error {
+	return autoConvert_v1alpha2_ExecRollingUpdateHook_To_kops_ExecRollingUpdateHook(in, out, s)
+}
+
+func autoConvert_kops_ExecRollingUpdateHook_To_v1alpha2_ExecRollingUpdateHook(in *kops.ExecRollingUpdateHook, out *ExecRollingUpdateHook, s conversion.Scope) error {
+	out.Command = in.Command
+	return nil
+}
+
+// Convert_kops_ExecRollingUpdateHook_To_v1alpha2_ExecRollingUpdateHook is an autogenerated conversion function.
+func Convert_kops_ExecRollingUpdateHook_To_v1alpha2_ExecRollingUpdateHook(in *kops.ExecRollingUpdateHook, out *ExecRollingUpdateHook, s conversion.Scope) error {
+	return autoConvert_kops_ExecRollingUpdateHook_To_v1alpha2_ExecRollingUpdateHook(in, out, s)
+}
+
 func autoConvert_v1alpha2_ExternalDNSConfig_To_kops_ExternalDNSConfig(in *ExternalDNSConfig, out *kops.ExternalDNSConfig, s conversion.Scope) error {
 	// INFO: in.Disable opted out of conversion generation
 	out.WatchIngress = in.WatchIngress
@@ -3862,6 +4134,26 @@ func Convert_kops_GossipConfigSecondary_To_v1alpha2_GossipConfigSecondary(in *ko
 	return autoConvert_kops_GossipConfigSecondary_To_v1alpha2_GossipConfigSecondary(in, out, s)
 }
 
+func autoConvert_v1alpha2_HTTPHealthCheck_To_kops_HTTPHealthCheck(in *HTTPHealthCheck, out *kops.HTTPHealthCheck, s conversion.Scope) error {
+	out.URL = in.URL
+	return nil
+}
+
+// Convert_v1alpha2_HTTPHealthCheck_To_kops_HTTPHealthCheck is an autogenerated conversion function.
+func Convert_v1alpha2_HTTPHealthCheck_To_kops_HTTPHealthCheck(in *HTTPHealthCheck, out *kops.HTTPHealthCheck, s conversion.Scope) error {
+	return autoConvert_v1alpha2_HTTPHealthCheck_To_kops_HTTPHealthCheck(in, out, s)
+}
+
+func autoConvert_kops_HTTPHealthCheck_To_v1alpha2_HTTPHealthCheck(in *kops.HTTPHealthCheck, out *HTTPHealthCheck, s conversion.Scope) error {
+	out.URL = in.URL
+	return nil
+}
+
+// Convert_kops_HTTPHealthCheck_To_v1alpha2_HTTPHealthCheck is an autogenerated conversion function.
+func Convert_kops_HTTPHealthCheck_To_v1alpha2_HTTPHealthCheck(in *kops.HTTPHealthCheck, out *HTTPHealthCheck, s conversion.Scope) error {
+	return autoConvert_kops_HTTPHealthCheck_To_v1alpha2_HTTPHealthCheck(in, out, s)
+}
+
 func autoConvert_v1alpha2_HTTPProxy_To_kops_HTTPProxy(in *HTTPProxy, out *kops.HTTPProxy, s conversion.Scope) error {
 	out.Host = in.Host
 	out.Port = in.Port
@@ -5728,6 +6020,32 @@ func Convert_kops_LyftVPCNetworkingSpec_To_v1alpha2_LyftVPCNetworkingSpec(in *ko
 	return autoConvert_kops_LyftVPCNetworkingSpec_To_v1alpha2_LyftVPCNetworkingSpec(in, out, s)
 }
 
+func autoConvert_v1alpha2_MaintenanceWindow_To_kops_MaintenanceWindow(in *MaintenanceWindow, out *kops.MaintenanceWindow, s conversion.Scope) error {
+	out.Days = in.Days
+	out.Start = in.Start
+	out.End = in.End
+	out.TimeZone = in.TimeZone
+	return nil
+}
+
+// Convert_v1alpha2_MaintenanceWindow_To_kops_MaintenanceWindow is an autogenerated conversion function.
+func Convert_v1alpha2_MaintenanceWindow_To_kops_MaintenanceWindow(in *MaintenanceWindow, out *kops.MaintenanceWindow, s conversion.Scope) error {
+	return autoConvert_v1alpha2_MaintenanceWindow_To_kops_MaintenanceWindow(in, out, s)
+}
+
+func autoConvert_kops_MaintenanceWindow_To_v1alpha2_MaintenanceWindow(in *kops.MaintenanceWindow, out *MaintenanceWindow, s conversion.Scope) error {
+	out.Days = in.Days
+	out.Start = in.Start
+	out.End = in.End
+	out.TimeZone = in.TimeZone
+	return nil
+}
+
+// Convert_kops_MaintenanceWindow_To_v1alpha2_MaintenanceWindow is an autogenerated conversion function.
+func Convert_kops_MaintenanceWindow_To_v1alpha2_MaintenanceWindow(in *kops.MaintenanceWindow, out *MaintenanceWindow, s conversion.Scope) error {
+	return autoConvert_kops_MaintenanceWindow_To_v1alpha2_MaintenanceWindow(in, out, s)
+}
+
 func autoConvert_v1alpha2_MetricsServerConfig_To_kops_MetricsServerConfig(in *MetricsServerConfig, out *kops.MetricsServerConfig, s conversion.Scope) error {
 	out.Enabled = in.Enabled
 	out.Image = in.Image
@@ -6742,6 +7060,72 @@ func Convert_kops_PDCSIDriver_To_v1alpha2_PDCSIDriver(in *kops.PDCSIDriver, out
 	return autoConvert_kops_PDCSIDriver_To_v1alpha2_PDCSIDriver(in, out, s)
 }
 
+func autoConvert_v1alpha2_PKIKeysetSpec_To_kops_PKIKeysetSpec(in *PKIKeysetSpec, out *kops.PKIKeysetSpec, s conversion.Scope) error {
+	out.KeyAlgorithm = in.KeyAlgorithm
+	return nil
+}
+
+// Convert_v1alpha2_PKIKeysetSpec_To_kops_PKIKeysetSpec is an autogenerated conversion function.
+func Convert_v1alpha2_PKIKeysetSpec_To_kops_PKIKeysetSpec(in *PKIKeysetSpec, out *kops.PKIKeysetSpec, s conversion.Scope) error {
+	return autoConvert_v1alpha2_PKIKeysetSpec_To_kops_PKIKeysetSpec(in, out, s)
+}
+
+func autoConvert_kops_PKIKeysetSpec_To_v1alpha2_PKIKeysetSpec(in *kops.PKIKeysetSpec, out *PKIKeysetSpec, s conversion.Scope) error {
+	out.KeyAlgorithm = in.KeyAlgorithm
+	return nil
+}
+
+// Convert_kops_PKIKeysetSpec_To_v1alpha2_PKIKeysetSpec is an autogenerated conversion function.
+func Convert_kops_PKIKeysetSpec_To_v1alpha2_PKIKeysetSpec(in *kops.PKIKeysetSpec, out *PKIKeysetSpec, s conversion.Scope) error {
+	return autoConvert_kops_PKIKeysetSpec_To_v1alpha2_PKIKeysetSpec(in, out, s)
+}
+
+func autoConvert_v1alpha2_PKISpec_To_kops_PKISpec(in *PKISpec, out *kops.PKISpec, s conversion.Scope) error {
+	out.KeyAlgorithm = in.KeyAlgorithm
+	if in.Keysets != nil {
+		in, out := &in.Keysets, &out.Keysets
+		*out = make(map[string]kops.PKIKeysetSpec, len(*in))
+		for key, val := range *in {
+			newVal := new(kops.PKIKeysetSpec)
+			if err := Convert_v1alpha2_PKIKeysetSpec_To_kops_PKIKeysetSpec(&val, newVal, s); err != nil {
+				return err
+			}
+			(*out)[key] = *newVal
+		}
+	} else {
+		out.Keysets = nil
+	}
+	return nil
+}
+
+// Convert_v1alpha2_PKISpec_To_kops_PKISpec is an autogenerated conversion function.
+func Convert_v1alpha2_PKISpec_To_kops_PKISpec(in *PKISpec, out *kops.PKISpec, s conversion.Scope) error {
+	return autoConvert_v1alpha2_PKISpec_To_kops_PKISpec(in, out, s)
+}
+
+func autoConvert_kops_PKISpec_To_v1alpha2_PKISpec(in *kops.PKISpec, out *PKISpec, s conversion.Scope) error {
+	out.KeyAlgorithm = in.KeyAlgorithm
+	if in.Keysets != nil {
+		in, out := &in.Keysets, &out.Keysets
+		*out = make(map[string]PKIKeysetSpec, len(*in))
+		for key, val := range *in {
+			newVal := new(PKIKeysetSpec)
+			if err := Convert_kops_PKIKeysetSpec_To_v1alpha2_PKIKeysetSpec(&val, newVal, s); err != nil {
+				return err
+			}
+			(*out)[key] = *newVal
+		}
+	} else {
+		out.Keysets = nil
+	}
+	return nil
+}
+
+// Convert_kops_PKISpec_To_v1alpha2_PKISpec is an autogenerated conversion function.
+func Convert_kops_PKISpec_To_v1alpha2_PKISpec(in *kops.PKISpec, out *PKISpec, s conversion.Scope) error {
+	return autoConvert_kops_PKISpec_To_v1alpha2_PKISpec(in, out, s)
+}
+
 func autoConvert_v1alpha2_PackagesConfig_To_kops_PackagesConfig(in *PackagesConfig, out *kops.PackagesConfig, s conversion.Scope) error {
 	out.HashAmd64 = in.HashAmd64
 	out.HashArm64 = in.HashArm64
@@ -6768,6 +7152,28 @@ func Convert_kops_PackagesConfig_To_v1alpha2_PackagesConfig(in *kops.PackagesCon
 	return autoConvert_kops_PackagesConfig_To_v1alpha2_PackagesConfig(in, out, s)
 }
 
+func autoConvert_v1alpha2_PodDisruptionBudgetHealthCheck_To_kops_PodDisruptionBudgetHealthCheck(in *PodDisruptionBudgetHealthCheck, out *kops.PodDisruptionBudgetHealthCheck, s conversion.Scope) error {
+	out.Namespace = in.Namespace
+	out.Name = in.Name
+	return nil
+}
+
+// Convert_v1alpha2_PodDisruptionBudgetHealthCheck_To_kops_PodDisruptionBudgetHealthCheck is an autogenerated conversion function.
+func Convert_v1alpha2_PodDisruptionBudgetHealthCheck_To_kops_PodDisruptionBudgetHealthCheck(in *PodDisruptionBudgetHealthCheck, out *kops.PodDisruptionBudgetHealthCheck, s conversion.Scope) error {
+	return autoConvert_v1alpha2_PodDisruptionBudgetHealthCheck_To_kops_PodDisruptionBudgetHealthCheck(in, out, s)
+}
+
+func autoConvert_kops_PodDisruptionBudgetHealthCheck_To_v1alpha2_PodDisruptionBudgetHealthCheck(in *kops.PodDisruptionBudgetHealthCheck, out *PodDisruptionBudgetHealthCheck, s conversion.Scope) error {
+	out.Namespace = in.Namespace
+	out.Name = in.Name
+	return nil
+}
+
+// Convert_kops_PodDisruptionBudgetHealthCheck_To_v1alpha2_PodDisruptionBudgetHealthCheck is an autogenerated conversion function.
+func Convert_kops_PodDisruptionBudgetHealthCheck_To_v1alpha2_PodDisruptionBudgetHealthCheck(in *kops.PodDisruptionBudgetHealthCheck, out *PodDisruptionBudgetHealthCheck, s conversion.Scope) error {
+	return autoConvert_kops_PodDisruptionBudgetHealthCheck_To_v1alpha2_PodDisruptionBudgetHealthCheck(in, out, s)
+}
+
 func autoConvert_v1alpha2_PodIdentityWebhookSpec_To_kops_PodIdentityWebhookSpec(in *PodIdentityWebhookSpec, out *kops.PodIdentityWebhookSpec, s conversion.Scope) error {
 	out.Enabled = in.Enabled
 	out.Replicas = in.Replicas
@@ -6790,6 +7196,30 @@ func Convert_kops_PodIdentityWebhookSpec_To_v1alpha2_PodIdentityWebhookSpec(in *
 	return autoConvert_kops_PodIdentityWebhookSpec_To_v1alpha2_PodIdentityWebhookSpec(in, out, s)
 }
 
+func autoConvert_v1alpha2_PodsHealthCheck_To_kops_PodsHealthCheck(in *PodsHealthCheck, out *kops.PodsHealthCheck, s conversion.Scope) error {
+	out.Namespace = in.Namespace
+	out.Selector = in.Selector
+	out.MinReady = in.MinReady
+	return nil
+}
+
+// Convert_v1alpha2_PodsHealthCheck_To_kops_PodsHealthCheck is an autogenerated conversion function.
+func Convert_v1alpha2_PodsHealthCheck_To_kops_PodsHealthCheck(in *PodsHealthCheck, out *kops.PodsHealthCheck, s conversion.Scope) error {
+	return autoConvert_v1alpha2_PodsHealthCheck_To_kops_PodsHealthCheck(in, out, s)
+}
+
+func autoConvert_kops_PodsHealthCheck_To_v1alpha2_PodsHealthCheck(in *kops.PodsHealthCheck, out *PodsHealthCheck, s conversion.Scope) error {
+	out.Namespace = in.Namespace
+	out.Selector = in.Selector
+	out.MinReady = in.MinReady
+	return nil
+}
+
+// Convert_kops_PodsHealthCheck_To_v1alpha2_PodsHealthCheck is an autogenerated conversion function.
+func Convert_kops_PodsHealthCheck_To_v1alpha2_PodsHealthCheck(in *kops.PodsHealthCheck, out *PodsHealthCheck, s conversion.Scope) error {
+	return autoConvert_kops_PodsHealthCheck_To_v1alpha2_PodsHealthCheck(in, out, s)
+}
+
 func autoConvert_v1alpha2_RBACAuthorizationSpec_To_kops_RBACAuthorizationSpec(in *RBACAuthorizationSpec, out *kops.RBACAuthorizationSpec, s conversion.Scope) error {
 	return nil
 }
@@ -6812,6 +7242,39 @@ func autoConvert_v1alpha2_RollingUpdate_To_kops_RollingUpdate(in *RollingUpdate,
 	out.DrainAndTerminate = in.DrainAndTerminate
 	out.MaxUnavailable = in.MaxUnavailable
 	out.MaxSurge = in.MaxSurge
+	if in.MaintenanceWindows != nil {
+		in, out := &in.MaintenanceWindows, &out.MaintenanceWindows
+		*out = make([]kops.MaintenanceWindow, len(*in))
+		for i := range *in {
+			if err := Convert_v1alpha2_MaintenanceWindow_To_kops_MaintenanceWindow(&(*in)[i], &(*out)[i], s); err != nil {
+				return err
+			}
+		}
+	} else {
+		out.MaintenanceWindows = nil
+	}
+	if in.InstanceHooks != nil {
+		in, out := &in.InstanceHooks, &out.InstanceHooks
+		*out = make([]kops.RollingUpdateHook, len(*in))
+		for i := range *in {
+			if err := Convert_v1alpha2_RollingUpdateHook_To_kops_RollingUpdateHook(&(*in)[i], &(*out)[i], s); err != nil {
+				return err
+			}
+		}
+	} else {
+		out.InstanceHooks = nil
+	}
+	out.Strategy = kops.RollingUpdateStrategy(in.Strategy)
+	if in.Drain != nil {
+		in, out := &in.Drain, &out.Drain
+		*out = new(kops.RollingUpdateDrain)
+		if err := Convert_v1alpha2_RollingUpdateDrain_To_kops_RollingUpdateDrain(*in, *out, s); err != nil {
+			return err
+		}
+	} else {
+		out.Drain = nil
+	}
+	out.OnFailure = kops.RollingUpdateFailurePolicy(in.OnFailure)
 	return nil
 }
 
@@ -6824,6 +7287,39 @@ func autoConvert_kops_RollingUpdate_To_v1alpha2_RollingUpdate(in *kops.RollingUp
 	out.DrainAndTerminate = in.DrainAndTerminate
 	out.MaxUnavailable = in.MaxUnavailable
 	out.MaxSurge = in.MaxSurge
+	if in.MaintenanceWindows != nil {
+		in, out := &in.MaintenanceWindows, &out.MaintenanceWindows
+		*out = make([]MaintenanceWindow, len(*in))
+		for i := range *in {
+			if err := Convert_kops_MaintenanceWindow_To_v1alpha2_MaintenanceWindow(&(*in)[i], &(*out)[i], s); err != nil {
+				return err
+			}
+		}
+	} else {
+		out.MaintenanceWindows = nil
+	}
+	if in.InstanceHooks != nil {
+		in, out := &in.InstanceHooks, &out.InstanceHooks
+		*out = make([]RollingUpdateHook, len(*in))
+		for i := range *in {
+			if err := Convert_kops_RollingUpdateHook_To_v1alpha2_RollingUpdateHook(&(*in)[i], &(*out)[i], s); err != nil {
+				return err
+			}
+		}
+	} else {
+		out.InstanceHooks = nil
+	}
+	out.Strategy = RollingUpdateStrategy(in.Strategy)
+	if in.Drain != nil {
+		in, out := &in.Drain, &out.Drain
+		*out = new(RollingUpdateDrain)
+		if err := Convert_kops_RollingUpdateDrain_To_v1alpha2_RollingUpdateDrain(*in, *out, s); err != nil {
+			return err
+		}
+	} else {
+		out.Drain = nil
+	}
+	out.OnFailure = RollingUpdateFailurePolicy(in.OnFailure)
 	return nil
 }
 
@@ -6832,6 +7328,114 @@ func Convert_kops_RollingUpdate_To_v1alpha2_RollingUpdate(in *kops.RollingUpdate
 	return autoConvert_kops_RollingUpdate_To_v1alpha2_RollingUpdate(in, out, s)
 }
 
+func autoConvert_v1alpha2_RollingUpdateDrain_To_kops_RollingUpdateDrain(in *RollingUpdateDrain, out *kops.RollingUpdateDrain, s conversion.Scope) error {
+	out.SkipPodSelector = in.SkipPodSelector
+	out.GracePeriodSeconds = in.GracePeriodSeconds
+	out.ForceUnmanagedPods = in.ForceUnmanagedPods
+	out.DeleteEmptyDirData = in.DeleteEmptyDirData
+	out.DisableEvictionAfter = in.DisableEvictionAfter
+	out.PriorityNamespaces = in.PriorityNamespaces
+	return nil
+}
+
+// Convert_v1alpha2_RollingUpdateDrain_To_kops_RollingUpdateDrain is an autogenerated conversion function.
+func Convert_v1alpha2_RollingUpdateDrain_To_kops_RollingUpdateDrain(in *RollingUpdateDrain, out *kops.RollingUpdateDrain, s conversion.Scope) error {
+	return autoConvert_v1alpha2_RollingUpdateDrain_To_kops_RollingUpdateDrain(in, out, s)
+}
+
+func autoConvert_kops_RollingUpdateDrain_To_v1alpha2_RollingUpdateDrain(in *kops.RollingUpdateDrain, out *RollingUpdateDrain, s conversion.Scope) error {
+	out.SkipPodSelector = in.SkipPodSelector
+	out.GracePeriodSeconds = in.GracePeriodSeconds
+	out.ForceUnmanagedPods = in.ForceUnmanagedPods
+	out.DeleteEmptyDirData = in.DeleteEmptyDirData
+	out.DisableEvictionAfter = in.DisableEvictionAfter
+	out.PriorityNamespaces = in.PriorityNamespaces
+	return nil
+}
+
+// Convert_kops_RollingUpdateDrain_To_v1alpha2_RollingUpdateDrain is an autogenerated conversion function.
+func Convert_kops_RollingUpdateDrain_To_v1alpha2_RollingUpdateDrain(in *kops.RollingUpdateDrain, out *RollingUpdateDrain, s conversion.Scope) error {
+	return autoConvert_kops_RollingUpdateDrain_To_v1alpha2_RollingUpdateDrain(in, out, s)
+}
+
+func autoConvert_v1alpha2_RollingUpdateHook_To_kops_RollingUpdateHook(in *RollingUpdateHook, out *kops.RollingUpdateHook, s conversion.Scope) error {
+	out.Name = in.Name
+	if in.Phases != nil {
+		in, out := &in.Phases, &out.Phases
+		*out = make([]kops.RollingUpdateHookPhase, len(*in))
+		for i := range *in {
+			(*out)[i] = kops.RollingUpdateHookPhase((*in)[i])
+		}
+	} else {
+		out.Phases = nil
+	}
+	if in.Exec != nil {
+		in, out := &in.Exec, &out.Exec
+		*out = new(kops.ExecRollingUpdateHook)
+		if err := Convert_v1alpha2_ExecRollingUpdateHook_To_kops_ExecRollingUpdateHook(*in, *out, s); err != nil {
+			return err
+		}
+	} else {
+		out.Exec = nil
+	}
+	if in.Webhook != nil {
+		in, out := &in.Webhook, &out.Webhook
+		*out = new(kops.WebhookRollingUpdateHook)
+		if err := Convert_v1alpha2_WebhookRollingUpdateHook_To_kops_WebhookRollingUpdateHook(*in, *out, s); err != nil {
+			return err
+		}
+	} else {
+		out.Webhook = nil
+	}
+	out.FailurePolicy = kops.RollingUpdateHookFailurePolicy(in.FailurePolicy)
+	out.Timeout = in.Timeout
+	return nil
+}
+
+// Convert_v1alpha2_RollingUpdateHook_To_kops_RollingUpdateHook is an autogenerated conversion function.
+func Convert_v1alpha2_RollingUpdateHook_To_kops_RollingUpdateHook(in *RollingUpdateHook, out *kops.RollingUpdateHook, s conversion.Scope) error {
+	return autoConvert_v1alpha2_RollingUpdateHook_To_kops_RollingUpdateHook(in, out, s)
+}
+
+func autoConvert_kops_RollingUpdateHook_To_v1alpha2_RollingUpdateHook(in *kops.RollingUpdateHook, out *RollingUpdateHook, s conversion.Scope) error {
+	out.Name = in.Name
+	if in.Phases != nil {
+		in, out := &in.Phases, &out.Phases
+		*out = make([]RollingUpdateHookPhase, len(*in))
+		for i := range *in {
+			(*out)[i] = RollingUpdateHookPhase((*in)[i])
+		}
+	} else {
+		out.Phases = nil
+	}
+	if in.Exec != nil {
+		in, out := &in.Exec, &out.Exec
+		*out = new(ExecRollingUpdateHook)
+		if err := Convert_kops_ExecRollingUpdateHook_To_v1alpha2_ExecRollingUpdateHook(*in, *out, s); err != nil {
+			return err
+		}
+	} else {
+		out.Exec = nil
+	}
+	if in.Webhook != nil {
+		in, out := &in.Webhook, &out.Webhook
+		*out = new(WebhookRollingUpdateHook)
+		if err := Convert_kops_WebhookRollingUpdateHook_To_v1alpha2_WebhookRollingUpdateHook(*in, *out, s); err != nil {
+			return err
+		}
+	} else {
+		out.Webhook = nil
+	}
+	out.FailurePolicy = RollingUpdateHookFailurePolicy(in.FailurePolicy)
+	out.Timeout = in.Timeout
+	return nil
+}
+
+// Convert_kops_RollingUpdateHook_To_v1alpha2_RollingUpdateHook is an autogenerated conversion function.
+func Convert_kops_RollingUpdateHook_To_v1alpha2_RollingUpdateHook(in *kops.RollingUpdateHook, out *RollingUpdateHook, s conversion.Scope) error {
+	return autoConvert_kops_RollingUpdateHook_To_v1alpha2_RollingUpdateHook(in, out, s)
+}
+
 func autoConvert_v1alpha2_RomanaNetworkingSpec_To_kops_RomanaNetworkingSpec(in *RomanaNetworkingSpec, out *kops.RomanaNetworkingSpec, s conversion.Scope) error {
 	out.DaemonServiceIP = in.DaemonServiceIP
 	out.EtcdServiceIP = in.EtcdServiceIP
@@ -7334,3 +7938,49 @@ func autoConvert_kops_WeaveNetworkingSpec_To_v1alpha2_WeaveNetworkingSpec(in *ko
 func Convert_kops_WeaveNetworkingSpec_To_v1alpha2_WeaveNetworkingSpec(in *kops.WeaveNetworkingSpec, out *WeaveNetworkingSpec, s conversion.Scope) error {
 	return autoConvert_kops_WeaveNetworkingSpec_To_v1alpha2_WeaveNetworkingSpec(in, out, s)
 }
+
+func autoConvert_v1alpha2_WebhookRollingUpdateHook_To_kops_WebhookRollingUpdateHook(in *WebhookRollingUpdateHook, out *kops.WebhookRollingUpdateHook, s conversion.Scope) error {
+	out.URL = in.URL
+	out.HeaderSecrets = in.HeaderSecrets
+	return nil
+}
+
+// Convert_v1alpha2_WebhookRollingUpdateHook_To_kops_WebhookRollingUpdateHook is an autogenerated conversion function.
+func Convert_v1alpha2_WebhookRollingUpdateHook_To_kops_WebhookRollingUpdateHook(in *WebhookRollingUpdateHook, out *kops.WebhookRollingUpdateHook, s conversion.Scope) error {
+	return autoConvert_v1alpha2_WebhookRollingUpdateHook_To_kops_WebhookRollingUpdateHook(in, out, s)
+}
+
+func autoConvert_kops_WebhookRollingUpdateHook_To_v1alpha2_WebhookRollingUpdateHook(in *kops.WebhookRollingUpdateHook, out *WebhookRollingUpdateHook, s conversion.Scope) error {
+	out.URL = in.URL
+	out.HeaderSecrets = in.HeaderSecrets
+	return nil
+}
+
+// Convert_kops_WebhookRollingUpdateHook_To_v1alpha2_WebhookRollingUpdateHook is an autogenerated conversion function.
+func Convert_kops_WebhookRollingUpdateHook_To_v1alpha2_WebhookRollingUpdateHook(in *kops.WebhookRollingUpdateHook, out *WebhookRollingUpdateHook, s conversion.Scope) error {
+	return autoConvert_kops_WebhookRollingUpdateHook_To_v1alpha2_WebhookRollingUpdateHook(in, out, s)
+}
+
+func autoConvert_v1alpha2_WorkloadHealthCheck_To_kops_WorkloadHealthCheck(in *WorkloadHealthCheck, out *kops.WorkloadHealthCheck, s conversion.Scope) error {
+	out.Kind = in.Kind
+	out.Namespace = in.Namespace
+	out.Name = in.Name
+	return nil
+}
+
+// Convert_v1alpha2_WorkloadHealthCheck_To_kops_WorkloadHealthCheck is an autogenerated conversion function.
+func Convert_v1alpha2_WorkloadHealthCheck_To_kops_WorkloadHealthCheck(in *WorkloadHealthCheck, out *kops.WorkloadHealthCheck, s conversion.Scope) error {
+	return autoConvert_v1alpha2_WorkloadHealthCheck_To_kops_WorkloadHealthCheck(in, out, s)
+}
+
+func autoConvert_kops_WorkloadHealthCheck_To_v1alpha2_WorkloadHealthCheck(in *kops.WorkloadHealthCheck, out *WorkloadHealthCheck, s conversion.Scope) error {
+	out.Kind = in.Kind
+	out.Namespace = in.Namespace
+	out.Name = in.Name
+	return nil
+}
+
+// Convert_kops_WorkloadHealthCheck_To_v1alpha2_WorkloadHealthCheck is an autogenerated conversion function.
+func Convert_kops_WorkloadHealthCheck_To_v1alpha2_WorkloadHealthCheck(in *kops.WorkloadHealthCheck, out *WorkloadHealthCheck, s conversion.Scope) error {
+	return autoConvert_kops_WorkloadHealthCheck_To_v1alpha2_WorkloadHealthCheck(in, out, s)
+}
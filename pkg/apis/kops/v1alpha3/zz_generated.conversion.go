@@ -294,6 +294,16 @@ func RegisterConversions(s *runtime.Scheme) error {
 	}); err != nil {
 		return err
 	}
+	if err := s.AddGeneratedConversionFunc((*ClusterHealthCheck)(nil), (*kops.ClusterHealthCheck)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha3_ClusterHealthCheck_To_kops_ClusterHealthCheck(a.(*ClusterHealthCheck), b.(*kops.ClusterHealthCheck), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*kops.ClusterHealthCheck)(nil), (*ClusterHealthCheck)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_kops_ClusterHealthCheck_To_v1alpha3_ClusterHealthCheck(a.(*kops.ClusterHealthCheck), b.(*ClusterHealthCheck), scope)
+	}); err != nil {
+		return err
+	}
 	if err := s.AddGeneratedConversionFunc((*ClusterList)(nil), (*kops.ClusterList)(nil), func(a, b interface{}, scope conversion.Scope) error {
 		return Convert_v1alpha3_ClusterList_To_kops_ClusterList(a.(*ClusterList), b.(*kops.ClusterList), scope)
 	}); err != nil {
@@ -474,6 +484,16 @@ func RegisterConversions(s *runtime.Scheme) error {
 	}); err != nil {
 		return err
 	}
+	if err := s.AddGeneratedConversionFunc((*ExecRollingUpdateHook)(nil), (*kops.ExecRollingUpdateHook)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha3_ExecRollingUpdateHook_To_kops_ExecRollingUpdateHook(a.(*ExecRollingUpdateHook), b.(*kops.ExecRollingUpdateHook), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*kops.ExecRollingUpdateHook)(nil), (*ExecRollingUpdateHook)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_kops_ExecRollingUpdateHook_To_v1alpha3_ExecRollingUpdateHook(a.(*kops.ExecRollingUpdateHook), b.(*ExecRollingUpdateHook), scope)
+	}); err != nil {
+		return err
+	}
 	if err := s.AddGeneratedConversionFunc((*ExternalDNSConfig)(nil), (*kops.ExternalDNSConfig)(nil), func(a, b interface{}, scope conversion.Scope) error {
 		return Convert_v1alpha3_ExternalDNSConfig_To_kops_ExternalDNSConfig(a.(*ExternalDNSConfig), b.(*kops.ExternalDNSConfig), scope)
 	}); err != nil {
@@ -554,6 +574,16 @@ func RegisterConversions(s *runtime.Scheme) error {
 	}); err != nil {
 		return err
 	}
+	if err := s.AddGeneratedConversionFunc((*HTTPHealthCheck)(nil), (*kops.HTTPHealthCheck)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha3_HTTPHealthCheck_To_kops_HTTPHealthCheck(a.(*HTTPHealthCheck), b.(*kops.HTTPHealthCheck), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*kops.HTTPHealthCheck)(nil), (*HTTPHealthCheck)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_kops_HTTPHealthCheck_To_v1alpha3_HTTPHealthCheck(a.(*kops.HTTPHealthCheck), b.(*HTTPHealthCheck), scope)
+	}); err != nil {
+		return err
+	}
 	if err := s.AddGeneratedConversionFunc((*HTTPProxy)(nil), (*kops.HTTPProxy)(nil), func(a, b interface{}, scope conversion.Scope) error {
 		return Convert_v1alpha3_HTTPProxy_To_kops_HTTPProxy(a.(*HTTPProxy), b.(*kops.HTTPProxy), scope)
 	}); err != nil {
@@ -874,6 +904,16 @@ func RegisterConversions(s *runtime.Scheme) error {
 	}); err != nil {
 		return err
 	}
+	if err := s.AddGeneratedConversionFunc((*MaintenanceWindow)(nil), (*kops.MaintenanceWindow)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha3_MaintenanceWindow_To_kops_MaintenanceWindow(a.(*MaintenanceWindow), b.(*kops.MaintenanceWindow), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*kops.MaintenanceWindow)(nil), (*MaintenanceWindow)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_kops_MaintenanceWindow_To_v1alpha3_MaintenanceWindow(a.(*kops.MaintenanceWindow), b.(*MaintenanceWindow), scope)
+	}); err != nil {
+		return err
+	}
 	if err := s.AddGeneratedConversionFunc((*MetricsServerConfig)(nil), (*kops.MetricsServerConfig)(nil), func(a, b interface{}, scope conversion.Scope) error {
 		return Convert_v1alpha3_MetricsServerConfig_To_kops_MetricsServerConfig(a.(*MetricsServerConfig), b.(*kops.MetricsServerConfig), scope)
 	}); err != nil {
@@ -1054,6 +1094,26 @@ func RegisterConversions(s *runtime.Scheme) error {
 	}); err != nil {
 		return err
 	}
+	if err := s.AddGeneratedConversionFunc((*PKIKeysetSpec)(nil), (*kops.PKIKeysetSpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha3_PKIKeysetSpec_To_kops_PKIKeysetSpec(a.(*PKIKeysetSpec), b.(*kops.PKIKeysetSpec), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*kops.PKIKeysetSpec)(nil), (*PKIKeysetSpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_kops_PKIKeysetSpec_To_v1alpha3_PKIKeysetSpec(a.(*kops.PKIKeysetSpec), b.(*PKIKeysetSpec), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*PKISpec)(nil), (*kops.PKISpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha3_PKISpec_To_kops_PKISpec(a.(*PKISpec), b.(*kops.PKISpec), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*kops.PKISpec)(nil), (*PKISpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_kops_PKISpec_To_v1alpha3_PKISpec(a.(*kops.PKISpec), b.(*PKISpec), scope)
+	}); err != nil {
+		return err
+	}
 	if err := s.AddGeneratedConversionFunc((*PackagesConfig)(nil), (*kops.PackagesConfig)(nil), func(a, b interface{}, scope conversion.Scope) error {
 		return Convert_v1alpha3_PackagesConfig_To_kops_PackagesConfig(a.(*PackagesConfig), b.(*kops.PackagesConfig), scope)
 	}); err != nil {
@@ -1064,6 +1124,16 @@ func RegisterConversions(s *runtime.Scheme) error {
 	}); err != nil {
 		return err
 	}
+	if err := s.AddGeneratedConversionFunc((*PodDisruptionBudgetHealthCheck)(nil), (*kops.PodDisruptionBudgetHealthCheck)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha3_PodDisruptionBudgetHealthCheck_To_kops_PodDisruptionBudgetHealthCheck(a.(*PodDisruptionBudgetHealthCheck), b.(*kops.PodDisruptionBudgetHealthCheck), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*kops.PodDisruptionBudgetHealthCheck)(nil), (*PodDisruptionBudgetHealthCheck)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_kops_PodDisruptionBudgetHealthCheck_To_v1alpha3_PodDisruptionBudgetHealthCheck(a.(*kops.PodDisruptionBudgetHealthCheck), b.(*PodDisruptionBudgetHealthCheck), scope)
+	}); err != nil {
+		return err
+	}
 	if err := s.AddGeneratedConversionFunc((*PodIdentityWebhookSpec)(nil), (*kops.PodIdentityWebhookSpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
 		return Convert_v1alpha3_PodIdentityWebhookSpec_To_kops_PodIdentityWebhookSpec(a.(*PodIdentityWebhookSpec), b.(*kops.PodIdentityWebhookSpec), scope)
 	}); err != nil {
@@ -1074,6 +1144,16 @@ func RegisterConversions(s *runtime.Scheme) error {
 	}); err != nil {
 		return err
 	}
+	if err := s.AddGeneratedConversionFunc((*PodsHealthCheck)(nil), (*kops.PodsHealthCheck)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha3_PodsHealthCheck_To_kops_PodsHealthCheck(a.(*PodsHealthCheck), b.(*kops.PodsHealthCheck), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*kops.PodsHealthCheck)(nil), (*PodsHealthCheck)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_kops_PodsHealthCheck_To_v1alpha3_PodsHealthCheck(a.(*kops.PodsHealthCheck), b.(*PodsHealthCheck), scope)
+	}); err != nil {
+		return err
+	}
 	if err := s.AddGeneratedConversionFunc((*RBACAuthorizationSpec)(nil), (*kops.RBACAuthorizationSpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
 		return Convert_v1alpha3_RBACAuthorizationSpec_To_kops_RBACAuthorizationSpec(a.(*RBACAuthorizationSpec), b.(*kops.RBACAuthorizationSpec), scope)
 	}); err != nil {
@@ -1094,6 +1174,26 @@ func RegisterConversions(s *runtime.Scheme) error {
 	}); err != nil {
 		return err
 	}
+	if err := s.AddGeneratedConversionFunc((*RollingUpdateDrain)(nil), (*kops.RollingUpdateDrain)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha3_RollingUpdateDrain_To_kops_RollingUpdateDrain(a.(*RollingUpdateDrain), b.(*kops.RollingUpdateDrain), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*kops.RollingUpdateDrain)(nil), (*RollingUpdateDrain)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_kops_RollingUpdateDrain_To_v1alpha3_RollingUpdateDrain(a.(*kops.RollingUpdateDrain), b.(*RollingUpdateDrain), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*RollingUpdateHook)(nil), (*kops.RollingUpdateHook)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha3_RollingUpdateHook_To_kops_RollingUpdateHook(a.(*RollingUpdateHook), b.(*kops.RollingUpdateHook), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*kops.RollingUpdateHook)(nil), (*RollingUpdateHook)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_kops_RollingUpdateHook_To_v1alpha3_RollingUpdateHook(a.(*kops.RollingUpdateHook), b.(*RollingUpdateHook), scope)
+	}); err != nil {
+		return err
+	}
 	if err := s.AddGeneratedConversionFunc((*RouteSpec)(nil), (*kops.RouteSpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
 		return Convert_v1alpha3_RouteSpec_To_kops_RouteSpec(a.(*RouteSpec), b.(*kops.RouteSpec), scope)
 	}); err != nil {
@@ -1264,6 +1364,26 @@ func RegisterConversions(s *runtime.Scheme) error {
 	}); err != nil {
 		return err
 	}
+	if err := s.AddGeneratedConversionFunc((*WebhookRollingUpdateHook)(nil), (*kops.WebhookRollingUpdateHook)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha3_WebhookRollingUpdateHook_To_kops_WebhookRollingUpdateHook(a.(*WebhookRollingUpdateHook), b.(*kops.WebhookRollingUpdateHook), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*kops.WebhookRollingUpdateHook)(nil), (*WebhookRollingUpdateHook)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_kops_WebhookRollingUpdateHook_To_v1alpha3_WebhookRollingUpdateHook(a.(*kops.WebhookRollingUpdateHook), b.(*WebhookRollingUpdateHook), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*WorkloadHealthCheck)(nil), (*kops.WorkloadHealthCheck)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha3_WorkloadHealthCheck_To_kops_WorkloadHealthCheck(a.(*WorkloadHealthCheck), b.(*kops.WorkloadHealthCheck), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*kops.WorkloadHealthCheck)(nil), (*WorkloadHealthCheck)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_kops_WorkloadHealthCheck_To_v1alpha3_WorkloadHealthCheck(a.(*kops.WorkloadHealthCheck), b.(*WorkloadHealthCheck), scope)
+	}); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -2521,6 +2641,98 @@ func Convert_kops_ClusterAutoscalerConfig_To_v1alpha3_ClusterAutoscalerConfig(in
 	return autoConvert_kops_ClusterAutoscalerConfig_To_v1alpha3_ClusterAutoscalerConfig(in, out, s)
 }
 
+func autoConvert_v1alpha3_ClusterHealthCheck_To_kops_ClusterHealthCheck(in *ClusterHealthCheck, out *kops.ClusterHealthCheck, s conversion.Scope) error {
+	out.Name = in.Name
+	if in.Workload != nil {
+		in, out := &in.Workload, &out.Workload
+		*out = new(kops.WorkloadHealthCheck)
+		if err := Convert_v1alpha3_WorkloadHealthCheck_To_kops_WorkloadHealthCheck(*in, *out, s); err != nil {
+			return err
+		}
+	} else {
+		out.Workload = nil
+	}
+	if in.PodDisruptionBudget != nil {
+		in, out := &in.PodDisruptionBudget, &out.PodDisruptionBudget
+		*out = new(kops.PodDisruptionBudgetHealthCheck)
+		if err := Convert_v1alpha3_PodDisruptionBudgetHealthCheck_To_kops_PodDisruptionBudgetHealthCheck(*in, *out, s); err != nil {
+			return err
+		}
+	} else {
+		out.PodDisruptionBudget = nil
+	}
+	if in.HTTP != nil {
+		in, out := &in.HTTP, &out.HTTP
+		*out = new(kops.HTTPHealthCheck)
+		if err := Convert_v1alpha3_HTTPHealthCheck_To_kops_HTTPHealthCheck(*in, *out, s); err != nil {
+			return err
+		}
+	} else {
+		out.HTTP = nil
+	}
+	if in.Pods != nil {
+		in, out := &in.Pods, &out.Pods
+		*out = new(kops.PodsHealthCheck)
+		if err := Convert_v1alpha3_PodsHealthCheck_To_kops_PodsHealthCheck(*in, *out, s); err != nil {
+			return err
+		}
+	} else {
+		out.Pods = nil
+	}
+	return nil
+}
+
+// Convert_v1alpha3_ClusterHealthCheck_To_kops_ClusterHealthCheck is an autogenerated conversion function.
+func Convert_v1alpha3_ClusterHealthCheck_To_kops_ClusterHealthCheck(in *ClusterHealthCheck, out *kops.ClusterHealthCheck, s conversion.Scope) error {
+	return autoConvert_v1alpha3_ClusterHealthCheck_To_kops_ClusterHealthCheck(in, out, s)
+}
+
+func autoConvert_kops_ClusterHealthCheck_To_v1alpha3_ClusterHealthCheck(in *kops.ClusterHealthCheck, out *ClusterHealthCheck, s conversion.Scope) error {
+	out.Name = in.Name
+	if in.Workload != nil {
+		in, out := &in.Workload, &out.Workload
+		*out = new(WorkloadHealthCheck)
+		if err := Convert_kops_WorkloadHealthCheck_To_v1alpha3_WorkloadHealthCheck(*in, *out, s); err != nil {
+			return err
+		}
+	} else {
+		out.Workload = nil
+	}
+	if in.PodDisruptionBudget != nil {
+		in, out := &in.PodDisruptionBudget, &out.PodDisruptionBudget
+		*out = new(PodDisruptionBudgetHealthCheck)
+		if err := Convert_kops_PodDisruptionBudgetHealthCheck_To_v1alpha3_PodDisruptionBudgetHealthCheck(*in, *out, s); err != nil {
+			return err
+		}
+	} else {
+		out.PodDisruptionBudget = nil
+	}
+	if in.HTTP != nil {
+		in, out := &in.HTTP, &out.HTTP
+		*out = new(HTTPHealthCheck)
+		if err := Convert_kops_HTTPHealthCheck_To_v1alpha3_HTTPHealthCheck(*in, *out, s); err != nil {
+			return err
+		}
+	} else {
+		out.HTTP = nil
+	}
+	if in.Pods != nil {
+		in, out := &in.Pods, &out.Pods
+		*out = new(PodsHealthCheck)
+		if err := Convert_kops_PodsHealthCheck_To_v1alpha3_PodsHealthCheck(*in, *out, s); err != nil {
+			return err
+		}
+	} else {
+		out.Pods = nil
+	}
+	return nil
+}
+
+// Convert_kops_ClusterHealthCheck_To_v1alpha3_ClusterHealthCheck is an autogenerated conversion function.
+func Convert_kops_ClusterHealthCheck_To_v1alpha3_ClusterHealthCheck(in *kops.ClusterHealthCheck, out *ClusterHealthCheck, s conversion.Scope) error {
+	return autoConvert_kops_ClusterHealthCheck_To_v1alpha3_ClusterHealthCheck(in, out, s)
+}
+
 func autoConvert_v1alpha3_ClusterList_To_kops_ClusterList(in *ClusterList, out *kops.ClusterList, s conversion.Scope) error {
 	out.ListMeta = in.ListMeta
 	if in.Items != nil {
@@ -2593,6 +2805,15 @@ func autoConvert_v1alpha3_ClusterSpec_To_kops_ClusterSpec(in *ClusterSpec, out *
 	out.KubernetesVersion = in.KubernetesVersion
 	out.SecretStore = in.SecretStore
 	out.KeyStore = in.KeyStore
+	if in.PKI != nil {
+		in, out := &in.PKI, &out.PKI
+		*out = new(kops.PKISpec)
+		if err := Convert_v1alpha3_PKISpec_To_kops_PKISpec(*in, *out, s); err != nil {
+			return err
+		}
+	} else {
+		out.PKI = nil
+	}
 	out.ConfigStore = in.ConfigStore
 	out.DNSZone = in.DNSZone
 	if in.DNSControllerGossipConfig != nil {
@@ -2853,6 +3074,17 @@ func autoConvert_v1alpha3_ClusterSpec_To_kops_ClusterSpec(in *ClusterSpec, out *
 	} else {
 		out.RollingUpdate = nil
 	}
+	if in.HealthChecks != nil {
+		in, out := &in.HealthChecks, &out.HealthChecks
+		*out = make([]kops.ClusterHealthCheck, len(*in))
+		for i := range *in {
+			if err := Convert_v1alpha3_ClusterHealthCheck_To_kops_ClusterHealthCheck(&(*in)[i], &(*out)[i], s); err != nil {
+				return err
+			}
+		}
+	} else {
+		out.HealthChecks = nil
+	}
 	if in.ClusterAutoscaler != nil {
 		in, out := &in.ClusterAutoscaler, &out.ClusterAutoscaler
 		*out = new(kops.ClusterAutoscalerConfig)
@@ -2927,6 +3159,15 @@ func autoConvert_kops_ClusterSpec_To_v1alpha3_ClusterSpec(in *kops.ClusterSpec,
 	out.KubernetesVersion = in.KubernetesVersion
 	out.SecretStore = in.SecretStore
 	out.KeyStore = in.KeyStore
+	if in.PKI != nil {
+		in, out := &in.PKI, &out.PKI
+		*out = new(PKISpec)
+		if err := Convert_kops_PKISpec_To_v1alpha3_PKISpec(*in, *out, s); err != nil {
+			return err
+		}
+	} else {
+		out.PKI = nil
+	}
 	out.ConfigStore = in.ConfigStore
 	out.DNSZone = in.DNSZone
 	if in.DNSControllerGossipConfig != nil {
@@ -3187,6 +3428,17 @@ func autoConvert_kops_ClusterSpec_To_v1alpha3_ClusterSpec(in *kops.ClusterSpec,
 	} else {
 		out.RollingUpdate = nil
 	}
+	if in.HealthChecks != nil {
+		in, out := &in.HealthChecks, &out.HealthChecks
+		*out = make([]ClusterHealthCheck, len(*in))
+		for i := range *in {
+			if err := Convert_kops_ClusterHealthCheck_To_v1alpha3_ClusterHealthCheck(&(*in)[i], &(*out)[i], s); err != nil {
+				return err
+			}
+		}
+	} else {
+		out.HealthChecks = nil
+	}
 	if in.ClusterAutoscaler != nil {
 		in, out := &in.ClusterAutoscaler, &out.ClusterAutoscaler
 		*out = new(ClusterAutoscalerConfig)
@@ -3903,6 +4155,26 @@ func Convert_kops_ExecContainerAction_To_v1alpha3_ExecContainerAction(in *kops.E
 	return autoConvert_kops_ExecContainerAction_To_v1alpha3_ExecContainerAction(in, out, s)
 }
 
+func autoConvert_v1alpha3_ExecRollingUpdateHook_To_kops_ExecRollingUpdateHook(in *ExecRollingUpdateHook, out *kops.ExecRollingUpdateHook, s conversion.Scope) error {
+	out.Command = in.Command
+	return nil
+}
+
+// Convert_v1alpha3_ExecRollingUpdateHook_To_kops_ExecRollingUpdateHook is an autogenerated conversion function.
+func Convert_v1alpha3_ExecRollingUpdateHook_To_kops_ExecRollingUpdateHook(in *ExecRollingUpdateHook, out *kops.ExecRollingUpdateHook, s conversion.Scope) error {
+	return autoConvert_v1alpha3_ExecRollingUpdateHook_To_kops_ExecRollingUpdateHook(in, out, s)
+}
+
+func autoConvert_kops_ExecRollingUpdateHook_To_v1alpha3_ExecRollingUpdateHook(in *kops.ExecRollingUpdateHook, out *ExecRollingUpdateHook, s conversion.Scope) error {
+	out.Command = in.Command
+	return nil
+}
+
+// Convert_kops_ExecRollingUpdateHook_To_v1alpha3_ExecRollingUpdateHook is an autogenerated conversion function.
+func Convert_kops_ExecRollingUpdateHook_To_v1alpha3_ExecRollingUpdateHook(in *kops.ExecRollingUpdateHook, out *ExecRollingUpdateHook, s conversion.Scope) error {
+	return autoConvert_kops_ExecRollingUpdateHook_To_v1alpha3_ExecRollingUpdateHook(in, out, s)
+}
+
 func autoConvert_v1alpha3_ExternalDNSConfig_To_kops_ExternalDNSConfig(in *ExternalDNSConfig, out *kops.ExternalDNSConfig, s conversion.Scope) error {
 	out.WatchIngress = in.WatchIngress
 	out.WatchNamespace = in.WatchNamespace
@@ -4143,6 +4415,26 @@ func Convert_kops_GossipConfigSecondary_To_v1alpha3_GossipConfigSecondary(in *ko
 	return autoConvert_kops_GossipConfigSecondary_To_v1alpha3_GossipConfigSecondary(in, out, s)
 }
 
+func autoConvert_v1alpha3_HTTPHealthCheck_To_kops_HTTPHealthCheck(in *HTTPHealthCheck, out *kops.HTTPHealthCheck, s conversion.Scope) error {
+	out.URL = in.URL
+	return nil
+}
+
+// Convert_v1alpha3_HTTPHealthCheck_To_kops_HTTPHealthCheck is an autogenerated conversion function.
+func Convert_v1alpha3_HTTPHealthCheck_To_kops_HTTPHealthCheck(in *HTTPHealthCheck, out *kops.HTTPHealthCheck, s conversion.Scope) error {
+	return autoConvert_v1alpha3_HTTPHealthCheck_To_kops_HTTPHealthCheck(in, out, s)
+}
+
+func autoConvert_kops_HTTPHealthCheck_To_v1alpha3_HTTPHealthCheck(in *kops.HTTPHealthCheck, out *HTTPHealthCheck, s conversion.Scope) error {
+	out.URL = in.URL
+	return nil
+}
+
+// Convert_kops_HTTPHealthCheck_To_v1alpha3_HTTPHealthCheck is an autogenerated conversion function.
+func Convert_kops_HTTPHealthCheck_To_v1alpha3_HTTPHealthCheck(in *kops.HTTPHealthCheck, out *HTTPHealthCheck, s conversion.Scope) error {
+	return autoConvert_kops_HTTPHealthCheck_To_v1alpha3_HTTPHealthCheck(in, out, s)
+}
+
 func autoConvert_v1alpha3_HTTPProxy_To_kops_HTTPProxy(in *HTTPProxy, out *kops.HTTPProxy, s conversion.Scope) error {
 	out.Host = in.Host
 	out.Port = in.Port
@@ -6065,6 +6357,32 @@ func Convert_kops_LoadBalancerSubnetSpec_To_v1alpha3_LoadBalancerSubnetSpec(in *
 	return autoConvert_kops_LoadBalancerSubnetSpec_To_v1alpha3_LoadBalancerSubnetSpec(in, out, s)
 }
 
+func autoConvert_v1alpha3_MaintenanceWindow_To_kops_MaintenanceWindow(in *MaintenanceWindow, out *kops.MaintenanceWindow, s conversion.Scope) error {
+	out.Days = in.Days
+	out.Start = in.Start
+	out.End = in.End
+	out.TimeZone = in.TimeZone
+	return nil
+}
+
+// Convert_v1alpha3_MaintenanceWindow_To_kops_MaintenanceWindow is an autogenerated conversion function.
+func Convert_v1alpha3_MaintenanceWindow_To_kops_MaintenanceWindow(in *MaintenanceWindow, out *kops.MaintenanceWindow, s conversion.Scope) error {
+	return autoConvert_v1alpha3_MaintenanceWindow_To_kops_MaintenanceWindow(in, out, s)
+}
+
+func autoConvert_kops_MaintenanceWindow_To_v1alpha3_MaintenanceWindow(in *kops.MaintenanceWindow, out *MaintenanceWindow, s conversion.Scope) error {
+	out.Days = in.Days
+	out.Start = in.Start
+	out.End = in.End
+	out.TimeZone = in.TimeZone
+	return nil
+}
+
+// Convert_kops_MaintenanceWindow_To_v1alpha3_MaintenanceWindow is an autogenerated conversion function.
+func Convert_kops_MaintenanceWindow_To_v1alpha3_MaintenanceWindow(in *kops.MaintenanceWindow, out *MaintenanceWindow, s conversion.Scope) error {
+	return autoConvert_kops_MaintenanceWindow_To_v1alpha3_MaintenanceWindow(in, out, s)
+}
+
 func autoConvert_v1alpha3_MetricsServerConfig_To_kops_MetricsServerConfig(in *MetricsServerConfig, out *kops.MetricsServerConfig, s conversion.Scope) error {
 	out.Enabled = in.Enabled
 	out.Image = in.Image
@@ -6993,6 +7311,72 @@ func Convert_kops_PDCSIDriver_To_v1alpha3_PDCSIDriver(in *kops.PDCSIDriver, out
 	return autoConvert_kops_PDCSIDriver_To_v1alpha3_PDCSIDriver(in, out, s)
 }
 
+func autoConvert_v1alpha3_PKIKeysetSpec_To_kops_PKIKeysetSpec(in *PKIKeysetSpec, out *kops.PKIKeysetSpec, s conversion.Scope) error {
+	out.KeyAlgorithm = in.KeyAlgorithm
+	return nil
+}
+
+// Convert_v1alpha3_PKIKeysetSpec_To_kops_PKIKeysetSpec is an autogenerated conversion function.
+func Convert_v1alpha3_PKIKeysetSpec_To_kops_PKIKeysetSpec(in *PKIKeysetSpec, out *kops.PKIKeysetSpec, s conversion.Scope) error {
+	return autoConvert_v1alpha3_PKIKeysetSpec_To_kops_PKIKeysetSpec(in, out, s)
+}
+
+func autoConvert_kops_PKIKeysetSpec_To_v1alpha3_PKIKeysetSpec(in *kops.PKIKeysetSpec, out *PKIKeysetSpec, s conversion.Scope) error {
+	out.KeyAlgorithm = in.KeyAlgorithm
+	return nil
+}
+
+// Convert_kops_PKIKeysetSpec_To_v1alpha3_PKIKeysetSpec is an autogenerated conversion function.
+func Convert_kops_PKIKeysetSpec_To_v1alpha3_PKIKeysetSpec(in *kops.PKIKeysetSpec, out *PKIKeysetSpec, s conversion.Scope) error {
+	return autoConvert_kops_PKIKeysetSpec_To_v1alpha3_PKIKeysetSpec(in, out, s)
+}
+
+func autoConvert_v1alpha3_PKISpec_To_kops_PKISpec(in *PKISpec, out *kops.PKISpec, s conversion.Scope) error {
+	out.KeyAlgorithm = in.KeyAlgorithm
+	if in.Keysets != nil {
+		in, out := &in.Keysets, &out.Keysets
+		*out = make(map[string]kops.PKIKeysetSpec, len(*in))
+		for key, val := range *in {
+			newVal := new(kops.PKIKeysetSpec)
+			if err := Convert_v1alpha3_PKIKeysetSpec_To_kops_PKIKeysetSpec(&val, newVal, s); err != nil {
+				return err
+			}
+			(*out)[key] = *newVal
+		}
+	} else {
+		out.Keysets = nil
+	}
+	return nil
+}
+
+// Convert_v1alpha3_PKISpec_To_kops_PKISpec is an autogenerated conversion function.
+func Convert_v1alpha3_PKISpec_To_kops_PKISpec(in *PKISpec, out *kops.PKISpec, s conversion.Scope) error {
+	return autoConvert_v1alpha3_PKISpec_To_kops_PKISpec(in, out, s)
+}
+
+func autoConvert_kops_PKISpec_To_v1alpha3_PKISpec(in *kops.PKISpec, out *PKISpec, s conversion.Scope) error {
+	out.KeyAlgorithm = in.KeyAlgorithm
+	if in.Keysets != nil {
+		in, out := &in.Keysets, &out.Keysets
+		*out = make(map[string]PKIKeysetSpec, len(*in))
+		for key, val := range *in {
+			newVal := new(PKIKeysetSpec)
+			if err := Convert_kops_PKIKeysetSpec_To_v1alpha3_PKIKeysetSpec(&val, newVal, s); err != nil {
+				return err
+			}
+			(*out)[key] = *newVal
+		}
+	} else {
+		out.Keysets = nil
+	}
+	return nil
+}
+
+// Convert_kops_PKISpec_To_v1alpha3_PKISpec is an autogenerated conversion function.
+func Convert_kops_PKISpec_To_v1alpha3_PKISpec(in *kops.PKISpec, out *PKISpec, s conversion.Scope) error {
+	return autoConvert_kops_PKISpec_To_v1alpha3_PKISpec(in, out, s)
+}
+
 func autoConvert_v1alpha3_PackagesConfig_To_kops_PackagesConfig(in *PackagesConfig, out *kops.PackagesConfig, s conversion.Scope) error {
 	out.HashAmd64 = in.HashAmd64
 	out.HashArm64 = in.HashArm64
@@ -7019,6 +7403,28 @@ func Convert_kops_PackagesConfig_To_v1alpha3_PackagesConfig(in *kops.PackagesCon
 	return autoConvert_kops_PackagesConfig_To_v1alpha3_PackagesConfig(in, out, s)
 }
 
+func autoConvert_v1alpha3_PodDisruptionBudgetHealthCheck_To_kops_PodDisruptionBudgetHealthCheck(in *PodDisruptionBudgetHealthCheck, out *kops.PodDisruptionBudgetHealthCheck, s conversion.Scope) error {
+	out.Namespace = in.Namespace
+	out.Name = in.Name
+	return nil
+}
+
+// Convert_v1alpha3_PodDisruptionBudgetHealthCheck_To_kops_PodDisruptionBudgetHealthCheck is an autogenerated conversion function.
+func Convert_v1alpha3_PodDisruptionBudgetHealthCheck_To_kops_PodDisruptionBudgetHealthCheck(in *PodDisruptionBudgetHealthCheck, out *kops.PodDisruptionBudgetHealthCheck, s conversion.Scope) error {
+	return autoConvert_v1alpha3_PodDisruptionBudgetHealthCheck_To_kops_PodDisruptionBudgetHealthCheck(in, out, s)
+}
+
+func autoConvert_kops_PodDisruptionBudgetHealthCheck_To_v1alpha3_PodDisruptionBudgetHealthCheck(in *kops.PodDisruptionBudgetHealthCheck, out *PodDisruptionBudgetHealthCheck, s conversion.Scope) error {
+	out.Namespace = in.Namespace
+	out.Name = in.Name
+	return nil
+}
+
+// Convert_kops_PodDisruptionBudgetHealthCheck_To_v1alpha3_PodDisruptionBudgetHealthCheck is an autogenerated conversion function.
+func Convert_kops_PodDisruptionBudgetHealthCheck_To_v1alpha3_PodDisruptionBudgetHealthCheck(in *kops.PodDisruptionBudgetHealthCheck, out *PodDisruptionBudgetHealthCheck, s conversion.Scope) error {
+	return autoConvert_kops_PodDisruptionBudgetHealthCheck_To_v1alpha3_PodDisruptionBudgetHealthCheck(in, out, s)
+}
+
 func autoConvert_v1alpha3_PodIdentityWebhookSpec_To_kops_PodIdentityWebhookSpec(in *PodIdentityWebhookSpec, out *kops.PodIdentityWebhookSpec, s conversion.Scope) error {
 	out.Enabled = in.Enabled
 	out.Replicas = in.Replicas
@@ -7041,6 +7447,30 @@ func Convert_kops_PodIdentityWebhookSpec_To_v1alpha3_PodIdentityWebhookSpec(in *
 	return autoConvert_kops_PodIdentityWebhookSpec_To_v1alpha3_PodIdentityWebhookSpec(in, out, s)
 }
 
+func autoConvert_v1alpha3_PodsHealthCheck_To_kops_PodsHealthCheck(in *PodsHealthCheck, out *kops.PodsHealthCheck, s conversion.Scope) error {
+	out.Namespace = in.Namespace
+	out.Selector = in.Selector
+	out.MinReady = in.MinReady
+	return nil
+}
+
+// Convert_v1alpha3_PodsHealthCheck_To_kops_PodsHealthCheck is an autogenerated conversion function.
+func Convert_v1alpha3_PodsHealthCheck_To_kops_PodsHealthCheck(in *PodsHealthCheck, out *kops.PodsHealthCheck, s conversion.Scope) error {
+	return autoConvert_v1alpha3_PodsHealthCheck_To_kops_PodsHealthCheck(in, out, s)
+}
+
+func autoConvert_kops_PodsHealthCheck_To_v1alpha3_PodsHealthCheck(in *kops.PodsHealthCheck, out *PodsHealthCheck, s conversion.Scope) error {
+	out.Namespace = in.Namespace
+	out.Selector = in.Selector
+	out.MinReady = in.MinReady
+	return nil
+}
+
+// Convert_kops_PodsHealthCheck_To_v1alpha3_PodsHealthCheck is an autogenerated conversion function.
+func Convert_kops_PodsHealthCheck_To_v1alpha3_PodsHealthCheck(in *kops.PodsHealthCheck, out *PodsHealthCheck, s conversion.Scope) error {
+	return autoConvert_kops_PodsHealthCheck_To_v1alpha3_PodsHealthCheck(in, out, s)
+}
+
 func autoConvert_v1alpha3_RBACAuthorizationSpec_To_kops_RBACAuthorizationSpec(in *RBACAuthorizationSpec, out *kops.RBACAuthorizationSpec, s conversion.Scope) error {
 	return nil
 }
@@ -7063,6 +7493,39 @@ func autoConvert_v1alpha3_RollingUpdate_To_kops_RollingUpdate(in *RollingUpdate,
 	out.DrainAndTerminate = in.DrainAndTerminate
 	out.MaxUnavailable = in.MaxUnavailable
 	out.MaxSurge = in.MaxSurge
+	if in.MaintenanceWindows != nil {
+		in, out := &in.MaintenanceWindows, &out.MaintenanceWindows
+		*out = make([]kops.MaintenanceWindow, len(*in))
+		for i := range *in {
+			if err := Convert_v1alpha3_MaintenanceWindow_To_kops_MaintenanceWindow(&(*in)[i], &(*out)[i], s); err != nil {
+				return err
+			}
+		}
+	} else {
+		out.MaintenanceWindows = nil
+	}
+	if in.InstanceHooks != nil {
+		in, out := &in.InstanceHooks, &out.InstanceHooks
+		*out = make([]kops.RollingUpdateHook, len(*in))
+		for i := range *in {
+			if err := Convert_v1alpha3_RollingUpdateHook_To_kops_RollingUpdateHook(&(*in)[i], &(*out)[i], s); err != nil {
+				return err
+			}
+		}
+	} else {
+		out.InstanceHooks = nil
+	}
+	out.Strategy = kops.RollingUpdateStrategy(in.Strategy)
+	if in.Drain != nil {
+		in, out := &in.Drain, &out.Drain
+		*out = new(kops.RollingUpdateDrain)
+		if err := Convert_v1alpha3_RollingUpdateDrain_To_kops_RollingUpdateDrain(*in, *out, s); err != nil {
+			return err
+		}
+	} else {
+		out.Drain = nil
+	}
+	out.OnFailure = kops.RollingUpdateFailurePolicy(in.OnFailure)
 	return nil
 }
 
@@ -7075,6 +7538,39 @@ func autoConvert_kops_RollingUpdate_To_v1alpha3_RollingUpdate(in *kops.RollingUp
 	out.DrainAndTerminate = in.DrainAndTerminate
 	out.MaxUnavailable = in.MaxUnavailable
 	out.MaxSurge = in.MaxSurge
+	if in.MaintenanceWindows != nil {
+		in, out := &in.MaintenanceWindows, &out.MaintenanceWindows
+		*out = make([]MaintenanceWindow, len(*in))
+		for i := range *in {
+			if err := Convert_kops_MaintenanceWindow_To_v1alpha3_MaintenanceWindow(&(*in)[i], &(*out)[i], s); err != nil {
+				return err
+			}
+		}
+	} else {
+		out.MaintenanceWindows = nil
+	}
+	if in.InstanceHooks != nil {
+		in, out := &in.InstanceHooks, &out.InstanceHooks
+		*out = make([]RollingUpdateHook, len(*in))
+		for i := range *in {
+			if err := Convert_kops_RollingUpdateHook_To_v1alpha3_RollingUpdateHook(&(*in)[i], &(*out)[i], s); err != nil {
+				return err
+			}
+		}
+	} else {
+		out.InstanceHooks = nil
+	}
+	out.Strategy = RollingUpdateStrategy(in.Strategy)
+	if in.Drain != nil {
+		in, out := &in.Drain, &out.Drain
+		*out = new(RollingUpdateDrain)
+		if err := Convert_kops_RollingUpdateDrain_To_v1alpha3_RollingUpdateDrain(*in, *out, s); err != nil {
+			return err
+		}
+	} else {
+		out.Drain = nil
+	}
+	out.OnFailure = RollingUpdateFailurePolicy(in.OnFailure)
 	return nil
 }
 
@@ -7083,6 +7579,114 @@ func Convert_kops_RollingUpdate_To_v1alpha3_RollingUpdate(in *kops.RollingUpdate
 	return autoConvert_kops_RollingUpdate_To_v1alpha3_RollingUpdate(in, out, s)
 }
 
+func autoConvert_v1alpha3_RollingUpdateDrain_To_kops_RollingUpdateDrain(in *RollingUpdateDrain, out *kops.RollingUpdateDrain, s conversion.Scope) error {
+	out.SkipPodSelector = in.SkipPodSelector
+	out.GracePeriodSeconds = in.GracePeriodSeconds
+	out.ForceUnmanagedPods = in.ForceUnmanagedPods
+	out.DeleteEmptyDirData = in.DeleteEmptyDirData
+	out.DisableEvictionAfter = in.DisableEvictionAfter
+	out.PriorityNamespaces = in.PriorityNamespaces
+	return nil
+}
+
+// Convert_v1alpha3_RollingUpdateDrain_To_kops_RollingUpdateDrain is an autogenerated conversion function.
+func Convert_v1alpha3_RollingUpdateDrain_To_kops_RollingUpdateDrain(in *RollingUpdateDrain, out *kops.RollingUpdateDrain, s conversion.Scope) error {
+	return autoConvert_v1alpha3_RollingUpdateDrain_To_kops_RollingUpdateDrain(in, out, s)
+}
+
+func autoConvert_kops_RollingUpdateDrain_To_v1alpha3_RollingUpdateDrain(in *kops.RollingUpdateDrain, out *RollingUpdateDrain, s conversion.Scope) error {
+	out.SkipPodSelector = in.SkipPodSelector
+	out.GracePeriodSeconds = in.GracePeriodSeconds
+	out.ForceUnmanagedPods = in.ForceUnmanagedPods
+	out.DeleteEmptyDirData = in.DeleteEmptyDirData
+	out.DisableEvictionAfter = in.DisableEvictionAfter
+	out.PriorityNamespaces = in.PriorityNamespaces
+	return nil
+}
+
+// Convert_kops_RollingUpdateDrain_To_v1alpha3_RollingUpdateDrain is an autogenerated conversion function.
+func Convert_kops_RollingUpdateDrain_To_v1alpha3_RollingUpdateDrain(in *kops.RollingUpdateDrain, out *RollingUpdateDrain, s conversion.Scope) error {
+	return autoConvert_kops_RollingUpdateDrain_To_v1alpha3_RollingUpdateDrain(in, out, s)
+}
+
+func autoConvert_v1alpha3_RollingUpdateHook_To_kops_RollingUpdateHook(in *RollingUpdateHook, out *kops.RollingUpdateHook, s conversion.Scope) error {
+	out.Name = in.Name
+	if in.Phases != nil {
+		in, out := &in.Phases, &out.Phases
+		*out = make([]kops.RollingUpdateHookPhase, len(*in))
+		for i := range *in {
+			(*out)[i] = kops.RollingUpdateHookPhase((*in)[i])
+		}
+	} else {
+		out.Phases = nil
+	}
+	if in.Exec != nil {
+		in, out := &in.Exec, &out.Exec
+		*out = new(kops.ExecRollingUpdateHook)
+		if err := Convert_v1alpha3_ExecRollingUpdateHook_To_kops_ExecRollingUpdateHook(*in, *out, s); err != nil {
+			return err
+		}
+	} else {
+		out.Exec = nil
+	}
+	if in.Webhook != nil {
+		in, out := &in.Webhook, &out.Webhook
+		*out = new(kops.WebhookRollingUpdateHook)
+		if err := Convert_v1alpha3_WebhookRollingUpdateHook_To_kops_WebhookRollingUpdateHook(*in, *out, s); err != nil {
+			return err
+		}
+	} else {
+		out.Webhook = nil
+	}
+	out.FailurePolicy = kops.RollingUpdateHookFailurePolicy(in.FailurePolicy)
+	out.Timeout = in.Timeout
+	return nil
+}
+
+// Convert_v1alpha3_RollingUpdateHook_To_kops_RollingUpdateHook is an autogenerated conversion function.
+func Convert_v1alpha3_RollingUpdateHook_To_kops_RollingUpdateHook(in *RollingUpdateHook, out *kops.RollingUpdateHook, s conversion.Scope) error {
+	return autoConvert_v1alpha3_RollingUpdateHook_To_kops_RollingUpdateHook(in, out, s)
+}
+
+func autoConvert_kops_RollingUpdateHook_To_v1alpha3_RollingUpdateHook(in *kops.RollingUpdateHook, out *RollingUpdateHook, s conversion.Scope) error {
+	out.Name = in.Name
+	if in.Phases != nil {
+		in, out := &in.Phases, &out.Phases
+		*out = make([]RollingUpdateHookPhase, len(*in))
+		for i := range *in {
+			(*out)[i] = RollingUpdateHookPhase((*in)[i])
+		}
+	} else {
+		out.Phases = nil
+	}
+	if in.Exec != nil {
+		in, out := &in.Exec, &out.Exec
+		*out = new(ExecRollingUpdateHook)
+		if err := Convert_kops_ExecRollingUpdateHook_To_v1alpha3_ExecRollingUpdateHook(*in, *out, s); err != nil {
+			return err
+		}
+	} else {
+		out.Exec = nil
+	}
+	if in.Webhook != nil {
+		in, out := &in.Webhook, &out.Webhook
+		*out = new(WebhookRollingUpdateHook)
+		if err := Convert_kops_WebhookRollingUpdateHook_To_v1alpha3_WebhookRollingUpdateHook(*in, *out, s); err != nil {
+			return err
+		}
+	} else {
+		out.Webhook = nil
+	}
+	out.FailurePolicy = RollingUpdateHookFailurePolicy(in.FailurePolicy)
+	out.Timeout = in.Timeout
+	return nil
+}
+
+// Convert_kops_RollingUpdateHook_To_v1alpha3_RollingUpdateHook is an autogenerated conversion function.
+func Convert_kops_RollingUpdateHook_To_v1alpha3_RollingUpdateHook(in *kops.RollingUpdateHook, out *RollingUpdateHook, s conversion.Scope) error {
+	return autoConvert_kops_RollingUpdateHook_To_v1alpha3_RollingUpdateHook(in, out, s)
+}
+
 func autoConvert_v1alpha3_RouteSpec_To_kops_RouteSpec(in *RouteSpec, out *kops.RouteSpec, s conversion.Scope) error {
 	out.CIDR = in.CIDR
 	out.Target = in.Target
@@ -7590,3 +8194,49 @@ func autoConvert_kops_WeaveNetworkingSpec_To_v1alpha3_WeaveNetworkingSpec(in *ko
 func Convert_kops_WeaveNetworkingSpec_To_v1alpha3_WeaveNetworkingSpec(in *kops.WeaveNetworkingSpec, out *WeaveNetworkingSpec, s conversion.Scope) error {
 	return autoConvert_kops_WeaveNetworkingSpec_To_v1alpha3_WeaveNetworkingSpec(in, out, s)
 }
+
+func autoConvert_v1alpha3_WebhookRollingUpdateHook_To_kops_WebhookRollingUpdateHook(in *WebhookRollingUpdateHook, out *kops.WebhookRollingUpdateHook, s conversion.Scope) error {
+	out.URL = in.URL
+	out.HeaderSecrets = in.HeaderSecrets
+	return nil
+}
+
+// Convert_v1alpha3_WebhookRollingUpdateHook_To_kops_WebhookRollingUpdateHook is an autogenerated conversion function.
+func Convert_v1alpha3_WebhookRollingUpdateHook_To_kops_WebhookRollingUpdateHook(in *WebhookRollingUpdateHook, out *kops.WebhookRollingUpdateHook, s conversion.Scope) error {
+	return autoConvert_v1alpha3_WebhookRollingUpdateHook_To_kops_WebhookRollingUpdateHook(in, out, s)
+}
+
+func autoConvert_kops_WebhookRollingUpdateHook_To_v1alpha3_WebhookRollingUpdateHook(in *kops.WebhookRollingUpdateHook, out *WebhookRollingUpdateHook, s conversion.Scope) error {
+	out.URL = in.URL
+	out.HeaderSecrets = in.HeaderSecrets
+	return nil
+}
+
+// Convert_kops_WebhookRollingUpdateHook_To_v1alpha3_WebhookRollingUpdateHook is an autogenerated conversion function.
+func Convert_kops_WebhookRollingUpdateHook_To_v1alpha3_WebhookRollingUpdateHook(in *kops.WebhookRollingUpdateHook, out *WebhookRollingUpdateHook, s conversion.Scope) error {
+	return autoConvert_kops_WebhookRollingUpdateHook_To_v1alpha3_WebhookRollingUpdateHook(in, out, s)
+}
+
+func autoConvert_v1alpha3_WorkloadHealthCheck_To_kops_WorkloadHealthCheck(in *WorkloadHealthCheck, out *kops.WorkloadHealthCheck, s conversion.Scope) error {
+	out.Kind = in.Kind
+	out.Namespace = in.Namespace
+	out.Name = in.Name
+	return nil
+}
+
+// Convert_v1alpha3_WorkloadHealthCheck_To_kops_WorkloadHealthCheck is an autogenerated conversion function.
+func Convert_v1alpha3_WorkloadHealthCheck_To_kops_WorkloadHealthCheck(in *WorkloadHealthCheck, out *kops.WorkloadHealthCheck, s conversion.Scope) error {
+	return autoConvert_v1alpha3_WorkloadHealthCheck_To_kops_WorkloadHealthCheck(in, out, s)
+}
+
+func autoConvert_kops_WorkloadHealthCheck_To_v1alpha3_WorkloadHealthCheck(in *kops.WorkloadHealthCheck, out *WorkloadHealthCheck, s conversion.Scope) error {
+	out.Kind = in.Kind
+	out.Namespace = in.Namespace
+	out.Name = in.Name
+	return nil
+}
+
+// Convert_kops_WorkloadHealthCheck_To_v1alpha3_WorkloadHealthCheck is an autogenerated conversion function.
+func Convert_kops_WorkloadHealthCheck_To_v1alpha3_WorkloadHealthCheck(in *kops.WorkloadHealthCheck, out *WorkloadHealthCheck, s conversion.Scope) error {
+	return autoConvert_kops_WorkloadHealthCheck_To_v1alpha3_WorkloadHealthCheck(in, out, s)
+}
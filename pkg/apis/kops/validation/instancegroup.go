@@ -240,6 +240,12 @@ func CrossValidateInstanceGroup(g *kops.InstanceGroup, cluster *kops.Cluster, cl
 		}
 	}
 
+	if g.Spec.RollingUpdate != nil && g.Spec.RollingUpdate.Strategy == kops.RollingUpdateStrategyBlueGreen {
+		if cluster.Spec.GetCloudProvider() != kops.CloudProviderAWS {
+			allErrs = append(allErrs, field.Forbidden(field.NewPath("spec", "rollingUpdate", "strategy"), "strategy \"BlueGreen\" only supported on AWS"))
+		}
+	}
+
 	// Check that instance groups are defined in subnets that are defined in the cluster
 	{
 		clusterSubnets := make(map[string]*kops.ClusterSubnetSpec)
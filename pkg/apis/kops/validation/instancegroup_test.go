@@ -384,6 +384,34 @@ func TestIGUpdatePolicy(t *testing.T) {
 	}
 }
 
+func TestIGBlueGreenStrategy(t *testing.T) {
+	for _, test := range []struct {
+		label         string
+		cloudProvider kops.CloudProviderSpec
+		expected      []string
+	}{
+		{
+			label:         "aws",
+			cloudProvider: kops.CloudProviderSpec{AWS: &kops.AWSSpec{}},
+		},
+		{
+			label:         "gce",
+			cloudProvider: kops.CloudProviderSpec{GCE: &kops.GCESpec{}},
+			expected:      []string{"Forbidden::spec.rollingUpdate.strategy"},
+		},
+	} {
+		t.Run(test.label, func(t *testing.T) {
+			cluster := &kops.Cluster{}
+			cluster.Spec.CloudProvider = test.cloudProvider
+			ig := createMinimalInstanceGroup()
+			ig.Spec.RollingUpdate = &kops.RollingUpdate{Strategy: kops.RollingUpdateStrategyBlueGreen}
+
+			errs := CrossValidateInstanceGroup(ig, cluster, nil, true)
+			testErrors(t, test.label, errs, test.expected)
+		})
+	}
+}
+
 func TestValidInstanceGroup(t *testing.T) {
 	grid := []struct {
 		IG             *kops.InstanceGroup
@@ -30,6 +30,7 @@ import (
 	"golang.org/x/net/ipv4"
 	"golang.org/x/net/ipv6"
 	"k8s.io/apimachinery/pkg/api/validation"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	utilnet "k8s.io/apimachinery/pkg/util/net"
 	"k8s.io/apimachinery/pkg/util/sets"
@@ -41,6 +42,7 @@ import (
 	"k8s.io/kops/pkg/featureflag"
 	"k8s.io/kops/pkg/model/components"
 	"k8s.io/kops/pkg/model/iam"
+	"k8s.io/kops/pkg/pki"
 	"k8s.io/kops/upup/pkg/fi"
 	"k8s.io/kops/upup/pkg/fi/utils"
 )
@@ -131,6 +133,10 @@ func validateClusterSpec(spec *kops.ClusterSpec, c *kops.Cluster, fieldPath *fie
 		allErrs = append(allErrs, validateKubeAPIServer(spec.KubeAPIServer, c, fieldPath.Child("kubeAPIServer"), strict)...)
 	}
 
+	if spec.PKI != nil {
+		allErrs = append(allErrs, validatePKI(spec.PKI, spec.EtcdClusters, fieldPath.Child("pki"))...)
+	}
+
 	if spec.ExternalCloudControllerManager == nil && spec.IsIPv6Only() {
 		allErrs = append(allErrs, field.Required(fieldPath.Child("cloudControllerManager"), "IPv6 requires external Cloud Controller Manager"))
 	}
@@ -213,6 +219,21 @@ func validateClusterSpec(spec *kops.ClusterSpec, c *kops.Cluster, fieldPath *fie
 
 	if spec.RollingUpdate != nil {
 		allErrs = append(allErrs, validateRollingUpdate(spec.RollingUpdate, fieldPath.Child("rollingUpdate"), false)...)
+		if spec.RollingUpdate.Strategy == kops.RollingUpdateStrategyBlueGreen && spec.GetCloudProvider() != kops.CloudProviderAWS {
+			allErrs = append(allErrs, field.Forbidden(fieldPath.Child("rollingUpdate", "strategy"), "strategy \"BlueGreen\" only supported on AWS"))
+		}
+	}
+
+	{
+		names := sets.NewString()
+		for i := range spec.HealthChecks {
+			fldPath := fieldPath.Child("healthChecks").Index(i)
+			allErrs = append(allErrs, validateClusterHealthCheck(&spec.HealthChecks[i], fldPath)...)
+			if name := spec.HealthChecks[i].Name; name != "" && names.Has(name) {
+				allErrs = append(allErrs, field.Duplicate(fldPath.Child("name"), spec.HealthChecks[i].Name))
+			}
+			names.Insert(spec.HealthChecks[i].Name)
+		}
 	}
 
 	if spec.API.LoadBalancer != nil {
@@ -711,6 +732,58 @@ func validateExecContainerAction(v *kops.ExecContainerAction, fldPath *field.Pat
 	return allErrs
 }
 
+func validatePKI(v *kops.PKISpec, etcdClusters []kops.EtcdClusterSpec, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	if v.KeyAlgorithm != "" {
+		keyAlgorithm := pki.KeyAlgorithm(v.KeyAlgorithm)
+		allErrs = append(allErrs, IsValidValue(fldPath.Child("keyAlgorithm"), &keyAlgorithm, pki.SupportedKeyAlgorithms)...)
+	}
+	for _, name := range sets.StringKeySet(v.Keysets).List() {
+		if v.Keysets[name].KeyAlgorithm != "" {
+			keyAlgorithm := pki.KeyAlgorithm(v.Keysets[name].KeyAlgorithm)
+			allErrs = append(allErrs, IsValidValue(fldPath.Child("keysets").Key(name).Child("keyAlgorithm"), &keyAlgorithm, pki.SupportedKeyAlgorithms)...)
+		}
+	}
+
+	// Kubernetes only supports RSA and ECDSA keys for signing service account tokens
+	if pki.KeyAlgorithm(v.KeyAlgorithmForKeyset("service-account")) == pki.KeyAlgorithmEd25519 {
+		if v.Keysets["service-account"].KeyAlgorithm != "" {
+			allErrs = append(allErrs, field.Forbidden(fldPath.Child("keysets").Key("service-account").Child("keyAlgorithm"), "Ed25519 keys cannot be used to sign service account tokens"))
+		} else {
+			allErrs = append(allErrs, field.Forbidden(fldPath.Child("keyAlgorithm"), "Ed25519 keys cannot be used to sign service account tokens; set a different keyAlgorithm for the service-account keyset"))
+		}
+	}
+
+	// etcd-manager only supports RSA and ECDSA keys for its CAs
+	etcdCAKeysets := sets.NewString()
+	for _, etcdCluster := range etcdClusters {
+		etcdCAKeysets.Insert("etcd-manager-ca-"+etcdCluster.Name, "etcd-peers-ca-"+etcdCluster.Name, "etcd-clients-ca")
+		if etcdCluster.Name == "cilium" {
+			etcdCAKeysets.Insert("etcd-clients-ca-cilium")
+		}
+	}
+	for name := range v.Keysets {
+		if kops.IsEtcdCAKeyset(name) {
+			etcdCAKeysets.Insert(name)
+		}
+	}
+	defaultForbidden := false
+	for _, name := range etcdCAKeysets.List() {
+		if pki.KeyAlgorithm(v.KeyAlgorithmForKeyset(name)) != pki.KeyAlgorithmEd25519 {
+			continue
+		}
+		if v.Keysets[name].KeyAlgorithm != "" {
+			allErrs = append(allErrs, field.Forbidden(fldPath.Child("keysets").Key(name).Child("keyAlgorithm"), "Ed25519 keys cannot be used for the CAs of etcd-manager"))
+		} else if !defaultForbidden {
+			allErrs = append(allErrs, field.Forbidden(fldPath.Child("keyAlgorithm"), "Ed25519 keys cannot be used for the CAs of etcd-manager; set a different keyAlgorithm for the etcd CA keysets"))
+			defaultForbidden = true
+		}
+	}
+
+	return allErrs
+}
+
 func validateKubeAPIServer(v *kops.KubeAPIServerConfig, c *kops.Cluster, fldPath *field.Path, strict bool) field.ErrorList {
 	allErrs := field.ErrorList{}
 
@@ -1819,6 +1892,147 @@ func validateRollingUpdate(rollingUpdate *kops.RollingUpdate, fldpath *field.Pat
 			allErrs = append(allErrs, field.Forbidden(fldpath.Child("maxSurge"), "Cannot be zero if maxUnavailable is zero"))
 		}
 	}
+	for i, window := range rollingUpdate.MaintenanceWindows {
+		if err := window.Validate(); err != nil {
+			allErrs = append(allErrs, field.Invalid(fldpath.Child("maintenanceWindows").Index(i), window, err.Error()))
+		}
+	}
+	for i := range rollingUpdate.InstanceHooks {
+		allErrs = append(allErrs, validateRollingUpdateHook(&rollingUpdate.InstanceHooks[i], fldpath.Child("instanceHooks").Index(i))...)
+	}
+	if rollingUpdate.Drain != nil {
+		allErrs = append(allErrs, validateRollingUpdateDrain(rollingUpdate.Drain, fldpath.Child("drain"))...)
+	}
+	if rollingUpdate.Strategy != "" {
+		allErrs = append(allErrs, IsValidValue(fldpath.Child("strategy"), &rollingUpdate.Strategy, []kops.RollingUpdateStrategy{
+			kops.RollingUpdateStrategyRollingUpdate,
+			kops.RollingUpdateStrategyBlueGreen,
+		})...)
+		if onControlPlaneInstanceGroup && rollingUpdate.Strategy == kops.RollingUpdateStrategyBlueGreen {
+			allErrs = append(allErrs, field.Forbidden(fldpath.Child("strategy"), "Cannot use strategy \"BlueGreen\" on instance groups with role \"ControlPlane\""))
+		}
+	}
+	if rollingUpdate.OnFailure != "" {
+		allErrs = append(allErrs, IsValidValue(fldpath.Child("onFailure"), &rollingUpdate.OnFailure, []kops.RollingUpdateFailurePolicy{
+			kops.RollingUpdateFailurePolicyStop,
+			kops.RollingUpdateFailurePolicyRollback,
+		})...)
+	}
+	return allErrs
+}
+
+func validateRollingUpdateDrain(drain *kops.RollingUpdateDrain, fldpath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if drain.SkipPodSelector != "" {
+		if _, err := labels.Parse(drain.SkipPodSelector); err != nil {
+			allErrs = append(allErrs, field.Invalid(fldpath.Child("skipPodSelector"), drain.SkipPodSelector, err.Error()))
+		}
+	}
+	if drain.GracePeriodSeconds != nil && *drain.GracePeriodSeconds < 0 {
+		allErrs = append(allErrs, field.Invalid(fldpath.Child("gracePeriodSeconds"), *drain.GracePeriodSeconds, "cannot be negative"))
+	}
+	if drain.DisableEvictionAfter != nil && drain.DisableEvictionAfter.Duration <= 0 {
+		allErrs = append(allErrs, field.Invalid(fldpath.Child("disableEvictionAfter"), drain.DisableEvictionAfter.Duration.String(), "must be positive"))
+	}
+	for i, namespace := range drain.PriorityNamespaces {
+		for _, msg := range utilvalidation.IsDNS1123Label(namespace) {
+			allErrs = append(allErrs, field.Invalid(fldpath.Child("priorityNamespaces").Index(i), namespace, msg))
+		}
+	}
+	return allErrs
+}
+
+func validateRollingUpdateHook(hook *kops.RollingUpdateHook, fldpath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if hook.Name == "" {
+		allErrs = append(allErrs, field.Required(fldpath.Child("name"), ""))
+	}
+	for i := range hook.Phases {
+		allErrs = append(allErrs, IsValidValue(fldpath.Child("phases").Index(i), &hook.Phases[i], kops.AllRollingUpdateHookPhases)...)
+	}
+	if hook.FailurePolicy != "" {
+		allErrs = append(allErrs, IsValidValue(fldpath.Child("failurePolicy"), &hook.FailurePolicy, []kops.RollingUpdateHookFailurePolicy{
+			kops.RollingUpdateHookFailurePolicyAbort,
+			kops.RollingUpdateHookFailurePolicyBlock,
+			kops.RollingUpdateHookFailurePolicyIgnore,
+		})...)
+	}
+	if hook.Timeout != nil && hook.Timeout.Duration <= 0 {
+		allErrs = append(allErrs, field.Invalid(fldpath.Child("timeout"), hook.Timeout.Duration.String(), "must be positive"))
+	}
+
+	switch {
+	case hook.Exec != nil && hook.Webhook != nil:
+		allErrs = append(allErrs, field.Forbidden(fldpath.Child("webhook"), "cannot be set with exec"))
+	case hook.Exec != nil:
+		if len(hook.Exec.Command) == 0 || hook.Exec.Command[0] == "" {
+			allErrs = append(allErrs, field.Required(fldpath.Child("exec", "command"), ""))
+		}
+	case hook.Webhook != nil:
+		if u, err := url.Parse(hook.Webhook.URL); err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+			allErrs = append(allErrs, field.Invalid(fldpath.Child("webhook", "url"), hook.Webhook.URL, "must be an http or https URL"))
+		}
+		for _, header := range sets.StringKeySet(hook.Webhook.HeaderSecrets).List() {
+			for _, msg := range utilvalidation.IsDNS1123Subdomain(hook.Webhook.HeaderSecrets[header]) {
+				allErrs = append(allErrs, field.Invalid(fldpath.Child("webhook", "headerSecrets").Key(header), hook.Webhook.HeaderSecrets[header], msg))
+			}
+		}
+	default:
+		allErrs = append(allErrs, field.Required(fldpath.Child("exec"), "one of exec or webhook must be set"))
+	}
+	return allErrs
+}
+
+func validateClusterHealthCheck(check *kops.ClusterHealthCheck, fldpath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if check.Name == "" {
+		allErrs = append(allErrs, field.Required(fldpath.Child("name"), ""))
+	}
+
+	count := 0
+	if check.Workload != nil {
+		count++
+		fldpath := fldpath.Child("workload")
+		allErrs = append(allErrs, IsValidValue(fldpath.Child("kind"), &check.Workload.Kind, []string{kops.WorkloadHealthCheckKindDeployment, kops.WorkloadHealthCheckKindStatefulSet})...)
+		if check.Workload.Namespace == "" {
+			allErrs = append(allErrs, field.Required(fldpath.Child("namespace"), ""))
+		}
+		if check.Workload.Name == "" {
+			allErrs = append(allErrs, field.Required(fldpath.Child("name"), ""))
+		}
+	}
+	if check.PodDisruptionBudget != nil {
+		count++
+		if check.PodDisruptionBudget.Name != "" && check.PodDisruptionBudget.Namespace == "" {
+			allErrs = append(allErrs, field.Required(fldpath.Child("podDisruptionBudget", "namespace"), "namespace is required with name"))
+		}
+	}
+	if check.HTTP != nil {
+		count++
+		if u, err := url.Parse(check.HTTP.URL); err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+			allErrs = append(allErrs, field.Invalid(fldpath.Child("http", "url"), check.HTTP.URL, "must be an http or https URL"))
+		}
+	}
+	if check.Pods != nil {
+		count++
+		fldpath := fldpath.Child("pods")
+		if check.Pods.Selector == "" {
+			allErrs = append(allErrs, field.Required(fldpath.Child("selector"), ""))
+		} else if _, err := labels.Parse(check.Pods.Selector); err != nil {
+			allErrs = append(allErrs, field.Invalid(fldpath.Child("selector"), check.Pods.Selector, err.Error()))
+		}
+		if check.Pods.MinReady != nil && *check.Pods.MinReady < 0 {
+			allErrs = append(allErrs, field.Invalid(fldpath.Child("minReady"), *check.Pods.MinReady, "cannot be negative"))
+		}
+	}
+
+	switch count {
+	case 0:
+		allErrs = append(allErrs, field.Required(fldpath, "one of workload, podDisruptionBudget, http or pods must be set"))
+	case 1:
+	default:
+		allErrs = append(allErrs, field.Forbidden(fldpath, "only one of workload, podDisruptionBudget, http or pods may be set"))
+	}
 	return allErrs
 }
 
@@ -19,7 +19,9 @@ package validation
 import (
 	"net"
 	"testing"
+	"time"
 
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/validation"
@@ -1132,6 +1134,156 @@ func Test_Validate_RollingUpdate(t *testing.T) {
 			},
 			ExpectedErrors: []string{"Forbidden::testField.maxSurge"},
 		},
+		{
+			Input: kops.RollingUpdate{
+				MaintenanceWindows: []kops.MaintenanceWindow{
+					{Days: []string{"Sat", "Sun"}, Start: "22:00", End: "04:00", TimeZone: "Europe/London"},
+				},
+			},
+		},
+		{
+			Input: kops.RollingUpdate{
+				MaintenanceWindows: []kops.MaintenanceWindow{
+					{Start: "02:00", End: "04:00"},
+					{Days: []string{"Caturday"}, Start: "02:00", End: "04:00"},
+				},
+			},
+			ExpectedErrors: []string{"Invalid value::testField.maintenanceWindows[1]"},
+		},
+		{
+			Input: kops.RollingUpdate{
+				MaintenanceWindows: []kops.MaintenanceWindow{
+					{Start: "2am", End: "4am"},
+				},
+			},
+			ExpectedErrors: []string{"Invalid value::testField.maintenanceWindows[0]"},
+		},
+		{
+			Input: kops.RollingUpdate{
+				InstanceHooks: []kops.RollingUpdateHook{
+					{
+						Name:   "drain-broker",
+						Phases: []kops.RollingUpdateHookPhase{kops.RollingUpdateHookPhaseBeforeDrain},
+						Exec:   &kops.ExecRollingUpdateHook{Command: []string{"/usr/local/bin/drain-broker"}},
+					},
+					{
+						Name:          "notify",
+						Webhook:       &kops.WebhookRollingUpdateHook{URL: "https://hooks.example.com/kops", HeaderSecrets: map[string]string{"Authorization": "hooks-token"}},
+						FailurePolicy: kops.RollingUpdateHookFailurePolicyIgnore,
+						Timeout:       &metav1.Duration{Duration: time.Minute},
+					},
+				},
+			},
+		},
+		{
+			Input: kops.RollingUpdate{
+				InstanceHooks: []kops.RollingUpdateHook{
+					{
+						Phases:        []kops.RollingUpdateHookPhase{"BeforeLunch"},
+						Exec:          &kops.ExecRollingUpdateHook{},
+						FailurePolicy: "Retry",
+						Timeout:       &metav1.Duration{},
+					},
+				},
+			},
+			ExpectedErrors: []string{
+				"Required value::testField.instanceHooks[0].name",
+				"Unsupported value::testField.instanceHooks[0].phases[0]",
+				"Required value::testField.instanceHooks[0].exec.command",
+				"Unsupported value::testField.instanceHooks[0].failurePolicy",
+				"Invalid value::testField.instanceHooks[0].timeout",
+			},
+		},
+		{
+			Input: kops.RollingUpdate{
+				InstanceHooks: []kops.RollingUpdateHook{
+					{Name: "none"},
+					{
+						Name:    "both",
+						Exec:    &kops.ExecRollingUpdateHook{Command: []string{"true"}},
+						Webhook: &kops.WebhookRollingUpdateHook{URL: "https://hooks.example.com/kops"},
+					},
+					{
+						Name:    "ftp",
+						Webhook: &kops.WebhookRollingUpdateHook{URL: "ftp://hooks.example.com/kops"},
+					},
+					{
+						Name:    "secret",
+						Webhook: &kops.WebhookRollingUpdateHook{URL: "https://hooks.example.com/kops", HeaderSecrets: map[string]string{"Authorization": "../admin"}},
+					},
+				},
+			},
+			ExpectedErrors: []string{
+				"Required value::testField.instanceHooks[0].exec",
+				"Forbidden::testField.instanceHooks[1].webhook",
+				"Invalid value::testField.instanceHooks[2].webhook.url",
+				"Invalid value::testField.instanceHooks[3].webhook.headerSecrets[Authorization]",
+			},
+		},
+		{
+			Input: kops.RollingUpdate{
+				Strategy: kops.RollingUpdateStrategyBlueGreen,
+			},
+		},
+		{
+			Input: kops.RollingUpdate{
+				Strategy: kops.RollingUpdateStrategyRollingUpdate,
+			},
+			OnMasterIG: true,
+		},
+		{
+			Input: kops.RollingUpdate{
+				Strategy: kops.RollingUpdateStrategyBlueGreen,
+			},
+			OnMasterIG:     true,
+			ExpectedErrors: []string{"Forbidden::testField.strategy"},
+		},
+		{
+			Input: kops.RollingUpdate{
+				Strategy: "Recreate",
+			},
+			ExpectedErrors: []string{"Unsupported value::testField.strategy"},
+		},
+		{
+			Input: kops.RollingUpdate{
+				OnFailure: kops.RollingUpdateFailurePolicyRollback,
+			},
+			OnMasterIG: true,
+		},
+		{
+			Input: kops.RollingUpdate{
+				OnFailure: "Retry",
+			},
+			ExpectedErrors: []string{"Unsupported value::testField.onFailure"},
+		},
+		{
+			Input: kops.RollingUpdate{
+				Drain: &kops.RollingUpdateDrain{
+					SkipPodSelector:      "app in (cache,queue)",
+					GracePeriodSeconds:   fi.PtrTo(int64(0)),
+					ForceUnmanagedPods:   fi.PtrTo(false),
+					DeleteEmptyDirData:   fi.PtrTo(false),
+					DisableEvictionAfter: &metav1.Duration{Duration: 10 * time.Minute},
+					PriorityNamespaces:   []string{"ingress", "default"},
+				},
+			},
+		},
+		{
+			Input: kops.RollingUpdate{
+				Drain: &kops.RollingUpdateDrain{
+					SkipPodSelector:      "app in cache",
+					GracePeriodSeconds:   fi.PtrTo(int64(-1)),
+					DisableEvictionAfter: &metav1.Duration{},
+					PriorityNamespaces:   []string{"Default"},
+				},
+			},
+			ExpectedErrors: []string{
+				"Invalid value::testField.drain.skipPodSelector",
+				"Invalid value::testField.drain.gracePeriodSeconds",
+				"Invalid value::testField.drain.disableEvictionAfter",
+				"Invalid value::testField.drain.priorityNamespaces[0]",
+			},
+		},
 	}
 	for _, g := range grid {
 		errs := validateRollingUpdate(&g.Input, field.NewPath("testField"), g.OnMasterIG)
@@ -1143,6 +1295,81 @@ func intStr(i intstr.IntOrString) *intstr.IntOrString {
 	return &i
 }
 
+func Test_Validate_ClusterHealthCheck(t *testing.T) {
+	grid := []struct {
+		Input          kops.ClusterHealthCheck
+		ExpectedErrors []string
+	}{
+		{
+			Input: kops.ClusterHealthCheck{
+				Name:     "kafka",
+				Workload: &kops.WorkloadHealthCheck{Kind: "StatefulSet", Namespace: "kafka", Name: "kafka"},
+			},
+		},
+		{
+			Input: kops.ClusterHealthCheck{
+				Name:     "kafka",
+				Workload: &kops.WorkloadHealthCheck{Kind: "DaemonSet"},
+			},
+			ExpectedErrors: []string{
+				"Unsupported value::spec.healthChecks[0].workload.kind",
+				"Required value::spec.healthChecks[0].workload.namespace",
+				"Required value::spec.healthChecks[0].workload.name",
+			},
+		},
+		{
+			Input: kops.ClusterHealthCheck{
+				Name:                "pdbs",
+				PodDisruptionBudget: &kops.PodDisruptionBudgetHealthCheck{},
+			},
+		},
+		{
+			Input: kops.ClusterHealthCheck{
+				Name:                "pdbs",
+				PodDisruptionBudget: &kops.PodDisruptionBudgetHealthCheck{Name: "kafka"},
+			},
+			ExpectedErrors: []string{"Required value::spec.healthChecks[0].podDisruptionBudget.namespace"},
+		},
+		{
+			Input: kops.ClusterHealthCheck{
+				Name: "prometheus",
+				HTTP: &kops.HTTPHealthCheck{URL: "prometheus.example.com/-/healthy"},
+			},
+			ExpectedErrors: []string{"Invalid value::spec.healthChecks[0].http.url"},
+		},
+		{
+			Input: kops.ClusterHealthCheck{
+				Name: "ingress",
+				Pods: &kops.PodsHealthCheck{Namespace: "ingress", Selector: "app in (ingress", MinReady: fi.PtrTo(int32(-1))},
+			},
+			ExpectedErrors: []string{
+				"Invalid value::spec.healthChecks[0].pods.selector",
+				"Invalid value::spec.healthChecks[0].pods.minReady",
+			},
+		},
+		{
+			Input: kops.ClusterHealthCheck{},
+			ExpectedErrors: []string{
+				"Required value::spec.healthChecks[0].name",
+				"Required value::spec.healthChecks[0]",
+			},
+		},
+		{
+			Input: kops.ClusterHealthCheck{
+				Name: "both",
+				HTTP: &kops.HTTPHealthCheck{URL: "https://prometheus.example.com/-/healthy"},
+				Pods: &kops.PodsHealthCheck{Selector: "app=ingress"},
+			},
+			ExpectedErrors: []string{"Forbidden::spec.healthChecks[0]"},
+		},
+	}
+
+	for _, g := range grid {
+		errs := validateClusterHealthCheck(&g.Input, field.NewPath("spec", "healthChecks").Index(0))
+		testErrors(t, g.Input, errs, g.ExpectedErrors)
+	}
+}
+
 func Test_Validate_NodeLocalDNS(t *testing.T) {
 	grid := []struct {
 		Input          kops.ClusterSpec
@@ -1428,6 +1655,107 @@ func TestValidateSAExternalPermissions(t *testing.T) {
 	}
 }
 
+func Test_Validate_PKI(t *testing.T) {
+	grid := []struct {
+		Description    string
+		Input          kops.PKISpec
+		EtcdClusters   []kops.EtcdClusterSpec
+		ExpectedErrors []string
+	}{
+		{
+			Description: "empty",
+		},
+		{
+			Description: "ECDSA default with Ed25519 keyset",
+			Input: kops.PKISpec{
+				KeyAlgorithm: "ECDSA-P256",
+				Keysets: map[string]kops.PKIKeysetSpec{
+					"kubernetes-ca": {KeyAlgorithm: "Ed25519"},
+				},
+			},
+		},
+		{
+			Description: "unsupported algorithms",
+			Input: kops.PKISpec{
+				KeyAlgorithm: "DSA",
+				Keysets: map[string]kops.PKIKeysetSpec{
+					"kubernetes-ca": {KeyAlgorithm: "ECDSA-P521"},
+				},
+			},
+			ExpectedErrors: []string{
+				"Unsupported value::pki.keyAlgorithm",
+				"Unsupported value::pki.keysets[kubernetes-ca].keyAlgorithm",
+			},
+		},
+		{
+			Description: "Ed25519 default",
+			Input: kops.PKISpec{
+				KeyAlgorithm: "Ed25519",
+			},
+			ExpectedErrors: []string{"Forbidden::pki.keyAlgorithm"},
+		},
+		{
+			Description: "Ed25519 default with RSA service account",
+			Input: kops.PKISpec{
+				KeyAlgorithm: "Ed25519",
+				Keysets: map[string]kops.PKIKeysetSpec{
+					"service-account": {KeyAlgorithm: "RSA"},
+				},
+			},
+		},
+		{
+			Description: "Ed25519 service account",
+			Input: kops.PKISpec{
+				Keysets: map[string]kops.PKIKeysetSpec{
+					"service-account": {KeyAlgorithm: "Ed25519"},
+				},
+			},
+			ExpectedErrors: []string{"Forbidden::pki.keysets[service-account].keyAlgorithm"},
+		},
+		{
+			Description: "Ed25519 etcd CAs",
+			Input: kops.PKISpec{
+				Keysets: map[string]kops.PKIKeysetSpec{
+					"etcd-manager-ca-main": {KeyAlgorithm: "Ed25519"},
+					"etcd-peers-ca-main":   {KeyAlgorithm: "ECDSA-P256"},
+				},
+			},
+			ExpectedErrors: []string{"Forbidden::pki.keysets[etcd-manager-ca-main].keyAlgorithm"},
+		},
+		{
+			Description: "Ed25519 default with etcd clusters",
+			Input: kops.PKISpec{
+				KeyAlgorithm: "Ed25519",
+				Keysets: map[string]kops.PKIKeysetSpec{
+					"service-account": {KeyAlgorithm: "RSA"},
+				},
+			},
+			EtcdClusters:   []kops.EtcdClusterSpec{{Name: "main"}, {Name: "events"}},
+			ExpectedErrors: []string{"Forbidden::pki.keyAlgorithm"},
+		},
+		{
+			Description: "Ed25519 default with ECDSA etcd CAs",
+			Input: kops.PKISpec{
+				KeyAlgorithm: "Ed25519",
+				Keysets: map[string]kops.PKIKeysetSpec{
+					"service-account":      {KeyAlgorithm: "RSA"},
+					"etcd-manager-ca-main": {KeyAlgorithm: "ECDSA-P256"},
+					"etcd-peers-ca-main":   {KeyAlgorithm: "ECDSA-P256"},
+					"etcd-clients-ca":      {KeyAlgorithm: "ECDSA-P256"},
+				},
+			},
+			EtcdClusters: []kops.EtcdClusterSpec{{Name: "main"}},
+		},
+	}
+
+	for _, g := range grid {
+		t.Run(g.Description, func(t *testing.T) {
+			errs := validatePKI(&g.Input, g.EtcdClusters, field.NewPath("pki"))
+			testErrors(t, g.Input, errs, g.ExpectedErrors)
+		})
+	}
+}
+
 func Test_Validate_Nvidia_Cluster(t *testing.T) {
 	grid := []struct {
 		Input          kops.ClusterSpec
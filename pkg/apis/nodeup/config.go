@@ -51,6 +51,8 @@ type Config struct {
 	CAs map[string]string
 	// KeypairIDs are the IDs of keysets used to sign things.
 	KeypairIDs map[string]string
+	// PKI configures the algorithms of the private keys that nodeup generates for certificates.
+	PKI *kops.PKISpec `json:",omitempty"`
 	// DefaultMachineType is the first-listed instance machine type, used if querying instance metadata fails.
 	DefaultMachineType *string `json:",omitempty"`
 	// EnableLifecycleHook defines whether we need to complete a lifecycle hook.
@@ -191,6 +193,7 @@ func NewConfig(cluster *kops.Cluster, instanceGroup *kops.InstanceGroup) (*Confi
 		KubernetesVersion: cluster.Spec.KubernetesVersion,
 		CAs:               map[string]string{},
 		KeypairIDs:        map[string]string{},
+		PKI:               cluster.Spec.PKI,
 		Networking: kops.NetworkingSpec{
 			NonMasqueradeCIDR:     cluster.Spec.Networking.NonMasqueradeCIDR,
 			ServiceClusterIPRange: cluster.Spec.Networking.ServiceClusterIPRange,
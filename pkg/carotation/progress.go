@@ -0,0 +1,203 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package carotation records the progress of rotating the keypairs of a keyset,
+// so that a rotation can be resumed after it was interrupted.
+package carotation
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/pkg/apis/kops/registry"
+	"k8s.io/kops/util/pkg/vfs"
+	"sigs.k8s.io/yaml"
+)
+
+// Phase is a step of a keyset rotation.
+type Phase string
+
+const (
+	// PhaseAddSecondary adds a new secondary keypair to each rotated keyset.
+	PhaseAddSecondary Phase = "add-secondary"
+	// PhaseRollSecondary updates and rolls the cluster, so that the new keypairs are trusted everywhere.
+	PhaseRollSecondary Phase = "roll-secondary"
+	// PhasePromote promotes the new keypairs to primary.
+	PhasePromote Phase = "promote"
+	// PhaseExportKubeconfig exports a kubeconfig trusting the new keypairs.
+	PhaseExportKubeconfig Phase = "export-kubeconfig"
+	// PhaseRollPrimary updates and rolls the cluster, so that everything is issued by the new keypairs.
+	PhaseRollPrimary Phase = "roll-primary"
+	// PhaseDistrust distrusts the previous keypairs, and updates and rolls the cluster to remove them from trust stores.
+	PhaseDistrust Phase = "distrust"
+)
+
+// Phases are the phases of a rotation, in the order they must be run.
+var Phases = []Phase{
+	PhaseAddSecondary,
+	PhaseRollSecondary,
+	PhasePromote,
+	PhaseExportKubeconfig,
+	PhaseRollPrimary,
+	PhaseDistrust,
+}
+
+// PhaseNames returns the names of all the phases, in order.
+func PhaseNames() []string {
+	var names []string
+	for _, phase := range Phases {
+		names = append(names, string(phase))
+	}
+	return names
+}
+
+// ParsePhase returns the phase with the given name.
+func ParsePhase(s string) (Phase, error) {
+	for _, phase := range Phases {
+		if string(phase) == s {
+			return phase, nil
+		}
+	}
+	return "", fmt.Errorf("unknown phase %q, expected one of %s", s, strings.Join(PhaseNames(), ", "))
+}
+
+// CompletedPhase records when a phase of a rotation completed.
+type CompletedPhase struct {
+	Phase Phase     `json:"phase"`
+	Time  time.Time `json:"time"`
+}
+
+// Progress is the record of a rotation stored in the state store.
+type Progress struct {
+	// Keyset is the keyset being rotated, or "all".
+	Keyset string `json:"keyset"`
+	// StartTime is when the rotation started.
+	StartTime time.Time `json:"startTime"`
+	// PreviousPrimaries maps the name of each rotated keyset to the ID of its primary keypair before the rotation.
+	PreviousPrimaries map[string]string `json:"previousPrimaries,omitempty"`
+	// NewKeypairs maps the name of each rotated keyset to the ID of the keypair added by the rotation.
+	NewKeypairs map[string]string `json:"newKeypairs,omitempty"`
+	// Completed lists the phases that have completed, in order.
+	Completed []CompletedPhase `json:"completed,omitempty"`
+}
+
+// NewProgress returns the progress of a rotation of the keyset that has not started yet.
+func NewProgress(keyset string, now time.Time) *Progress {
+	return &Progress{
+		Keyset:    keyset,
+		StartTime: now.UTC(),
+	}
+}
+
+// KeysetNames returns the names of the keysets that the rotation added keypairs to, sorted.
+func (p *Progress) KeysetNames() []string {
+	var names []string
+	for name := range p.NewKeypairs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// IsCompleted returns true if the phase has completed.
+func (p *Progress) IsCompleted(phase Phase) bool {
+	for _, completed := range p.Completed {
+		if completed.Phase == phase {
+			return true
+		}
+	}
+	return false
+}
+
+// NextPhase returns the first phase that has not completed, or "" if the rotation is complete.
+func (p *Progress) NextPhase() Phase {
+	for _, phase := range Phases {
+		if !p.IsCompleted(phase) {
+			return phase
+		}
+	}
+	return ""
+}
+
+// CheckNextPhase returns an error if the phase is not the next one to run.
+func (p *Progress) CheckNextPhase(phase Phase) error {
+	if p.IsCompleted(phase) {
+		return fmt.Errorf("phase %q of the rotation of %q has already completed", phase, p.Keyset)
+	}
+	if next := p.NextPhase(); next != phase {
+		return fmt.Errorf("phase %q of the rotation of %q must be run before phase %q", next, p.Keyset, phase)
+	}
+	return nil
+}
+
+// MarkCompleted records that the phase has completed.
+func (p *Progress) MarkCompleted(phase Phase, now time.Time) {
+	if p.IsCompleted(phase) {
+		return
+	}
+	p.Completed = append(p.Completed, CompletedPhase{Phase: phase, Time: now.UTC()})
+}
+
+// ProgressPath returns the location of the progress of a rotation of the keyset.
+func ProgressPath(cluster *kops.Cluster, keyset string) (vfs.Path, error) {
+	configBase, err := registry.ConfigBase(cluster)
+	if err != nil {
+		return nil, err
+	}
+	return configBase.Join(registry.PathRotation, keyset), nil
+}
+
+// Read returns the progress stored at p, or nil if no rotation is in progress.
+func Read(ctx context.Context, p vfs.Path) (*Progress, error) {
+	data, err := p.ReadFile(ctx)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error reading rotation progress %s: %w", p, err)
+	}
+	progress := &Progress{}
+	if err := yaml.Unmarshal(data, progress); err != nil {
+		return nil, fmt.Errorf("error parsing rotation progress %s: %w", p, err)
+	}
+	return progress, nil
+}
+
+// Write stores the progress at p.
+func Write(ctx context.Context, p vfs.Path, progress *Progress, acl vfs.ACL) error {
+	data, err := yaml.Marshal(progress)
+	if err != nil {
+		return fmt.Errorf("error serializing rotation progress: %w", err)
+	}
+	if err := p.WriteFile(ctx, bytes.NewReader(data), acl); err != nil {
+		return fmt.Errorf("error writing rotation progress %s: %w", p, err)
+	}
+	return nil
+}
+
+// Delete removes the progress stored at p, once the rotation is complete.
+func Delete(ctx context.Context, p vfs.Path) error {
+	if err := p.Remove(); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("error deleting rotation progress %s: %w", p, err)
+	}
+	return nil
+}
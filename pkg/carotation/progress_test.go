@@ -0,0 +1,113 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package carotation
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"k8s.io/kops/pkg/testutils/testcontext"
+	"k8s.io/kops/util/pkg/vfs"
+)
+
+func TestProgressPhases(t *testing.T) {
+	now := time.Now()
+	progress := NewProgress("kubernetes-ca", now)
+
+	if next := progress.NextPhase(); next != PhaseAddSecondary {
+		t.Fatalf("expected first phase %q, got %q", PhaseAddSecondary, next)
+	}
+	if err := progress.CheckNextPhase(PhasePromote); err == nil {
+		t.Errorf("expected error running %q before %q", PhasePromote, PhaseAddSecondary)
+	}
+
+	for i, phase := range Phases {
+		if err := progress.CheckNextPhase(phase); err != nil {
+			t.Fatalf("unexpected error checking phase %q: %v", phase, err)
+		}
+		progress.MarkCompleted(phase, now.Add(time.Duration(i)*time.Minute))
+		if err := progress.CheckNextPhase(phase); err == nil {
+			t.Errorf("expected error re-running completed phase %q", phase)
+		}
+	}
+
+	if next := progress.NextPhase(); next != "" {
+		t.Errorf("expected rotation to be complete, got next phase %q", next)
+	}
+	if len(progress.Completed) != len(Phases) {
+		t.Errorf("expected %d completed phases, got %d", len(Phases), len(progress.Completed))
+	}
+}
+
+func TestParsePhase(t *testing.T) {
+	for _, phase := range Phases {
+		actual, err := ParsePhase(string(phase))
+		if err != nil {
+			t.Errorf("unexpected error parsing %q: %v", phase, err)
+		}
+		if actual != phase {
+			t.Errorf("expected %q, got %q", phase, actual)
+		}
+	}
+	if _, err := ParsePhase("rotate"); err == nil {
+		t.Errorf("expected error parsing unknown phase")
+	}
+}
+
+func TestReadWrite(t *testing.T) {
+	ctx := testcontext.ForTest(t)
+	p := vfs.NewMemFSPath(vfs.NewMemFSContext(), "cluster/rotation/all")
+
+	progress, err := Read(ctx, p)
+	if err != nil {
+		t.Fatalf("error reading missing progress: %v", err)
+	}
+	if progress != nil {
+		t.Fatalf("expected no progress, got %+v", progress)
+	}
+
+	now := time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)
+	expected := NewProgress("all", now)
+	expected.PreviousPrimaries = map[string]string{"kubernetes-ca": "1", "service-account": "2"}
+	expected.NewKeypairs = map[string]string{"service-account": "4", "kubernetes-ca": "3"}
+	expected.MarkCompleted(PhaseAddSecondary, now.Add(time.Minute))
+
+	if err := Write(ctx, p, expected, nil); err != nil {
+		t.Fatalf("error writing progress: %v", err)
+	}
+	actual, err := Read(ctx, p)
+	if err != nil {
+		t.Fatalf("error reading progress: %v", err)
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("unexpected progress after round trip: %+v", actual)
+	}
+	if names := actual.KeysetNames(); !reflect.DeepEqual(names, []string{"kubernetes-ca", "service-account"}) {
+		t.Errorf("unexpected keyset names %v", names)
+	}
+	if next := actual.NextPhase(); next != PhaseRollSecondary {
+		t.Errorf("expected next phase %q, got %q", PhaseRollSecondary, next)
+	}
+
+	if err := Delete(ctx, p); err != nil {
+		t.Fatalf("error deleting progress: %v", err)
+	}
+	if progress, err := Read(ctx, p); err != nil || progress != nil {
+		t.Errorf("expected no progress after delete, got %+v, %v", progress, err)
+	}
+}
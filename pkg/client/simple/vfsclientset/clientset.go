@@ -143,7 +143,7 @@ func DeleteAllClusterState(basePath vfs.Path) error {
 		}
 
 		// "cluster.spec" was written by kOps 1.21 and earlier.
-		if relativePath == "config" || relativePath == "cluster.spec" || relativePath == "cluster-completed.spec" || relativePath == registry.PathKopsVersionUpdated {
+		if relativePath == "config" || relativePath == "cluster.spec" || relativePath == "cluster-completed.spec" || relativePath == registry.PathKopsVersionUpdated || relativePath == registry.PathAppliedResources || relativePath == registry.PathLock || relativePath == registry.PathRollingUpdate || relativePath == registry.PathRollingUpdatePaused {
 			continue
 		}
 		if strings.HasPrefix(relativePath, "addons/") {
@@ -164,6 +164,15 @@ func DeleteAllClusterState(basePath vfs.Path) error {
 		if strings.HasPrefix(relativePath, "igconfig/") {
 			continue
 		}
+		if strings.HasPrefix(relativePath, registry.PathHistory+"/") {
+			continue
+		}
+		if strings.HasPrefix(relativePath, registry.PathPrunedInstanceGroups+"/") {
+			continue
+		}
+		if strings.HasPrefix(relativePath, registry.PathRotation+"/") {
+			continue
+		}
 		if strings.HasPrefix(relativePath, "manifests/") {
 			continue
 		}
@@ -17,9 +17,14 @@ limitations under the License.
 package vfsclientset
 
 import (
+	"strings"
 	"testing"
 
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/pkg/testutils/testcontext"
+	"k8s.io/kops/upup/pkg/fi"
 	"k8s.io/kops/util/pkg/vfs"
 )
 
@@ -68,3 +73,170 @@ func TestSSHCredentialStoreOnOwnCFS(t *testing.T) {
 		t.Errorf("Expected %v, got %v", expected, actual)
 	}
 }
+
+func TestInstanceGroupUpdateConflict(t *testing.T) {
+	ctx := testcontext.ForTest(t)
+	vfs.Context.ResetMemfsContext(true)
+
+	basePath, err := vfs.Context.BuildVfsPath("memfs://tests")
+	if err != nil {
+		t.Fatalf("error building path: %v", err)
+	}
+	clientset := NewVFSClientset(basePath)
+
+	cluster := &kops.Cluster{}
+	cluster.ObjectMeta.Name = "test.k8s.io"
+	cluster.Spec.ConfigBase = "memfs://tests/test.k8s.io"
+
+	ig := &kops.InstanceGroup{}
+	ig.ObjectMeta.Name = "nodes"
+	ig.Spec.Role = kops.InstanceGroupRoleNode
+	ig.Spec.MinSize = fi.PtrTo(int32(1))
+	ig.Spec.MaxSize = fi.PtrTo(int32(1))
+	if _, err := clientset.InstanceGroupsFor(cluster).Create(ctx, ig, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("error creating instance group: %v", err)
+	}
+
+	first, err := clientset.InstanceGroupsFor(cluster).Get(ctx, "nodes", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("error reading instance group: %v", err)
+	}
+	if first.ObjectMeta.ResourceVersion == "" {
+		t.Fatalf("expected resourceVersion to be set")
+	}
+	second := first.DeepCopy()
+
+	first.Spec.MaxSize = fi.PtrTo(int32(2))
+	if _, err := clientset.InstanceGroupsFor(cluster).Update(ctx, first, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("error updating instance group: %v", err)
+	}
+
+	// The second writer read the same version, so its update must be rejected
+	second.Spec.MaxSize = fi.PtrTo(int32(3))
+	_, err = clientset.InstanceGroupsFor(cluster).Update(ctx, second, metav1.UpdateOptions{})
+	if !errors.IsConflict(err) {
+		t.Fatalf("expected conflict error, got %v", err)
+	}
+
+	// The first writer can keep updating, as the new resourceVersion was recorded
+	first.Spec.MaxSize = fi.PtrTo(int32(4))
+	if _, err := clientset.InstanceGroupsFor(cluster).Update(ctx, first, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("error updating instance group again: %v", err)
+	}
+
+	stored, err := clientset.InstanceGroupsFor(cluster).Get(ctx, "nodes", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("error reading instance group: %v", err)
+	}
+	if *stored.Spec.MaxSize != 4 {
+		t.Errorf("expected maxSize 4, got %d", *stored.Spec.MaxSize)
+	}
+}
+
+func TestInstanceGroupHistory(t *testing.T) {
+	ctx := testcontext.ForTest(t)
+	vfs.Context.ResetMemfsContext(true)
+
+	basePath, err := vfs.Context.BuildVfsPath("memfs://tests")
+	if err != nil {
+		t.Fatalf("error building path: %v", err)
+	}
+	clientset := NewVFSClientset(basePath)
+
+	cluster := &kops.Cluster{}
+	cluster.ObjectMeta.Name = "test.k8s.io"
+	cluster.Spec.ConfigBase = "memfs://tests/test.k8s.io"
+	igs := clientset.InstanceGroupsFor(cluster)
+
+	for _, name := range []string{"nodes", "extra"} {
+		ig := &kops.InstanceGroup{}
+		ig.ObjectMeta.Name = name
+		ig.Spec.Role = kops.InstanceGroupRoleNode
+		ig.Spec.MinSize = fi.PtrTo(int32(1))
+		ig.Spec.MaxSize = fi.PtrTo(int32(1))
+		if _, err := igs.Create(ctx, ig, metav1.CreateOptions{}); err != nil {
+			t.Fatalf("error creating instance group: %v", err)
+		}
+	}
+
+	nodes, err := igs.Get(ctx, "nodes", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("error reading instance group: %v", err)
+	}
+	nodes.Spec.MaxSize = fi.PtrTo(int32(5))
+	// The holder of the cluster lock can differ from the user running the process, as for kops-controller
+	holder := "operator@workstation (pid 42)"
+	if _, err := igs.Update(WithRevisionAuthor(ctx, holder), nodes, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("error updating instance group: %v", err)
+	}
+	// An update that does not change anything is not recorded
+	if _, err := igs.Update(ctx, nodes, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("error updating instance group: %v", err)
+	}
+	if err := igs.Delete(ctx, "extra", metav1.DeleteOptions{}); err != nil {
+		t.Fatalf("error deleting instance group: %v", err)
+	}
+
+	configBase, err := clientset.ConfigBaseFor(cluster)
+	if err != nil {
+		t.Fatalf("error getting config base: %v", err)
+	}
+	revisions, err := ListRevisions(ctx, configBase)
+	if err != nil {
+		t.Fatalf("error listing revisions: %v", err)
+	}
+
+	expected := []struct {
+		name      string
+		operation RevisionOperation
+	}{
+		{"nodes", RevisionOperationCreate},
+		{"extra", RevisionOperationCreate},
+		{"nodes", RevisionOperationUpdate},
+		{"extra", RevisionOperationDelete},
+	}
+	if len(revisions) != len(expected) {
+		t.Fatalf("expected %d revisions, got %d", len(expected), len(revisions))
+	}
+	for i, rev := range revisions {
+		if rev.Revision != i+1 || rev.Kind != "InstanceGroup" || rev.Name != expected[i].name || rev.Operation != expected[i].operation {
+			t.Errorf("unexpected revision %d: %+v", i+1, rev)
+		}
+		author := currentUser()
+		if i == 2 {
+			author = holder
+		}
+		if rev.User != author {
+			t.Errorf("expected revision %d to be made by %q, got %q", i+1, author, rev.User)
+		}
+	}
+	if !strings.Contains(revisions[2].Diff, "+   maxSize: 5") {
+		t.Errorf("expected diff of update to show the new maxSize, got %q", revisions[2].Diff)
+	}
+
+	objects, err := ObjectsAtRevision(revisions, 1)
+	if err != nil {
+		t.Fatalf("error getting objects at revision: %v", err)
+	}
+	if rev := objects[RevisionObject{Kind: "InstanceGroup", Name: "nodes"}]; rev == nil || rev.Revision != 1 {
+		t.Errorf("expected nodes at revision 1, got %+v", rev)
+	}
+	if rev, found := objects[RevisionObject{Kind: "InstanceGroup", Name: "extra"}]; !found || rev != nil {
+		t.Errorf("expected extra to be absent at revision 1, got %+v", rev)
+	}
+
+	objects, err = ObjectsAtRevision(revisions, 4)
+	if err != nil {
+		t.Fatalf("error getting objects at revision: %v", err)
+	}
+	if rev := objects[RevisionObject{Kind: "InstanceGroup", Name: "nodes"}]; rev == nil || rev.Revision != 3 {
+		t.Errorf("expected nodes at revision 3, got %+v", rev)
+	}
+	if rev, found := objects[RevisionObject{Kind: "InstanceGroup", Name: "extra"}]; !found || rev != nil {
+		t.Errorf("expected extra to be deleted at revision 4, got %+v", rev)
+	}
+
+	if _, err := ObjectsAtRevision(revisions, 10); err == nil {
+		t.Errorf("expected error for unknown revision")
+	}
+}
@@ -44,6 +44,9 @@ type ClusterVFS struct {
 func newClusterVFS(basePath vfs.Path) *ClusterVFS {
 	c := &ClusterVFS{}
 	c.init("Cluster", basePath, StoreVersion)
+	c.history = func(cluster *api.Cluster) vfs.Path {
+		return basePath.Join(cluster.Name, registry.PathHistory)
+	}
 	return c
 }
 
@@ -152,7 +155,7 @@ func (r *ClusterVFS) Update(c *api.Cluster, status *api.ClusterStatus) (*api.Clu
 	}
 
 	if err := r.writeConfig(ctx, c, r.basePath.Join(clusterName, registry.PathCluster), c, vfs.WriteOptionOnlyIfExists); err != nil {
-		if os.IsNotExist(err) {
+		if os.IsNotExist(err) || errors.IsConflict(err) {
 			return nil, err
 		}
 		return nil, fmt.Errorf("error writing Cluster: %v", err)
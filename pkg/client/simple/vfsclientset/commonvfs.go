@@ -25,9 +25,11 @@ import (
 	"sort"
 	"time"
 
+	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/klog/v2"
 	"k8s.io/kops/pkg/acls"
 	"k8s.io/kops/pkg/apis/kops"
@@ -45,6 +47,10 @@ type commonVFS struct {
 	basePath vfs.Path
 	encoder  runtime.Encoder
 	validate ValidationFunction
+
+	// history returns the location of the revision log for changes to objects of the cluster;
+	// if nil, changes are not recorded.
+	history func(cluster *kops.Cluster) vfs.Path
 }
 
 func (c *commonVFS) init(kind string, basePath vfs.Path, storeVersion runtime.GroupVersioner) {
@@ -113,8 +119,10 @@ func (c *commonVFS) serialize(o runtime.Object) ([]byte, error) {
 	return b.Bytes(), nil
 }
 
+// readConfig reads and decodes the object at configPath.
+// The ResourceVersion of the object is set to the version of the file that was read.
 func (c *commonVFS) readConfig(ctx context.Context, configPath vfs.Path) (runtime.Object, error) {
-	data, err := configPath.ReadFile(ctx)
+	data, version, err := vfs.ReadFileVersion(ctx, configPath)
 	if err != nil {
 		if os.IsNotExist(err) {
 			return nil, err
@@ -126,22 +134,45 @@ func (c *commonVFS) readConfig(ctx context.Context, configPath vfs.Path) (runtim
 	if err != nil {
 		return nil, fmt.Errorf("error parsing %s: %v", configPath, err)
 	}
+
+	objectMeta, err := meta.Accessor(object)
+	if err != nil {
+		return nil, err
+	}
+	objectMeta.SetResourceVersion(version)
+
 	return object, nil
 }
 
+// writeConfig serializes and writes the object to configPath.
+// When updating an object with a ResourceVersion, the write only succeeds if the file has not been
+// changed since that version was read; otherwise a Conflict error is returned.
+// On success, the ResourceVersion of the object is set to the version that was written.
 func (c *commonVFS) writeConfig(ctx context.Context, cluster *kops.Cluster, configPath vfs.Path, o runtime.Object, writeOptions ...vfs.WriteOption) error {
+	objectMeta, err := meta.Accessor(o)
+	if err != nil {
+		return err
+	}
+
+	// The ResourceVersion is derived from the stored file, so we never persist it
+	resourceVersion := objectMeta.GetResourceVersion()
+	objectMeta.SetResourceVersion("")
 	data, err := c.serialize(o)
+	objectMeta.SetResourceVersion(resourceVersion)
 	if err != nil {
 		return fmt.Errorf("error marshaling object: %v", err)
 	}
 
 	create := false
+	onlyIfExists := false
+	var previous []byte
 	for _, writeOption := range writeOptions {
 		switch writeOption {
 		case vfs.WriteOptionCreate:
 			create = true
 		case vfs.WriteOptionOnlyIfExists:
-			_, err = configPath.ReadFile(ctx)
+			onlyIfExists = true
+			previous, err = configPath.ReadFile(ctx)
 			if err != nil {
 				if os.IsNotExist(err) {
 					return fmt.Errorf("cannot update configuration file %s: does not exist", configPath)
@@ -159,6 +190,19 @@ func (c *commonVFS) writeConfig(ctx context.Context, cluster *kops.Cluster, conf
 	}
 
 	rs := bytes.NewReader(data)
+	if onlyIfExists && resourceVersion != "" {
+		newVersion, err := vfs.WriteFileIfVersion(ctx, configPath, rs, acl, resourceVersion)
+		if err != nil {
+			if vfs.IsVersionConflict(err) {
+				return errors.NewConflict(schema.GroupResource{Group: kops.GroupName, Resource: c.kind}, objectMeta.GetName(), err)
+			}
+			return fmt.Errorf("error writing configuration file %s: %v", configPath, err)
+		}
+		objectMeta.SetResourceVersion(newVersion)
+		c.recordRevision(ctx, cluster, objectMeta.GetName(), RevisionOperationUpdate, previous, data)
+		return nil
+	}
+
 	if create {
 		err = configPath.CreateFile(ctx, rs, acl)
 	} else {
@@ -171,6 +215,14 @@ func (c *commonVFS) writeConfig(ctx context.Context, cluster *kops.Cluster, conf
 		}
 		return fmt.Errorf("error writing configuration file %s: %v", configPath, err)
 	}
+	// We don't know the version we wrote; the object must be read again to update it conditionally
+	objectMeta.SetResourceVersion("")
+
+	operation := RevisionOperationUpdate
+	if create {
+		operation = RevisionOperationCreate
+	}
+	c.recordRevision(ctx, cluster, objectMeta.GetName(), operation, previous, data)
 	return nil
 }
 
@@ -194,14 +246,27 @@ func (c *commonVFS) update(ctx context.Context, cluster *kops.Cluster, i runtime
 
 	err = c.writeConfig(ctx, cluster, c.basePath.Join(objectMeta.GetName()), i, vfs.WriteOptionOnlyIfExists)
 	if err != nil {
+		if errors.IsConflict(err) {
+			return err
+		}
 		return fmt.Errorf("error writing %s: %v", c.kind, err)
 	}
 
 	return nil
 }
 
-func (c *commonVFS) delete(ctx context.Context, name string, options metav1.DeleteOptions) error {
+func (c *commonVFS) delete(ctx context.Context, cluster *kops.Cluster, name string, options metav1.DeleteOptions) error {
 	p := c.basePath.Join(name)
+
+	var previous []byte
+	if c.history != nil {
+		data, err := p.ReadFile(ctx)
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("error reading %s configuration %q: %v", c.kind, name, err)
+		}
+		previous = data
+	}
+
 	err := p.Remove()
 	if err != nil {
 		if os.IsNotExist(err) {
@@ -209,6 +274,7 @@ func (c *commonVFS) delete(ctx context.Context, name string, options metav1.Dele
 		}
 		return fmt.Errorf("error deleting %s configuration %q: %v", c.kind, name, err)
 	}
+	c.recordRevision(ctx, cluster, name, RevisionOperationDelete, previous, nil)
 	return nil
 }
 
@@ -0,0 +1,246 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vfsclientset
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/user"
+	"sort"
+	"strconv"
+	"time"
+
+	"k8s.io/klog/v2"
+	kopsbase "k8s.io/kops"
+	"k8s.io/kops/pkg/acls"
+	"k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/pkg/apis/kops/registry"
+	"k8s.io/kops/pkg/diff"
+	"k8s.io/kops/util/pkg/vfs"
+	"sigs.k8s.io/yaml"
+)
+
+// RevisionOperation is the kind of change recorded by a Revision.
+type RevisionOperation string
+
+const (
+	RevisionOperationCreate RevisionOperation = "Create"
+	RevisionOperationUpdate RevisionOperation = "Update"
+	RevisionOperationDelete RevisionOperation = "Delete"
+)
+
+// maxAppendAttempts bounds the retries when another writer appends a revision at the same time.
+const maxAppendAttempts = 5
+
+// Revision is an entry in the revision log of a cluster,
+// recording a change to the Cluster or one of its InstanceGroups.
+type Revision struct {
+	// Revision is the number of the entry; revisions are numbered from 1, in the order they were written.
+	Revision int `json:"revision"`
+	// Timestamp is when the change was made.
+	Timestamp time.Time `json:"timestamp"`
+	// User identifies who made the change, e.g. user@host.
+	User string `json:"user,omitempty"`
+	// KopsVersion is the version of kOps that made the change.
+	KopsVersion string `json:"kopsVersion,omitempty"`
+	// Kind is the kind of object that was changed.
+	Kind string `json:"kind"`
+	// Name is the name of the object that was changed.
+	Name string `json:"name"`
+	// Operation is the kind of change.
+	Operation RevisionOperation `json:"operation"`
+	// Object is the serialized object after the change; it is empty for a deletion.
+	Object string `json:"object,omitempty"`
+	// Diff is the difference from the previous version of the object, if there was one.
+	Diff string `json:"diff,omitempty"`
+}
+
+// RevisionObject identifies an object in the revision log.
+type RevisionObject struct {
+	Kind string
+	Name string
+}
+
+// revisionAuthorKey is the context key for the author of revisions, as set by WithRevisionAuthor.
+type revisionAuthorKey struct{}
+
+// WithRevisionAuthor returns a context in which changes are recorded as made by author, e.g. the holder of the
+// cluster lock, rather than by the user running the current process.
+func WithRevisionAuthor(ctx context.Context, author string) context.Context {
+	return context.WithValue(ctx, revisionAuthorKey{}, author)
+}
+
+// revisionAuthor returns who makes the changes recorded with ctx.
+func revisionAuthor(ctx context.Context) string {
+	if author, ok := ctx.Value(revisionAuthorKey{}).(string); ok && author != "" {
+		return author
+	}
+	return currentUser()
+}
+
+// recordRevision appends a revision to the log, if the object changed.
+// The change has already been written, so failures are logged rather than returned.
+func (c *commonVFS) recordRevision(ctx context.Context, cluster *kops.Cluster, name string, operation RevisionOperation, previous, current []byte) {
+	if c.history == nil {
+		return
+	}
+	if operation == RevisionOperationUpdate && previous != nil && bytes.Equal(previous, current) {
+		return
+	}
+
+	rev := &Revision{
+		Timestamp:   time.Now().UTC(),
+		User:        revisionAuthor(ctx),
+		KopsVersion: kopsbase.Version,
+		Kind:        c.kind,
+		Name:        name,
+		Operation:   operation,
+		Object:      string(current),
+	}
+	if previous != nil {
+		rev.Diff = diff.FormatDiff(string(previous), string(current))
+	}
+
+	historyPath := c.history(cluster)
+	if err := appendRevision(ctx, cluster, historyPath, rev); err != nil {
+		klog.Warningf("unable to record change to %s %q in %s: %v", c.kind, name, historyPath, err)
+	}
+}
+
+// appendRevision writes the revision as the next entry in the log at historyPath.
+func appendRevision(ctx context.Context, cluster *kops.Cluster, historyPath vfs.Path, rev *Revision) error {
+	for attempt := 1; ; attempt++ {
+		numbers, err := listRevisionNumbers(ctx, historyPath)
+		if err != nil {
+			return err
+		}
+		rev.Revision = 1
+		if len(numbers) != 0 {
+			rev.Revision = numbers[len(numbers)-1] + 1
+		}
+
+		data, err := yaml.Marshal(rev)
+		if err != nil {
+			return fmt.Errorf("error serializing revision: %w", err)
+		}
+
+		p := historyPath.Join(revisionFileName(rev.Revision))
+		acl, err := acls.GetACL(ctx, p, cluster)
+		if err != nil {
+			return err
+		}
+		err = p.CreateFile(ctx, bytes.NewReader(data), acl)
+		if err == nil {
+			return nil
+		}
+		if !os.IsExist(err) || attempt >= maxAppendAttempts {
+			return fmt.Errorf("error writing %s: %w", p, err)
+		}
+		// Someone else wrote this revision; try the next one
+	}
+}
+
+// ListRevisions returns the revision log of the cluster whose state is stored at configBase, oldest first.
+func ListRevisions(ctx context.Context, configBase vfs.Path) ([]*Revision, error) {
+	historyPath := configBase.Join(registry.PathHistory)
+	numbers, err := listRevisionNumbers(ctx, historyPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var revisions []*Revision
+	for _, n := range numbers {
+		p := historyPath.Join(revisionFileName(n))
+		data, err := p.ReadFile(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("error reading %s: %w", p, err)
+		}
+		rev := &Revision{}
+		if err := yaml.Unmarshal(data, rev); err != nil {
+			return nil, fmt.Errorf("error parsing %s: %w", p, err)
+		}
+		revisions = append(revisions, rev)
+	}
+	return revisions, nil
+}
+
+// ObjectsAtRevision returns the state of each object in the log as of the specified revision:
+// the last revision of the object up to and including it, or nil if the object was deleted by then
+// or only created afterwards. Objects whose changes were all recorded later, but which already
+// existed, are not included, as their state at that revision is unknown.
+func ObjectsAtRevision(revisions []*Revision, revision int) (map[RevisionObject]*Revision, error) {
+	found := false
+	objects := make(map[RevisionObject]*Revision)
+	for _, rev := range revisions {
+		key := RevisionObject{Kind: rev.Kind, Name: rev.Name}
+		if rev.Revision > revision {
+			if _, seen := objects[key]; !seen && rev.Operation == RevisionOperationCreate {
+				objects[key] = nil
+			}
+			continue
+		}
+		if rev.Revision == revision {
+			found = true
+		}
+		if rev.Operation == RevisionOperationDelete {
+			objects[key] = nil
+		} else {
+			objects[key] = rev
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("revision %d not found", revision)
+	}
+	return objects, nil
+}
+
+func listRevisionNumbers(ctx context.Context, historyPath vfs.Path) ([]int, error) {
+	names, err := listChildNames(ctx, historyPath)
+	if err != nil {
+		return nil, err
+	}
+	var numbers []int
+	for _, name := range names {
+		n, err := strconv.Atoi(name)
+		if err != nil || n <= 0 {
+			klog.V(2).Infof("ignoring unexpected file %q in %s", name, historyPath)
+			continue
+		}
+		numbers = append(numbers, n)
+	}
+	sort.Ints(numbers)
+	return numbers, nil
+}
+
+// revisionFileName is zero-padded so the log sorts naturally when browsing the state store.
+func revisionFileName(revision int) string {
+	return fmt.Sprintf("%08d", revision)
+}
+
+func currentUser() string {
+	username := "unknown"
+	if u, err := user.Current(); err == nil {
+		username = u.Username
+	}
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+	return username + "@" + hostname
+}
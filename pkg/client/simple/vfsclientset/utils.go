@@ -20,6 +20,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"strings"
 
 	"k8s.io/kops/util/pkg/vfs"
 )
@@ -35,7 +36,12 @@ func listChildNames(ctx context.Context, vfsPath vfs.Path) ([]string, error) {
 
 	var names []string
 	for _, child := range children {
-		names = append(names, child.Base())
+		name := child.Base()
+		if strings.HasPrefix(name, ".") {
+			// Skip hidden files, such as lock files
+			continue
+		}
+		names = append(names, name)
 	}
 	return names, nil
 }
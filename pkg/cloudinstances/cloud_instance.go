@@ -32,6 +32,9 @@ type State string
 // WarmPool means the instance is in the warm pool
 const WarmPool State = "WarmPool"
 
+// Replacement means the instance is in a copy of its cloud group, created to replace the instances of the group blue/green
+const Replacement State = "Replacement"
+
 // CloudInstance describes an instance in a CloudInstanceGroup group.
 type CloudInstance struct {
 	// ID is a unique identifier for the instance, meaningful to the cloud
@@ -0,0 +1,441 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clusterlock
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/user"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+	"k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/pkg/apis/kops/registry"
+	"k8s.io/kops/util/pkg/vfs"
+)
+
+const (
+	// DefaultLeaseDuration is how long a lease is valid for if it is not renewed.
+	DefaultLeaseDuration = time.Minute
+)
+
+// Lease is the record stored in the state store to lock a cluster.
+// A lease that has been released is kept with an empty Holder, so that later
+// acquisitions can always use a conditional write.
+type Lease struct {
+	// Holder identifies who holds the lease, e.g. user@host.
+	Holder string `json:"holder,omitempty"`
+	// ID is unique to each acquisition of the lease.
+	ID string `json:"id,omitempty"`
+	// Operation describes what the holder is doing, e.g. "update cluster".
+	Operation string `json:"operation,omitempty"`
+	// AcquireTime is when the lease was acquired.
+	AcquireTime time.Time `json:"acquireTime,omitempty"`
+	// RenewTime is when the holder last renewed the lease.
+	RenewTime time.Time `json:"renewTime,omitempty"`
+	// ExpireTime is when the lease expires, unless it is renewed.
+	ExpireTime time.Time `json:"expireTime,omitempty"`
+}
+
+// IsHeld returns true if the lease has a holder and has not expired.
+func (l *Lease) IsHeld(now time.Time) bool {
+	return l.Holder != "" && now.Before(l.ExpireTime)
+}
+
+// HeldError is returned when trying to acquire a lease that is held by someone else.
+type HeldError struct {
+	Lease *Lease
+}
+
+func (e *HeldError) Error() string {
+	return fmt.Sprintf("cluster is locked by %s (%s) since %s, until %s; if that operation is no longer running, wait for the lock to expire or remove it with kops delete lock --force",
+		e.Lease.Holder, e.Lease.Operation, e.Lease.AcquireTime.Format(time.RFC3339), e.Lease.ExpireTime.Format(time.RFC3339))
+}
+
+// Options configures the acquisition of a lease.
+type Options struct {
+	// Holder identifies who is acquiring the lease; defaults to DefaultHolder().
+	Holder string
+	// Operation describes the operation being performed.
+	Operation string
+	// LeaseDuration is how long the lease is valid for without renewal; defaults to DefaultLeaseDuration.
+	LeaseDuration time.Duration
+}
+
+// Lock is a lease that we hold, and renew in the background until it is released.
+type Lock struct {
+	path          vfs.Path
+	leaseDuration time.Duration
+
+	mutex   sync.Mutex
+	lease   Lease
+	version string
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	// nested is true if the lease is held by an enclosing operation, which renews and releases it.
+	nested bool
+}
+
+// heldLockKey is the context key for the Lock held by the operation, as set by WithContext.
+type heldLockKey struct{}
+
+// LockPath returns the location of the lease for the cluster.
+func LockPath(cluster *kops.Cluster) (vfs.Path, error) {
+	configBase, err := registry.ConfigBase(cluster)
+	if err != nil {
+		return nil, err
+	}
+	return configBase.Join(registry.PathLock), nil
+}
+
+// DefaultHolder returns an identity for the current process, of the form user@host (pid N).
+func DefaultHolder() string {
+	username := "unknown"
+	if u, err := user.Current(); err == nil {
+		username = u.Username
+	}
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+	return fmt.Sprintf("%s@%s (pid %d)", username, hostname, os.Getpid())
+}
+
+// Read returns the lease stored at p, or nil if there is none.
+func Read(ctx context.Context, p vfs.Path) (*Lease, error) {
+	lease, _, err := read(ctx, p)
+	return lease, err
+}
+
+func read(ctx context.Context, p vfs.Path) (*Lease, string, error) {
+	data, version, err := vfs.ReadFileVersion(ctx, p)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, "", nil
+		}
+		return nil, "", fmt.Errorf("error reading lock %s: %w", p, err)
+	}
+	lease := &Lease{}
+	if err := json.Unmarshal(data, lease); err != nil {
+		return nil, "", fmt.Errorf("error parsing lock %s: %w", p, err)
+	}
+	return lease, version, nil
+}
+
+// Delete removes the lease stored at p, regardless of who holds it.
+func Delete(ctx context.Context, p vfs.Path) error {
+	if err := p.Remove(); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("error deleting lock %s: %w", p, err)
+	}
+	return nil
+}
+
+// Acquire takes the lease stored at p, returning a HeldError if someone else holds it.
+// The lease is renewed in the background until Release is called.
+// If ctx was returned by WithContext of a Lock for p, that lease is reused, and releasing the returned Lock does nothing,
+// so that an operation can run other operations that take the same lock.
+func Acquire(ctx context.Context, p vfs.Path, options Options) (*Lock, error) {
+	if held, ok := ctx.Value(heldLockKey{}).(*Lock); ok && held.path.Path() == p.Path() {
+		klog.V(2).Infof("reusing lock %s for %s", p, options.Operation)
+		return &Lock{
+			path:   p,
+			lease:  Lease{Holder: held.Holder()},
+			done:   held.done,
+			nested: true,
+		}, nil
+	}
+
+	if options.Holder == "" {
+		options.Holder = DefaultHolder()
+	}
+	if options.LeaseDuration == 0 {
+		options.LeaseDuration = DefaultLeaseDuration
+	}
+
+	id, err := newLeaseID()
+	if err != nil {
+		return nil, err
+	}
+
+	existing, version, err := read(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	if existing != nil && existing.IsHeld(now) {
+		return nil, &HeldError{Lease: existing}
+	}
+	if existing != nil && existing.Holder != "" {
+		klog.Warningf("taking over expired lock held by %s (%s), which expired at %s", existing.Holder, existing.Operation, existing.ExpireTime.Format(time.RFC3339))
+	}
+
+	l := &Lock{
+		path:          p,
+		leaseDuration: options.LeaseDuration,
+		lease: Lease{
+			Holder:      options.Holder,
+			ID:          id,
+			Operation:   options.Operation,
+			AcquireTime: now,
+			RenewTime:   now,
+			ExpireTime:  now.Add(options.LeaseDuration),
+		},
+		done: make(chan struct{}),
+	}
+
+	data, err := json.Marshal(&l.lease)
+	if err != nil {
+		return nil, fmt.Errorf("error serializing lock: %w", err)
+	}
+
+	// On stores without conditional writes, a concurrent acquisition can replace our lease without either of us
+	// seeing an error, so we read the lease back to find out who won.
+	_, atomic := p.(vfs.VersionedPath)
+	if existing == nil {
+		l.version, atomic, err = vfs.CreateFileIfNotExists(ctx, p, bytes.NewReader(data), nil)
+		if err != nil {
+			if os.IsExist(err) || vfs.IsVersionConflict(err) {
+				return nil, acquireRaceError(ctx, p)
+			}
+			return nil, fmt.Errorf("error creating lock %s: %w", p, err)
+		}
+	} else {
+		l.version, err = vfs.WriteFileIfVersion(ctx, p, bytes.NewReader(data), nil, version)
+		if err != nil {
+			if vfs.IsVersionConflict(err) {
+				return nil, acquireRaceError(ctx, p)
+			}
+			return nil, fmt.Errorf("error writing lock %s: %w", p, err)
+		}
+	}
+	if !atomic {
+		l.version, err = confirmWritten(ctx, p, id)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	klog.V(2).Infof("acquired lock %s as %s", p, options.Holder)
+
+	l.ctx, l.cancel = context.WithCancel(context.Background())
+	go l.renewLoop()
+
+	return l, nil
+}
+
+// confirmSettleDelay is how long confirmWritten waits before reading the lease back a second time.
+var confirmSettleDelay = 5 * time.Second
+
+// confirmWritten checks that the lease at p is still the one with the given id, both immediately after we wrote it
+// and again after confirmSettleDelay, returning its version.  This is for stores that cannot write conditionally
+// (e.g. Swift): another process that read the lease before our write landed may still replace it, and the second
+// read catches writes that take up to confirmSettleDelay to land.  It is not safe against slower writers,
+// or against stores that do not read back their own writes consistently.
+func confirmWritten(ctx context.Context, p vfs.Path, id string) (string, error) {
+	written, _, err := read(ctx, p)
+	if err != nil {
+		return "", err
+	}
+	if written == nil || written.ID != id {
+		return "", acquireRaceError(ctx, p)
+	}
+
+	select {
+	case <-ctx.Done():
+		return "", ctx.Err()
+	case <-time.After(confirmSettleDelay):
+	}
+
+	written, version, err := read(ctx, p)
+	if err != nil {
+		return "", err
+	}
+	if written == nil || written.ID != id {
+		return "", acquireRaceError(ctx, p)
+	}
+	return version, nil
+}
+
+// AcquireForCluster takes the lease that locks the cluster, for the described operation.
+func AcquireForCluster(ctx context.Context, cluster *kops.Cluster, operation string) (*Lock, error) {
+	p, err := LockPath(cluster)
+	if err != nil {
+		return nil, err
+	}
+	return Acquire(ctx, p, Options{Operation: operation})
+}
+
+// acquireRaceError builds the error when someone else acquired the lease at the same time as us.
+func acquireRaceError(ctx context.Context, p vfs.Path) error {
+	lease, err := Read(ctx, p)
+	if err == nil && lease != nil && lease.Holder != "" {
+		return &HeldError{Lease: lease}
+	}
+	return fmt.Errorf("lock %s was modified concurrently; please try again", p)
+}
+
+// Lost returns a channel that is closed if the lease is lost, for example because
+// it could not be renewed before it expired, or it was forcibly deleted.
+// It is also closed when the lease is released.
+func (l *Lock) Lost() <-chan struct{} {
+	return l.done
+}
+
+// Holder returns who holds the lease, as recorded in the state store.
+func (l *Lock) Holder() string {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.lease.Holder
+}
+
+// WithContext returns a context derived from ctx that is cancelled if the lease is lost,
+// so that the operation protected by the lock stops.
+// Acquiring the same lock with the returned context reuses the lease.
+func (l *Lock) WithContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.WithValue(ctx, heldLockKey{}, l))
+	go func() {
+		select {
+		case <-l.done:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, cancel
+}
+
+// Release stops renewing the lease, and marks it as released in the state store.
+// A Lock that reuses the lease of an enclosing operation is not released.
+func (l *Lock) Release(ctx context.Context) error {
+	if l.nested {
+		return nil
+	}
+
+	l.cancel()
+	<-l.done
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	current, version, err := read(ctx, l.path)
+	if err != nil {
+		return err
+	}
+	if current == nil || current.ID != l.lease.ID {
+		klog.Warningf("lock %s is no longer held by us; not releasing", l.path)
+		return nil
+	}
+
+	released := Lease{
+		RenewTime: time.Now().UTC(),
+	}
+	data, err := json.Marshal(&released)
+	if err != nil {
+		return fmt.Errorf("error serializing lock: %w", err)
+	}
+	if _, err := vfs.WriteFileIfVersion(ctx, l.path, bytes.NewReader(data), nil, version); err != nil {
+		return fmt.Errorf("error releasing lock %s: %w", l.path, err)
+	}
+	klog.V(2).Infof("released lock %s", l.path)
+	return nil
+}
+
+// renewLoop renews the lease until the lock is released or lost.
+func (l *Lock) renewLoop() {
+	defer close(l.done)
+
+	interval := l.leaseDuration / 3
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-l.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		lost, err := l.renew()
+		if err != nil {
+			if l.ctx.Err() != nil {
+				// Released while we were renewing
+				return
+			}
+			if lost {
+				klog.Errorf("lost lock %s: %v", l.path, err)
+				return
+			}
+			klog.Warningf("error renewing lock %s: %v", l.path, err)
+
+			l.mutex.Lock()
+			expired := !time.Now().Before(l.lease.ExpireTime)
+			l.mutex.Unlock()
+			if expired {
+				klog.Errorf("lost lock %s: lease expired before it could be renewed", l.path)
+				return
+			}
+		}
+	}
+}
+
+// renew extends the lease, returning lost=true if the lease is no longer ours.
+func (l *Lock) renew() (bool, error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	ctx := l.ctx
+
+	lease := l.lease
+	now := time.Now().UTC()
+	lease.RenewTime = now
+	lease.ExpireTime = now.Add(l.leaseDuration)
+
+	data, err := json.Marshal(&lease)
+	if err != nil {
+		return false, fmt.Errorf("error serializing lock: %w", err)
+	}
+
+	version, err := vfs.WriteFileIfVersion(ctx, l.path, bytes.NewReader(data), nil, l.version)
+	if err != nil {
+		if vfs.IsVersionConflict(err) || errors.Is(err, os.ErrNotExist) {
+			return true, fmt.Errorf("lock was modified by someone else: %w", err)
+		}
+		return false, err
+	}
+
+	l.lease = lease
+	l.version = version
+	return false, nil
+}
+
+func newLeaseID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("error generating lock id: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
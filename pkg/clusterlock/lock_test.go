@@ -0,0 +1,260 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clusterlock
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"k8s.io/kops/pkg/testutils/testcontext"
+	"k8s.io/kops/util/pkg/vfs"
+)
+
+func TestAcquireRelease(t *testing.T) {
+	ctx := testcontext.ForTest(t)
+	p := vfs.NewMemFSPath(vfs.NewMemFSContext(), "cluster/lock")
+
+	first, err := Acquire(ctx, p, Options{Holder: "first", Operation: "update cluster"})
+	if err != nil {
+		t.Fatalf("error acquiring lock: %v", err)
+	}
+	if first.Holder() != "first" {
+		t.Errorf("expected lock to be held by %q, got %q", "first", first.Holder())
+	}
+
+	_, err = Acquire(ctx, p, Options{Holder: "second", Operation: "rolling-update cluster"})
+	var heldError *HeldError
+	if !errors.As(err, &heldError) {
+		t.Fatalf("expected HeldError, got %v", err)
+	}
+	if heldError.Lease.Holder != "first" || heldError.Lease.Operation != "update cluster" {
+		t.Errorf("unexpected lease in error: %+v", heldError.Lease)
+	}
+
+	if err := first.Release(ctx); err != nil {
+		t.Fatalf("error releasing lock: %v", err)
+	}
+
+	lease, err := Read(ctx, p)
+	if err != nil {
+		t.Fatalf("error reading lock: %v", err)
+	}
+	if lease == nil || lease.Holder != "" {
+		t.Errorf("expected released lease, got %+v", lease)
+	}
+
+	second, err := Acquire(ctx, p, Options{Holder: "second", Operation: "rolling-update cluster"})
+	if err != nil {
+		t.Fatalf("error acquiring released lock: %v", err)
+	}
+	if err := second.Release(ctx); err != nil {
+		t.Fatalf("error releasing lock: %v", err)
+	}
+}
+
+func TestAcquireNested(t *testing.T) {
+	ctx := testcontext.ForTest(t)
+	p := vfs.NewMemFSPath(vfs.NewMemFSContext(), "cluster/lock")
+
+	outer, err := Acquire(ctx, p, Options{Holder: "holder", Operation: "rotate ca"})
+	if err != nil {
+		t.Fatalf("error acquiring lock: %v", err)
+	}
+	outerCtx, cancel := outer.WithContext(ctx)
+	defer cancel()
+
+	// An operation run by the holder reuses the lease
+	inner, err := Acquire(outerCtx, p, Options{Holder: "holder", Operation: "update cluster"})
+	if err != nil {
+		t.Fatalf("error acquiring nested lock: %v", err)
+	}
+	if inner.Holder() != "holder" {
+		t.Errorf("expected nested lock to be held by %q, got %q", "holder", inner.Holder())
+	}
+	if err := inner.Release(outerCtx); err != nil {
+		t.Fatalf("error releasing nested lock: %v", err)
+	}
+
+	lease, err := Read(ctx, p)
+	if err != nil {
+		t.Fatalf("error reading lock: %v", err)
+	}
+	if lease == nil || lease.Holder != "holder" || lease.Operation != "rotate ca" {
+		t.Errorf("expected lease to still be held by the outer operation, got %+v", lease)
+	}
+
+	// Anyone else is still locked out
+	if _, err := Acquire(ctx, p, Options{Holder: "other", Operation: "update cluster"}); err == nil {
+		t.Errorf("expected error acquiring lock held by another operation")
+	}
+
+	if err := outer.Release(ctx); err != nil {
+		t.Fatalf("error releasing lock: %v", err)
+	}
+}
+
+func TestAcquireExpired(t *testing.T) {
+	ctx := testcontext.ForTest(t)
+	p := vfs.NewMemFSPath(vfs.NewMemFSContext(), "cluster/lock")
+
+	stale := &Lease{
+		Holder:      "crashed",
+		ID:          "1234",
+		Operation:   "update cluster",
+		AcquireTime: time.Now().Add(-time.Hour),
+		RenewTime:   time.Now().Add(-time.Hour),
+		ExpireTime:  time.Now().Add(-time.Hour + DefaultLeaseDuration),
+	}
+	data, err := json.Marshal(stale)
+	if err != nil {
+		t.Fatalf("error serializing lease: %v", err)
+	}
+	if err := p.WriteFile(ctx, bytes.NewReader(data), nil); err != nil {
+		t.Fatalf("error writing lease: %v", err)
+	}
+
+	l, err := Acquire(ctx, p, Options{Holder: "new"})
+	if err != nil {
+		t.Fatalf("error taking over expired lock: %v", err)
+	}
+	defer l.Release(ctx)
+
+	lease, err := Read(ctx, p)
+	if err != nil {
+		t.Fatalf("error reading lock: %v", err)
+	}
+	if lease.Holder != "new" {
+		t.Errorf("expected lock to be held by new, got %q", lease.Holder)
+	}
+}
+
+func TestRenewAndLost(t *testing.T) {
+	ctx := testcontext.ForTest(t)
+	p := vfs.NewMemFSPath(vfs.NewMemFSContext(), "cluster/lock")
+
+	l, err := Acquire(ctx, p, Options{Holder: "holder", LeaseDuration: 150 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("error acquiring lock: %v", err)
+	}
+
+	initial, err := Read(ctx, p)
+	if err != nil {
+		t.Fatalf("error reading lock: %v", err)
+	}
+
+	// Wait for a few renewals; the lease should still be held, with a later expiry
+	time.Sleep(200 * time.Millisecond)
+	renewed, err := Read(ctx, p)
+	if err != nil {
+		t.Fatalf("error reading lock: %v", err)
+	}
+	if !renewed.IsHeld(time.Now()) {
+		t.Fatalf("expected lease to have been renewed, got %+v", renewed)
+	}
+	if !renewed.ExpireTime.After(initial.ExpireTime) {
+		t.Errorf("expected expiry to be extended beyond %v, got %v", initial.ExpireTime, renewed.ExpireTime)
+	}
+
+	// Forcibly deleting the lease should be noticed by the holder
+	if err := Delete(ctx, p); err != nil {
+		t.Fatalf("error deleting lock: %v", err)
+	}
+	select {
+	case <-l.Lost():
+	case <-time.After(5 * time.Second):
+		t.Fatalf("expected lock to be lost after deletion")
+	}
+}
+
+// basePath hides the conditional writes of the path it wraps.
+type basePath interface {
+	vfs.Path
+}
+
+// racingPath is a Path without conditional writes, like Swift, where someone else's lease
+// lands after we have read ours back once.
+type racingPath struct {
+	basePath
+
+	created bool
+	reads   int
+	other   []byte
+}
+
+func (p *racingPath) CreateFile(ctx context.Context, data io.ReadSeeker, acl vfs.ACL) error {
+	if err := p.basePath.CreateFile(ctx, data, acl); err != nil {
+		return err
+	}
+	p.created = true
+	return nil
+}
+
+func (p *racingPath) ReadFile(ctx context.Context) ([]byte, error) {
+	data, err := p.basePath.ReadFile(ctx)
+	if p.created && p.other != nil {
+		p.reads++
+		if p.reads == 1 {
+			if err := p.basePath.WriteFile(ctx, bytes.NewReader(p.other), nil); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return data, err
+}
+
+func TestAcquireWithoutConditionalWrites(t *testing.T) {
+	ctx := testcontext.ForTest(t)
+
+	defer func(delay time.Duration) { confirmSettleDelay = delay }(confirmSettleDelay)
+	confirmSettleDelay = 0
+
+	other, err := json.Marshal(&Lease{
+		Holder:     "other",
+		ID:         "1234",
+		Operation:  "update cluster",
+		ExpireTime: time.Now().Add(time.Hour),
+	})
+	if err != nil {
+		t.Fatalf("error serializing lease: %v", err)
+	}
+
+	// Someone else's create lands after we have read our lease back once; we must back off
+	p := &racingPath{basePath: vfs.NewMemFSPath(vfs.NewMemFSContext(), "cluster/lock"), other: other}
+	_, err = Acquire(ctx, p, Options{Holder: "holder"})
+	var heldError *HeldError
+	if !errors.As(err, &heldError) {
+		t.Fatalf("expected HeldError, got %v", err)
+	}
+	if heldError.Lease.Holder != "other" {
+		t.Errorf("expected lock to be held by %q, got %q", "other", heldError.Lease.Holder)
+	}
+
+	// Without a race, we hold the lease
+	p = &racingPath{basePath: vfs.NewMemFSPath(vfs.NewMemFSContext(), "cluster/lock")}
+	l, err := Acquire(ctx, p, Options{Holder: "holder"})
+	if err != nil {
+		t.Fatalf("error acquiring lock: %v", err)
+	}
+	if err := l.Release(ctx); err != nil {
+		t.Fatalf("error releasing lock: %v", err)
+	}
+}
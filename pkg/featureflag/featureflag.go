@@ -96,6 +96,8 @@ var (
 	SELinuxMount = new("SELinuxMount", Bool(false))
 	// DO Terraform toggles the DO terraform support.
 	DOTerraform = new("DOTerraform", Bool(false))
+	// InClusterRollingUpdate enables kops-controller to perform rolling updates from within the cluster.
+	InClusterRollingUpdate = new("InClusterRollingUpdate", Bool(false))
 )
 
 // FeatureFlag defines a feature flag
@@ -0,0 +1,259 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instancegroups
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/json"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	"k8s.io/klog/v2"
+	"k8s.io/kubectl/pkg/drain"
+
+	api "k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/pkg/cloudinstances"
+	"k8s.io/kops/upup/pkg/fi"
+)
+
+// blueGreenCloud returns the cloud, if the instance group can be replaced blue/green.
+func (c *RollingUpdateCluster) blueGreenCloud(ig *api.InstanceGroup) (fi.BlueGreenCloud, bool) {
+	if c.DisableBlueGreen || ig.Spec.Role != api.InstanceGroupRoleNode || ig.Spec.Manager == api.InstanceManagerKarpenter {
+		return nil, false
+	}
+	cloud, ok := c.Cloud.(fi.BlueGreenCloud)
+	return cloud, ok
+}
+
+// blueGreenInstanceGroup replaces all the instances of a group through a temporary copy of its cloud group,
+// which launches the replacements. Once the cluster validates with them, the original instances are drained
+// in batches, then the replacements are moved into the cloud group and the original instances terminated,
+// so the instance group and its cloud group keep their names. If the cluster does not validate, the copy
+// and its instances are deleted and the original instances are left in place.
+func (c *RollingUpdateCluster) blueGreenInstanceGroup(cloud fi.BlueGreenCloud, group *cloudinstances.CloudInstanceGroup, settings api.RollingUpdate, hooks []*instanceHook, sleepAfterTerminate time.Duration) error {
+	if err := c.waitBeforeReplacing(group, settings.MaintenanceWindows); err != nil {
+		return err
+	}
+
+	var instances []*cloudinstances.CloudInstance
+	for _, u := range append(append([]*cloudinstances.CloudInstance{}, group.NeedUpdate...), group.Ready...) {
+		// Warm pool instances have already been deleted, and replacements are left by an interrupted update
+		if u.State != cloudinstances.WarmPool && u.State != cloudinstances.Replacement {
+			instances = append(instances, u)
+		}
+	}
+
+	green, err := cloud.CreateGreenGroup(group)
+	if err != nil {
+		return fmt.Errorf("error creating replacement instances for InstanceGroup %q: %w", group.InstanceGroup.Name, err)
+	}
+
+	klog.Infof("waiting for %v after creating %q", sleepAfterTerminate, green.HumanName)
+	time.Sleep(sleepAfterTerminate)
+
+	if err := c.maybeValidate(fmt.Sprintf(" after creating %q", green.HumanName), c.ValidateCount, group); err != nil {
+		return c.rollBackBlueGreen(cloud, green, instances, nil, err)
+	}
+
+	// The replacements are serving, so the original instances can be drained as many at a time as may be unavailable
+	batchSize := settings.MaxUnavailable.IntValue()
+	if batchSize < 1 || c.Interactive {
+		batchSize = 1
+	}
+
+	var drained []*cloudinstances.CloudInstance
+	for start := 0; start < len(instances); start += batchSize {
+		if err := c.waitBeforeReplacing(group, settings.MaintenanceWindows); err != nil {
+			return c.rollBackBlueGreen(cloud, green, instances, drained, err)
+		}
+
+		end := start + batchSize
+		if end > len(instances) {
+			end = len(instances)
+		}
+		for _, u := range instances[start:end] {
+			c.Progress.InstanceStarted(u)
+
+			if err := c.runInstanceHooks(hooks, api.RollingUpdateHookPhaseBeforeDrain, u); err != nil {
+				return c.rollBackBlueGreen(cloud, green, instances, drained, err)
+			}
+
+			if c.CloudOnly {
+				klog.Warning("Not draining cluster nodes as 'cloudonly' flag is set.")
+			} else if u.Node == nil {
+				klog.Warningf("Skipping drain of instance %q, because it is not registered in kubernetes", u.ID)
+			} else {
+				klog.Infof("Draining the node: %q.", u.Node.Name)
+				drained = append(drained, u)
+				if err := c.drainNode(u); err != nil {
+					if c.FailOnDrainError {
+						return c.rollBackBlueGreen(cloud, green, instances, drained, fmt.Errorf("failed to drain node %q: %v", u.Node.Name, err))
+					}
+					klog.Infof("Ignoring error draining node %q: %v", u.Node.Name, err)
+				}
+			}
+
+			if err := c.runInstanceHooks(hooks, api.RollingUpdateHookPhaseAfterDrain, u); err != nil {
+				return c.rollBackBlueGreen(cloud, green, instances, drained, err)
+			}
+		}
+
+		if err := c.maybeValidate(" after draining instances", c.ValidateCount, group); err != nil {
+			return c.rollBackBlueGreen(cloud, green, instances, drained, err)
+		}
+	}
+
+	klog.Infof("Moving the instances of %q into %q, terminating the instances they replace.", green.HumanName, group.HumanName)
+	if err := cloud.PromoteGreenGroup(group, green); err != nil {
+		// PromoteGreenGroup moves the replacements back into the copy when it fails to move them all,
+		// so they are deleted with it.
+		return c.rollBackBlueGreen(cloud, green, instances, drained, fmt.Errorf("error replacing the instances of InstanceGroup %q: %w", group.InstanceGroup.Name, err))
+	}
+
+	for _, u := range instances {
+		c.Progress.InstanceTerminated(u)
+		if err := c.runInstanceHooks(hooks, api.RollingUpdateHookPhaseAfterTerminate, u); err != nil {
+			return err
+		}
+	}
+
+	klog.Infof("waiting for %v after terminating instances", sleepAfterTerminate)
+	time.Sleep(sleepAfterTerminate)
+
+	if err := c.maybeValidate(" after terminating instances", c.ValidateCount, group); err != nil {
+		return err
+	}
+	for _, u := range instances {
+		if err := c.runInstanceHooks(hooks, api.RollingUpdateHookPhaseAfterValidate, u); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// rollBackBlueGreen undoes a blue/green update of instances that failed with err: it uncordons the drained nodes,
+// which lets the load balancers of Kubernetes services use them again, removes the taint that marks the nodes
+// as scheduled for update, then deletes the copy of the cloud group and the replacements. Instances that draining deregistered from the load balancers attached to the cloud group itself
+// are not registered with them again.
+func (c *RollingUpdateCluster) rollBackBlueGreen(cloud fi.BlueGreenCloud, green *cloudinstances.CloudInstanceGroup, instances, drained []*cloudinstances.CloudInstance, err error) error {
+	klog.Errorf("Rolling back the replacement of instances by %q: %v", green.HumanName, err)
+
+	var errs []string
+	for _, u := range drained {
+		if uncordonErr := c.uncordonNode(u.Node); uncordonErr != nil {
+			errs = append(errs, fmt.Sprintf("error uncordoning node %q: %v", u.Node.Name, uncordonErr))
+		}
+	}
+	if !c.CloudOnly {
+		for _, u := range instances {
+			if u.Node == nil {
+				continue
+			}
+			if untaintErr := c.removeTaint(u.Node); untaintErr != nil {
+				errs = append(errs, fmt.Sprintf("error removing taint from node %q: %v", u.Node.Name, untaintErr))
+			}
+		}
+	}
+
+	if deleteErr := cloud.DeleteGreenGroup(green); deleteErr != nil {
+		errs = append(errs, fmt.Sprintf("error deleting %q: %v", green.HumanName, deleteErr))
+	}
+
+	if len(errs) != 0 {
+		return fmt.Errorf("%w; rolling back: %s", err, strings.Join(errs, "; "))
+	}
+	return err
+}
+
+// uncordonNode makes a drained node schedulable again, and lets it serve load balancers.
+func (c *RollingUpdateCluster) uncordonNode(node *corev1.Node) error {
+	helper := &drain.Helper{
+		Ctx:    c.Ctx,
+		Client: c.K8sClient,
+		Out:    os.Stdout,
+		ErrOut: os.Stderr,
+	}
+	if err := drain.RunCordonOrUncordon(helper, node, false); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	if _, ok := node.Labels[corev1.LabelNodeExcludeBalancers]; !ok {
+		return nil
+	}
+	oldData, err := json.Marshal(node)
+	if err != nil {
+		return err
+	}
+	delete(node.Labels, corev1.LabelNodeExcludeBalancers)
+	newData, err := json.Marshal(node)
+	if err != nil {
+		return err
+	}
+	patchBytes, err := strategicpatch.CreateTwoWayMergePatch(oldData, newData, node)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.K8sClient.CoreV1().Nodes().Patch(c.Ctx, node.Name, types.StrategicMergePatchType, patchBytes, metav1.PatchOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// removeTaint removes the taint that marks a node as scheduled for update.
+func (c *RollingUpdateCluster) removeTaint(node *corev1.Node) error {
+	var taints []corev1.Taint
+	for _, taint := range node.Spec.Taints {
+		if taint.Key != rollingUpdateTaintKey {
+			taints = append(taints, taint)
+		}
+	}
+	if len(taints) == len(node.Spec.Taints) {
+		return nil
+	}
+
+	oldData, err := json.Marshal(node)
+	if err != nil {
+		return err
+	}
+	node.Spec.Taints = taints
+	newData, err := json.Marshal(node)
+	if err != nil {
+		return err
+	}
+	patchBytes, err := strategicpatch.CreateTwoWayMergePatch(oldData, newData, node)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.K8sClient.CoreV1().Nodes().Patch(c.Ctx, node.Name, types.StrategicMergePatchType, patchBytes, metav1.PatchOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
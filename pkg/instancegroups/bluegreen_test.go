@@ -0,0 +1,257 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instancegroups
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	v1meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kopsapi "k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/pkg/cloudinstances"
+	"k8s.io/kops/pkg/validation"
+	"k8s.io/kops/upup/pkg/fi"
+	"k8s.io/kops/upup/pkg/fi/cloudup/awsup"
+)
+
+// blueGreenCloud records the copies of cloud groups it creates, promotes and deletes.
+type blueGreenCloud struct {
+	*awsup.MockAWSCloud
+	created  []string
+	promoted []string
+	deleted  []string
+
+	promoteErr error
+}
+
+var _ fi.BlueGreenCloud = &blueGreenCloud{}
+
+func (c *blueGreenCloud) CreateGreenGroup(g *cloudinstances.CloudInstanceGroup) (*cloudinstances.CloudInstanceGroup, error) {
+	c.created = append(c.created, g.HumanName)
+	return &cloudinstances.CloudInstanceGroup{
+		HumanName:     g.HumanName + "-green",
+		InstanceGroup: g.InstanceGroup,
+		TargetSize:    g.TargetSize,
+	}, nil
+}
+
+func (c *blueGreenCloud) PromoteGreenGroup(g *cloudinstances.CloudInstanceGroup, green *cloudinstances.CloudInstanceGroup) error {
+	c.promoted = append(c.promoted, green.HumanName)
+	return c.promoteErr
+}
+
+func (c *blueGreenCloud) DeleteGreenGroup(green *cloudinstances.CloudInstanceGroup) error {
+	c.deleted = append(c.deleted, green.HumanName)
+	return nil
+}
+
+// blueGreenClusterValidator fails validation of the "nodes" instance group while unhealthy returns true.
+type blueGreenClusterValidator struct {
+	instanceGroup *kopsapi.InstanceGroup
+	unhealthy     func() bool
+}
+
+func (v *blueGreenClusterValidator) Validate() (*validation.ValidationCluster, error) {
+	result := &validation.ValidationCluster{}
+	if v.unhealthy != nil && v.unhealthy() {
+		result.Failures = append(result.Failures, &validation.ValidationError{
+			Kind:          "InstanceGroup",
+			Name:          v.instanceGroup.Name,
+			Message:       "testing failure",
+			InstanceGroup: v.instanceGroup,
+		})
+	}
+	return result, nil
+}
+
+func getBlueGreenTestSetup() (*RollingUpdateCluster, *blueGreenCloud, *blueGreenClusterValidator, map[string]*cloudinstances.CloudInstanceGroup) {
+	c, mockcloud := getTestSetup()
+
+	groups := make(map[string]*cloudinstances.CloudInstanceGroup)
+	makeGroup(groups, c.K8sClient, mockcloud, "nodes", kopsapi.InstanceGroupRoleNode, 3, 3)
+	groups["nodes"].InstanceGroup.Spec.RollingUpdate = &kopsapi.RollingUpdate{
+		Strategy: kopsapi.RollingUpdateStrategyBlueGreen,
+	}
+
+	cloud := &blueGreenCloud{
+		MockAWSCloud: mockcloud,
+	}
+	c.Cloud = cloud
+
+	validator := &blueGreenClusterValidator{
+		instanceGroup: groups["nodes"].InstanceGroup,
+	}
+	c.ClusterValidator = validator
+
+	return c, cloud, validator, groups
+}
+
+func cordonedNodes(t *testing.T, c *RollingUpdateCluster) []string {
+	nodes, err := c.K8sClient.CoreV1().Nodes().List(context.Background(), v1meta.ListOptions{})
+	require.NoError(t, err)
+	var cordoned []string
+	for _, node := range nodes.Items {
+		if node.Spec.Unschedulable {
+			cordoned = append(cordoned, node.Name)
+		}
+	}
+	sort.Strings(cordoned)
+	return cordoned
+}
+
+func taintedNodes(t *testing.T, c *RollingUpdateCluster) []string {
+	nodes, err := c.K8sClient.CoreV1().Nodes().List(context.Background(), v1meta.ListOptions{})
+	require.NoError(t, err)
+	var tainted []string
+	for _, node := range nodes.Items {
+		for _, taint := range node.Spec.Taints {
+			if taint.Key == rollingUpdateTaintKey {
+				tainted = append(tainted, node.Name)
+			}
+		}
+	}
+	sort.Strings(tainted)
+	return tainted
+}
+
+func TestRollingUpdateBlueGreen(t *testing.T) {
+	c, cloud, _, groups := getBlueGreenTestSetup()
+
+	err := c.RollingUpdate(groups, &kopsapi.InstanceGroupList{})
+	assert.NoError(t, err, "rolling update")
+
+	assert.Equal(t, []string{"nodes"}, cloud.created, "copied cloud groups")
+	assert.Equal(t, []string{"nodes-green"}, cloud.promoted, "promoted cloud groups")
+	assert.Empty(t, cloud.deleted, "deleted cloud groups")
+	assert.Equal(t, []string{"nodesa.local", "nodesb.local", "nodesc.local"}, cordonedNodes(t, c), "cordoned nodes")
+	assert.Equal(t, []string{"nodesa.local", "nodesb.local", "nodesc.local"}, taintedNodes(t, c), "nodes tainted for update")
+}
+
+func TestRollingUpdateBlueGreenRollsBackUnhealthyGroup(t *testing.T) {
+	c, cloud, validator, groups := getBlueGreenTestSetup()
+	validator.unhealthy = func() bool {
+		return len(cloud.created) > 0
+	}
+
+	err := c.RollingUpdate(groups, &kopsapi.InstanceGroupList{})
+	assert.Error(t, err, "rolling update")
+
+	assert.Empty(t, cloud.promoted, "promoted cloud groups")
+	assert.Equal(t, []string{"nodes-green"}, cloud.deleted, "deleted cloud groups")
+	assert.Empty(t, cordonedNodes(t, c), "cordoned nodes")
+	assert.Empty(t, taintedNodes(t, c), "nodes tainted for update")
+}
+
+func TestRollingUpdateBlueGreenRollsBackAfterDraining(t *testing.T) {
+	c, cloud, validator, groups := getBlueGreenTestSetup()
+	validator.unhealthy = func() bool {
+		return len(cordonedNodes(t, c)) > 0
+	}
+
+	err := c.RollingUpdate(groups, &kopsapi.InstanceGroupList{})
+	assert.Error(t, err, "rolling update")
+
+	assert.Empty(t, cloud.promoted, "promoted cloud groups")
+	assert.Equal(t, []string{"nodes-green"}, cloud.deleted, "deleted cloud groups")
+	assert.Empty(t, cordonedNodes(t, c), "drained nodes are uncordoned")
+	assert.Empty(t, taintedNodes(t, c), "nodes tainted for update")
+
+	node, err := c.K8sClient.CoreV1().Nodes().Get(context.Background(), "nodesa.local", v1meta.GetOptions{})
+	require.NoError(t, err)
+	assert.NotContains(t, node.Labels, v1.LabelNodeExcludeBalancers, "drained node serves load balancers")
+}
+
+func TestRollingUpdateBlueGreenRollsBackWhenCancelled(t *testing.T) {
+	c, cloud, validator, groups := getBlueGreenTestSetup()
+	ctx, cancel := context.WithCancel(c.Ctx)
+	defer cancel()
+	c.Ctx = ctx
+	validator.unhealthy = func() bool {
+		// Cancel the rolling update while it is paused after draining the first instance
+		if len(cordonedNodes(t, c)) > 0 {
+			c.Paused.Store(true)
+			cancel()
+		}
+		return false
+	}
+
+	err := c.RollingUpdate(groups, &kopsapi.InstanceGroupList{})
+	assert.Error(t, err, "rolling update")
+
+	assert.Empty(t, cloud.promoted, "promoted cloud groups")
+	assert.Equal(t, []string{"nodes-green"}, cloud.deleted, "deleted cloud groups")
+	assert.Empty(t, cordonedNodes(t, c), "drained nodes are uncordoned")
+}
+
+func TestRollingUpdateBlueGreenRollsBackWhenPromotingFails(t *testing.T) {
+	c, cloud, _, groups := getBlueGreenTestSetup()
+	cloud.promoteErr = errors.New("error attaching instances")
+
+	err := c.RollingUpdate(groups, &kopsapi.InstanceGroupList{})
+	assert.Error(t, err, "rolling update")
+
+	assert.Equal(t, []string{"nodes-green"}, cloud.promoted, "promoted cloud groups")
+	assert.Equal(t, []string{"nodes-green"}, cloud.deleted, "deleted cloud groups")
+	assert.Empty(t, cordonedNodes(t, c), "drained nodes are uncordoned")
+	assert.Empty(t, taintedNodes(t, c), "nodes tainted for update")
+}
+
+func TestRollingUpdateBlueGreenSkipsReplacements(t *testing.T) {
+	c, cloud, _, groups := getBlueGreenTestSetup()
+	group := groups["nodes"]
+	replacement := group.NeedUpdate[2]
+	replacement.State = cloudinstances.Replacement
+	group.NeedUpdate = group.NeedUpdate[:2]
+	group.Ready = append(group.Ready, replacement)
+
+	err := c.RollingUpdate(groups, &kopsapi.InstanceGroupList{})
+	assert.NoError(t, err, "rolling update")
+
+	assert.Equal(t, []string{"nodes-green"}, cloud.promoted, "promoted cloud groups")
+	assert.Len(t, cordonedNodes(t, c), 2, "cordoned nodes")
+}
+
+func TestRollingUpdateBlueGreenDisabled(t *testing.T) {
+	c, cloud, _, groups := getBlueGreenTestSetup()
+	c.DisableBlueGreen = true
+
+	err := c.RollingUpdate(groups, &kopsapi.InstanceGroupList{})
+	assert.NoError(t, err, "rolling update")
+
+	assert.Empty(t, cloud.created, "copied cloud groups")
+	assertGroupInstanceCount(t, cloud.MockAWSCloud, "nodes", 0)
+}
+
+func TestRollingUpdateBlueGreenControlPlane(t *testing.T) {
+	c, cloud := getTestSetup()
+	c.Cluster.Spec.RollingUpdate = &kopsapi.RollingUpdate{
+		Strategy: kopsapi.RollingUpdateStrategyBlueGreen,
+	}
+
+	groups := make(map[string]*cloudinstances.CloudInstanceGroup)
+	makeGroup(groups, c.K8sClient, cloud, "master-1", kopsapi.InstanceGroupRoleControlPlane, 2, 2)
+
+	err := c.RollingUpdate(groups, &kopsapi.InstanceGroupList{})
+	assert.NoError(t, err, "rolling update")
+
+	assertGroupInstanceCount(t, cloud, "master-1", 0)
+}
@@ -0,0 +1,325 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instancegroups
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"k8s.io/klog/v2"
+	api "k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/pkg/cloudinstances"
+)
+
+// defaultHookTimeout is the maximum time an instance hook may take, unless configured otherwise.
+const defaultHookTimeout = 5 * time.Minute
+
+// HookEvent describes the replacement of an instance to an instance hook.
+type HookEvent struct {
+	// Cluster is the name of the cluster.
+	Cluster string `json:"cluster"`
+	// Phase is the point in the replacement of the instance at which the hook is run.
+	Phase api.RollingUpdateHookPhase `json:"phase"`
+	// InstanceID is the cloud ID of the instance.
+	InstanceID string `json:"instanceID"`
+	// NodeName is the name of the node of the instance, if it is known.
+	NodeName string `json:"nodeName,omitempty"`
+	// InstanceGroup is the name of the instance group of the instance.
+	InstanceGroup string `json:"instanceGroup"`
+}
+
+// InstanceHook is run at points in the replacement of each instance during a rolling update.
+type InstanceHook interface {
+	// Run runs the hook for the event, returning an error if it fails.
+	Run(ctx context.Context, event *HookEvent) error
+}
+
+// ExecHook runs a command, passing the event in environment variables and as JSON on standard input.
+type ExecHook struct {
+	// Command is the command to run, followed by its arguments.
+	Command []string
+}
+
+var _ InstanceHook = &ExecHook{}
+
+// Run implements InstanceHook.
+func (h *ExecHook) Run(ctx context.Context, event *HookEvent) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("error serializing hook event: %w", err)
+	}
+
+	cmd := exec.CommandContext(ctx, h.Command[0], h.Command[1:]...)
+	cmd.Env = append(os.Environ(),
+		"KOPS_CLUSTER_NAME="+event.Cluster,
+		"KOPS_HOOK_PHASE="+string(event.Phase),
+		"KOPS_INSTANCE_ID="+event.InstanceID,
+		"KOPS_NODE_NAME="+event.NodeName,
+		"KOPS_INSTANCE_GROUP="+event.InstanceGroup,
+	)
+	cmd.Stdin = bytes.NewReader(payload)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("command %q failed: %w: %s", strings.Join(h.Command, " "), err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// WebhookHook sends the event as JSON in an HTTP POST request.
+type WebhookHook struct {
+	// URL is the URL the request is sent to.
+	URL string
+	// Headers are added to the request.
+	Headers map[string]string
+	// Client sends the request. Defaults to http.DefaultClient.
+	Client *http.Client
+}
+
+var _ InstanceHook = &WebhookHook{}
+
+// Run implements InstanceHook.
+func (h *WebhookHook) Run(ctx context.Context, event *HookEvent) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("error serializing hook event: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.URL, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("error building request to %s: %w", h.URL, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range h.Headers {
+		req.Header.Set(k, v)
+	}
+
+	client := h.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error calling webhook %s: %w", h.URL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("webhook %s returned %s: %s", h.URL, resp.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+// instanceHook is an InstanceHook with the settings it was configured with.
+type instanceHook struct {
+	name          string
+	hook          InstanceHook
+	phases        []api.RollingUpdateHookPhase
+	failurePolicy api.RollingUpdateHookFailurePolicy
+	timeout       time.Duration
+}
+
+// buildInstanceHook returns the hook configured by the spec.
+func (c *RollingUpdateCluster) buildInstanceHook(spec *api.RollingUpdateHook) (*instanceHook, error) {
+	h := &instanceHook{
+		name:          spec.Name,
+		phases:        spec.Phases,
+		failurePolicy: spec.FailurePolicy,
+		timeout:       defaultHookTimeout,
+	}
+	if len(h.phases) == 0 {
+		h.phases = api.AllRollingUpdateHookPhases
+	}
+	if h.failurePolicy == "" {
+		h.failurePolicy = api.RollingUpdateHookFailurePolicyAbort
+	}
+	if spec.Timeout != nil {
+		h.timeout = spec.Timeout.Duration
+	}
+
+	switch {
+	case spec.Exec != nil && len(spec.Exec.Command) != 0:
+		h.hook = &ExecHook{Command: spec.Exec.Command}
+	case spec.Webhook != nil:
+		headers, err := c.webhookHeaders(spec)
+		if err != nil {
+			return nil, err
+		}
+		h.hook = &WebhookHook{URL: spec.Webhook.URL, Headers: headers}
+	default:
+		return nil, fmt.Errorf("instance hook %q has neither a command nor a webhook", spec.Name)
+	}
+	return h, nil
+}
+
+// HookHeaderSecretName returns the name in the secret store of the secret holding the value of a webhook header,
+// so that instance hooks can only reference secrets created for them.
+func HookHeaderSecretName(name string) string {
+	return "instancehookheader-" + name
+}
+
+// webhookHeaders reads the values of the headers of the webhook of the hook from the secret store.
+func (c *RollingUpdateCluster) webhookHeaders(spec *api.RollingUpdateHook) (map[string]string, error) {
+	if len(spec.Webhook.HeaderSecrets) == 0 {
+		return nil, nil
+	}
+	if c.Clientset == nil {
+		return nil, fmt.Errorf("cannot read the header secrets of instance hook %q without a clientset", spec.Name)
+	}
+	secretStore, err := c.Clientset.SecretStore(c.Cluster)
+	if err != nil {
+		return nil, err
+	}
+
+	headers := make(map[string]string, len(spec.Webhook.HeaderSecrets))
+	for header, name := range spec.Webhook.HeaderSecrets {
+		secret, err := secretStore.FindSecret(HookHeaderSecretName(name))
+		if err != nil {
+			return nil, fmt.Errorf("error reading secret %q of instance hook %q: %w", name, spec.Name, err)
+		}
+		if secret == nil {
+			return nil, fmt.Errorf("secret %q of instance hook %q not found; create it with kops create secret instancehookheader", name, spec.Name)
+		}
+		headers[header] = strings.TrimSpace(string(secret.Data))
+	}
+	return headers, nil
+}
+
+// runsAt returns true if the hook is run at the phase.
+func (h *instanceHook) runsAt(phase api.RollingUpdateHookPhase) bool {
+	for _, p := range h.phases {
+		if p == phase {
+			return true
+		}
+	}
+	return false
+}
+
+// hasHookAt returns true if any of the hooks is run at the phase.
+func hasHookAt(hooks []*instanceHook, phase api.RollingUpdateHookPhase) bool {
+	for _, h := range hooks {
+		if h.runsAt(phase) {
+			return true
+		}
+	}
+	return false
+}
+
+// instanceHooks returns the hooks to run around the replacement of instances:
+// those of the rolling update, followed by those configured for the instance group or cluster.
+// Hooks configured in the spec that run a command are only run if AllowSpecExecHooks is set,
+// as anyone who can write to the state store could otherwise run commands where the rolling update runs.
+func (c *RollingUpdateCluster) instanceHooks(configured []api.RollingUpdateHook) ([]*instanceHook, error) {
+	var hooks []*instanceHook
+	for j, specs := range [][]api.RollingUpdateHook{c.InstanceHooks, configured} {
+		fromSpec := j == 1
+		for i := range specs {
+			spec := &specs[i]
+			if spec.Exec != nil {
+				var reason string
+				switch {
+				case c.DisableExecHooks:
+					reason = "its command cannot be run by this rolling update"
+				case fromSpec && !c.AllowSpecExecHooks:
+					reason = "commands from the spec are only run with --allow-spec-exec-hooks"
+				}
+				if reason != "" {
+					// Replacing instances without a hook that must succeed could lose data it protects
+					if spec.FailurePolicy != api.RollingUpdateHookFailurePolicyIgnore {
+						return nil, fmt.Errorf("instance hook %q cannot be run, as %s; set its failurePolicy to %s to skip it", spec.Name, reason, api.RollingUpdateHookFailurePolicyIgnore)
+					}
+					klog.Warningf("Skipping instance hook %q, as %s", spec.Name, reason)
+					c.Progress.HookSkipped(spec.Name)
+					continue
+				}
+			}
+			hook, err := c.buildInstanceHook(spec)
+			if err != nil {
+				return nil, err
+			}
+			hooks = append(hooks, hook)
+		}
+	}
+	return hooks, nil
+}
+
+// runInstanceHooks runs the hooks for the phase of the replacement of the instance, applying their failure policies.
+func (c *RollingUpdateCluster) runInstanceHooks(hooks []*instanceHook, phase api.RollingUpdateHookPhase, u *cloudinstances.CloudInstance) error {
+	if len(hooks) == 0 {
+		return nil
+	}
+
+	event := &HookEvent{
+		Cluster:    c.ClusterName,
+		Phase:      phase,
+		InstanceID: u.ID,
+	}
+	if u.Node != nil {
+		event.NodeName = u.Node.Name
+	}
+	if u.CloudInstanceGroup != nil && u.CloudInstanceGroup.InstanceGroup != nil {
+		event.InstanceGroup = u.CloudInstanceGroup.InstanceGroup.ObjectMeta.Name
+	}
+
+	for _, h := range hooks {
+		if !h.runsAt(phase) {
+			continue
+		}
+		if err := c.runInstanceHook(h, event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *RollingUpdateCluster) runInstanceHook(h *instanceHook, event *HookEvent) error {
+	ctx := c.Ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	for {
+		klog.V(2).Infof("Running hook %q at %s of instance %q", h.name, event.Phase, event.InstanceID)
+		hookCtx, cancel := context.WithTimeout(ctx, h.timeout)
+		err := h.hook.Run(hookCtx, event)
+		cancel()
+		if err == nil {
+			return nil
+		}
+
+		switch h.failurePolicy {
+		case api.RollingUpdateHookFailurePolicyIgnore:
+			klog.Warningf("Ignoring failure of hook %q at %s of instance %q: %v", h.name, event.Phase, event.InstanceID, err)
+			return nil
+		case api.RollingUpdateHookFailurePolicyBlock:
+			klog.Warningf("Hook %q failed at %s of instance %q; retrying in %v: %v", h.name, event.Phase, event.InstanceID, holdTickDuration, err)
+			if err := c.sleep(holdTickDuration); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("hook %q failed at %s of instance %q: %w", h.name, event.Phase, event.InstanceID, err)
+		}
+	}
+}
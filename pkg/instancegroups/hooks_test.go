@@ -0,0 +1,318 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instancegroups
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	kopsapi "k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/pkg/client/simple/vfsclientset"
+	"k8s.io/kops/upup/pkg/fi"
+	"k8s.io/kops/util/pkg/vfs"
+)
+
+func TestExecHook(t *testing.T) {
+	ctx := context.Background()
+	out := filepath.Join(t.TempDir(), "out")
+	event := &HookEvent{
+		Cluster:       "test.k8s.local",
+		Phase:         kopsapi.RollingUpdateHookPhaseBeforeDrain,
+		InstanceID:    "node-1a",
+		NodeName:      "node-1a.local",
+		InstanceGroup: "node-1",
+	}
+
+	hook := &ExecHook{Command: []string{"sh", "-c", `echo "$KOPS_HOOK_PHASE $KOPS_INSTANCE_ID $KOPS_NODE_NAME $KOPS_INSTANCE_GROUP" > "$0"; cat >> "$0"`, out}}
+	require.NoError(t, hook.Run(ctx, event))
+
+	data, err := os.ReadFile(out)
+	require.NoError(t, err)
+	assert.Equal(t, "BeforeDrain node-1a node-1a.local node-1\n"+`{"cluster":"test.k8s.local","phase":"BeforeDrain","instanceID":"node-1a","nodeName":"node-1a.local","instanceGroup":"node-1"}`, string(data))
+
+	hook = &ExecHook{Command: []string{"sh", "-c", "echo broker not replicated; exit 3"}}
+	err = hook.Run(ctx, event)
+	assert.ErrorContains(t, err, "broker not replicated")
+}
+
+func TestWebhookHook(t *testing.T) {
+	ctx := context.Background()
+	var received HookEvent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			http.Error(w, "not allowed", http.StatusForbidden)
+			return
+		}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+	}))
+	defer server.Close()
+
+	event := &HookEvent{
+		Cluster:       "test.k8s.local",
+		Phase:         kopsapi.RollingUpdateHookPhaseAfterTerminate,
+		InstanceID:    "node-1a",
+		InstanceGroup: "node-1",
+	}
+
+	hook := &WebhookHook{URL: server.URL, Headers: map[string]string{"Authorization": "Bearer secret"}}
+	require.NoError(t, hook.Run(ctx, event))
+	assert.Equal(t, *event, received)
+
+	hook = &WebhookHook{URL: server.URL}
+	err := hook.Run(ctx, event)
+	assert.ErrorContains(t, err, "403 Forbidden: not allowed")
+}
+
+// hookRecorder is a webhook that records the events it receives, failing the first failures of them.
+type hookRecorder struct {
+	mutex    sync.Mutex
+	failures int
+	events   []HookEvent
+}
+
+func (h *hookRecorder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	if h.failures != 0 {
+		if h.failures > 0 {
+			h.failures--
+		}
+		http.Error(w, "failed", http.StatusInternalServerError)
+		return
+	}
+	var event HookEvent
+	if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	h.events = append(h.events, event)
+}
+
+func TestRollingUpdateInstanceHooks(t *testing.T) {
+	c, cloud := getTestSetup()
+	recorder := &hookRecorder{}
+	server := httptest.NewServer(recorder)
+	defer server.Close()
+	c.InstanceHooks = []kopsapi.RollingUpdateHook{
+		{Name: "record", Webhook: &kopsapi.WebhookRollingUpdateHook{URL: server.URL}},
+	}
+
+	groups := getGroupsAllNeedUpdate(c.K8sClient, cloud)
+	err := c.RollingUpdate(groups, &kopsapi.InstanceGroupList{})
+	assert.NoError(t, err, "rolling update")
+
+	phases := map[string][]kopsapi.RollingUpdateHookPhase{}
+	for _, event := range recorder.events {
+		phases[event.InstanceID] = append(phases[event.InstanceID], event.Phase)
+	}
+	assert.Len(t, phases, 9)
+	for id, p := range phases {
+		assert.Equal(t, kopsapi.AllRollingUpdateHookPhases, p, "phases of instance %s", id)
+	}
+}
+
+func TestRollingUpdateInstanceHooksDisableExec(t *testing.T) {
+	c, cloud := getTestSetup()
+	recorder := &hookRecorder{}
+	server := httptest.NewServer(recorder)
+	defer server.Close()
+	c.DisableExecHooks = true
+	progress, err := NewProgressRecorder(context.Background(), nil, nil, &RollingUpdateProgress{Cluster: "test.k8s.local"})
+	require.NoError(t, err)
+	c.Progress = progress
+	c.InstanceHooks = []kopsapi.RollingUpdateHook{
+		{Name: "fail", Exec: &kopsapi.ExecRollingUpdateHook{Command: []string{"false"}}, FailurePolicy: kopsapi.RollingUpdateHookFailurePolicyIgnore},
+		{Name: "record", Webhook: &kopsapi.WebhookRollingUpdateHook{URL: server.URL}},
+	}
+
+	groups := getGroupsAllNeedUpdate(c.K8sClient, cloud)
+	err = c.RollingUpdate(groups, &kopsapi.InstanceGroupList{})
+	assert.NoError(t, err, "rolling update")
+	assert.NotEmpty(t, recorder.events, "webhook events")
+	assert.Equal(t, []string{"fail"}, progress.Progress().SkippedHooks)
+}
+
+func TestRollingUpdateInstanceHooksDisableExecRequired(t *testing.T) {
+	for _, policy := range []kopsapi.RollingUpdateHookFailurePolicy{"", kopsapi.RollingUpdateHookFailurePolicyAbort, kopsapi.RollingUpdateHookFailurePolicyBlock} {
+		t.Run(string(policy), func(t *testing.T) {
+			c, cloud := getTestSetup()
+			c.DisableExecHooks = true
+			c.InstanceHooks = []kopsapi.RollingUpdateHook{
+				{Name: "required", Exec: &kopsapi.ExecRollingUpdateHook{Command: []string{"true"}}, FailurePolicy: policy},
+			}
+
+			groups := getGroupsAllNeedUpdate(c.K8sClient, cloud)
+			err := c.RollingUpdate(groups, &kopsapi.InstanceGroupList{})
+			assert.ErrorContains(t, err, `instance hook "required" cannot be run, as its command cannot be run by this rolling update`)
+			assertGroupInstanceCount(t, cloud, "node-1", 3)
+			assertGroupInstanceCount(t, cloud, "master-1", 2)
+		})
+	}
+}
+
+func TestRollingUpdateInstanceHookFailurePolicies(t *testing.T) {
+	defer func(d time.Duration) { holdTickDuration = d }(holdTickDuration)
+	holdTickDuration = time.Millisecond
+
+	for _, tc := range []struct {
+		policy      kopsapi.RollingUpdateHookFailurePolicy
+		failures    int
+		expectError bool
+		expectHooks int
+	}{
+		{
+			policy:      kopsapi.RollingUpdateHookFailurePolicyAbort,
+			failures:    1,
+			expectError: true,
+		},
+		{
+			policy:      kopsapi.RollingUpdateHookFailurePolicyBlock,
+			failures:    3,
+			expectHooks: 9,
+		},
+		{
+			policy:   kopsapi.RollingUpdateHookFailurePolicyIgnore,
+			failures: -1,
+		},
+	} {
+		t.Run(string(tc.policy), func(t *testing.T) {
+			c, cloud := getTestSetup()
+			recorder := &hookRecorder{failures: tc.failures}
+			server := httptest.NewServer(recorder)
+			defer server.Close()
+			c.Cluster.Spec.RollingUpdate = &kopsapi.RollingUpdate{
+				InstanceHooks: []kopsapi.RollingUpdateHook{
+					{
+						Name:          "before-drain",
+						Phases:        []kopsapi.RollingUpdateHookPhase{kopsapi.RollingUpdateHookPhaseBeforeDrain},
+						Webhook:       &kopsapi.WebhookRollingUpdateHook{URL: server.URL},
+						FailurePolicy: tc.policy,
+					},
+				},
+			}
+
+			groups := getGroupsAllNeedUpdate(c.K8sClient, cloud)
+			err := c.RollingUpdate(groups, &kopsapi.InstanceGroupList{})
+			if tc.expectError {
+				assert.ErrorContains(t, err, `hook "before-drain" failed at BeforeDrain`)
+				assertGroupInstanceCount(t, cloud, "node-1", 3)
+				assertGroupInstanceCount(t, cloud, "node-2", 3)
+				assertGroupInstanceCount(t, cloud, "master-1", 2)
+				assertGroupInstanceCount(t, cloud, "bastion-1", 1)
+				return
+			}
+
+			assert.NoError(t, err, "rolling update")
+			assert.Len(t, recorder.events, tc.expectHooks)
+			assertGroupInstanceCount(t, cloud, "node-1", 0)
+			assertGroupInstanceCount(t, cloud, "node-2", 0)
+			assertGroupInstanceCount(t, cloud, "master-1", 0)
+			assertGroupInstanceCount(t, cloud, "bastion-1", 0)
+		})
+	}
+}
+
+func TestRollingUpdateInstanceHooksFromSpecExec(t *testing.T) {
+	for _, allow := range []bool{false, true} {
+		t.Run(fmt.Sprintf("allow=%v", allow), func(t *testing.T) {
+			c, cloud := getTestSetup()
+			c.AllowSpecExecHooks = allow
+			out := filepath.Join(t.TempDir(), "out")
+			c.Cluster.Spec.RollingUpdate = &kopsapi.RollingUpdate{
+				InstanceHooks: []kopsapi.RollingUpdateHook{
+					{
+						Name:   "spec",
+						Phases: []kopsapi.RollingUpdateHookPhase{kopsapi.RollingUpdateHookPhaseBeforeDrain},
+						Exec:   &kopsapi.ExecRollingUpdateHook{Command: []string{"sh", "-c", `echo "$KOPS_INSTANCE_ID" >> "$0"`, out}},
+					},
+				},
+			}
+
+			groups := getGroupsAllNeedUpdate(c.K8sClient, cloud)
+			err := c.RollingUpdate(groups, &kopsapi.InstanceGroupList{})
+			if !allow {
+				assert.ErrorContains(t, err, `instance hook "spec" cannot be run, as commands from the spec are only run with --allow-spec-exec-hooks`)
+				assert.NoFileExists(t, out)
+				assertGroupInstanceCount(t, cloud, "node-1", 3)
+				return
+			}
+
+			assert.NoError(t, err, "rolling update")
+			assert.FileExists(t, out)
+			assertGroupInstanceCount(t, cloud, "node-1", 0)
+		})
+	}
+}
+
+func TestRollingUpdateInstanceHookHeaderSecrets(t *testing.T) {
+	ctx := context.Background()
+	c, cloud := getTestSetup()
+
+	vfs.Context.ResetMemfsContext(true)
+	basePath, err := vfs.Context.BuildVfsPath("memfs://tests")
+	require.NoError(t, err)
+	c.Clientset = vfsclientset.NewVFSClientset(basePath)
+	c.Cluster.Spec.ConfigBase = "memfs://tests/test.k8s.local"
+
+	var authorizations []string
+	var mutex sync.Mutex
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		authorizations = append(authorizations, r.Header.Get("Authorization"))
+	}))
+	defer server.Close()
+	c.Cluster.Spec.RollingUpdate = &kopsapi.RollingUpdate{
+		InstanceHooks: []kopsapi.RollingUpdateHook{
+			{
+				Name:    "authenticated",
+				Phases:  []kopsapi.RollingUpdateHookPhase{kopsapi.RollingUpdateHookPhaseBeforeDrain},
+				Webhook: &kopsapi.WebhookRollingUpdateHook{URL: server.URL, HeaderSecrets: map[string]string{"Authorization": "hooks-token"}},
+			},
+		},
+	}
+
+	groups := getGroupsAllNeedUpdate(c.K8sClient, cloud)
+	err = c.RollingUpdate(groups, &kopsapi.InstanceGroupList{})
+	assert.ErrorContains(t, err, `secret "hooks-token" of instance hook "authenticated" not found`)
+	assert.Empty(t, authorizations)
+
+	secretStore, err := c.Clientset.SecretStore(c.Cluster)
+	require.NoError(t, err)
+	_, _, err = secretStore.GetOrCreateSecret(ctx, HookHeaderSecretName("hooks-token"), &fi.Secret{Data: []byte("Bearer secret\n")})
+	require.NoError(t, err)
+
+	groups = getGroupsAllNeedUpdate(c.K8sClient, cloud)
+	err = c.RollingUpdate(groups, &kopsapi.InstanceGroupList{})
+	assert.NoError(t, err, "rolling update")
+	assert.Len(t, authorizations, 9)
+	for _, authorization := range authorizations {
+		assert.Equal(t, "Bearer secret", authorization)
+	}
+}
@@ -0,0 +1,143 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instancegroups
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// InClusterConfigMapNamespace is the namespace of the ConfigMap through which rolling updates
+	// are requested from, and reported by, kops-controller.
+	InClusterConfigMapNamespace = "kube-system"
+	// InClusterConfigMapName is the name of the ConfigMap through which rolling updates
+	// are requested from, and reported by, kops-controller.
+	InClusterConfigMapName = "kops-rolling-update"
+
+	// inClusterRequestKey is the ConfigMap key of the requested rolling update.
+	inClusterRequestKey = "request"
+	// inClusterProgressKey is the ConfigMap key of the progress of the current or last rolling update.
+	inClusterProgressKey = "progress"
+)
+
+// RollingUpdateRequest asks kops-controller to perform a rolling update.
+type RollingUpdateRequest struct {
+	// RequestTime is when the rolling update was requested.
+	RequestTime time.Time `json:"requestTime"`
+	// Force replaces all instances, even those that do not need update.
+	Force bool `json:"force,omitempty"`
+	// InstanceGroups restricts the rolling update to the named instance groups.
+	InstanceGroups []string `json:"instanceGroups,omitempty"`
+	// InstanceGroupRoles restricts the rolling update to instance groups of the roles.
+	InstanceGroupRoles []string `json:"instanceGroupRoles,omitempty"`
+}
+
+// NewProgress returns the progress of a rolling update of the cluster that is starting to perform the request.
+func (r *RollingUpdateRequest) NewProgress(clusterName string) *RollingUpdateProgress {
+	return &RollingUpdateProgress{
+		Cluster:            clusterName,
+		StartTime:          time.Now().UTC(),
+		Force:              r.Force,
+		InstanceGroups:     r.InstanceGroups,
+		InstanceGroupRoles: r.InstanceGroupRoles,
+	}
+}
+
+// ParseInClusterConfigMap returns the pending request and the progress recorded in the ConfigMap; either may be nil.
+func ParseInClusterConfigMap(configMap *corev1.ConfigMap) (*RollingUpdateRequest, *RollingUpdateProgress, error) {
+	var request *RollingUpdateRequest
+	if data := configMap.Data[inClusterRequestKey]; data != "" {
+		request = &RollingUpdateRequest{}
+		if err := yaml.Unmarshal([]byte(data), request); err != nil {
+			return nil, nil, fmt.Errorf("error parsing rolling update request in ConfigMap %s/%s: %w", configMap.Namespace, configMap.Name, err)
+		}
+	}
+
+	var progress *RollingUpdateProgress
+	if data := configMap.Data[inClusterProgressKey]; data != "" {
+		progress = &RollingUpdateProgress{}
+		if err := yaml.Unmarshal([]byte(data), progress); err != nil {
+			return nil, nil, fmt.Errorf("error parsing rolling update progress in ConfigMap %s/%s: %w", configMap.Namespace, configMap.Name, err)
+		}
+	}
+
+	return request, progress, nil
+}
+
+// SetInClusterProgress records the progress in the ConfigMap, removing any pending request.
+func SetInClusterProgress(configMap *corev1.ConfigMap, progress *RollingUpdateProgress) error {
+	data, err := yaml.Marshal(progress)
+	if err != nil {
+		return fmt.Errorf("error serializing rolling update progress: %w", err)
+	}
+	if configMap.Data == nil {
+		configMap.Data = make(map[string]string)
+	}
+	delete(configMap.Data, inClusterRequestKey)
+	configMap.Data[inClusterProgressKey] = string(data)
+	return nil
+}
+
+// RequestInClusterRollingUpdate asks kops-controller to perform a rolling update, replacing any request it has not yet started.
+func RequestInClusterRollingUpdate(ctx context.Context, k8sClient kubernetes.Interface, request *RollingUpdateRequest) error {
+	data, err := yaml.Marshal(request)
+	if err != nil {
+		return fmt.Errorf("error serializing rolling update request: %w", err)
+	}
+
+	configMaps := k8sClient.CoreV1().ConfigMaps(InClusterConfigMapNamespace)
+	configMap, err := configMaps.Get(ctx, InClusterConfigMapName, metav1.GetOptions{})
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			return fmt.Errorf("error reading ConfigMap %s/%s: %w", InClusterConfigMapNamespace, InClusterConfigMapName, err)
+		}
+		configMap = &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: InClusterConfigMapNamespace,
+				Name:      InClusterConfigMapName,
+			},
+			Data: map[string]string{
+				inClusterRequestKey: string(data),
+			},
+		}
+		if _, err := configMaps.Create(ctx, configMap, metav1.CreateOptions{}); err != nil {
+			return fmt.Errorf("error creating ConfigMap %s/%s: %w", InClusterConfigMapNamespace, InClusterConfigMapName, err)
+		}
+		return nil
+	}
+
+	if _, progress, err := ParseInClusterConfigMap(configMap); err == nil && progress != nil && !progress.IsComplete() && progress.Error == "" {
+		return fmt.Errorf("kops-controller is performing the rolling update started at %s", progress.StartTime.Format(time.RFC3339))
+	}
+
+	if configMap.Data == nil {
+		configMap.Data = make(map[string]string)
+	}
+	configMap.Data[inClusterRequestKey] = string(data)
+	if _, err := configMaps.Update(ctx, configMap, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("error updating ConfigMap %s/%s: %w", InClusterConfigMapNamespace, InClusterConfigMapName, err)
+	}
+	return nil
+}
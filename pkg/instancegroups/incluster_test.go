@@ -0,0 +1,98 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instancegroups
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestRequestInClusterRollingUpdate(t *testing.T) {
+	ctx := context.Background()
+	k8sClient := fake.NewSimpleClientset()
+	configMaps := k8sClient.CoreV1().ConfigMaps(InClusterConfigMapNamespace)
+
+	requestTime := time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)
+	err := RequestInClusterRollingUpdate(ctx, k8sClient, &RollingUpdateRequest{
+		RequestTime:    requestTime,
+		Force:          true,
+		InstanceGroups: []string{"nodes-1a"},
+	})
+	require.NoError(t, err)
+
+	configMap, err := configMaps.Get(ctx, InClusterConfigMapName, metav1.GetOptions{})
+	require.NoError(t, err)
+	request, progress, err := ParseInClusterConfigMap(configMap)
+	require.NoError(t, err)
+	require.NotNil(t, request)
+	assert.Nil(t, progress)
+	assert.Equal(t, requestTime, request.RequestTime)
+	assert.True(t, request.Force)
+	assert.Equal(t, []string{"nodes-1a"}, request.InstanceGroups)
+
+	// Starting the rolling update consumes the request
+	progress = request.NewProgress("test.k8s.local")
+	assert.True(t, progress.Force)
+	assert.Equal(t, []string{"nodes-1a"}, progress.InstanceGroups)
+	require.NoError(t, SetInClusterProgress(configMap, progress))
+	_, err = configMaps.Update(ctx, configMap, metav1.UpdateOptions{})
+	require.NoError(t, err)
+
+	request, progress, err = ParseInClusterConfigMap(configMap)
+	require.NoError(t, err)
+	assert.Nil(t, request)
+	require.NotNil(t, progress)
+	assert.Equal(t, "test.k8s.local", progress.Cluster)
+
+	// Another rolling update can't be requested while one is running
+	err = RequestInClusterRollingUpdate(ctx, k8sClient, &RollingUpdateRequest{RequestTime: time.Now()})
+	assert.Error(t, err)
+
+	// ... but can be once it has stopped
+	progress.Error = "validation failed"
+	require.NoError(t, SetInClusterProgress(configMap, progress))
+	_, err = configMaps.Update(ctx, configMap, metav1.UpdateOptions{})
+	require.NoError(t, err)
+
+	err = RequestInClusterRollingUpdate(ctx, k8sClient, &RollingUpdateRequest{RequestTime: requestTime, InstanceGroupRoles: []string{"node"}})
+	require.NoError(t, err)
+	configMap, err = configMaps.Get(ctx, InClusterConfigMapName, metav1.GetOptions{})
+	require.NoError(t, err)
+	request, progress, err = ParseInClusterConfigMap(configMap)
+	require.NoError(t, err)
+	require.NotNil(t, request)
+	assert.Equal(t, []string{"node"}, request.InstanceGroupRoles)
+	require.NotNil(t, progress)
+	assert.Equal(t, "validation failed", progress.Error)
+}
+
+func TestParseInClusterConfigMapInvalid(t *testing.T) {
+	configMap := &corev1.ConfigMap{
+		Data: map[string]string{
+			inClusterRequestKey: "force: [",
+		},
+	}
+	_, _, err := ParseInClusterConfigMap(configMap)
+	assert.Error(t, err)
+}
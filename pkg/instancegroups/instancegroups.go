@@ -19,9 +19,11 @@ package instancegroups
 import (
 	"bufio"
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"strings"
+	"sync"
 	"time"
 
 	"k8s.io/kops/upup/pkg/fi"
@@ -30,6 +32,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/json"
 	"k8s.io/apimachinery/pkg/util/strategicpatch"
@@ -98,7 +101,13 @@ func promptInteractive(upgradedHostID, upgradedHostName string) (stopPrompting b
 }
 
 // RollingUpdate performs a rolling update on a list of instances.
-func (c *RollingUpdateCluster) rollingUpdateInstanceGroup(group *cloudinstances.CloudInstanceGroup, sleepAfterTerminate time.Duration) (err error) {
+func (c *RollingUpdateCluster) rollingUpdateInstanceGroup(group *cloudinstances.CloudInstanceGroup, sleepAfterTerminate time.Duration) error {
+	return c.updateInstanceGroup(group, sleepAfterTerminate, false)
+}
+
+// updateInstanceGroup replaces the instances of a group. When rollingBack, the group has been returned to
+// its known-good template, and only the instances created from the failing template are replaced.
+func (c *RollingUpdateCluster) updateInstanceGroup(group *cloudinstances.CloudInstanceGroup, sleepAfterTerminate time.Duration, rollingBack bool) (err error) {
 	isBastion := group.InstanceGroup.IsBastion()
 	// Do not need a k8s client if you are doing cloudonly.
 	if c.K8sClient == nil && !c.CloudOnly {
@@ -108,16 +117,22 @@ func (c *RollingUpdateCluster) rollingUpdateInstanceGroup(group *cloudinstances.
 	noneReady := len(group.Ready) == 0
 	numInstances := len(group.Ready) + len(group.NeedUpdate)
 	update := group.NeedUpdate
-	if c.Force {
+	if c.Force && !rollingBack {
 		update = append(update, group.Ready...)
 	}
 
 	if len(update) == 0 {
+		c.recordKnownGoodTemplate(group)
+		if !rollingBack {
+			c.Progress.GroupCompleted(group.InstanceGroup.ObjectMeta.Name)
+		}
 		return nil
 	}
 
 	if isBastion {
 		klog.V(3).Info("Not validating the cluster as instance is a bastion.")
+	} else if rollingBack {
+		klog.V(3).Info("Not validating the cluster as the instance group is being rolled back.")
 	} else if err = c.maybeValidate("", 1, group); err != nil {
 		return err
 	}
@@ -146,41 +161,61 @@ func (c *RollingUpdateCluster) rollingUpdateInstanceGroup(group *cloudinstances.
 
 	settings := resolveSettings(c.Cluster, group.InstanceGroup, numInstances)
 
-	runningDrains := 0
-	maxSurge := settings.MaxSurge.IntValue()
-
-	if maxSurge > len(update) {
-		maxSurge = len(update)
+	hooks, err := c.instanceHooks(settings.InstanceHooks)
+	if err != nil {
+		return err
 	}
 
-	maxConcurrency := maxSurge + settings.MaxUnavailable.IntValue()
+	if settings.Strategy == api.RollingUpdateStrategyBlueGreen && !rollingBack {
+		if cloud, ok := c.blueGreenCloud(group.InstanceGroup); ok {
+			if !*settings.DrainAndTerminate {
+				klog.Infof("Rolling updates for InstanceGroup %s are disabled", group.InstanceGroup.Name)
+				return nil
+			}
+			if err := c.blueGreenInstanceGroup(cloud, group, settings, hooks, sleepAfterTerminate); err != nil {
+				return err
+			}
+			c.Progress.GroupCompleted(group.InstanceGroup.ObjectMeta.Name)
+			return nil
+		}
+		klog.Infof("InstanceGroup %q cannot be replaced blue/green, updating it instance by instance.", group.InstanceGroup.Name)
+	}
 
-	// Karpenter cannot surge
-	if group.InstanceGroup.Spec.Manager == api.InstanceManagerKarpenter {
-		maxSurge = 0
+	if settings.OnFailure == api.RollingUpdateFailurePolicyRollback && !rollingBack {
+		if knownGood, failing := c.knownGoodTemplate(group, update); knownGood != "" {
+			defer func() {
+				if errors.Is(err, &ValidationTimeoutError{}) {
+					err = c.rollBackInstanceGroup(group, knownGood, failing, sleepAfterTerminate, err)
+				}
+			}()
+		}
 	}
 
-	if group.InstanceGroup.Spec.Role == api.InstanceGroupRoleControlPlane && maxSurge != 0 {
-		// Control plane nodes are incapable of surging because they rely on registering themselves through
-		// the local apiserver. That apiserver depends on the local etcd, which relies on being
-		// joined to the etcd cluster.
+	runningDrains := 0
+	maxSurge, maxConcurrency := c.surgeAndConcurrency(group.InstanceGroup, settings, len(update))
+	if rollingBack {
+		// The instances created from the failing template are not serving, so are replaced without surging
 		maxSurge = 0
 		maxConcurrency = settings.MaxUnavailable.IntValue()
-		if maxConcurrency == 0 {
+		if maxConcurrency < 1 || c.Interactive {
 			maxConcurrency = 1
 		}
 	}
 
-	if c.Interactive {
-		if maxSurge > 1 {
-			maxSurge = 1
+	// Instances detached by an interrupted rolling update have already been surged
+	for _, u := range update {
+		if u.Status != cloudinstances.CloudInstanceStatusDetached && c.Progress.IsDetached(u.ID) {
+			u.Status = cloudinstances.CloudInstanceStatusDetached
 		}
-		maxConcurrency = 1
 	}
 
-	update = prioritizeUpdate(update)
+	update = prioritizeUpdate(update, c.Progress.IsInFlight)
 
 	if maxSurge > 0 && !c.CloudOnly {
+		if err := c.waitBeforeReplacing(group, settings.MaintenanceWindows); err != nil {
+			return err
+		}
+
 		skippedNodes := 0
 		for numSurge := 1; numSurge <= maxSurge; numSurge++ {
 			u := update[len(update)-numSurge-skippedNodes]
@@ -199,6 +234,11 @@ func (c *RollingUpdateCluster) rollingUpdateInstanceGroup(group *cloudinstances.
 				// If noneReady, wait until after one node is detached and its replacement validates
 				// before detaching more in case the current spec does not result in usable nodes.
 				if numSurge == maxSurge || noneReady {
+					// Providers without a group controller create the replacements of the batch on apply
+					if err := c.reconcileInstanceGroup(); err != nil {
+						return fmt.Errorf("error reconciling instance group %q: %v", group.HumanName, err)
+					}
+
 					// Wait for the minimum interval
 					klog.Infof("waiting for %v after detaching instance", sleepAfterTerminate)
 					time.Sleep(sleepAfterTerminate)
@@ -219,9 +259,48 @@ func (c *RollingUpdateCluster) rollingUpdateInstanceGroup(group *cloudinstances.
 
 	terminateChan := make(chan error, maxConcurrency)
 
+	// The instances that have been terminated, whose AfterValidate hooks have yet to run
+	var terminatedMutex sync.Mutex
+	var terminated []*cloudinstances.CloudInstance
+
+	// clusterValidated is whether the cluster validated after the last instances were terminated.
+	// The template of the group is only known to be good if it did.
+	clusterValidated := false
+
+	// validateAfterTerminating validates the cluster, then runs the AfterValidate hooks
+	// of the instances that were terminated before validation started.
+	validateAfterTerminating := func() error {
+		terminatedMutex.Lock()
+		validated := terminated
+		terminated = nil
+		terminatedMutex.Unlock()
+
+		var err error
+		clusterValidated, err = c.validate(" after terminating instance", c.ValidateCount, group)
+		if err != nil {
+			return err
+		}
+		for _, m := range validated {
+			if err := c.runInstanceHooks(hooks, api.RollingUpdateHookPhaseAfterValidate, m); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
 	for uIdx, u := range update {
+		if err := c.waitBeforeReplacing(group, settings.MaintenanceWindows); err != nil {
+			return waitForPendingBeforeReturningError(runningDrains, terminateChan, err)
+		}
+
 		go func(m *cloudinstances.CloudInstance) {
-			terminateChan <- c.drainTerminateAndWait(m, sleepAfterTerminate)
+			err := c.drainTerminateAndWait(m, sleepAfterTerminate, hooks)
+			if err == nil {
+				terminatedMutex.Lock()
+				terminated = append(terminated, m)
+				terminatedMutex.Unlock()
+			}
+			terminateChan <- err
 		}(u)
 		runningDrains++
 
@@ -237,7 +316,7 @@ func (c *RollingUpdateCluster) rollingUpdateInstanceGroup(group *cloudinstances.
 			return waitForPendingBeforeReturningError(runningDrains, terminateChan, err)
 		}
 
-		err = c.maybeValidate(" after terminating instance", c.ValidateCount, group)
+		err = validateAfterTerminating()
 		if err != nil {
 			return waitForPendingBeforeReturningError(runningDrains, terminateChan, err)
 		}
@@ -274,7 +353,8 @@ func (c *RollingUpdateCluster) rollingUpdateInstanceGroup(group *cloudinstances.
 		}
 	}
 
-	if runningDrains > 0 {
+	// Instances terminated while sweeping up completions have not been followed by a validation
+	if runningDrains > 0 || (len(terminated) > 0 && hasHookAt(hooks, api.RollingUpdateHookPhaseAfterValidate)) {
 		for runningDrains > 0 {
 			err = <-terminateChan
 			runningDrains--
@@ -283,30 +363,78 @@ func (c *RollingUpdateCluster) rollingUpdateInstanceGroup(group *cloudinstances.
 			}
 		}
 
-		err = c.maybeValidate(" after terminating instance", c.ValidateCount, group)
+		err = validateAfterTerminating()
 		if err != nil {
 			return err
 		}
 	}
 
+	if clusterValidated {
+		c.recordKnownGoodTemplate(group)
+	}
+	if !rollingBack {
+		c.Progress.GroupCompleted(group.InstanceGroup.ObjectMeta.Name)
+	}
 	return nil
 }
 
-func prioritizeUpdate(update []*cloudinstances.CloudInstance) []*cloudinstances.CloudInstance {
+// surgeAndConcurrency returns how many of the numUpdate instances of the group needing update may be
+// detached for surging, and how many may be drained and terminated at once.
+func (c *RollingUpdateCluster) surgeAndConcurrency(ig *api.InstanceGroup, settings api.RollingUpdate, numUpdate int) (maxSurge int, maxConcurrency int) {
+	maxSurge = settings.MaxSurge.IntValue()
+
+	if maxSurge > numUpdate {
+		maxSurge = numUpdate
+	}
+
+	maxConcurrency = maxSurge + settings.MaxUnavailable.IntValue()
+
+	// Karpenter cannot surge
+	if ig.Spec.Manager == api.InstanceManagerKarpenter {
+		maxSurge = 0
+	}
+
+	if ig.Spec.Role == api.InstanceGroupRoleControlPlane && maxSurge != 0 {
+		// Control plane nodes are incapable of surging because they rely on registering themselves through
+		// the local apiserver. That apiserver depends on the local etcd, which relies on being
+		// joined to the etcd cluster.
+		maxSurge = 0
+		maxConcurrency = settings.MaxUnavailable.IntValue()
+		if maxConcurrency == 0 {
+			maxConcurrency = 1
+		}
+	}
+
+	if c.Interactive {
+		if maxSurge > 1 {
+			maxSurge = 1
+		}
+		maxConcurrency = 1
+	}
+
+	return maxSurge, maxConcurrency
+}
+
+func prioritizeUpdate(update []*cloudinstances.CloudInstance, inFlight func(id string) bool) []*cloudinstances.CloudInstance {
 	// The priorities are, in order:
+	//   in flight when an interrupted rolling update stopped before others
 	//   attached before detached
 	//   TODO unhealthy before healthy
 	//   NeedUpdate before Ready (preserve original order)
 	result := make([]*cloudinstances.CloudInstance, 0, len(update))
+	var attached []*cloudinstances.CloudInstance
 	var detached []*cloudinstances.CloudInstance
 	for _, u := range update {
-		if u.Status == cloudinstances.CloudInstanceStatusDetached {
+		if inFlight(u.ID) {
+			result = append(result, u)
+		} else if u.Status == cloudinstances.CloudInstanceStatusDetached {
 			detached = append(detached, u)
 		} else {
-			result = append(result, u)
+			attached = append(attached, u)
 		}
 	}
 
+	result = append(result, attached...)
 	result = append(result, detached...)
 	return result
 }
@@ -412,7 +540,7 @@ func (c *RollingUpdateCluster) patchExcludeFromLB(node *corev1.Node) error {
 	return err
 }
 
-func (c *RollingUpdateCluster) drainTerminateAndWait(u *cloudinstances.CloudInstance, sleepAfterTerminate time.Duration) error {
+func (c *RollingUpdateCluster) drainTerminateAndWait(u *cloudinstances.CloudInstance, sleepAfterTerminate time.Duration, hooks []*instanceHook) error {
 	instanceID := u.ID
 
 	nodeName := ""
@@ -422,6 +550,12 @@ func (c *RollingUpdateCluster) drainTerminateAndWait(u *cloudinstances.CloudInst
 
 	isBastion := u.CloudInstanceGroup.InstanceGroup.IsBastion()
 
+	c.Progress.InstanceStarted(u)
+
+	if err := c.runInstanceHooks(hooks, api.RollingUpdateHookPhaseBeforeDrain, u); err != nil {
+		return err
+	}
+
 	if isBastion {
 		// We don't want to validate for bastions - they aren't part of the cluster
 	} else if c.CloudOnly {
@@ -441,6 +575,10 @@ func (c *RollingUpdateCluster) drainTerminateAndWait(u *cloudinstances.CloudInst
 		}
 	}
 
+	if err := c.runInstanceHooks(hooks, api.RollingUpdateHookPhaseAfterDrain, u); err != nil {
+		return err
+	}
+
 	// GCE often re-uses names, so we delete the node object to prevent the new instance from using the cordoned Node object
 	if c.Cluster.Spec.GetCloudProvider() == api.CloudProviderGCE && !isBastion && !c.CloudOnly {
 		if u.Node == nil {
@@ -458,6 +596,12 @@ func (c *RollingUpdateCluster) drainTerminateAndWait(u *cloudinstances.CloudInst
 		return err
 	}
 
+	c.Progress.InstanceTerminated(u)
+
+	if err := c.runInstanceHooks(hooks, api.RollingUpdateHookPhaseAfterTerminate, u); err != nil {
+		return err
+	}
+
 	if err := c.reconcileInstanceGroup(); err != nil {
 		klog.Errorf("error reconciling instance group %q: %v", u.CloudInstanceGroup.HumanName, err)
 		return err
@@ -477,7 +621,7 @@ func (c *RollingUpdateCluster) reconcileInstanceGroup() error {
 		return nil
 	}
 	rto := fi.RunTasksOptions{}
-	rto.InitDefaults()
+	rto.InitCloudupDefaults()
 	applyCmd := &cloudup.ApplyClusterCmd{
 		Cloud:              c.Cloud,
 		Clientset:          c.Clientset,
@@ -495,25 +639,34 @@ func (c *RollingUpdateCluster) reconcileInstanceGroup() error {
 }
 
 func (c *RollingUpdateCluster) maybeValidate(operation string, validateCount int, group *cloudinstances.CloudInstanceGroup) error {
+	_, err := c.validate(operation, validateCount, group)
+	return err
+}
+
+// validate validates the cluster as maybeValidate does, also returning whether it validated.
+// It returns false without an error if validation was skipped, or failed and fail-on-validate is false.
+func (c *RollingUpdateCluster) validate(operation string, validateCount int, group *cloudinstances.CloudInstanceGroup) (bool, error) {
 	if c.CloudOnly {
 		klog.Warningf("Not validating cluster as cloudonly flag is set.")
-	} else {
-		klog.Info("Validating the cluster.")
+		return false, nil
+	}
 
-		if err := c.validateClusterWithTimeout(validateCount, group); err != nil {
+	klog.Info("Validating the cluster.")
 
-			if c.FailOnValidate {
-				klog.Errorf("Cluster did not validate within %s", c.ValidationTimeout)
-				return &ValidationTimeoutError{
-					operation: operation,
-					err:       err,
-				}
-			}
+	if err := c.validateClusterWithTimeout(validateCount, group); err != nil {
 
-			klog.Warningf("Cluster validation failed%s, proceeding since fail-on-validate is set to false: %v", operation, err)
+		if c.FailOnValidate {
+			klog.Errorf("Cluster did not validate within %s", c.ValidationTimeout)
+			return false, &ValidationTimeoutError{
+				operation: operation,
+				err:       err,
+			}
 		}
+
+		klog.Warningf("Cluster validation failed%s, proceeding since fail-on-validate is set to false: %v", operation, err)
+		return false, nil
 	}
-	return nil
+	return true, nil
 }
 
 // validateClusterWithTimeout runs validation.ValidateCluster until either we get positive result or the timeout expires
@@ -531,6 +684,7 @@ func (c *RollingUpdateCluster) validateClusterWithTimeout(validateCount int, gro
 	for {
 		// Note that we validate at least once before checking the timeout, in case the cluster is healthy with a short timeout
 		result, err := c.ClusterValidator.Validate()
+		c.Progress.Validated(result, err)
 		if err == nil && !hasFailureRelevantToGroup(result.Failures, group) {
 			successCount++
 			if successCount >= validateCount {
@@ -611,6 +765,8 @@ func (c *RollingUpdateCluster) detachInstance(u *cloudinstances.CloudInstance) e
 		return fmt.Errorf("error detaching instance %q: %v", id, err)
 	}
 
+	c.Progress.InstanceDetached(u)
+
 	return nil
 }
 
@@ -651,18 +807,27 @@ func (c *RollingUpdateCluster) drainNode(u *cloudinstances.CloudInstance) error
 		return fmt.Errorf("node name not set")
 	}
 
+	settings := c.drainSettings(u)
 	helper := &drain.Helper{
 		Ctx:                 c.Ctx,
 		Client:              c.K8sClient,
-		Force:               true,
+		Force:               *settings.ForceUnmanagedPods,
 		GracePeriodSeconds:  -1,
 		IgnoreAllDaemonSets: true,
 		Out:                 os.Stdout,
 		ErrOut:              os.Stderr,
 		Timeout:             c.DrainTimeout,
-
-		// We want to proceed even when pods are using emptyDir volumes
-		DeleteEmptyDirData: true,
+		DeleteEmptyDirData:  *settings.DeleteEmptyDirData,
+	}
+	if settings.GracePeriodSeconds != nil {
+		helper.GracePeriodSeconds = int(*settings.GracePeriodSeconds)
+	}
+	if settings.SkipPodSelector != "" {
+		selector, err := labels.Parse(settings.SkipPodSelector)
+		if err != nil {
+			return fmt.Errorf("error parsing skipPodSelector %q: %v", settings.SkipPodSelector, err)
+		}
+		helper.AdditionalFilters = append(helper.AdditionalFilters, skipPodsFilter(selector))
 	}
 
 	if err := drain.RunCordonOrUncordon(helper, u.Node, true); err != nil {
@@ -697,7 +862,7 @@ func (c *RollingUpdateCluster) drainNode(u *cloudinstances.CloudInstance) error
 		}
 	}
 
-	if err := drain.RunNodeDrain(helper, u.Node.Name); err != nil {
+	if err := runNodeDrain(helper, u.Node.Name, settings); err != nil {
 		if apierrors.IsNotFound(err) {
 			return nil
 		}
@@ -712,6 +877,89 @@ func (c *RollingUpdateCluster) drainNode(u *cloudinstances.CloudInstance) error
 	return nil
 }
 
+// drainSettings returns the drain settings of the instance group of an instance.
+func (c *RollingUpdateCluster) drainSettings(u *cloudinstances.CloudInstance) *api.RollingUpdateDrain {
+	group := u.CloudInstanceGroup
+	if group == nil || group.InstanceGroup == nil {
+		return resolveDrainSettings(nil, c.Cluster.Spec.RollingUpdate)
+	}
+	return resolveSettings(c.Cluster, group.InstanceGroup, len(group.Ready)+len(group.NeedUpdate)).Drain
+}
+
+// runDrain drains the pods of a node selected by the filters of helper, within its Timeout. It is replaced by tests.
+var runDrain = drain.RunNodeDrain
+
+// runNodeDrain evicts the pods of a node, those of the priority namespaces first. If evicting the pods
+// takes longer than DisableEvictionAfter, the remaining pods are deleted instead. The whole drain, across
+// all of its steps, takes at most the Timeout of helper.
+func runNodeDrain(helper *drain.Helper, nodeName string, settings *api.RollingUpdateDrain) error {
+	start := time.Now()
+	var deadline time.Time
+	if helper.Timeout != 0 {
+		deadline = start.Add(helper.Timeout)
+	}
+	evictionDeadline := deadline
+	if after := settings.DisableEvictionAfter; after != nil && (deadline.IsZero() || start.Add(after.Duration).Before(deadline)) {
+		evictionDeadline = start.Add(after.Duration)
+	}
+
+	err := drainNamespacesInOrder(helper, nodeName, settings.PriorityNamespaces, evictionDeadline)
+	if err == nil || evictionDeadline.Equal(deadline) {
+		return err
+	}
+
+	klog.Warningf("Pods were not evicted from node %q within %v, deleting them instead: %v", nodeName, settings.DisableEvictionAfter.Duration, err)
+	deleting := *helper
+	deleting.DisableEviction = true
+	return drainBefore(&deleting, nodeName, deadline)
+}
+
+// drainNamespacesInOrder drains the pods of each of the namespaces, one namespace after the other,
+// then all the remaining pods of the node. All of them must be drained by the deadline, unless it is zero.
+func drainNamespacesInOrder(helper *drain.Helper, nodeName string, namespaces []string, deadline time.Time) error {
+	for _, namespace := range namespaces {
+		klog.Infof("Draining the pods of namespace %q from node %q.", namespace, nodeName)
+		inNamespace := *helper
+		inNamespace.AdditionalFilters = append(append([]drain.PodFilter{}, helper.AdditionalFilters...), namespaceFilter(namespace))
+		if err := drainBefore(&inNamespace, nodeName, deadline); err != nil {
+			return err
+		}
+	}
+	return drainBefore(helper, nodeName, deadline)
+}
+
+// drainBefore drains the pods of a node selected by the filters of helper, giving up at the deadline, unless it is zero.
+func drainBefore(helper *drain.Helper, nodeName string, deadline time.Time) error {
+	step := *helper
+	if !deadline.IsZero() {
+		step.Timeout = time.Until(deadline)
+		if step.Timeout <= 0 {
+			return fmt.Errorf("timed out draining node %q", nodeName)
+		}
+	}
+	return runDrain(&step, nodeName)
+}
+
+// skipPodsFilter leaves the pods matching the selector on the node.
+func skipPodsFilter(selector labels.Selector) drain.PodFilter {
+	return func(pod corev1.Pod) drain.PodDeleteStatus {
+		if selector.Matches(labels.Set(pod.Labels)) {
+			return drain.MakePodDeleteStatusSkip()
+		}
+		return drain.MakePodDeleteStatusOkay()
+	}
+}
+
+// namespaceFilter leaves the pods not in the namespace on the node.
+func namespaceFilter(namespace string) drain.PodFilter {
+	return func(pod corev1.Pod) drain.PodDeleteStatus {
+		if pod.Namespace != namespace {
+			return drain.MakePodDeleteStatusSkip()
+		}
+		return drain.MakePodDeleteStatusOkay()
+	}
+}
+
 // deleteNode deletes a node from the k8s API.  It does not delete the underlying instance.
 func (c *RollingUpdateCluster) deleteNode(node *corev1.Node) error {
 	var options metav1.DeleteOptions
@@ -737,11 +985,29 @@ func (c *RollingUpdateCluster) UpdateSingleInstance(cloudMember *cloudinstances.
 			if err != nil {
 				return fmt.Errorf("failed to detach instance: %v", err)
 			}
+			if err := c.reconcileInstanceGroup(); err != nil {
+				return fmt.Errorf("error reconciling instance group %q: %v", cloudMember.CloudInstanceGroup.HumanName, err)
+			}
 			if err := c.maybeValidate(" after detaching instance", c.ValidateCount, cloudMember.CloudInstanceGroup); err != nil {
 				return err
 			}
 		}
 	}
 
-	return c.drainTerminateAndWait(cloudMember, 0)
+	settings := resolveSettings(c.Cluster, cloudMember.CloudInstanceGroup.InstanceGroup, 1)
+	hooks, err := c.instanceHooks(settings.InstanceHooks)
+	if err != nil {
+		return err
+	}
+
+	if err := c.drainTerminateAndWait(cloudMember, 0, hooks); err != nil {
+		return err
+	}
+	if !hasHookAt(hooks, api.RollingUpdateHookPhaseAfterValidate) {
+		return nil
+	}
+	if err := c.maybeValidate(" after terminating instance", c.ValidateCount, cloudMember.CloudInstanceGroup); err != nil {
+		return err
+	}
+	return c.runInstanceHooks(hooks, api.RollingUpdateHookPhaseAfterValidate, cloudMember)
 }
@@ -17,13 +17,21 @@ limitations under the License.
 package instancegroups
 
 import (
+	"fmt"
 	"testing"
 	"time"
 
 	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	v1meta "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes/fake"
+	testingclient "k8s.io/client-go/testing"
 	kopsapi "k8s.io/kops/pkg/apis/kops"
 	"k8s.io/kops/pkg/cloudinstances"
+	"k8s.io/kubectl/pkg/drain"
 )
 
 func TestWarmPoolOnlyRoll(t *testing.T) {
@@ -64,3 +72,95 @@ func TestWarmPoolOnlyRoll(t *testing.T) {
 		}
 	}
 }
+
+func TestDrainNodeSettings(t *testing.T) {
+	c, cloud := getTestSetup()
+	fakeClient := c.K8sClient.(*fake.Clientset)
+	// The API server does not support eviction, so pods are deleted
+	fakeClient.Resources = []*v1meta.APIResourceList{{GroupVersion: "v1"}}
+
+	groups := make(map[string]*cloudinstances.CloudInstanceGroup)
+	makeGroup(groups, c.K8sClient, cloud, "node-1", kopsapi.InstanceGroupRoleNode, 1, 1)
+	group := groups["node-1"]
+	group.InstanceGroup.Spec.RollingUpdate = &kopsapi.RollingUpdate{
+		Drain: &kopsapi.RollingUpdateDrain{
+			SkipPodSelector:    "app=cache",
+			PriorityNamespaces: []string{"ingress"},
+		},
+	}
+
+	for _, pod := range []*v1.Pod{
+		{ObjectMeta: v1meta.ObjectMeta{Namespace: "default", Name: "web", Labels: map[string]string{"app": "web"}}},
+		{ObjectMeta: v1meta.ObjectMeta{Namespace: "default", Name: "cache", Labels: map[string]string{"app": "cache"}}},
+		{ObjectMeta: v1meta.ObjectMeta{Namespace: "ingress", Name: "proxy", Labels: map[string]string{"app": "proxy"}}},
+	} {
+		pod.Spec.NodeName = "node-1a.local"
+		require.NoError(t, fakeClient.Tracker().Add(pod))
+	}
+
+	err := c.drainNode(group.NeedUpdate[0])
+	require.NoError(t, err)
+
+	var deleted []string
+	for _, action := range fakeClient.Actions() {
+		if a, ok := action.(testingclient.DeleteAction); ok && a.GetResource().Resource == "pods" {
+			deleted = append(deleted, a.GetNamespace()+"/"+a.GetName())
+		}
+	}
+	assert.Equal(t, []string{"ingress/proxy", "default/web"}, deleted, "pods of priority namespaces are drained first, skipped pods are left")
+}
+
+func TestRunNodeDrainSharesTimeout(t *testing.T) {
+	type step struct {
+		timeout         time.Duration
+		disableEviction bool
+	}
+	var steps []step
+	defer func(original func(*drain.Helper, string) error) { runDrain = original }(runDrain)
+	runDrain = func(helper *drain.Helper, nodeName string) error {
+		steps = append(steps, step{timeout: helper.Timeout, disableEviction: helper.DisableEviction})
+		// Each step uses up some of the time, and the pods are not evicted
+		time.Sleep(50 * time.Millisecond)
+		if helper.DisableEviction {
+			return nil
+		}
+		return fmt.Errorf("pods not evicted")
+	}
+
+	settings := &kopsapi.RollingUpdateDrain{
+		PriorityNamespaces:   []string{"ingress", "monitoring"},
+		DisableEvictionAfter: &v1meta.Duration{Duration: 200 * time.Millisecond},
+	}
+	helper := &drain.Helper{Timeout: time.Second}
+	require.NoError(t, runNodeDrain(helper, "node-1a.local", settings))
+
+	// Evicting the pods of the first priority namespace fails, so the remaining pods are deleted
+	require.Len(t, steps, 2)
+	assert.False(t, steps[0].disableEviction, "pods are evicted first")
+	assert.LessOrEqual(t, steps[0].timeout, 200*time.Millisecond, "evicting stops after disableEvictionAfter")
+	assert.True(t, steps[1].disableEviction, "pods are deleted after disableEvictionAfter")
+	assert.LessOrEqual(t, steps[1].timeout, time.Second-50*time.Millisecond, "deleting the pods only has the time remaining")
+
+	// Each priority namespace only has the time remaining
+	steps = nil
+	runDrain = func(helper *drain.Helper, nodeName string) error {
+		steps = append(steps, step{timeout: helper.Timeout})
+		time.Sleep(50 * time.Millisecond)
+		return nil
+	}
+	require.NoError(t, runNodeDrain(helper, "node-1a.local", &kopsapi.RollingUpdateDrain{PriorityNamespaces: []string{"ingress", "monitoring"}}))
+	require.Len(t, steps, 3)
+	for i := 1; i < len(steps); i++ {
+		assert.LessOrEqual(t, steps[i].timeout, steps[i-1].timeout-50*time.Millisecond, "step %d only has the time remaining", i)
+	}
+
+	// Once the time is up, the drain fails without running the remaining steps
+	steps = nil
+	runDrain = func(helper *drain.Helper, nodeName string) error {
+		steps = append(steps, step{timeout: helper.Timeout})
+		time.Sleep(helper.Timeout)
+		return nil
+	}
+	assert.Error(t, runNodeDrain(&drain.Helper{Timeout: 100 * time.Millisecond}, "node-1a.local", &kopsapi.RollingUpdateDrain{PriorityNamespaces: []string{"ingress"}}))
+	assert.Len(t, steps, 1, "no step runs after the time is up")
+}
@@ -0,0 +1,147 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instancegroups
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"k8s.io/klog/v2"
+	api "k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/pkg/apis/kops/registry"
+	"k8s.io/kops/pkg/cloudinstances"
+	"k8s.io/kops/util/pkg/vfs"
+)
+
+// holdTickDuration is the amount of time to wait between checks of whether a held rolling update can continue.
+var holdTickDuration = 30 * time.Second
+
+// RollingUpdatePausePath returns the location of the flag that pauses rolling updates of the cluster.
+func RollingUpdatePausePath(cluster *api.Cluster) (vfs.Path, error) {
+	configBase, err := registry.ConfigBase(cluster)
+	if err != nil {
+		return nil, err
+	}
+	return configBase.Join(registry.PathRollingUpdatePaused), nil
+}
+
+// IsRollingUpdatePaused returns true if the pause flag at p exists.
+func IsRollingUpdatePaused(ctx context.Context, p vfs.Path) (bool, error) {
+	if _, err := p.ReadFile(ctx); err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("error reading rolling update pause flag %s: %w", p, err)
+	}
+	return true, nil
+}
+
+// PauseRollingUpdate writes the pause flag at p, so that rolling updates hold once they have finished
+// the instances they are replacing.
+func PauseRollingUpdate(ctx context.Context, p vfs.Path, acl vfs.ACL) error {
+	data := []byte(time.Now().UTC().Format(time.RFC3339) + "\n")
+	if err := p.WriteFile(ctx, bytes.NewReader(data), acl); err != nil {
+		return fmt.Errorf("error writing rolling update pause flag %s: %w", p, err)
+	}
+	return nil
+}
+
+// UnpauseRollingUpdate removes the pause flag at p, if it exists.
+func UnpauseRollingUpdate(ctx context.Context, p vfs.Path) error {
+	if err := p.Remove(); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("error removing rolling update pause flag %s: %w", p, err)
+	}
+	return nil
+}
+
+// isPaused returns true if the rolling update has been paused, by signal or through the pause flag.
+func (c *RollingUpdateCluster) isPaused() (bool, error) {
+	if c.Paused.Load() {
+		return true, nil
+	}
+	if c.PausePath == nil {
+		return false, nil
+	}
+	return IsRollingUpdatePaused(c.Ctx, c.PausePath)
+}
+
+// waitBeforeReplacing is called before draining and terminating each instance of the group.
+// It holds the rolling update while it is paused, and when none of the maintenance windows is open,
+// either waits for the next one or returns an error, so that the rolling update can be resumed later.
+func (c *RollingUpdateCluster) waitBeforeReplacing(group *cloudinstances.CloudInstanceGroup, windows []api.MaintenanceWindow) error {
+	name := group.InstanceGroup.ObjectMeta.Name
+	held := ""
+	for {
+		paused, err := c.isPaused()
+		if err != nil {
+			return err
+		}
+		if paused {
+			if held != "paused" {
+				klog.Infof("Rolling update is paused; waiting until it is unpaused before updating instance group %q", name)
+				c.Progress.Held("RollingUpdatePaused", "Rolling update is paused")
+				held = "paused"
+			}
+			if err := c.sleep(holdTickDuration); err != nil {
+				return err
+			}
+			continue
+		}
+
+		now := time.Now()
+		open, next, err := api.MaintenanceWindowsOpen(windows, now)
+		if err != nil {
+			return fmt.Errorf("invalid maintenance window for instance group %q: %w", name, err)
+		}
+		if open {
+			return nil
+		}
+		if !c.WaitForMaintenanceWindow {
+			return fmt.Errorf("instance group %q is outside of its maintenance windows until %s", name, next.Format(time.RFC3339))
+		}
+		if held != "window" {
+			klog.Infof("Waiting until %s for the next maintenance window of instance group %q", next.Format(time.RFC3339), name)
+			c.Progress.Held("WaitingForMaintenanceWindow", fmt.Sprintf("Waiting until %s for the next maintenance window of instance group %s", next.Format(time.RFC3339), name))
+			held = "window"
+		}
+		// Keep checking whether the rolling update has been paused while waiting
+		wait := next.Sub(now)
+		if wait > holdTickDuration {
+			wait = holdTickDuration
+		}
+		if err := c.sleep(wait); err != nil {
+			return err
+		}
+	}
+}
+
+// sleep waits for d, returning early with an error if the context of the rolling update is cancelled.
+func (c *RollingUpdateCluster) sleep(d time.Duration) error {
+	ctx := c.Ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(d):
+		return nil
+	}
+}
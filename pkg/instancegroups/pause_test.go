@@ -0,0 +1,153 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instancegroups
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	kopsapi "k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/util/pkg/vfs"
+)
+
+// closedMaintenanceWindows returns maintenance windows that are not open now.
+func closedMaintenanceWindows() []kopsapi.MaintenanceWindow {
+	tomorrow := time.Now().UTC().AddDate(0, 0, 1).Weekday()
+	return []kopsapi.MaintenanceWindow{
+		{Days: []string{tomorrow.String()}, Start: "00:00", End: "00:01"},
+	}
+}
+
+func TestPauseRollingUpdate(t *testing.T) {
+	ctx := context.Background()
+	p := vfs.NewMemFSPath(vfs.NewMemFSContext(), "cluster/rolling-update-paused")
+
+	paused, err := IsRollingUpdatePaused(ctx, p)
+	require.NoError(t, err)
+	assert.False(t, paused)
+
+	require.NoError(t, PauseRollingUpdate(ctx, p, nil))
+	paused, err = IsRollingUpdatePaused(ctx, p)
+	require.NoError(t, err)
+	assert.True(t, paused)
+
+	require.NoError(t, UnpauseRollingUpdate(ctx, p))
+	paused, err = IsRollingUpdatePaused(ctx, p)
+	require.NoError(t, err)
+	assert.False(t, paused)
+
+	// Unpausing a rolling update that is not paused does nothing
+	assert.NoError(t, UnpauseRollingUpdate(ctx, p))
+}
+
+func TestRollingUpdateOutsideMaintenanceWindows(t *testing.T) {
+	c, cloud := getTestSetup()
+	c.Cluster.Spec.RollingUpdate = &kopsapi.RollingUpdate{
+		MaintenanceWindows: closedMaintenanceWindows(),
+	}
+
+	groups := getGroupsAllNeedUpdate(c.K8sClient, cloud)
+	err := c.RollingUpdate(groups, &kopsapi.InstanceGroupList{})
+	assert.ErrorContains(t, err, "outside of its maintenance windows")
+
+	assertGroupInstanceCount(t, cloud, "node-1", 3)
+	assertGroupInstanceCount(t, cloud, "node-2", 3)
+	assertGroupInstanceCount(t, cloud, "master-1", 2)
+	assertGroupInstanceCount(t, cloud, "bastion-1", 1)
+}
+
+func TestRollingUpdateInstanceGroupMaintenanceWindows(t *testing.T) {
+	c, cloud := getTestSetup()
+	c.Cluster.Spec.RollingUpdate = &kopsapi.RollingUpdate{
+		MaintenanceWindows: closedMaintenanceWindows(),
+	}
+
+	groups := getGroupsAllNeedUpdate(c.K8sClient, cloud)
+	for _, group := range groups {
+		// A window that ends when it starts is open all day
+		group.InstanceGroup.Spec.RollingUpdate = &kopsapi.RollingUpdate{
+			MaintenanceWindows: []kopsapi.MaintenanceWindow{{Start: "00:00", End: "00:00"}},
+		}
+	}
+	err := c.RollingUpdate(groups, &kopsapi.InstanceGroupList{})
+	assert.NoError(t, err, "rolling update")
+
+	assertGroupInstanceCount(t, cloud, "node-1", 0)
+	assertGroupInstanceCount(t, cloud, "node-2", 0)
+	assertGroupInstanceCount(t, cloud, "master-1", 0)
+	assertGroupInstanceCount(t, cloud, "bastion-1", 0)
+}
+
+func TestRollingUpdateWaitsForMaintenanceWindow(t *testing.T) {
+	defer func(d time.Duration) { holdTickDuration = d }(holdTickDuration)
+	holdTickDuration = time.Millisecond
+
+	c, cloud := getTestSetup()
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	c.Ctx = ctx
+	c.WaitForMaintenanceWindow = true
+	c.Cluster.Spec.RollingUpdate = &kopsapi.RollingUpdate{
+		MaintenanceWindows: closedMaintenanceWindows(),
+	}
+
+	groups := getGroupsAllNeedUpdate(c.K8sClient, cloud)
+	err := c.RollingUpdate(groups, &kopsapi.InstanceGroupList{})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	assertGroupInstanceCount(t, cloud, "node-1", 3)
+	assertGroupInstanceCount(t, cloud, "master-1", 2)
+}
+
+func TestRollingUpdatePaused(t *testing.T) {
+	defer func(d time.Duration) { holdTickDuration = d }(holdTickDuration)
+	holdTickDuration = time.Millisecond
+
+	ctx := context.Background()
+	c, cloud := getTestSetup()
+	c.PausePath = vfs.NewMemFSPath(vfs.NewMemFSContext(), "cluster/rolling-update-paused")
+	require.NoError(t, PauseRollingUpdate(ctx, c.PausePath, nil))
+	c.Paused.Store(true)
+
+	r, err := NewProgressRecorder(ctx, nil, nil, &RollingUpdateProgress{Cluster: "test.k8s.local"})
+	require.NoError(t, err)
+	var reasons []string
+	r.Observe(func(progress RollingUpdateProgress, event ProgressEvent) {
+		reasons = append(reasons, event.Reason)
+	})
+	c.Progress = r
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		c.Paused.Store(false)
+		time.Sleep(10 * time.Millisecond)
+		if err := UnpauseRollingUpdate(ctx, c.PausePath); err != nil {
+			t.Errorf("unexpected error unpausing: %v", err)
+		}
+	}()
+
+	groups := getGroupsAllNeedUpdate(c.K8sClient, cloud)
+	err = c.RollingUpdate(groups, &kopsapi.InstanceGroupList{})
+	assert.NoError(t, err, "rolling update")
+
+	assertGroupInstanceCount(t, cloud, "node-1", 0)
+	assertGroupInstanceCount(t, cloud, "master-1", 0)
+	assert.Contains(t, reasons, "RollingUpdatePaused")
+}
@@ -0,0 +1,391 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instancegroups
+
+import (
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	api "k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/pkg/cloudinstances"
+)
+
+// RollingUpdatePlan is the predicted schedule of a rolling update.
+type RollingUpdatePlan struct {
+	// Cluster is the name of the cluster to be updated.
+	Cluster string `json:"cluster"`
+	// InstanceGroups are the plans of the instance groups, in the order they will be updated.
+	// Bastions are updated in parallel; all other instance groups are updated one after the other.
+	InstanceGroups []*InstanceGroupPlan `json:"instanceGroups,omitempty"`
+	// EstimatedDuration is the least time the rolling update is expected to take.
+	EstimatedDuration metav1.Duration `json:"estimatedDuration"`
+}
+
+// InstanceGroupPlan is the predicted schedule of the update of an instance group.
+type InstanceGroupPlan struct {
+	// Name is the name of the instance group.
+	Name string `json:"name"`
+	// Role is the role of the instance group.
+	Role api.InstanceGroupRole `json:"role"`
+	// Strategy is the strategy used to replace the instances of the group.
+	Strategy api.RollingUpdateStrategy `json:"strategy"`
+	// MaxSurge is the number of instances that are detached for surging before any are replaced.
+	MaxSurge int `json:"maxSurge"`
+	// MaxConcurrency is the largest number of instances that are replaced at once.
+	MaxConcurrency int `json:"maxConcurrency"`
+	// Note explains why the instance group is not updated as usual, if it is not.
+	Note string `json:"note,omitempty"`
+	// WarmPool are the IDs of the warm pool instances that are deleted without being drained.
+	WarmPool []string `json:"warmPool,omitempty"`
+	// Batches are the instances that are drained and terminated together, in order.
+	Batches []*BatchPlan `json:"batches,omitempty"`
+	// EstimatedDuration is the least time the update of the instance group is expected to take.
+	EstimatedDuration metav1.Duration `json:"estimatedDuration"`
+}
+
+// BatchPlan is a set of instances that are drained and terminated together.
+type BatchPlan struct {
+	// Instances are the instances of the batch.
+	Instances []*InstancePlan `json:"instances"`
+	// BlockingPodDisruptionBudgets are the PodDisruptionBudgets, as namespace/name, that do not currently
+	// allow all the evictions of the batch.
+	BlockingPodDisruptionBudgets []string `json:"blockingPodDisruptionBudgets,omitempty"`
+}
+
+// InstancePlan is the predicted replacement of an instance.
+type InstancePlan struct {
+	// ID is the cloud ID of the instance.
+	ID string `json:"id"`
+	// Node is the name of the node of the instance, if it is known.
+	Node string `json:"node,omitempty"`
+	// Detached is true if the instance is detached for surging, so that its replacement is created before it is drained.
+	Detached bool `json:"detached,omitempty"`
+	// Evictions are the pods, as namespace/name, that are evicted when the node is drained.
+	Evictions []string `json:"evictions,omitempty"`
+}
+
+// podsAndBudgets are the pods, by node name, and PodDisruptionBudgets of the cluster.
+type podsAndBudgets struct {
+	pods    map[string][]*corev1.Pod
+	budgets []*policyv1.PodDisruptionBudget
+}
+
+// Plan predicts the rolling update of the groups without changing anything: the batches of instances that
+// are replaced, the pods that are evicted from them and the PodDisruptionBudgets that would block the evictions.
+// It uses the same settings as RollingUpdate. Unless CloudOnly is set, the pods and PodDisruptionBudgets are
+// read from the cluster.
+func (c *RollingUpdateCluster) Plan(groups map[string]*cloudinstances.CloudInstanceGroup) (*RollingUpdatePlan, error) {
+	var cluster *podsAndBudgets
+	if !c.CloudOnly {
+		if c.K8sClient == nil {
+			return nil, fmt.Errorf("rollingUpdate is missing a k8s client")
+		}
+		var err error
+		cluster, err = c.listPodsAndBudgets()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	byRole := make(map[api.InstanceGroupRole]map[string]*cloudinstances.CloudInstanceGroup)
+	for k, group := range groups {
+		if c.Progress.IsGroupCompleted(group.InstanceGroup.ObjectMeta.Name) {
+			continue
+		}
+		role := group.InstanceGroup.Spec.Role
+		if byRole[role] == nil {
+			byRole[role] = make(map[string]*cloudinstances.CloudInstanceGroup)
+		}
+		byRole[role][k] = group
+	}
+
+	plan := &RollingUpdatePlan{
+		Cluster: c.ClusterName,
+	}
+	var bastionDuration, duration time.Duration
+	for _, role := range []api.InstanceGroupRole{api.InstanceGroupRoleBastion, api.InstanceGroupRoleControlPlane, api.InstanceGroupRoleAPIServer, api.InstanceGroupRoleNode} {
+		interval := c.NodeInterval
+		switch role {
+		case api.InstanceGroupRoleBastion:
+			interval = c.BastionInterval
+		case api.InstanceGroupRoleControlPlane:
+			interval = c.MasterInterval
+		}
+
+		for _, k := range sortGroups(byRole[role]) {
+			groupPlan := c.planInstanceGroup(byRole[role][k], interval, cluster)
+			if groupPlan == nil {
+				continue
+			}
+			plan.InstanceGroups = append(plan.InstanceGroups, groupPlan)
+
+			if role == api.InstanceGroupRoleBastion {
+				if groupPlan.EstimatedDuration.Duration > bastionDuration {
+					bastionDuration = groupPlan.EstimatedDuration.Duration
+				}
+			} else {
+				duration += groupPlan.EstimatedDuration.Duration
+			}
+		}
+	}
+	plan.EstimatedDuration = metav1.Duration{Duration: bastionDuration + duration}
+
+	return plan, nil
+}
+
+// planInstanceGroup predicts the update of an instance group, following rollingUpdateInstanceGroup.
+// It returns nil if the instance group does not need updating.
+func (c *RollingUpdateCluster) planInstanceGroup(group *cloudinstances.CloudInstanceGroup, sleepAfterTerminate time.Duration, cluster *podsAndBudgets) *InstanceGroupPlan {
+	ig := group.InstanceGroup
+	numInstances := len(group.Ready) + len(group.NeedUpdate)
+	update := group.NeedUpdate
+	if c.Force {
+		update = append(update, group.Ready...)
+	}
+	if len(update) == 0 {
+		return nil
+	}
+
+	settings := resolveSettings(c.Cluster, ig, numInstances)
+	plan := &InstanceGroupPlan{
+		Name:     ig.ObjectMeta.Name,
+		Role:     ig.Spec.Role,
+		Strategy: settings.Strategy,
+	}
+
+	// Instances detached by an interrupted rolling update have already been surged
+	var instances []*cloudinstances.CloudInstance
+	for _, u := range update {
+		if u.State == cloudinstances.WarmPool {
+			plan.WarmPool = append(plan.WarmPool, u.ID)
+			continue
+		}
+		instance := *u
+		if c.Progress.IsDetached(u.ID) {
+			instance.Status = cloudinstances.CloudInstanceStatusDetached
+		}
+		instances = append(instances, &instance)
+	}
+
+	// Pods the drain settings leave on their nodes are not evicted
+	skip := labels.Nothing()
+	if settings.Drain.SkipPodSelector != "" {
+		if selector, err := labels.Parse(settings.Drain.SkipPodSelector); err == nil {
+			skip = selector
+		}
+	}
+
+	validateDuration := c.estimateValidation()
+	var duration time.Duration
+
+	if _, ok := c.blueGreenCloud(ig); ok && settings.Strategy == api.RollingUpdateStrategyBlueGreen {
+		if !*settings.DrainAndTerminate {
+			plan.Note = "drainAndTerminate is disabled"
+			return plan
+		}
+		plan.Note = "replaced blue/green"
+		plan.MaxConcurrency = settings.MaxUnavailable.IntValue()
+		if plan.MaxConcurrency < 1 || c.Interactive {
+			plan.MaxConcurrency = 1
+		}
+		plan.Batches = c.planBatches(instances, plan.MaxConcurrency, false, cluster, skip)
+
+		duration += sleepAfterTerminate + validateDuration
+		for _, batch := range plan.Batches {
+			duration += time.Duration(len(batch.Instances))*c.estimateDrain() + validateDuration
+		}
+		duration += sleepAfterTerminate + validateDuration
+		plan.EstimatedDuration = metav1.Duration{Duration: duration}
+		return plan
+	}
+
+	noneReady := len(group.Ready) == 0
+	maxSurge, maxConcurrency := c.surgeAndConcurrency(ig, settings, len(update))
+	if c.CloudOnly {
+		maxSurge = 0
+	}
+	plan.MaxSurge = maxSurge
+	plan.MaxConcurrency = maxConcurrency
+
+	instances = prioritizeUpdate(instances, c.Progress.IsInFlight)
+
+	if maxSurge > len(instances) {
+		maxSurge = len(instances)
+	}
+	if maxSurge > 0 {
+		detaching := false
+		for _, u := range instances[len(instances)-maxSurge:] {
+			if u.Status != cloudinstances.CloudInstanceStatusDetached {
+				detaching = true
+			}
+			u.Status = cloudinstances.CloudInstanceStatusDetached
+		}
+		if detaching {
+			// If none are ready, the first detached instance is validated before the others are detached
+			if noneReady && maxSurge > 1 {
+				duration += sleepAfterTerminate + validateDuration
+			}
+			duration += sleepAfterTerminate + validateDuration
+		}
+	}
+
+	if !*settings.DrainAndTerminate {
+		plan.Note = "drainAndTerminate is disabled"
+		plan.EstimatedDuration = metav1.Duration{Duration: duration}
+		return plan
+	}
+
+	if maxConcurrency < 1 {
+		maxConcurrency = 1
+	}
+	plan.Batches = c.planBatches(instances, maxConcurrency, noneReady, cluster, skip)
+	for range plan.Batches {
+		duration += c.estimateDrain() + sleepAfterTerminate + validateDuration
+	}
+	plan.EstimatedDuration = metav1.Duration{Duration: duration}
+
+	return plan
+}
+
+// planBatches divides the instances into batches of up to batchSize instances, starting with a batch
+// of one instance if firstAlone is set, and looks up the pods other than those matching skip that are evicted from each.
+func (c *RollingUpdateCluster) planBatches(instances []*cloudinstances.CloudInstance, batchSize int, firstAlone bool, cluster *podsAndBudgets, skip labels.Selector) []*BatchPlan {
+	var batches []*BatchPlan
+	for start := 0; start < len(instances); {
+		end := start + batchSize
+		if firstAlone && start == 0 {
+			end = 1
+		}
+		if end > len(instances) {
+			end = len(instances)
+		}
+
+		batch := &BatchPlan{}
+		var evicted []*corev1.Pod
+		for _, u := range instances[start:end] {
+			instance := &InstancePlan{
+				ID:       u.ID,
+				Detached: u.Status == cloudinstances.CloudInstanceStatusDetached,
+			}
+			if u.Node != nil {
+				instance.Node = u.Node.Name
+				if cluster != nil && !u.CloudInstanceGroup.InstanceGroup.IsBastion() {
+					for _, pod := range cluster.pods[u.Node.Name] {
+						if skip.Matches(labels.Set(pod.Labels)) {
+							continue
+						}
+						instance.Evictions = append(instance.Evictions, pod.Namespace+"/"+pod.Name)
+						evicted = append(evicted, pod)
+					}
+				}
+			}
+			batch.Instances = append(batch.Instances, instance)
+		}
+		if cluster != nil {
+			batch.BlockingPodDisruptionBudgets = cluster.blockingBudgets(evicted)
+		}
+		batches = append(batches, batch)
+		start = end
+	}
+	return batches
+}
+
+// estimateDrain returns the least time draining a node is expected to take.
+func (c *RollingUpdateCluster) estimateDrain() time.Duration {
+	if c.CloudOnly {
+		return 0
+	}
+	return c.PostDrainDelay
+}
+
+// estimateValidation returns the least time validating the cluster is expected to take,
+// which is the time between the consecutive successful validations.
+func (c *RollingUpdateCluster) estimateValidation() time.Duration {
+	if c.CloudOnly || c.ValidateCount < 2 {
+		return 0
+	}
+	return time.Duration(c.ValidateCount-1) * c.ValidateSuccessDuration
+}
+
+// listPodsAndBudgets reads the pods that draining evicts and the PodDisruptionBudgets of the cluster.
+func (c *RollingUpdateCluster) listPodsAndBudgets() (*podsAndBudgets, error) {
+	podList, err := c.K8sClient.CoreV1().Pods("").List(c.Ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("error listing pods: %w", err)
+	}
+	budgetList, err := c.K8sClient.PolicyV1().PodDisruptionBudgets("").List(c.Ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("error listing PodDisruptionBudgets: %w", err)
+	}
+
+	result := &podsAndBudgets{
+		pods: make(map[string][]*corev1.Pod),
+	}
+	for i := range podList.Items {
+		pod := &podList.Items[i]
+		if pod.Spec.NodeName == "" || !isEvicted(pod) {
+			continue
+		}
+		result.pods[pod.Spec.NodeName] = append(result.pods[pod.Spec.NodeName], pod)
+	}
+	for i := range budgetList.Items {
+		result.budgets = append(result.budgets, &budgetList.Items[i])
+	}
+	return result, nil
+}
+
+// isEvicted returns true if draining the node of the pod evicts it.
+// Like drainNode, this ignores DaemonSet pods; mirror pods and pods that have finished are not evicted either.
+func isEvicted(pod *corev1.Pod) bool {
+	if _, found := pod.Annotations[corev1.MirrorPodAnnotationKey]; found {
+		return false
+	}
+	if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+		return false
+	}
+	if controller := metav1.GetControllerOf(pod); controller != nil && controller.Kind == "DaemonSet" {
+		return false
+	}
+	return true
+}
+
+// blockingBudgets returns the PodDisruptionBudgets, as namespace/name, that allow fewer disruptions
+// than the number of their pods that are evicted.
+func (p *podsAndBudgets) blockingBudgets(evicted []*corev1.Pod) []string {
+	var blocking []string
+	for _, budget := range p.budgets {
+		selector, err := metav1.LabelSelectorAsSelector(budget.Spec.Selector)
+		if err != nil {
+			continue
+		}
+		count := 0
+		for _, pod := range evicted {
+			if pod.Namespace == budget.Namespace && selector.Matches(labels.Set(pod.Labels)) {
+				count++
+			}
+		}
+		if count > int(budget.Status.DisruptionsAllowed) {
+			blocking = append(blocking, budget.Namespace+"/"+budget.Name)
+		}
+	}
+	return blocking
+}
@@ -0,0 +1,171 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instancegroups
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	v1meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes/fake"
+	kopsapi "k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/pkg/cloudinstances"
+	"k8s.io/kops/upup/pkg/fi"
+)
+
+func addPlanTestPod(t *testing.T, c *RollingUpdateCluster, name string, node string, labels map[string]string, mutate func(pod *v1.Pod)) {
+	pod := &v1.Pod{
+		ObjectMeta: v1meta.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+			Labels:    labels,
+		},
+		Spec: v1.PodSpec{
+			NodeName: node,
+		},
+	}
+	if mutate != nil {
+		mutate(pod)
+	}
+	require.NoError(t, c.K8sClient.(*fake.Clientset).Tracker().Add(pod))
+}
+
+func addPlanTestPodDisruptionBudget(t *testing.T, c *RollingUpdateCluster, name string, app string, disruptionsAllowed int32) {
+	pdb := &policyv1.PodDisruptionBudget{
+		ObjectMeta: v1meta.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+		},
+		Spec: policyv1.PodDisruptionBudgetSpec{
+			Selector: &v1meta.LabelSelector{MatchLabels: map[string]string{"app": app}},
+		},
+		Status: policyv1.PodDisruptionBudgetStatus{
+			DisruptionsAllowed: disruptionsAllowed,
+		},
+	}
+	require.NoError(t, c.K8sClient.(*fake.Clientset).Tracker().Add(pdb))
+}
+
+func TestPlan(t *testing.T) {
+	c, cloud := getTestSetup()
+	c.Cluster.Spec.CloudProvider.AWS = &kopsapi.AWSSpec{}
+	groups := getGroupsAllNeedUpdate(c.K8sClient, cloud)
+
+	addPlanTestPod(t, c, "web", "node-1a.local", map[string]string{"app": "web"}, nil)
+	addPlanTestPod(t, c, "logs", "node-1a.local", nil, func(pod *v1.Pod) {
+		pod.OwnerReferences = []v1meta.OwnerReference{{Kind: "DaemonSet", Name: "logs", Controller: fi.PtrTo(true)}}
+	})
+	addPlanTestPod(t, c, "static", "node-1b.local", nil, func(pod *v1.Pod) {
+		pod.Annotations = map[string]string{v1.MirrorPodAnnotationKey: ""}
+	})
+	addPlanTestPod(t, c, "api", "node-1b.local", map[string]string{"app": "api"}, nil)
+	addPlanTestPod(t, c, "done", "node-1c.local", nil, func(pod *v1.Pod) {
+		pod.Status.Phase = v1.PodSucceeded
+	})
+	addPlanTestPodDisruptionBudget(t, c, "web", "web", 0)
+	addPlanTestPodDisruptionBudget(t, c, "api", "api", 1)
+
+	plan, err := c.Plan(groups)
+	require.NoError(t, err)
+
+	var names []string
+	for _, group := range plan.InstanceGroups {
+		names = append(names, group.Name)
+	}
+	assert.Equal(t, []string{"bastion-1", "master-1", "node-1", "node-2"}, names, "instance groups")
+
+	master := plan.InstanceGroups[1]
+	assert.Equal(t, 0, master.MaxSurge, "control plane does not surge")
+	require.Len(t, master.Batches, 2)
+
+	nodes := plan.InstanceGroups[2]
+	assert.Equal(t, kopsapi.RollingUpdateStrategyRollingUpdate, nodes.Strategy)
+	assert.Equal(t, 1, nodes.MaxSurge)
+	assert.Equal(t, 1, nodes.MaxConcurrency)
+	require.Len(t, nodes.Batches, 3)
+
+	first := nodes.Batches[0]
+	require.Len(t, first.Instances, 1)
+	assert.Equal(t, "node-1a", first.Instances[0].ID)
+	assert.Equal(t, "node-1a.local", first.Instances[0].Node)
+	assert.False(t, first.Instances[0].Detached)
+	assert.Equal(t, []string{"default/web"}, first.Instances[0].Evictions, "DaemonSet pods are not evicted")
+	assert.Equal(t, []string{"default/web"}, first.BlockingPodDisruptionBudgets)
+
+	second := nodes.Batches[1]
+	assert.Equal(t, []string{"default/api"}, second.Instances[0].Evictions, "mirror pods are not evicted")
+	assert.Empty(t, second.BlockingPodDisruptionBudgets)
+
+	last := nodes.Batches[2]
+	assert.Equal(t, "node-1c", last.Instances[0].ID)
+	assert.True(t, last.Instances[0].Detached, "surged instance is replaced last")
+	assert.Empty(t, last.Instances[0].Evictions, "finished pods are not evicted")
+
+	// Each instance waits for the interval and two validations; each group that surges waits for them once more
+	assert.Equal(t, 24*time.Millisecond, nodes.EstimatedDuration.Duration)
+	assert.Equal(t, 72*time.Millisecond, plan.EstimatedDuration.Duration)
+
+	// Planning changes nothing
+	for _, action := range c.K8sClient.(*fake.Clientset).Actions() {
+		assert.Equal(t, "list", action.GetVerb(), "action on %s", action.GetResource().Resource)
+	}
+	assertGroupInstanceCount(t, cloud, "node-1", 3)
+	assert.Equal(t, cloudinstances.CloudInstanceStatusNeedsUpdate, groups["node-1"].NeedUpdate[2].Status)
+}
+
+func TestPlanResumedCloudOnly(t *testing.T) {
+	ctx := context.Background()
+	c, cloud := getTestSetup()
+	c.CloudOnly = true
+	groups := getGroupsAllNeedUpdate(c.K8sClient, cloud)
+	two := intstr.FromInt(2)
+	groups["node-1"].InstanceGroup.Spec.RollingUpdate = &kopsapi.RollingUpdate{
+		MaxUnavailable: &two,
+	}
+
+	r, err := NewProgressRecorder(ctx, nil, nil, &RollingUpdateProgress{
+		Cluster:         "test.k8s.local",
+		CompletedGroups: []string{"bastion-1", "master-1"},
+	})
+	require.NoError(t, err)
+	c.Progress = r
+
+	plan, err := c.Plan(groups)
+	require.NoError(t, err)
+
+	require.Len(t, plan.InstanceGroups, 2)
+	nodes := plan.InstanceGroups[0]
+	assert.Equal(t, "node-1", nodes.Name)
+	assert.Equal(t, 0, nodes.MaxSurge)
+	assert.Equal(t, 2, nodes.MaxConcurrency)
+	require.Len(t, nodes.Batches, 2, "first instance is replaced alone when none are ready")
+	assert.Len(t, nodes.Batches[0].Instances, 1)
+	assert.Len(t, nodes.Batches[1].Instances, 2)
+	for _, batch := range nodes.Batches {
+		for _, instance := range batch.Instances {
+			assert.Empty(t, instance.Evictions, "evictions are not looked up when cloudonly")
+		}
+	}
+	assert.Equal(t, 2*time.Millisecond, nodes.EstimatedDuration.Duration, "cloudonly does not validate")
+	assert.Empty(t, c.K8sClient.(*fake.Clientset).Actions())
+}
@@ -0,0 +1,510 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instancegroups
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+	api "k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/pkg/apis/kops/registry"
+	"k8s.io/kops/pkg/cloudinstances"
+	"k8s.io/kops/pkg/validation"
+	"k8s.io/kops/util/pkg/vfs"
+	"sigs.k8s.io/yaml"
+)
+
+// RollingUpdateProgress is the record of a rolling update that is kept in the state store,
+// so that an interrupted rolling update can be inspected and resumed.
+type RollingUpdateProgress struct {
+	// Cluster is the name of the cluster being updated.
+	Cluster string `json:"cluster"`
+	// StartTime is when the rolling update started.
+	StartTime time.Time `json:"startTime"`
+	// UpdateTime is when the progress was last recorded.
+	UpdateTime time.Time `json:"updateTime"`
+	// CompletionTime is when the rolling update completed successfully.
+	CompletionTime *time.Time `json:"completionTime,omitempty"`
+	// Error is the error that stopped the rolling update, if any.
+	Error string `json:"error,omitempty"`
+
+	// Force, CloudOnly, InstanceGroups and InstanceGroupRoles are the options the rolling update was started with,
+	// so that it can be resumed with the same options.
+	Force              bool     `json:"force,omitempty"`
+	CloudOnly          bool     `json:"cloudOnly,omitempty"`
+	InstanceGroups     []string `json:"instanceGroups,omitempty"`
+	InstanceGroupRoles []string `json:"instanceGroupRoles,omitempty"`
+
+	// CompletedGroups are the instance groups that have been updated, in order.
+	CompletedGroups []string `json:"completedGroups,omitempty"`
+	// InFlight are the instances that are being drained and terminated.
+	InFlight []InstanceProgress `json:"inFlight,omitempty"`
+	// Detached are the instances that have been detached for surging, and not yet terminated.
+	Detached []InstanceProgress `json:"detached,omitempty"`
+	// LastValidation is the result of the last validation of the cluster.
+	LastValidation *ValidationProgress `json:"lastValidation,omitempty"`
+	// SkippedHooks are the instance hooks that were not run, as the rolling update does not run their commands.
+	SkippedHooks []string `json:"skippedHooks,omitempty"`
+	// KnownGoodTemplates are the template versions, by instance group name, that instance groups were last
+	// updated to successfully. They are carried over from one rolling update to the next, so that an instance
+	// group with the Rollback failure policy can be returned to them.
+	KnownGoodTemplates map[string]string `json:"knownGoodTemplates,omitempty"`
+}
+
+// InstanceProgress records an instance that a rolling update is operating on.
+type InstanceProgress struct {
+	// ID is the cloud ID of the instance.
+	ID string `json:"id"`
+	// InstanceGroup is the name of the instance group of the instance.
+	InstanceGroup string `json:"instanceGroup"`
+	// Node is the name of the node of the instance, if it is known.
+	Node string `json:"node,omitempty"`
+	// Time is when the operation on the instance started.
+	Time time.Time `json:"time"`
+}
+
+// ValidationProgress records the result of a validation of the cluster.
+type ValidationProgress struct {
+	// Time is when the cluster was validated.
+	Time time.Time `json:"time"`
+	// Succeeded is true if the cluster validated.
+	Succeeded bool `json:"succeeded"`
+	// Failures are the messages of the validation failures.
+	Failures []string `json:"failures,omitempty"`
+	// Error is the error that prevented validation, if any.
+	Error string `json:"error,omitempty"`
+}
+
+// IsComplete returns true if the rolling update completed successfully.
+func (p *RollingUpdateProgress) IsComplete() bool {
+	return p.CompletionTime != nil
+}
+
+// RollingUpdateProgressPath returns the location of the progress of the rolling update of the cluster.
+func RollingUpdateProgressPath(cluster *api.Cluster) (vfs.Path, error) {
+	configBase, err := registry.ConfigBase(cluster)
+	if err != nil {
+		return nil, err
+	}
+	return configBase.Join(registry.PathRollingUpdate), nil
+}
+
+// ReadRollingUpdateProgress returns the progress stored at p, or nil if there is none.
+func ReadRollingUpdateProgress(ctx context.Context, p vfs.Path) (*RollingUpdateProgress, error) {
+	data, err := p.ReadFile(ctx)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error reading rolling update progress %s: %w", p, err)
+	}
+	progress := &RollingUpdateProgress{}
+	if err := yaml.Unmarshal(data, progress); err != nil {
+		return nil, fmt.Errorf("error parsing rolling update progress %s: %w", p, err)
+	}
+	return progress, nil
+}
+
+// ProgressEvent describes a change in the progress of a rolling update.
+type ProgressEvent struct {
+	// Warning is true if the change is a failure.
+	Warning bool
+	// Reason is a short, CamelCase description of the change.
+	Reason string
+	// Message is a human-readable description of the change.
+	Message string
+	// Node is the name of the node the change concerns, if any.
+	Node string
+}
+
+// finishedWriteTimeout bounds the write of the final progress, which is not cancelled with the rolling update.
+const finishedWriteTimeout = 30 * time.Second
+
+// ProgressObserver is called with the progress of a rolling update each time it changes.
+// It is called with the recorder locked, so must not call the recorder.
+type ProgressObserver func(progress RollingUpdateProgress, event ProgressEvent)
+
+// ProgressRecorder writes the progress of a rolling update to the state store as it changes.
+// A nil ProgressRecorder records nothing.
+type ProgressRecorder struct {
+	ctx  context.Context
+	path vfs.Path
+	acl  vfs.ACL
+
+	mutex    sync.Mutex
+	progress RollingUpdateProgress
+	observer ProgressObserver
+}
+
+// NewProgressRecorder returns a recorder that writes progress to p, starting from the given progress.
+// To resume a rolling update, pass the progress that was read from p.
+// If p is nil, progress is only reported to the observer.
+func NewProgressRecorder(ctx context.Context, p vfs.Path, acl vfs.ACL, progress *RollingUpdateProgress) (*ProgressRecorder, error) {
+	r := &ProgressRecorder{
+		ctx:      ctx,
+		path:     p,
+		acl:      acl,
+		progress: *progress,
+	}
+	r.progress.CompletionTime = nil
+	r.progress.Error = ""
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if err := r.write(r.ctx); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Observe sets the observer that is notified of each change in the progress.
+func (r *ProgressRecorder) Observe(observer ProgressObserver) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.observer = observer
+}
+
+// Progress returns a copy of the recorded progress.
+func (r *ProgressRecorder) Progress() RollingUpdateProgress {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.progress
+}
+
+// IsGroupCompleted returns true if the instance group has already been updated.
+func (r *ProgressRecorder) IsGroupCompleted(name string) bool {
+	if r == nil {
+		return false
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for _, completed := range r.progress.CompletedGroups {
+		if completed == name {
+			return true
+		}
+	}
+	return false
+}
+
+// IsDetached returns true if the instance was detached for surging.
+func (r *ProgressRecorder) IsDetached(id string) bool {
+	if r == nil {
+		return false
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return indexOfInstance(r.progress.Detached, id) >= 0
+}
+
+// IsInFlight returns true if the instance was being drained and terminated.
+func (r *ProgressRecorder) IsInFlight(id string) bool {
+	if r == nil {
+		return false
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return indexOfInstance(r.progress.InFlight, id) >= 0
+}
+
+// KnownGoodTemplate returns the template version the instance group was last updated to successfully,
+// or "" if none is recorded.
+func (r *ProgressRecorder) KnownGoodTemplate(name string) string {
+	if r == nil {
+		return ""
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.progress.KnownGoodTemplates[name]
+}
+
+// TemplateKnownGood records that the instance group was updated successfully to a template version.
+func (r *ProgressRecorder) TemplateKnownGood(name string, template string) {
+	if r == nil {
+		return
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.progress.KnownGoodTemplates[name] == template {
+		return
+	}
+	knownGood := make(map[string]string, len(r.progress.KnownGoodTemplates)+1)
+	for k, v := range r.progress.KnownGoodTemplates {
+		knownGood[k] = v
+	}
+	knownGood[name] = template
+	r.progress.KnownGoodTemplates = knownGood
+	if err := r.write(r.ctx); err != nil {
+		klog.Warningf("%v", err)
+	}
+}
+
+// RolledBack reports that the instance group was returned to a template version, replacing the instances
+// that had been created from the failing version.
+func (r *ProgressRecorder) RolledBack(name string, template string, instances []string) {
+	if r == nil {
+		return
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	replaced := "no instances"
+	if len(instances) != 0 {
+		replaced = "instances " + strings.Join(instances, ", ")
+	}
+	r.notify(ProgressEvent{
+		Warning: true,
+		Reason:  "InstanceGroupRolledBack",
+		Message: fmt.Sprintf("Rolled back instance group %s to template %s, replacing %s", name, template, replaced),
+	})
+}
+
+// GroupCompleted records that the instance group has been updated.
+func (r *ProgressRecorder) GroupCompleted(name string) {
+	if r == nil {
+		return
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for _, completed := range r.progress.CompletedGroups {
+		if completed == name {
+			return
+		}
+	}
+	r.progress.CompletedGroups = append(r.progress.CompletedGroups, name)
+	r.notify(ProgressEvent{
+		Reason:  "InstanceGroupUpdated",
+		Message: fmt.Sprintf("Instance group %s has been updated", name),
+	})
+}
+
+// InstanceDetached records that the instance was detached for surging.
+func (r *ProgressRecorder) InstanceDetached(u *cloudinstances.CloudInstance) {
+	if r == nil {
+		return
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	i := newInstanceProgress(u)
+	if indexOfInstance(r.progress.Detached, u.ID) < 0 {
+		r.progress.Detached = append(r.progress.Detached, i)
+	}
+	r.notify(ProgressEvent{
+		Reason:  "InstanceDetached",
+		Message: fmt.Sprintf("Detached instance %s from instance group %s", i.ID, i.InstanceGroup),
+		Node:    i.Node,
+	})
+}
+
+// InstanceStarted records that the instance is being drained and terminated.
+func (r *ProgressRecorder) InstanceStarted(u *cloudinstances.CloudInstance) {
+	if r == nil {
+		return
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	i := newInstanceProgress(u)
+	if indexOfInstance(r.progress.InFlight, u.ID) < 0 {
+		r.progress.InFlight = append(r.progress.InFlight, i)
+	}
+	r.notify(ProgressEvent{
+		Reason:  "ReplacingInstance",
+		Message: fmt.Sprintf("Draining and terminating instance %s of instance group %s", i.ID, i.InstanceGroup),
+		Node:    i.Node,
+	})
+}
+
+// InstanceTerminated records that the instance has been terminated.
+func (r *ProgressRecorder) InstanceTerminated(u *cloudinstances.CloudInstance) {
+	if r == nil {
+		return
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if i := indexOfInstance(r.progress.InFlight, u.ID); i >= 0 {
+		r.progress.InFlight = append(r.progress.InFlight[:i], r.progress.InFlight[i+1:]...)
+	}
+	if i := indexOfInstance(r.progress.Detached, u.ID); i >= 0 {
+		r.progress.Detached = append(r.progress.Detached[:i], r.progress.Detached[i+1:]...)
+	}
+	i := newInstanceProgress(u)
+	r.notify(ProgressEvent{
+		Reason:  "InstanceTerminated",
+		Message: fmt.Sprintf("Terminated instance %s of instance group %s", i.ID, i.InstanceGroup),
+		Node:    i.Node,
+	})
+}
+
+// Validated records the result of a validation of the cluster.
+func (r *ProgressRecorder) Validated(result *validation.ValidationCluster, err error) {
+	if r == nil {
+		return
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	v := &ValidationProgress{
+		Time: time.Now().UTC(),
+	}
+	if err != nil {
+		v.Error = err.Error()
+	} else if result != nil {
+		for _, failure := range result.Failures {
+			v.Failures = append(v.Failures, failure.Message)
+		}
+		v.Succeeded = len(v.Failures) == 0
+	}
+	r.progress.LastValidation = v
+	switch {
+	case v.Error != "":
+		r.notify(ProgressEvent{
+			Warning: true,
+			Reason:  "ClusterValidationFailed",
+			Message: fmt.Sprintf("Error validating cluster: %s", v.Error),
+		})
+	case !v.Succeeded:
+		r.notify(ProgressEvent{
+			Warning: true,
+			Reason:  "ClusterValidationFailed",
+			Message: fmt.Sprintf("Cluster did not pass validation: %s", strings.Join(v.Failures, "; ")),
+		})
+	default:
+		r.notify(ProgressEvent{
+			Reason:  "ClusterValidated",
+			Message: "Cluster passed validation",
+		})
+	}
+}
+
+// HookSkipped records that the instance hook is not run, as the rolling update does not run its command.
+func (r *ProgressRecorder) HookSkipped(name string) {
+	if r == nil {
+		return
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for _, skipped := range r.progress.SkippedHooks {
+		if skipped == name {
+			return
+		}
+	}
+	r.progress.SkippedHooks = append(r.progress.SkippedHooks, name)
+	r.notify(ProgressEvent{
+		Warning: true,
+		Reason:  "InstanceHookSkipped",
+		Message: fmt.Sprintf("Skipping instance hook %s, as this rolling update does not run its command", name),
+	})
+}
+
+// Held reports that the rolling update is holding before replacing another instance.
+func (r *ProgressRecorder) Held(reason string, message string) {
+	if r == nil {
+		return
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.notify(ProgressEvent{
+		Reason:  reason,
+		Message: message,
+	})
+}
+
+// Finished records that the rolling update has stopped, successfully if err is nil.
+func (r *ProgressRecorder) Finished(err error) {
+	if r == nil {
+		return
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	// The rolling update may have stopped because its context was cancelled,
+	// so the final progress is written with a context of its own
+	ctx, cancel := context.WithTimeout(context.Background(), finishedWriteTimeout)
+	defer cancel()
+
+	if err != nil {
+		r.progress.Error = err.Error()
+		r.notifyContext(ctx, ProgressEvent{
+			Warning: true,
+			Reason:  "RollingUpdateFailed",
+			Message: fmt.Sprintf("Rolling update failed: %v", err),
+		})
+	} else {
+		now := time.Now().UTC()
+		r.progress.CompletionTime = &now
+		r.notifyContext(ctx, ProgressEvent{
+			Reason:  "RollingUpdateCompleted",
+			Message: "Rolling update completed",
+		})
+	}
+}
+
+// notify writes the progress and reports the change to the observer,
+// logging rather than failing the rolling update if the progress cannot be written.
+func (r *ProgressRecorder) notify(event ProgressEvent) {
+	r.notifyContext(r.ctx, event)
+}
+
+func (r *ProgressRecorder) notifyContext(ctx context.Context, event ProgressEvent) {
+	if err := r.write(ctx); err != nil {
+		klog.Warningf("%v", err)
+	}
+	if r.observer != nil {
+		r.observer(r.progress, event)
+	}
+}
+
+func (r *ProgressRecorder) write(ctx context.Context) error {
+	r.progress.UpdateTime = time.Now().UTC()
+	if r.path == nil {
+		return nil
+	}
+	data, err := yaml.Marshal(&r.progress)
+	if err != nil {
+		return fmt.Errorf("error serializing rolling update progress: %w", err)
+	}
+	if err := r.path.WriteFile(ctx, bytes.NewReader(data), r.acl); err != nil {
+		return fmt.Errorf("error writing rolling update progress %s: %w", r.path, err)
+	}
+	return nil
+}
+
+func newInstanceProgress(u *cloudinstances.CloudInstance) InstanceProgress {
+	i := InstanceProgress{
+		ID:   u.ID,
+		Time: time.Now().UTC(),
+	}
+	if u.CloudInstanceGroup != nil && u.CloudInstanceGroup.InstanceGroup != nil {
+		i.InstanceGroup = u.CloudInstanceGroup.InstanceGroup.ObjectMeta.Name
+	}
+	if u.Node != nil {
+		i.Node = u.Node.Name
+	}
+	return i
+}
+
+func indexOfInstance(instances []InstanceProgress, id string) int {
+	for i := range instances {
+		if instances[i].ID == id {
+			return i
+		}
+	}
+	return -1
+}
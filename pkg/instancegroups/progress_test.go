@@ -0,0 +1,198 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instancegroups
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	kopsapi "k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/pkg/cloudinstances"
+	"k8s.io/kops/pkg/validation"
+	"k8s.io/kops/util/pkg/vfs"
+)
+
+func TestProgressRecorder(t *testing.T) {
+	ctx := context.Background()
+	p := vfs.NewMemFSPath(vfs.NewMemFSContext(), "cluster/rolling-update")
+
+	progress, err := ReadRollingUpdateProgress(ctx, p)
+	require.NoError(t, err)
+	assert.Nil(t, progress)
+
+	r, err := NewProgressRecorder(ctx, p, nil, &RollingUpdateProgress{
+		Cluster:   "test.k8s.local",
+		StartTime: time.Now().UTC(),
+		Force:     true,
+	})
+	require.NoError(t, err)
+
+	group := &cloudinstances.CloudInstanceGroup{
+		InstanceGroup: &kopsapi.InstanceGroup{},
+	}
+	group.InstanceGroup.Name = "node-1"
+	detached, err := group.NewCloudInstance("node-1a", cloudinstances.CloudInstanceStatusNeedsUpdate, nil)
+	require.NoError(t, err)
+	replaced, err := group.NewCloudInstance("node-1b", cloudinstances.CloudInstanceStatusNeedsUpdate, nil)
+	require.NoError(t, err)
+
+	r.GroupCompleted("master-1")
+	r.InstanceDetached(detached)
+	r.InstanceStarted(replaced)
+	r.Validated(&validation.ValidationCluster{
+		Failures: []*validation.ValidationError{{Message: "node-1b is not ready"}},
+	}, nil)
+
+	progress, err = ReadRollingUpdateProgress(ctx, p)
+	require.NoError(t, err)
+	require.NotNil(t, progress)
+	assert.True(t, progress.Force)
+	assert.False(t, progress.IsComplete())
+	assert.Equal(t, []string{"master-1"}, progress.CompletedGroups)
+	require.Len(t, progress.Detached, 1)
+	assert.Equal(t, "node-1a", progress.Detached[0].ID)
+	assert.Equal(t, "node-1", progress.Detached[0].InstanceGroup)
+	require.Len(t, progress.InFlight, 1)
+	assert.Equal(t, "node-1b", progress.InFlight[0].ID)
+	require.NotNil(t, progress.LastValidation)
+	assert.False(t, progress.LastValidation.Succeeded)
+	assert.Equal(t, []string{"node-1b is not ready"}, progress.LastValidation.Failures)
+
+	// A resumed rolling update knows what was done
+	resumed, err := NewProgressRecorder(ctx, p, nil, progress)
+	require.NoError(t, err)
+	assert.True(t, resumed.IsGroupCompleted("master-1"))
+	assert.False(t, resumed.IsGroupCompleted("node-1"))
+	assert.True(t, resumed.IsDetached("node-1a"))
+	assert.True(t, resumed.IsInFlight("node-1b"))
+
+	resumed.InstanceTerminated(replaced)
+	resumed.InstanceTerminated(detached)
+	resumed.Validated(&validation.ValidationCluster{}, nil)
+	resumed.GroupCompleted("node-1")
+	resumed.Finished(nil)
+
+	progress, err = ReadRollingUpdateProgress(ctx, p)
+	require.NoError(t, err)
+	assert.True(t, progress.IsComplete())
+	assert.Empty(t, progress.InFlight)
+	assert.Empty(t, progress.Detached)
+	assert.True(t, progress.LastValidation.Succeeded)
+	assert.Equal(t, []string{"master-1", "node-1"}, progress.CompletedGroups)
+}
+
+func TestProgressRecorderObserver(t *testing.T) {
+	ctx := context.Background()
+
+	// Without a path, progress is only reported to the observer
+	r, err := NewProgressRecorder(ctx, nil, nil, &RollingUpdateProgress{Cluster: "test.k8s.local"})
+	require.NoError(t, err)
+
+	var events []ProgressEvent
+	var last RollingUpdateProgress
+	r.Observe(func(progress RollingUpdateProgress, event ProgressEvent) {
+		events = append(events, event)
+		last = progress
+	})
+
+	group := &cloudinstances.CloudInstanceGroup{
+		InstanceGroup: &kopsapi.InstanceGroup{},
+	}
+	group.InstanceGroup.Name = "node-1"
+	instance, err := group.NewCloudInstance("node-1a", cloudinstances.CloudInstanceStatusNeedsUpdate, nil)
+	require.NoError(t, err)
+
+	r.InstanceStarted(instance)
+	r.InstanceTerminated(instance)
+	r.Validated(nil, errors.New("unreachable"))
+	r.GroupCompleted("node-1")
+	r.Finished(nil)
+
+	var reasons []string
+	for _, event := range events {
+		reasons = append(reasons, event.Reason)
+	}
+	assert.Equal(t, []string{"ReplacingInstance", "InstanceTerminated", "ClusterValidationFailed", "InstanceGroupUpdated", "RollingUpdateCompleted"}, reasons)
+	assert.True(t, events[2].Warning)
+	assert.Equal(t, "Error validating cluster: unreachable", events[2].Message)
+	assert.True(t, last.IsComplete())
+	assert.Equal(t, []string{"node-1"}, last.CompletedGroups)
+}
+
+// contextCheckingPath fails writes with a cancelled context, as the writes to cloud object stores do.
+type contextCheckingPath struct {
+	*vfs.MemFSPath
+}
+
+func (p *contextCheckingPath) WriteFile(ctx context.Context, r io.ReadSeeker, acl vfs.ACL) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return p.MemFSPath.WriteFile(ctx, r, acl)
+}
+
+func TestProgressRecorderFinishedAfterCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	p := &contextCheckingPath{MemFSPath: vfs.NewMemFSPath(vfs.NewMemFSContext(), "cluster/rolling-update")}
+
+	r, err := NewProgressRecorder(ctx, p, nil, &RollingUpdateProgress{Cluster: "test.k8s.local"})
+	require.NoError(t, err)
+
+	cancel()
+	r.Finished(context.Canceled)
+
+	progress, err := ReadRollingUpdateProgress(context.Background(), p)
+	require.NoError(t, err)
+	require.NotNil(t, progress)
+	assert.Equal(t, "context canceled", progress.Error, "the final progress is written after the rolling update is cancelled")
+}
+
+func TestRollingUpdateResumeSkipsCompletedGroups(t *testing.T) {
+	ctx := context.Background()
+	c, cloud := getTestSetup()
+	c.Force = true
+
+	p := vfs.NewMemFSPath(vfs.NewMemFSContext(), "cluster/rolling-update")
+	r, err := NewProgressRecorder(ctx, p, nil, &RollingUpdateProgress{
+		Cluster:         "test.k8s.local",
+		Force:           true,
+		CompletedGroups: []string{"bastion-1", "master-1"},
+	})
+	require.NoError(t, err)
+	c.Progress = r
+
+	groups := getGroups(c.K8sClient, cloud)
+	err = c.RollingUpdate(groups, &kopsapi.InstanceGroupList{})
+	assert.NoError(t, err, "rolling update")
+
+	assertGroupInstanceCount(t, cloud, "node-1", 0)
+	assertGroupInstanceCount(t, cloud, "node-2", 0)
+	assertGroupInstanceCount(t, cloud, "master-1", 2)
+	assertGroupInstanceCount(t, cloud, "bastion-1", 1)
+
+	progress, err := ReadRollingUpdateProgress(ctx, p)
+	require.NoError(t, err)
+	assert.True(t, progress.IsComplete())
+	assert.Equal(t, []string{"bastion-1", "master-1", "node-1", "node-2"}, progress.CompletedGroups)
+	assert.Empty(t, progress.InFlight)
+	assert.Empty(t, progress.Detached)
+}
@@ -0,0 +1,142 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instancegroups
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+
+	api "k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/pkg/cloudinstances"
+	"k8s.io/kops/upup/pkg/fi"
+)
+
+// knownGoodTemplate returns the template version to return the group to should its update fail validation:
+// the template version it was last updated to successfully or, failing that, the template version of the
+// instances being replaced. It also returns the template version the group is being updated to.
+// It returns "" if the group cannot be rolled back.
+func (c *RollingUpdateCluster) knownGoodTemplate(group *cloudinstances.CloudInstanceGroup, update []*cloudinstances.CloudInstance) (knownGood string, current string) {
+	name := group.InstanceGroup.ObjectMeta.Name
+	cloud, ok := c.Cloud.(fi.InstanceTemplateCloud)
+	if !ok {
+		klog.Warningf("InstanceGroup %q will not be rolled back on failure, as the cloud does not version instance templates.", name)
+		return "", ""
+	}
+	current, err := cloud.GetGroupTemplate(group)
+	if err != nil {
+		klog.Warningf("InstanceGroup %q will not be rolled back on failure: %v", name, err)
+		return "", ""
+	}
+
+	knownGood = c.Progress.KnownGoodTemplate(name)
+	if knownGood == "" {
+		for _, u := range update {
+			template, err := cloud.GetInstanceTemplate(u)
+			if err != nil {
+				klog.Warningf("InstanceGroup %q will not be rolled back on failure: %v", name, err)
+				return "", ""
+			}
+			if template != "" && template != current {
+				knownGood = template
+				break
+			}
+		}
+	}
+
+	if knownGood == "" || knownGood == current {
+		klog.Warningf("InstanceGroup %q will not be rolled back on failure, as no earlier template is known to be good.", name)
+		return "", ""
+	}
+	klog.V(2).Infof("InstanceGroup %q will be rolled back to template %s on failure.", name, knownGood)
+	return knownGood, current
+}
+
+// recordKnownGoodTemplate records the template version of a group whose instances have all been updated.
+func (c *RollingUpdateCluster) recordKnownGoodTemplate(group *cloudinstances.CloudInstanceGroup) {
+	cloud, ok := c.Cloud.(fi.InstanceTemplateCloud)
+	if !ok || c.Progress == nil {
+		return
+	}
+	template, err := cloud.GetGroupTemplate(group)
+	if err != nil {
+		klog.V(2).Infof("Not recording the template of InstanceGroup %q: %v", group.InstanceGroup.ObjectMeta.Name, err)
+		return
+	}
+	c.Progress.TemplateKnownGood(group.InstanceGroup.ObjectMeta.Name, template)
+}
+
+// rollBackInstanceGroup returns a group whose update to the failing template version failed with err to its
+// known-good template version, then replaces the instances created from the failing version, along with
+// the instances detached for surging. It returns err, annotated with what was rolled back.
+func (c *RollingUpdateCluster) rollBackInstanceGroup(group *cloudinstances.CloudInstanceGroup, knownGood string, failing string, sleepAfterTerminate time.Duration, err error) error {
+	name := group.InstanceGroup.ObjectMeta.Name
+	klog.Errorf("Rolling back InstanceGroup %q to template %s: %v", name, knownGood, err)
+
+	cloud := c.Cloud.(fi.InstanceTemplateCloud)
+	if setErr := cloud.SetGroupTemplate(group, knownGood); setErr != nil {
+		return fmt.Errorf("%w; error rolling back InstanceGroup %q: %v", err, name, setErr)
+	}
+
+	var nodes []corev1.Node
+	if !c.CloudOnly {
+		list, listErr := c.K8sClient.CoreV1().Nodes().List(c.Ctx, metav1.ListOptions{})
+		if listErr != nil {
+			return fmt.Errorf("%w; error rolling back InstanceGroup %q: error listing nodes: %v", err, name, listErr)
+		}
+		nodes = list.Items
+	}
+	groups, getErr := c.Cloud.GetCloudGroups(c.Cluster, []*api.InstanceGroup{group.InstanceGroup}, false, nodes)
+	if getErr != nil {
+		return fmt.Errorf("%w; error rolling back InstanceGroup %q: %v", err, name, getErr)
+	}
+	rolledBack := groups[name]
+	if rolledBack == nil {
+		return fmt.Errorf("%w; error rolling back InstanceGroup %q: cloud resources not found", err, name)
+	}
+
+	// Instances created from neither template are left for a later rolling update
+	var update []*cloudinstances.CloudInstance
+	var replaced []string
+	for _, u := range rolledBack.NeedUpdate {
+		template, templateErr := cloud.GetInstanceTemplate(u)
+		if templateErr != nil {
+			return fmt.Errorf("%w; error rolling back InstanceGroup %q: %v", err, name, templateErr)
+		}
+		if template == failing || u.Status == cloudinstances.CloudInstanceStatusDetached {
+			update = append(update, u)
+			replaced = append(replaced, u.ID)
+		} else {
+			rolledBack.Ready = append(rolledBack.Ready, u)
+		}
+	}
+	rolledBack.NeedUpdate = update
+
+	if rollErr := c.updateInstanceGroup(rolledBack, sleepAfterTerminate, true); rollErr != nil {
+		return fmt.Errorf("%w; error rolling back InstanceGroup %q to template %s: %v", err, name, knownGood, rollErr)
+	}
+
+	c.Progress.RolledBack(name, knownGood, replaced)
+	if len(replaced) == 0 {
+		return fmt.Errorf("%w; rolled back InstanceGroup %q to template %s", err, name, knownGood)
+	}
+	return fmt.Errorf("%w; rolled back InstanceGroup %q to template %s, replacing instances %s", err, name, knownGood, strings.Join(replaced, ", "))
+}
@@ -0,0 +1,247 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instancegroups
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	kopsapi "k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/pkg/cloudinstances"
+	"k8s.io/kops/pkg/validation"
+	"k8s.io/kops/upup/pkg/fi"
+	"k8s.io/kops/upup/pkg/fi/cloudup/awsup"
+)
+
+// templateCloud is a single instance group that replaces each deleted instance with one created from
+// the group's template version.
+type templateCloud struct {
+	*awsup.MockAWSCloud
+
+	mutex     sync.Mutex
+	template  string
+	instances map[string]string
+	created   int
+}
+
+var _ fi.InstanceTemplateCloud = &templateCloud{}
+
+func (c *templateCloud) GetGroupTemplate(g *cloudinstances.CloudInstanceGroup) (string, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.template, nil
+}
+
+func (c *templateCloud) GetInstanceTemplate(i *cloudinstances.CloudInstance) (string, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.instances[i.ID], nil
+}
+
+func (c *templateCloud) SetGroupTemplate(g *cloudinstances.CloudInstanceGroup, template string) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.template = template
+	return nil
+}
+
+func (c *templateCloud) DeleteInstance(i *cloudinstances.CloudInstance) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	delete(c.instances, i.ID)
+	c.created++
+	c.instances[fmt.Sprintf("new-%d", c.created)] = c.template
+	return nil
+}
+
+func (c *templateCloud) GetCloudGroups(cluster *kopsapi.Cluster, instancegroups []*kopsapi.InstanceGroup, warnUnmatched bool, nodes []v1.Node) (map[string]*cloudinstances.CloudInstanceGroup, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	group := &cloudinstances.CloudInstanceGroup{
+		HumanName:     instancegroups[0].Name,
+		InstanceGroup: instancegroups[0],
+		Raw:           &autoscaling.Group{},
+	}
+	for _, id := range c.instanceIDs() {
+		var node *v1.Node
+		for i := range nodes {
+			if nodes[i].Name == id+".local" {
+				node = &nodes[i]
+			}
+		}
+		status := cloudinstances.CloudInstanceStatusUpToDate
+		if c.instances[id] != c.template {
+			status = cloudinstances.CloudInstanceStatusNeedsUpdate
+		}
+		if _, err := group.NewCloudInstance(id, status, node); err != nil {
+			return nil, err
+		}
+	}
+	return map[string]*cloudinstances.CloudInstanceGroup{group.InstanceGroup.Name: group}, nil
+}
+
+func (c *templateCloud) instanceIDs() []string {
+	var ids []string
+	for id := range c.instances {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Instances returns the instances of the group, by template version.
+func (c *templateCloud) Instances() map[string][]string {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	result := make(map[string][]string)
+	for _, id := range c.instanceIDs() {
+		result[c.instances[id]] = append(result[c.instances[id]], id)
+	}
+	return result
+}
+
+// templateClusterValidator fails validation while any instance was created from a bad template version.
+type templateClusterValidator struct {
+	cloud *templateCloud
+	bad   string
+}
+
+func (v *templateClusterValidator) Validate() (*validation.ValidationCluster, error) {
+	result := &validation.ValidationCluster{}
+	for _, id := range v.cloud.Instances()[v.bad] {
+		result.Failures = append(result.Failures, &validation.ValidationError{
+			Kind:    "Machine",
+			Name:    id,
+			Message: fmt.Sprintf("machine %q has not yet joined cluster", id),
+		})
+	}
+	return result, nil
+}
+
+func getRollbackTestSetup(t *testing.T, bad string) (*RollingUpdateCluster, *templateCloud, map[string]*cloudinstances.CloudInstanceGroup) {
+	c, mockcloud := getTestSetup()
+	cloud := &templateCloud{
+		MockAWSCloud: mockcloud,
+		template:     "lt-1:2",
+		instances: map[string]string{
+			"node-1a": "lt-1:1",
+			"node-1b": "lt-1:1",
+			"node-1c": "lt-1:1",
+		},
+	}
+	c.Cloud = cloud
+	c.ClusterValidator = &templateClusterValidator{cloud: cloud, bad: bad}
+	c.Cluster.Spec.RollingUpdate = &kopsapi.RollingUpdate{
+		OnFailure: kopsapi.RollingUpdateFailurePolicyRollback,
+	}
+
+	progress, err := NewProgressRecorder(context.Background(), nil, nil, &RollingUpdateProgress{})
+	require.NoError(t, err)
+	c.Progress = progress
+
+	groups := make(map[string]*cloudinstances.CloudInstanceGroup)
+	makeGroup(groups, c.K8sClient, mockcloud, "node-1", kopsapi.InstanceGroupRoleNode, 3, 3)
+	return c, cloud, groups
+}
+
+func TestRollingUpdateRollsBackFailingTemplate(t *testing.T) {
+	c, cloud, groups := getRollbackTestSetup(t, "lt-1:2")
+	var events []ProgressEvent
+	c.Progress.Observe(func(progress RollingUpdateProgress, event ProgressEvent) {
+		if event.Reason == "InstanceGroupRolledBack" {
+			events = append(events, event)
+		}
+	})
+
+	err := c.RollingUpdate(groups, &kopsapi.InstanceGroupList{})
+	require.Error(t, err, "rolling update")
+	assert.ErrorIs(t, err, &ValidationTimeoutError{})
+	assert.Contains(t, err.Error(), `rolled back InstanceGroup "node-1" to template lt-1:1, replacing instances new-1`)
+
+	assert.Equal(t, "lt-1:1", cloud.template, "group template")
+	assert.Equal(t, map[string][]string{"lt-1:1": {"new-2", "node-1b", "node-1c"}}, cloud.Instances(), "instances by template")
+	require.Len(t, events, 1)
+	assert.True(t, events[0].Warning)
+	assert.Equal(t, "Rolled back instance group node-1 to template lt-1:1, replacing instances new-1", events[0].Message)
+
+	progress := c.Progress.Progress()
+	assert.Empty(t, progress.CompletedGroups, "rolled back group is not completed")
+	assert.Equal(t, map[string]string{"node-1": "lt-1:1"}, progress.KnownGoodTemplates)
+}
+
+func TestRollingUpdateRollsBackToKnownGoodTemplate(t *testing.T) {
+	c, cloud, groups := getRollbackTestSetup(t, "lt-1:3")
+	cloud.template = "lt-1:3"
+	c.Progress.TemplateKnownGood("node-1", "lt-1:2")
+
+	err := c.RollingUpdate(groups, &kopsapi.InstanceGroupList{})
+	require.Error(t, err, "rolling update")
+
+	assert.Equal(t, "lt-1:2", cloud.template, "group template")
+	assert.Equal(t, map[string][]string{
+		"lt-1:1": {"node-1b", "node-1c"},
+		"lt-1:2": {"new-2"},
+	}, cloud.Instances(), "instances by template")
+}
+
+func TestRollingUpdateRecordsKnownGoodTemplate(t *testing.T) {
+	c, cloud, groups := getRollbackTestSetup(t, "lt-1:0")
+
+	err := c.RollingUpdate(groups, &kopsapi.InstanceGroupList{})
+	require.NoError(t, err, "rolling update")
+
+	assert.Equal(t, map[string][]string{"lt-1:2": {"new-1", "new-2", "new-3"}}, cloud.Instances(), "instances by template")
+	progress := c.Progress.Progress()
+	assert.Equal(t, []string{"node-1"}, progress.CompletedGroups)
+	assert.Equal(t, map[string]string{"node-1": "lt-1:2"}, progress.KnownGoodTemplates)
+}
+
+func TestRollingUpdateDoesNotRecordTemplateThatFailedValidation(t *testing.T) {
+	c, cloud, groups := getRollbackTestSetup(t, "lt-1:2")
+	c.Cluster.Spec.RollingUpdate = nil
+	c.FailOnValidate = false
+
+	err := c.RollingUpdate(groups, &kopsapi.InstanceGroupList{})
+	require.NoError(t, err, "rolling update")
+
+	assert.Equal(t, map[string][]string{"lt-1:2": {"new-1", "new-2", "new-3"}}, cloud.Instances(), "instances by template")
+	progress := c.Progress.Progress()
+	assert.Equal(t, []string{"node-1"}, progress.CompletedGroups)
+	assert.Empty(t, progress.KnownGoodTemplates, "a template is not known to be good if the cluster did not validate")
+}
+
+func TestRollingUpdateStopsOnFailure(t *testing.T) {
+	c, cloud, groups := getRollbackTestSetup(t, "lt-1:2")
+	c.Cluster.Spec.RollingUpdate = nil
+
+	err := c.RollingUpdate(groups, &kopsapi.InstanceGroupList{})
+	require.Error(t, err, "rolling update")
+	assert.NotContains(t, err.Error(), "rolled back")
+
+	assert.Equal(t, "lt-1:2", cloud.template, "group template")
+	assert.Equal(t, map[string][]string{
+		"lt-1:1": {"node-1b", "node-1c"},
+		"lt-1:2": {"new-1"},
+	}, cloud.Instances(), "instances by template")
+}
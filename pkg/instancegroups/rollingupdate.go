@@ -22,6 +22,7 @@ import (
 	"fmt"
 	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"k8s.io/apimachinery/pkg/util/errors"
@@ -33,6 +34,7 @@ import (
 	"k8s.io/kops/pkg/cloudinstances"
 	"k8s.io/kops/pkg/validation"
 	"k8s.io/kops/upup/pkg/fi"
+	"k8s.io/kops/util/pkg/vfs"
 )
 
 // RollingUpdateCluster is a struct containing cluster information for a rolling update.
@@ -85,6 +87,30 @@ type RollingUpdateCluster struct {
 
 	// Options holds user-specified options
 	Options RollingUpdateOptions
+
+	// Progress records the progress of the rolling update in the state store, if set.
+	// Instance groups it records as completed are skipped, so that an interrupted rolling update can be resumed.
+	Progress *ProgressRecorder
+
+	// PausePath is the location of the pause flag in the state store. While it exists, the rolling update
+	// holds before draining and terminating another instance. Unused if nil.
+	PausePath vfs.Path
+	// Paused holds the rolling update before draining and terminating another instance while it is set.
+	Paused atomic.Bool
+	// WaitForMaintenanceWindow waits for the next maintenance window, rather than stopping, when an instance group is outside of them.
+	WaitForMaintenanceWindow bool
+
+	// InstanceHooks are run around the replacement of each instance, before those configured
+	// for the instance group or cluster.
+	InstanceHooks []api.RollingUpdateHook
+	// DisableBlueGreen updates instance groups with the BlueGreen strategy instance by instance,
+	// for callers that may not create cloud groups.
+	DisableBlueGreen bool
+	// DisableExecHooks skips the instance hooks that run a command, for callers that run inside the cluster,
+	// where the commands are not available.
+	DisableExecHooks bool
+	// AllowSpecExecHooks runs the instance hooks configured in the cluster and instance group specs that run a command.
+	AllowSpecExecHooks bool
 }
 
 type RollingUpdateOptions struct {
@@ -107,6 +133,12 @@ func (*RollingUpdateCluster) AdjustNeedUpdate(groups map[string]*cloudinstances.
 
 // RollingUpdate performs a rolling update on a K8s Cluster.
 func (c *RollingUpdateCluster) RollingUpdate(groups map[string]*cloudinstances.CloudInstanceGroup, instanceGroups *api.InstanceGroupList) error {
+	err := c.rollingUpdate(groups, instanceGroups)
+	c.Progress.Finished(err)
+	return err
+}
+
+func (c *RollingUpdateCluster) rollingUpdate(groups map[string]*cloudinstances.CloudInstanceGroup, instanceGroups *api.InstanceGroupList) error {
 	if len(groups) == 0 {
 		klog.Info("Cloud Instance Group length is zero. Not doing a rolling-update.")
 		return nil
@@ -120,6 +152,10 @@ func (c *RollingUpdateCluster) RollingUpdate(groups map[string]*cloudinstances.C
 	nodeGroups := make(map[string]*cloudinstances.CloudInstanceGroup)
 	bastionGroups := make(map[string]*cloudinstances.CloudInstanceGroup)
 	for k, group := range groups {
+		if c.Progress.IsGroupCompleted(group.InstanceGroup.ObjectMeta.Name) {
+			klog.Infof("Skipping InstanceGroup %q, which was updated before the rolling update was resumed.", group.InstanceGroup.ObjectMeta.Name)
+			continue
+		}
 		switch group.InstanceGroup.Spec.Role {
 		case api.InstanceGroupRoleNode:
 			nodeGroups[k] = group
@@ -161,7 +197,7 @@ func (c *RollingUpdateCluster) RollingUpdate(groups map[string]*cloudinstances.C
 	// Do not continue update if bastion(s) failed
 	for _, err := range results {
 		if err != nil {
-			return fmt.Errorf("bastion not healthy after update, stopping rolling-update: %q", err)
+			return fmt.Errorf("bastion not healthy after update, stopping rolling-update: %w", err)
 		}
 	}
 
@@ -175,7 +211,7 @@ func (c *RollingUpdateCluster) RollingUpdate(groups map[string]*cloudinstances.C
 			err := c.rollingUpdateInstanceGroup(masterGroups[k], c.MasterInterval)
 			// Do not continue update if control-plane node(s) failed; cluster is potentially in an unhealthy state.
 			if err != nil {
-				return fmt.Errorf("control-plane node not healthy after update, stopping rolling-update: %q", err)
+				return fmt.Errorf("control-plane node not healthy after update, stopping rolling-update: %w", err)
 			}
 		}
 	}
@@ -921,14 +921,25 @@ func TestRollingUpdateDisabled(t *testing.T) {
 		DrainAndTerminate: fi.PtrTo(false),
 	}
 
+	r, err := NewProgressRecorder(context.Background(), nil, nil, &RollingUpdateProgress{Cluster: "test.k8s.local"})
+	if err != nil {
+		t.Fatalf("error building progress recorder: %v", err)
+	}
+	c.Progress = r
+
 	groups := getGroupsAllNeedUpdate(c.K8sClient, cloud)
-	err := c.RollingUpdate(groups, &kopsapi.InstanceGroupList{})
+	err = c.RollingUpdate(groups, &kopsapi.InstanceGroupList{})
 	assert.NoError(t, err, "rolling update")
 
 	assertGroupInstanceCount(t, cloud, "node-1", 3)
 	assertGroupInstanceCount(t, cloud, "node-2", 3)
 	assertGroupInstanceCount(t, cloud, "master-1", 2)
 	assertGroupInstanceCount(t, cloud, "bastion-1", 1)
+
+	// The groups were not rolled, so a later rolling update must not skip them
+	for _, name := range []string{"node-1", "node-2", "master-1", "bastion-1"} {
+		assert.False(t, r.IsGroupCompleted(name), "group %s should not be recorded as completed", name)
+	}
 }
 
 type disabledSurgeTest struct {
@@ -40,15 +40,37 @@ func resolveSettings(cluster *kops.Cluster, group *kops.InstanceGroup, numInstan
 		if rollingUpdate.MaxSurge == nil {
 			rollingUpdate.MaxSurge = def.MaxSurge
 		}
+		if rollingUpdate.MaintenanceWindows == nil {
+			rollingUpdate.MaintenanceWindows = def.MaintenanceWindows
+		}
+		if rollingUpdate.InstanceHooks == nil {
+			rollingUpdate.InstanceHooks = def.InstanceHooks
+		}
+		if rollingUpdate.Strategy == "" {
+			rollingUpdate.Strategy = def.Strategy
+		}
+		if rollingUpdate.OnFailure == "" {
+			rollingUpdate.OnFailure = def.OnFailure
+		}
 	}
 
+	rollingUpdate.Drain = resolveDrainSettings(rollingUpdate.Drain, cluster.Spec.RollingUpdate)
+
 	if rollingUpdate.DrainAndTerminate == nil {
 		rollingUpdate.DrainAndTerminate = fi.PtrTo(true)
 	}
 
+	if rollingUpdate.Strategy == "" {
+		rollingUpdate.Strategy = kops.RollingUpdateStrategyRollingUpdate
+	}
+
+	if rollingUpdate.OnFailure == "" {
+		rollingUpdate.OnFailure = kops.RollingUpdateFailurePolicyStop
+	}
+
 	if rollingUpdate.MaxSurge == nil {
 		val := intstr.FromInt(0)
-		if cluster.Spec.GetCloudProvider() == kops.CloudProviderAWS && !featureflag.Spotinst.Enabled() && group.Spec.Manager != kops.InstanceManagerKarpenter {
+		if supportsSurge(cluster) && group.Spec.Manager != kops.InstanceManagerKarpenter {
 			val = intstr.FromInt(1)
 		}
 		rollingUpdate.MaxSurge = &val
@@ -80,3 +102,54 @@ func resolveSettings(cluster *kops.Cluster, group *kops.InstanceGroup, numInstan
 
 	return rollingUpdate
 }
+
+// resolveDrainSettings returns the drain settings of an instance group, with those it does not set
+// taken from the cluster's rolling update settings def or defaulted.
+func resolveDrainSettings(group *kops.RollingUpdateDrain, def *kops.RollingUpdate) *kops.RollingUpdateDrain {
+	drain := &kops.RollingUpdateDrain{}
+	if group != nil {
+		drain = group.DeepCopy()
+	}
+
+	if def != nil && def.Drain != nil {
+		if drain.SkipPodSelector == "" {
+			drain.SkipPodSelector = def.Drain.SkipPodSelector
+		}
+		if drain.GracePeriodSeconds == nil {
+			drain.GracePeriodSeconds = def.Drain.GracePeriodSeconds
+		}
+		if drain.ForceUnmanagedPods == nil {
+			drain.ForceUnmanagedPods = def.Drain.ForceUnmanagedPods
+		}
+		if drain.DeleteEmptyDirData == nil {
+			drain.DeleteEmptyDirData = def.Drain.DeleteEmptyDirData
+		}
+		if drain.DisableEvictionAfter == nil {
+			drain.DisableEvictionAfter = def.Drain.DisableEvictionAfter
+		}
+		if drain.PriorityNamespaces == nil {
+			drain.PriorityNamespaces = def.Drain.PriorityNamespaces
+		}
+	}
+
+	if drain.ForceUnmanagedPods == nil {
+		drain.ForceUnmanagedPods = fi.PtrTo(true)
+	}
+	if drain.DeleteEmptyDirData == nil {
+		drain.DeleteEmptyDirData = fi.PtrTo(true)
+	}
+
+	return drain
+}
+
+// supportsSurge returns whether the cloud provider of a cluster can detach instances from their groups.
+func supportsSurge(cluster *kops.Cluster) bool {
+	switch cluster.Spec.GetCloudProvider() {
+	case kops.CloudProviderAWS:
+		return !featureflag.Spotinst.Enabled()
+	case kops.CloudProviderGCE, kops.CloudProviderAzure, kops.CloudProviderOpenstack:
+		return true
+	default:
+		return false
+	}
+}
@@ -20,10 +20,13 @@ import (
 	"fmt"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/upup/pkg/fi"
 )
 
 func TestSettings(t *testing.T) {
@@ -223,3 +226,227 @@ func TestAWSDefault(t *testing.T) {
 	assert.Equal(t, intstr.Int, resolved.MaxUnavailable.Type)
 	assert.Equal(t, int32(0), resolved.MaxUnavailable.IntVal)
 }
+
+func TestMaxSurgeDefault(t *testing.T) {
+	for _, tc := range []struct {
+		name          string
+		cloudProvider kops.CloudProviderSpec
+		manager       kops.InstanceManager
+		expected      int32
+	}{
+		{
+			name:          "aws",
+			cloudProvider: kops.CloudProviderSpec{AWS: &kops.AWSSpec{}},
+			expected:      1,
+		},
+		{
+			name:          "aws karpenter",
+			cloudProvider: kops.CloudProviderSpec{AWS: &kops.AWSSpec{}},
+			manager:       kops.InstanceManagerKarpenter,
+			expected:      0,
+		},
+		{
+			name:          "gce",
+			cloudProvider: kops.CloudProviderSpec{GCE: &kops.GCESpec{}},
+			expected:      1,
+		},
+		{
+			name:          "azure",
+			cloudProvider: kops.CloudProviderSpec{Azure: &kops.AzureSpec{}},
+			expected:      1,
+		},
+		{
+			name:          "openstack",
+			cloudProvider: kops.CloudProviderSpec{Openstack: &kops.OpenstackSpec{}},
+			expected:      1,
+		},
+		{
+			name:          "digitalocean",
+			cloudProvider: kops.CloudProviderSpec{DO: &kops.DOSpec{}},
+			expected:      0,
+		},
+		{
+			name:          "hetzner",
+			cloudProvider: kops.CloudProviderSpec{Hetzner: &kops.HetznerSpec{}},
+			expected:      0,
+		},
+		{
+			name:          "scaleway",
+			cloudProvider: kops.CloudProviderSpec{Scaleway: &kops.ScalewaySpec{}},
+			expected:      0,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			cluster := &kops.Cluster{
+				Spec: kops.ClusterSpec{
+					CloudProvider: tc.cloudProvider,
+				},
+			}
+			ig := &kops.InstanceGroup{
+				Spec: kops.InstanceGroupSpec{
+					Manager: tc.manager,
+				},
+			}
+			resolved := resolveSettings(cluster, ig, 10)
+			assert.Equal(t, intstr.Int, resolved.MaxSurge.Type)
+			assert.Equal(t, tc.expected, resolved.MaxSurge.IntVal)
+		})
+	}
+}
+
+func TestMaintenanceWindows(t *testing.T) {
+	clusterWindows := []kops.MaintenanceWindow{{Days: []string{"Sat"}, Start: "02:00", End: "06:00"}}
+	groupWindows := []kops.MaintenanceWindow{{Start: "22:00", End: "23:00"}}
+
+	cluster := &kops.Cluster{
+		Spec: kops.ClusterSpec{
+			RollingUpdate: &kops.RollingUpdate{
+				MaintenanceWindows: clusterWindows,
+			},
+		},
+	}
+
+	resolved := resolveSettings(&kops.Cluster{}, &kops.InstanceGroup{}, 1)
+	assert.Empty(t, resolved.MaintenanceWindows, "no windows")
+
+	resolved = resolveSettings(cluster, &kops.InstanceGroup{}, 1)
+	assert.Equal(t, clusterWindows, resolved.MaintenanceWindows, "cluster windows")
+
+	resolved = resolveSettings(cluster, &kops.InstanceGroup{
+		Spec: kops.InstanceGroupSpec{
+			RollingUpdate: &kops.RollingUpdate{
+				MaintenanceWindows: groupWindows,
+			},
+		},
+	}, 1)
+	assert.Equal(t, groupWindows, resolved.MaintenanceWindows, "group windows replace cluster windows")
+}
+
+func TestInstanceHooks(t *testing.T) {
+	clusterHooks := []kops.RollingUpdateHook{{Name: "cluster", Exec: &kops.ExecRollingUpdateHook{Command: []string{"true"}}}}
+	groupHooks := []kops.RollingUpdateHook{{Name: "group", Webhook: &kops.WebhookRollingUpdateHook{URL: "https://example.com"}}}
+
+	cluster := &kops.Cluster{
+		Spec: kops.ClusterSpec{
+			RollingUpdate: &kops.RollingUpdate{
+				InstanceHooks: clusterHooks,
+			},
+		},
+	}
+
+	resolved := resolveSettings(&kops.Cluster{}, &kops.InstanceGroup{}, 1)
+	assert.Empty(t, resolved.InstanceHooks, "no hooks")
+
+	resolved = resolveSettings(cluster, &kops.InstanceGroup{}, 1)
+	assert.Equal(t, clusterHooks, resolved.InstanceHooks, "cluster hooks")
+
+	resolved = resolveSettings(cluster, &kops.InstanceGroup{
+		Spec: kops.InstanceGroupSpec{
+			RollingUpdate: &kops.RollingUpdate{
+				InstanceHooks: groupHooks,
+			},
+		},
+	}, 1)
+	assert.Equal(t, groupHooks, resolved.InstanceHooks, "group hooks replace cluster hooks")
+}
+
+func TestStrategy(t *testing.T) {
+	resolved := resolveSettings(&kops.Cluster{}, &kops.InstanceGroup{}, 1)
+	assert.Equal(t, kops.RollingUpdateStrategyRollingUpdate, resolved.Strategy, "default")
+
+	cluster := &kops.Cluster{
+		Spec: kops.ClusterSpec{
+			RollingUpdate: &kops.RollingUpdate{
+				Strategy: kops.RollingUpdateStrategyBlueGreen,
+			},
+		},
+	}
+	resolved = resolveSettings(cluster, &kops.InstanceGroup{}, 1)
+	assert.Equal(t, kops.RollingUpdateStrategyBlueGreen, resolved.Strategy, "cluster strategy")
+
+	resolved = resolveSettings(cluster, &kops.InstanceGroup{
+		Spec: kops.InstanceGroupSpec{
+			RollingUpdate: &kops.RollingUpdate{
+				Strategy: kops.RollingUpdateStrategyRollingUpdate,
+			},
+		},
+	}, 1)
+	assert.Equal(t, kops.RollingUpdateStrategyRollingUpdate, resolved.Strategy, "group strategy overrides cluster strategy")
+}
+
+func TestOnFailure(t *testing.T) {
+	resolved := resolveSettings(&kops.Cluster{}, &kops.InstanceGroup{}, 1)
+	assert.Equal(t, kops.RollingUpdateFailurePolicyStop, resolved.OnFailure, "default")
+
+	cluster := &kops.Cluster{
+		Spec: kops.ClusterSpec{
+			RollingUpdate: &kops.RollingUpdate{
+				OnFailure: kops.RollingUpdateFailurePolicyRollback,
+			},
+		},
+	}
+	resolved = resolveSettings(cluster, &kops.InstanceGroup{}, 1)
+	assert.Equal(t, kops.RollingUpdateFailurePolicyRollback, resolved.OnFailure, "cluster policy")
+
+	resolved = resolveSettings(cluster, &kops.InstanceGroup{
+		Spec: kops.InstanceGroupSpec{
+			RollingUpdate: &kops.RollingUpdate{
+				OnFailure: kops.RollingUpdateFailurePolicyStop,
+			},
+		},
+	}, 1)
+	assert.Equal(t, kops.RollingUpdateFailurePolicyStop, resolved.OnFailure, "group policy overrides cluster policy")
+}
+
+func TestDrainSettings(t *testing.T) {
+	resolved := resolveSettings(&kops.Cluster{}, &kops.InstanceGroup{}, 1)
+	assert.Equal(t, &kops.RollingUpdateDrain{
+		ForceUnmanagedPods: fi.PtrTo(true),
+		DeleteEmptyDirData: fi.PtrTo(true),
+	}, resolved.Drain, "default")
+
+	clusterDrain := &kops.RollingUpdateDrain{
+		SkipPodSelector:    "app=cache",
+		GracePeriodSeconds: fi.PtrTo(int64(30)),
+		DeleteEmptyDirData: fi.PtrTo(false),
+		PriorityNamespaces: []string{"ingress"},
+	}
+	cluster := &kops.Cluster{
+		Spec: kops.ClusterSpec{
+			RollingUpdate: &kops.RollingUpdate{
+				Drain: clusterDrain,
+			},
+		},
+	}
+	resolved = resolveSettings(cluster, &kops.InstanceGroup{}, 1)
+	assert.Equal(t, &kops.RollingUpdateDrain{
+		SkipPodSelector:    "app=cache",
+		GracePeriodSeconds: fi.PtrTo(int64(30)),
+		ForceUnmanagedPods: fi.PtrTo(true),
+		DeleteEmptyDirData: fi.PtrTo(false),
+		PriorityNamespaces: []string{"ingress"},
+	}, resolved.Drain, "cluster drain settings")
+
+	group := &kops.InstanceGroup{
+		Spec: kops.InstanceGroupSpec{
+			RollingUpdate: &kops.RollingUpdate{
+				Drain: &kops.RollingUpdateDrain{
+					GracePeriodSeconds:   fi.PtrTo(int64(600)),
+					ForceUnmanagedPods:   fi.PtrTo(false),
+					DisableEvictionAfter: &metav1.Duration{Duration: time.Hour},
+				},
+			},
+		},
+	}
+	groupCopy := group.DeepCopy()
+	resolved = resolveSettings(cluster, group, 1)
+	assert.Equal(t, &kops.RollingUpdateDrain{
+		SkipPodSelector:      "app=cache",
+		GracePeriodSeconds:   fi.PtrTo(int64(600)),
+		ForceUnmanagedPods:   fi.PtrTo(false),
+		DeleteEmptyDirData:   fi.PtrTo(false),
+		DisableEvictionAfter: &metav1.Duration{Duration: time.Hour},
+		PriorityNamespaces:   []string{"ingress"},
+	}, resolved.Drain, "group drain settings override cluster drain settings one by one")
+	assert.Equal(t, groupCopy, group, "instancegroup not modified")
+}
@@ -232,6 +232,7 @@ func (b *IAMModelBuilder) buildIAMRolePolicy(role iam.Subject, iamName string, i
 			Role:                                  role,
 			Region:                                b.Region,
 			Partition:                             b.AWSPartition,
+			StateEncryptionKMSKeys:                b.StateEncryptionKMSKeys,
 			UseServiceAccountExternalPermisssions: b.UseServiceAccountExternalPermissions(),
 		},
 	}
@@ -38,6 +38,8 @@ import (
 
 	"k8s.io/kops/pkg/apis/kops"
 	"k8s.io/kops/pkg/apis/kops/model"
+	"k8s.io/kops/pkg/apis/kops/registry"
+	"k8s.io/kops/pkg/featureflag"
 	"k8s.io/kops/pkg/util/stringorslice"
 	"k8s.io/kops/upup/pkg/fi"
 	"k8s.io/kops/upup/pkg/fi/cloudup/awstasks"
@@ -311,6 +313,7 @@ type PolicyBuilder struct {
 	Partition                             string
 	ResourceARN                           *string
 	Role                                  Subject
+	StateEncryptionKMSKeys                []string
 	UseServiceAccountExternalPermisssions bool
 }
 
@@ -361,6 +364,8 @@ func (r *NodeRoleAPIServer) BuildAWSPolicy(b *PolicyBuilder) (*Policy, error) {
 		addKMSIAMPolicies(p, stringorslice.Slice(b.KMSKeys))
 	}
 
+	addStateEncryptionKMSPolicies(p, b.StateEncryptionKMSKeys)
+
 	if b.Cluster.Spec.IAM != nil && b.Cluster.Spec.IAM.AllowContainerRegistry {
 		addECRPermissions(p)
 	}
@@ -393,6 +398,10 @@ func (r *NodeRoleMaster) BuildAWSPolicy(b *PolicyBuilder) (*Policy, error) {
 		addKopsControllerIPAMPermissions(p)
 	}
 
+	if featureflag.InClusterRollingUpdate.Enabled() {
+		addKopsControllerRollingUpdatePermissions(p)
+	}
+
 	var err error
 	if p, err = b.AddS3Permissions(p); err != nil {
 		return nil, fmt.Errorf("failed to generate AWS IAM S3 access statements: %v", err)
@@ -402,6 +411,8 @@ func (r *NodeRoleMaster) BuildAWSPolicy(b *PolicyBuilder) (*Policy, error) {
 		addKMSIAMPolicies(p, stringorslice.Slice(b.KMSKeys))
 	}
 
+	addStateEncryptionKMSPolicies(p, b.StateEncryptionKMSKeys)
+
 	// Protokube needs dns-controller permissions in instance role even if UseServiceAccountExternalPermissions.
 	AddDNSControllerPermissions(b, p)
 
@@ -472,6 +483,11 @@ func (r *NodeRoleNode) BuildAWSPolicy(b *PolicyBuilder) (*Policy, error) {
 		}
 	}
 
+	// nodeup decrypts the keys and secrets it reads from the state store
+	if !model.UseKopsControllerForNodeBootstrap(b.Cluster) {
+		addStateEncryptionKMSPolicies(p, b.StateEncryptionKMSKeys)
+	}
+
 	if b.Cluster.Spec.IAM != nil && b.Cluster.Spec.IAM.AllowContainerRegistry {
 		addECRPermissions(p)
 	}
@@ -606,6 +622,27 @@ func (b *PolicyBuilder) AddS3Permissions(p *Policy) (*Policy, error) {
 		}
 	}
 
+	// kops-controller takes the lock of the cluster while it performs rolling updates
+	if _, ok := b.Role.(*NodeRoleMaster); ok && featureflag.InClusterRollingUpdate.Enabled() && b.Cluster.Spec.ConfigStore != "" {
+		configBase, err := vfs.Context.BuildVfsPath(b.Cluster.Spec.ConfigStore)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse VFS path %q: %v", b.Cluster.Spec.ConfigStore, err)
+		}
+		if path, ok := configBase.Join(registry.PathLock).(*vfs.S3Path); ok {
+			p.Statement = append(p.Statement, &Statement{
+				Effect: StatementEffectAllow,
+				Action: stringorslice.Slice([]string{
+					"s3:GetObject",
+					"s3:PutObject",
+				}),
+				Resource: stringorslice.Of(
+					fmt.Sprintf("arn:%v:s3:::%v/%v", p.partition, path.Bucket(), path.Key()),
+				),
+			})
+			s3Buckets.Insert(path.Bucket())
+		}
+	}
+
 	// We need some permissions on the buckets themselves
 	for _, s3Bucket := range s3Buckets.List() {
 		p.Statement = append(p.Statement, &Statement{
@@ -708,7 +745,9 @@ func ReadableStatePaths(cluster *kops.Cluster, role Subject) ([]string, error) {
 		}
 		if !model.UseKopsControllerForNodeBootstrap(cluster) {
 			paths = append(paths,
+				"/secrets/.encryption",
 				"/secrets/dockerconfig",
+				"/pki/.encryption",
 				"/pki/private/kube-proxy/*",
 			)
 
@@ -843,6 +882,27 @@ func addKopsControllerIPAMPermissions(p *Policy) {
 	)
 }
 
+// addKopsControllerRollingUpdatePermissions allows kops-controller to replace instances during a rolling update.
+func addKopsControllerRollingUpdatePermissions(p *Policy) {
+	p.unconditionalAction.Insert(
+		"autoscaling:DescribeAutoScalingGroups",
+		"autoscaling:DescribeWarmPool",
+		"ec2:DescribeInstances",
+		"ec2:DescribeLaunchTemplateVersions",
+		"ec2:DescribeLaunchTemplates",
+		"elasticloadbalancing:DescribeLoadBalancers",
+		"elasticloadbalancing:DescribeTargetGroups",
+	)
+	p.clusterTaggedAction.Insert(
+		"autoscaling:DetachInstances",
+		"autoscaling:UpdateAutoScalingGroup", // rollback.go
+		"ec2:CreateTags",
+		"ec2:TerminateInstances",
+		"elasticloadbalancing:DeregisterInstancesFromLoadBalancer",
+		"elasticloadbalancing:DeregisterTargets",
+	)
+}
+
 func addEtcdManagerPermissions(p *Policy) {
 	p.unconditionalAction.Insert(
 		"ec2:DescribeVolumes", // aws.go
@@ -1169,6 +1229,18 @@ func addKMSIAMPolicies(p *Policy, resource stringorslice.StringOrSlice) {
 	)
 }
 
+// addStateEncryptionKMSPolicies allows the decryption of the data keys of the encrypted key and secret stores.
+func addStateEncryptionKMSPolicies(p *Policy, keys []string) {
+	if len(keys) == 0 {
+		return
+	}
+	p.Statement = append(p.Statement, &Statement{
+		Effect:   StatementEffectAllow,
+		Action:   stringorslice.Of("kms:Decrypt"),
+		Resource: stringorslice.Slice(keys),
+	})
+}
+
 func addKMSGenerateRandomPolicies(p *Policy) {
 	// For nodeup to seed the instance's random number generator.
 	p.unconditionalAction.Insert(
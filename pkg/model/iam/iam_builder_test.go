@@ -265,3 +265,43 @@ func TestEmptyPolicy(t *testing.T) {
 		t.Errorf("empty policy should result in empty string, but was %q", policy)
 	}
 }
+
+func TestStateEncryptionKMSPolicy(t *testing.T) {
+	keyARN := "arn:aws:kms:us-east-1:123456789012:key/1234abcd-12ab-34cd-56ef-1234567890ab"
+
+	grid := []struct {
+		Role     Subject
+		Expected bool
+	}{
+		{Role: &NodeRoleMaster{}, Expected: true},
+		{Role: &NodeRoleAPIServer{}, Expected: true},
+		// Nodes are bootstrapped by kops-controller, so they do not read the stores
+		{Role: &NodeRoleNode{}, Expected: false},
+		{Role: &NodeRoleBastion{}, Expected: false},
+	}
+	for _, g := range grid {
+		cluster := testutils.BuildMinimalCluster("encrypted.example.com")
+		cluster.Spec.ConfigBase = "s3://kops-tests/encrypted.example.com"
+
+		b := &PolicyBuilder{
+			Cluster:                cluster,
+			Role:                   g.Role,
+			Partition:              "aws",
+			StateEncryptionKMSKeys: []string{keyARN},
+		}
+		policy, err := b.BuildAWSPolicy()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		found := false
+		for _, statement := range policy.Statement {
+			if statement.Action.Equal(stringorslice.Of("kms:Decrypt")) && statement.Resource.Equal(stringorslice.Of(keyARN)) {
+				found = true
+			}
+		}
+		if found != g.Expected {
+			t.Errorf("%T: expected kms:Decrypt on the state encryption key %v, got %v", g.Role, g.Expected, found)
+		}
+	}
+}
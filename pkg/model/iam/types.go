@@ -44,6 +44,9 @@ type IAMModelContext struct {
 	AWSPartition string
 
 	Cluster *kops.Cluster
+
+	// StateEncryptionKMSKeys holds the ARNs of the AWS KMS keys that wrap the data keys of the encrypted key and secret stores
+	StateEncryptionKMSKeys []string
 }
 
 // IAMNameForServiceAccountRole determines the name of the IAM Role and Instance Profile to use for the service-account role
@@ -18,6 +18,7 @@ package pki
 
 import (
 	crypto_rand "crypto/rand"
+	"crypto/rsa"
 	"crypto/x509"
 	"fmt"
 	"math/big"
@@ -81,6 +82,10 @@ func signNewCertificate(privateKey *PrivateKey, template *x509.Certificate, sign
 	if template.KeyUsage == 0 {
 		template.KeyUsage = x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment
 	}
+	if _, ok := template.PublicKey.(*rsa.PublicKey); !ok {
+		// Key encipherment is only meaningful for RSA keys
+		template.KeyUsage &^= x509.KeyUsageKeyEncipherment
+	}
 
 	if template.ExtKeyUsage == nil && !template.IsCA {
 		template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
@@ -49,6 +49,8 @@ type IssueCertRequest struct {
 	PublicKey crypto.PublicKey
 	// PrivateKey is the private key for this certificate. If both this and PublicKey are nil, a new private key will be generated.
 	PrivateKey *PrivateKey
+	// KeyAlgorithm is the algorithm of the private key to generate, if one is generated. The default is RSA.
+	KeyAlgorithm KeyAlgorithm
 	// Validity is the certificate validity. The default is 10 years.
 	Validity time.Duration
 
@@ -136,7 +138,7 @@ func IssueCert(ctx context.Context, request *IssueCertRequest, keystore Keystore
 		template.PublicKey = request.PublicKey
 	} else if privateKey == nil {
 		var err error
-		privateKey, err = GeneratePrivateKey()
+		privateKey, err = GeneratePrivateKeyWithAlgorithm(request.KeyAlgorithm)
 		if err != nil {
 			return nil, nil, nil, err
 		}
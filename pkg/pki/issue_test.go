@@ -224,3 +224,45 @@ func TestIssueCert(t *testing.T) {
 		})
 	}
 }
+
+func TestIssueCertKeyAlgorithms(t *testing.T) {
+	ctx := context.TODO()
+
+	for _, caAlgorithm := range SupportedKeyAlgorithms {
+		caCertificate, caPrivateKey, _, err := IssueCert(ctx, &IssueCertRequest{
+			Type:         "ca",
+			Subject:      pkix.Name{CommonName: "Test CA"},
+			KeyAlgorithm: caAlgorithm,
+		}, nil)
+		require.NoError(t, err)
+		assert.Equal(t, caAlgorithm, KeyAlgorithmOf(caPrivateKey.Key), "CA key algorithm")
+
+		for _, algorithm := range SupportedKeyAlgorithms {
+			t.Run(string(caAlgorithm)+"-"+string(algorithm), func(t *testing.T) {
+				keystore := &mockKeystore{
+					t:      t,
+					signer: "ca",
+					cert:   caCertificate,
+					key:    caPrivateKey,
+				}
+				certificate, key, _, err := IssueCert(ctx, &IssueCertRequest{
+					Signer:       "ca",
+					Type:         "server",
+					Subject:      pkix.Name{CommonName: "Test server"},
+					KeyAlgorithm: algorithm,
+				}, keystore)
+				require.NoError(t, err)
+
+				cert := certificate.Certificate
+				assert.NoError(t, cert.CheckSignatureFrom(caCertificate.Certificate), "check signature")
+				assert.Equal(t, algorithm, KeyAlgorithmOf(key.Key), "private key algorithm")
+				assert.Equal(t, algorithm, KeyAlgorithmOf(cert.PublicKey), "certificate public key algorithm")
+				if algorithm == KeyAlgorithmRSA {
+					assert.Equal(t, x509.KeyUsageDigitalSignature|x509.KeyUsageKeyEncipherment, cert.KeyUsage, "KeyUsage")
+				} else {
+					assert.Equal(t, x509.KeyUsageDigitalSignature, cert.KeyUsage, "KeyUsage")
+				}
+			})
+		}
+	}
+}
@@ -20,6 +20,8 @@ import (
 	"bytes"
 	"crypto"
 	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
 	crypto_rand "crypto/rand"
 	"crypto/rsa"
 	"crypto/x509"
@@ -39,6 +41,45 @@ import (
 // (as generating RSA keys can be a bottleneck for testing)
 var DefaultPrivateKeySize = 2048
 
+// KeyAlgorithm is the algorithm of a private key.
+type KeyAlgorithm string
+
+const (
+	// KeyAlgorithmRSA is an RSA key, of DefaultPrivateKeySize bits. It is the default.
+	KeyAlgorithmRSA KeyAlgorithm = "RSA"
+	// KeyAlgorithmECDSAP256 is an ECDSA key on the NIST P-256 curve.
+	KeyAlgorithmECDSAP256 KeyAlgorithm = "ECDSA-P256"
+	// KeyAlgorithmECDSAP384 is an ECDSA key on the NIST P-384 curve.
+	KeyAlgorithmECDSAP384 KeyAlgorithm = "ECDSA-P384"
+	// KeyAlgorithmEd25519 is an Ed25519 key.
+	// Kubernetes does not support Ed25519 keys for signing service account tokens.
+	KeyAlgorithmEd25519 KeyAlgorithm = "Ed25519"
+)
+
+// SupportedKeyAlgorithms are the algorithms of the private keys that can be generated.
+var SupportedKeyAlgorithms = []KeyAlgorithm{KeyAlgorithmRSA, KeyAlgorithmECDSAP256, KeyAlgorithmECDSAP384, KeyAlgorithmEd25519}
+
+// KeyAlgorithmOf returns the algorithm of the public or private key, or "" if it is not a supported type.
+func KeyAlgorithmOf(key interface{}) KeyAlgorithm {
+	if signer, ok := key.(crypto.Signer); ok {
+		key = signer.Public()
+	}
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		return KeyAlgorithmRSA
+	case *ecdsa.PublicKey:
+		switch k.Curve {
+		case elliptic.P256():
+			return KeyAlgorithmECDSAP256
+		case elliptic.P384():
+			return KeyAlgorithmECDSAP384
+		}
+	case ed25519.PublicKey:
+		return KeyAlgorithmEd25519
+	}
+	return ""
+}
+
 func ParsePEMPrivateKey(data []byte) (*PrivateKey, error) {
 	k, err := parsePEMPrivateKey(data)
 	if err != nil {
@@ -50,7 +91,38 @@ func ParsePEMPrivateKey(data []byte) (*PrivateKey, error) {
 	return &PrivateKey{Key: k}, nil
 }
 
+// GeneratePrivateKey generates an RSA private key.
 func GeneratePrivateKey() (*PrivateKey, error) {
+	return GeneratePrivateKeyWithAlgorithm(KeyAlgorithmRSA)
+}
+
+// GeneratePrivateKeyWithAlgorithm generates a private key with the algorithm, defaulting to RSA.
+func GeneratePrivateKeyWithAlgorithm(algorithm KeyAlgorithm) (*PrivateKey, error) {
+	switch algorithm {
+	case "", KeyAlgorithmRSA:
+		return generateRSAPrivateKey()
+	case KeyAlgorithmECDSAP256, KeyAlgorithmECDSAP384:
+		curve := elliptic.P256()
+		if algorithm == KeyAlgorithmECDSAP384 {
+			curve = elliptic.P384()
+		}
+		ecdsaKey, err := ecdsa.GenerateKey(curve, crypto_rand.Reader)
+		if err != nil {
+			return nil, fmt.Errorf("error generating ECDSA private key: %w", err)
+		}
+		return &PrivateKey{Key: ecdsaKey}, nil
+	case KeyAlgorithmEd25519:
+		_, ed25519Key, err := ed25519.GenerateKey(crypto_rand.Reader)
+		if err != nil {
+			return nil, fmt.Errorf("error generating Ed25519 private key: %w", err)
+		}
+		return &PrivateKey{Key: ed25519Key}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key algorithm %q", algorithm)
+	}
+}
+
+func generateRSAPrivateKey() (*PrivateKey, error) {
 	rsaKeySize := DefaultPrivateKeySize
 
 	if os.Getenv("KOPS_RSA_PRIVATE_KEY_SIZE") != "" {
@@ -164,6 +236,14 @@ func (k *PrivateKey) WriteTo(w io.Writer) (int64, error) {
 		if err := pem.Encode(w, &pem.Block{Type: "EC PRIVATE KEY", Bytes: b}); err != nil {
 			return 0, fmt.Errorf("error encoding ECDSA private key: %w", err)
 		}
+	case ed25519.PrivateKey:
+		b, err := x509.MarshalPKCS8PrivateKey(pk)
+		if err != nil {
+			return 0, fmt.Errorf("error encoding Ed25519 private key: %w", err)
+		}
+		if err := pem.Encode(w, &pem.Block{Type: "PRIVATE KEY", Bytes: b}); err != nil {
+			return 0, fmt.Errorf("error encoding Ed25519 private key: %w", err)
+		}
 	default:
 		return 0, fmt.Errorf("unknown private key type: %T", k.Key)
 	}
@@ -202,7 +282,11 @@ func parsePEMPrivateKey(pemData []byte) (crypto.Signer, error) {
 			if err != nil {
 				return nil, err
 			}
-			return k.(crypto.Signer), nil
+			signer, ok := k.(crypto.Signer)
+			if !ok {
+				return nil, fmt.Errorf("unsupported private key type %T", k)
+			}
+			return signer, nil
 		} else {
 			klog.Infof("Ignoring unexpected PEM block: %q", block.Type)
 		}
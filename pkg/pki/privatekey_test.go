@@ -60,3 +60,45 @@ pTejuKor1QB7wzbfGuANjE9mgHuhfQLPGw==
 		})
 	}
 }
+
+func TestGeneratePrivateKeyWithAlgorithm(t *testing.T) {
+	for _, algorithm := range SupportedKeyAlgorithms {
+		t.Run(string(algorithm), func(t *testing.T) {
+			key, err := GeneratePrivateKeyWithAlgorithm(algorithm)
+			if err != nil {
+				t.Fatalf("error from GeneratePrivateKeyWithAlgorithm: %v", err)
+			}
+			if actual := KeyAlgorithmOf(key.Key); actual != algorithm {
+				t.Fatalf("unexpected algorithm of generated key: %q", actual)
+			}
+
+			data, err := key.AsBytes()
+			if err != nil {
+				t.Fatalf("error from PrivateKey AsBytes: %v", err)
+			}
+			parsed, err := ParsePEMPrivateKey(data)
+			if err != nil {
+				t.Fatalf("error from ParsePEMPrivateKey: %v", err)
+			}
+			if actual := KeyAlgorithmOf(parsed.Key); actual != algorithm {
+				t.Fatalf("unexpected algorithm of parsed key: %q", actual)
+			}
+
+			publicKeyData, err := EncodePEMPublicKey(key.Key.Public())
+			if err != nil {
+				t.Fatalf("error from EncodePEMPublicKey: %v", err)
+			}
+			publicKey, err := ParsePEMPublicKey(publicKeyData)
+			if err != nil {
+				t.Fatalf("error from ParsePEMPublicKey: %v", err)
+			}
+			if actual := KeyAlgorithmOf(publicKey.Key); actual != algorithm {
+				t.Fatalf("unexpected algorithm of parsed public key: %q", actual)
+			}
+		})
+	}
+
+	if _, err := GeneratePrivateKeyWithAlgorithm("DSA"); err == nil {
+		t.Errorf("expected error generating key with unsupported algorithm")
+	}
+}
@@ -18,6 +18,7 @@ package pki
 
 import (
 	"crypto"
+	"crypto/rsa"
 	"crypto/x509"
 	"encoding/pem"
 	"fmt"
@@ -40,6 +41,20 @@ type PublicKey struct {
 	Key crypto.PublicKey
 }
 
+// EncodePEMPublicKey encodes the public key as a PKIX PEM block.
+// RSA keys use the "RSA PUBLIC KEY" block type that kOps has always written, so they can be read by older versions.
+func EncodePEMPublicKey(key crypto.PublicKey) ([]byte, error) {
+	data, err := x509.MarshalPKIXPublicKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling public key: %w", err)
+	}
+	blockType := "PUBLIC KEY"
+	if _, ok := key.(*rsa.PublicKey); ok {
+		blockType = "RSA PUBLIC KEY"
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: data}), nil
+}
+
 func parsePEMPublicKey(pemData []byte) (crypto.PublicKey, error) {
 	for {
 		block, rest := pem.Decode(pemData)
@@ -50,6 +65,10 @@ func parsePEMPublicKey(pemData []byte) (crypto.PublicKey, error) {
 		if block.Type == "RSA PUBLIC KEY" {
 			k, err := x509.ParsePKCS1PublicKey(block.Bytes)
 			if err != nil {
+				// kOps has historically written PKIX keys with this block type
+				if k, err2 := x509.ParsePKIXPublicKey(block.Bytes); err2 == nil {
+					return k, nil
+				}
 				return nil, err
 			}
 			return k, nil
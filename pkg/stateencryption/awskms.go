@@ -0,0 +1,127 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stateencryption
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/arn"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/kms"
+	"github.com/aws/aws-sdk-go/service/kms/kmsiface"
+)
+
+// AWSKMSScheme is the scheme of the URIs of AWS KMS keys, e.g. awskms:///arn:aws:kms:us-east-1:123456789012:key/<id>
+const AWSKMSScheme = "awskms"
+
+// awsKMSKeyWrapper wraps data keys with an AWS KMS key.
+// It uses the default AWS credentials, e.g. the instance profile on nodes.
+type awsKMSKeyWrapper struct {
+	uri   string
+	keyID string
+	kms   kmsiface.KMSAPI
+}
+
+var _ KeyWrapper = &awsKMSKeyWrapper{}
+
+func newAWSKMSKeyWrapper(u *url.URL) (KeyWrapper, error) {
+	keyID, region, err := parseAWSKMSKeyURI(u)
+	if err != nil {
+		return nil, err
+	}
+
+	config := aws.NewConfig().WithCredentialsChainVerboseErrors(true).WithRegion(region)
+	sess, err := session.NewSession(config)
+	if err != nil {
+		return nil, fmt.Errorf("error building AWS session for %q: %w", u, err)
+	}
+
+	return &awsKMSKeyWrapper{
+		uri:   u.String(),
+		keyID: keyID,
+		kms:   kms.New(sess, config),
+	}, nil
+}
+
+// parseAWSKMSKeyURI returns the key ARN and region of an AWS KMS key URI.
+// The region is that of the ARN, unless it is overridden with a region query parameter.
+func parseAWSKMSKeyURI(u *url.URL) (string, string, error) {
+	keyID := strings.TrimPrefix(u.Path, "/")
+	if u.Host != "" || keyID == "" {
+		return "", "", fmt.Errorf("key URI %q must be of the form %s:///<key ARN>", u, AWSKMSScheme)
+	}
+	keyARN, err := arn.Parse(keyID)
+	if err != nil {
+		return "", "", fmt.Errorf("key URI %q does not contain a valid key ARN: %w", u, err)
+	}
+	if keyARN.Service != "kms" {
+		return "", "", fmt.Errorf("key URI %q does not contain a KMS key ARN", u)
+	}
+
+	region := keyARN.Region
+	if r := u.Query().Get("region"); r != "" {
+		region = r
+	}
+	return keyID, region, nil
+}
+
+// AWSKMSKeyARN returns the ARN of the key identified by a key URI,
+// or an empty string if the URI does not identify an AWS KMS key.
+func AWSKMSKeyARN(uri string) (string, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return "", fmt.Errorf("error parsing key URI %q: %w", uri, err)
+	}
+	if u.Scheme != AWSKMSScheme {
+		return "", nil
+	}
+	keyARN, _, err := parseAWSKMSKeyURI(u)
+	if err != nil {
+		return "", err
+	}
+	return keyARN, nil
+}
+
+func (w *awsKMSKeyWrapper) URI() string {
+	return w.uri
+}
+
+func (w *awsKMSKeyWrapper) Wrap(ctx context.Context, dataKey []byte) ([]byte, error) {
+	response, err := w.kms.EncryptWithContext(ctx, &kms.EncryptInput{
+		KeyId:     aws.String(w.keyID),
+		Plaintext: dataKey,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error encrypting with %s: %w", w.keyID, err)
+	}
+	return response.CiphertextBlob, nil
+}
+
+func (w *awsKMSKeyWrapper) Unwrap(ctx context.Context, wrapped []byte) ([]byte, error) {
+	response, err := w.kms.DecryptWithContext(ctx, &kms.DecryptInput{
+		KeyId:          aws.String(w.keyID),
+		CiphertextBlob: wrapped,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to unwrap data key with %s: %w", w.uri, err)
+	}
+	return response.Plaintext, nil
+}
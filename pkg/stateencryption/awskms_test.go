@@ -0,0 +1,78 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stateencryption
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestParseAWSKMSKeyURI(t *testing.T) {
+	grid := []struct {
+		uri       string
+		keyID     string
+		region    string
+		expectErr bool
+	}{
+		{
+			uri:    "awskms:///arn:aws:kms:us-east-1:123456789012:key/1234abcd-12ab-34cd-56ef-1234567890ab",
+			keyID:  "arn:aws:kms:us-east-1:123456789012:key/1234abcd-12ab-34cd-56ef-1234567890ab",
+			region: "us-east-1",
+		},
+		{
+			uri:    "awskms:///arn:aws:kms:us-east-1:123456789012:alias/kops?region=us-west-2",
+			keyID:  "arn:aws:kms:us-east-1:123456789012:alias/kops",
+			region: "us-west-2",
+		},
+		{
+			uri:       "awskms:///1234abcd-12ab-34cd-56ef-1234567890ab",
+			expectErr: true,
+		},
+		{
+			uri:       "awskms:///arn:aws:s3:::bucket",
+			expectErr: true,
+		},
+		{
+			uri:       "awskms://arn/key",
+			expectErr: true,
+		},
+	}
+	for _, g := range grid {
+		t.Run(g.uri, func(t *testing.T) {
+			u, err := url.Parse(g.uri)
+			if err != nil {
+				t.Fatalf("error parsing %q: %v", g.uri, err)
+			}
+			keyID, region, err := parseAWSKMSKeyURI(u)
+			if g.expectErr {
+				if err == nil {
+					t.Fatalf("expected error parsing %q", g.uri)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if keyID != g.keyID {
+				t.Errorf("expected key %q, got %q", g.keyID, keyID)
+			}
+			if region != g.region {
+				t.Errorf("expected region %q, got %q", g.region, region)
+			}
+		})
+	}
+}
@@ -0,0 +1,296 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stateencryption
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+	"k8s.io/kops/util/pkg/vfs"
+)
+
+// EnvelopeFileName is the name of the file in the base directory of an encrypted store that holds its wrapped data key.
+// It is hidden, so it is skipped when listing the contents of the store.
+const EnvelopeFileName = ".encryption"
+
+// encryptedPrefix marks the contents of files that are encrypted with the data key.
+var encryptedPrefix = []byte("kops-encrypted:v1\n")
+
+// Envelope is the wrapped data key of a store, as stored in EnvelopeFileName.
+type Envelope struct {
+	// KeyURI identifies the key that wraps the data key.
+	KeyURI string `json:"keyURI"`
+	// WrappedKey is the data key, encrypted by the key identified by KeyURI.
+	WrappedKey []byte `json:"wrappedKey"`
+	// WrapTime is when the data key was last wrapped.
+	WrapTime time.Time `json:"wrapTime"`
+}
+
+// Store encrypts and decrypts the files of a key or secret store.
+// A store is encrypted once an envelope has been written to its base directory by Enable;
+// until then, files are written in plaintext.
+// Plaintext files are always readable, so a store can be encrypted without downtime.
+type Store struct {
+	basedir vfs.Path
+
+	mutex   sync.Mutex
+	dataKey []byte
+	// checked is true once the envelope has been read, even if the store was not encrypted
+	checked bool
+}
+
+// ForPath returns the Store for the key or secret store with the base directory.
+func ForPath(basedir vfs.Path) *Store {
+	return &Store{basedir: basedir}
+}
+
+// IsEncrypted returns true if the contents of a file were encrypted by a Store.
+func IsEncrypted(data []byte) bool {
+	return bytes.HasPrefix(data, encryptedPrefix)
+}
+
+// Encrypt returns the contents to write to the file p of the store; they are only encrypted if the store is encrypted.
+// The path of the file relative to the base directory is authenticated, so the contents cannot be moved to another file.
+func (s *Store) Encrypt(ctx context.Context, p vfs.Path, plaintext []byte) ([]byte, error) {
+	dataKey, err := s.getDataKey(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if dataKey == nil {
+		return plaintext, nil
+	}
+
+	aad, err := s.additionalData(p)
+	if err != nil {
+		return nil, err
+	}
+	ciphertext, err := seal(dataKey, plaintext, aad)
+	if err != nil {
+		return nil, fmt.Errorf("error encrypting: %w", err)
+	}
+	return append(append([]byte{}, encryptedPrefix...), ciphertext...), nil
+}
+
+// Decrypt returns the plaintext of the contents of the file p of the store, which may or may not be encrypted.
+func (s *Store) Decrypt(ctx context.Context, p vfs.Path, data []byte) ([]byte, error) {
+	if !IsEncrypted(data) {
+		return data, nil
+	}
+
+	dataKey, err := s.getDataKey(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if dataKey == nil {
+		// The store may have been encrypted since we last read the envelope
+		dataKey, err = s.refreshDataKey(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if dataKey == nil {
+		return nil, fmt.Errorf("file is encrypted, but %s has no %s", s.basedir, EnvelopeFileName)
+	}
+
+	aad, err := s.additionalData(p)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := open(dataKey, data[len(encryptedPrefix):], aad)
+	if err != nil {
+		return nil, fmt.Errorf("error decrypting: %w", err)
+	}
+	return plaintext, nil
+}
+
+// additionalData returns the data that is authenticated along with the contents of the file p,
+// which is its path relative to the base directory, so that it is the same in copies of the store.
+func (s *Store) additionalData(p vfs.Path) ([]byte, error) {
+	relativePath, err := vfs.RelativePath(s.basedir, p)
+	if err != nil {
+		return nil, fmt.Errorf("file is not in the store: %w", err)
+	}
+	return []byte(relativePath), nil
+}
+
+// getDataKey returns the data key of the store, or nil if it is not encrypted.
+// The result is cached, so the envelope is only read once;
+// Decrypt reads it again if it finds a file that was encrypted since.
+func (s *Store) getDataKey(ctx context.Context) ([]byte, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.checked {
+		return s.dataKey, nil
+	}
+	return s.readDataKey(ctx)
+}
+
+// refreshDataKey reads the envelope again, returning the data key of the store, or nil if it is not encrypted.
+func (s *Store) refreshDataKey(ctx context.Context) ([]byte, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.readDataKey(ctx)
+}
+
+func (s *Store) readDataKey(ctx context.Context) ([]byte, error) {
+	envelope, err := ReadEnvelope(ctx, s.basedir)
+	if err != nil {
+		return nil, err
+	}
+	if envelope == nil {
+		s.dataKey = nil
+		s.checked = true
+		return nil, nil
+	}
+
+	dataKey, err := unwrap(ctx, envelope)
+	if err != nil {
+		return nil, fmt.Errorf("error reading data key of %s: %w", s.basedir, err)
+	}
+	s.dataKey = dataKey
+	s.checked = true
+	return dataKey, nil
+}
+
+// ReadEnvelope reads the envelope of the store with the base directory, returning nil if it is not encrypted.
+func ReadEnvelope(ctx context.Context, basedir vfs.Path) (*Envelope, error) {
+	p := basedir.Join(EnvelopeFileName)
+	data, err := p.ReadFile(ctx)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error reading %s: %w", p, err)
+	}
+
+	envelope := &Envelope{}
+	if err := json.Unmarshal(data, envelope); err != nil {
+		return nil, fmt.Errorf("error parsing %s: %w", p, err)
+	}
+	return envelope, nil
+}
+
+// Enable encrypts the store with the base directory, generating a data key wrapped by the KeyWrapper.
+// Files that are already in the store are not encrypted until they are next written.
+func Enable(ctx context.Context, basedir vfs.Path, wrapper KeyWrapper, acl vfs.ACL) error {
+	dataKey := make([]byte, 32)
+	if _, err := rand.Read(dataKey); err != nil {
+		return fmt.Errorf("error generating data key: %w", err)
+	}
+
+	data, err := buildEnvelope(ctx, wrapper, dataKey)
+	if err != nil {
+		return err
+	}
+
+	p := basedir.Join(EnvelopeFileName)
+	if err := p.CreateFile(ctx, bytes.NewReader(data), acl); err != nil {
+		if os.IsExist(err) {
+			return fmt.Errorf("%s is already encrypted", basedir)
+		}
+		return fmt.Errorf("error writing %s: %w", p, err)
+	}
+	klog.Infof("encrypted %s with key %s", basedir, wrapper.URI())
+	return nil
+}
+
+// Rewrap wraps the data key of the store with the base directory with a new KeyWrapper.
+// The files in the store are unchanged, as the data key is the same.
+func Rewrap(ctx context.Context, basedir vfs.Path, wrapper KeyWrapper, acl vfs.ACL) error {
+	p := basedir.Join(EnvelopeFileName)
+	current, version, err := vfs.ReadFileVersion(ctx, p)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("%s is not encrypted", basedir)
+		}
+		return fmt.Errorf("error reading %s: %w", p, err)
+	}
+
+	envelope := &Envelope{}
+	if err := json.Unmarshal(current, envelope); err != nil {
+		return fmt.Errorf("error parsing %s: %w", p, err)
+	}
+
+	dataKey, err := unwrap(ctx, envelope)
+	if err != nil {
+		return err
+	}
+
+	data, err := buildEnvelope(ctx, wrapper, dataKey)
+	if err != nil {
+		return err
+	}
+
+	if _, err := vfs.WriteFileIfVersion(ctx, p, bytes.NewReader(data), acl, version); err != nil {
+		return fmt.Errorf("error writing %s: %w", p, err)
+	}
+	klog.Infof("rewrapped data key of %s from %s to %s", basedir, envelope.KeyURI, wrapper.URI())
+	return nil
+}
+
+// CopyEnvelope copies the envelope of the store at src to dest, so that files copied from src can be read at dest.
+// It does nothing if src is not encrypted.
+func CopyEnvelope(ctx context.Context, src, dest vfs.Path, acl vfs.ACL) error {
+	data, err := src.Join(EnvelopeFileName).ReadFile(ctx)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("error reading %s: %w", src.Join(EnvelopeFileName), err)
+	}
+
+	p := dest.Join(EnvelopeFileName)
+	if err := p.WriteFile(ctx, bytes.NewReader(data), acl); err != nil {
+		return fmt.Errorf("error writing %s: %w", p, err)
+	}
+	return nil
+}
+
+func buildEnvelope(ctx context.Context, wrapper KeyWrapper, dataKey []byte) ([]byte, error) {
+	wrapped, err := wrapper.Wrap(ctx, dataKey)
+	if err != nil {
+		return nil, fmt.Errorf("error wrapping data key with %s: %w", wrapper.URI(), err)
+	}
+
+	envelope := &Envelope{
+		KeyURI:     wrapper.URI(),
+		WrappedKey: wrapped,
+		WrapTime:   time.Now().UTC(),
+	}
+	data, err := json.Marshal(envelope)
+	if err != nil {
+		return nil, fmt.Errorf("error serializing envelope: %w", err)
+	}
+	return data, nil
+}
+
+func unwrap(ctx context.Context, envelope *Envelope) ([]byte, error) {
+	wrapper, err := NewKeyWrapper(envelope.KeyURI)
+	if err != nil {
+		return nil, err
+	}
+	return wrapper.Unwrap(ctx, envelope.WrappedKey)
+}
@@ -0,0 +1,170 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stateencryption
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"k8s.io/kops/util/pkg/vfs"
+)
+
+// newTestKeyWrapper writes a random key to a file, and returns the KeyWrapper for it.
+func newTestKeyWrapper(t *testing.T, name string) KeyWrapper {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		t.Fatalf("error generating key: %v", err)
+	}
+	p := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(p, []byte(base64.StdEncoding.EncodeToString(key)+"\n"), 0o600); err != nil {
+		t.Fatalf("error writing key: %v", err)
+	}
+	wrapper, err := NewKeyWrapper("file://" + p)
+	if err != nil {
+		t.Fatalf("error building key wrapper: %v", err)
+	}
+	return wrapper
+}
+
+func TestStoreEncryptDecrypt(t *testing.T) {
+	ctx := context.TODO()
+	basedir := vfs.NewMemFSPath(vfs.NewMemFSContext(), "pki")
+	plaintext := []byte("secret material")
+	p := basedir.Join("ca", "keyset.yaml")
+
+	store := ForPath(basedir)
+
+	// Before encryption is enabled, files are written in plaintext
+	data, err := store.Encrypt(ctx, p, plaintext)
+	if err != nil {
+		t.Fatalf("error encrypting: %v", err)
+	}
+	if !bytes.Equal(data, plaintext) {
+		t.Fatalf("expected plaintext before encryption is enabled, got %q", data)
+	}
+
+	wrapper := newTestKeyWrapper(t, "first.key")
+	if err := Enable(ctx, basedir, wrapper, nil); err != nil {
+		t.Fatalf("error enabling encryption: %v", err)
+	}
+	if err := Enable(ctx, basedir, wrapper, nil); err == nil {
+		t.Fatalf("expected error enabling encryption twice")
+	}
+
+	// The existing store has cached that it is not encrypted
+	data, err = store.Encrypt(ctx, p, plaintext)
+	if err != nil {
+		t.Fatalf("error encrypting: %v", err)
+	}
+	if !bytes.Equal(data, plaintext) {
+		t.Fatalf("expected the existing store to write plaintext, got %q", data)
+	}
+
+	encrypted, err := ForPath(basedir).Encrypt(ctx, p, plaintext)
+	if err != nil {
+		t.Fatalf("error encrypting: %v", err)
+	}
+	if !IsEncrypted(encrypted) || bytes.Contains(encrypted, plaintext) {
+		t.Fatalf("expected encrypted data, got %q", encrypted)
+	}
+
+	// The existing store notices that encryption was enabled when it reads an encrypted file
+	actual, err := store.Decrypt(ctx, p, encrypted)
+	if err != nil {
+		t.Fatalf("error decrypting with the existing store: %v", err)
+	}
+	if !bytes.Equal(actual, plaintext) {
+		t.Errorf("unexpected plaintext %q", actual)
+	}
+
+	// Both encrypted and plaintext files can be read by a new store
+	reader := ForPath(basedir)
+	for _, data := range [][]byte{encrypted, plaintext} {
+		actual, err := reader.Decrypt(ctx, p, data)
+		if err != nil {
+			t.Fatalf("error decrypting: %v", err)
+		}
+		if !bytes.Equal(actual, plaintext) {
+			t.Errorf("unexpected plaintext %q", actual)
+		}
+	}
+
+	// The contents of a file cannot be moved to another file of the store
+	if _, err := reader.Decrypt(ctx, basedir.Join("other", "keyset.yaml"), encrypted); err == nil {
+		t.Errorf("expected error decrypting the contents of another file")
+	}
+
+	// After rewrapping, the data key is the same, so existing files can still be read
+	second := newTestKeyWrapper(t, "second.key")
+	if err := Rewrap(ctx, basedir, second, nil); err != nil {
+		t.Fatalf("error rewrapping: %v", err)
+	}
+	envelope, err := ReadEnvelope(ctx, basedir)
+	if err != nil {
+		t.Fatalf("error reading envelope: %v", err)
+	}
+	if envelope.KeyURI != second.URI() {
+		t.Errorf("expected envelope to be wrapped by %s, got %s", second.URI(), envelope.KeyURI)
+	}
+	actual, err = ForPath(basedir).Decrypt(ctx, p, encrypted)
+	if err != nil {
+		t.Fatalf("error decrypting after rewrap: %v", err)
+	}
+	if !bytes.Equal(actual, plaintext) {
+		t.Errorf("unexpected plaintext after rewrap %q", actual)
+	}
+
+	// A copy of the store can be read with a copy of the envelope
+	mirror := vfs.NewMemFSPath(vfs.NewMemFSContext(), "mirror")
+	mirrored := mirror.Join("ca", "keyset.yaml")
+	if _, err := ForPath(mirror).Decrypt(ctx, mirrored, encrypted); err == nil {
+		t.Errorf("expected error decrypting without an envelope")
+	}
+	if err := CopyEnvelope(ctx, basedir, mirror, nil); err != nil {
+		t.Fatalf("error copying envelope: %v", err)
+	}
+	if _, err := ForPath(mirror).Decrypt(ctx, mirrored, encrypted); err != nil {
+		t.Errorf("error decrypting with copied envelope: %v", err)
+	}
+}
+
+func TestLocalKeyWrapperWrongKey(t *testing.T) {
+	ctx := context.TODO()
+
+	first := newTestKeyWrapper(t, "first.key")
+	second := newTestKeyWrapper(t, "second.key")
+
+	wrapped, err := first.Wrap(ctx, []byte("data key"))
+	if err != nil {
+		t.Fatalf("error wrapping: %v", err)
+	}
+	if _, err := second.Unwrap(ctx, wrapped); err == nil {
+		t.Errorf("expected error unwrapping with the wrong key")
+	}
+	unwrapped, err := first.Unwrap(ctx, wrapped)
+	if err != nil {
+		t.Fatalf("error unwrapping: %v", err)
+	}
+	if string(unwrapped) != "data key" {
+		t.Errorf("unexpected data key %q", unwrapped)
+	}
+}
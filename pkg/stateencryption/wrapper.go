@@ -0,0 +1,164 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stateencryption
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+)
+
+// KeyWrapper wraps and unwraps data keys with a key encryption key that it holds,
+// in the manner of a KMS. The key encryption key never leaves the wrapper.
+type KeyWrapper interface {
+	// URI identifies the key encryption key, so the data key can be unwrapped later.
+	URI() string
+	// Wrap encrypts the data key.
+	Wrap(ctx context.Context, dataKey []byte) ([]byte, error)
+	// Unwrap decrypts a data key that was encrypted by Wrap.
+	Unwrap(ctx context.Context, wrapped []byte) ([]byte, error)
+}
+
+// KeyWrapperBuilder builds a KeyWrapper for a key URI.
+type KeyWrapperBuilder func(uri *url.URL) (KeyWrapper, error)
+
+var (
+	buildersMutex sync.Mutex
+	builders      = map[string]KeyWrapperBuilder{
+		"file":       newLocalKeyWrapper,
+		AWSKMSScheme: newAWSKMSKeyWrapper,
+	}
+)
+
+// RegisterKeyWrapper registers the builder of KeyWrappers for key URIs with the scheme, e.g. for a cloud KMS.
+func RegisterKeyWrapper(scheme string, builder KeyWrapperBuilder) {
+	buildersMutex.Lock()
+	defer buildersMutex.Unlock()
+
+	builders[scheme] = builder
+}
+
+// NewKeyWrapper builds the KeyWrapper for the key URI.
+func NewKeyWrapper(uri string) (KeyWrapper, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, fmt.Errorf("invalid key URI %q: %w", uri, err)
+	}
+
+	buildersMutex.Lock()
+	builder := builders[u.Scheme]
+	buildersMutex.Unlock()
+
+	if builder == nil {
+		return nil, fmt.Errorf("unsupported key URI %q", uri)
+	}
+	return builder(u)
+}
+
+// IsLocalKeyURI returns true if the key URI identifies a key that is only available where it was created,
+// so nodes and kops-controller cannot unwrap a data key that it wraps.
+func IsLocalKeyURI(uri string) bool {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return false
+	}
+	return u.Scheme == "file"
+}
+
+// localKeyWrapper wraps data keys with an AES-256 key read from a local file.
+// The file holds the base64 encoding of 32 random bytes, e.g. from `head -c 32 /dev/urandom | base64`.
+type localKeyWrapper struct {
+	uri string
+	key []byte
+}
+
+var _ KeyWrapper = &localKeyWrapper{}
+
+func newLocalKeyWrapper(u *url.URL) (KeyWrapper, error) {
+	if u.Path == "" {
+		return nil, fmt.Errorf("key URI %q must specify a file", u)
+	}
+	data, err := os.ReadFile(u.Path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading key file %q: %w", u.Path, err)
+	}
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil {
+		return nil, fmt.Errorf("key file %q is not valid base64: %w", u.Path, err)
+	}
+	if len(key) != 32 {
+		return nil, fmt.Errorf("key file %q must contain a 32 byte key, found %d bytes", u.Path, len(key))
+	}
+	return &localKeyWrapper{uri: u.String(), key: key}, nil
+}
+
+func (w *localKeyWrapper) URI() string {
+	return w.uri
+}
+
+func (w *localKeyWrapper) Wrap(ctx context.Context, dataKey []byte) ([]byte, error) {
+	return seal(w.key, dataKey, nil)
+}
+
+func (w *localKeyWrapper) Unwrap(ctx context.Context, wrapped []byte) ([]byte, error) {
+	dataKey, err := open(w.key, wrapped, nil)
+	if err != nil {
+		return nil, fmt.Errorf("unable to unwrap data key with %s: %w", w.uri, err)
+	}
+	return dataKey, nil
+}
+
+// seal encrypts the plaintext with AES-GCM, prefixing the random nonce, and authenticates the additional data.
+func seal(key, plaintext, additionalData []byte) ([]byte, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("error generating nonce: %w", err)
+	}
+	return aead.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+// open decrypts data encrypted by seal with the same additional data.
+func open(key, data, additionalData []byte) ([]byte, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < aead.NonceSize() {
+		return nil, fmt.Errorf("ciphertext is too short")
+	}
+	nonce, ciphertext := data[:aead.NonceSize()], data[aead.NonceSize():]
+	return aead.Open(nil, nonce, ciphertext, additionalData)
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("error building cipher: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
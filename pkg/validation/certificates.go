@@ -0,0 +1,67 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"k8s.io/kops/upup/pkg/fi"
+)
+
+// certificateExpiryWarning is how long before a certificate expires that a warning is reported.
+const certificateExpiryWarning = 30 * 24 * time.Hour
+
+// validateCertificates reports the primary certificates of the keysets in the keystore that have expired
+// as failures, and those that expire within certificateExpiryWarning of now as warnings.
+func (v *ValidationCluster) validateCertificates(keystore fi.CAStore, now time.Time) error {
+	keysets, err := keystore.ListKeysets()
+	if err != nil {
+		return fmt.Errorf("error listing keysets: %v", err)
+	}
+
+	var names []string
+	for name := range keysets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		primary := keysets[name].Primary
+		if primary == nil || primary.Certificate == nil || primary.Certificate.Certificate == nil {
+			continue
+		}
+
+		notAfter := primary.Certificate.Certificate.NotAfter
+		if !now.Before(notAfter) {
+			v.addError(&ValidationError{
+				Kind:    ValidationErrorKindCertificate,
+				Name:    name,
+				Message: fmt.Sprintf("certificate of keyset %q expired at %s", name, notAfter.UTC().Format(time.RFC3339)),
+			})
+		} else if remaining := notAfter.Sub(now); remaining < certificateExpiryWarning {
+			v.addWarning(&ValidationError{
+				Kind:    ValidationErrorKindCertificate,
+				Name:    name,
+				Message: fmt.Sprintf("certificate of keyset %q expires in %d days, at %s", name, int(remaining.Hours()/24), notAfter.UTC().Format(time.RFC3339)),
+			})
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,79 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"crypto/x509"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/kops/pkg/pki"
+	"k8s.io/kops/upup/pkg/fi"
+)
+
+// mockKeystore lists keysets whose primary certificates expire at the given times.
+type mockKeystore struct {
+	fi.CAStore
+	notAfter map[string]time.Time
+}
+
+func (k *mockKeystore) ListKeysets() (map[string]*fi.Keyset, error) {
+	keysets := map[string]*fi.Keyset{}
+	for name, notAfter := range k.notAfter {
+		keysets[name] = &fi.Keyset{
+			Primary: &fi.KeysetItem{
+				Id: "1",
+				Certificate: &pki.Certificate{
+					Certificate: &x509.Certificate{NotAfter: notAfter},
+				},
+			},
+		}
+	}
+	keysets["service-account"] = &fi.Keyset{}
+	return keysets, nil
+}
+
+func Test_ValidateCertificates(t *testing.T) {
+	now := time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)
+	keystore := &mockKeystore{
+		notAfter: map[string]time.Time{
+			"kubernetes-ca":           now.AddDate(10, 0, 0),
+			"etcd-clients-ca":         now.AddDate(0, 0, 12),
+			"apiserver-aggregator-ca": now.Add(-time.Hour),
+		},
+	}
+
+	v := &ValidationCluster{}
+	require.NoError(t, v.validateCertificates(keystore, now))
+
+	assert.Equal(t, []*ValidationError{
+		{
+			Kind:    "Certificate",
+			Name:    "apiserver-aggregator-ca",
+			Message: "certificate of keyset \"apiserver-aggregator-ca\" expired at 2023-05-31T23:00:00Z",
+		},
+	}, v.Failures)
+	assert.Equal(t, []*ValidationError{
+		{
+			Kind:    "Certificate",
+			Name:    "etcd-clients-ca",
+			Message: "certificate of keyset \"etcd-clients-ca\" expires in 12 days, at 2023-06-13T00:00:00Z",
+		},
+	}, v.Warnings)
+}
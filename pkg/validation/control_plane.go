@@ -0,0 +1,333 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/upup/pkg/fi"
+)
+
+// apiServerProbeTimeout bounds each request to the /readyz endpoint of an apiserver.
+const apiServerProbeTimeout = 5 * time.Second
+
+// apiServerProber queries the /readyz endpoint of the apiserver on a node, returning the names of the checks that
+// failed. It returns an error if the apiserver cannot be reached or does not report its readiness.
+type apiServerProber func(ctx context.Context, node *v1.Node) ([]string, error)
+
+// newAPIServerProber returns an apiServerProber that connects to the addresses of the nodes on the secure port of
+// the apiserver, with the credentials of restConfig. It needs network access to the nodes, as kops-controller has,
+// and each node that cannot be reached takes up to apiServerProbeTimeout per address. The certificate of each apiserver is verified against the name
+// that restConfig connects to, which kOps includes in the certificates of all of them.
+func newAPIServerProber(cluster *kops.Cluster, restConfig *rest.Config) (apiServerProber, error) {
+	serverName := restConfig.TLSClientConfig.ServerName
+	if serverName == "" {
+		apiURL, err := url.Parse(restConfig.Host)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse Kubernetes cluster API URL: %v", err)
+		}
+		serverName = apiURL.Hostname()
+	}
+
+	port := int32(443)
+	if cluster.Spec.KubeAPIServer != nil && cluster.Spec.KubeAPIServer.SecurePort != 0 {
+		port = cluster.Spec.KubeAPIServer.SecurePort
+	}
+
+	return func(ctx context.Context, node *v1.Node) ([]string, error) {
+		var lastErr error
+		for _, addressType := range []v1.NodeAddressType{v1.NodeInternalIP, v1.NodeExternalIP} {
+			for _, address := range node.Status.Addresses {
+				if address.Type != addressType {
+					continue
+				}
+				failedChecks, err := probeAPIServer(ctx, restConfig, serverName, net.JoinHostPort(address.Address, strconv.Itoa(int(port))))
+				if err == nil {
+					return failedChecks, nil
+				}
+				lastErr = err
+			}
+		}
+		if lastErr == nil {
+			return nil, fmt.Errorf("node has no IP address")
+		}
+		return nil, lastErr
+	}, nil
+}
+
+// newAPIServerEndpointProber returns a function that queries the /readyz endpoint through the endpoint that
+// restConfig connects to, returning the names of the checks that failed on whichever apiserver answered.
+func newAPIServerEndpointProber(restConfig *rest.Config) func(ctx context.Context) ([]string, error) {
+	return func(ctx context.Context) ([]string, error) {
+		config := rest.CopyConfig(restConfig)
+		config.Timeout = apiServerProbeTimeout
+		return queryReadyz(ctx, config, restConfig.Host)
+	}
+}
+
+// probeAPIServer queries the /readyz endpoint of the apiserver at hostPort, returning the names of the checks that failed.
+func probeAPIServer(ctx context.Context, restConfig *rest.Config, serverName string, hostPort string) ([]string, error) {
+	config := rest.CopyConfig(restConfig)
+	config.Host = "https://" + hostPort
+	config.TLSClientConfig.ServerName = serverName
+	config.Timeout = apiServerProbeTimeout
+	return queryReadyz(ctx, config, hostPort)
+}
+
+// queryReadyz queries the /readyz endpoint of the apiserver that config connects to, named target in errors,
+// returning the names of the checks that failed.
+func queryReadyz(ctx context.Context, config *rest.Config, target string) ([]string, error) {
+	client, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("building client for %s: %v", target, err)
+	}
+
+	var statusCode int
+	result := client.Discovery().RESTClient().Get().AbsPath("/readyz").Param("verbose", "true").Do(ctx).StatusCode(&statusCode)
+	body, err := result.Raw()
+	switch statusCode {
+	case 0:
+		return nil, fmt.Errorf("querying %s: %v", target, err)
+	case 200:
+		return nil, nil
+	case 500:
+		return parseFailedReadyzChecks(string(body)), nil
+	default:
+		return nil, fmt.Errorf("querying %s: unexpected status %d: %v", target, statusCode, err)
+	}
+}
+
+// parseFailedReadyzChecks returns the names of the failed checks in the verbose output of /readyz,
+// in which each check is listed as "[+]name ok" or "[-]name failed: reason".
+func parseFailedReadyzChecks(body string) []string {
+	var failed []string
+	for _, line := range strings.Split(body, "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "[-]") {
+			continue
+		}
+		name, _, _ := strings.Cut(strings.TrimPrefix(line, "[-]"), " ")
+		failed = append(failed, name)
+	}
+	if len(failed) == 0 {
+		// The apiserver is not ready, although it did not say why
+		failed = append(failed, "readyz")
+	}
+	return failed
+}
+
+// validateControlPlane checks the etcd-manager members of each etcd cluster and the readiness of each apiserver.
+// Control-plane nodes that are not ready are already reported, so their apiservers are not checked.
+// The apiservers are probed on their /readyz endpoints, which also check the local member of the main etcd cluster.
+// If probe is nil or an apiserver cannot be reached, the readiness of its pod is checked instead.
+func (v *ValidationCluster) validateControlPlane(ctx context.Context, client kubernetes.Interface, cluster *kops.Cluster, nodes []v1.Node, readyNodes []v1.Node,
+	nodeInstanceGroupMapping map[string]*kops.InstanceGroup, probe apiServerProber,
+) error {
+	pods, err := client.CoreV1().Pods(metav1.NamespaceSystem).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("error listing Pods: %v", err)
+	}
+
+	// podsByApp indexes the kube-system pods by their k8s-app label, then by node
+	podsByApp := map[string]map[string]*v1.Pod{}
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		app := pod.GetLabels()["k8s-app"]
+		if app == "" || pod.Spec.NodeName == "" {
+			continue
+		}
+		if podsByApp[app] == nil {
+			podsByApp[app] = map[string]*v1.Pod{}
+		}
+		podsByApp[app][pod.Spec.NodeName] = pod
+	}
+
+	for _, etcdCluster := range cluster.Spec.EtcdClusters {
+		v.validateEtcdCluster(etcdCluster, podsByApp, nodes, nodeInstanceGroupMapping)
+	}
+
+	for i := range readyNodes {
+		node := &readyNodes[i]
+		ig := nodeInstanceGroupMapping[node.Name]
+		if ig == nil || !ig.HasAPIServer() {
+			continue
+		}
+		// A missing or failing apiserver pod is reported as a pod failure
+		pod := podsByApp["kube-apiserver"][node.Name]
+		if pod == nil || pod.Status.Phase != v1.PodRunning {
+			continue
+		}
+
+		if probe != nil {
+			failedChecks, err := probe(ctx, node)
+			if err == nil {
+				v.addAPIServerFailures(cluster, node, ig, failedChecks)
+				continue
+			}
+			v.addWarning(&ValidationError{
+				Kind:          ValidationErrorKindAPIServer,
+				Name:          node.Name,
+				Message:       fmt.Sprintf("cannot probe apiserver on node %q, checking the readiness of its pod instead: %v", node.Name, err),
+				InstanceGroup: ig,
+			})
+		}
+
+		// The kubelet probes the apiserver through its kube-apiserver-healthcheck sidecar, which queries
+		// /healthz with credentials, and the pod is not ready while the probe fails
+		if !isPodReady(pod) {
+			v.addError(&ValidationError{
+				Kind:          ValidationErrorKindAPIServer,
+				Name:          node.Name,
+				Message:       fmt.Sprintf("apiserver on node %q is not ready", node.Name),
+				InstanceGroup: ig,
+			})
+		}
+	}
+
+	return nil
+}
+
+// validateAPIServerEndpoint reports the checks that failed when the /readyz endpoint was queried through the
+// endpoint of the cluster, named host. The apiserver that answered is not known, so failures are not attributed
+// to a node or an etcd member.
+func (v *ValidationCluster) validateAPIServerEndpoint(ctx context.Context, host string, probe func(ctx context.Context) ([]string, error)) {
+	failedChecks, err := probe(ctx)
+	if err != nil {
+		v.addWarning(&ValidationError{
+			Kind:    ValidationErrorKindAPIServer,
+			Name:    host,
+			Message: fmt.Sprintf("cannot query the readiness of the apiserver at %q: %v", host, err),
+		})
+		return
+	}
+	if len(failedChecks) == 0 {
+		return
+	}
+	v.addError(&ValidationError{
+		Kind:    ValidationErrorKindAPIServer,
+		Name:    host,
+		Message: fmt.Sprintf("apiserver at %q is not ready, failing checks %s", host, strings.Join(failedChecks, ",")),
+	})
+}
+
+// addAPIServerFailures reports the checks that failed when the apiserver on a node was probed.
+// kOps points each apiserver at the member of the main etcd cluster on its own node, so a failed etcd check is
+// reported as a failure of that member.
+func (v *ValidationCluster) addAPIServerFailures(cluster *kops.Cluster, node *v1.Node, ig *kops.InstanceGroup, failedChecks []string) {
+	if len(failedChecks) == 0 {
+		return
+	}
+
+	v.addError(&ValidationError{
+		Kind:          ValidationErrorKindAPIServer,
+		Name:          node.Name,
+		Message:       fmt.Sprintf("apiserver on node %q is not ready, failing checks %s", node.Name, strings.Join(failedChecks, ",")),
+		InstanceGroup: ig,
+	})
+
+	for _, check := range failedChecks {
+		if check != "etcd" && !strings.HasPrefix(check, "etcd-") {
+			continue
+		}
+		for _, etcdCluster := range cluster.Spec.EtcdClusters {
+			if etcdCluster.Name != "main" {
+				continue
+			}
+			for _, member := range etcdCluster.Members {
+				if fi.ValueOf(member.InstanceGroup) == ig.Name {
+					v.addError(&ValidationError{
+						Kind:          ValidationErrorKindEtcdMember,
+						Name:          etcdCluster.Name + "/" + node.Name,
+						Message:       fmt.Sprintf("etcd cluster %q member on node %q is not healthy, as reported by the apiserver check %q", etcdCluster.Name, node.Name, check),
+						InstanceGroup: ig,
+					})
+					return
+				}
+			}
+		}
+	}
+}
+
+// validateEtcdCluster reports each node that should run a member of the etcd cluster but has no ready
+// etcd-manager pod, and whether the ready members are fewer than a quorum.
+func (v *ValidationCluster) validateEtcdCluster(etcdCluster kops.EtcdClusterSpec, podsByApp map[string]map[string]*v1.Pod, nodes []v1.Node,
+	nodeInstanceGroupMapping map[string]*kops.InstanceGroup,
+) {
+	if len(etcdCluster.Members) == 0 {
+		return
+	}
+
+	memberGroups := map[string]bool{}
+	for _, member := range etcdCluster.Members {
+		if member.InstanceGroup != nil {
+			memberGroups[*member.InstanceGroup] = true
+		}
+	}
+
+	// This matches the selector of the etcd-manager pods, see etcdmanager.SelectorForCluster
+	pods := podsByApp["etcd-manager-"+etcdCluster.Name]
+
+	ready := 0
+	for _, pod := range pods {
+		if isPodReady(pod) {
+			ready++
+		}
+	}
+
+	for _, node := range nodes {
+		ig := nodeInstanceGroupMapping[node.Name]
+		if ig == nil || !memberGroups[ig.Name] {
+			continue
+		}
+		pod := pods[node.Name]
+		if pod == nil {
+			v.addError(&ValidationError{
+				Kind:          ValidationErrorKindEtcdMember,
+				Name:          etcdCluster.Name + "/" + node.Name,
+				Message:       fmt.Sprintf("etcd cluster %q has no member on node %q", etcdCluster.Name, node.Name),
+				InstanceGroup: ig,
+			})
+		} else if !isPodReady(pod) {
+			v.addError(&ValidationError{
+				Kind:          ValidationErrorKindEtcdMember,
+				Name:          etcdCluster.Name + "/" + node.Name,
+				Message:       fmt.Sprintf("etcd cluster %q member on node %q is not ready", etcdCluster.Name, node.Name),
+				InstanceGroup: ig,
+			})
+		}
+	}
+
+	quorum := len(etcdCluster.Members)/2 + 1
+	if ready < quorum {
+		v.addError(&ValidationError{
+			Kind:    ValidationErrorKindEtcdQuorum,
+			Name:    etcdCluster.Name,
+			Message: fmt.Sprintf("etcd cluster %q has %d of %d members ready, fewer than the quorum of %d", etcdCluster.Name, ready, len(etcdCluster.Members), quorum),
+		})
+	}
+}
@@ -0,0 +1,256 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+	kopsapi "k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/upup/pkg/fi"
+)
+
+func controlPlaneTestNode(name string) v1.Node {
+	return v1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Status: v1.NodeStatus{
+			Conditions: []v1.NodeCondition{
+				{Type: "Ready", Status: v1.ConditionTrue},
+			},
+		},
+	}
+}
+
+func controlPlaneTestPod(name string, app string, node string, ready bool) *v1.Pod {
+	status := v1.ConditionFalse
+	if ready {
+		status = v1.ConditionTrue
+	}
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "kube-system",
+			Labels:    map[string]string{"k8s-app": app},
+		},
+		Spec: v1.PodSpec{
+			NodeName: node,
+		},
+		Status: v1.PodStatus{
+			Phase: v1.PodRunning,
+			Conditions: []v1.PodCondition{
+				{Type: v1.PodReady, Status: status},
+			},
+		},
+	}
+}
+
+func controlPlaneTestSetup(objects ...runtime.Object) (*kopsapi.Cluster, []v1.Node, map[string]*kopsapi.InstanceGroup, *fake.Clientset) {
+	cluster := &kopsapi.Cluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "testcluster.k8s.local"},
+	}
+	mapping := map[string]*kopsapi.InstanceGroup{}
+	var nodes []v1.Node
+	etcdCluster := kopsapi.EtcdClusterSpec{Name: "main"}
+	for _, zone := range []string{"a", "b", "c"} {
+		ig := &kopsapi.InstanceGroup{
+			ObjectMeta: metav1.ObjectMeta{Name: "master-1" + zone},
+			Spec: kopsapi.InstanceGroupSpec{
+				Role: kopsapi.InstanceGroupRoleControlPlane,
+			},
+		}
+		etcdCluster.Members = append(etcdCluster.Members, kopsapi.EtcdMemberSpec{
+			Name:          zone,
+			InstanceGroup: fi.PtrTo(ig.Name),
+		})
+		node := controlPlaneTestNode("master-1" + zone + ".local")
+		nodes = append(nodes, node)
+		mapping[node.Name] = ig
+	}
+	cluster.Spec.EtcdClusters = []kopsapi.EtcdClusterSpec{etcdCluster}
+
+	return cluster, nodes, mapping, fake.NewSimpleClientset(objects...)
+}
+
+func Test_ValidateEtcdMembers(t *testing.T) {
+	cluster, nodes, mapping, client := controlPlaneTestSetup(
+		controlPlaneTestPod("etcd-manager-main-a", "etcd-manager-main", "master-1a.local", true),
+		controlPlaneTestPod("etcd-manager-main-b", "etcd-manager-main", "master-1b.local", false),
+	)
+
+	v := &ValidationCluster{}
+	require.NoError(t, v.validateControlPlane(context.Background(), client, cluster, nodes, nil, mapping, nil))
+
+	assert.ElementsMatch(t, []*ValidationError{
+		{
+			Kind:          "EtcdMember",
+			Name:          "main/master-1b.local",
+			Message:       "etcd cluster \"main\" member on node \"master-1b.local\" is not ready",
+			InstanceGroup: mapping["master-1b.local"],
+		},
+		{
+			Kind:          "EtcdMember",
+			Name:          "main/master-1c.local",
+			Message:       "etcd cluster \"main\" has no member on node \"master-1c.local\"",
+			InstanceGroup: mapping["master-1c.local"],
+		},
+		{
+			Kind:    "EtcdQuorum",
+			Name:    "main",
+			Message: "etcd cluster \"main\" has 1 of 3 members ready, fewer than the quorum of 2",
+		},
+	}, v.Failures)
+}
+
+func Test_ValidateEtcdQuorum(t *testing.T) {
+	cluster, nodes, mapping, client := controlPlaneTestSetup(
+		controlPlaneTestPod("etcd-manager-main-a", "etcd-manager-main", "master-1a.local", true),
+		controlPlaneTestPod("etcd-manager-main-b", "etcd-manager-main", "master-1b.local", true),
+	)
+
+	v := &ValidationCluster{}
+	require.NoError(t, v.validateControlPlane(context.Background(), client, cluster, nodes, nil, mapping, nil))
+
+	if assert.Len(t, v.Failures, 1) {
+		assert.Equal(t, "EtcdMember", v.Failures[0].Kind, "a cluster with a quorum only reports the missing member")
+		assert.Equal(t, "main/master-1c.local", v.Failures[0].Name)
+	}
+}
+
+func Test_ValidateAPIServerReady(t *testing.T) {
+	cluster, nodes, mapping, client := controlPlaneTestSetup(
+		controlPlaneTestPod("kube-apiserver-a", "kube-apiserver", "master-1a.local", true),
+		controlPlaneTestPod("kube-apiserver-b", "kube-apiserver", "master-1b.local", false),
+	)
+	cluster.Spec.EtcdClusters = nil
+
+	v := &ValidationCluster{}
+	require.NoError(t, v.validateControlPlane(context.Background(), client, cluster, nodes, nodes, mapping, nil))
+
+	assert.Equal(t, []*ValidationError{
+		{
+			Kind:          "APIServer",
+			Name:          "master-1b.local",
+			Message:       "apiserver on node \"master-1b.local\" is not ready",
+			InstanceGroup: mapping["master-1b.local"],
+		},
+	}, v.Failures, "a pod that is not ready is reported, and a node without one is not")
+}
+
+func Test_ValidateAPIServerProbe(t *testing.T) {
+	cluster, nodes, mapping, client := controlPlaneTestSetup(
+		controlPlaneTestPod("kube-apiserver-a", "kube-apiserver", "master-1a.local", true),
+		controlPlaneTestPod("kube-apiserver-b", "kube-apiserver", "master-1b.local", true),
+		controlPlaneTestPod("kube-apiserver-c", "kube-apiserver", "master-1c.local", false),
+		controlPlaneTestPod("etcd-manager-main-a", "etcd-manager-main", "master-1a.local", true),
+		controlPlaneTestPod("etcd-manager-main-b", "etcd-manager-main", "master-1b.local", true),
+		controlPlaneTestPod("etcd-manager-main-c", "etcd-manager-main", "master-1c.local", true),
+	)
+	probe := func(ctx context.Context, node *v1.Node) ([]string, error) {
+		switch node.Name {
+		case "master-1a.local":
+			return nil, nil
+		case "master-1b.local":
+			return []string{"etcd", "etcd-readiness"}, nil
+		default:
+			return nil, fmt.Errorf("dial tcp: i/o timeout")
+		}
+	}
+
+	v := &ValidationCluster{}
+	require.NoError(t, v.validateControlPlane(context.Background(), client, cluster, nodes, nodes, mapping, probe))
+
+	assert.Equal(t, []*ValidationError{
+		{
+			Kind:          "APIServer",
+			Name:          "master-1b.local",
+			Message:       "apiserver on node \"master-1b.local\" is not ready, failing checks etcd,etcd-readiness",
+			InstanceGroup: mapping["master-1b.local"],
+		},
+		{
+			Kind:          "EtcdMember",
+			Name:          "main/master-1b.local",
+			Message:       "etcd cluster \"main\" member on node \"master-1b.local\" is not healthy, as reported by the apiserver check \"etcd\"",
+			InstanceGroup: mapping["master-1b.local"],
+		},
+		{
+			Kind:          "APIServer",
+			Name:          "master-1c.local",
+			Message:       "apiserver on node \"master-1c.local\" is not ready",
+			InstanceGroup: mapping["master-1c.local"],
+		},
+	}, v.Failures, "the failed checks of a probed apiserver are reported, and the pod of one that cannot be reached is checked")
+	assert.Equal(t, []*ValidationError{
+		{
+			Kind:          "APIServer",
+			Name:          "master-1c.local",
+			Message:       "cannot probe apiserver on node \"master-1c.local\", checking the readiness of its pod instead: dial tcp: i/o timeout",
+			InstanceGroup: mapping["master-1c.local"],
+		},
+	}, v.Warnings)
+}
+
+func Test_ValidateAPIServerEndpoint(t *testing.T) {
+	var path string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte("[+]ping ok\n[-]etcd failed: reason withheld\nreadyz check failed\n"))
+	}))
+	defer server.Close()
+
+	v := &ValidationCluster{}
+	v.validateAPIServerEndpoint(context.Background(), server.URL, newAPIServerEndpointProber(&rest.Config{Host: server.URL}))
+
+	assert.Equal(t, "/readyz", path, "the endpoint of the cluster is queried")
+	assert.Equal(t, []*ValidationError{
+		{
+			Kind:    "APIServer",
+			Name:    server.URL,
+			Message: fmt.Sprintf("apiserver at %q is not ready, failing checks etcd", server.URL),
+		},
+	}, v.Failures)
+	assert.Empty(t, v.Warnings)
+
+	v = &ValidationCluster{}
+	v.validateAPIServerEndpoint(context.Background(), "https://api.example.com", func(ctx context.Context) ([]string, error) {
+		return nil, fmt.Errorf("connection refused")
+	})
+	assert.Empty(t, v.Failures)
+	assert.Equal(t, []*ValidationError{
+		{
+			Kind:    "APIServer",
+			Name:    "https://api.example.com",
+			Message: "cannot query the readiness of the apiserver at \"https://api.example.com\": connection refused",
+		},
+	}, v.Warnings, "an endpoint that cannot be queried is a warning")
+}
+
+func Test_ParseFailedReadyzChecks(t *testing.T) {
+	body := "[+]ping ok\n[+]log ok\n[-]etcd failed: reason withheld\n[+]etcd-readiness ok\n[-]informer-sync failed: reason withheld\nreadyz check failed\n"
+	assert.Equal(t, []string{"etcd", "informer-sync"}, parseFailedReadyzChecks(body))
+	assert.Equal(t, []string{"readyz"}, parseFailedReadyzChecks("readyz check failed\n"), "an apiserver that is not ready without saying why")
+}
@@ -0,0 +1,236 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/kops/pkg/apis/kops"
+)
+
+// healthCheckHTTPTimeout is the maximum time to wait for the endpoint of an HTTP health check.
+var healthCheckHTTPTimeout = 10 * time.Second
+
+// HealthCheckResult is the result of one of the health checks of the cluster spec.
+type HealthCheckResult struct {
+	// Name is the name of the health check.
+	Name string `json:"name"`
+	// Type is the type of the health check: Workload, PodDisruptionBudget, HTTP or Pods.
+	Type string `json:"type"`
+	// Healthy is true if the health check passed.
+	Healthy bool `json:"healthy"`
+	// Message describes why the health check failed.
+	Message string `json:"message,omitempty"`
+}
+
+// runHealthChecks runs the health checks, recording their results and adding a failure for each that fails.
+func (v *ValidationCluster) runHealthChecks(ctx context.Context, client kubernetes.Interface, checks []kops.ClusterHealthCheck) {
+	for i := range checks {
+		check := &checks[i]
+		result := &HealthCheckResult{
+			Name: check.Name,
+		}
+
+		var err error
+		switch {
+		case check.Workload != nil:
+			result.Type = "Workload"
+			err = checkWorkload(ctx, client, check.Workload)
+		case check.PodDisruptionBudget != nil:
+			result.Type = "PodDisruptionBudget"
+			err = checkPodDisruptionBudgets(ctx, client, check.PodDisruptionBudget)
+		case check.HTTP != nil:
+			result.Type = "HTTP"
+			err = checkHTTP(ctx, check.HTTP)
+		case check.Pods != nil:
+			result.Type = "Pods"
+			err = checkPods(ctx, client, check.Pods)
+		default:
+			err = fmt.Errorf("health check has nothing to check")
+		}
+
+		if err != nil {
+			result.Message = err.Error()
+			v.addError(&ValidationError{
+				Kind:    ValidationErrorKindHealthCheck,
+				Name:    check.Name,
+				Message: fmt.Sprintf("health check %q failed: %v", check.Name, err),
+			})
+		} else {
+			result.Healthy = true
+		}
+		v.HealthChecks = append(v.HealthChecks, result)
+	}
+}
+
+// checkWorkload returns an error unless all the replicas of the Deployment or StatefulSet are updated and available.
+func checkWorkload(ctx context.Context, client kubernetes.Interface, check *kops.WorkloadHealthCheck) error {
+	id := check.Namespace + "/" + check.Name
+
+	var generation, observedGeneration int64
+	var replicas, updatedReplicas, availableReplicas int32
+	switch check.Kind {
+	case kops.WorkloadHealthCheckKindDeployment:
+		d, err := client.AppsV1().Deployments(check.Namespace).Get(ctx, check.Name, metav1.GetOptions{})
+		if err != nil {
+			return fmt.Errorf("error getting Deployment %s: %v", id, err)
+		}
+		replicas = 1
+		if d.Spec.Replicas != nil {
+			replicas = *d.Spec.Replicas
+		}
+		generation, observedGeneration = d.Generation, d.Status.ObservedGeneration
+		updatedReplicas, availableReplicas = d.Status.UpdatedReplicas, d.Status.AvailableReplicas
+	case kops.WorkloadHealthCheckKindStatefulSet:
+		s, err := client.AppsV1().StatefulSets(check.Namespace).Get(ctx, check.Name, metav1.GetOptions{})
+		if err != nil {
+			return fmt.Errorf("error getting StatefulSet %s: %v", id, err)
+		}
+		replicas = 1
+		if s.Spec.Replicas != nil {
+			replicas = *s.Spec.Replicas
+		}
+		generation, observedGeneration = s.Generation, s.Status.ObservedGeneration
+		updatedReplicas, availableReplicas = s.Status.UpdatedReplicas, s.Status.AvailableReplicas
+	default:
+		return fmt.Errorf("unsupported workload kind %q", check.Kind)
+	}
+
+	if observedGeneration < generation {
+		return fmt.Errorf("%s %s has not yet observed its latest spec", check.Kind, id)
+	}
+	if updatedReplicas < replicas {
+		return fmt.Errorf("%s %s has %d of %d replicas updated", check.Kind, id, updatedReplicas, replicas)
+	}
+	if availableReplicas < replicas {
+		return fmt.Errorf("%s %s has %d of %d replicas available", check.Kind, id, availableReplicas, replicas)
+	}
+	return nil
+}
+
+// checkPodDisruptionBudgets returns an error if any of the PodDisruptionBudgets does not allow a disruption.
+func checkPodDisruptionBudgets(ctx context.Context, client kubernetes.Interface, check *kops.PodDisruptionBudgetHealthCheck) error {
+	pdbs := client.PolicyV1().PodDisruptionBudgets(check.Namespace)
+
+	var names []string
+	if check.Name != "" {
+		pdb, err := pdbs.Get(ctx, check.Name, metav1.GetOptions{})
+		if err != nil {
+			return fmt.Errorf("error getting PodDisruptionBudget %s/%s: %v", check.Namespace, check.Name, err)
+		}
+		if pdb.Status.DisruptionsAllowed < 1 {
+			names = append(names, pdb.Namespace+"/"+pdb.Name)
+		}
+	} else {
+		list, err := pdbs.List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return fmt.Errorf("error listing PodDisruptionBudgets: %v", err)
+		}
+		for i := range list.Items {
+			pdb := &list.Items[i]
+			if pdb.Status.DisruptionsAllowed < 1 {
+				names = append(names, pdb.Namespace+"/"+pdb.Name)
+			}
+		}
+	}
+
+	if len(names) != 0 {
+		return fmt.Errorf("PodDisruptionBudget does not allow disruption: %s", strings.Join(names, ", "))
+	}
+	return nil
+}
+
+// checkHTTP returns an error unless the endpoint returns a successful status.
+func checkHTTP(ctx context.Context, check *kops.HTTPHealthCheck) error {
+	ctx, cancel := context.WithTimeout(ctx, healthCheckHTTPTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, check.URL, nil)
+	if err != nil {
+		return fmt.Errorf("error building request for %s: %v", check.URL, err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("error requesting %s: %v", check.URL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("%s returned %s", check.URL, resp.Status)
+	}
+	return nil
+}
+
+// checkPods returns an error unless all the pods matching the selector, and at least the minimum number of them, are ready.
+func checkPods(ctx context.Context, client kubernetes.Interface, check *kops.PodsHealthCheck) error {
+	selector, err := labels.Parse(check.Selector)
+	if err != nil {
+		return fmt.Errorf("error parsing selector %q: %v", check.Selector, err)
+	}
+	pods, err := client.CoreV1().Pods(check.Namespace).List(ctx, metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return fmt.Errorf("error listing pods matching %q: %v", check.Selector, err)
+	}
+
+	minReady := int32(1)
+	if check.MinReady != nil {
+		minReady = *check.MinReady
+	}
+
+	ready := int32(0)
+	var notReady []string
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if pod.Status.Phase == v1.PodSucceeded {
+			continue
+		}
+		if isPodReady(pod) {
+			ready++
+		} else {
+			notReady = append(notReady, pod.Namespace+"/"+pod.Name)
+		}
+	}
+
+	if len(notReady) != 0 {
+		return fmt.Errorf("pods matching %q are not ready: %s", check.Selector, strings.Join(notReady, ", "))
+	}
+	if ready < minReady {
+		return fmt.Errorf("%d pods matching %q are ready, but %d are required", ready, check.Selector, minReady)
+	}
+	return nil
+}
+
+// isPodReady returns true if the pod is running and has the Ready condition.
+func isPodReady(pod *v1.Pod) bool {
+	if pod.DeletionTimestamp != nil || pod.Status.Phase != v1.PodRunning {
+		return false
+	}
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == v1.PodReady {
+			return condition.Status == v1.ConditionTrue
+		}
+	}
+	return false
+}
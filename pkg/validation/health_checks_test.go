@@ -0,0 +1,172 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	kopsapi "k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/upup/pkg/fi"
+)
+
+func testPod(name string, ready bool) *v1.Pod {
+	status := v1.ConditionFalse
+	if ready {
+		status = v1.ConditionTrue
+	}
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "kafka",
+			Name:      name,
+			Labels:    map[string]string{"app": "kafka"},
+		},
+		Status: v1.PodStatus{
+			Phase: v1.PodRunning,
+			Conditions: []v1.PodCondition{
+				{Type: v1.PodReady, Status: status},
+			},
+		},
+	}
+}
+
+func Test_HealthChecks(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/-/healthy" {
+			http.Error(w, "not healthy", http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	objects := []runtime.Object{
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ingress", Name: "available", Generation: 2},
+			Spec:       appsv1.DeploymentSpec{Replicas: fi.PtrTo(int32(2))},
+			Status:     appsv1.DeploymentStatus{ObservedGeneration: 2, Replicas: 2, UpdatedReplicas: 2, AvailableReplicas: 2},
+		},
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ingress", Name: "rolling", Generation: 2},
+			Spec:       appsv1.DeploymentSpec{Replicas: fi.PtrTo(int32(2))},
+			Status:     appsv1.DeploymentStatus{ObservedGeneration: 2, Replicas: 3, UpdatedReplicas: 1, AvailableReplicas: 2},
+		},
+		&appsv1.StatefulSet{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "kafka", Name: "kafka", Generation: 1},
+			Spec:       appsv1.StatefulSetSpec{Replicas: fi.PtrTo(int32(3))},
+			Status:     appsv1.StatefulSetStatus{ObservedGeneration: 1, Replicas: 3, UpdatedReplicas: 3, AvailableReplicas: 2},
+		},
+		&policyv1.PodDisruptionBudget{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ingress", Name: "ingress"},
+			Status:     policyv1.PodDisruptionBudgetStatus{DisruptionsAllowed: 1},
+		},
+		&policyv1.PodDisruptionBudget{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "kafka", Name: "kafka"},
+			Status:     policyv1.PodDisruptionBudgetStatus{DisruptionsAllowed: 0},
+		},
+		testPod("kafka-0", true),
+		testPod("kafka-1", true),
+		testPod("kafka-2", false),
+	}
+
+	grid := []struct {
+		Check           kopsapi.ClusterHealthCheck
+		ExpectedType    string
+		ExpectedMessage string
+	}{
+		{
+			Check:        kopsapi.ClusterHealthCheck{Workload: &kopsapi.WorkloadHealthCheck{Kind: "Deployment", Namespace: "ingress", Name: "available"}},
+			ExpectedType: "Workload",
+		},
+		{
+			Check:           kopsapi.ClusterHealthCheck{Workload: &kopsapi.WorkloadHealthCheck{Kind: "Deployment", Namespace: "ingress", Name: "rolling"}},
+			ExpectedType:    "Workload",
+			ExpectedMessage: "Deployment ingress/rolling has 1 of 2 replicas updated",
+		},
+		{
+			Check:           kopsapi.ClusterHealthCheck{Workload: &kopsapi.WorkloadHealthCheck{Kind: "StatefulSet", Namespace: "kafka", Name: "kafka"}},
+			ExpectedType:    "Workload",
+			ExpectedMessage: "StatefulSet kafka/kafka has 2 of 3 replicas available",
+		},
+		{
+			Check:        kopsapi.ClusterHealthCheck{PodDisruptionBudget: &kopsapi.PodDisruptionBudgetHealthCheck{Namespace: "ingress"}},
+			ExpectedType: "PodDisruptionBudget",
+		},
+		{
+			Check:           kopsapi.ClusterHealthCheck{PodDisruptionBudget: &kopsapi.PodDisruptionBudgetHealthCheck{}},
+			ExpectedType:    "PodDisruptionBudget",
+			ExpectedMessage: "PodDisruptionBudget does not allow disruption: kafka/kafka",
+		},
+		{
+			Check:        kopsapi.ClusterHealthCheck{HTTP: &kopsapi.HTTPHealthCheck{URL: server.URL + "/-/healthy"}},
+			ExpectedType: "HTTP",
+		},
+		{
+			Check:           kopsapi.ClusterHealthCheck{HTTP: &kopsapi.HTTPHealthCheck{URL: server.URL + "/-/ready"}},
+			ExpectedType:    "HTTP",
+			ExpectedMessage: server.URL + "/-/ready returned 503 Service Unavailable",
+		},
+		{
+			Check:           kopsapi.ClusterHealthCheck{Pods: &kopsapi.PodsHealthCheck{Namespace: "kafka", Selector: "app=kafka"}},
+			ExpectedType:    "Pods",
+			ExpectedMessage: `pods matching "app=kafka" are not ready: kafka/kafka-2`,
+		},
+		{
+			Check:           kopsapi.ClusterHealthCheck{Pods: &kopsapi.PodsHealthCheck{Selector: "app=zookeeper"}},
+			ExpectedType:    "Pods",
+			ExpectedMessage: `0 pods matching "app=zookeeper" are ready, but 1 are required`,
+		},
+		{
+			Check:        kopsapi.ClusterHealthCheck{Pods: &kopsapi.PodsHealthCheck{Selector: "app=zookeeper", MinReady: fi.PtrTo(int32(0))}},
+			ExpectedType: "Pods",
+		},
+	}
+
+	for _, g := range grid {
+		g.Check.Name = "check"
+		v := &ValidationCluster{}
+		v.runHealthChecks(context.Background(), fake.NewSimpleClientset(objects...), []kopsapi.ClusterHealthCheck{g.Check})
+
+		if !assert.Len(t, v.HealthChecks, 1) {
+			continue
+		}
+		result := v.HealthChecks[0]
+		assert.Equal(t, "check", result.Name)
+		assert.Equal(t, g.ExpectedType, result.Type)
+		assert.Equal(t, g.ExpectedMessage, result.Message)
+		if g.ExpectedMessage == "" {
+			assert.True(t, result.Healthy)
+			assert.Empty(t, v.Failures)
+		} else {
+			assert.False(t, result.Healthy)
+			if assert.Len(t, v.Failures, 1) {
+				assert.Equal(t, &ValidationError{
+					Kind:    "HealthCheck",
+					Name:    "check",
+					Message: `health check "check" failed: ` + g.ExpectedMessage,
+				}, v.Failures[0])
+			}
+		}
+	}
+}
@@ -0,0 +1,85 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"fmt"
+
+	"github.com/blang/semver/v4"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+	"k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/pkg/apis/kops/util"
+)
+
+// validateKubeletVersions reports the nodes whose kubelet version differs from the KubernetesVersion of the cluster.
+// A kubelet within the supported version skew, such as while a rolling update replaces the nodes after an upgrade,
+// is a warning. A kubelet that is newer than the cluster, or older than the skew allows, is a failure.
+func (v *ValidationCluster) validateKubeletVersions(cluster *kops.Cluster, nodes []v1.Node, nodeInstanceGroupMapping map[string]*kops.InstanceGroup) {
+	if cluster.Spec.KubernetesVersion == "" {
+		return
+	}
+	clusterVersion, err := util.ParseKubernetesVersion(cluster.Spec.KubernetesVersion)
+	if err != nil {
+		klog.V(2).Infof("not checking kubelet versions: %v", err)
+		return
+	}
+
+	for _, node := range nodes {
+		ig := nodeInstanceGroupMapping[node.Name]
+		if ig == nil || node.Status.NodeInfo.KubeletVersion == "" {
+			continue
+		}
+		kubeletVersion, err := semver.ParseTolerant(node.Status.NodeInfo.KubeletVersion)
+		if err != nil {
+			klog.Warningf("unable to parse kubelet version %q of node %q: %v", node.Status.NodeInfo.KubeletVersion, node.Name, err)
+			continue
+		}
+		if kubeletVersion.Major == clusterVersion.Major && kubeletVersion.Minor == clusterVersion.Minor && kubeletVersion.Patch == clusterVersion.Patch {
+			continue
+		}
+
+		failure := &ValidationError{
+			Kind:          ValidationErrorKindKubeletVersion,
+			Name:          node.Name,
+			InstanceGroup: ig,
+		}
+		skew := int(clusterVersion.Minor) - int(kubeletVersion.Minor)
+		switch {
+		case kubeletVersion.Major != clusterVersion.Major:
+			failure.Message = fmt.Sprintf("kubelet version %s of node %q has a different major version than the cluster version %s", kubeletVersion, node.Name, clusterVersion)
+			v.addError(failure)
+		case skew < 0:
+			failure.Message = fmt.Sprintf("kubelet version %s of node %q is newer than the cluster version %s", kubeletVersion, node.Name, clusterVersion)
+			v.addError(failure)
+		case skew > maxKubeletVersionSkew(*clusterVersion):
+			failure.Message = fmt.Sprintf("kubelet version %s of node %q is more than %d minor versions older than the cluster version %s", kubeletVersion, node.Name, maxKubeletVersionSkew(*clusterVersion), clusterVersion)
+			v.addError(failure)
+		default:
+			failure.Message = fmt.Sprintf("kubelet version %s of node %q does not match the cluster version %s", kubeletVersion, node.Name, clusterVersion)
+			v.addWarning(failure)
+		}
+	}
+}
+
+// maxKubeletVersionSkew returns how many minor versions older than the apiserver a kubelet may be.
+func maxKubeletVersionSkew(clusterVersion semver.Version) int {
+	if util.IsKubernetesGTE("1.28", clusterVersion) {
+		return 3
+	}
+	return 2
+}
@@ -0,0 +1,114 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kopsapi "k8s.io/kops/pkg/apis/kops"
+)
+
+func Test_ValidateKubeletVersions(t *testing.T) {
+	grid := []struct {
+		clusterVersion string
+		kubeletVersion string
+		failure        string
+		warning        string
+	}{
+		{
+			clusterVersion: "1.27.3",
+			kubeletVersion: "v1.27.3",
+		},
+		{
+			clusterVersion: "1.27.3",
+			kubeletVersion: "",
+		},
+		{
+			clusterVersion: "1.27.3",
+			kubeletVersion: "v1.26.6",
+			warning:        "kubelet version 1.26.6 of node \"node-1a\" does not match the cluster version 1.27.3",
+		},
+		{
+			clusterVersion: "1.27.3",
+			kubeletVersion: "v1.24.15",
+			failure:        "kubelet version 1.24.15 of node \"node-1a\" is more than 2 minor versions older than the cluster version 1.27.3",
+		},
+		{
+			clusterVersion: "1.28.0",
+			kubeletVersion: "v1.25.11",
+			warning:        "kubelet version 1.25.11 of node \"node-1a\" does not match the cluster version 1.28.0",
+		},
+		{
+			clusterVersion: "1.27.3",
+			kubeletVersion: "v1.28.0",
+			failure:        "kubelet version 1.28.0 of node \"node-1a\" is newer than the cluster version 1.27.3",
+		},
+	}
+	for _, g := range grid {
+		t.Run(g.clusterVersion+"/"+g.kubeletVersion, func(t *testing.T) {
+			cluster := &kopsapi.Cluster{
+				Spec: kopsapi.ClusterSpec{
+					KubernetesVersion: g.clusterVersion,
+				},
+			}
+			ig := &kopsapi.InstanceGroup{
+				ObjectMeta: metav1.ObjectMeta{Name: "node-1"},
+			}
+			nodes := []v1.Node{
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "node-1a"},
+					Status: v1.NodeStatus{
+						NodeInfo: v1.NodeSystemInfo{KubeletVersion: g.kubeletVersion},
+					},
+				},
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "not-validated"},
+					Status: v1.NodeStatus{
+						NodeInfo: v1.NodeSystemInfo{KubeletVersion: "v1.20.0"},
+					},
+				},
+			}
+
+			v := &ValidationCluster{}
+			v.validateKubeletVersions(cluster, nodes, map[string]*kopsapi.InstanceGroup{"node-1a": ig})
+
+			var failures, warnings []string
+			for _, failure := range v.Failures {
+				assert.Equal(t, "KubeletVersion", failure.Kind)
+				assert.Equal(t, ig, failure.InstanceGroup)
+				failures = append(failures, failure.Message)
+			}
+			for _, warning := range v.Warnings {
+				assert.Equal(t, "KubeletVersion", warning.Kind)
+				warnings = append(warnings, warning.Message)
+			}
+			if g.failure == "" {
+				assert.Empty(t, failures)
+			} else {
+				assert.Equal(t, []string{g.failure}, failures)
+			}
+			if g.warning == "" {
+				assert.Empty(t, warnings)
+			} else {
+				assert.Equal(t, []string{g.warning}, warnings)
+			}
+		})
+	}
+}
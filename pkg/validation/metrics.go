@@ -0,0 +1,108 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/kops/pkg/apis/kops"
+)
+
+// Metrics exports the results of cluster validations as Prometheus gauges.
+type Metrics struct {
+	healthy  prometheus.Gauge
+	errored  prometheus.Gauge
+	duration prometheus.Gauge
+	failures *prometheus.GaugeVec
+	nodes    *prometheus.GaugeVec
+}
+
+// NewMetrics creates the validation gauges and registers them with registerer.
+func NewMetrics(registerer prometheus.Registerer) (*Metrics, error) {
+	m := &Metrics{
+		healthy: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "kops_validation_healthy",
+			Help: "Whether the last validation of the cluster found no failures.",
+		}),
+		errored: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "kops_validation_error",
+			Help: "Whether the last validation of the cluster could not be completed.",
+		}),
+		duration: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "kops_validation_duration_seconds",
+			Help: "How long the last validation of the cluster took.",
+		}),
+		failures: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "kops_validation_failures",
+			Help: "Number of failures found by the last validation of the cluster, by kind and instance group.",
+		}, []string{"kind", "instance_group"}),
+		nodes: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "kops_validation_nodes",
+			Help: "Number of nodes found by the last validation of the cluster, by instance group and readiness.",
+		}, []string{"instance_group", "ready"}),
+	}
+
+	for _, collector := range []prometheus.Collector{m.healthy, m.errored, m.duration, m.failures, m.nodes} {
+		if err := registerer.Register(collector); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// Record updates the gauges with the result of a validation that took duration. If the validation
+// returned err, the cluster is reported as not healthy, and the failures and nodes of the previous
+// result are kept. The nodes of each of instanceGroups are reported, even if there are none.
+func (m *Metrics) Record(result *ValidationCluster, err error, duration time.Duration, instanceGroups []kops.InstanceGroup) {
+	m.duration.Set(duration.Seconds())
+	if err != nil {
+		m.healthy.Set(0)
+		m.errored.Set(1)
+		return
+	}
+	m.errored.Set(0)
+
+	if len(result.Failures) == 0 {
+		m.healthy.Set(1)
+	} else {
+		m.healthy.Set(0)
+	}
+
+	m.failures.Reset()
+	for _, failure := range result.Failures {
+		instanceGroup := ""
+		if failure.InstanceGroup != nil {
+			instanceGroup = failure.InstanceGroup.Name
+		}
+		m.failures.WithLabelValues(failure.Kind, instanceGroup).Inc()
+	}
+
+	m.nodes.Reset()
+	for _, ig := range instanceGroups {
+		m.nodes.WithLabelValues(ig.Name, "true").Set(0)
+		m.nodes.WithLabelValues(ig.Name, "false").Set(0)
+	}
+	for _, node := range result.Nodes {
+		ready := "false"
+		if node.Status == v1.ConditionTrue {
+			ready = "true"
+		}
+		m.nodes.WithLabelValues(node.InstanceGroup, ready).Inc()
+	}
+}
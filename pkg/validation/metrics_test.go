@@ -0,0 +1,111 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kopsapi "k8s.io/kops/pkg/apis/kops"
+)
+
+// gatherGauges returns the values of the gauges in registry, by name and labels.
+func gatherGauges(t *testing.T, registry *prometheus.Registry) map[string]float64 {
+	families, err := registry.Gather()
+	require.NoError(t, err)
+	gauges := map[string]float64{}
+	for _, family := range families {
+		for _, metric := range family.GetMetric() {
+			var labels []string
+			for _, label := range metric.GetLabel() {
+				labels = append(labels, fmt.Sprintf("%s=%q", label.GetName(), label.GetValue()))
+			}
+			sort.Strings(labels)
+			gauges[family.GetName()+"{"+strings.Join(labels, ",")+"}"] = metric.GetGauge().GetValue()
+		}
+	}
+	return gauges
+}
+
+func TestMetrics(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	metrics, err := NewMetrics(registry)
+	require.NoError(t, err)
+
+	nodes := kopsapi.InstanceGroup{ObjectMeta: metav1.ObjectMeta{Name: "nodes"}}
+	master := kopsapi.InstanceGroup{ObjectMeta: metav1.ObjectMeta{Name: "master"}}
+	instanceGroups := []kopsapi.InstanceGroup{master, nodes}
+
+	metrics.Record(&ValidationCluster{
+		Failures: []*ValidationError{
+			{Kind: "Node", Name: "node-a", InstanceGroup: &nodes},
+			{Kind: "Node", Name: "node-b", InstanceGroup: &nodes},
+			{Kind: "EtcdQuorum", Name: "main"},
+		},
+		Nodes: []*ValidationNode{
+			{Name: "node-a", InstanceGroup: "nodes", Status: v1.ConditionFalse},
+			{Name: "node-b", InstanceGroup: "nodes", Status: v1.ConditionUnknown},
+			{Name: "node-c", InstanceGroup: "nodes", Status: v1.ConditionTrue},
+		},
+	}, nil, 2*time.Second, instanceGroups)
+
+	assert.Equal(t, map[string]float64{
+		`kops_validation_healthy{}`:                                     0,
+		`kops_validation_error{}`:                                       0,
+		`kops_validation_duration_seconds{}`:                            2,
+		`kops_validation_failures{instance_group="",kind="EtcdQuorum"}`: 1,
+		`kops_validation_failures{instance_group="nodes",kind="Node"}`:  2,
+		`kops_validation_nodes{instance_group="master",ready="false"}`:  0,
+		`kops_validation_nodes{instance_group="master",ready="true"}`:   0,
+		`kops_validation_nodes{instance_group="nodes",ready="false"}`:   2,
+		`kops_validation_nodes{instance_group="nodes",ready="true"}`:    1,
+	}, gatherGauges(t, registry))
+
+	// A failed validation keeps the previous results
+	metrics.Record(nil, fmt.Errorf("error listing nodes"), time.Second, instanceGroups)
+	gauges := gatherGauges(t, registry)
+	assert.Equal(t, float64(1), gauges[`kops_validation_error{}`])
+	assert.Equal(t, float64(2), gauges[`kops_validation_failures{instance_group="nodes",kind="Node"}`])
+
+	// Resolved failures are no longer reported
+	metrics.Record(&ValidationCluster{
+		Nodes: []*ValidationNode{
+			{Name: "node-c", InstanceGroup: "nodes", Status: v1.ConditionTrue},
+		},
+	}, nil, time.Second, instanceGroups)
+	gauges = gatherGauges(t, registry)
+	assert.Equal(t, float64(1), gauges[`kops_validation_healthy{}`])
+	assert.Equal(t, float64(0), gauges[`kops_validation_error{}`])
+	for name := range gauges {
+		assert.NotContains(t, name, "kops_validation_failures", "resolved failure")
+	}
+	assert.Equal(t, float64(0), gauges[`kops_validation_nodes{instance_group="nodes",ready="false"}`])
+
+	// A cluster that cannot be validated is not reported as healthy
+	metrics.Record(nil, fmt.Errorf("error listing nodes"), time.Second, instanceGroups)
+	gauges = gatherGauges(t, registry)
+	assert.Equal(t, float64(0), gauges[`kops_validation_healthy{}`])
+	assert.Equal(t, float64(1), gauges[`kops_validation_error{}`])
+}
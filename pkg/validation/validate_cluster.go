@@ -23,6 +23,7 @@ import (
 	"net/url"
 	"sort"
 	"strings"
+	"time"
 
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/tools/pager"
@@ -33,6 +34,7 @@ import (
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
 	"k8s.io/klog/v2"
 	"k8s.io/kops/pkg/cloudinstances"
 )
@@ -42,8 +44,29 @@ type ValidationCluster struct {
 	Failures []*ValidationError `json:"failures,omitempty"`
 
 	Nodes []*ValidationNode `json:"nodes,omitempty"`
+
+	// HealthChecks are the results of the health checks of the cluster spec.
+	HealthChecks []*HealthCheckResult `json:"healthChecks,omitempty"`
+
+	// Warnings are problems that do not fail validation, such as certificates that expire soon.
+	Warnings []*ValidationError `json:"warnings,omitempty"`
 }
 
+// Kinds of ValidationError, which consumers of the validation output may filter on.
+const (
+	ValidationErrorKindDNS            = "dns"
+	ValidationErrorKindPod            = "Pod"
+	ValidationErrorKindNode           = "Node"
+	ValidationErrorKindInstanceGroup  = "InstanceGroup"
+	ValidationErrorKindMachine        = "Machine"
+	ValidationErrorKindHealthCheck    = "HealthCheck"
+	ValidationErrorKindEtcdMember     = "EtcdMember"
+	ValidationErrorKindEtcdQuorum     = "EtcdQuorum"
+	ValidationErrorKindAPIServer      = "APIServer"
+	ValidationErrorKindCertificate    = "Certificate"
+	ValidationErrorKindKubeletVersion = "KubeletVersion"
+)
+
 // ValidationError holds a validation failure
 type ValidationError struct {
 	Kind    string `json:"type,omitempty"`
@@ -64,12 +87,20 @@ type clusterValidatorImpl struct {
 	instanceGroups []*kops.InstanceGroup
 	host           string
 	k8sClient      kubernetes.Interface
+	keystore       fi.CAStore
+	probeAPIServer apiServerProber
+	// probeEndpoint is used instead of probeAPIServer when the nodes are not probed
+	probeEndpoint func(ctx context.Context) ([]string, error)
 }
 
 func (v *ValidationCluster) addError(failure *ValidationError) {
 	v.Failures = append(v.Failures, failure)
 }
 
+func (v *ValidationCluster) addWarning(warning *ValidationError) {
+	v.Warnings = append(v.Warnings, warning)
+}
+
 // ValidationNode represents the validation status for a node
 type ValidationNode struct {
 	Name     string             `json:"name,omitempty"`
@@ -77,6 +108,8 @@ type ValidationNode struct {
 	Role     string             `json:"role,omitempty"`
 	Hostname string             `json:"hostname,omitempty"`
 	Status   v1.ConditionStatus `json:"status,omitempty"`
+	// InstanceGroup is the name of the instance group of the node.
+	InstanceGroup string `json:"instanceGroup,omitempty"`
 }
 
 // hasPlaceHolderIP checks if the API DNS has been updated.
@@ -100,7 +133,11 @@ func hasPlaceHolderIP(host string) (string, error) {
 	return "", nil
 }
 
-func NewClusterValidator(cluster *kops.Cluster, cloud fi.Cloud, instanceGroupList *kops.InstanceGroupList, host string, k8sClient kubernetes.Interface) (ClusterValidator, error) {
+// NewClusterValidator returns a ClusterValidator for the cluster. The readiness of the apiservers is queried with the
+// credentials of restConfig: if probeNodes is set, the apiserver on each control-plane node is queried on the node's
+// address, which needs network access to the nodes, otherwise the endpoint that restConfig connects to is queried.
+// The certificates in keystore are checked for expiry; if it is nil, a warning says they were not checked.
+func NewClusterValidator(cluster *kops.Cluster, cloud fi.Cloud, instanceGroupList *kops.InstanceGroupList, restConfig *rest.Config, k8sClient kubernetes.Interface, keystore fi.CAStore, probeNodes bool) (ClusterValidator, error) {
 	var instanceGroups []*kops.InstanceGroup
 
 	for i := range instanceGroupList.Items {
@@ -112,13 +149,24 @@ func NewClusterValidator(cluster *kops.Cluster, cloud fi.Cloud, instanceGroupLis
 		return nil, fmt.Errorf("no InstanceGroup objects found")
 	}
 
-	return &clusterValidatorImpl{
+	validator := &clusterValidatorImpl{
 		cluster:        cluster,
 		cloud:          cloud,
 		instanceGroups: instanceGroups,
-		host:           host,
+		host:           restConfig.Host,
 		k8sClient:      k8sClient,
-	}, nil
+		keystore:       keystore,
+	}
+	if probeNodes {
+		probeAPIServer, err := newAPIServerProber(cluster, restConfig)
+		if err != nil {
+			return nil, err
+		}
+		validator.probeAPIServer = probeAPIServer
+	} else {
+		validator.probeEndpoint = newAPIServerEndpointProber(restConfig)
+	}
+	return validator, nil
 }
 
 func (v *clusterValidatorImpl) Validate() (*ValidationCluster, error) {
@@ -146,7 +194,7 @@ func (v *clusterValidatorImpl) Validate() (*ValidationCluster, error) {
 				"  The protokube container and %[1]v deployment logs may contain more diagnostic information."+
 				"  Etcd and the API DNS entries must be updated for a kops Kubernetes cluster to start.", dnsProvider, hasPlaceHolderIPAddress)
 			validation.addError(&ValidationError{
-				Kind:    "dns",
+				Kind:    ValidationErrorKindDNS,
 				Name:    "apiserver",
 				Message: message,
 			})
@@ -170,6 +218,29 @@ func (v *clusterValidatorImpl) Validate() (*ValidationCluster, error) {
 		return nil, fmt.Errorf("cannot get pod health for %q: %v", v.cluster.Name, err)
 	}
 
+	if err := validation.validateControlPlane(ctx, v.k8sClient, v.cluster, nodeList.Items, readyNodes, nodeInstanceGroupMapping, v.probeAPIServer); err != nil {
+		return nil, fmt.Errorf("cannot get control plane health for %q: %v", v.cluster.Name, err)
+	}
+	if v.probeEndpoint != nil {
+		validation.validateAPIServerEndpoint(ctx, v.host, v.probeEndpoint)
+	}
+
+	validation.validateKubeletVersions(v.cluster, nodeList.Items, nodeInstanceGroupMapping)
+
+	if v.keystore != nil {
+		if err := validation.validateCertificates(v.keystore, time.Now()); err != nil {
+			return nil, fmt.Errorf("cannot check certificates for %q: %v", v.cluster.Name, err)
+		}
+	} else {
+		validation.addWarning(&ValidationError{
+			Kind:    ValidationErrorKindCertificate,
+			Name:    "keystore",
+			Message: "the expiry of the certificates was not checked, as the keystore is not available",
+		})
+	}
+
+	validation.runHealthChecks(ctx, v.k8sClient, v.cluster.Spec.HealthChecks)
+
 	return validation, nil
 }
 
@@ -224,7 +295,7 @@ func (v *ValidationCluster) collectPodFailures(ctx context.Context, client kuber
 
 		if pod.Status.Phase == v1.PodPending {
 			v.addError(&ValidationError{
-				Kind:          "Pod",
+				Kind:          ValidationErrorKindPod,
 				Name:          pod.Namespace + "/" + pod.Name,
 				Message:       fmt.Sprintf("%s pod %q is pending", priority, pod.Name),
 				InstanceGroup: podNode,
@@ -233,7 +304,7 @@ func (v *ValidationCluster) collectPodFailures(ctx context.Context, client kuber
 		}
 		if pod.Status.Phase == v1.PodUnknown {
 			v.addError(&ValidationError{
-				Kind:          "Pod",
+				Kind:          ValidationErrorKindPod,
 				Name:          pod.Namespace + "/" + pod.Name,
 				Message:       fmt.Sprintf("%s pod %q is unknown phase", priority, pod.Name),
 				InstanceGroup: podNode,
@@ -248,7 +319,7 @@ func (v *ValidationCluster) collectPodFailures(ctx context.Context, client kuber
 		}
 		if len(notready) != 0 {
 			v.addError(&ValidationError{
-				Kind:          "Pod",
+				Kind:          ValidationErrorKindPod,
 				Name:          pod.Namespace + "/" + pod.Name,
 				Message:       fmt.Sprintf("%s pod %q is not ready (%s)", priority, pod.Name, strings.Join(notready, ",")),
 				InstanceGroup: podNode,
@@ -263,7 +334,7 @@ func (v *ValidationCluster) collectPodFailures(ctx context.Context, client kuber
 	for node, nodeMap := range masterWithoutPod {
 		for app := range nodeMap {
 			v.addError(&ValidationError{
-				Kind:          "Node",
+				Kind:          ValidationErrorKindNode,
 				Name:          node,
 				Message:       fmt.Sprintf("control-plane node %q is missing %s pod", node, app),
 				InstanceGroup: nodeInstanceGroupMapping[node],
@@ -293,7 +364,7 @@ func (v *ValidationCluster) validateNodes(cloudGroups map[string]*cloudinstances
 		}
 		if numNodes < cloudGroup.TargetSize {
 			v.addError(&ValidationError{
-				Kind: "InstanceGroup",
+				Kind: ValidationErrorKindInstanceGroup,
 				Name: cloudGroup.InstanceGroup.Name,
 				Message: fmt.Sprintf("InstanceGroup %q did not have enough nodes %d vs %d",
 					cloudGroup.InstanceGroup.Name,
@@ -322,7 +393,7 @@ func (v *ValidationCluster) validateNodes(cloudGroups map[string]*cloudinstances
 
 				if nodeExpectedToJoin {
 					v.addError(&ValidationError{
-						Kind:          "Machine",
+						Kind:          ValidationErrorKindMachine,
 						Name:          member.ID,
 						Message:       fmt.Sprintf("machine %q has not yet joined cluster", member.ID),
 						InstanceGroup: cloudGroup.InstanceGroup,
@@ -339,11 +410,12 @@ func (v *ValidationCluster) validateNodes(cloudGroups map[string]*cloudinstances
 			}
 
 			n := &ValidationNode{
-				Name:     node.Name,
-				Zone:     node.ObjectMeta.Labels["topology.kubernetes.io/zone"],
-				Hostname: node.ObjectMeta.Labels["kubernetes.io/hostname"],
-				Role:     role,
-				Status:   getNodeReadyStatus(node),
+				Name:          node.Name,
+				Zone:          node.ObjectMeta.Labels["topology.kubernetes.io/zone"],
+				Hostname:      node.ObjectMeta.Labels["kubernetes.io/hostname"],
+				Role:          role,
+				Status:        getNodeReadyStatus(node),
+				InstanceGroup: cloudGroup.InstanceGroup.Name,
 			}
 			if n.Zone == "" {
 				n.Zone = node.ObjectMeta.Labels["failure-domain.beta.kubernetes.io/zone"]
@@ -358,7 +430,7 @@ func (v *ValidationCluster) validateNodes(cloudGroups map[string]*cloudinstances
 			case "control-plane", "apiserver", "node":
 				if !ready {
 					v.addError(&ValidationError{
-						Kind:          "Node",
+						Kind:          ValidationErrorKindNode,
 						Name:          node.Name,
 						Message:       fmt.Sprintf("node %q of role %q is not ready", node.Name, n.Role),
 						InstanceGroup: cloudGroup.InstanceGroup,
@@ -376,7 +448,7 @@ func (v *ValidationCluster) validateNodes(cloudGroups map[string]*cloudinstances
 	for _, ig := range groups {
 		if !groupsSeen[ig.Name] {
 			v.addError(&ValidationError{
-				Kind:          "InstanceGroup",
+				Kind:          ValidationErrorKindInstanceGroup,
 				Name:          ig.Name,
 				Message:       fmt.Sprintf("InstanceGroup %q is missing from the cloud provider", ig.Name),
 				InstanceGroup: ig,
@@ -19,8 +19,6 @@ package fi
 import (
 	"bytes"
 	"context"
-	"crypto/x509"
-	"encoding/pem"
 	"fmt"
 	"math/big"
 	"sort"
@@ -166,13 +164,11 @@ func (k *Keyset) ToPublicKeys() (string, error) {
 	for _, key := range keys {
 		item := k.Items[key]
 		if item.Certificate != nil {
-			publicKeyData, err := x509.MarshalPKIXPublicKey(item.Certificate.PublicKey)
+			publicKeyData, err := pki.EncodePEMPublicKey(item.Certificate.PublicKey)
 			if err != nil {
-				return "", fmt.Errorf("marshalling public key %s: %v", item.Id, err)
-			}
-			if err = pem.Encode(buf, &pem.Block{Type: "RSA PUBLIC KEY", Bytes: publicKeyData}); err != nil {
 				return "", fmt.Errorf("encoding public key %s: %v", item.Id, err)
 			}
+			buf.Write(publicKeyData)
 		}
 	}
 	return buf.String(), nil
@@ -29,6 +29,7 @@ import (
 	"k8s.io/kops/pkg/apis/kops"
 	kopsinternalversion "k8s.io/kops/pkg/client/clientset_generated/clientset/typed/kops/internalversion"
 	"k8s.io/kops/pkg/pki"
+	"k8s.io/kops/pkg/stateencryption"
 	"k8s.io/kops/util/pkg/vfs"
 )
 
@@ -67,7 +68,8 @@ func NewClientsetSSHCredentialStore(cluster *kops.Cluster, clientset kopsinterna
 	return c
 }
 
-func parseKeyset(o *kops.Keyset) (*Keyset, error) {
+// ParseKeyset converts a Keyset API object, as read from the state store or a manifest, to a Keyset.
+func ParseKeyset(o *kops.Keyset) (*Keyset, error) {
 	name := o.Name
 
 	keyset := &Keyset{
@@ -117,7 +119,7 @@ func (c *ClientsetCAStore) loadKeyset(ctx context.Context, name string) (*Keyset
 		return nil, fmt.Errorf("error reading keyset %q: %v", name, err)
 	}
 
-	keyset, err := parseKeyset(o)
+	keyset, err := ParseKeyset(o)
 	if err != nil {
 		return nil, err
 	}
@@ -175,7 +177,7 @@ func (c *ClientsetCAStore) ListKeysets() (map[string]*Keyset, error) {
 			keyset := &list.Items[i]
 			switch keyset.Spec.Type {
 			case kops.SecretTypeKeypair:
-				item, err := parseKeyset(keyset)
+				item, err := ParseKeyset(keyset)
 				if err != nil {
 					return nil, fmt.Errorf("parsing keyset %q: %w", keyset.Name, err)
 				}
@@ -315,8 +317,9 @@ func (c *ClientsetCAStore) MirrorTo(ctx context.Context, basedir vfs.Path) error
 		return err
 	}
 
+	encryption := stateencryption.ForPath(basedir)
 	for name, keyset := range keysets {
-		if err := mirrorKeyset(ctx, c.cluster, basedir, name, keyset); err != nil {
+		if err := mirrorKeyset(ctx, c.cluster, encryption, basedir, name, keyset); err != nil {
 			return err
 		}
 	}
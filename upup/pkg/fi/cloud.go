@@ -55,6 +55,36 @@ type Cloud interface {
 	GetApiIngressStatus(cluster *kops.Cluster) ([]ApiIngressStatus, error)
 }
 
+// InstanceTemplateCloud is implemented by clouds whose groups create instances from versioned templates,
+// so that a group can be returned to a template version it created instances from before.
+type InstanceTemplateCloud interface {
+	// GetGroupTemplate returns the template version the group creates new instances from.
+	GetGroupTemplate(group *cloudinstances.CloudInstanceGroup) (string, error)
+
+	// GetInstanceTemplate returns the template version an instance was created from, or "" if it is not known.
+	GetInstanceTemplate(instance *cloudinstances.CloudInstance) (string, error)
+
+	// SetGroupTemplate makes the group create new instances from a template version returned by
+	// GetGroupTemplate or GetInstanceTemplate.
+	SetGroupTemplate(group *cloudinstances.CloudInstanceGroup, template string) error
+}
+
+// BlueGreenCloud is implemented by clouds that can replace all the instances of a group at once,
+// through a temporary copy of the group that launches the replacements.
+type BlueGreenCloud interface {
+	// CreateGreenGroup creates a copy of the group, or reuses the copy left by an interrupted update, and waits for it
+	// to launch as many instances as the group wants. Its instances are members of the group's instance group,
+	// with state cloudinstances.Replacement.
+	CreateGreenGroup(group *cloudinstances.CloudInstanceGroup) (*cloudinstances.CloudInstanceGroup, error)
+
+	// PromoteGreenGroup moves the instances of the copy into the group, terminating the instances they replace,
+	// then deletes the copy. If the instances cannot all be moved, they are moved back into the copy.
+	PromoteGreenGroup(group *cloudinstances.CloudInstanceGroup, green *cloudinstances.CloudInstanceGroup) error
+
+	// DeleteGreenGroup deletes the copy of a group, and its instances.
+	DeleteGreenGroup(green *cloudinstances.CloudInstanceGroup) error
+}
+
 type VPCInfo struct {
 	// CIDR is the IP address range for the VPC
 	CIDR string
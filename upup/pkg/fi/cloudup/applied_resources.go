@@ -0,0 +1,87 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudup
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"k8s.io/kops/pkg/acls"
+	"k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/pkg/apis/kops/registry"
+	"k8s.io/kops/upup/pkg/fi"
+)
+
+// ReadAppliedResources returns the tasks that were last applied to the cloud of the cluster.
+// It returns an empty set if the cluster was not updated since kOps started recording them.
+func ReadAppliedResources(ctx context.Context, cluster *kops.Cluster) (fi.AppliedResources, error) {
+	configBase, err := registry.ConfigBase(cluster)
+	if err != nil {
+		return nil, err
+	}
+
+	applied := make(fi.AppliedResources)
+	b, err := configBase.Join(registry.PathAppliedResources).ReadFile(ctx)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return applied, nil
+		}
+		return nil, fmt.Errorf("error reading applied resources: %w", err)
+	}
+	if err := json.Unmarshal(b, &applied); err != nil {
+		return nil, fmt.Errorf("error parsing applied resources: %w", err)
+	}
+	return applied, nil
+}
+
+// writeAppliedResources records the tasks that were applied to the cloud of the cluster, with the digests of their fields.
+// Unless replace is set, the tasks are added to those recorded before, as when only a phase is applied.
+func writeAppliedResources(ctx context.Context, cluster *kops.Cluster, taskMap map[string]fi.CloudupTask, replace bool) error {
+	applied := make(fi.AppliedResources)
+	if !replace {
+		previous, err := ReadAppliedResources(ctx, cluster)
+		if err != nil {
+			return err
+		}
+		applied = previous
+	}
+	for key, task := range taskMap {
+		applied[key] = fi.AppliedFieldDigests(task)
+	}
+
+	b, err := json.Marshal(applied)
+	if err != nil {
+		return fmt.Errorf("error serializing applied resources: %w", err)
+	}
+
+	configBase, err := registry.ConfigBase(cluster)
+	if err != nil {
+		return err
+	}
+	p := configBase.Join(registry.PathAppliedResources)
+	acl, err := acls.GetACL(ctx, p, cluster)
+	if err != nil {
+		return err
+	}
+	if err := p.WriteFile(ctx, bytes.NewReader(b), acl); err != nil {
+		return fmt.Errorf("error writing applied resources: %w", err)
+	}
+	return nil
+}
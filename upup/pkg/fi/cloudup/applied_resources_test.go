@@ -0,0 +1,62 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudup
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/pkg/testutils/testcontext"
+	"k8s.io/kops/upup/pkg/fi"
+	"k8s.io/kops/upup/pkg/fi/cloudup/awstasks"
+	"k8s.io/kops/util/pkg/vfs"
+)
+
+func TestAppliedResources(t *testing.T) {
+	ctx := testcontext.ForTest(t)
+	vfs.Context.ResetMemfsContext(true)
+
+	cluster := &kops.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "test.example.com"}}
+	cluster.Spec.ConfigBase = "memfs://tests/test.example.com"
+
+	applied, err := ReadAppliedResources(ctx, cluster)
+	assert.NoError(t, err, "ReadAppliedResources()")
+	assert.Empty(t, applied, "nothing is recorded before the first apply")
+
+	assert.NoError(t, writeAppliedResources(ctx, cluster, map[string]fi.CloudupTask{"VPC/a": nil, "Subnet/b": nil}, true), "writeAppliedResources()")
+	assert.NoError(t, writeAppliedResources(ctx, cluster, map[string]fi.CloudupTask{"SecurityGroup/c": nil}, false), "writeAppliedResources()")
+
+	applied, err = ReadAppliedResources(ctx, cluster)
+	assert.NoError(t, err, "ReadAppliedResources()")
+	assert.Equal(t, fi.AppliedResources{"VPC/a": {}, "Subnet/b": {}, "SecurityGroup/c": {}}, applied, "a phase adds to the recorded resources")
+
+	assert.NoError(t, writeAppliedResources(ctx, cluster, map[string]fi.CloudupTask{"VPC/a": nil}, true), "writeAppliedResources()")
+
+	applied, err = ReadAppliedResources(ctx, cluster)
+	assert.NoError(t, err, "ReadAppliedResources()")
+	assert.Equal(t, fi.AppliedResources{"VPC/a": {}}, applied, "a full apply replaces the recorded resources")
+
+	vpc := &awstasks.VPC{Name: fi.PtrTo("a"), CIDR: fi.PtrTo("10.0.0.0/16")}
+	assert.NoError(t, writeAppliedResources(ctx, cluster, map[string]fi.CloudupTask{"VPC/a": vpc}, true), "writeAppliedResources()")
+
+	applied, err = ReadAppliedResources(ctx, cluster)
+	assert.NoError(t, err, "ReadAppliedResources()")
+	assert.Equal(t, fi.AppliedFieldDigests[fi.CloudupSubContext](vpc), applied["VPC/a"], "the fields of the applied tasks are recorded")
+	assert.NotEqual(t, applied["VPC/a"]["CIDR"], fi.AppliedFieldDigests[fi.CloudupSubContext](&awstasks.VPC{CIDR: fi.PtrTo("10.1.0.0/16")})["CIDR"], "the digests differ with the values")
+}
@@ -32,6 +32,7 @@ import (
 
 	"github.com/blang/semver/v4"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/klog/v2"
 	kopsbase "k8s.io/kops"
 	"k8s.io/kops/pkg/apis/kops"
@@ -57,6 +58,7 @@ import (
 	"k8s.io/kops/pkg/model/iam"
 	"k8s.io/kops/pkg/model/openstackmodel"
 	"k8s.io/kops/pkg/model/scalewaymodel"
+	"k8s.io/kops/pkg/stateencryption"
 	"k8s.io/kops/pkg/templates"
 	"k8s.io/kops/pkg/wellknownports"
 	"k8s.io/kops/upup/models"
@@ -124,6 +126,9 @@ type ApplyClusterCmd struct {
 	// DryRun is true if this is only a dry run
 	DryRun bool
 
+	// Quiet suppresses the human-readable dry-run report, for callers that produce their own output.
+	Quiet bool
+
 	// AllowKopsDowngrade permits applying with a kops version older than what was last used to apply to the cluster.
 	AllowKopsDowngrade bool
 
@@ -449,6 +454,11 @@ func (c *ApplyClusterCmd) Run(ctx context.Context) error {
 			modelContext.AWSAccountID = accountID
 			modelContext.AWSPartition = partition
 
+			modelContext.StateEncryptionKMSKeys, err = stateEncryptionKMSKeys(ctx, keyStore, secretStore)
+			if err != nil {
+				return err
+			}
+
 			if len(sshPublicKeys) > 1 {
 				return fmt.Errorf("exactly one 'admin' SSH public key can be specified when running with AWS; please delete a key using `kops delete secret`")
 			}
@@ -757,7 +767,7 @@ func (c *ApplyClusterCmd) Run(ctx context.Context) error {
 
 	case TargetDryRun:
 		var out io.Writer = os.Stdout
-		if c.GetAssets {
+		if c.GetAssets || c.Quiet {
 			out = io.Discard
 		}
 		target = fi.NewCloudupDryRunTarget(assetBuilder, out)
@@ -786,7 +796,7 @@ func (c *ApplyClusterCmd) Run(ctx context.Context) error {
 	if c.RunTasksOptions != nil {
 		options = *c.RunTasksOptions
 	} else {
-		options.InitDefaults()
+		options.InitCloudupDefaults()
 	}
 
 	err = context.RunTasks(options)
@@ -809,6 +819,30 @@ func (c *ApplyClusterCmd) Run(ctx context.Context) error {
 		return fmt.Errorf("error closing target: %v", err)
 	}
 
+	// Record what was applied, so that kops get drift can tell missing resources from new ones
+	if c.TargetName == TargetDirect {
+		if err := writeAppliedResources(ctx, cluster, c.TaskMap, c.Phase == ""); err != nil {
+			return err
+		}
+	}
+
+	// Delete the cloud resources of the instance groups pruned by kops apply. With Terraform,
+	// they are deleted by applying the configuration, which no longer holds them.
+	if clusterLifecycle == fi.LifecycleSync {
+		switch c.TargetName {
+		case TargetDirect:
+			if err := deletePrunedInstanceGroups(ctx, cluster, cloud, c.InstanceGroups, nil); err != nil {
+				return err
+			}
+		case TargetDryRun:
+			if !c.GetAssets && !c.Quiet {
+				if err := deletePrunedInstanceGroups(ctx, cluster, nil, c.InstanceGroups, os.Stdout); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
 	c.ImageAssets = assetBuilder.ImageAssets
 	c.FileAssets = assetBuilder.FileAssets
 
@@ -1592,3 +1626,29 @@ func (n *nodeUpConfigBuilder) buildWarmPoolImages(ig *kops.InstanceGroup) []stri
 
 	return unique
 }
+
+// stateEncryptionKMSKeys returns the ARNs of the AWS KMS keys that wrap the data keys of the encrypted stores.
+func stateEncryptionKMSKeys(ctx context.Context, stores ...interface{}) ([]string, error) {
+	keys := sets.NewString()
+	for _, store := range stores {
+		hasVFSPath, ok := store.(fi.HasVFSPath)
+		if !ok {
+			continue
+		}
+		envelope, err := stateencryption.ReadEnvelope(ctx, hasVFSPath.VFSPath())
+		if err != nil {
+			return nil, err
+		}
+		if envelope == nil {
+			continue
+		}
+		keyARN, err := stateencryption.AWSKMSKeyARN(envelope.KeyURI)
+		if err != nil {
+			return nil, err
+		}
+		if keyARN != "" {
+			keys.Insert(keyARN)
+		}
+	}
+	return keys.List(), nil
+}
@@ -826,6 +826,83 @@ func detachInstance(c AWSCloud, i *cloudinstances.CloudInstance) error {
 	return nil
 }
 
+// GetGroupTemplate returns the launch template version, or launch configuration, the ASG creates new instances from.
+func (c *awsCloudImplementation) GetGroupTemplate(g *cloudinstances.CloudInstanceGroup) (string, error) {
+	return getGroupTemplate(c, g)
+}
+
+// GetInstanceTemplate returns the launch template version, or launch configuration, an instance was created from.
+func (c *awsCloudImplementation) GetInstanceTemplate(i *cloudinstances.CloudInstance) (string, error) {
+	return getInstanceTemplate(i)
+}
+
+// SetGroupTemplate makes the ASG create new instances from a version of its launch template.
+func (c *awsCloudImplementation) SetGroupTemplate(g *cloudinstances.CloudInstanceGroup, template string) error {
+	return setGroupTemplate(c, g, template)
+}
+
+func getGroupTemplate(c AWSCloud, g *cloudinstances.CloudInstanceGroup) (string, error) {
+	asg, ok := g.Raw.(*autoscaling.Group)
+	if !ok {
+		return "", fmt.Errorf("%s is not an autoscaling group", g.HumanName)
+	}
+	return findAutoscalingGroupLaunchConfiguration(c, asg)
+}
+
+func getInstanceTemplate(i *cloudinstances.CloudInstance) (string, error) {
+	if i.CloudInstanceGroup == nil {
+		return "", nil
+	}
+	asg, ok := i.CloudInstanceGroup.Raw.(*autoscaling.Group)
+	if !ok {
+		return "", fmt.Errorf("%s is not an autoscaling group", i.CloudInstanceGroup.HumanName)
+	}
+	for _, instance := range asg.Instances {
+		if aws.StringValue(instance.InstanceId) == i.ID {
+			return findInstanceLaunchConfiguration(instance), nil
+		}
+	}
+	return "", nil
+}
+
+// setGroupTemplate points the ASG at a launch template version, in the form "<id>:<version>".
+// Launch configurations are replaced, rather than versioned, so an ASG cannot be returned to one.
+func setGroupTemplate(c AWSCloud, g *cloudinstances.CloudInstanceGroup, template string) error {
+	asg, ok := g.Raw.(*autoscaling.Group)
+	if !ok {
+		return fmt.Errorf("%s is not an autoscaling group", g.HumanName)
+	}
+	id, version, found := strings.Cut(template, ":")
+	if !found {
+		return fmt.Errorf("cannot return autoscaling group %s to launch configuration %q", g.HumanName, template)
+	}
+
+	spec := &autoscaling.LaunchTemplateSpecification{
+		LaunchTemplateId: aws.String(id),
+		Version:          aws.String(version),
+	}
+	input := &autoscaling.UpdateAutoScalingGroupInput{
+		AutoScalingGroupName: asg.AutoScalingGroupName,
+	}
+	if asg.LaunchTemplate == nil && asg.MixedInstancesPolicy != nil && asg.MixedInstancesPolicy.LaunchTemplate != nil {
+		input.MixedInstancesPolicy = &autoscaling.MixedInstancesPolicy{
+			InstancesDistribution: asg.MixedInstancesPolicy.InstancesDistribution,
+			LaunchTemplate: &autoscaling.LaunchTemplate{
+				LaunchTemplateSpecification: spec,
+				Overrides:                   asg.MixedInstancesPolicy.LaunchTemplate.Overrides,
+			},
+		}
+	} else {
+		input.LaunchTemplate = spec
+	}
+
+	klog.V(2).Infof("Setting launch template of autoscaling group %s to %s", g.HumanName, template)
+	if _, err := c.Autoscaling().UpdateAutoScalingGroup(input); err != nil {
+		return fmt.Errorf("error setting launch template of autoscaling group %s: %v", g.HumanName, err)
+	}
+	return nil
+}
+
 // GetCloudGroups returns a groups of instances that back a kops instance groups
 func (c *awsCloudImplementation) GetCloudGroups(cluster *kops.Cluster, instancegroups []*kops.InstanceGroup, warnUnmatched bool, nodes []v1.Node) (map[string]*cloudinstances.CloudInstanceGroup, error) {
 	if c.spotinst != nil {
@@ -1224,6 +1301,23 @@ func awsBuildCloudInstanceGroup(c AWSCloud, cluster *kops.Cluster, ig *kops.Inst
 			addCloudInstanceData(cm, instances[aws.StringValue(id)])
 		}
 	}
+	// Instances of a copy of the ASG, replacing its instances blue/green, are members of the instance group
+	for id, instance := range instances {
+		if instanceSeen[id] {
+			continue
+		}
+		for _, tag := range instance.Tags {
+			if aws.StringValue(tag.Key) == tagNameGreenInstance && aws.StringValue(tag.Value) == aws.StringValue(g.AutoScalingGroupName) {
+				cm, err := cg.NewCloudInstance(id, cloudinstances.CloudInstanceStatusUpToDate, nodeMap[id])
+				if err != nil {
+					return nil, fmt.Errorf("error creating cloud instance group member: %v", err)
+				}
+				cm.State = cloudinstances.Replacement
+				instanceSeen[id] = true
+				addCloudInstanceData(cm, instance)
+			}
+		}
+	}
 
 	return cg, nil
 }
@@ -0,0 +1,384 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package awsup
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"k8s.io/klog/v2"
+
+	"k8s.io/kops/pkg/cloudinstances"
+)
+
+// tagNameGreenInstance marks the instances of the copy of an ASG that replaces its instances blue/green,
+// with the name of the ASG they replace the instances of.
+const tagNameGreenInstance = "kops.k8s.io/replacing-asg"
+
+// greenGroupTimeout is how long to wait for the instances of the copy of an ASG to be launched, in service or detached.
+const greenGroupTimeout = 15 * time.Minute
+
+// greenGroupPollInterval is how often the copy of an ASG is checked while waiting for it.
+const greenGroupPollInterval = 10 * time.Second
+
+// maxAttachDetachInstances is the most instances AttachInstances and DetachInstances accept at a time.
+const maxAttachDetachInstances = 20
+
+// CreateGreenGroup creates a copy of the ASG, which launches as many instances as the ASG wants from its launch template.
+func (c *awsCloudImplementation) CreateGreenGroup(g *cloudinstances.CloudInstanceGroup) (*cloudinstances.CloudInstanceGroup, error) {
+	return createGreenGroup(c, g)
+}
+
+// PromoteGreenGroup moves the instances of the copy into the ASG, terminating the instances they replace, then deletes the copy.
+// If the instances cannot all be moved, they are moved back into the copy.
+func (c *awsCloudImplementation) PromoteGreenGroup(g *cloudinstances.CloudInstanceGroup, green *cloudinstances.CloudInstanceGroup) error {
+	return promoteGreenGroup(c, g, green)
+}
+
+// DeleteGreenGroup deletes the copy of an ASG, and its instances.
+func (c *awsCloudImplementation) DeleteGreenGroup(green *cloudinstances.CloudInstanceGroup) error {
+	return deleteGreenGroup(c, green)
+}
+
+func greenGroupName(name string) string {
+	return name + "-green"
+}
+
+// greenGroupTags returns the tags of the copy of an ASG. Cluster autoscaler must not discover the copy,
+// and its instances are marked as replacing those of the ASG.
+func greenGroupTags(asg *autoscaling.Group) []*autoscaling.Tag {
+	name := greenGroupName(aws.StringValue(asg.AutoScalingGroupName))
+	var tags []*autoscaling.Tag
+	for _, tag := range asg.Tags {
+		key := aws.StringValue(tag.Key)
+		if strings.HasPrefix(key, "k8s.io/cluster-autoscaler/") || key == tagNameGreenInstance {
+			continue
+		}
+		tags = append(tags, &autoscaling.Tag{
+			Key:               tag.Key,
+			Value:             tag.Value,
+			PropagateAtLaunch: tag.PropagateAtLaunch,
+			ResourceId:        aws.String(name),
+			ResourceType:      aws.String("auto-scaling-group"),
+		})
+	}
+	tags = append(tags, &autoscaling.Tag{
+		Key:               aws.String(tagNameGreenInstance),
+		Value:             asg.AutoScalingGroupName,
+		PropagateAtLaunch: aws.Bool(true),
+		ResourceId:        aws.String(name),
+		ResourceType:      aws.String("auto-scaling-group"),
+	})
+	return tags
+}
+
+// createGreenGroup creates the copy of the ASG, or reuses the copy left by an interrupted update,
+// and waits for it to launch its instances.
+func createGreenGroup(c AWSCloud, g *cloudinstances.CloudInstanceGroup) (*cloudinstances.CloudInstanceGroup, error) {
+	asg, ok := g.Raw.(*autoscaling.Group)
+	if !ok {
+		return nil, fmt.Errorf("%s is not an autoscaling group", g.HumanName)
+	}
+	name := greenGroupName(aws.StringValue(asg.AutoScalingGroupName))
+	capacity := asg.DesiredCapacity
+
+	green, err := findAutoscalingGroup(c, name)
+	if err != nil {
+		return nil, err
+	}
+	if green == nil {
+		request := &autoscaling.CreateAutoScalingGroupInput{
+			AutoScalingGroupName:    aws.String(name),
+			LaunchConfigurationName: asg.LaunchConfigurationName,
+			LaunchTemplate:          asg.LaunchTemplate,
+			MixedInstancesPolicy:    asg.MixedInstancesPolicy,
+			VPCZoneIdentifier:       asg.VPCZoneIdentifier,
+			LoadBalancerNames:       asg.LoadBalancerNames,
+			TargetGroupARNs:         asg.TargetGroupARNs,
+			HealthCheckType:         asg.HealthCheckType,
+			HealthCheckGracePeriod:  asg.HealthCheckGracePeriod,
+			CapacityRebalance:       asg.CapacityRebalance,
+			MinSize:                 aws.Int64(0),
+			MaxSize:                 capacity,
+			DesiredCapacity:         capacity,
+			Tags:                    greenGroupTags(asg),
+		}
+		if aws.StringValue(asg.VPCZoneIdentifier) == "" {
+			request.AvailabilityZones = asg.AvailabilityZones
+		}
+
+		klog.Infof("Creating autoscaling group %q to replace the instances of %q", name, g.HumanName)
+		if _, err := c.Autoscaling().CreateAutoScalingGroup(request); err != nil {
+			return nil, fmt.Errorf("error creating autoscaling group %q: %v", name, err)
+		}
+	} else {
+		klog.Infof("Using existing autoscaling group %q to replace the instances of %q", name, g.HumanName)
+		if aws.Int64Value(green.DesiredCapacity) != aws.Int64Value(capacity) {
+			request := &autoscaling.UpdateAutoScalingGroupInput{
+				AutoScalingGroupName: aws.String(name),
+				MinSize:              aws.Int64(0),
+				MaxSize:              capacity,
+				DesiredCapacity:      capacity,
+			}
+			if _, err := c.Autoscaling().UpdateAutoScalingGroup(request); err != nil {
+				return nil, fmt.Errorf("error resizing autoscaling group %q: %v", name, err)
+			}
+		}
+	}
+
+	green, err = waitForGreenGroup(c, name, func(green *autoscaling.Group) bool {
+		return int64(len(green.Instances)) >= aws.Int64Value(green.DesiredCapacity)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error waiting for autoscaling group %q to launch instances: %v", name, err)
+	}
+
+	return &cloudinstances.CloudInstanceGroup{
+		HumanName:     name,
+		InstanceGroup: g.InstanceGroup,
+		MinSize:       int(aws.Int64Value(green.MinSize)),
+		TargetSize:    int(aws.Int64Value(green.DesiredCapacity)),
+		MaxSize:       int(aws.Int64Value(green.MaxSize)),
+		Raw:           green,
+	}, nil
+}
+
+// promoteGreenGroup moves the instances of the copy into the ASG, so that the ASG keeps its name and configuration,
+// and terminates the instances of the ASG they replace. If detaching or attaching them fails, restoreGreenGroup
+// moves them back into the copy; instances that were already terminated cannot be restored.
+func promoteGreenGroup(c AWSCloud, g *cloudinstances.CloudInstanceGroup, green *cloudinstances.CloudInstanceGroup) error {
+	asg, err := findAutoscalingGroup(c, g.HumanName)
+	if err != nil {
+		return err
+	}
+	if asg == nil {
+		return fmt.Errorf("autoscaling group %q not found", g.HumanName)
+	}
+
+	greenASG, err := waitForGreenGroup(c, green.HumanName, func(green *autoscaling.Group) bool {
+		inService := 0
+		for _, i := range green.Instances {
+			if aws.StringValue(i.LifecycleState) == autoscaling.LifecycleStateInService {
+				inService++
+			}
+		}
+		return int64(inService) >= aws.Int64Value(green.DesiredCapacity)
+	})
+	if err != nil {
+		return fmt.Errorf("error waiting for the instances of autoscaling group %q to be in service: %v", green.HumanName, err)
+	}
+
+	var greenIDs, oldIDs []*string
+	for _, i := range greenASG.Instances {
+		greenIDs = append(greenIDs, i.InstanceId)
+	}
+	for _, i := range asg.Instances {
+		if aws.StringValue(i.LifecycleState) != autoscaling.LifecycleStateTerminating {
+			oldIDs = append(oldIDs, i.InstanceId)
+		}
+	}
+
+	// The ASG briefly holds both sets of instances
+	restoreSize := &autoscaling.UpdateAutoScalingGroupInput{
+		AutoScalingGroupName: asg.AutoScalingGroupName,
+		MinSize:              asg.MinSize,
+		MaxSize:              asg.MaxSize,
+	}
+	maxSize := aws.Int64Value(asg.MaxSize)
+	if n := int64(len(oldIDs) + len(greenIDs)); n > maxSize {
+		maxSize = n
+	}
+	if _, err := c.Autoscaling().UpdateAutoScalingGroup(&autoscaling.UpdateAutoScalingGroupInput{
+		AutoScalingGroupName: asg.AutoScalingGroupName,
+		MinSize:              aws.Int64(0),
+		MaxSize:              aws.Int64(maxSize),
+	}); err != nil {
+		return fmt.Errorf("error resizing autoscaling group %q: %v", g.HumanName, err)
+	}
+
+	detached, err := inBatches(greenIDs, func(batch []*string) error {
+		_, err := c.Autoscaling().DetachInstances(&autoscaling.DetachInstancesInput{
+			AutoScalingGroupName:           greenASG.AutoScalingGroupName,
+			InstanceIds:                    batch,
+			ShouldDecrementDesiredCapacity: aws.Bool(true),
+		})
+		return err
+	})
+	if err != nil {
+		err = fmt.Errorf("error detaching instances from autoscaling group %q: %v", green.HumanName, err)
+		return restoreGreenGroup(c, restoreSize, green.HumanName, nil, greenIDs[:detached], err)
+	}
+	if _, err := waitForGreenGroup(c, green.HumanName, func(green *autoscaling.Group) bool {
+		return len(green.Instances) == 0
+	}); err != nil {
+		err = fmt.Errorf("error waiting for instances to detach from autoscaling group %q: %v", green.HumanName, err)
+		return restoreGreenGroup(c, restoreSize, green.HumanName, nil, greenIDs, err)
+	}
+
+	klog.Infof("Moving instances %v from autoscaling group %q to %q", aws.StringValueSlice(greenIDs), green.HumanName, g.HumanName)
+	attached, err := inBatches(greenIDs, func(batch []*string) error {
+		_, err := c.Autoscaling().AttachInstances(&autoscaling.AttachInstancesInput{
+			AutoScalingGroupName: asg.AutoScalingGroupName,
+			InstanceIds:          batch,
+		})
+		return err
+	})
+	if err != nil {
+		err = fmt.Errorf("error attaching instances to autoscaling group %q: %v", g.HumanName, err)
+		return restoreGreenGroup(c, restoreSize, green.HumanName, greenIDs[:attached], greenIDs[attached:], err)
+	}
+
+	for _, id := range oldIDs {
+		klog.Infof("Terminating instance %q of autoscaling group %q", aws.StringValue(id), g.HumanName)
+		if _, err := c.Autoscaling().TerminateInstanceInAutoScalingGroup(&autoscaling.TerminateInstanceInAutoScalingGroupInput{
+			InstanceId:                     id,
+			ShouldDecrementDesiredCapacity: aws.Bool(true),
+		}); err != nil {
+			return fmt.Errorf("error terminating instance %q: %v", aws.StringValue(id), err)
+		}
+	}
+
+	if _, err := c.Autoscaling().UpdateAutoScalingGroup(restoreSize); err != nil {
+		return fmt.Errorf("error resizing autoscaling group %q: %v", g.HumanName, err)
+	}
+
+	return deleteGreenGroup(c, green)
+}
+
+// restoreGreenGroup moves the instances of the copy of an ASG back into the copy after promoting it failed with err,
+// so that none of them is left outside both groups: attached are detached from the ASG again, and detached are in
+// neither group. The ASG is then resized with restoreSize.
+func restoreGreenGroup(c AWSCloud, restoreSize *autoscaling.UpdateAutoScalingGroupInput, greenName string, attached, detached []*string, err error) error {
+	name := aws.StringValue(restoreSize.AutoScalingGroupName)
+	klog.Errorf("Moving instances %v back to autoscaling group %q: %v", aws.StringValueSlice(append(append([]*string{}, attached...), detached...)), greenName, err)
+
+	var errs []string
+	ids := append([]*string{}, detached...)
+	if _, detachErr := inBatches(attached, func(batch []*string) error {
+		_, err := c.Autoscaling().DetachInstances(&autoscaling.DetachInstancesInput{
+			AutoScalingGroupName:           restoreSize.AutoScalingGroupName,
+			InstanceIds:                    batch,
+			ShouldDecrementDesiredCapacity: aws.Bool(true),
+		})
+		return err
+	}); detachErr != nil {
+		errs = append(errs, fmt.Sprintf("error detaching instances from autoscaling group %q: %v", name, detachErr))
+	} else {
+		if _, waitErr := waitForGreenGroup(c, name, func(asg *autoscaling.Group) bool {
+			for _, i := range asg.Instances {
+				for _, id := range attached {
+					if aws.StringValue(i.InstanceId) == aws.StringValue(id) {
+						return false
+					}
+				}
+			}
+			return true
+		}); waitErr != nil {
+			errs = append(errs, fmt.Sprintf("error waiting for instances to detach from autoscaling group %q: %v", name, waitErr))
+		} else {
+			ids = append(ids, attached...)
+		}
+	}
+
+	if _, attachErr := inBatches(ids, func(batch []*string) error {
+		_, err := c.Autoscaling().AttachInstances(&autoscaling.AttachInstancesInput{
+			AutoScalingGroupName: aws.String(greenName),
+			InstanceIds:          batch,
+		})
+		return err
+	}); attachErr != nil {
+		errs = append(errs, fmt.Sprintf("error attaching instances to autoscaling group %q: %v", greenName, attachErr))
+	}
+
+	if _, updateErr := c.Autoscaling().UpdateAutoScalingGroup(restoreSize); updateErr != nil {
+		errs = append(errs, fmt.Sprintf("error resizing autoscaling group %q: %v", name, updateErr))
+	}
+
+	if len(errs) != 0 {
+		return fmt.Errorf("%w; moving instances back to autoscaling group %q: %s", err, greenName, strings.Join(errs, "; "))
+	}
+	return err
+}
+
+// inBatches calls f with as many of the instance IDs at a time as AttachInstances and DetachInstances accept.
+// It returns how many of the IDs f succeeded with before it failed.
+func inBatches(ids []*string, f func(batch []*string) error) (int, error) {
+	for start := 0; start < len(ids); start += maxAttachDetachInstances {
+		end := start + maxAttachDetachInstances
+		if end > len(ids) {
+			end = len(ids)
+		}
+		if err := f(ids[start:end]); err != nil {
+			return start, err
+		}
+	}
+	return len(ids), nil
+}
+
+// deleteGreenGroup deletes the copy of an ASG, terminating any instances it has.
+func deleteGreenGroup(c AWSCloud, green *cloudinstances.CloudInstanceGroup) error {
+	klog.Infof("Deleting autoscaling group %q", green.HumanName)
+	request := &autoscaling.DeleteAutoScalingGroupInput{
+		AutoScalingGroupName: aws.String(green.HumanName),
+		ForceDelete:          aws.Bool(true),
+	}
+	if _, err := c.Autoscaling().DeleteAutoScalingGroup(request); err != nil {
+		return fmt.Errorf("error deleting autoscaling group %q: %v", green.HumanName, err)
+	}
+	return nil
+}
+
+// findAutoscalingGroup returns the named ASG, or nil if it does not exist.
+func findAutoscalingGroup(c AWSCloud, name string) (*autoscaling.Group, error) {
+	response, err := c.Autoscaling().DescribeAutoScalingGroups(&autoscaling.DescribeAutoScalingGroupsInput{
+		AutoScalingGroupNames: []*string{aws.String(name)},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error describing autoscaling group %q: %v", name, err)
+	}
+	for _, asg := range response.AutoScalingGroups {
+		// An ASG being deleted cannot be used
+		if aws.StringValue(asg.AutoScalingGroupName) == name && asg.Status == nil {
+			return asg, nil
+		}
+	}
+	return nil, nil
+}
+
+// waitForGreenGroup polls the named ASG until done returns true for it.
+func waitForGreenGroup(c AWSCloud, name string, done func(*autoscaling.Group) bool) (*autoscaling.Group, error) {
+	deadline := time.Now().Add(greenGroupTimeout)
+	for {
+		asg, err := findAutoscalingGroup(c, name)
+		if err != nil {
+			return nil, err
+		}
+		if asg == nil {
+			return nil, fmt.Errorf("autoscaling group %q not found", name)
+		}
+		if done(asg) {
+			return asg, nil
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("timed out after %v", greenGroupTimeout)
+		}
+		time.Sleep(greenGroupPollInterval)
+	}
+}
@@ -0,0 +1,101 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package awsup
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"k8s.io/kops/cloudmock/aws/mockautoscaling"
+	"k8s.io/kops/pkg/cloudinstances"
+)
+
+// failingAttachAutoscaling fails the second AttachInstances call that targets the ASG being promoted.
+type failingAttachAutoscaling struct {
+	*mockautoscaling.MockAutoscaling
+	group   string
+	attachs int
+}
+
+func (m *failingAttachAutoscaling) AttachInstances(input *autoscaling.AttachInstancesInput) (*autoscaling.AttachInstancesOutput, error) {
+	if aws.StringValue(input.AutoScalingGroupName) == m.group {
+		m.attachs++
+		if m.attachs == 2 {
+			return nil, fmt.Errorf("injected failure")
+		}
+	}
+	return m.MockAutoscaling.AttachInstances(input)
+}
+
+func TestPromoteGreenGroupRestoresInstancesWhenAttachFails(t *testing.T) {
+	mock := &mockautoscaling.MockAutoscaling{}
+	c := BuildMockAWSCloud("us-test-1", "a")
+	c.MockAutoscaling = &failingAttachAutoscaling{MockAutoscaling: mock, group: "nodes"}
+
+	// More replacements than fit in one AttachInstances call, so that the first batch is attached before the failure
+	var oldIDs, greenIDs []string
+	for i := 0; i < 3; i++ {
+		oldIDs = append(oldIDs, fmt.Sprintf("i-old-%02d", i))
+	}
+	for i := 0; i < maxAttachDetachInstances+5; i++ {
+		greenIDs = append(greenIDs, fmt.Sprintf("i-green-%02d", i))
+	}
+	for name, ids := range map[string][]string{"nodes": oldIDs, "nodes-green": greenIDs} {
+		if _, err := mock.CreateAutoScalingGroup(&autoscaling.CreateAutoScalingGroupInput{
+			AutoScalingGroupName: aws.String(name),
+			MinSize:              aws.Int64(int64(len(ids))),
+			MaxSize:              aws.Int64(int64(len(ids))),
+			DesiredCapacity:      aws.Int64(int64(len(ids))),
+		}); err != nil {
+			t.Fatalf("error creating autoscaling group %q: %v", name, err)
+		}
+		for _, id := range ids {
+			mock.Groups[name].Instances = append(mock.Groups[name].Instances, &autoscaling.Instance{
+				InstanceId:     aws.String(id),
+				LifecycleState: aws.String(autoscaling.LifecycleStateInService),
+			})
+		}
+	}
+
+	err := promoteGreenGroup(c, &cloudinstances.CloudInstanceGroup{HumanName: "nodes"}, &cloudinstances.CloudInstanceGroup{HumanName: "nodes-green"})
+	if err == nil {
+		t.Fatalf("expected an error promoting the copy")
+	}
+
+	if got := instanceIDs(mock.Groups["nodes-green"]); !reflect.DeepEqual(got, greenIDs) {
+		t.Errorf("unexpected instances in the copy: expected %v, got %v", greenIDs, got)
+	}
+	if got := instanceIDs(mock.Groups["nodes"]); !reflect.DeepEqual(got, oldIDs) {
+		t.Errorf("unexpected instances in the group: expected %v, got %v", oldIDs, got)
+	}
+	if got := aws.Int64Value(mock.Groups["nodes"].MinSize); got != int64(len(oldIDs)) {
+		t.Errorf("expected the minimum size of the group to be restored to %d, got %d", len(oldIDs), got)
+	}
+}
+
+func instanceIDs(g *autoscaling.Group) []string {
+	var ids []string
+	for _, i := range g.Instances {
+		ids = append(ids, aws.StringValue(i.InstanceId))
+	}
+	sort.Strings(ids)
+	return ids
+}
@@ -105,6 +105,18 @@ func (c *MockAWSCloud) DetachInstance(i *cloudinstances.CloudInstance) error {
 	return detachInstance(c, i)
 }
 
+func (c *MockAWSCloud) GetGroupTemplate(g *cloudinstances.CloudInstanceGroup) (string, error) {
+	return getGroupTemplate(c, g)
+}
+
+func (c *MockAWSCloud) GetInstanceTemplate(i *cloudinstances.CloudInstance) (string, error) {
+	return getInstanceTemplate(i)
+}
+
+func (c *MockAWSCloud) SetGroupTemplate(g *cloudinstances.CloudInstanceGroup, template string) error {
+	return setGroupTemplate(c, g, template)
+}
+
 func (c *MockAWSCloud) GetCloudGroups(cluster *kops.Cluster, instancegroups []*kops.InstanceGroup, warnUnmatched bool, nodes []v1.Node) (map[string]*cloudinstances.CloudInstanceGroup, error) {
 	return getCloudGroups(c, cluster, instancegroups, warnUnmatched, nodes)
 }
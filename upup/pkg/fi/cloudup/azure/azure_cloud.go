@@ -20,8 +20,12 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 
+	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2022-08-01/compute"
+	autorestazure "github.com/Azure/go-autorest/autorest/azure"
 	"github.com/Azure/go-autorest/autorest/azure/auth"
+	"github.com/Azure/go-autorest/autorest/to"
 	"k8s.io/klog/v2"
 	"k8s.io/kops/dnsprovider/pkg/dnsprovider"
 	"k8s.io/kops/pkg/apis/kops"
@@ -37,6 +41,8 @@ const (
 	TagRoleControlPlane      = "control_plane"
 	TagRoleMaster            = "master"
 	TagNameEtcdClusterPrefix = "k8s.io_etcd_"
+	// TagDetached marks the VMs detached from their VM Scale Set by a rolling update.
+	TagDetached = "kops.k8s.io_detached-from-vmss"
 )
 
 // AzureCloud provides clients to make API calls to Azure.
@@ -142,8 +148,23 @@ func (c *azureCloudImplementation) FindVNetInfo(id, resourceGroup string) (*fi.V
 	return nil, nil
 }
 
+// DeleteInstance deletes a VM of a VM Scale Set. Deleting a VM scales in its VM Scale Set,
+// so the VM Scale Set is scaled out again to replace the VM, unless it was detached.
 func (c *azureCloudImplementation) DeleteInstance(i *cloudinstances.CloudInstance) error {
-	return errors.New("DeleteInstance not implemented on azureCloud")
+	resourceGroupName, vmssName, instanceID, err := vmScaleSetVMOf(i)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.TODO()
+	if err := c.vmscaleSetVMsClient.Delete(ctx, resourceGroupName, vmssName, instanceID); err != nil {
+		return fmt.Errorf("error deleting VM %q: %w", i.ID, err)
+	}
+	if i.Status == cloudinstances.CloudInstanceStatusDetached {
+		// The VM Scale Set was scaled out when the VM was detached
+		return nil
+	}
+	return c.scaleOutVMScaleSet(ctx, resourceGroupName, vmssName)
 }
 
 // DeregisterInstance drains a cloud instance and loadbalancers.
@@ -156,8 +177,74 @@ func (c *azureCloudImplementation) DeleteGroup(g *cloudinstances.CloudInstanceGr
 	return errors.New("DeleteGroup not implemented on azureCloud")
 }
 
+// DetachInstance protects a VM from scale-in and tags it as detached, then scales out its VM Scale Set
+// so that a replacement is created while the VM keeps running.
 func (c *azureCloudImplementation) DetachInstance(i *cloudinstances.CloudInstance) error {
-	return errors.New("DetachInstance not implemented on azureCloud")
+	resourceGroupName, vmssName, instanceID, err := vmScaleSetVMOf(i)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.TODO()
+	vm, err := c.vmscaleSetVMsClient.Get(ctx, resourceGroupName, vmssName, instanceID)
+	if err != nil {
+		return fmt.Errorf("error getting VM %q: %w", i.ID, err)
+	}
+	if vm.Tags == nil {
+		vm.Tags = map[string]*string{}
+	}
+	vm.Tags[TagDetached] = to.StringPtr(vmssName)
+	if vm.VirtualMachineScaleSetVMProperties == nil {
+		vm.VirtualMachineScaleSetVMProperties = &compute.VirtualMachineScaleSetVMProperties{}
+	}
+	vm.ProtectionPolicy = &compute.VirtualMachineScaleSetVMProtectionPolicy{
+		ProtectFromScaleIn: to.BoolPtr(true),
+	}
+	if _, err := c.vmscaleSetVMsClient.Update(ctx, resourceGroupName, vmssName, instanceID, *vm); err != nil {
+		return fmt.Errorf("error protecting VM %q from scale-in: %w", i.ID, err)
+	}
+
+	if err := c.scaleOutVMScaleSet(ctx, resourceGroupName, vmssName); err != nil {
+		return err
+	}
+	i.Status = cloudinstances.CloudInstanceStatusDetached
+	return nil
+}
+
+// scaleOutVMScaleSet adds a VM to a VM Scale Set.
+func (c *azureCloudImplementation) scaleOutVMScaleSet(ctx context.Context, resourceGroupName, vmssName string) error {
+	vmss, err := c.vmscaleSetsClient.Get(ctx, resourceGroupName, vmssName)
+	if err != nil {
+		return fmt.Errorf("error getting VM Scale Set %q: %w", vmssName, err)
+	}
+	if vmss == nil || vmss.Sku == nil || vmss.Sku.Capacity == nil {
+		return fmt.Errorf("VM Scale Set %q has no capacity", vmssName)
+	}
+
+	sku := *vmss.Sku
+	sku.Capacity = to.Int64Ptr(*sku.Capacity + 1)
+	if _, err := c.vmscaleSetsClient.Update(ctx, resourceGroupName, vmssName, compute.VirtualMachineScaleSetUpdate{Sku: &sku}); err != nil {
+		return fmt.Errorf("error scaling out VM Scale Set %q: %w", vmssName, err)
+	}
+	return nil
+}
+
+// vmScaleSetVMOf returns the resource group, VM Scale Set name and instance ID of a VM Scale Set VM.
+func vmScaleSetVMOf(i *cloudinstances.CloudInstance) (string, string, string, error) {
+	vmss, ok := i.CloudInstanceGroup.Raw.(*compute.VirtualMachineScaleSet)
+	if !ok || vmss.Name == nil || vmss.ID == nil {
+		return "", "", "", fmt.Errorf("VM %q is not a member of a VM Scale Set", i.ID)
+	}
+	resource, err := autorestazure.ParseResourceID(*vmss.ID)
+	if err != nil {
+		return "", "", "", fmt.Errorf("error parsing ID of VM Scale Set %q: %w", *vmss.Name, err)
+	}
+	// VM Scale Set VMs are named <VM Scale Set name>_<instance ID>
+	instanceID := strings.TrimPrefix(i.ID, *vmss.Name+"_")
+	if instanceID == i.ID || instanceID == "" {
+		return "", "", "", fmt.Errorf("unexpected name %q of a VM in VM Scale Set %q", i.ID, *vmss.Name)
+	}
+	return resource.ResourceGroup, *vmss.Name, instanceID, nil
 }
 
 // AddClusterTags adds cluster tags to the resource.
@@ -0,0 +1,149 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2022-08-01/compute"
+	"github.com/Azure/go-autorest/autorest/to"
+	"k8s.io/kops/pkg/cloudinstances"
+)
+
+func newTestVMScaleSetCloud() (*azureCloudImplementation, *cloudinstances.CloudInstanceGroup, *mockVMScaleSetsClient, *mockVMScaleSetVMsClient) {
+	const vmssName = "nodes.my-cluster"
+
+	vmssClient := &mockVMScaleSetsClient{}
+	vmssClient.vmsses = append(vmssClient.vmsses, compute.VirtualMachineScaleSet{
+		ID:   to.StringPtr("/subscriptions/sub/resourceGroups/my-rg/providers/Microsoft.Compute/virtualMachineScaleSets/" + vmssName),
+		Name: to.StringPtr(vmssName),
+		Sku: &compute.Sku{
+			Name:     to.StringPtr("Standard_B2s"),
+			Capacity: to.Int64Ptr(2),
+		},
+	})
+
+	vmClient := &mockVMScaleSetVMsClient{
+		vmssClient: vmssClient,
+	}
+	vmClient.vms = append(vmClient.vms,
+		compute.VirtualMachineScaleSetVM{
+			Name:       to.StringPtr(vmssName + "_0"),
+			InstanceID: to.StringPtr("0"),
+		},
+		compute.VirtualMachineScaleSetVM{
+			Name:       to.StringPtr(vmssName + "_1"),
+			InstanceID: to.StringPtr("1"),
+		},
+	)
+
+	c := &azureCloudImplementation{
+		vmscaleSetsClient:   vmssClient,
+		vmscaleSetVMsClient: vmClient,
+	}
+	group := &cloudinstances.CloudInstanceGroup{
+		HumanName: vmssName,
+		Raw:       &vmssClient.vmsses[0],
+	}
+	return c, group, vmssClient, vmClient
+}
+
+func TestDetachInstance(t *testing.T) {
+	c, group, vmssClient, vmClient := newTestVMScaleSetCloud()
+	i, err := group.NewCloudInstance("nodes.my-cluster_1", cloudinstances.CloudInstanceStatusNeedsUpdate, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if err := c.DetachInstance(i); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if a, e := i.Status, cloudinstances.CloudInstanceStatusDetached; a != e {
+		t.Errorf("expected status %s, but got %s", e, a)
+	}
+	if a, e := *vmssClient.vmsses[0].Sku.Capacity, int64(3); a != e {
+		t.Errorf("expected capacity %d, but got %d", e, a)
+	}
+	if a, e := *vmssClient.vmsses[0].Sku.Name, "Standard_B2s"; a != e {
+		t.Errorf("expected SKU %s, but got %s", e, a)
+	}
+
+	vm := vmClient.vms[1]
+	if _, ok := vm.Tags[TagDetached]; !ok {
+		t.Errorf("expected VM to be tagged %s, but got tags %v", TagDetached, vm.Tags)
+	}
+	if vm.ProtectionPolicy == nil || !*vm.ProtectionPolicy.ProtectFromScaleIn {
+		t.Errorf("expected VM to be protected from scale-in")
+	}
+
+	// Deleting the detached VM scales in to the original capacity
+	if err := c.DeleteInstance(i); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if a, e := len(vmClient.vms), 1; a != e {
+		t.Errorf("expected %d VM(s), but found %d", e, a)
+	}
+	if a, e := *vmssClient.vmsses[0].Sku.Capacity, int64(2); a != e {
+		t.Errorf("expected capacity %d, but got %d", e, a)
+	}
+}
+
+func TestDeleteInstance(t *testing.T) {
+	c, group, vmssClient, vmClient := newTestVMScaleSetCloud()
+	i, err := group.NewCloudInstance("nodes.my-cluster_0", cloudinstances.CloudInstanceStatusNeedsUpdate, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if err := c.DeleteInstance(i); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if a, e := len(vmClient.vms), 1; a != e {
+		t.Errorf("expected %d VM(s), but found %d", e, a)
+	}
+	if a, e := *vmClient.vms[0].Name, "nodes.my-cluster_1"; a != e {
+		t.Errorf("expected remaining VM %s, but got %s", e, a)
+	}
+	// The VM Scale Set is scaled out to replace the deleted VM
+	if a, e := *vmssClient.vmsses[0].Sku.Capacity, int64(2); a != e {
+		t.Errorf("expected capacity %d, but got %d", e, a)
+	}
+}
+
+func TestVMScaleSetVMOf(t *testing.T) {
+	_, group, _, _ := newTestVMScaleSetCloud()
+
+	i, err := group.NewCloudInstance("nodes.my-cluster_12", cloudinstances.CloudInstanceStatusUpToDate, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	resourceGroupName, vmssName, instanceID, err := vmScaleSetVMOf(i)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if resourceGroupName != "my-rg" || vmssName != "nodes.my-cluster" || instanceID != "12" {
+		t.Errorf("unexpected VM Scale Set VM %s/%s/%s", resourceGroupName, vmssName, instanceID)
+	}
+
+	i, err = group.NewCloudInstance("other_12", cloudinstances.CloudInstanceStatusUpToDate, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if _, _, _, err := vmScaleSetVMOf(i); err == nil {
+		t.Errorf("expected an error for a VM of another VM Scale Set")
+	}
+}
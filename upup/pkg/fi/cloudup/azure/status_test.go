@@ -53,12 +53,26 @@ func (c *mockVMScaleSetsClient) Get(ctx context.Context, resourceGroupName strin
 	return nil, nil
 }
 
+func (c *mockVMScaleSetsClient) Update(ctx context.Context, resourceGroupName, vmssName string, parameters compute.VirtualMachineScaleSetUpdate) (*compute.VirtualMachineScaleSet, error) {
+	for i, vmss := range c.vmsses {
+		if *vmss.Name == vmssName {
+			if parameters.Sku != nil {
+				c.vmsses[i].Sku = parameters.Sku
+			}
+			return &c.vmsses[i], nil
+		}
+	}
+	return nil, fmt.Errorf("VM Scale Set %q not found", vmssName)
+}
+
 func (c *mockVMScaleSetsClient) Delete(ctx context.Context, resourceGroupName, vmssName string) error {
 	return fmt.Errorf("unimplemented")
 }
 
 type mockVMScaleSetVMsClient struct {
 	vms []compute.VirtualMachineScaleSetVM
+	// vmssClient, if set, has the capacity of its VM Scale Sets reduced by deleting VMs
+	vmssClient *mockVMScaleSetsClient
 }
 
 var _ VMScaleSetVMsClient = &mockVMScaleSetVMsClient{}
@@ -67,6 +81,42 @@ func (c *mockVMScaleSetVMsClient) List(ctx context.Context, resourceGroupName, v
 	return c.vms, nil
 }
 
+func (c *mockVMScaleSetVMsClient) Get(ctx context.Context, resourceGroupName, vmssName, instanceID string) (*compute.VirtualMachineScaleSetVM, error) {
+	for _, vm := range c.vms {
+		if *vm.InstanceID == instanceID {
+			return &vm, nil
+		}
+	}
+	return nil, fmt.Errorf("VM %q not found", instanceID)
+}
+
+func (c *mockVMScaleSetVMsClient) Update(ctx context.Context, resourceGroupName, vmssName, instanceID string, parameters compute.VirtualMachineScaleSetVM) (*compute.VirtualMachineScaleSetVM, error) {
+	for i, vm := range c.vms {
+		if *vm.InstanceID == instanceID {
+			c.vms[i] = parameters
+			return &parameters, nil
+		}
+	}
+	return nil, fmt.Errorf("VM %q not found", instanceID)
+}
+
+func (c *mockVMScaleSetVMsClient) Delete(ctx context.Context, resourceGroupName, vmssName, instanceID string) error {
+	for i, vm := range c.vms {
+		if *vm.InstanceID == instanceID {
+			c.vms = append(c.vms[:i], c.vms[i+1:]...)
+			if c.vmssClient != nil {
+				for _, vmss := range c.vmssClient.vmsses {
+					if *vmss.Name == vmssName {
+						vmss.Sku.Capacity = to.Int64Ptr(*vmss.Sku.Capacity - 1)
+					}
+				}
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("VM %q not found", instanceID)
+}
+
 func TestFindEtcdStatus(t *testing.T) {
 	clusterName := "my-cluster"
 	c := &azureCloudImplementation{
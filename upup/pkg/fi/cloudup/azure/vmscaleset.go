@@ -29,6 +29,7 @@ type VMScaleSetsClient interface {
 	CreateOrUpdate(ctx context.Context, resourceGroupName, vmScaleSetName string, parameters compute.VirtualMachineScaleSet) (*compute.VirtualMachineScaleSet, error)
 	List(ctx context.Context, resourceGroupName string) ([]compute.VirtualMachineScaleSet, error)
 	Get(ctx context.Context, resourceGroupName string, vmssName string) (*compute.VirtualMachineScaleSet, error)
+	Update(ctx context.Context, resourceGroupName, vmssName string, parameters compute.VirtualMachineScaleSetUpdate) (*compute.VirtualMachineScaleSet, error)
 	Delete(ctx context.Context, resourceGroupName, vmssName string) error
 }
 
@@ -72,6 +73,21 @@ func (c *vmScaleSetsClientImpl) Get(ctx context.Context, resourceGroupName strin
 	return &vmss, nil
 }
 
+func (c *vmScaleSetsClientImpl) Update(ctx context.Context, resourceGroupName, vmssName string, parameters compute.VirtualMachineScaleSetUpdate) (*compute.VirtualMachineScaleSet, error) {
+	future, err := c.c.Update(ctx, resourceGroupName, vmssName, parameters)
+	if err != nil {
+		return nil, fmt.Errorf("error updating VM Scale Set: %s", err)
+	}
+	if err := future.WaitForCompletionRef(ctx, c.c.Client); err != nil {
+		return nil, fmt.Errorf("error waiting for VM Scale Set update completion: %s", err)
+	}
+	vmss, err := future.Result(*c.c)
+	if err != nil {
+		return nil, fmt.Errorf("error obtaining result for VM Scale Set update: %s", err)
+	}
+	return &vmss, nil
+}
+
 func (c *vmScaleSetsClientImpl) Delete(ctx context.Context, resourceGroupName, vmssName string) error {
 	future, err := c.c.Delete(ctx, resourceGroupName, vmssName, nil)
 	if err != nil {
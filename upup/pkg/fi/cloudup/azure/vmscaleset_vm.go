@@ -18,6 +18,7 @@ package azure
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2022-08-01/compute"
 	"github.com/Azure/go-autorest/autorest"
@@ -26,6 +27,9 @@ import (
 // VMScaleSetVMsClient is a client for managing VMs in VM Scale Sets.
 type VMScaleSetVMsClient interface {
 	List(ctx context.Context, resourceGroupName, vmssName string) ([]compute.VirtualMachineScaleSetVM, error)
+	Get(ctx context.Context, resourceGroupName, vmssName, instanceID string) (*compute.VirtualMachineScaleSetVM, error)
+	Update(ctx context.Context, resourceGroupName, vmssName, instanceID string, parameters compute.VirtualMachineScaleSetVM) (*compute.VirtualMachineScaleSetVM, error)
+	Delete(ctx context.Context, resourceGroupName, vmssName, instanceID string) error
 }
 
 type vmScaleSetVMsClientImpl struct {
@@ -45,6 +49,40 @@ func (c *vmScaleSetVMsClientImpl) List(ctx context.Context, resourceGroupName, v
 	return l, nil
 }
 
+func (c *vmScaleSetVMsClientImpl) Get(ctx context.Context, resourceGroupName, vmssName, instanceID string) (*compute.VirtualMachineScaleSetVM, error) {
+	vm, err := c.c.Get(ctx, resourceGroupName, vmssName, instanceID, "")
+	if err != nil {
+		return nil, err
+	}
+	return &vm, nil
+}
+
+func (c *vmScaleSetVMsClientImpl) Update(ctx context.Context, resourceGroupName, vmssName, instanceID string, parameters compute.VirtualMachineScaleSetVM) (*compute.VirtualMachineScaleSetVM, error) {
+	future, err := c.c.Update(ctx, resourceGroupName, vmssName, instanceID, parameters)
+	if err != nil {
+		return nil, fmt.Errorf("error updating VM Scale Set VM: %s", err)
+	}
+	if err := future.WaitForCompletionRef(ctx, c.c.Client); err != nil {
+		return nil, fmt.Errorf("error waiting for VM Scale Set VM update completion: %s", err)
+	}
+	vm, err := future.Result(*c.c)
+	if err != nil {
+		return nil, fmt.Errorf("error obtaining result for VM Scale Set VM update: %s", err)
+	}
+	return &vm, nil
+}
+
+func (c *vmScaleSetVMsClientImpl) Delete(ctx context.Context, resourceGroupName, vmssName, instanceID string) error {
+	future, err := c.c.Delete(ctx, resourceGroupName, vmssName, instanceID, nil)
+	if err != nil {
+		return fmt.Errorf("error deleting VM Scale Set VM: %s", err)
+	}
+	if err := future.WaitForCompletionRef(ctx, c.c.Client); err != nil {
+		return fmt.Errorf("error waiting for VM Scale Set VM deletion completion: %s", err)
+	}
+	return nil
+}
+
 func newVMScaleSetVMsClientImpl(subscriptionID string, authorizer autorest.Authorizer) *vmScaleSetVMsClientImpl {
 	c := compute.NewVirtualMachineScaleSetVMsClient(subscriptionID)
 	c.Authorizer = authorizer
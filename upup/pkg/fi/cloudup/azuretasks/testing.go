@@ -412,6 +412,20 @@ func (c *MockVMScaleSetsClient) Get(ctx context.Context, resourceGroupName strin
 	return &vmss, nil
 }
 
+// Update updates a VM Scale Set.
+func (c *MockVMScaleSetsClient) Update(ctx context.Context, resourceGroupName, vmssName string, parameters compute.VirtualMachineScaleSetUpdate) (*compute.VirtualMachineScaleSet, error) {
+	// Ignore resourceGroupName for simplicity.
+	vmss, ok := c.VMSSes[vmssName]
+	if !ok {
+		return nil, fmt.Errorf("%s does not exist", vmssName)
+	}
+	if parameters.Sku != nil {
+		vmss.Sku = parameters.Sku
+	}
+	c.VMSSes[vmssName] = vmss
+	return &vmss, nil
+}
+
 // Delete deletes a specified VM Scale Set.
 func (c *MockVMScaleSetsClient) Delete(ctx context.Context, resourceGroupName, vmssName string) error {
 	// Ignore resourceGroupName for simplicity.
@@ -424,6 +438,7 @@ func (c *MockVMScaleSetsClient) Delete(ctx context.Context, resourceGroupName, v
 
 // MockVMScaleSetVMsClient is a mock implementation of VM Scale Set VM client.
 type MockVMScaleSetVMsClient struct {
+	// VMs are keyed by their names, of the form <VM Scale Set name>_<instance ID>.
 	VMs map[string]compute.VirtualMachineScaleSetVM
 }
 
@@ -439,6 +454,38 @@ func (c *MockVMScaleSetVMsClient) List(ctx context.Context, resourceGroupName, v
 	return l, nil
 }
 
+// Get returns a specified VM Scale Set VM.
+func (c *MockVMScaleSetVMsClient) Get(ctx context.Context, resourceGroupName, vmssName, instanceID string) (*compute.VirtualMachineScaleSetVM, error) {
+	// Ignore resourceGroupName for simplicity.
+	vm, ok := c.VMs[vmssName+"_"+instanceID]
+	if !ok {
+		return nil, fmt.Errorf("%s_%s does not exist", vmssName, instanceID)
+	}
+	return &vm, nil
+}
+
+// Update updates a specified VM Scale Set VM.
+func (c *MockVMScaleSetVMsClient) Update(ctx context.Context, resourceGroupName, vmssName, instanceID string, parameters compute.VirtualMachineScaleSetVM) (*compute.VirtualMachineScaleSetVM, error) {
+	// Ignore resourceGroupName for simplicity.
+	name := vmssName + "_" + instanceID
+	if _, ok := c.VMs[name]; !ok {
+		return nil, fmt.Errorf("%s does not exist", name)
+	}
+	c.VMs[name] = parameters
+	return &parameters, nil
+}
+
+// Delete deletes a specified VM Scale Set VM.
+func (c *MockVMScaleSetVMsClient) Delete(ctx context.Context, resourceGroupName, vmssName, instanceID string) error {
+	// Ignore resourceGroupName for simplicity.
+	name := vmssName + "_" + instanceID
+	if _, ok := c.VMs[name]; !ok {
+		return fmt.Errorf("%s does not exist", name)
+	}
+	delete(c.VMs, name)
+	return nil
+}
+
 // MockDisksClient is a mock implementation of disk client.
 type MockDisksClient struct {
 	Disks map[string]compute.Disk
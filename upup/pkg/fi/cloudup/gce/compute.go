@@ -597,6 +597,7 @@ type InstanceClient interface {
 	List(ctx context.Context, project, zone string) ([]*compute.Instance, error)
 	Delete(project, zone, name string) (*compute.Operation, error)
 	SetMetadata(project, zone, name string, metadata *compute.Metadata) (*compute.Operation, error)
+	SetLabels(project, zone, name string, req *compute.InstancesSetLabelsRequest) (*compute.Operation, error)
 }
 
 type instanceClientImpl struct {
@@ -632,6 +633,10 @@ func (c *instanceClientImpl) SetMetadata(project, zone, name string, metadata *c
 	return c.srv.SetMetadata(project, zone, name, metadata).Do()
 }
 
+func (c *instanceClientImpl) SetLabels(project, zone, name string, req *compute.InstancesSetLabelsRequest) (*compute.Operation, error) {
+	return c.srv.SetLabels(project, zone, name, req).Do()
+}
+
 type InstanceTemplateClient interface {
 	Insert(project string, template *compute.InstanceTemplate) (*compute.Operation, error)
 	Delete(project, name string) (*compute.Operation, error)
@@ -670,6 +675,7 @@ type InstanceGroupManagerClient interface {
 	List(ctx context.Context, project, zone string) ([]*compute.InstanceGroupManager, error)
 	ListManagedInstances(ctx context.Context, project, zone, name string) ([]*compute.ManagedInstance, error)
 	RecreateInstances(project, zone, name, id string) (*compute.Operation, error)
+	AbandonInstances(project, zone, name, id string) (*compute.Operation, error)
 	SetTargetPools(project, zone, name string, targetPools []string) (*compute.Operation, error)
 	SetInstanceTemplate(project, zone, name, instanceTemplateURL string) (*compute.Operation, error)
 	Resize(project, zone, name string, newSize int64) (*compute.Operation, error)
@@ -724,6 +730,15 @@ func (c *instanceGroupManagerClientImpl) RecreateInstances(project, zone, name,
 	return c.srv.RecreateInstances(project, zone, name, req).Do()
 }
 
+func (c *instanceGroupManagerClientImpl) AbandonInstances(project, zone, name, id string) (*compute.Operation, error) {
+	req := &compute.InstanceGroupManagersAbandonInstancesRequest{
+		Instances: []string{
+			id,
+		},
+	}
+	return c.srv.AbandonInstances(project, zone, name, req).Do()
+}
+
 func (c *instanceGroupManagerClientImpl) SetTargetPools(project, zone, name string, targetPools []string) (*compute.Operation, error) {
 	req := &compute.InstanceGroupManagersSetTargetPoolsRequest{
 		TargetPools: targetPools,
@@ -35,7 +35,7 @@ func (c *gceCloudImplementation) DeleteGroup(g *cloudinstances.CloudInstanceGrou
 	return deleteCloudInstanceGroup(c, g)
 }
 
-// deleteCloudInstanceGroup deletes the InstanceGroupManager and current InstanceTemplate
+// deleteCloudInstanceGroup deletes the InstanceGroupManager, current InstanceTemplate and the instances detached from the InstanceGroupManager
 func deleteCloudInstanceGroup(c GCECloud, g *cloudinstances.CloudInstanceGroup) error {
 	mig := g.Raw.(*compute.InstanceGroupManager)
 	err := DeleteInstanceGroupManager(c, mig)
@@ -43,11 +43,27 @@ func deleteCloudInstanceGroup(c GCECloud, g *cloudinstances.CloudInstanceGroup)
 		return err
 	}
 
+	for _, i := range g.NeedUpdate {
+		if i.Status == cloudinstances.CloudInstanceStatusDetached {
+			if err := DeleteInstance(c, i.ID); err != nil {
+				return err
+			}
+		}
+	}
+
 	return DeleteInstanceTemplate(c, mig.InstanceTemplate)
 }
 
 // DeleteInstance deletes a GCE instance
 func (c *gceCloudImplementation) DeleteInstance(i *cloudinstances.CloudInstance) error {
+	return DeleteCloudInstance(c, i)
+}
+
+// DeleteCloudInstance deletes an instance detached from its InstanceGroupManager, or recreates an instance managed by one
+func DeleteCloudInstance(c GCECloud, i *cloudinstances.CloudInstance) error {
+	if i.Status == cloudinstances.CloudInstanceStatusDetached {
+		return DeleteInstance(c, i.ID)
+	}
 	return recreateCloudInstance(c, i)
 }
 
@@ -56,10 +72,71 @@ func (c *gceCloudImplementation) DeregisterInstance(i *cloudinstances.CloudInsta
 	return nil
 }
 
-// DetachInstance is not implemented yet. It needs to cause a cloud instance to no longer be counted against the group's size limits.
+// DetachInstance causes a cloud instance to no longer be counted against the group's size limits.
 func (c *gceCloudImplementation) DetachInstance(i *cloudinstances.CloudInstance) error {
-	klog.V(8).Info("gce cloud provider DetachInstance not implemented yet")
-	return fmt.Errorf("gce cloud provider does not support surging")
+	return DetachCloudInstance(c, i)
+}
+
+// DetachCloudInstance abandons an instance from its InstanceGroupManager, which leaves it running,
+// then resizes the InstanceGroupManager so that it creates a replacement.
+// The instance is labeled so that it can be found and deleted afterwards.
+func DetachCloudInstance(c GCECloud, i *cloudinstances.CloudInstance) error {
+	mig := i.CloudInstanceGroup.Raw.(*compute.InstanceGroupManager)
+
+	klog.V(2).Infof("Detaching GCE Instance %s from MIG %s", i.ID, mig.Name)
+
+	migURL, err := ParseGoogleCloudURL(mig.SelfLink)
+	if err != nil {
+		return err
+	}
+	instanceURL, err := ParseGoogleCloudURL(i.ID)
+	if err != nil {
+		return err
+	}
+
+	instance, err := c.Compute().Instances().Get(instanceURL.Project, instanceURL.Zone, instanceURL.Name)
+	if err != nil {
+		return fmt.Errorf("error getting Instance %s: %v", i.ID, err)
+	}
+	labels := make(map[string]string)
+	for k, v := range instance.Labels {
+		labels[k] = v
+	}
+	labels[GceLabelNameDetachedFrom] = mig.Name
+	op, err := c.Compute().Instances().SetLabels(instanceURL.Project, instanceURL.Zone, instanceURL.Name, &compute.InstancesSetLabelsRequest{
+		Labels:           labels,
+		LabelFingerprint: instance.LabelFingerprint,
+	})
+	if err != nil {
+		return fmt.Errorf("error labeling Instance %s: %v", i.ID, err)
+	}
+	if err := c.WaitForOp(op); err != nil {
+		return fmt.Errorf("error labeling Instance %s: %v", i.ID, err)
+	}
+
+	// Abandoning an instance reduces the target size of the InstanceGroupManager
+	op, err = c.Compute().InstanceGroupManagers().AbandonInstances(migURL.Project, migURL.Zone, migURL.Name, i.ID)
+	if err != nil {
+		return fmt.Errorf("error abandoning Instance %s: %v", i.ID, err)
+	}
+	if err := c.WaitForOp(op); err != nil {
+		return fmt.Errorf("error abandoning Instance %s: %v", i.ID, err)
+	}
+
+	current, err := c.Compute().InstanceGroupManagers().Get(migURL.Project, migURL.Zone, migURL.Name)
+	if err != nil {
+		return fmt.Errorf("error getting InstanceGroupManager %s: %v", mig.Name, err)
+	}
+	op, err = c.Compute().InstanceGroupManagers().Resize(migURL.Project, migURL.Zone, migURL.Name, current.TargetSize+1)
+	if err != nil {
+		return fmt.Errorf("error resizing InstanceGroupManager %s: %v", mig.Name, err)
+	}
+	if err := c.WaitForOp(op); err != nil {
+		return fmt.Errorf("error resizing InstanceGroupManager %s: %v", mig.Name, err)
+	}
+
+	i.Status = cloudinstances.CloudInstanceStatusDetached
+	return nil
 }
 
 // recreateCloudInstance recreates the specified instances, managed by an InstanceGroupManager
@@ -85,6 +162,77 @@ func recreateCloudInstance(c GCECloud, i *cloudinstances.CloudInstance) error {
 	return c.WaitForOp(op)
 }
 
+// GetGroupTemplate returns the InstanceTemplate the InstanceGroupManager creates new instances from.
+func (c *gceCloudImplementation) GetGroupTemplate(g *cloudinstances.CloudInstanceGroup) (string, error) {
+	return GetCloudGroupTemplate(c, g)
+}
+
+// GetInstanceTemplate returns the InstanceTemplate an instance was created from.
+func (c *gceCloudImplementation) GetInstanceTemplate(i *cloudinstances.CloudInstance) (string, error) {
+	return GetCloudInstanceTemplate(c, i)
+}
+
+// SetGroupTemplate makes the InstanceGroupManager create new instances from an InstanceTemplate.
+func (c *gceCloudImplementation) SetGroupTemplate(g *cloudinstances.CloudInstanceGroup, template string) error {
+	return SetCloudGroupTemplate(c, g, template)
+}
+
+// GetCloudGroupTemplate returns the URL of the InstanceTemplate an InstanceGroupManager creates new instances from.
+func GetCloudGroupTemplate(c GCECloud, g *cloudinstances.CloudInstanceGroup) (string, error) {
+	mig, ok := g.Raw.(*compute.InstanceGroupManager)
+	if !ok {
+		return "", fmt.Errorf("%s is not an InstanceGroupManager", g.HumanName)
+	}
+	return mig.InstanceTemplate, nil
+}
+
+// GetCloudInstanceTemplate returns the URL of the InstanceTemplate a managed instance was created from,
+// or "" if the instance is no longer managed by its InstanceGroupManager.
+func GetCloudInstanceTemplate(c GCECloud, i *cloudinstances.CloudInstance) (string, error) {
+	if i.CloudInstanceGroup == nil {
+		return "", nil
+	}
+	mig, ok := i.CloudInstanceGroup.Raw.(*compute.InstanceGroupManager)
+	if !ok {
+		return "", fmt.Errorf("%s is not an InstanceGroupManager", i.CloudInstanceGroup.HumanName)
+	}
+	instances, err := ListManagedInstances(c, mig)
+	if err != nil {
+		return "", err
+	}
+	for _, instance := range instances {
+		if instance.Instance == i.ID && instance.Version != nil {
+			return instance.Version.InstanceTemplate, nil
+		}
+	}
+	return "", nil
+}
+
+// SetCloudGroupTemplate makes an InstanceGroupManager create new instances from the InstanceTemplate at a URL.
+// Existing instances are not recreated.
+func SetCloudGroupTemplate(c GCECloud, g *cloudinstances.CloudInstanceGroup, template string) error {
+	mig, ok := g.Raw.(*compute.InstanceGroupManager)
+	if !ok {
+		return fmt.Errorf("%s is not an InstanceGroupManager", g.HumanName)
+	}
+
+	klog.V(2).Infof("Setting InstanceTemplate of MIG %s to %s", mig.Name, template)
+
+	migURL, err := ParseGoogleCloudURL(mig.SelfLink)
+	if err != nil {
+		return err
+	}
+	op, err := c.Compute().InstanceGroupManagers().SetInstanceTemplate(migURL.Project, migURL.Zone, migURL.Name, template)
+	if err != nil {
+		return fmt.Errorf("error setting InstanceTemplate of MIG %s: %v", mig.Name, err)
+	}
+	if err := c.WaitForOp(op); err != nil {
+		return fmt.Errorf("error setting InstanceTemplate of MIG %s: %v", mig.Name, err)
+	}
+	mig.InstanceTemplate = template
+	return nil
+}
+
 // GetCloudGroups returns a map of CloudGroup that backs a list of instance groups
 func (c *gceCloudImplementation) GetCloudGroups(cluster *kops.Cluster, instancegroups []*kops.InstanceGroup, warnUnmatched bool, nodes []v1.Node) (map[string]*cloudinstances.CloudInstanceGroup, error) {
 	return getCloudGroups(c, cluster, instancegroups, warnUnmatched, nodes)
@@ -132,6 +280,10 @@ func getCloudGroups(c GCECloud, cluster *kops.Cluster, instancegroups []*kops.In
 		if err != nil {
 			return nil, fmt.Errorf("error listing InstanceGroupManagers: %v", err)
 		}
+
+		// The instances detached from the MIGs of the zone, keyed by MIG name, listed when a MIG matches
+		var detached map[string][]*compute.Instance
+
 		for _, mig := range migs {
 			name := mig.Name
 
@@ -169,8 +321,7 @@ func getCloudGroups(c GCECloud, cluster *kops.Cluster, instancegroups []*kops.In
 				return nil, err
 			}
 
-			for _, i := range instances {
-				id := i.Instance
+			newCloudInstance := func(id string) *cloudinstances.CloudInstance {
 				cm := &cloudinstances.CloudInstance{
 					ID:                 id,
 					CloudInstanceGroup: g,
@@ -186,7 +337,11 @@ func getCloudGroups(c GCECloud, cluster *kops.Cluster, instancegroups []*kops.In
 				} else {
 					klog.V(8).Infof("unable to find node for instance: %s", id)
 				}
+				return cm
+			}
 
+			for _, i := range instances {
+				cm := newCloudInstance(i.Instance)
 				if i.Version != nil && latestInstanceTemplate == i.Version.InstanceTemplate {
 					g.Ready = append(g.Ready, cm)
 				} else {
@@ -194,12 +349,40 @@ func getCloudGroups(c GCECloud, cluster *kops.Cluster, instancegroups []*kops.In
 				}
 			}
 
+			if detached == nil {
+				detached, err = findDetachedInstances(c, zoneName)
+				if err != nil {
+					return nil, err
+				}
+			}
+			for _, i := range detached[mig.Name] {
+				cm := newCloudInstance(i.SelfLink)
+				cm.Status = cloudinstances.CloudInstanceStatusDetached
+				g.NeedUpdate = append(g.NeedUpdate, cm)
+			}
+
 		}
 	}
 
 	return groups, nil
 }
 
+// findDetachedInstances returns the instances of a zone detached from their InstanceGroupManager, keyed by its name
+func findDetachedInstances(c GCECloud, zone string) (map[string][]*compute.Instance, error) {
+	instances, err := c.Compute().Instances().List(context.Background(), c.Project(), zone)
+	if err != nil {
+		return nil, fmt.Errorf("error listing Instances: %v", err)
+	}
+
+	detached := make(map[string][]*compute.Instance)
+	for _, i := range instances {
+		if mig := i.Labels[GceLabelNameDetachedFrom]; mig != "" {
+			detached[mig] = append(detached[mig], i)
+		}
+	}
+	return detached, nil
+}
+
 // NameForInstanceGroupManager builds a name for an InstanceGroupManager in the specified zone
 func NameForInstanceGroupManager(c *kops.Cluster, ig *kops.InstanceGroup, zone string) string {
 	shortZone := zone
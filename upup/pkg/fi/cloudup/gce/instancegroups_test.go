@@ -0,0 +1,153 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gce_test
+
+import (
+	"testing"
+
+	compute "google.golang.org/api/compute/v1"
+	gcemock "k8s.io/kops/cloudmock/gce"
+	"k8s.io/kops/cloudmock/gce/mockcompute"
+	"k8s.io/kops/pkg/cloudinstances"
+	"k8s.io/kops/upup/pkg/fi/cloudup/gce"
+)
+
+const (
+	testProject = "testproject"
+	testZone    = "us-test1-a"
+)
+
+func setupDetachTest(t *testing.T) (*gcemock.MockGCECloud, *cloudinstances.CloudInstanceGroup) {
+	cloud := gcemock.InstallMockGCECloud("us-test1", testProject)
+	client := cloud.Compute().(*mockcompute.MockClient)
+
+	mig := &compute.InstanceGroupManager{
+		Name:       "a-nodes-example-com",
+		Zone:       testZone,
+		TargetSize: 2,
+	}
+	if _, err := client.InstanceGroupManagers().Insert(testProject, testZone, mig); err != nil {
+		t.Fatalf("error creating InstanceGroupManager: %v", err)
+	}
+
+	group := &cloudinstances.CloudInstanceGroup{
+		HumanName: mig.Name,
+		Raw:       mig,
+	}
+	for _, name := range []string{"nodes-abcd", "nodes-efgh"} {
+		instance := &compute.Instance{
+			Name: name,
+			Labels: map[string]string{
+				gce.GceLabelNameInstanceGroup: "nodes",
+			},
+		}
+		if err := client.AddManagedInstance(testProject, testZone, mig.Name, instance); err != nil {
+			t.Fatalf("error creating Instance: %v", err)
+		}
+		if _, err := group.NewCloudInstance(instance.SelfLink, cloudinstances.CloudInstanceStatusNeedsUpdate, nil); err != nil {
+			t.Fatalf("error creating CloudInstance: %v", err)
+		}
+	}
+	return cloud, group
+}
+
+func TestDetachCloudInstance(t *testing.T) {
+	cloud, group := setupDetachTest(t)
+	i := group.NeedUpdate[1]
+
+	if err := gce.DetachCloudInstance(cloud, i); err != nil {
+		t.Fatalf("error detaching instance: %v", err)
+	}
+	if i.Status != cloudinstances.CloudInstanceStatusDetached {
+		t.Errorf("expected status %q, got %q", cloudinstances.CloudInstanceStatusDetached, i.Status)
+	}
+
+	mig, err := cloud.Compute().InstanceGroupManagers().Get(testProject, testZone, "a-nodes-example-com")
+	if err != nil {
+		t.Fatalf("error getting InstanceGroupManager: %v", err)
+	}
+	if mig.TargetSize != 2 {
+		t.Errorf("expected the InstanceGroupManager to be resized to replace the instance, got target size %d", mig.TargetSize)
+	}
+	managed, err := gce.ListManagedInstances(cloud, mig)
+	if err != nil {
+		t.Fatalf("error listing managed instances: %v", err)
+	}
+	if len(managed) != 1 || managed[0].Instance != group.NeedUpdate[0].ID {
+		t.Errorf("expected the instance to be abandoned, got managed instances %+v", managed)
+	}
+
+	instance, err := cloud.Compute().Instances().Get(testProject, testZone, "nodes-efgh")
+	if err != nil {
+		t.Fatalf("error getting instance: %v", err)
+	}
+	if instance.Labels[gce.GceLabelNameDetachedFrom] != "a-nodes-example-com" {
+		t.Errorf("expected the instance to be labeled as detached, got labels %v", instance.Labels)
+	}
+	if instance.Labels[gce.GceLabelNameInstanceGroup] != "nodes" {
+		t.Errorf("expected the instance to keep its labels, got labels %v", instance.Labels)
+	}
+
+	// A detached instance is deleted rather than recreated
+	if err := gce.DeleteCloudInstance(cloud, i); err != nil {
+		t.Fatalf("error deleting instance: %v", err)
+	}
+	if _, err := cloud.Compute().Instances().Get(testProject, testZone, "nodes-efgh"); !gce.IsNotFound(err) {
+		t.Errorf("expected the detached instance to be deleted, got %v", err)
+	}
+}
+
+func TestDeleteCloudInstanceRecreatesManagedInstance(t *testing.T) {
+	cloud, group := setupDetachTest(t)
+
+	if err := gce.DeleteCloudInstance(cloud, group.NeedUpdate[0]); err != nil {
+		t.Fatalf("error deleting instance: %v", err)
+	}
+	if _, err := cloud.Compute().Instances().Get(testProject, testZone, "nodes-abcd"); err != nil {
+		t.Errorf("expected the managed instance to be recreated by its InstanceGroupManager, got %v", err)
+	}
+}
+
+func TestSetCloudGroupTemplate(t *testing.T) {
+	cloud, group := setupDetachTest(t)
+	previous := "https://www.googleapis.com/compute/v1/projects/testproject/global/instanceTemplates/nodes-1"
+	if err := gce.SetCloudGroupTemplate(cloud, group, previous); err != nil {
+		t.Fatalf("error setting instance template: %v", err)
+	}
+
+	mig, err := cloud.Compute().InstanceGroupManagers().Get(testProject, testZone, "a-nodes-example-com")
+	if err != nil {
+		t.Fatalf("error getting InstanceGroupManager: %v", err)
+	}
+	if mig.InstanceTemplate != previous {
+		t.Errorf("expected the InstanceGroupManager to use %s, got %s", previous, mig.InstanceTemplate)
+	}
+	template, err := gce.GetCloudGroupTemplate(cloud, group)
+	if err != nil {
+		t.Fatalf("error getting group template: %v", err)
+	}
+	if template != previous {
+		t.Errorf("expected group template %s, got %s", previous, template)
+	}
+	template, err = gce.GetCloudInstanceTemplate(cloud, group.NeedUpdate[0])
+	if err != nil {
+		t.Fatalf("error getting instance template: %v", err)
+	}
+	if template != previous {
+		t.Errorf("expected instance template %s, got %s", previous, template)
+	}
+}
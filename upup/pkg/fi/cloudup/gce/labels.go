@@ -34,6 +34,10 @@ const (
 	ControlPlane                  = "control-plane"
 	Bastion                       = "bastion"
 	Node                          = "node"
+
+	// GceLabelNameDetachedFrom labels the instances detached from an InstanceGroupManager by a rolling update,
+	// with the name of the InstanceGroupManager
+	GceLabelNameDetachedFrom = "k8s-io-detached-from"
 )
 
 // EncodeGCELabel encodes a string into an RFC1035 compatible value, suitable for use as GCE label key or value
@@ -70,6 +70,7 @@ const (
 	TagKopsNetwork           = "KopsNetwork"
 	TagKopsName              = "KopsName"
 	TagKopsRole              = "KopsRole"
+	TagKopsDetached          = "KopsDetached"
 	ResourceTypePort         = "ports"
 	ResourceTypeNetwork      = "networks"
 	ResourceTypeSubnet       = "subnets"
@@ -19,6 +19,7 @@ package openstack
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/gophercloud/gophercloud/openstack/compute/v2/flavors"
@@ -47,6 +48,7 @@ const (
 	ALLOWED_ADDRESS_PAIR      = "allowedAddressPair"
 
 	defaultActiveTimeout = time.Second * 120
+	detachedSuffix       = "-detached"
 	activeStatus         = "ACTIVE"
 	errorStatus          = "ERROR"
 )
@@ -186,7 +188,29 @@ func (c *openstackCloud) DeleteInstance(i *cloudinstances.CloudInstance) error {
 }
 
 func deleteInstance(c OpenstackCloud, i *cloudinstances.CloudInstance) error {
-	return deleteInstanceWithID(c, i.ID)
+	if i.Status != cloudinstances.CloudInstanceStatusDetached {
+		return deleteInstanceWithID(c, i.ID)
+	}
+
+	// The ports of a detached server are not reused by its replacement, so delete them with it
+	serverPorts, err := c.ListPorts(ports.ListOpts{
+		DeviceID: i.ID,
+	})
+	if err != nil {
+		return fmt.Errorf("error listing ports of instance %q: %v", i.ID, err)
+	}
+	if err := deleteInstanceWithID(c, i.ID); err != nil {
+		return err
+	}
+	for _, port := range serverPorts {
+		if !strings.HasSuffix(port.Name, detachedSuffix) {
+			continue
+		}
+		if err := c.DeletePort(port.ID); err != nil {
+			return fmt.Errorf("error deleting port %q of instance %q: %v", port.Name, i.ID, err)
+		}
+	}
+	return nil
 }
 
 func (c *openstackCloud) DeleteInstanceWithID(instanceID string) error {
@@ -306,14 +330,59 @@ func drainSingleLB(c OpenstackCloud, lb loadbalancers.LoadBalancer, instanceName
 	return nil
 }
 
-// DetachInstance is not implemented yet. It needs to cause a cloud instance to no longer be counted against the group's size limits.
+// DetachInstance causes a cloud instance to no longer be counted against the group's size limits:
+// the next apply creates a replacement for it.
 func (c *openstackCloud) DetachInstance(i *cloudinstances.CloudInstance) error {
 	return detachInstance(c, i)
 }
 
 func detachInstance(c OpenstackCloud, i *cloudinstances.CloudInstance) error {
-	klog.V(8).Info("openstack cloud provider DetachInstance not implemented yet")
-	return fmt.Errorf("openstack cloud provider does not support surging")
+	server, err := c.GetInstance(i.ID)
+	if err != nil {
+		return err
+	}
+
+	// Instances find their ports by name, so renaming them lets the replacement create its own
+	serverPorts, err := c.ListPorts(ports.ListOpts{
+		DeviceID: server.ID,
+	})
+	if err != nil {
+		return fmt.Errorf("error listing ports of instance %q: %v", server.ID, err)
+	}
+	for _, port := range serverPorts {
+		if strings.HasSuffix(port.Name, detachedSuffix) {
+			continue
+		}
+		_, err := c.UpdatePort(port.ID, ports.UpdateOpts{
+			Name: fi.PtrTo(port.Name + detachedSuffix),
+		})
+		if err != nil {
+			return fmt.Errorf("error renaming port %q of instance %q: %v", port.Name, server.ID, err)
+		}
+	}
+
+	// Servers are found by their name or their KopsName metadata
+	name := server.Metadata[TagKopsName]
+	if name != "" && server.Name == name {
+		_, err := servers.Update(c.ComputeClient(), server.ID, servers.UpdateOpts{
+			Name: name + detachedSuffix,
+		}).Extract()
+		if err != nil {
+			return fmt.Errorf("error renaming instance %q: %v", server.ID, err)
+		}
+	}
+	metadata := servers.MetadataOpts{
+		TagKopsDetached: "true",
+	}
+	if name != "" && !strings.HasSuffix(name, detachedSuffix) {
+		metadata[TagKopsName] = name + detachedSuffix
+	}
+	if _, err := servers.UpdateMetadata(c.ComputeClient(), server.ID, metadata).Extract(); err != nil {
+		return fmt.Errorf("error updating metadata of instance %q: %v", server.ID, err)
+	}
+
+	i.Status = cloudinstances.CloudInstanceStatusDetached
+	return nil
 }
 
 func (c *openstackCloud) GetInstance(id string) (*servers.Server, error) {
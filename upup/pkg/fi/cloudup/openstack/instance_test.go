@@ -22,7 +22,13 @@ import (
 
 	"time"
 
+	"github.com/gophercloud/gophercloud/openstack/compute/v2/extensions/servergroups"
 	"github.com/gophercloud/gophercloud/openstack/compute/v2/servers"
+	"github.com/gophercloud/gophercloud/openstack/networking/v2/ports"
+	"k8s.io/kops/cloudmock/openstack/mockcompute"
+	"k8s.io/kops/cloudmock/openstack/mocknetworking"
+	"k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/pkg/cloudinstances"
 	"k8s.io/kops/upup/pkg/fi"
 )
 
@@ -87,3 +93,133 @@ func Test_WaitForStatusActiveResultsInTimeout(t *testing.T) {
 	expectedErr := fmt.Errorf("A timeout occurred")
 	assertTestResults(t, nil, actualErr, expectedErr)
 }
+
+func createDetachTestServer(t *testing.T) (*MockCloud, *servers.Server) {
+	c := BuildMockOpenstackCloud("us-test1")
+	c.MockNeutronClient = mocknetworking.CreateClient()
+	c.MockNovaClient = mockcompute.CreateClient(c.MockNeutronClient.ServiceClient())
+
+	port, err := c.CreatePort(ports.CreateOpts{
+		Name:      "port-nodes-1-cluster-k8s-local",
+		NetworkID: "network",
+	})
+	if err != nil {
+		t.Fatalf("error creating port: %v", err)
+	}
+	server, err := servers.Create(c.ComputeClient(), servers.CreateOpts{
+		Name: "nodes-abcdef",
+		Networks: []servers.Network{
+			{Port: port.ID},
+		},
+		Metadata: map[string]string{
+			TagKopsName:               "nodes-1-cluster-k8s-local",
+			INSTANCE_GROUP_GENERATION: "1",
+			CLUSTER_GENERATION:        "1",
+		},
+	}).Extract()
+	if err != nil {
+		t.Fatalf("error creating server: %v", err)
+	}
+	return c, server
+}
+
+func Test_DetachInstance(t *testing.T) {
+	c, server := createDetachTestServer(t)
+
+	group := &cloudinstances.CloudInstanceGroup{
+		InstanceGroup: &kops.InstanceGroup{},
+	}
+	i, err := group.NewCloudInstance(server.ID, cloudinstances.CloudInstanceStatusNeedsUpdate, nil)
+	if err != nil {
+		t.Fatalf("error creating cloud instance: %v", err)
+	}
+
+	if err := c.DetachInstance(i); err != nil {
+		t.Fatalf("error detaching instance: %v", err)
+	}
+	if i.Status != cloudinstances.CloudInstanceStatusDetached {
+		t.Errorf("expected status %q, got %q", cloudinstances.CloudInstanceStatusDetached, i.Status)
+	}
+
+	detached, err := c.GetInstance(server.ID)
+	if err != nil {
+		t.Fatalf("error getting server: %v", err)
+	}
+	if detached.Name != "nodes-abcdef" {
+		t.Errorf("expected server to keep its name, got %q", detached.Name)
+	}
+	if name := detached.Metadata[TagKopsName]; name != "nodes-1-cluster-k8s-local-detached" {
+		t.Errorf("unexpected %s metadata %q", TagKopsName, name)
+	}
+	if detached.Metadata[TagKopsDetached] != "true" {
+		t.Errorf("expected %s metadata, got %v", TagKopsDetached, detached.Metadata)
+	}
+	serverPorts, err := c.ListPorts(ports.ListOpts{DeviceID: server.ID})
+	if err != nil {
+		t.Fatalf("error listing ports: %v", err)
+	}
+	if len(serverPorts) != 1 || serverPorts[0].Name != "port-nodes-1-cluster-k8s-local-detached" {
+		t.Errorf("expected port to be renamed, got %+v", serverPorts)
+	}
+
+	// Detaching again does not rename anything twice
+	if err := c.DetachInstance(i); err != nil {
+		t.Fatalf("error detaching instance again: %v", err)
+	}
+	detached, err = c.GetInstance(server.ID)
+	if err != nil {
+		t.Fatalf("error getting server: %v", err)
+	}
+	if name := detached.Metadata[TagKopsName]; name != "nodes-1-cluster-k8s-local-detached" {
+		t.Errorf("unexpected %s metadata %q after detaching twice", TagKopsName, name)
+	}
+
+	cluster := &kops.Cluster{}
+	cluster.Generation = 1
+	ig := &kops.InstanceGroup{}
+	ig.Generation = 1
+	cg, err := osBuildCloudInstanceGroup(c, cluster, ig, servergroups.ServerGroup{Members: []string{server.ID}}, nil)
+	if err != nil {
+		t.Fatalf("error building cloud instance group: %v", err)
+	}
+	if len(cg.NeedUpdate) != 1 || cg.NeedUpdate[0].Status != cloudinstances.CloudInstanceStatusDetached {
+		t.Errorf("expected the instance to be detached, got %+v", cg.NeedUpdate)
+	}
+
+	if err := c.DeleteInstance(i); err != nil {
+		t.Fatalf("error deleting instance: %v", err)
+	}
+	if _, err := servers.Get(c.ComputeClient(), server.ID).Extract(); err == nil {
+		t.Errorf("expected server to be deleted")
+	}
+	allPorts, err := c.ListPorts(ports.ListOpts{})
+	if err != nil {
+		t.Fatalf("error listing ports: %v", err)
+	}
+	if len(allPorts) != 0 {
+		t.Errorf("expected the ports of the detached server to be deleted, got %+v", allPorts)
+	}
+}
+
+func Test_DeleteInstanceKeepsPorts(t *testing.T) {
+	c, server := createDetachTestServer(t)
+
+	group := &cloudinstances.CloudInstanceGroup{
+		InstanceGroup: &kops.InstanceGroup{},
+	}
+	i, err := group.NewCloudInstance(server.ID, cloudinstances.CloudInstanceStatusNeedsUpdate, nil)
+	if err != nil {
+		t.Fatalf("error creating cloud instance: %v", err)
+	}
+
+	if err := c.DeleteInstance(i); err != nil {
+		t.Fatalf("error deleting instance: %v", err)
+	}
+	allPorts, err := c.ListPorts(ports.ListOpts{})
+	if err != nil {
+		t.Fatalf("error listing ports: %v", err)
+	}
+	if len(allPorts) != 1 {
+		t.Errorf("expected the port to be kept for the replacement, got %+v", allPorts)
+	}
+}
@@ -76,6 +76,17 @@ func (s *ServerGroup) Find(context *fi.CloudupContext) (*ServerGroup, error) {
 			if actual != nil {
 				return nil, fmt.Errorf("Found multiple server groups with name %s", fi.ValueOf(s.Name))
 			}
+			// Servers detached by a rolling update are replaced, so they do not count against the size of the group
+			detached, err := findDetachedServers(cloud, fi.ValueOf(s.IGName), fi.ValueOf(s.ClusterName))
+			if err != nil {
+				return nil, err
+			}
+			size := 0
+			for _, member := range serverGroup.Members {
+				if !detached[member] {
+					size++
+				}
+			}
 			actual = &ServerGroup{
 				Name:        fi.PtrTo(serverGroup.Name),
 				ClusterName: s.ClusterName,
@@ -83,7 +94,7 @@ func (s *ServerGroup) Find(context *fi.CloudupContext) (*ServerGroup, error) {
 				ID:          fi.PtrTo(serverGroup.ID),
 				Lifecycle:   s.Lifecycle,
 				Policies:    serverGroup.Policies,
-				MaxSize:     fi.PtrTo(int32(len(serverGroup.Members))),
+				MaxSize:     fi.PtrTo(int32(size)),
 				members:     serverGroup.Members,
 			}
 		}
@@ -102,6 +113,24 @@ func (s *ServerGroup) Find(context *fi.CloudupContext) (*ServerGroup, error) {
 	return actual, nil
 }
 
+// findDetachedServers returns the IDs of the servers of an instance group detached by a rolling update.
+func findDetachedServers(cloud openstack.OpenstackCloud, igName, clusterName string) (map[string]bool, error) {
+	allInstances, err := cloud.ListInstances(servers.ListOpts{
+		Name: fmt.Sprintf("^%s", igName),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error fetching instance list: %v", err)
+	}
+
+	detached := make(map[string]bool)
+	for _, server := range allInstances {
+		if server.Metadata["k8s"] == clusterName && server.Metadata[openstack.TagKopsDetached] == "true" {
+			detached[server.ID] = true
+		}
+	}
+	return detached, nil
+}
+
 func (s *ServerGroup) Run(context *fi.CloudupContext) error {
 	return fi.CloudupDefaultDeltaRunMethod(s, context)
 }
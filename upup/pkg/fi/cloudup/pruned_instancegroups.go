@@ -0,0 +1,155 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudup
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"k8s.io/klog/v2"
+	"k8s.io/kops/pkg/acls"
+	"k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/pkg/apis/kops/registry"
+	"k8s.io/kops/pkg/kopscodecs"
+	"k8s.io/kops/upup/pkg/fi"
+	"k8s.io/kops/util/pkg/vfs"
+)
+
+// RecordPrunedInstanceGroup records an instance group that is being deleted from the state store
+// without its cloud resources, so that the next update of the cluster deletes them.
+func RecordPrunedInstanceGroup(ctx context.Context, cluster *kops.Cluster, ig *kops.InstanceGroup) error {
+	dir, err := prunedInstanceGroupsPath(cluster)
+	if err != nil {
+		return err
+	}
+
+	b, err := kopscodecs.ToVersionedYaml(ig)
+	if err != nil {
+		return fmt.Errorf("error serializing InstanceGroup %q: %w", ig.ObjectMeta.Name, err)
+	}
+
+	p := dir.Join(ig.ObjectMeta.Name)
+	acl, err := acls.GetACL(ctx, p, cluster)
+	if err != nil {
+		return err
+	}
+	if err := p.WriteFile(ctx, bytes.NewReader(b), acl); err != nil {
+		return fmt.Errorf("error recording pruned InstanceGroup %q: %w", ig.ObjectMeta.Name, err)
+	}
+	return nil
+}
+
+// ReadPrunedInstanceGroups returns the instance groups that were pruned from the state store
+// and whose cloud resources have not been deleted yet, sorted by name.
+func ReadPrunedInstanceGroups(ctx context.Context, cluster *kops.Cluster) ([]*kops.InstanceGroup, error) {
+	dir, err := prunedInstanceGroupsPath(cluster)
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := dir.ReadDir()
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error listing pruned InstanceGroups: %w", err)
+	}
+
+	var instanceGroups []*kops.InstanceGroup
+	for _, f := range files {
+		b, err := f.ReadFile(ctx)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, fmt.Errorf("error reading %s: %w", f, err)
+		}
+		o, _, err := kopscodecs.Decode(b, nil)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing %s: %w", f, err)
+		}
+		ig, ok := o.(*kops.InstanceGroup)
+		if !ok {
+			return nil, fmt.Errorf("%s does not contain an InstanceGroup", f)
+		}
+		instanceGroups = append(instanceGroups, ig)
+	}
+	sort.Slice(instanceGroups, func(i, j int) bool {
+		return instanceGroups[i].ObjectMeta.Name < instanceGroups[j].ObjectMeta.Name
+	})
+	return instanceGroups, nil
+}
+
+// deletePrunedInstanceGroups deletes the cloud resources of the pruned instance groups, terminating their
+// instances without draining them, as kops delete instancegroup does. An instance group that was created
+// again is left to the model. With a nil cloud, the deletions are only written to out.
+func deletePrunedInstanceGroups(ctx context.Context, cluster *kops.Cluster, cloud fi.Cloud, instanceGroups []*kops.InstanceGroup, out io.Writer) error {
+	pruned, err := ReadPrunedInstanceGroups(ctx, cluster)
+	if err != nil {
+		return err
+	}
+
+	existing := make(map[string]bool)
+	for _, ig := range instanceGroups {
+		existing[ig.ObjectMeta.Name] = true
+	}
+
+	dir, err := prunedInstanceGroupsPath(cluster)
+	if err != nil {
+		return err
+	}
+
+	for _, ig := range pruned {
+		name := ig.ObjectMeta.Name
+		if !existing[name] {
+			if cloud == nil {
+				fmt.Fprintf(out, "Will delete the cloud resources of InstanceGroup %q, which was pruned from the state store\n", name)
+				continue
+			}
+
+			groups, err := cloud.GetCloudGroups(cluster, []*kops.InstanceGroup{ig}, false, nil)
+			if err != nil {
+				return fmt.Errorf("error finding the cloud resources of pruned InstanceGroup %q: %w", name, err)
+			}
+			for _, g := range groups {
+				klog.Infof("Deleting the cloud resources of pruned InstanceGroup %q", name)
+				if err := cloud.DeleteGroup(g); err != nil {
+					return fmt.Errorf("error deleting the cloud resources of pruned InstanceGroup %q: %w", name, err)
+				}
+			}
+		} else if cloud == nil {
+			continue
+		}
+
+		if err := dir.Join(name).Remove(); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("error removing the record of pruned InstanceGroup %q: %w", name, err)
+		}
+	}
+	return nil
+}
+
+func prunedInstanceGroupsPath(cluster *kops.Cluster) (vfs.Path, error) {
+	configBase, err := registry.ConfigBase(cluster)
+	if err != nil {
+		return nil, err
+	}
+	return configBase.Join(registry.PathPrunedInstanceGroups), nil
+}
@@ -0,0 +1,111 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudup
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/kops/cloudmock/aws/mockautoscaling"
+	"k8s.io/kops/cloudmock/aws/mockec2"
+	"k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/pkg/testutils/testcontext"
+	"k8s.io/kops/upup/pkg/fi/cloudup/awsup"
+	"k8s.io/kops/util/pkg/vfs"
+)
+
+func TestDeletePrunedInstanceGroups(t *testing.T) {
+	ctx := testcontext.ForTest(t)
+	vfs.Context.ResetMemfsContext(true)
+
+	cluster := &kops.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "minimal.example.com"}}
+	cluster.Spec.ConfigBase = "memfs://tests/minimal.example.com"
+	cluster.Spec.CloudProvider.AWS = &kops.AWSSpec{}
+
+	cloud := awsup.BuildMockAWSCloud("us-test-1", "a")
+	cloud.MockAutoscaling = &mockautoscaling.MockAutoscaling{}
+	cloud.MockEC2 = &mockec2.MockEC2{}
+
+	instanceGroups := make(map[string]*kops.InstanceGroup)
+	for _, name := range []string{"nodes-a", "nodes-b", "nodes-c"} {
+		ig := &kops.InstanceGroup{ObjectMeta: metav1.ObjectMeta{Name: name}}
+		ig.Spec.Role = kops.InstanceGroupRoleNode
+		instanceGroups[name] = ig
+
+		asgName := name + "." + cluster.ObjectMeta.Name
+		lt, err := cloud.EC2().CreateLaunchTemplate(&ec2.CreateLaunchTemplateInput{
+			LaunchTemplateName: aws.String(asgName),
+			LaunchTemplateData: &ec2.RequestLaunchTemplateData{},
+		})
+		if err != nil {
+			t.Fatalf("error creating launch template for %q: %v", name, err)
+		}
+		if _, err := cloud.Autoscaling().CreateAutoScalingGroup(&autoscaling.CreateAutoScalingGroupInput{
+			AutoScalingGroupName: aws.String(asgName),
+			LaunchTemplate: &autoscaling.LaunchTemplateSpecification{
+				LaunchTemplateId:   lt.LaunchTemplate.LaunchTemplateId,
+				LaunchTemplateName: aws.String(asgName),
+				Version:            aws.String("1"),
+			},
+			MinSize: aws.Int64(1),
+			MaxSize: aws.Int64(1),
+			Tags: []*autoscaling.Tag{{
+				Key:          aws.String(awsup.TagClusterName),
+				Value:        aws.String(cluster.ObjectMeta.Name),
+				ResourceId:   aws.String(asgName),
+				ResourceType: aws.String("auto-scaling-group"),
+			}},
+		}); err != nil {
+			t.Fatalf("error creating autoscaling group for %q: %v", name, err)
+		}
+	}
+
+	// nodes-b was pruned, and nodes-c was pruned and then created again
+	for _, name := range []string{"nodes-b", "nodes-c"} {
+		assert.NoError(t, RecordPrunedInstanceGroup(ctx, cluster, instanceGroups[name]), "RecordPrunedInstanceGroup()")
+	}
+	remaining := []*kops.InstanceGroup{instanceGroups["nodes-a"], instanceGroups["nodes-c"]}
+
+	var out bytes.Buffer
+	assert.NoError(t, deletePrunedInstanceGroups(ctx, cluster, nil, remaining, &out), "deletePrunedInstanceGroups() dry run")
+	assert.Equal(t, "Will delete the cloud resources of InstanceGroup \"nodes-b\", which was pruned from the state store\n", out.String())
+
+	pruned, err := ReadPrunedInstanceGroups(ctx, cluster)
+	assert.NoError(t, err, "ReadPrunedInstanceGroups()")
+	assert.Len(t, pruned, 2, "a dry run keeps the records")
+
+	assert.NoError(t, deletePrunedInstanceGroups(ctx, cluster, cloud, remaining, nil), "deletePrunedInstanceGroups()")
+
+	groups := cloud.MockAutoscaling.(*mockautoscaling.MockAutoscaling).Groups
+	for _, name := range []string{"nodes-a", "nodes-b", "nodes-c"} {
+		asg := groups[name+"."+cluster.ObjectMeta.Name]
+		if name == "nodes-b" {
+			assert.Nil(t, asg, "the autoscaling group of the pruned InstanceGroup is deleted")
+		} else {
+			assert.NotNil(t, asg, "the autoscaling group of InstanceGroup %q is kept", name)
+		}
+	}
+
+	pruned, err = ReadPrunedInstanceGroups(ctx, cluster)
+	assert.NoError(t, err, "ReadPrunedInstanceGroups()")
+	assert.Empty(t, pruned, "the records are removed once handled")
+}
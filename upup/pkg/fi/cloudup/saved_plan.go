@@ -0,0 +1,219 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudup
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/pkg/kopscodecs"
+	"k8s.io/kops/upup/pkg/fi"
+)
+
+// SavedPlanVersion identifies the format of a saved plan file.
+const SavedPlanVersion = "kops.k8s.io/plan/v1"
+
+// SavedPlan is the result of a dry run, saved so that exactly those changes can be applied later.
+type SavedPlan struct {
+	// Version identifies the format of the file.
+	Version string `json:"version"`
+	// ClusterName is the name of the cluster the plan was computed for.
+	ClusterName string `json:"clusterName"`
+	// StateStore is the state store the cluster configuration was read from.
+	StateStore string `json:"stateStore"`
+	// Fingerprint is a hash of the cluster and instance group specs, the keysets and secrets, and the state store.
+	Fingerprint string `json:"fingerprint"`
+	// Plan holds the changes that would be made to the cloud.
+	Plan *fi.Plan `json:"plan"`
+}
+
+// BuildSavedPlan builds a SavedPlan from the changes recorded by a dry run.
+// The fingerprint should be computed by ComputePlanFingerprint before the dry run.
+func BuildSavedPlan(target *fi.CloudupDryRunTarget, taskMap map[string]fi.CloudupTask, clusterName string, stateStore string, fingerprint string) (*SavedPlan, error) {
+	plan, err := target.Plan(taskMap)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SavedPlan{
+		Version:     SavedPlanVersion,
+		ClusterName: clusterName,
+		StateStore:  stateStore,
+		Fingerprint: fingerprint,
+		Plan:        plan,
+	}, nil
+}
+
+// ComputePlanFingerprint returns a hash over the cluster spec, the instance group specs, the versions of the keysets
+// and secrets, and the state store location.
+// It must be called with the objects as read from the state store, before they are populated by ApplyClusterCmd.
+func ComputePlanFingerprint(cluster *kops.Cluster, instanceGroups []*kops.InstanceGroup, keyStore fi.CAStore, secretStore fi.SecretStore, stateStore string) (string, error) {
+	hasher := sha256.New()
+
+	fmt.Fprintf(hasher, "stateStore: %s\n", stateStore)
+
+	b, err := kopscodecs.ToVersionedYaml(cluster)
+	if err != nil {
+		return "", fmt.Errorf("error serializing cluster: %w", err)
+	}
+	hasher.Write(b)
+
+	sorted := append([]*kops.InstanceGroup{}, instanceGroups...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].ObjectMeta.Name < sorted[j].ObjectMeta.Name
+	})
+	for _, ig := range sorted {
+		b, err := kopscodecs.ToVersionedYaml(ig)
+		if err != nil {
+			return "", fmt.Errorf("error serializing instance group %q: %w", ig.ObjectMeta.Name, err)
+		}
+		fmt.Fprintf(hasher, "---\n")
+		hasher.Write(b)
+	}
+
+	// The tasks are built from the keypairs and secrets, so a plan must not be applied after they have been rotated
+	keysets, err := keyStore.ListKeysets()
+	if err != nil {
+		return "", fmt.Errorf("error listing keysets: %w", err)
+	}
+	var keysetNames []string
+	for name := range keysets {
+		keysetNames = append(keysetNames, name)
+	}
+	sort.Strings(keysetNames)
+	for _, name := range keysetNames {
+		keyset := keysets[name]
+		var ids []string
+		for id := range keyset.Items {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		fmt.Fprintf(hasher, "---\nkeyset: %s\n", name)
+		if keyset.Primary != nil {
+			fmt.Fprintf(hasher, "primary: %s\n", keyset.Primary.Id)
+		}
+		for _, id := range ids {
+			fmt.Fprintf(hasher, "item: %s", id)
+			if distrusted := keyset.Items[id].DistrustTimestamp; distrusted != nil {
+				fmt.Fprintf(hasher, " distrusted: %s", distrusted.UTC().Format(time.RFC3339))
+			}
+			fmt.Fprintf(hasher, "\n")
+		}
+	}
+
+	secretNames, err := secretStore.ListSecrets()
+	if err != nil {
+		return "", fmt.Errorf("error listing secrets: %w", err)
+	}
+	sort.Strings(secretNames)
+	for _, name := range secretNames {
+		secret, err := secretStore.FindSecret(name)
+		if err != nil {
+			return "", fmt.Errorf("error reading secret %q: %w", name, err)
+		}
+		if secret == nil {
+			continue
+		}
+		// Secrets have no version of their own, so they are identified by a hash of their data
+		fmt.Fprintf(hasher, "---\nsecret: %s %x\n", name, sha256.Sum256(secret.Data))
+	}
+
+	return "sha256:" + hex.EncodeToString(hasher.Sum(nil)), nil
+}
+
+// ReadSavedPlan reads a plan previously written by WriteSavedPlan.
+func ReadSavedPlan(path string) (*SavedPlan, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading plan file %q: %w", path, err)
+	}
+
+	plan := &SavedPlan{}
+	if err := json.Unmarshal(b, plan); err != nil {
+		return nil, fmt.Errorf("error parsing plan file %q: %w", path, err)
+	}
+	if plan.Version != SavedPlanVersion {
+		return nil, fmt.Errorf("plan file %q has unsupported version %q (expected %q)", path, plan.Version, SavedPlanVersion)
+	}
+	if plan.Plan == nil {
+		return nil, fmt.Errorf("plan file %q does not contain a plan", path)
+	}
+	return plan, nil
+}
+
+// WriteSavedPlan writes the plan to a local file.
+func WriteSavedPlan(path string, plan *SavedPlan) error {
+	b, err := json.MarshalIndent(plan, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error serializing plan: %w", err)
+	}
+	if err := os.WriteFile(path, b, 0o644); err != nil {
+		return fmt.Errorf("error writing plan file %q: %w", path, err)
+	}
+	return nil
+}
+
+// Verify returns an error describing the differences if the current plan does not match the saved plan.
+func (p *SavedPlan) Verify(current *SavedPlan) error {
+	if p.ClusterName != current.ClusterName {
+		return fmt.Errorf("plan was created for cluster %q, not %q", p.ClusterName, current.ClusterName)
+	}
+	if p.StateStore != current.StateStore {
+		return fmt.Errorf("plan was created with state store %q, not %q", p.StateStore, current.StateStore)
+	}
+	if p.Fingerprint != current.Fingerprint {
+		return fmt.Errorf("cluster, instance group, keyset or secret configuration has changed since the plan was created")
+	}
+
+	saved := make(map[string]*fi.PlannedChange)
+	for _, c := range p.Plan.Changes {
+		saved[string(c.Action)+" "+c.Key] = c
+	}
+	actual := make(map[string]*fi.PlannedChange)
+	for _, c := range current.Plan.Changes {
+		actual[string(c.Action)+" "+c.Key] = c
+	}
+
+	var problems []string
+	for k, c := range actual {
+		s := saved[k]
+		if s == nil {
+			problems = append(problems, fmt.Sprintf("  %s: not in saved plan", k))
+		} else if !reflect.DeepEqual(s.Fields, c.Fields) {
+			problems = append(problems, fmt.Sprintf("  %s: fields differ from saved plan", k))
+		}
+	}
+	for k := range saved {
+		if actual[k] == nil {
+			problems = append(problems, fmt.Sprintf("  %s: no longer needed", k))
+		}
+	}
+
+	if len(problems) != 0 {
+		sort.Strings(problems)
+		return fmt.Errorf("cloud state has changed since the plan was created:\n%s", strings.Join(problems, "\n"))
+	}
+	return nil
+}
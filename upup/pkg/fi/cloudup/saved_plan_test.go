@@ -0,0 +1,262 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudup
+
+import (
+	"crypto/x509/pkix"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/pkg/pki"
+	"k8s.io/kops/pkg/testutils/testcontext"
+	"k8s.io/kops/upup/pkg/fi"
+	"k8s.io/kops/upup/pkg/fi/secrets"
+	"k8s.io/kops/util/pkg/vfs"
+)
+
+func TestComputePlanFingerprint(t *testing.T) {
+	ctx := testcontext.ForTest(t)
+
+	cluster := &kops.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "test.example.com"}}
+	cluster.Spec.KubernetesVersion = "1.26.0"
+	basePath := vfs.NewMemFSPath(vfs.NewMemFSContext(), "state")
+	keyStore := fi.NewVFSCAStore(cluster, basePath.Join("pki"))
+	secretStore := secrets.NewVFSSecretStore(cluster, basePath.Join("secrets"))
+	igA := &kops.InstanceGroup{ObjectMeta: metav1.ObjectMeta{Name: "a"}}
+	igB := &kops.InstanceGroup{ObjectMeta: metav1.ObjectMeta{Name: "b"}}
+
+	f1, err := ComputePlanFingerprint(cluster, []*kops.InstanceGroup{igA, igB}, keyStore, secretStore, "memfs://state")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	f2, err := ComputePlanFingerprint(cluster, []*kops.InstanceGroup{igB, igA}, keyStore, secretStore, "memfs://state")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if f1 != f2 {
+		t.Errorf("expected fingerprint to be independent of instance group order")
+	}
+
+	f3, err := ComputePlanFingerprint(cluster, []*kops.InstanceGroup{igA, igB}, keyStore, secretStore, "memfs://other")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if f1 == f3 {
+		t.Errorf("expected fingerprint to depend on state store")
+	}
+
+	igB.Spec.MinSize = fi.PtrTo(int32(3))
+	f4, err := ComputePlanFingerprint(cluster, []*kops.InstanceGroup{igA, igB}, keyStore, secretStore, "memfs://state")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if f1 == f4 {
+		t.Errorf("expected fingerprint to depend on instance group spec")
+	}
+
+	issueCA := func() (*pki.Certificate, *pki.PrivateKey) {
+		cert, key, _, err := pki.IssueCert(ctx, &pki.IssueCertRequest{
+			Type:         "ca",
+			Subject:      pkix.Name{CommonName: "kubernetes-ca"},
+			KeyAlgorithm: pki.KeyAlgorithmECDSAP256,
+		}, nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return cert, key
+	}
+	keyset, err := fi.NewKeyset(issueCA())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := keyStore.StoreKeyset(ctx, "kubernetes-ca", keyset); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	f5, err := ComputePlanFingerprint(cluster, []*kops.InstanceGroup{igA, igB}, keyStore, secretStore, "memfs://state")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if f4 == f5 {
+		t.Errorf("expected fingerprint to depend on keysets")
+	}
+
+	previous := keyset.Primary
+	cert, key := issueCA()
+	if _, err := keyset.AddItem(cert, key, true); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	distrusted := time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)
+	previous.DistrustTimestamp = &distrusted
+	if err := keyStore.StoreKeyset(ctx, "kubernetes-ca", keyset); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	f6, err := ComputePlanFingerprint(cluster, []*kops.InstanceGroup{igA, igB}, keyStore, secretStore, "memfs://state")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if f5 == f6 {
+		t.Errorf("expected fingerprint to depend on the keypairs of keysets")
+	}
+
+	if _, _, err := secretStore.GetOrCreateSecret(ctx, "admin", &fi.Secret{Data: []byte("first")}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	f7, err := ComputePlanFingerprint(cluster, []*kops.InstanceGroup{igA, igB}, keyStore, secretStore, "memfs://state")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if f6 == f7 {
+		t.Errorf("expected fingerprint to depend on secrets")
+	}
+
+	if _, err := secretStore.ReplaceSecret("admin", &fi.Secret{Data: []byte("second")}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	f8, err := ComputePlanFingerprint(cluster, []*kops.InstanceGroup{igA, igB}, keyStore, secretStore, "memfs://state")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if f7 == f8 {
+		t.Errorf("expected fingerprint to depend on secret data")
+	}
+}
+
+func TestSavedPlanVerify(t *testing.T) {
+	newPlan := func(changes ...*fi.PlannedChange) *SavedPlan {
+		return &SavedPlan{
+			Version:     SavedPlanVersion,
+			ClusterName: "test.example.com",
+			StateStore:  "memfs://state",
+			Fingerprint: "sha256:abc",
+			Plan:        &fi.Plan{Changes: changes},
+		}
+	}
+
+	create := &fi.PlannedChange{
+		Key:    "SecurityGroup/nodes",
+		Type:   "SecurityGroup",
+		Name:   "nodes",
+		Action: fi.PlanActionCreate,
+		Fields: []*fi.PlannedFieldChange{{Field: "Description", After: "nodes"}},
+	}
+	update := &fi.PlannedChange{
+		Key:    "AutoscalingGroup/nodes",
+		Type:   "AutoscalingGroup",
+		Name:   "nodes",
+		Action: fi.PlanActionUpdate,
+		Fields: []*fi.PlannedFieldChange{{Field: "MinSize", Before: "1", After: "2"}},
+	}
+	updateDiffers := &fi.PlannedChange{
+		Key:    "AutoscalingGroup/nodes",
+		Type:   "AutoscalingGroup",
+		Name:   "nodes",
+		Action: fi.PlanActionUpdate,
+		Fields: []*fi.PlannedFieldChange{{Field: "MinSize", Before: "3", After: "2"}},
+	}
+
+	grid := []struct {
+		Name          string
+		Current       *SavedPlan
+		ExpectedError string
+	}{
+		{
+			Name:    "identical",
+			Current: newPlan(create, update),
+		},
+		{
+			Name:          "missing change",
+			Current:       newPlan(create),
+			ExpectedError: "update AutoscalingGroup/nodes: no longer needed",
+		},
+		{
+			Name:          "extra change",
+			Current:       newPlan(create, update, &fi.PlannedChange{Key: "Instance/foo", Action: fi.PlanActionDelete}),
+			ExpectedError: "delete Instance/foo: not in saved plan",
+		},
+		{
+			Name:          "different values",
+			Current:       newPlan(create, updateDiffers),
+			ExpectedError: "update AutoscalingGroup/nodes: fields differ from saved plan",
+		},
+		{
+			Name: "different fingerprint",
+			Current: func() *SavedPlan {
+				p := newPlan(create, update)
+				p.Fingerprint = "sha256:def"
+				return p
+			}(),
+			ExpectedError: "configuration has changed",
+		},
+	}
+
+	for _, g := range grid {
+		t.Run(g.Name, func(t *testing.T) {
+			err := newPlan(create, update).Verify(g.Current)
+			if g.ExpectedError == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), g.ExpectedError) {
+				t.Errorf("expected error containing %q, got %v", g.ExpectedError, err)
+			}
+		})
+	}
+}
+
+func TestSavedPlanRoundTrip(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "plan.json")
+
+	plan := &SavedPlan{
+		Version:     SavedPlanVersion,
+		ClusterName: "test.example.com",
+		StateStore:  "memfs://state",
+		Fingerprint: "sha256:abc",
+		Plan: &fi.Plan{Changes: []*fi.PlannedChange{
+			{Key: "Instance/foo", Type: "Instance", Name: "foo", Action: fi.PlanActionDelete},
+		}},
+	}
+	if err := WriteSavedPlan(p, plan); err != nil {
+		t.Fatalf("error writing plan: %v", err)
+	}
+
+	read, err := ReadSavedPlan(p)
+	if err != nil {
+		t.Fatalf("error reading plan: %v", err)
+	}
+	if err := plan.Verify(read); err != nil {
+		t.Errorf("plan did not survive round trip: %v", err)
+	}
+}
+
+func TestReadSavedPlanWithoutPlan(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "plan.json")
+	if err := os.WriteFile(p, []byte(`{"version": "`+SavedPlanVersion+`", "clusterName": "test.example.com"}`), 0o644); err != nil {
+		t.Fatalf("error writing plan: %v", err)
+	}
+
+	if _, err := ReadSavedPlan(p); err == nil || !strings.Contains(err.Error(), "does not contain a plan") {
+		t.Errorf("expected an error for a plan file without a plan, got %v", err)
+	}
+}
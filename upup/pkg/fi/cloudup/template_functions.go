@@ -753,6 +753,16 @@ func (tf *TemplateFunctions) KopsControllerConfig() (string, error) {
 		}
 	}
 
+	if featureflag.InClusterRollingUpdate.Enabled() {
+		// kops-controller is only granted the cloud permissions to replace instances on AWS
+		if cluster.Spec.GetCloudProvider() != kops.CloudProviderAWS {
+			return "", fmt.Errorf("the InClusterRollingUpdate feature flag is not supported on %s", cluster.Spec.GetCloudProvider())
+		}
+		config.RollingUpdate = &kopscontrollerconfig.RollingUpdateOptions{
+			Enabled: true,
+		}
+	}
+
 	// To avoid indentation problems, we marshal as json.  json is a subset of yaml
 	b, err := json.Marshal(config)
 	if err != nil {
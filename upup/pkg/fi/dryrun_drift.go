@@ -0,0 +1,177 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fi
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"reflect"
+	"sort"
+)
+
+// DriftClass classifies how a resource has drifted from the model.
+type DriftClass string
+
+const (
+	// DriftClassConsoleEdit is a resource that exists but differs from the model in fields that have not changed
+	// in the model since it was applied, as when it is edited in the cloud console.
+	DriftClassConsoleEdit DriftClass = "ConsoleEdit"
+	// DriftClassMissingResource is a resource that does not exist, although it was applied to the cloud before.
+	DriftClassMissingResource DriftClass = "MissingResource"
+	// DriftClassExtraResource is a resource owned by the cluster that is not in the model.
+	DriftClassExtraResource DriftClass = "ExtraResource"
+)
+
+// Drift is a machine-readable description of the resources whose actual state differs from the model.
+type Drift struct {
+	// Resources is the list of drifted resources, ordered by class and key.
+	Resources []*DriftedResource `json:"resources"`
+}
+
+// DriftedResource describes a single resource that has drifted.
+type DriftedResource struct {
+	// Key identifies the resource, in the form type/name.
+	Key string `json:"key"`
+	// Type is the type of the task for the resource, e.g. SecurityGroup.
+	Type string `json:"type"`
+	// Name is the name of the task for the resource.
+	Name string `json:"name"`
+	// Class is how the resource has drifted.
+	Class DriftClass `json:"class"`
+	// Fields lists the fields whose actual value differs from the value that was applied. It is set if and only if
+	// Class is ConsoleEdit.
+	Fields []*PlannedFieldChange `json:"fields,omitempty"`
+}
+
+// AppliedResources records the tasks that were applied to the cloud, by task key, with a digest of the value
+// of each of their fields as it was applied.
+type AppliedResources map[string]map[string]string
+
+// AppliedFieldDigests returns a digest of the value of each exported field of a task, for AppliedResources.
+func AppliedFieldDigests[T SubContext](task Task[T]) map[string]string {
+	digests := make(map[string]string)
+	v := reflect.ValueOf(task)
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return digests
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return digests
+	}
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		if field.PkgPath != "" {
+			// Not exported
+			continue
+		}
+		digests[field.Name] = fieldDigest(planValue(field.Name, v.Field(i)))
+	}
+	return digests
+}
+
+// fieldDigest returns the digest of a field value, as returned by planValue.
+func fieldDigest(value string) string {
+	hash := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(hash[:])
+}
+
+// Drift returns the resources recorded by the dry run whose actual state differs from what was applied.
+// Resources that would be created are only drift if their task key is in applied, as otherwise they
+// are new to the model rather than missing from the cloud. Likewise, only the fields whose value in the
+// model is the value that was applied are drift, as the others have changes that were not applied yet;
+// resources that were not applied are skipped. Tasks for which include returns false are skipped.
+func (t *DryRunTarget[T]) Drift(taskMap map[string]Task[T], applied AppliedResources, include func(task Task[T]) bool) (*Drift, error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	drift := &Drift{
+		Resources: []*DriftedResource{},
+	}
+
+	taskKeys := make(map[Task[T]]string, len(taskMap))
+	for k, task := range taskMap {
+		taskKeys[task] = k
+	}
+
+	changes := append([]*render[T]{}, t.changes...)
+	sort.Sort(ByTaskKey[T](changes))
+
+	for _, r := range changes {
+		if include != nil && !include(r.e) {
+			continue
+		}
+
+		appliedFields, ok := applied[taskKeys[r.e]]
+		if !ok {
+			continue
+		}
+		if r.aIsNil {
+			drift.Resources = append(drift.Resources, newDriftedResource(getTaskName(r.changes), idForTask(taskMap, r.e), DriftClassMissingResource))
+			continue
+		}
+
+		changeList, err := buildChangeList(r.a, r.e, r.changes)
+		if err != nil {
+			return nil, err
+		}
+		resource := newDriftedResource(getTaskName(r.changes), idForTask(taskMap, r.e), DriftClassConsoleEdit)
+		for _, change := range changeList {
+			if appliedFields[change.FieldName] != fieldDigest(change.After) {
+				// The field changed in the model since it was applied
+				continue
+			}
+			resource.Fields = append(resource.Fields, &PlannedFieldChange{
+				Field:  change.FieldName,
+				Before: change.Before,
+				After:  change.After,
+			})
+		}
+		if len(resource.Fields) != 0 {
+			drift.Resources = append(drift.Resources, resource)
+		}
+	}
+
+	deletions := append([]Deletion[T]{}, t.deletions...)
+	sort.Sort(DeletionByTaskName[T](deletions))
+	for _, d := range deletions {
+		drift.Resources = append(drift.Resources, newDriftedResource(d.TaskName(), d.Item(), DriftClassExtraResource))
+	}
+
+	sort.SliceStable(drift.Resources, func(i, j int) bool {
+		return driftClassOrder[drift.Resources[i].Class] < driftClassOrder[drift.Resources[j].Class]
+	})
+
+	return drift, nil
+}
+
+// driftClassOrder is the order of the classes in a Drift.
+var driftClassOrder = map[DriftClass]int{
+	DriftClassConsoleEdit:     0,
+	DriftClassMissingResource: 1,
+	DriftClassExtraResource:   2,
+}
+
+func newDriftedResource(taskType string, name string, class DriftClass) *DriftedResource {
+	return &DriftedResource{
+		Key:   taskType + "/" + name,
+		Type:  taskType,
+		Name:  name,
+		Class: class,
+	}
+}
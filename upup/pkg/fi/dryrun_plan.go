@@ -0,0 +1,193 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fi
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"reflect"
+	"sort"
+	"strings"
+
+	"k8s.io/kops/pkg/pki"
+	"k8s.io/kops/util/pkg/reflectutils"
+)
+
+// PlanAction is the action that will be taken for a task in a Plan.
+type PlanAction string
+
+const (
+	PlanActionCreate PlanAction = "create"
+	PlanActionUpdate PlanAction = "update"
+	PlanActionDelete PlanAction = "delete"
+)
+
+// RedactedValue replaces the value of sensitive fields in a Plan.
+const RedactedValue = "<redacted>"
+
+// KnownAfterApplyValue replaces references in a Plan to tasks whose ID is only known once they have been created.
+const KnownAfterApplyValue = "(known after apply)"
+
+// Plan is a machine-readable description of the changes recorded by a DryRunTarget.
+// The schema is intended to be stable, so that it can be consumed by automation.
+type Plan struct {
+	// Changes is the list of tasks that would be created, updated or deleted, ordered by action and key.
+	Changes []*PlannedChange `json:"changes"`
+}
+
+// PlannedChange describes the change to a single task.
+type PlannedChange struct {
+	// Key identifies the task, in the form type/name.
+	Key string `json:"key"`
+	// Type is the type of the task, e.g. SecurityGroup.
+	Type string `json:"type"`
+	// Name is the name of the task.
+	Name string `json:"name"`
+	// Action is the action that would be taken.
+	Action PlanAction `json:"action"`
+	// Fields lists the field-level changes.  It is empty for deletions.
+	Fields []*PlannedFieldChange `json:"fields,omitempty"`
+}
+
+// PlannedFieldChange describes the change to a single field of a task.
+// Sensitive values are redacted, and resource contents are replaced by their sha256 hash.
+type PlannedFieldChange struct {
+	Field  string `json:"field"`
+	Before string `json:"before,omitempty"`
+	After  string `json:"after,omitempty"`
+}
+
+// Plan returns a structured description of the changes that would be made.
+func (t *DryRunTarget[T]) Plan(taskMap map[string]Task[T]) (*Plan, error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	plan := &Plan{
+		Changes: []*PlannedChange{},
+	}
+
+	var creates []*render[T]
+	var updates []*render[T]
+	for _, r := range t.changes {
+		if r.aIsNil {
+			creates = append(creates, r)
+		} else {
+			updates = append(updates, r)
+		}
+	}
+	sort.Sort(ByTaskKey[T](creates))
+	sort.Sort(ByTaskKey[T](updates))
+
+	for _, r := range creates {
+		pc := newPlannedChange(getTaskName(r.changes), idForTask(taskMap, r.e), PlanActionCreate)
+		for _, change := range buildCreateChangeList(r.changes) {
+			pc.Fields = append(pc.Fields, &PlannedFieldChange{
+				Field: change.FieldName,
+				After: change.After,
+			})
+		}
+		plan.Changes = append(plan.Changes, pc)
+	}
+
+	for _, r := range updates {
+		changeList, err := buildChangeList(r.a, r.e, r.changes)
+		if err != nil {
+			return nil, err
+		}
+		pc := newPlannedChange(getTaskName(r.changes), idForTask(taskMap, r.e), PlanActionUpdate)
+		for _, change := range changeList {
+			pc.Fields = append(pc.Fields, &PlannedFieldChange{
+				Field:  change.FieldName,
+				Before: change.Before,
+				After:  change.After,
+			})
+		}
+		plan.Changes = append(plan.Changes, pc)
+	}
+
+	deletions := append([]Deletion[T]{}, t.deletions...)
+	sort.Sort(DeletionByTaskName[T](deletions))
+	for _, d := range deletions {
+		plan.Changes = append(plan.Changes, newPlannedChange(d.TaskName(), d.Item(), PlanActionDelete))
+	}
+
+	return plan, nil
+}
+
+func newPlannedChange(taskType string, name string, action PlanAction) *PlannedChange {
+	return &PlannedChange{
+		Key:    taskType + "/" + name,
+		Type:   taskType,
+		Name:   name,
+		Action: action,
+	}
+}
+
+// sensitiveFieldNames are substrings of (lower-cased) field names whose values are never included in a Plan.
+var sensitiveFieldNames = []string{"password", "secret", "token", "privatekey", "credential"}
+
+// planValue returns the value of a task field as it should appear in a Plan.
+func planValue(fieldName string, v reflect.Value) string {
+	if !v.IsValid() {
+		return ""
+	}
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Slice, reflect.Map:
+		if v.IsNil() {
+			return ""
+		}
+	}
+
+	if v.CanInterface() {
+		switch v.Interface().(type) {
+		case *Secret, *pki.PrivateKey, *Keyset:
+			return RedactedValue
+		case Resource:
+			s, ok := tryResourceAsString(v)
+			if !ok {
+				return ""
+			}
+			hash := sha256.Sum256([]byte(s))
+			return "sha256:" + hex.EncodeToString(hash[:])
+		}
+	}
+
+	lowerName := strings.ToLower(fieldName)
+	for _, sensitive := range sensitiveFieldNames {
+		if strings.Contains(lowerName, sensitive) {
+			return RedactedValue
+		}
+	}
+
+	if v.Kind() == reflect.Slice {
+		var items []string
+		for i := 0; i < v.Len(); i++ {
+			items = append(items, planValue(fieldName, v.Index(i)))
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	}
+
+	s := reflectutils.ValueAsString(v)
+	switch s {
+	case "<nil>":
+		return ""
+	case "id:<nil>":
+		// An unnamed reference to a task that has not been created yet
+		return KnownAfterApplyValue
+	}
+	return s
+}
@@ -166,56 +166,11 @@ func (t *DryRunTarget[T]) PrintReport(taskMap map[string]Task[T], out io.Writer)
 				taskName := getTaskName(r.changes)
 				fmt.Fprintf(b, "  %s/%s\n", taskName, idForTask(taskMap, r.e))
 
-				changes := reflect.ValueOf(r.changes)
-				if changes.Kind() == reflect.Ptr && !changes.IsNil() {
-					changes = changes.Elem()
-				}
-
-				if changes.Kind() == reflect.Struct {
-					for i := 0; i < changes.NumField(); i++ {
-
-						field := changes.Field(i)
-
-						fieldName := changes.Type().Field(i).Name
-						if changes.Type().Field(i).PkgPath != "" {
-							// Not exported
-							continue
-						}
-
-						fieldValue := reflectutils.ValueAsString(field)
-
-						shouldPrint := true
-						if fieldName == "Name" {
-							// The field name is already printed above, no need to repeat it.
-							shouldPrint = false
-						}
-						if fieldName == "Lifecycle" {
-							// Lifecycle is a "system" field; no need to show it
-							shouldPrint = false
-						}
-						if fieldValue == "<nil>" || fieldValue == "<resource>" {
-							// Uninformative
-							shouldPrint = false
-						}
-						if fieldValue == "id:<nil>" {
-							// Uninformative, but we can often print the name instead
-							name := ""
-							if field.CanInterface() {
-								hasName, ok := field.Interface().(HasName)
-								if ok {
-									name = ValueOf(hasName.GetName())
-								}
-							}
-							if name != "" {
-								fieldValue = "name:" + name
-							} else {
-								shouldPrint = false
-							}
-						}
-						if shouldPrint {
-							fmt.Fprintf(b, "  \t%-20s\t%s\n", fieldName, fieldValue)
-						}
+				for _, change := range buildCreateChangeList(r.changes) {
+					if change.Description == "" {
+						continue
 					}
+					fmt.Fprintf(b, "  \t%-20s\t%s\n", change.FieldName, change.Description)
 				}
 
 				fmt.Fprintf(b, "\n")
@@ -290,6 +245,85 @@ func (t *DryRunTarget[T]) PrintReport(taskMap map[string]Task[T], out io.Writer)
 type change struct {
 	FieldName   string
 	Description string
+
+	// Before and After are the old and new values of the field, with secrets redacted and resources hashed
+	Before string
+	After  string
+}
+
+// buildCreateChangeList returns the fields that will be set when creating a task.
+// Fields that are not informative in the text report (such as resources) have an empty Description.
+func buildCreateChangeList[T SubContext](changes Task[T]) []change {
+	var changeList []change
+
+	valC := reflect.ValueOf(changes)
+	if valC.Kind() == reflect.Ptr && !valC.IsNil() {
+		valC = valC.Elem()
+	}
+
+	if valC.Kind() != reflect.Struct {
+		return nil
+	}
+
+	for i := 0; i < valC.NumField(); i++ {
+		field := valC.Field(i)
+
+		fieldName := valC.Type().Field(i).Name
+		if valC.Type().Field(i).PkgPath != "" {
+			// Not exported
+			continue
+		}
+
+		if fieldName == "Name" {
+			// The field name is already printed above, no need to repeat it.
+			continue
+		}
+		if fieldName == "Lifecycle" {
+			// Lifecycle is a "system" field; no need to show it
+			continue
+		}
+
+		fieldValue := reflectutils.ValueAsString(field)
+
+		shouldPrint := true
+		if fieldValue == "<nil>" {
+			// Uninformative
+			continue
+		}
+		if fieldValue == "<resource>" {
+			// Uninformative in the report, but we can still record a hash
+			shouldPrint = false
+		}
+		if fieldValue == "id:<nil>" {
+			// Uninformative, but we can often print the name instead
+			name := ""
+			if field.CanInterface() {
+				hasName, ok := field.Interface().(HasName)
+				if ok {
+					name = ValueOf(hasName.GetName())
+				}
+			}
+			if name != "" {
+				fieldValue = "name:" + name
+			} else {
+				continue
+			}
+		}
+
+		c := change{
+			FieldName: fieldName,
+			After:     planValue(fieldName, field),
+		}
+		if shouldPrint {
+			c.Description = fieldValue
+		}
+		if c.After == "" && c.Description == "" {
+			continue
+		}
+		changeList = append(changeList, c)
+	}
+
+	return changeList
 }
 
 func buildChangeList[T SubContext](a, e, changes Task[T]) ([]change, error) {
@@ -355,7 +389,13 @@ func buildChangeList[T SubContext](a, e, changes Task[T]) ([]change, error) {
 			if ignored {
 				continue
 			}
-			changeList = append(changeList, change{FieldName: valC.Type().Field(i).Name, Description: description})
+			fieldName := valC.Type().Field(i).Name
+			changeList = append(changeList, change{
+				FieldName:   fieldName,
+				Description: description,
+				Before:      planValue(fieldName, fieldValA),
+				After:       planValue(fieldName, fieldValE),
+			})
 		}
 	} else {
 		return nil, fmt.Errorf("unhandled change type: %v", valC.Type())
@@ -18,6 +18,7 @@ package fi
 
 import (
 	"bytes"
+	"io"
 	"reflect"
 	"testing"
 
@@ -90,3 +91,224 @@ func Test_DryrunTarget_PrintReport(t *testing.T) {
 	err = target.PrintReport(tasks, &out)
 	assert.NoError(t, err, "target.PrintReport()")
 }
+
+type testPlanTask struct {
+	Name       *string
+	Lifecycle  Lifecycle
+	ID         *string
+	Size       *int64
+	Password   *string
+	Contents   Resource
+	References []*testPlanReference
+}
+
+type testPlanReference struct {
+	Name *string
+	ID   *string
+}
+
+func (e *testPlanReference) GetName() *string {
+	return e.Name
+}
+
+func (e *testPlanReference) CompareWithID() *string {
+	return e.ID
+}
+
+var _ CloudupTask = &testPlanTask{}
+
+func (*testPlanTask) Run(_ *CloudupContext) error {
+	panic("not implemented")
+}
+
+func Test_DryrunTarget_Plan(t *testing.T) {
+	builder := assets.NewAssetBuilder(nil, "1.17.3", false)
+	target := newDryRunTarget[CloudupSubContext](builder, io.Discard)
+	tasks := map[string]CloudupTask{}
+
+	create := &testPlanTask{
+		Name:      PtrTo("created"),
+		Lifecycle: LifecycleSync,
+		Size:      PtrTo(int64(3)),
+		Password:  PtrTo("hunter2"),
+		Contents:  NewStringResource("hello"),
+		References: []*testPlanReference{
+			{ID: PtrTo("sg-1")},
+			{Name: PtrTo("pending")},
+			{},
+		},
+	}
+	tasks["testPlanTask/created"] = create
+	assert.NoError(t, target.Render((*testPlanTask)(nil), create, create), "target.Render()")
+
+	a := &testPlanTask{
+		Name:      PtrTo("updated"),
+		Lifecycle: LifecycleSync,
+		Size:      PtrTo(int64(1)),
+	}
+	e := &testPlanTask{
+		Name:      PtrTo("updated"),
+		Lifecycle: LifecycleSync,
+		Size:      PtrTo(int64(2)),
+	}
+	changes := &testPlanTask{}
+	_ = BuildChanges(a, e, changes)
+	tasks["testPlanTask/updated"] = e
+	assert.NoError(t, target.Render(a, e, changes), "target.Render()")
+
+	plan, err := target.Plan(tasks)
+	assert.NoError(t, err, "target.Plan()")
+
+	expected := &Plan{
+		Changes: []*PlannedChange{
+			{
+				Key:    "testPlanTask/created",
+				Type:   "testPlanTask",
+				Name:   "created",
+				Action: PlanActionCreate,
+				Fields: []*PlannedFieldChange{
+					{Field: "Size", After: "3"},
+					{Field: "Password", After: RedactedValue},
+					{Field: "Contents", After: "sha256:2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"},
+					{Field: "References", After: "[id:sg-1, name:pending, (known after apply)]"},
+				},
+			},
+			{
+				Key:    "testPlanTask/updated",
+				Type:   "testPlanTask",
+				Name:   "updated",
+				Action: PlanActionUpdate,
+				Fields: []*PlannedFieldChange{
+					{Field: "Size", Before: "1", After: "2"},
+				},
+			},
+		},
+	}
+	assert.Equal(t, expected, plan)
+}
+
+type testDriftTask struct {
+	Name      *string
+	Lifecycle Lifecycle
+	Size      *int64
+	Parent    *testDriftTask
+}
+
+var _ CloudupTask = &testDriftTask{}
+
+func (e *testDriftTask) GetName() *string {
+	return e.Name
+}
+
+func (*testDriftTask) Run(_ *CloudupContext) error {
+	panic("not implemented")
+}
+
+type testDeletion struct {
+	item string
+}
+
+func (d *testDeletion) Delete(target Target[CloudupSubContext]) error {
+	panic("not implemented")
+}
+
+func (d *testDeletion) TaskName() string {
+	return "testDriftTask"
+}
+
+func (d *testDeletion) Item() string {
+	return d.item
+}
+
+func Test_DryrunTarget_Drift(t *testing.T) {
+	builder := assets.NewAssetBuilder(nil, "1.17.3", false)
+	target := newDryRunTarget[CloudupSubContext](builder, io.Discard)
+	tasks := map[string]CloudupTask{}
+
+	existing := &testDriftTask{Name: PtrTo("existing"), Lifecycle: LifecycleSync}
+	tasks["testDriftTask/existing"] = existing
+
+	// A resource that was applied before, and then deleted
+	missing := &testDriftTask{Name: PtrTo("missing"), Lifecycle: LifecycleSync, Parent: existing}
+	tasks["testDriftTask/missing"] = missing
+	assert.NoError(t, target.Render((*testDriftTask)(nil), missing, missing), "target.Render()")
+
+	// New resources, which were never applied
+	added := &testDriftTask{Name: PtrTo("added"), Lifecycle: LifecycleSync}
+	tasks["testDriftTask/added"] = added
+	assert.NoError(t, target.Render((*testDriftTask)(nil), added, added), "target.Render()")
+	addedChild := &testDriftTask{Name: PtrTo("added-child"), Lifecycle: LifecycleSync, Parent: added}
+	tasks["testDriftTask/added-child"] = addedChild
+	assert.NoError(t, target.Render((*testDriftTask)(nil), addedChild, addedChild), "target.Render()")
+
+	// A resource that was edited
+	a := &testDriftTask{Name: PtrTo("edited"), Lifecycle: LifecycleSync, Size: PtrTo(int64(1))}
+	e := &testDriftTask{Name: PtrTo("edited"), Lifecycle: LifecycleSync, Size: PtrTo(int64(2))}
+	changes := &testDriftTask{}
+	_ = BuildChanges(a, e, changes)
+	tasks["testDriftTask/edited"] = e
+	assert.NoError(t, target.Render(a, e, changes), "target.Render()")
+
+	// A resource whose spec changed, which was not applied yet
+	changedActual := &testDriftTask{Name: PtrTo("changed"), Lifecycle: LifecycleSync, Size: PtrTo(int64(1))}
+	changed := &testDriftTask{Name: PtrTo("changed"), Lifecycle: LifecycleSync, Size: PtrTo(int64(3))}
+	changes = &testDriftTask{}
+	_ = BuildChanges(changedActual, changed, changes)
+	tasks["testDriftTask/changed"] = changed
+	assert.NoError(t, target.Render(changedActual, changed, changes), "target.Render()")
+
+	// A resource without differing fields
+	unchanged := &testDriftTask{Name: PtrTo("unchanged"), Lifecycle: LifecycleSync}
+	tasks["testDriftTask/unchanged"] = unchanged
+	assert.NoError(t, target.Render(unchanged, unchanged, &testDriftTask{}), "target.Render()")
+
+	assert.NoError(t, target.Delete(&testDeletion{item: "extra"}), "target.Delete()")
+
+	applied := AppliedResources{
+		"testDriftTask/existing":  AppliedFieldDigests[CloudupSubContext](existing),
+		"testDriftTask/missing":   AppliedFieldDigests[CloudupSubContext](missing),
+		"testDriftTask/edited":    AppliedFieldDigests[CloudupSubContext](e),
+		"testDriftTask/changed":   AppliedFieldDigests[CloudupSubContext](changedActual),
+		"testDriftTask/unchanged": AppliedFieldDigests[CloudupSubContext](unchanged),
+	}
+
+	drift, err := target.Drift(tasks, applied, nil)
+	assert.NoError(t, err, "target.Drift()")
+
+	expected := &Drift{
+		Resources: []*DriftedResource{
+			{
+				Key:   "testDriftTask/edited",
+				Type:  "testDriftTask",
+				Name:  "edited",
+				Class: DriftClassConsoleEdit,
+				Fields: []*PlannedFieldChange{
+					{Field: "Size", Before: "1", After: "2"},
+				},
+			},
+			{
+				Key:   "testDriftTask/missing",
+				Type:  "testDriftTask",
+				Name:  "missing",
+				Class: DriftClassMissingResource,
+			},
+			{
+				Key:   "testDriftTask/extra",
+				Type:  "testDriftTask",
+				Name:  "extra",
+				Class: DriftClassExtraResource,
+			},
+		},
+	}
+	assert.Equal(t, expected, drift)
+
+	drift, err = target.Drift(tasks, applied, func(task CloudupTask) bool {
+		return task != e
+	})
+	assert.NoError(t, err, "target.Drift()")
+	assert.Len(t, drift.Resources, 2, "excluded tasks are not drift")
+
+	drift, err = target.Drift(tasks, nil, nil)
+	assert.NoError(t, err, "target.Drift()")
+	assert.Len(t, drift.Resources, 1, "resources are not drift unless they were applied before")
+}
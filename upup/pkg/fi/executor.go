@@ -17,7 +17,9 @@ limitations under the License.
 package fi
 
 import (
+	"context"
 	"fmt"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -38,21 +40,62 @@ type taskState[T SubContext] struct {
 	deadline     time.Time
 	lastError    error
 	dependencies []*taskState[T]
+
+	// backoff is the delay to apply after the next failure of this task
+	backoff time.Duration
+	// nextAttempt is the earliest time at which a failed task will be retried
+	nextAttempt time.Time
 }
 
 type RunTasksOptions struct {
-	MaxTaskDuration         time.Duration
+	MaxTaskDuration time.Duration
+	// WaitAfterAllTasksFailed is the initial delay before a failed task is retried.
+	// The delay doubles on each subsequent failure of the same task, up to MaxBackoff.
 	WaitAfterAllTasksFailed time.Duration
+	// MaxBackoff caps the per-task retry delay.  If it is less than WaitAfterAllTasksFailed,
+	// failed tasks are always retried after WaitAfterAllTasksFailed.
+	MaxBackoff time.Duration
+	// MaxConcurrency is the maximum number of tasks that will be run at the same time.
+	// A value of zero (or less) means no limit.
+	MaxConcurrency int
 }
 
+// InitDefaults sets the default options, with which failed tasks are retried at a fixed interval, as nodeup does.
 func (o *RunTasksOptions) InitDefaults() {
 	o.MaxTaskDuration = 10 * time.Minute
 	o.WaitAfterAllTasksFailed = 10 * time.Second
+	o.MaxBackoff = 0
+	o.MaxConcurrency = 0
+}
+
+// InitCloudupDefaults sets the default options for tasks that call cloud APIs, whose retries back off
+// so that they do not add to throttling.
+func (o *RunTasksOptions) InitCloudupDefaults() {
+	o.InitDefaults()
+	o.MaxBackoff = 2 * time.Minute
+}
+
+// nextBackoff returns the retry delay to use after the failure of a task that last waited current.
+func (o *RunTasksOptions) nextBackoff(current time.Duration) time.Duration {
+	if current == 0 {
+		return o.WaitAfterAllTasksFailed
+	}
+	next := current * 2
+	if next > o.MaxBackoff {
+		next = o.MaxBackoff
+	}
+	if next < o.WaitAfterAllTasksFailed {
+		next = o.WaitAfterAllTasksFailed
+	}
+	return next
 }
 
 // RunTasks executes all the tasks, considering their dependencies
-// It will perform some re-execution on error, retrying as long as progress is still being made
+// It will perform some re-execution on error, retrying each failed task with exponential backoff until its deadline.
+// If the context is cancelled, no further tasks are started and an error is returned once the running tasks complete.
 func (e *executor[T]) RunTasks(taskMap map[string]Task[T]) error {
+	ctx := e.context.Context()
+
 	dependencies := FindTaskDependencies(taskMap)
 
 	for _, task := range taskMap {
@@ -84,7 +127,15 @@ func (e *executor[T]) RunTasks(taskMap map[string]Task[T]) error {
 	}
 
 	for {
+		if err := ctx.Err(); err != nil && countNotDone(taskStates) != 0 {
+			return fmt.Errorf("task execution cancelled with %d task(s) not done: %w", countNotDone(taskStates), err)
+		}
+
+		now := time.Now()
+
 		var canRun []*taskState[T]
+		var nextAttempt time.Time
+		waiting := 0
 		doneCount := 0
 		for _, ts := range taskStates {
 			if ts.done {
@@ -100,26 +151,38 @@ func (e *executor[T]) RunTasks(taskMap map[string]Task[T]) error {
 			}
 			if ready {
 				if ts.deadline.IsZero() {
-					ts.deadline = time.Now().Add(e.options.MaxTaskDuration)
-				} else if time.Now().After(ts.deadline) {
+					ts.deadline = now.Add(e.options.MaxTaskDuration)
+				} else if now.After(ts.deadline) {
 					return fmt.Errorf("deadline exceeded executing task %v. Example error: %v", ts.key, ts.lastError)
 				}
+				if now.Before(ts.nextAttempt) {
+					waiting++
+					if nextAttempt.IsZero() || ts.nextAttempt.Before(nextAttempt) {
+						nextAttempt = ts.nextAttempt
+					}
+					continue
+				}
 				canRun = append(canRun, ts)
 			}
 		}
 
 		klog.Infof("Tasks: %d done / %d total; %d can run", doneCount, len(taskStates), len(canRun))
 		if len(canRun) == 0 {
-			break
+			if waiting == 0 {
+				break
+			}
+			klog.Infof("No tasks can run, waiting %v before retrying %d task(s)", time.Until(nextAttempt).Round(time.Second), waiting)
+			if err := sleepContext(ctx, time.Until(nextAttempt)); err != nil {
+				return fmt.Errorf("task execution cancelled with %d task(s) not done: %w", countNotDone(taskStates), err)
+			}
+			continue
 		}
 
-		progress := false
-
 		var tasks []*taskState[T]
 		tasks = append(tasks, canRun...)
 
-		taskErrors := e.forkJoin(tasks)
-		var errors []error
+		taskErrors, interrupted := e.forkJoin(tasks)
+
 		for i, err := range taskErrors {
 			ts := tasks[i]
 			if err != nil {
@@ -128,32 +191,33 @@ func (e *executor[T]) RunTasks(taskMap map[string]Task[T]) error {
 					klog.Warningf(err.Error())
 					ts.done = true
 					ts.lastError = nil
-					progress = true
 					continue
 				}
 
+				if ctx.Err() != nil {
+					// Cancelled, so we won't retry
+					ts.lastError = err
+					continue
+				}
+
+				ts.backoff = e.options.nextBackoff(ts.backoff)
+				ts.nextAttempt = time.Now().Add(ts.backoff)
+
 				remaining := time.Second * time.Duration(int(time.Until(ts.deadline).Seconds()))
 				if _, ok := err.(*TryAgainLaterError); ok {
-					klog.V(2).Infof("Task %q not ready: %v", ts.key, err)
+					klog.V(2).Infof("Task %q not ready (retrying in %v): %v", ts.key, ts.backoff, err)
 				} else {
-					klog.Warningf("error running task %q (%v remaining to succeed): %v", ts.key, remaining, err)
+					klog.Warningf("error running task %q (%v remaining to succeed, retrying in %v): %v", ts.key, remaining, ts.backoff, err)
 				}
-				errors = append(errors, err)
 				ts.lastError = err
 			} else {
 				ts.done = true
 				ts.lastError = nil
-				progress = true
 			}
 		}
 
-		if !progress {
-			if len(errors) == 0 {
-				// Logic error!
-				panic("did not make progress executing tasks; but no errors reported")
-			}
-			klog.Infof("No progress made, sleeping before retrying %d task(s)", len(errors))
-			time.Sleep(e.options.WaitAfterAllTasksFailed)
+		if len(interrupted) != 0 {
+			return fmt.Errorf("task execution cancelled while tasks were in progress: %s", strings.Join(interrupted, ", "))
 		}
 	}
 
@@ -171,42 +235,121 @@ func (e *executor[T]) RunTasks(taskMap map[string]Task[T]) error {
 	return nil
 }
 
-func (e *executor[T]) forkJoin(tasks []*taskState[T]) []error {
+// forkJoin runs the tasks, with at most MaxConcurrency running at once, and waits for them to complete.
+// If the context is cancelled, tasks that have not yet started are not run.
+// Every task that ran has its own result recorded, even if it completed after the cancellation;
+// the keys of the tasks that failed after the context was cancelled are returned as interrupted.
+func (e *executor[T]) forkJoin(tasks []*taskState[T]) ([]error, []string) {
 	if len(tasks) == 0 {
-		return nil
+		return nil, nil
 	}
 
+	ctx := e.context.Context()
+
 	results := make([]error, len(tasks))
 	var resultsMutex sync.Mutex
 
+	inProgress := make(map[string]bool)
+	var interrupted []string
+
+	limit := e.options.MaxConcurrency
+	if limit <= 0 || limit > len(tasks) {
+		limit = len(tasks)
+	}
+	sem := make(chan struct{}, limit)
+
 	var wg sync.WaitGroup
 	for i := 0; i < len(tasks); i++ {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			for j := i; j < len(tasks); j++ {
+				results[j] = ctx.Err()
+			}
+			break
+		}
+
+		resultsMutex.Lock()
+		inProgress[tasks[i].key] = true
+		resultsMutex.Unlock()
+
 		wg.Add(1)
 		go func(ts *taskState[T], index int) {
 			defer wg.Done()
+			defer func() { <-sem }()
 
 			resultsMutex.Lock()
 			results[index] = fmt.Errorf("function panic")
 			resultsMutex.Unlock()
 
-			klog.V(2).Infof("Executing task %q: %v\n", ts.key, ts.task)
-
-			if taskNormalize, ok := ts.task.(TaskNormalize[T]); ok {
-				if err := taskNormalize.Normalize(e.context); err != nil {
-					results[index] = err
-					return
-				}
-			}
-
-			result := ts.task.Run(e.context)
+			result := e.runTask(ts)
 
 			resultsMutex.Lock()
 			results[index] = result
+			delete(inProgress, ts.key)
+			if result != nil && ctx.Err() != nil {
+				interrupted = append(interrupted, ts.key)
+			}
 			resultsMutex.Unlock()
 		}(tasks[i], i)
 	}
 
+	if ctx.Err() != nil {
+		var running []string
+		resultsMutex.Lock()
+		for k := range inProgress {
+			running = append(running, k)
+		}
+		resultsMutex.Unlock()
+		if len(running) != 0 {
+			sort.Strings(running)
+			klog.Warningf("Cancelled; waiting for %d task(s) in progress to finish: %s", len(running), strings.Join(running, ", "))
+		}
+	}
+
 	wg.Wait()
 
-	return results
+	sort.Strings(interrupted)
+	return results, interrupted
+}
+
+// runTask normalizes and runs a single task.
+func (e *executor[T]) runTask(ts *taskState[T]) error {
+	klog.V(2).Infof("Executing task %q: %v\n", ts.key, ts.task)
+
+	if taskNormalize, ok := ts.task.(TaskNormalize[T]); ok {
+		if err := taskNormalize.Normalize(e.context); err != nil {
+			return err
+		}
+	}
+
+	return ts.task.Run(e.context)
+}
+
+// countNotDone returns the number of tasks that have not completed.
+func countNotDone[T SubContext](taskStates map[string]*taskState[T]) int {
+	n := 0
+	for _, ts := range taskStates {
+		if !ts.done {
+			n++
+		}
+	}
+	return n
+}
+
+// sleepContext waits for the duration to elapse, returning early with an error if the context is cancelled.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
 }
@@ -0,0 +1,285 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fi
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type executorTestTask struct {
+	Name string
+
+	run func() error
+}
+
+func (t *executorTestTask) Run(_ *InstallContext) error {
+	return t.run()
+}
+
+func newExecutorTestContext(t *testing.T, ctx context.Context, tasks map[string]InstallTask) *InstallContext {
+	c, err := NewInstallContext(ctx, nil, tasks)
+	if err != nil {
+		t.Fatalf("error building context: %v", err)
+	}
+	return c
+}
+
+func TestRunTasks_MaxConcurrency(t *testing.T) {
+	var mutex sync.Mutex
+	running := 0
+	maxRunning := 0
+
+	tasks := make(map[string]InstallTask)
+	for i := 0; i < 20; i++ {
+		tasks[fmt.Sprintf("task-%d", i)] = &executorTestTask{
+			Name: fmt.Sprintf("task-%d", i),
+			run: func() error {
+				mutex.Lock()
+				running++
+				if running > maxRunning {
+					maxRunning = running
+				}
+				mutex.Unlock()
+
+				time.Sleep(10 * time.Millisecond)
+
+				mutex.Lock()
+				running--
+				mutex.Unlock()
+				return nil
+			},
+		}
+	}
+
+	c := newExecutorTestContext(t, context.Background(), tasks)
+	options := RunTasksOptions{
+		MaxTaskDuration:         time.Second,
+		WaitAfterAllTasksFailed: 10 * time.Millisecond,
+		MaxConcurrency:          3,
+	}
+	if err := c.RunTasks(options); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if maxRunning > 3 {
+		t.Errorf("expected at most 3 concurrent tasks, got %d", maxRunning)
+	}
+}
+
+func TestRunTasks_Backoff(t *testing.T) {
+	var attempts int32
+	var attemptTimes []time.Time
+
+	tasks := map[string]InstallTask{
+		"flaky": &executorTestTask{
+			Name: "flaky",
+			run: func() error {
+				attemptTimes = append(attemptTimes, time.Now())
+				if atomic.AddInt32(&attempts, 1) < 4 {
+					return fmt.Errorf("not yet")
+				}
+				return nil
+			},
+		},
+	}
+
+	c := newExecutorTestContext(t, context.Background(), tasks)
+	options := RunTasksOptions{
+		MaxTaskDuration:         5 * time.Second,
+		WaitAfterAllTasksFailed: 10 * time.Millisecond,
+		MaxBackoff:              40 * time.Millisecond,
+	}
+	if err := c.RunTasks(options); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(attemptTimes) != 4 {
+		t.Fatalf("expected 4 attempts, got %d", len(attemptTimes))
+	}
+
+	// Delays should be 10ms, 20ms, 40ms
+	expected := []time.Duration{10 * time.Millisecond, 20 * time.Millisecond, 40 * time.Millisecond}
+	for i, want := range expected {
+		got := attemptTimes[i+1].Sub(attemptTimes[i])
+		if got < want {
+			t.Errorf("attempt %d: expected delay of at least %v, got %v", i+1, want, got)
+		}
+	}
+}
+
+func TestRunTasksOptions_nextBackoff(t *testing.T) {
+	options := RunTasksOptions{
+		WaitAfterAllTasksFailed: time.Second,
+		MaxBackoff:              5 * time.Second,
+	}
+
+	grid := []struct {
+		Current  time.Duration
+		Expected time.Duration
+	}{
+		{Current: 0, Expected: time.Second},
+		{Current: time.Second, Expected: 2 * time.Second},
+		{Current: 2 * time.Second, Expected: 4 * time.Second},
+		{Current: 4 * time.Second, Expected: 5 * time.Second},
+		{Current: 5 * time.Second, Expected: 5 * time.Second},
+	}
+	for _, g := range grid {
+		actual := options.nextBackoff(g.Current)
+		if actual != g.Expected {
+			t.Errorf("nextBackoff(%v): expected %v, got %v", g.Current, g.Expected, actual)
+		}
+	}
+
+	// Without a MaxBackoff, the delay is fixed
+	options.MaxBackoff = 0
+	if actual := options.nextBackoff(time.Second); actual != time.Second {
+		t.Errorf("expected fixed backoff of 1s, got %v", actual)
+	}
+}
+
+func TestRunTasksOptions_InitDefaults(t *testing.T) {
+	// nodeup retries failed tasks at a fixed interval
+	var options RunTasksOptions
+	options.InitDefaults()
+	if actual := options.nextBackoff(options.WaitAfterAllTasksFailed); actual != options.WaitAfterAllTasksFailed {
+		t.Errorf("expected fixed backoff of %v, got %v", options.WaitAfterAllTasksFailed, actual)
+	}
+
+	var cloudupOptions RunTasksOptions
+	cloudupOptions.InitCloudupDefaults()
+	if actual := cloudupOptions.nextBackoff(cloudupOptions.WaitAfterAllTasksFailed); actual != 2*cloudupOptions.WaitAfterAllTasksFailed {
+		t.Errorf("expected backoff of %v, got %v", 2*cloudupOptions.WaitAfterAllTasksFailed, actual)
+	}
+}
+
+func TestRunTasks_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	started := make(chan struct{})
+	var startedOnce sync.Once
+	var ran int32
+
+	tasks := make(map[string]InstallTask)
+	for i := 0; i < 5; i++ {
+		tasks[fmt.Sprintf("task-%d", i)] = &executorTestTask{
+			Name: fmt.Sprintf("task-%d", i),
+			run: func() error {
+				atomic.AddInt32(&ran, 1)
+				startedOnce.Do(func() { close(started) })
+				<-ctx.Done()
+				return ctx.Err()
+			},
+		}
+	}
+
+	c := newExecutorTestContext(t, ctx, tasks)
+	options := RunTasksOptions{
+		MaxTaskDuration:         time.Minute,
+		WaitAfterAllTasksFailed: time.Minute,
+		MaxConcurrency:          1,
+	}
+
+	go func() {
+		<-started
+		cancel()
+	}()
+
+	err := c.RunTasks(options)
+	if err == nil {
+		t.Fatalf("expected error from cancelled run")
+	}
+	if !strings.Contains(err.Error(), "in progress") {
+		t.Errorf("expected error to report in-progress tasks, got %v", err)
+	}
+	if n := atomic.LoadInt32(&ran); n != 1 {
+		t.Errorf("expected only one task to start before cancellation, %d started", n)
+	}
+}
+
+func TestRunTasks_CancelledAfterTasksSucceed(t *testing.T) {
+	grid := []struct {
+		name string
+		// fail is the set of tasks that fail once cancelled; the others succeed
+		fail map[string]bool
+		// expectInterrupted is the interrupted tasks the error should report, if any
+		expectInterrupted string
+	}{
+		{
+			name: "all succeed",
+		},
+		{
+			name:              "one fails",
+			fail:              map[string]bool{"fails": true},
+			expectInterrupted: "fails",
+		},
+	}
+
+	for _, g := range grid {
+		t.Run(g.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			var started sync.WaitGroup
+			tasks := make(map[string]InstallTask)
+			for _, name := range []string{"succeeds", "fails"} {
+				fails := g.fail[name]
+				started.Add(1)
+				tasks[name] = &executorTestTask{
+					Name: name,
+					run: func() error {
+						started.Done()
+						<-ctx.Done()
+						if fails {
+							return ctx.Err()
+						}
+						return nil
+					},
+				}
+			}
+
+			c := newExecutorTestContext(t, ctx, tasks)
+			options := RunTasksOptions{
+				MaxTaskDuration:         time.Minute,
+				WaitAfterAllTasksFailed: time.Minute,
+			}
+
+			go func() {
+				started.Wait()
+				cancel()
+			}()
+
+			err := c.RunTasks(options)
+			if g.expectInterrupted == "" {
+				if err != nil {
+					t.Fatalf("unexpected error after all tasks completed: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("expected error from cancelled run")
+			}
+			if !strings.HasSuffix(err.Error(), "in progress: "+g.expectInterrupted) {
+				t.Errorf("expected error to report only %q as interrupted, got %v", g.expectInterrupted, err)
+			}
+		})
+	}
+}
@@ -242,6 +242,7 @@ func (_ *Keypair) Render(c *fi.CloudupContext, a, e, changes *Keypair) error {
 			Subject:        *subjectPkix,
 			AlternateNames: e.AlternateNames,
 			PrivateKey:     privateKey,
+			KeyAlgorithm:   keyAlgorithm(c, name),
 			Serial:         serial,
 		}
 		cert, privateKey, _, err := pki.IssueCert(ctx, &req, fi.NewPKIKeystoreAdapter(c.T.Keystore))
@@ -303,6 +304,15 @@ func (_ *Keypair) Render(c *fi.CloudupContext, a, e, changes *Keypair) error {
 	return nil
 }
 
+// keyAlgorithm returns the algorithm of the private key to generate for the keyset, as configured in the cluster spec.
+// Existing private keys are reused regardless of their algorithm.
+func keyAlgorithm(c *fi.CloudupContext, name string) pki.KeyAlgorithm {
+	if c.T.Cluster == nil {
+		return ""
+	}
+	return pki.KeyAlgorithm(c.T.Cluster.Spec.PKI.KeyAlgorithmForKeyset(name))
+}
+
 func parsePkixName(s string) (*pkix.Name, error) {
 	name := new(pkix.Name)
 
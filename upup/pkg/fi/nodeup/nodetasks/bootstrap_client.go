@@ -17,8 +17,6 @@ limitations under the License.
 package nodetasks
 
 import (
-	"crypto/x509"
-	"encoding/pem"
 	"fmt"
 	"strconv"
 
@@ -118,7 +116,7 @@ func (b *BootstrapClientTask) Run(c *fi.NodeupContext) error {
 		key, ok := b.keys[name]
 		if !ok {
 			var err error
-			key, err = pki.GeneratePrivateKey()
+			key, err = pki.GeneratePrivateKeyWithAlgorithm(keyAlgorithm(c, name))
 			if err != nil {
 				return fmt.Errorf("generating private key: %v", err)
 			}
@@ -127,12 +125,12 @@ func (b *BootstrapClientTask) Run(c *fi.NodeupContext) error {
 			b.keys[name] = key
 		}
 
-		pkData, err := x509.MarshalPKIXPublicKey(key.Key.Public())
+		pkData, err := pki.EncodePEMPublicKey(key.Key.Public())
 		if err != nil {
 			return fmt.Errorf("marshalling public key: %v", err)
 		}
 		// TODO perhaps send a CSR instead to prove we own the private key?
-		req.Certs[name] = string(pem.EncodeToMemory(&pem.Block{Type: "RSA PUBLIC KEY", Bytes: pkData}))
+		req.Certs[name] = string(pkData)
 	}
 
 	var resp nodeup.BootstrapResponse
@@ -149,6 +149,7 @@ func (e *IssueCert) Run(c *fi.NodeupContext) error {
 		Type:           e.Type,
 		Subject:        e.Subject.toPKIXName(),
 		AlternateNames: e.AlternateNames,
+		KeyAlgorithm:   keyAlgorithm(c, e.Name),
 		Validity:       time.Hour * time.Duration(validHours),
 	}
 
@@ -183,6 +184,14 @@ func (e *IssueCert) Run(c *fi.NodeupContext) error {
 	return nil
 }
 
+// keyAlgorithm returns the algorithm of the private key to generate for the named certificate, as configured in the cluster spec.
+func keyAlgorithm(c *fi.NodeupContext, name string) pki.KeyAlgorithm {
+	if c.T.NodeupConfig == nil {
+		return ""
+	}
+	return pki.KeyAlgorithm(c.T.NodeupConfig.PKI.KeyAlgorithmForKeyset(name))
+}
+
 type hasAsBytes interface {
 	AsBytes() ([]byte, error)
 }
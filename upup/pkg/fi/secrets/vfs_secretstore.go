@@ -22,10 +22,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"strings"
 
 	"k8s.io/klog/v2"
 	"k8s.io/kops/pkg/acls"
 	"k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/pkg/stateencryption"
 	"k8s.io/kops/upup/pkg/fi"
 	"k8s.io/kops/util/pkg/vfs"
 )
@@ -40,7 +42,8 @@ var _ fi.SecretStore = &VFSSecretStore{}
 func NewVFSSecretStore(cluster *kops.Cluster, basedir vfs.Path) fi.SecretStore {
 	c := &VFSSecretStore{
 		VFSSecretStoreReader: VFSSecretStoreReader{
-			basedir: basedir,
+			basedir:    basedir,
+			encryption: stateencryption.ForPath(basedir),
 		},
 		cluster: cluster,
 	}
@@ -59,6 +62,16 @@ func (c *VFSSecretStore) MirrorTo(ctx context.Context, basedir vfs.Path) error {
 		return fmt.Errorf("error listing secrets for mirror: %v", err)
 	}
 
+	// The mirror is encrypted with the same data key
+	envelopeACL, err := acls.GetACL(ctx, basedir.Join(stateencryption.EnvelopeFileName), c.cluster)
+	if err != nil {
+		return fmt.Errorf("error building acl for mirror: %v", err)
+	}
+	if err := stateencryption.CopyEnvelope(ctx, c.basedir, basedir, envelopeACL); err != nil {
+		return err
+	}
+	encryption := stateencryption.ForPath(basedir)
+
 	for _, name := range secrets {
 		secret, err := c.FindSecret(name)
 		if err != nil {
@@ -78,7 +91,7 @@ func (c *VFSSecretStore) MirrorTo(ctx context.Context, basedir vfs.Path) error {
 
 		klog.Infof("mirroring secret %s -> %s", name, p)
 
-		err = createSecret(ctx, secret, p, acl, true)
+		err = createSecret(ctx, encryption, secret, p, acl, true)
 		if err != nil {
 			return fmt.Errorf("error writing secret %q for mirror: %v", name, err)
 		}
@@ -104,6 +117,10 @@ func (c *VFSSecretStore) ListSecrets() ([]string, error) {
 	}
 	for _, f := range files {
 		id := f.Base()
+		if strings.HasPrefix(id, ".") {
+			// Skip hidden files, such as the encryption envelope
+			continue
+		}
 		ids = append(ids, id)
 	}
 	return ids, nil
@@ -127,7 +144,7 @@ func (c *VFSSecretStore) GetOrCreateSecret(ctx context.Context, id string, secre
 			return nil, false, err
 		}
 
-		err = createSecret(ctx, secret, p, acl, false)
+		err = createSecret(ctx, c.encryptionStore(), secret, p, acl, false)
 		if err != nil {
 			if os.IsExist(err) && i == 0 {
 				klog.Infof("Got already-exists error when writing secret; likely due to concurrent creation.  Will retry")
@@ -161,7 +178,7 @@ func (c *VFSSecretStore) ReplaceSecret(id string, secret *fi.Secret) (*fi.Secret
 		return nil, err
 	}
 
-	err = createSecret(ctx, secret, p, acl, true)
+	err = createSecret(ctx, c.encryptionStore(), secret, p, acl, true)
 	if err != nil {
 		return nil, fmt.Errorf("unable to write secret: %v", err)
 	}
@@ -174,13 +191,19 @@ func (c *VFSSecretStore) ReplaceSecret(id string, secret *fi.Secret) (*fi.Secret
 	return s, nil
 }
 
-// createSecret will create the Secret, overwriting an existing secret if replace is true
-func createSecret(ctx context.Context, s *fi.Secret, p vfs.Path, acl vfs.ACL, replace bool) error {
+// createSecret will create the Secret, overwriting an existing secret if replace is true.
+// The secret is encrypted if the store is encrypted.
+func createSecret(ctx context.Context, encryption *stateencryption.Store, s *fi.Secret, p vfs.Path, acl vfs.ACL, replace bool) error {
 	data, err := json.Marshal(s)
 	if err != nil {
 		return fmt.Errorf("error serializing secret: %v", err)
 	}
 
+	data, err = encryption.Encrypt(ctx, p, data)
+	if err != nil {
+		return fmt.Errorf("error encrypting secret: %v", err)
+	}
+
 	rs := bytes.NewReader(data)
 	if replace {
 		return p.WriteFile(ctx, rs, acl)
@@ -22,19 +22,24 @@ import (
 	"fmt"
 	"os"
 
+	"k8s.io/kops/pkg/stateencryption"
 	"k8s.io/kops/upup/pkg/fi"
 	"k8s.io/kops/util/pkg/vfs"
 )
 
 type VFSSecretStoreReader struct {
 	basedir vfs.Path
+
+	// encryption encrypts and decrypts the files of the store, if it is encrypted
+	encryption *stateencryption.Store
 }
 
 var _ fi.SecretStoreReader = &VFSSecretStoreReader{}
 
 func NewVFSSecretStoreReader(basedir vfs.Path) fi.SecretStoreReader {
 	c := &VFSSecretStoreReader{
-		basedir: basedir,
+		basedir:    basedir,
+		encryption: stateencryption.ForPath(basedir),
 	}
 	return c
 }
@@ -43,6 +48,14 @@ func (c *VFSSecretStoreReader) VFSPath() vfs.Path {
 	return c.basedir
 }
 
+// encryptionStore returns the Store that encrypts the files of the secret store.
+func (c *VFSSecretStoreReader) encryptionStore() *stateencryption.Store {
+	if c.encryption == nil {
+		return stateencryption.ForPath(c.basedir)
+	}
+	return c.encryption
+}
+
 func BuildVfsSecretPath(basedir vfs.Path, name string) vfs.Path {
 	return basedir.Join(name)
 }
@@ -80,6 +93,10 @@ func (c *VFSSecretStoreReader) loadSecret(ctx context.Context, p vfs.Path) (*fi.
 			return nil, nil
 		}
 	}
+	data, err = c.encryptionStore().Decrypt(ctx, p, data)
+	if err != nil {
+		return nil, fmt.Errorf("decrypting secret from %q: %v", p, err)
+	}
 	s := &fi.Secret{}
 	err = json.Unmarshal(data, s)
 	if err != nil {
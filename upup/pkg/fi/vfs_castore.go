@@ -32,6 +32,7 @@ import (
 	"k8s.io/kops/pkg/apis/kops/v1alpha2"
 	"k8s.io/kops/pkg/kopscodecs"
 	"k8s.io/kops/pkg/sshcredentials"
+	"k8s.io/kops/pkg/stateencryption"
 	"k8s.io/kops/util/pkg/vfs"
 )
 
@@ -48,7 +49,8 @@ var (
 func NewVFSCAStore(cluster *kops.Cluster, basedir vfs.Path) *VFSCAStore {
 	c := &VFSCAStore{
 		VFSKeystoreReader: VFSKeystoreReader{
-			basedir: basedir,
+			basedir:    basedir,
+			encryption: stateencryption.ForPath(basedir),
 		},
 		cluster: cluster,
 	}
@@ -61,7 +63,8 @@ func NewVFSSSHCredentialStore(cluster *kops.Cluster, basedir vfs.Path) SSHCreden
 	// Note currently identical to NewVFSCAStore
 	c := &VFSCAStore{
 		VFSKeystoreReader: VFSKeystoreReader{
-			basedir: basedir,
+			basedir:    basedir,
+			encryption: stateencryption.ForPath(basedir),
 		},
 		cluster: cluster,
 	}
@@ -118,8 +121,8 @@ func (k *Keyset) ToAPIObject(name string) (*kops.Keyset, error) {
 	return o, nil
 }
 
-// writeKeysetBundle writes a Keyset bundle to VFS.
-func writeKeysetBundle(ctx context.Context, cluster *kops.Cluster, p vfs.Path, name string, keyset *Keyset) error {
+// writeKeysetBundle writes a Keyset bundle to VFS, encrypted if the store is encrypted.
+func writeKeysetBundle(ctx context.Context, cluster *kops.Cluster, encryption *stateencryption.Store, p vfs.Path, name string, keyset *Keyset) error {
 	p = p.Join("keyset.yaml")
 
 	o, err := keyset.ToAPIObject(name)
@@ -132,6 +135,11 @@ func writeKeysetBundle(ctx context.Context, cluster *kops.Cluster, p vfs.Path, n
 		return err
 	}
 
+	objectData, err = encryption.Encrypt(ctx, p, objectData)
+	if err != nil {
+		return fmt.Errorf("error encrypting keyset %q: %v", name, err)
+	}
+
 	acl, err := acls.GetACL(ctx, p, cluster)
 	if err != nil {
 		return err
@@ -205,8 +213,18 @@ func (c *VFSCAStore) MirrorTo(ctx context.Context, basedir vfs.Path) error {
 		return err
 	}
 
+	// The mirror is encrypted with the same data key
+	envelopeACL, err := acls.GetACL(ctx, basedir.Join(stateencryption.EnvelopeFileName), c.cluster)
+	if err != nil {
+		return err
+	}
+	if err := stateencryption.CopyEnvelope(ctx, c.basedir, basedir, envelopeACL); err != nil {
+		return err
+	}
+	encryption := stateencryption.ForPath(basedir)
+
 	for name, keyset := range keysets {
-		if err := mirrorKeyset(ctx, c.cluster, basedir, name, keyset); err != nil {
+		if err := mirrorKeyset(ctx, c.cluster, encryption, basedir, name, keyset); err != nil {
 			return err
 		}
 	}
@@ -226,8 +244,8 @@ func (c *VFSCAStore) MirrorTo(ctx context.Context, basedir vfs.Path) error {
 }
 
 // mirrorKeyset writes Keyset bundles for the certificates & privatekeys.
-func mirrorKeyset(ctx context.Context, cluster *kops.Cluster, basedir vfs.Path, name string, keyset *Keyset) error {
-	if err := writeKeysetBundle(ctx, cluster, basedir.Join("private"), name, keyset); err != nil {
+func mirrorKeyset(ctx context.Context, cluster *kops.Cluster, encryption *stateencryption.Store, basedir vfs.Path, name string, keyset *Keyset) error {
+	if err := writeKeysetBundle(ctx, cluster, encryption, basedir.Join("private"), name, keyset); err != nil {
 		return fmt.Errorf("writing private bundle: %v", err)
 	}
 
@@ -275,7 +293,7 @@ func (c *VFSCAStore) StoreKeyset(ctx context.Context, name string, keyset *Keyse
 
 	{
 		p := c.buildPrivateKeyPoolPath(name)
-		if err := writeKeysetBundle(ctx, c.cluster, p, name, keyset); err != nil {
+		if err := writeKeysetBundle(ctx, c.cluster, c.encryptionStore(), p, name, keyset); err != nil {
 			return fmt.Errorf("writing private bundle: %v", err)
 		}
 	}
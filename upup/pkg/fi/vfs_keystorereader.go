@@ -26,12 +26,16 @@ import (
 	"k8s.io/kops/pkg/apis/kops"
 	"k8s.io/kops/pkg/apis/kops/v1alpha2"
 	"k8s.io/kops/pkg/kopscodecs"
+	"k8s.io/kops/pkg/stateencryption"
 	"k8s.io/kops/util/pkg/vfs"
 )
 
 type VFSKeystoreReader struct {
 	basedir vfs.Path
 
+	// encryption encrypts and decrypts the files of the store, if it is encrypted
+	encryption *stateencryption.Store
+
 	mutex    sync.Mutex
 	cachedCA *Keyset
 }
@@ -40,7 +44,8 @@ var _ KeystoreReader = &VFSKeystoreReader{}
 
 func NewVFSKeystoreReader(basedir vfs.Path) *VFSKeystoreReader {
 	k := &VFSKeystoreReader{
-		basedir: basedir,
+		basedir:    basedir,
+		encryption: stateencryption.ForPath(basedir),
 	}
 
 	return k
@@ -50,6 +55,14 @@ func (c *VFSKeystoreReader) VFSPath() vfs.Path {
 	return c.basedir
 }
 
+// encryptionStore returns the Store that encrypts the files of the keystore.
+func (c *VFSKeystoreReader) encryptionStore() *stateencryption.Store {
+	if c.encryption == nil {
+		return stateencryption.ForPath(c.basedir)
+	}
+	return c.encryption
+}
+
 func (c *VFSKeystoreReader) buildPrivateKeyPoolPath(name string) vfs.Path {
 	return c.basedir.Join("private", name)
 }
@@ -87,12 +100,17 @@ func (c *VFSKeystoreReader) loadKeyset(ctx context.Context, p vfs.Path) (*Keyset
 		return nil, fmt.Errorf("unable to read bundle %q: %v", p, err)
 	}
 
+	data, err = c.encryptionStore().Decrypt(ctx, bundlePath, data)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decrypt bundle %q: %v", p, err)
+	}
+
 	o, legacyFormat, err := c.parseKeysetYaml(data)
 	if err != nil {
 		return nil, fmt.Errorf("error parsing bundle %q: %v", p, err)
 	}
 
-	keyset, err := parseKeyset(o)
+	keyset, err := ParseKeyset(o)
 	if err != nil {
 		return nil, fmt.Errorf("error mapping bundle %q: %v", p, err)
 	}
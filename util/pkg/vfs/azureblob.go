@@ -40,8 +40,9 @@ type AzureBlobPath struct {
 }
 
 var (
-	_ Path    = &AzureBlobPath{}
-	_ HasHash = &AzureBlobPath{}
+	_ Path          = &AzureBlobPath{}
+	_ HasHash       = &AzureBlobPath{}
+	_ VersionedPath = &AzureBlobPath{}
 )
 
 // NewAzureBlobPath returns a new AzureBlobPath.
@@ -165,26 +166,32 @@ func (p *AzureBlobPath) CreateFile(ctx context.Context, data io.ReadSeeker, acl
 //
 // TODO(kenji): Support ACL.
 func (p *AzureBlobPath) WriteFile(ctx context.Context, data io.ReadSeeker, acl ACL) error {
+	_, err := p.writeFile(ctx, data, azblob.BlobAccessConditions{})
+	return err
+}
+
+// writeFile uploads the blob subject to the access conditions, returning its new ETag.
+func (p *AzureBlobPath) writeFile(ctx context.Context, data io.ReadSeeker, conditions azblob.BlobAccessConditions) (azblob.ETag, error) {
 	client, err := p.getClient(ctx)
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	md5Hash, err := hashing.HashAlgorithmMD5.Hash(data)
 	if err != nil {
-		return err
+		return "", err
 	}
 	if _, err := data.Seek(0, 0); err != nil {
-		return fmt.Errorf("error seeking to start of data stream: %v", err)
+		return "", fmt.Errorf("error seeking to start of data stream: %v", err)
 	}
 
 	cURL, err := client.newContainerURL(p.container)
 	if err != nil {
-		return err
+		return "", err
 	}
 	// Use block blob. Other options are page blobs (optimized for
 	// random read/write) and append blob (optimized for append).
-	_, err = cURL.NewBlockBlobURL(p.key).Upload(
+	resp, err := cURL.NewBlockBlobURL(p.key).Upload(
 		ctx,
 		data,
 		azblob.BlobHTTPHeaders{
@@ -192,13 +199,92 @@ func (p *AzureBlobPath) WriteFile(ctx context.Context, data io.ReadSeeker, acl A
 			ContentMD5:  md5Hash.HashValue,
 		},
 		azblob.Metadata{},
-		azblob.BlobAccessConditions{},
+		conditions,
 		azblob.AccessTierNone,
 		azblob.BlobTagsMap{},
 		azblob.ClientProvidedKeyOptions{},
 		azblob.ImmutabilityPolicyOptions{},
 	)
-	return err
+	if err != nil {
+		return "", err
+	}
+	return resp.ETag(), nil
+}
+
+// ReadFileVersion returns the content of the blob, along with its ETag as the version.
+func (p *AzureBlobPath) ReadFileVersion(ctx context.Context) ([]byte, string, error) {
+	client, err := p.getClient(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+
+	cURL, err := client.newContainerURL(p.container)
+	if err != nil {
+		return nil, "", err
+	}
+	resp, err := cURL.NewBlockBlobURL(p.key).Download(
+		ctx,
+		0, /* offset */
+		azblob.CountToEnd,
+		azblob.BlobAccessConditions{},
+		false, /* rangeGetContentMD5 */
+		azblob.ClientProvidedKeyOptions{},
+	)
+	if err != nil {
+		serr, ok := err.(azblob.StorageError)
+		if ok && serr.ServiceCode() == azblob.ServiceCodeBlobNotFound {
+			return nil, "", os.ErrNotExist
+		}
+		return nil, "", err
+	}
+
+	body := resp.Body(azblob.RetryReaderOptions{MaxRetryRequests: 10})
+	defer body.Close()
+
+	var b bytes.Buffer
+	if _, err := io.Copy(&b, body); err != nil {
+		return nil, "", err
+	}
+	return b.Bytes(), string(resp.ETag()), nil
+}
+
+// WriteFileIfVersion writes the blob only if its ETag still matches version.
+func (p *AzureBlobPath) WriteFileIfVersion(ctx context.Context, data io.ReadSeeker, acl ACL, version string) (string, error) {
+	if version == "" {
+		return "", fmt.Errorf("version is required for conditional write to %s", p.Path())
+	}
+	conditions := azblob.BlobAccessConditions{
+		ModifiedAccessConditions: azblob.ModifiedAccessConditions{
+			IfMatch: azblob.ETag(version),
+		},
+	}
+	etag, err := p.writeFile(ctx, data, conditions)
+	if err != nil {
+		serr, ok := err.(azblob.StorageError)
+		if ok && serr.ServiceCode() == azblob.ServiceCodeConditionNotMet {
+			return "", fmt.Errorf("error writing %s: %w", p.Path(), ErrVersionConflict)
+		}
+		return "", err
+	}
+	return string(etag), nil
+}
+
+// CreateFileIfNotExists writes the blob only if it does not exist, using an If-None-Match precondition.
+func (p *AzureBlobPath) CreateFileIfNotExists(ctx context.Context, data io.ReadSeeker, acl ACL) (string, error) {
+	conditions := azblob.BlobAccessConditions{
+		ModifiedAccessConditions: azblob.ModifiedAccessConditions{
+			IfNoneMatch: azblob.ETagAny,
+		},
+	}
+	etag, err := p.writeFile(ctx, data, conditions)
+	if err != nil {
+		serr, ok := err.(azblob.StorageError)
+		if ok && (serr.ServiceCode() == azblob.ServiceCodeBlobAlreadyExists || serr.ServiceCode() == azblob.ServiceCodeConditionNotMet) {
+			return "", os.ErrExist
+		}
+		return "", err
+	}
+	return string(etag), nil
 }
 
 // Remove deletes the blob.
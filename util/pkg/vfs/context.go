@@ -33,6 +33,7 @@ import (
 	"google.golang.org/api/option"
 	storage "google.golang.org/api/storage/v1"
 	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
 	"k8s.io/klog/v2"
 )
 
@@ -372,12 +373,13 @@ func (c *VFSContext) buildKubernetesPath(p string) (*KubernetesPath, error) {
 		return nil, fmt.Errorf("invalid kubernetes vfs path: %q", p)
 	}
 
-	bucket := strings.TrimSuffix(u.Host, "/")
-	if bucket == "" {
-		return nil, fmt.Errorf("invalid kubernetes vfs path: %q", p)
+	// The host is the kubeconfig context, and the namespace is the first component of the path
+	namespace, key, _ := strings.Cut(strings.TrimPrefix(u.Path, "/"), "/")
+	if namespace == "" {
+		return nil, fmt.Errorf("invalid kubernetes vfs path, expected k8s://<context>/<namespace>/: %q", p)
 	}
 
-	k8sPath := newKubernetesPath(c.k8sContext, bucket, u.Path)
+	k8sPath := newKubernetesPath(c.k8sContext, u.Host, namespace, key)
 	return k8sPath, nil
 }
 
@@ -401,6 +403,11 @@ func (c *VFSContext) ResetMemfsContext(clusterReadable bool) {
 	}
 }
 
+// ResetKubernetesContext makes k8s:// paths use the specified kubernetes client.
+func (c *VFSContext) ResetKubernetesContext(client kubernetes.Interface) {
+	c.k8sContext = NewKubernetesContextForClient(client)
+}
+
 func (c *VFSContext) buildGCSPath(p string) (*GSPath, error) {
 	u, err := url.Parse(p)
 	if err != nil {
@@ -17,6 +17,7 @@ limitations under the License.
 package vfs
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
@@ -25,6 +26,7 @@ import (
 	"path"
 	"sync"
 	"syscall"
+	"time"
 
 	"k8s.io/klog/v2"
 	"k8s.io/kops/pkg/try"
@@ -36,8 +38,9 @@ type FSPath struct {
 }
 
 var (
-	_ Path    = &FSPath{}
-	_ HasHash = &FSPath{}
+	_ Path          = &FSPath{}
+	_ HasHash       = &FSPath{}
+	_ VersionedPath = &FSPath{}
 )
 
 func NewFSPath(location string) *FSPath {
@@ -123,6 +126,99 @@ func (p *FSPath) ReadFile(ctx context.Context) ([]byte, error) {
 	return file, err
 }
 
+// ReadFileVersion implements VersionedPath::ReadFileVersion; the version is a hash of the contents.
+func (p *FSPath) ReadFileVersion(ctx context.Context) ([]byte, string, error) {
+	data, err := p.ReadFile(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+	return data, contentVersion(data), nil
+}
+
+// fsLockTimeout is how long we wait to acquire the lock file before giving up
+const fsLockTimeout = 10 * time.Second
+
+// WriteFileIfVersion implements VersionedPath::WriteFileIfVersion.
+// A lock file alongside the target serializes conditional writes, including from other processes.
+func (p *FSPath) WriteFileIfVersion(ctx context.Context, data io.ReadSeeker, acl ACL, version string) (string, error) {
+	unlock, err := p.lock(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer unlock()
+
+	current, err := p.ReadFile(ctx)
+	if err != nil {
+		return "", err
+	}
+	if contentVersion(current) != version {
+		return "", fmt.Errorf("error writing %s: %w", p, ErrVersionConflict)
+	}
+
+	b, err := io.ReadAll(data)
+	if err != nil {
+		return "", fmt.Errorf("error reading data: %v", err)
+	}
+	if err := p.WriteFile(ctx, bytes.NewReader(b), acl); err != nil {
+		return "", err
+	}
+	return contentVersion(b), nil
+}
+
+// CreateFileIfNotExists implements VersionedPath::CreateFileIfNotExists.
+// It takes the same lock file as WriteFileIfVersion, so the check and the write are atomic across processes.
+func (p *FSPath) CreateFileIfNotExists(ctx context.Context, data io.ReadSeeker, acl ACL) (string, error) {
+	unlock, err := p.lock(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer unlock()
+
+	if _, err := os.Stat(p.location); err == nil {
+		return "", os.ErrExist
+	} else if !os.IsNotExist(err) {
+		return "", err
+	}
+
+	b, err := io.ReadAll(data)
+	if err != nil {
+		return "", fmt.Errorf("error reading data: %v", err)
+	}
+	if err := p.WriteFile(ctx, bytes.NewReader(b), acl); err != nil {
+		return "", err
+	}
+	return contentVersion(b), nil
+}
+
+// lock creates a lock file for the path, waiting for up to fsLockTimeout if it is held by someone else.
+// The returned function releases the lock.
+func (p *FSPath) lock(ctx context.Context) (func(), error) {
+	lockPath := path.Join(path.Dir(p.location), "."+path.Base(p.location)+".lock")
+	deadline := time.Now().Add(fsLockTimeout)
+	for {
+		f, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+		if err == nil {
+			try.CloseFile(f)
+			return func() {
+				if err := os.Remove(lockPath); err != nil {
+					klog.Warningf("unable to remove lock file %q: %v", lockPath, err)
+				}
+			}, nil
+		}
+		if !os.IsExist(err) {
+			return nil, fmt.Errorf("error creating lock file %q: %v", lockPath, err)
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("timed out waiting for lock file %q; remove it if no other kops process is running", lockPath)
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
+
 // WriteTo implements io.WriterTo
 func (p *FSPath) WriteTo(out io.Writer) (int64, error) {
 	f, err := os.Open(p.location)
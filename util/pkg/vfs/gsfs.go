@@ -26,6 +26,7 @@ import (
 	"net/http"
 	"os"
 	"path"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -53,6 +54,7 @@ var (
 	_ Path          = &GSPath{}
 	_ TerraformPath = &GSPath{}
 	_ HasHash       = &GSPath{}
+	_ VersionedPath = &GSPath{}
 )
 
 // gcsReadBackoff is the backoff strategy for GCS read retries
@@ -159,11 +161,20 @@ func (p *GSPath) Join(relativePath ...string) Path {
 }
 
 func (p *GSPath) WriteFile(ctx context.Context, data io.ReadSeeker, acl ACL) error {
+	_, err := p.writeFile(ctx, data, acl, nil)
+	return err
+}
+
+// writeFile uploads the object, returning its new generation.  If ifGeneration is not nil,
+// the write is conditional on the object's current generation matching it; generation 0
+// matches only if the object does not exist.
+func (p *GSPath) writeFile(ctx context.Context, data io.ReadSeeker, acl ACL, ifGeneration *int64) (int64, error) {
 	md5Hash, err := hashing.HashAlgorithmMD5.Hash(data)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
+	var generation int64
 	done, err := RetryWithBackoff(gcsWriteBackoff, func() (bool, error) {
 		obj := &storage.Object{
 			Name:    p.key,
@@ -190,23 +201,93 @@ func (p *GSPath) WriteFile(ctx context.Context, data io.ReadSeeker, acl ACL) err
 			return false, err
 		}
 
-		_, err = client.Objects.Insert(p.bucket, obj).Context(ctx).Media(data).Do()
+		call := client.Objects.Insert(p.bucket, obj).Context(ctx).Media(data)
+		if ifGeneration != nil {
+			call = call.IfGenerationMatch(*ifGeneration)
+		}
+		written, err := call.Do()
 		if err != nil {
+			if ifGeneration != nil && isGCSPreconditionFailed(err) {
+				// Not recoverable
+				if *ifGeneration == 0 {
+					return true, os.ErrExist
+				}
+				return true, fmt.Errorf("error writing %s: %w", p, ErrVersionConflict)
+			}
 			return false, fmt.Errorf("error writing %s: %v", p, err)
 		}
+		generation = written.Generation
 
 		return true, nil
 	})
 	if err != nil {
-		return err
+		return 0, err
 	} else if done {
-		return nil
+		return generation, nil
 	} else {
 		// Shouldn't happen - we always return a non-nil error with false
-		return wait.ErrWaitTimeout
+		return 0, wait.ErrWaitTimeout
 	}
 }
 
+// ReadFileVersion implements VersionedPath::ReadFileVersion; the version is the object's generation.
+func (p *GSPath) ReadFileVersion(ctx context.Context) ([]byte, string, error) {
+	klog.V(4).Infof("Reading file %q", p)
+
+	client, err := p.getStorageClient(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// We read the metadata first, and then download that specific generation,
+	// so the contents always correspond to the version we return.
+	obj, err := client.Objects.Get(p.bucket, p.key).Context(ctx).Do()
+	if err != nil {
+		if isGCSNotFound(err) {
+			return nil, "", os.ErrNotExist
+		}
+		return nil, "", fmt.Errorf("error reading %s: %v", p, err)
+	}
+
+	response, err := client.Objects.Get(p.bucket, p.key).Generation(obj.Generation).Context(ctx).Download()
+	if err != nil {
+		if isGCSNotFound(err) {
+			return nil, "", os.ErrNotExist
+		}
+		return nil, "", fmt.Errorf("error reading %s: %v", p, err)
+	}
+	defer response.Body.Close()
+
+	var b bytes.Buffer
+	if _, err := io.Copy(&b, response.Body); err != nil {
+		return nil, "", fmt.Errorf("error reading %s: %v", p, err)
+	}
+	return b.Bytes(), strconv.FormatInt(obj.Generation, 10), nil
+}
+
+// WriteFileIfVersion implements VersionedPath::WriteFileIfVersion, using an ifGenerationMatch precondition.
+func (p *GSPath) WriteFileIfVersion(ctx context.Context, data io.ReadSeeker, acl ACL, version string) (string, error) {
+	generation, err := strconv.ParseInt(version, 10, 64)
+	if err != nil || generation == 0 {
+		return "", fmt.Errorf("invalid version %q for conditional write to %s", version, p)
+	}
+	written, err := p.writeFile(ctx, data, acl, &generation)
+	if err != nil {
+		return "", err
+	}
+	return strconv.FormatInt(written, 10), nil
+}
+
+// CreateFileIfNotExists implements VersionedPath::CreateFileIfNotExists, using an ifGenerationMatch=0 precondition.
+func (p *GSPath) CreateFileIfNotExists(ctx context.Context, data io.ReadSeeker, acl ACL) (string, error) {
+	var doesNotExist int64
+	written, err := p.writeFile(ctx, data, acl, &doesNotExist)
+	if err != nil {
+		return "", err
+	}
+	return strconv.FormatInt(written, 10), nil
+}
+
 // To prevent concurrent creates on the same file while maintaining atomicity of writes,
 // we take a process-wide lock during the operation.
 // Not a great approach, but fine for a single process (with low concurrency)
@@ -473,6 +554,11 @@ func isGCSNotFound(err error) bool {
 	return ok && ae.Code == http.StatusNotFound
 }
 
+func isGCSPreconditionFailed(err error) bool {
+	ae, ok := err.(*googleapi.Error)
+	return ok && ae.Code == http.StatusPreconditionFailed
+}
+
 func (p *GSPath) getStorageClient(ctx context.Context) (*storage.Service, error) {
 	return p.vfsContext.getGCSClient(ctx)
 }
@@ -16,11 +16,240 @@ limitations under the License.
 
 package vfs
 
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
 // KubernetesContext is the context for a Kubernetes VFS implementation
-type KubernetesContext struct{}
+type KubernetesContext struct {
+	mutex sync.Mutex
+	// client, if set, is used for all kubeconfig contexts
+	client kubernetes.Interface
+	// clients holds the clients we have built, keyed by kubeconfig context
+	clients map[string]kubernetes.Interface
+}
 
-// NewKubernetesContext builds a KubernetesContext
-// This will likely take a kubernetes rest client object (or similar) once the implementation is more complete
+// NewKubernetesContext builds a KubernetesContext.
+// Clients are built on first use, from the default kubeconfig loading rules.
 func NewKubernetesContext() *KubernetesContext {
-	return &KubernetesContext{}
+	return &KubernetesContext{
+		clients: make(map[string]kubernetes.Interface),
+	}
+}
+
+// NewKubernetesContextForClient builds a KubernetesContext that uses the specified client.
+func NewKubernetesContextForClient(client kubernetes.Interface) *KubernetesContext {
+	return &KubernetesContext{client: client}
+}
+
+// getClient returns the client for the kubeconfig context; an empty kubeContext selects the current context.
+func (c *KubernetesContext) getClient(kubeContext string) (kubernetes.Interface, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.client != nil {
+		return c.client, nil
+	}
+
+	client := c.clients[kubeContext]
+	if client == nil {
+		loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+		configOverrides := &clientcmd.ConfigOverrides{CurrentContext: kubeContext}
+		kubeConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, configOverrides)
+		config, err := kubeConfig.ClientConfig()
+		if err != nil {
+			return nil, fmt.Errorf("error loading kubeconfig for kubernetes VFS: %w", err)
+		}
+		client, err = kubernetes.NewForConfig(config)
+		if err != nil {
+			return nil, fmt.Errorf("error building kubernetes client for kubernetes VFS: %w", err)
+		}
+		c.clients[kubeContext] = client
+	}
+	return client, nil
+}
+
+func (c *KubernetesContext) secrets(kubeContext string, namespace string) (k8sObjectClient, error) {
+	client, err := c.getClient(kubeContext)
+	if err != nil {
+		return nil, err
+	}
+	return &k8sSecretClient{client: client, namespace: namespace}, nil
+}
+
+func (c *KubernetesContext) configMaps(kubeContext string, namespace string) (k8sObjectClient, error) {
+	client, err := c.getClient(kubeContext)
+	if err != nil {
+		return nil, err
+	}
+	return &k8sConfigMapClient{client: client, namespace: namespace}, nil
+}
+
+// k8sDataKey is the key in the object that holds the file contents
+const k8sDataKey = "contents"
+
+// k8sObject is the subset of a Secret or ConfigMap that we use to store a file.
+type k8sObject struct {
+	name            string
+	resourceVersion string
+	labels          map[string]string
+	annotations     map[string]string
+	data            []byte
+}
+
+// k8sObjectClient abstracts over the kinds of objects we store files in.
+type k8sObjectClient interface {
+	get(ctx context.Context, name string) (*k8sObject, error)
+	create(ctx context.Context, obj *k8sObject) (*k8sObject, error)
+	update(ctx context.Context, obj *k8sObject) (*k8sObject, error)
+	delete(ctx context.Context, name string) error
+	list(ctx context.Context, labelSelector string) ([]*k8sObject, error)
+}
+
+type k8sSecretClient struct {
+	client    kubernetes.Interface
+	namespace string
+}
+
+var _ k8sObjectClient = &k8sSecretClient{}
+
+func (c *k8sSecretClient) toSecret(obj *k8sObject) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            obj.name,
+			Namespace:       c.namespace,
+			ResourceVersion: obj.resourceVersion,
+			Labels:          obj.labels,
+			Annotations:     obj.annotations,
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: map[string][]byte{k8sDataKey: obj.data},
+	}
+}
+
+func fromSecret(secret *corev1.Secret) *k8sObject {
+	return &k8sObject{
+		name:            secret.Name,
+		resourceVersion: secret.ResourceVersion,
+		labels:          secret.Labels,
+		annotations:     secret.Annotations,
+		data:            secret.Data[k8sDataKey],
+	}
+}
+
+func (c *k8sSecretClient) get(ctx context.Context, name string) (*k8sObject, error) {
+	secret, err := c.client.CoreV1().Secrets(c.namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return fromSecret(secret), nil
+}
+
+func (c *k8sSecretClient) create(ctx context.Context, obj *k8sObject) (*k8sObject, error) {
+	secret, err := c.client.CoreV1().Secrets(c.namespace).Create(ctx, c.toSecret(obj), metav1.CreateOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return fromSecret(secret), nil
+}
+
+func (c *k8sSecretClient) update(ctx context.Context, obj *k8sObject) (*k8sObject, error) {
+	secret, err := c.client.CoreV1().Secrets(c.namespace).Update(ctx, c.toSecret(obj), metav1.UpdateOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return fromSecret(secret), nil
+}
+
+func (c *k8sSecretClient) delete(ctx context.Context, name string) error {
+	return c.client.CoreV1().Secrets(c.namespace).Delete(ctx, name, metav1.DeleteOptions{})
+}
+
+func (c *k8sSecretClient) list(ctx context.Context, labelSelector string) ([]*k8sObject, error) {
+	list, err := c.client.CoreV1().Secrets(c.namespace).List(ctx, metav1.ListOptions{LabelSelector: labelSelector})
+	if err != nil {
+		return nil, err
+	}
+	var objects []*k8sObject
+	for i := range list.Items {
+		objects = append(objects, fromSecret(&list.Items[i]))
+	}
+	return objects, nil
+}
+
+type k8sConfigMapClient struct {
+	client    kubernetes.Interface
+	namespace string
+}
+
+var _ k8sObjectClient = &k8sConfigMapClient{}
+
+func (c *k8sConfigMapClient) toConfigMap(obj *k8sObject) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            obj.name,
+			Namespace:       c.namespace,
+			ResourceVersion: obj.resourceVersion,
+			Labels:          obj.labels,
+			Annotations:     obj.annotations,
+		},
+		BinaryData: map[string][]byte{k8sDataKey: obj.data},
+	}
+}
+
+func fromConfigMap(configMap *corev1.ConfigMap) *k8sObject {
+	return &k8sObject{
+		name:            configMap.Name,
+		resourceVersion: configMap.ResourceVersion,
+		labels:          configMap.Labels,
+		annotations:     configMap.Annotations,
+		data:            configMap.BinaryData[k8sDataKey],
+	}
+}
+
+func (c *k8sConfigMapClient) get(ctx context.Context, name string) (*k8sObject, error) {
+	configMap, err := c.client.CoreV1().ConfigMaps(c.namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return fromConfigMap(configMap), nil
+}
+
+func (c *k8sConfigMapClient) create(ctx context.Context, obj *k8sObject) (*k8sObject, error) {
+	configMap, err := c.client.CoreV1().ConfigMaps(c.namespace).Create(ctx, c.toConfigMap(obj), metav1.CreateOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return fromConfigMap(configMap), nil
+}
+
+func (c *k8sConfigMapClient) update(ctx context.Context, obj *k8sObject) (*k8sObject, error) {
+	configMap, err := c.client.CoreV1().ConfigMaps(c.namespace).Update(ctx, c.toConfigMap(obj), metav1.UpdateOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return fromConfigMap(configMap), nil
+}
+
+func (c *k8sConfigMapClient) delete(ctx context.Context, name string) error {
+	return c.client.CoreV1().ConfigMaps(c.namespace).Delete(ctx, name, metav1.DeleteOptions{})
+}
+
+func (c *k8sConfigMapClient) list(ctx context.Context, labelSelector string) ([]*k8sObject, error) {
+	list, err := c.client.CoreV1().ConfigMaps(c.namespace).List(ctx, metav1.ListOptions{LabelSelector: labelSelector})
+	if err != nil {
+		return nil, err
+	}
+	var objects []*k8sObject
+	for i := range list.Items {
+		objects = append(objects, fromConfigMap(&list.Items[i]))
+	}
+	return objects, nil
 }
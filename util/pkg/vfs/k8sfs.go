@@ -17,48 +17,93 @@ limitations under the License.
 package vfs
 
 import (
-	"bytes"
 	"context"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"fmt"
 	"io"
+	"os"
 	"path"
+	"strconv"
 	"strings"
 
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/klog/v2"
 	"k8s.io/kops/util/pkg/hashing"
 )
 
-// KubernetesPath is a path for a VFS backed by the kubernetes API
-// Currently all operations are no-ops
+// KubernetesPath is a path for a VFS backed by the kubernetes API, of the form k8s://<context>/<namespace>/<key>.
+// The host of the path is the kubeconfig context, as for the kops API server state store; an empty host selects the
+// current context. Each file is stored in its own object in the namespace:
+// files under a pki or secrets directory are stored in Secrets, and all other files in ConfigMaps.
+// Files that are too large for a single object are split across additional chunk objects.
 type KubernetesPath struct {
 	k8sContext *KubernetesContext
 	host       string
+	namespace  string
 	key        string
+
+	// md5Hash is the base64 encoded MD5 hash of the contents, if known (e.g. from a listing)
+	md5Hash string
 }
 
 var (
-	_ Path    = &KubernetesPath{}
-	_ HasHash = &KubernetesPath{}
+	_ Path          = &KubernetesPath{}
+	_ HasHash       = &KubernetesPath{}
+	_ VersionedPath = &KubernetesPath{}
+)
+
+const (
+	// k8sChunkSize is the maximum number of bytes stored in a single object; objects are limited to 1MiB.
+	k8sChunkSize = 512 * 1024
+
+	// k8sMaxWriteAttempts bounds the retries of an unconditional write that races with other writers
+	k8sMaxWriteAttempts = 5
+	// k8sMaxReadAttempts bounds the retries of a read that races with a write of a chunked file
+	k8sMaxReadAttempts = 3
+
+	k8sLabelType    = "kops.k8s.io/vfs"
+	k8sLabelDir     = "kops.k8s.io/vfs-dir"
+	k8sLabelFile    = "kops.k8s.io/vfs-file"
+	k8sLabelChunkID = "kops.k8s.io/vfs-chunk-id"
+
+	k8sTypeFile  = "file"
+	k8sTypeChunk = "chunk"
+
+	k8sAnnotationPath    = "kops.k8s.io/vfs-path"
+	k8sAnnotationMD5     = "kops.k8s.io/vfs-md5"
+	k8sAnnotationChunks  = "kops.k8s.io/vfs-chunks"
+	k8sAnnotationChunkID = "kops.k8s.io/vfs-chunk-id"
 )
 
-func newKubernetesPath(k8sContext *KubernetesContext, host string, key string) *KubernetesPath {
+func newKubernetesPath(k8sContext *KubernetesContext, host string, namespace string, key string) *KubernetesPath {
 	host = strings.TrimSuffix(host, "/")
 	key = strings.TrimPrefix(key, "/")
 
 	return &KubernetesPath{
 		k8sContext: k8sContext,
 		host:       host,
+		namespace:  namespace,
 		key:        key,
 	}
 }
 
 func (p *KubernetesPath) Path() string {
-	return "k8s://" + p.host + "/" + p.key
+	return "k8s://" + p.host + "/" + p.namespace + "/" + p.key
 }
 
+// Host returns the kubeconfig context
 func (p *KubernetesPath) Host() string {
 	return p.host
 }
 
+func (p *KubernetesPath) Namespace() string {
+	return p.namespace
+}
+
 func (p *KubernetesPath) Key() string {
 	return p.key
 }
@@ -67,8 +112,79 @@ func (p *KubernetesPath) String() string {
 	return p.Path()
 }
 
+// objects returns the client for the kind of object that stores this path.
+func (p *KubernetesPath) objects() (k8sObjectClient, error) {
+	if isSensitiveKey(p.key) {
+		return p.k8sContext.secrets(p.host, p.namespace)
+	}
+	return p.k8sContext.configMaps(p.host, p.namespace)
+}
+
+// allObjects returns the clients for all the kinds of object that store files.
+func (p *KubernetesPath) allObjects() ([]k8sObjectClient, error) {
+	secrets, err := p.k8sContext.secrets(p.host, p.namespace)
+	if err != nil {
+		return nil, err
+	}
+	configMaps, err := p.k8sContext.configMaps(p.host, p.namespace)
+	if err != nil {
+		return nil, err
+	}
+	return []k8sObjectClient{configMaps, secrets}, nil
+}
+
+// isSensitiveKey returns true if the file should be stored in a Secret.
+func isSensitiveKey(key string) bool {
+	for _, token := range strings.Split(key, "/") {
+		if token == "pki" || token == "secrets" {
+			return true
+		}
+	}
+	return false
+}
+
+// k8sObjectName maps a key to a valid object name.
+func k8sObjectName(key string) string {
+	return "kops-vfs-" + k8sKeyHash(key)
+}
+
+// k8sKeyHash returns a hash of the key, suitable for use as an object name or label value.
+func k8sKeyHash(key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(hash[:])[:32]
+}
+
+// k8sDirKey returns the key of the directory containing the key.
+func k8sDirKey(key string) string {
+	dir := path.Dir(key)
+	if dir == "." || dir == "/" {
+		return ""
+	}
+	return dir
+}
+
+func k8sChunkName(name string, chunkID string, i int) string {
+	return fmt.Sprintf("%s-%s-%d", name, chunkID, i)
+}
+
 func (p *KubernetesPath) Remove() error {
-	return fmt.Errorf("KubernetesPath::Remove not supported")
+	ctx := context.TODO()
+
+	objects, err := p.objects()
+	if err != nil {
+		return err
+	}
+
+	name := k8sObjectName(p.key)
+	if err := objects.delete(ctx, name); err != nil {
+		if apierrors.IsNotFound(err) {
+			return os.ErrNotExist
+		}
+		return fmt.Errorf("error deleting %s: %w", p, err)
+	}
+
+	p.deleteChunks(ctx, objects, name, func(string) bool { return true })
+	return nil
 }
 
 func (p *KubernetesPath) RemoveAllVersions() error {
@@ -82,39 +198,317 @@ func (p *KubernetesPath) Join(relativePath ...string) Path {
 	return &KubernetesPath{
 		k8sContext: p.k8sContext,
 		host:       p.host,
+		namespace:  p.namespace,
 		key:        joined,
 	}
 }
 
+// k8sWriteMode controls the preconditions of a write.
+type k8sWriteMode int
+
+const (
+	// k8sWriteAlways creates or replaces the file
+	k8sWriteAlways k8sWriteMode = iota
+	// k8sWriteCreate only creates the file if it does not exist
+	k8sWriteCreate
+	// k8sWriteIfVersion only replaces the file if it exists, at the specified version
+	k8sWriteIfVersion
+)
+
 func (p *KubernetesPath) WriteFile(ctx context.Context, data io.ReadSeeker, acl ACL) error {
-	return fmt.Errorf("KubernetesPath::WriteFile not supported")
+	_, err := p.writeFile(ctx, data, k8sWriteAlways, "")
+	return err
 }
 
 func (p *KubernetesPath) CreateFile(ctx context.Context, data io.ReadSeeker, acl ACL) error {
-	return fmt.Errorf("KubernetesPath::CreateFile not supported")
+	_, err := p.writeFile(ctx, data, k8sWriteCreate, "")
+	return err
+}
+
+// WriteFileIfVersion implements VersionedPath::WriteFileIfVersion; the version is the resourceVersion of the object.
+// The file must already exist.
+func (p *KubernetesPath) WriteFileIfVersion(ctx context.Context, data io.ReadSeeker, acl ACL, version string) (string, error) {
+	return p.writeFile(ctx, data, k8sWriteIfVersion, version)
+}
+
+// CreateFileIfNotExists implements VersionedPath::CreateFileIfNotExists; the object is created with a create call,
+// which the apiserver rejects if it already exists.
+func (p *KubernetesPath) CreateFileIfNotExists(ctx context.Context, data io.ReadSeeker, acl ACL) (string, error) {
+	return p.writeFile(ctx, data, k8sWriteCreate, "")
+}
+
+// writeFile writes the contents, returning the resourceVersion of the object that was written.
+// Any additional chunks are written before the object that references them,
+// so readers never see a file with missing chunks.
+func (p *KubernetesPath) writeFile(ctx context.Context, r io.ReadSeeker, mode k8sWriteMode, version string) (string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return "", fmt.Errorf("error reading data: %w", err)
+	}
+
+	objects, err := p.objects()
+	if err != nil {
+		return "", err
+	}
+
+	klog.V(4).Infof("Writing file %q", p)
+
+	name := k8sObjectName(p.key)
+	md5Hash := md5.Sum(data)
+
+	first := data
+	var chunks [][]byte
+	if len(data) > k8sChunkSize {
+		first = data[:k8sChunkSize]
+		for rest := data[k8sChunkSize:]; len(rest) != 0; {
+			n := len(rest)
+			if n > k8sChunkSize {
+				n = k8sChunkSize
+			}
+			chunks = append(chunks, rest[:n])
+			rest = rest[n:]
+		}
+	}
+
+	chunkID := ""
+	if len(chunks) != 0 {
+		chunkID, err = newChunkID()
+		if err != nil {
+			return "", err
+		}
+		for i, chunk := range chunks {
+			obj := &k8sObject{
+				name: k8sChunkName(name, chunkID, i+1),
+				labels: map[string]string{
+					k8sLabelType:    k8sTypeChunk,
+					k8sLabelFile:    name,
+					k8sLabelChunkID: chunkID,
+				},
+				data: chunk,
+			}
+			if _, err := objects.create(ctx, obj); err != nil {
+				p.deleteChunks(ctx, objects, name, func(id string) bool { return id == chunkID })
+				return "", fmt.Errorf("error writing %s: %w", p, err)
+			}
+		}
+	}
+
+	primary := &k8sObject{
+		name: name,
+		labels: map[string]string{
+			k8sLabelType: k8sTypeFile,
+			k8sLabelDir:  k8sKeyHash(k8sDirKey(p.key)),
+		},
+		annotations: map[string]string{
+			k8sAnnotationPath:    p.key,
+			k8sAnnotationMD5:     base64.StdEncoding.EncodeToString(md5Hash[:]),
+			k8sAnnotationChunks:  strconv.Itoa(len(chunks)),
+			k8sAnnotationChunkID: chunkID,
+		},
+		data: first,
+	}
+
+	written, err := p.writePrimary(ctx, objects, primary, mode, version)
+	if err != nil {
+		p.deleteChunks(ctx, objects, name, func(id string) bool { return id == chunkID })
+		return "", err
+	}
+
+	// Clean up the chunks of the contents we replaced
+	p.deleteChunks(ctx, objects, name, func(id string) bool { return id != chunkID })
+
+	return written.resourceVersion, nil
+}
+
+// writePrimary creates or updates the object holding the file, according to the write mode.
+func (p *KubernetesPath) writePrimary(ctx context.Context, objects k8sObjectClient, primary *k8sObject, mode k8sWriteMode, version string) (*k8sObject, error) {
+	for attempt := 1; ; attempt++ {
+		existing, err := objects.get(ctx, primary.name)
+		if err != nil && !apierrors.IsNotFound(err) {
+			return nil, fmt.Errorf("error reading %s: %w", p, err)
+		}
+		exists := err == nil
+
+		switch mode {
+		case k8sWriteCreate:
+			if exists {
+				return nil, os.ErrExist
+			}
+		case k8sWriteIfVersion:
+			if !exists {
+				return nil, os.ErrNotExist
+			}
+			if existing.resourceVersion != version {
+				return nil, fmt.Errorf("error writing %s: %w", p, ErrVersionConflict)
+			}
+		}
+
+		var written *k8sObject
+		if !exists {
+			primary.resourceVersion = ""
+			written, err = objects.create(ctx, primary)
+			if err != nil && apierrors.IsAlreadyExists(err) {
+				if mode == k8sWriteCreate {
+					return nil, os.ErrExist
+				}
+				if attempt < k8sMaxWriteAttempts {
+					continue
+				}
+			}
+		} else {
+			primary.resourceVersion = existing.resourceVersion
+			written, err = objects.update(ctx, primary)
+			if err != nil && apierrors.IsConflict(err) {
+				if mode == k8sWriteIfVersion {
+					return nil, fmt.Errorf("error writing %s: %w", p, ErrVersionConflict)
+				}
+				if attempt < k8sMaxWriteAttempts {
+					continue
+				}
+			}
+			if err != nil && apierrors.IsNotFound(err) && mode == k8sWriteIfVersion {
+				return nil, os.ErrNotExist
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error writing %s: %w", p, err)
+		}
+		return written, nil
+	}
+}
+
+// deleteChunks removes the chunk objects of the file whose chunk ID matches.
+// Failures are only logged; a leftover chunk is never read, and is removed by the next write.
+func (p *KubernetesPath) deleteChunks(ctx context.Context, objects k8sObjectClient, name string, match func(chunkID string) bool) {
+	chunks, err := objects.list(ctx, k8sLabelType+"="+k8sTypeChunk+","+k8sLabelFile+"="+name)
+	if err != nil {
+		klog.Warningf("error listing chunks of %s: %v", p, err)
+		return
+	}
+	for _, chunk := range chunks {
+		if !match(chunk.labels[k8sLabelChunkID]) {
+			continue
+		}
+		if err := objects.delete(ctx, chunk.name); err != nil && !apierrors.IsNotFound(err) {
+			klog.Warningf("error deleting chunk %s of %s: %v", chunk.name, p, err)
+		}
+	}
 }
 
 // ReadFile implements Path::ReadFile
 func (p *KubernetesPath) ReadFile(ctx context.Context) ([]byte, error) {
-	var b bytes.Buffer
-	_, err := p.WriteTo(&b)
+	data, _, err := p.ReadFileVersion(ctx)
+	return data, err
+}
+
+// ReadFileVersion implements VersionedPath::ReadFileVersion; the version is the resourceVersion of the object.
+func (p *KubernetesPath) ReadFileVersion(ctx context.Context) ([]byte, string, error) {
+	objects, err := p.objects()
 	if err != nil {
-		return nil, err
+		return nil, "", err
+	}
+
+	klog.V(4).Infof("Reading file %q", p)
+
+	name := k8sObjectName(p.key)
+	for attempt := 1; ; attempt++ {
+		obj, err := objects.get(ctx, name)
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				return nil, "", os.ErrNotExist
+			}
+			return nil, "", fmt.Errorf("error reading %s: %w", p, err)
+		}
+
+		chunkCount := 0
+		if s := obj.annotations[k8sAnnotationChunks]; s != "" {
+			chunkCount, err = strconv.Atoi(s)
+			if err != nil {
+				return nil, "", fmt.Errorf("error reading %s: invalid chunk count %q", p, s)
+			}
+		}
+
+		data := obj.data
+		complete := true
+		for i := 1; i <= chunkCount; i++ {
+			chunk, err := objects.get(ctx, k8sChunkName(name, obj.annotations[k8sAnnotationChunkID], i))
+			if err != nil {
+				if apierrors.IsNotFound(err) {
+					// The file was replaced while we were reading it
+					complete = false
+					break
+				}
+				return nil, "", fmt.Errorf("error reading %s: %w", p, err)
+			}
+			data = append(data, chunk.data...)
+		}
+		if complete {
+			return data, obj.resourceVersion, nil
+		}
+		if attempt >= k8sMaxReadAttempts {
+			return nil, "", fmt.Errorf("error reading %s: file changed while it was being read", p)
+		}
 	}
-	return b.Bytes(), nil
 }
 
 // WriteTo implements io.WriterTo
 func (p *KubernetesPath) WriteTo(out io.Writer) (int64, error) {
-	return 0, fmt.Errorf("KubernetesPath::WriteTo not supported")
+	data, err := p.ReadFile(context.TODO())
+	if err != nil {
+		return 0, err
+	}
+	n, err := out.Write(data)
+	return int64(n), err
 }
 
+// ReadDir implements Path::ReadDir, returning the files directly in this directory.
 func (p *KubernetesPath) ReadDir() ([]Path, error) {
-	return nil, fmt.Errorf("KubernetesPath::ReadDir not supported")
+	return p.listFiles(k8sLabelType+"="+k8sTypeFile+","+k8sLabelDir+"="+k8sKeyHash(p.key), func(key string) bool {
+		return k8sDirKey(key) == p.key
+	})
 }
 
+// ReadTree implements Path::ReadTree, returning all the files under this directory.
 func (p *KubernetesPath) ReadTree() ([]Path, error) {
-	return nil, fmt.Errorf("KubernetesPath::ReadTree not supported")
+	prefix := p.key
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	return p.listFiles(k8sLabelType+"="+k8sTypeFile, func(key string) bool {
+		return strings.HasPrefix(key, prefix)
+	})
+}
+
+func (p *KubernetesPath) listFiles(selector string, match func(key string) bool) ([]Path, error) {
+	ctx := context.TODO()
+
+	allObjects, err := p.allObjects()
+	if err != nil {
+		return nil, err
+	}
+
+	var paths []Path
+	for _, objects := range allObjects {
+		list, err := objects.list(ctx, selector)
+		if err != nil {
+			return nil, fmt.Errorf("error listing %s: %w", p, err)
+		}
+		for _, obj := range list {
+			key := obj.annotations[k8sAnnotationPath]
+			if key == "" || !match(key) {
+				continue
+			}
+			paths = append(paths, &KubernetesPath{
+				k8sContext: p.k8sContext,
+				host:       p.host,
+				namespace:  p.namespace,
+				key:        key,
+				md5Hash:    obj.annotations[k8sAnnotationMD5],
+			})
+		}
+	}
+	klog.V(8).Infof("Listed files in %v: %v", p, paths)
+	return paths, nil
 }
 
 func (p *KubernetesPath) Base() string {
@@ -126,5 +520,41 @@ func (p *KubernetesPath) PreferredHash() (*hashing.Hash, error) {
 }
 
 func (p *KubernetesPath) Hash(a hashing.HashAlgorithm) (*hashing.Hash, error) {
-	return nil, fmt.Errorf("KubernetesPath::Hash not supported")
+	if a != hashing.HashAlgorithmMD5 {
+		return nil, nil
+	}
+
+	md5Hash := p.md5Hash
+	if md5Hash == "" {
+		objects, err := p.objects()
+		if err != nil {
+			return nil, err
+		}
+		obj, err := objects.get(context.TODO(), k8sObjectName(p.key))
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				return nil, os.ErrNotExist
+			}
+			return nil, fmt.Errorf("error reading %s: %w", p, err)
+		}
+		md5Hash = obj.annotations[k8sAnnotationMD5]
+	}
+	if md5Hash == "" {
+		return nil, nil
+	}
+
+	md5Bytes, err := base64.StdEncoding.DecodeString(md5Hash)
+	if err != nil {
+		return nil, fmt.Errorf("not valid MD5 sum: %q", md5Hash)
+	}
+
+	return &hashing.Hash{Algorithm: hashing.HashAlgorithmMD5, HashValue: md5Bytes}, nil
+}
+
+func newChunkID() (string, error) {
+	b := make([]byte, 4)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("error generating chunk id: %w", err)
+	}
+	return hex.EncodeToString(b), nil
 }
@@ -0,0 +1,352 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vfs
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"os"
+	"sort"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/kops/util/pkg/hashing"
+)
+
+func newTestKubernetesPath(t *testing.T, p string) (*KubernetesPath, *fake.Clientset) {
+	client := fake.NewSimpleClientset()
+	c := NewVFSContext()
+	c.ResetKubernetesContext(client)
+	k8sPath, err := c.buildKubernetesPath(p)
+	if err != nil {
+		t.Fatalf("error building path %q: %v", p, err)
+	}
+	return k8sPath, client
+}
+
+func TestBuildKubernetesPath(t *testing.T) {
+	grid := []struct {
+		path      string
+		host      string
+		namespace string
+		key       string
+		expectErr bool
+	}{
+		{
+			// As for the kops API server state store, the host is the kubeconfig context
+			path:      "k8s://my-context/kops-system/cluster.example.com/config",
+			host:      "my-context",
+			namespace: "kops-system",
+			key:       "cluster.example.com/config",
+		},
+		{
+			path:      "k8s:///kops-system/",
+			host:      "",
+			namespace: "kops-system",
+			key:       "",
+		},
+		{
+			path:      "k8s:///kops-system",
+			host:      "",
+			namespace: "kops-system",
+			key:       "",
+		},
+		{
+			// The kops API server state store has no namespace
+			path:      "k8s://my-context",
+			expectErr: true,
+		},
+		{
+			path:      "k8s://",
+			expectErr: true,
+		},
+	}
+	for _, g := range grid {
+		t.Run(g.path, func(t *testing.T) {
+			c := NewVFSContext()
+			p, err := c.buildKubernetesPath(g.path)
+			if g.expectErr {
+				if err == nil {
+					t.Fatalf("expected error building path %q, got %v", g.path, p)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("error building path %q: %v", g.path, err)
+			}
+			if p.Host() != g.host {
+				t.Errorf("expected host %q, got %q", g.host, p.Host())
+			}
+			if p.Namespace() != g.namespace {
+				t.Errorf("expected namespace %q, got %q", g.namespace, p.Namespace())
+			}
+			if p.Key() != g.key {
+				t.Errorf("expected key %q, got %q", g.key, p.Key())
+			}
+		})
+	}
+}
+
+func TestKubernetesPathReadWrite(t *testing.T) {
+	ctx := context.TODO()
+
+	large := make([]byte, 2*k8sChunkSize+100)
+	for i := range large {
+		large[i] = byte(i % 251)
+	}
+
+	grid := []struct {
+		name       string
+		key        string
+		data       []byte
+		secret     bool
+		wantChunks int
+	}{
+		{
+			name: "small config",
+			key:  "cluster.example.com/config",
+			data: []byte("hello"),
+		},
+		{
+			name:   "secret",
+			key:    "cluster.example.com/secrets/admin",
+			data:   []byte("password"),
+			secret: true,
+		},
+		{
+			name:   "keyset",
+			key:    "cluster.example.com/pki/private/kubernetes-ca/keyset.yaml",
+			data:   []byte("keyset"),
+			secret: true,
+		},
+		{
+			name:       "chunked",
+			key:        "cluster.example.com/instancegroup/nodes",
+			data:       large,
+			wantChunks: 2,
+		},
+	}
+	for _, g := range grid {
+		t.Run(g.name, func(t *testing.T) {
+			root, client := newTestKubernetesPath(t, "k8s:///kops-system/")
+			p := root.Join(g.key)
+
+			if _, err := p.ReadFile(ctx); !os.IsNotExist(err) {
+				t.Fatalf("expected not-exist reading missing file, got %v", err)
+			}
+
+			if err := p.WriteFile(ctx, bytes.NewReader(g.data), nil); err != nil {
+				t.Fatalf("error writing file: %v", err)
+			}
+
+			secrets, err := client.CoreV1().Secrets("kops-system").List(ctx, metav1.ListOptions{})
+			if err != nil {
+				t.Fatalf("error listing secrets: %v", err)
+			}
+			configMaps, err := client.CoreV1().ConfigMaps("kops-system").List(ctx, metav1.ListOptions{})
+			if err != nil {
+				t.Fatalf("error listing configmaps: %v", err)
+			}
+			wantObjects := 1 + g.wantChunks
+			if g.secret {
+				if len(secrets.Items) != wantObjects || len(configMaps.Items) != 0 {
+					t.Errorf("expected %d secrets and no configmaps, got %d and %d", wantObjects, len(secrets.Items), len(configMaps.Items))
+				}
+			} else {
+				if len(configMaps.Items) != wantObjects || len(secrets.Items) != 0 {
+					t.Errorf("expected %d configmaps and no secrets, got %d and %d", wantObjects, len(configMaps.Items), len(secrets.Items))
+				}
+			}
+
+			actual, err := p.ReadFile(ctx)
+			if err != nil {
+				t.Fatalf("error reading file: %v", err)
+			}
+			if !bytes.Equal(actual, g.data) {
+				t.Errorf("unexpected contents after round trip (%d bytes, expected %d)", len(actual), len(g.data))
+			}
+
+			// Overwriting with smaller contents must clean up the chunks
+			if err := p.WriteFile(ctx, bytes.NewReader([]byte("replaced")), nil); err != nil {
+				t.Fatalf("error overwriting file: %v", err)
+			}
+			actual, err = p.ReadFile(ctx)
+			if err != nil {
+				t.Fatalf("error reading file: %v", err)
+			}
+			if string(actual) != "replaced" {
+				t.Errorf("unexpected contents after overwrite: %q", actual)
+			}
+
+			if err := p.Remove(); err != nil {
+				t.Fatalf("error removing file: %v", err)
+			}
+			if err := p.Remove(); !os.IsNotExist(err) {
+				t.Errorf("expected not-exist removing missing file, got %v", err)
+			}
+			configMaps, _ = client.CoreV1().ConfigMaps("kops-system").List(ctx, metav1.ListOptions{})
+			secrets, _ = client.CoreV1().Secrets("kops-system").List(ctx, metav1.ListOptions{})
+			if len(configMaps.Items) != 0 || len(secrets.Items) != 0 {
+				t.Errorf("expected all objects to be removed, found %d configmaps and %d secrets", len(configMaps.Items), len(secrets.Items))
+			}
+		})
+	}
+}
+
+func TestKubernetesPathCreateFile(t *testing.T) {
+	ctx := context.TODO()
+	root, _ := newTestKubernetesPath(t, "k8s:///kops-system/")
+	p := root.Join("cluster.example.com", "config")
+
+	if err := p.CreateFile(ctx, bytes.NewReader([]byte("first")), nil); err != nil {
+		t.Fatalf("error creating file: %v", err)
+	}
+	if err := p.CreateFile(ctx, bytes.NewReader([]byte("second")), nil); !os.IsExist(err) {
+		t.Fatalf("expected exists error creating existing file, got %v", err)
+	}
+
+	actual, err := p.ReadFile(ctx)
+	if err != nil {
+		t.Fatalf("error reading file: %v", err)
+	}
+	if string(actual) != "first" {
+		t.Errorf("unexpected contents %q", actual)
+	}
+
+	missing := root.Join("cluster.example.com", "missing").(*KubernetesPath)
+	if _, err := missing.WriteFileIfVersion(ctx, bytes.NewReader([]byte("data")), nil, ""); !os.IsNotExist(err) {
+		t.Errorf("expected not-exist writing missing file conditionally, got %v", err)
+	}
+}
+
+func TestKubernetesPathReadDirAndTree(t *testing.T) {
+	ctx := context.TODO()
+	root, _ := newTestKubernetesPath(t, "k8s:///kops-system/")
+
+	files := []string{
+		"cluster.example.com/config",
+		"cluster.example.com/instancegroup/master",
+		"cluster.example.com/instancegroup/nodes",
+		"cluster.example.com/pki/issued/ca/keyset.yaml",
+		"other.example.com/config",
+	}
+	for _, f := range files {
+		if err := root.Join(f).WriteFile(ctx, bytes.NewReader([]byte(f)), nil); err != nil {
+			t.Fatalf("error writing %s: %v", f, err)
+		}
+	}
+
+	grid := []struct {
+		name   string
+		dir    string
+		tree   bool
+		expect []string
+	}{
+		{
+			name:   "readdir",
+			dir:    "cluster.example.com/instancegroup",
+			expect: []string{"k8s:///kops-system/cluster.example.com/instancegroup/master", "k8s:///kops-system/cluster.example.com/instancegroup/nodes"},
+		},
+		{
+			name:   "readdir files only",
+			dir:    "cluster.example.com",
+			expect: []string{"k8s:///kops-system/cluster.example.com/config"},
+		},
+		{
+			name: "readdir empty",
+			dir:  "missing.example.com",
+		},
+		{
+			name: "readtree",
+			dir:  "cluster.example.com",
+			tree: true,
+			expect: []string{
+				"k8s:///kops-system/cluster.example.com/config",
+				"k8s:///kops-system/cluster.example.com/instancegroup/master",
+				"k8s:///kops-system/cluster.example.com/instancegroup/nodes",
+				"k8s:///kops-system/cluster.example.com/pki/issued/ca/keyset.yaml",
+			},
+		},
+	}
+	for _, g := range grid {
+		t.Run(g.name, func(t *testing.T) {
+			dir := root.Join(g.dir)
+			var paths []Path
+			var err error
+			if g.tree {
+				paths, err = dir.ReadTree()
+			} else {
+				paths, err = dir.ReadDir()
+			}
+			if err != nil {
+				t.Fatalf("error listing %s: %v", dir, err)
+			}
+			var actual []string
+			for _, p := range paths {
+				actual = append(actual, p.Path())
+			}
+			sort.Strings(actual)
+			if len(actual) != len(g.expect) {
+				t.Fatalf("expected %v, got %v", g.expect, actual)
+			}
+			for i := range actual {
+				if actual[i] != g.expect[i] {
+					t.Errorf("expected %v, got %v", g.expect, actual)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestKubernetesPathHash(t *testing.T) {
+	ctx := context.TODO()
+	root, _ := newTestKubernetesPath(t, "k8s:///kops-system/")
+	p := root.Join("cluster.example.com", "config")
+
+	data := []byte("contents")
+	if err := p.WriteFile(ctx, bytes.NewReader(data), nil); err != nil {
+		t.Fatalf("error writing file: %v", err)
+	}
+	expected := md5.Sum(data)
+
+	hash, err := p.(HasHash).Hash(hashing.HashAlgorithmMD5)
+	if err != nil {
+		t.Fatalf("error getting hash: %v", err)
+	}
+	if hash == nil || !bytes.Equal(hash.HashValue, expected[:]) {
+		t.Errorf("unexpected hash %v", hash)
+	}
+
+	// The hash should also be available on listed paths
+	children, err := root.Join("cluster.example.com").ReadDir()
+	if err != nil {
+		t.Fatalf("error listing directory: %v", err)
+	}
+	if len(children) != 1 {
+		t.Fatalf("expected one child, got %v", children)
+	}
+	hash, err = children[0].(HasHash).Hash(hashing.HashAlgorithmMD5)
+	if err != nil {
+		t.Fatalf("error getting hash: %v", err)
+	}
+	if hash == nil || !bytes.Equal(hash.HashValue, expected[:]) {
+		t.Errorf("unexpected hash for listed path %v", hash)
+	}
+}
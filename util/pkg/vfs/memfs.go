@@ -22,6 +22,7 @@ import (
 	"io"
 	"os"
 	"path"
+	"strconv"
 	"strings"
 	"sync"
 
@@ -36,11 +37,15 @@ type MemFSPath struct {
 	mutex    sync.Mutex
 	contents []byte
 	children map[string]*MemFSPath
+
+	// generation is incremented on every write, and is used as the version for conditional writes
+	generation int64
 }
 
 var (
 	_ Path          = &MemFSPath{}
 	_ TerraformPath = &MemFSPath{}
+	_ VersionedPath = &MemFSPath{}
 )
 
 type MemFSContext struct {
@@ -110,22 +115,41 @@ func (p *MemFSPath) WriteFile(ctx context.Context, r io.ReadSeeker, acl ACL) err
 	if err != nil {
 		return fmt.Errorf("error reading data: %v", err)
 	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
 	p.contents = data
 	p.acl = acl
+	p.generation++
 	return nil
 }
 
-func (p *MemFSPath) CreateFile(ctx context.Context, data io.ReadSeeker, acl ACL) error {
+func (p *MemFSPath) CreateFile(ctx context.Context, r io.ReadSeeker, acl ACL) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("error reading data: %v", err)
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
 	// Check if exists
 	if p.contents != nil {
 		return os.ErrExist
 	}
 
-	return p.WriteFile(ctx, data, acl)
+	p.contents = data
+	p.acl = acl
+	p.generation++
+	return nil
 }
 
 // ReadFile implements Path::ReadFile
 func (p *MemFSPath) ReadFile(ctx context.Context) ([]byte, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
 	if p.contents == nil {
 		return nil, os.ErrNotExist
 	}
@@ -133,8 +157,55 @@ func (p *MemFSPath) ReadFile(ctx context.Context) ([]byte, error) {
 	return p.contents, nil
 }
 
+// ReadFileVersion implements VersionedPath::ReadFileVersion
+func (p *MemFSPath) ReadFileVersion(ctx context.Context) ([]byte, string, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.contents == nil {
+		return nil, "", os.ErrNotExist
+	}
+	return p.contents, strconv.FormatInt(p.generation, 10), nil
+}
+
+// WriteFileIfVersion implements VersionedPath::WriteFileIfVersion
+func (p *MemFSPath) WriteFileIfVersion(ctx context.Context, r io.ReadSeeker, acl ACL, version string) (string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return "", fmt.Errorf("error reading data: %v", err)
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.contents == nil {
+		return "", os.ErrNotExist
+	}
+	if strconv.FormatInt(p.generation, 10) != version {
+		return "", fmt.Errorf("error writing %s: %w", p, ErrVersionConflict)
+	}
+	p.contents = data
+	p.acl = acl
+	p.generation++
+	return strconv.FormatInt(p.generation, 10), nil
+}
+
+// CreateFileIfNotExists implements VersionedPath::CreateFileIfNotExists
+func (p *MemFSPath) CreateFileIfNotExists(ctx context.Context, r io.ReadSeeker, acl ACL) (string, error) {
+	if err := p.CreateFile(ctx, r, acl); err != nil {
+		return "", err
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return strconv.FormatInt(p.generation, 10), nil
+}
+
 // WriteTo implements io.WriterTo
 func (p *MemFSPath) WriteTo(out io.Writer) (int64, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
 	if p.contents == nil {
 		return 0, os.ErrNotExist
 	}
@@ -164,13 +235,22 @@ func (p *MemFSPath) readTree(dest *[]Path) {
 	defer p.mutex.Unlock()
 
 	for _, f := range p.children {
-		if !f.HasChildren() {
+		// Paths that have been joined but never written are not files
+		if f.isFile() {
 			*dest = append(*dest, f)
 		}
 		f.readTree(dest)
 	}
 }
 
+// isFile returns true if the path has been written and has no children.
+func (p *MemFSPath) isFile() bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	return len(p.children) == 0 && p.contents != nil
+}
+
 func (p *MemFSPath) Base() string {
 	return path.Base(p.location)
 }
@@ -184,7 +264,12 @@ func (p *MemFSPath) String() string {
 }
 
 func (p *MemFSPath) Remove() error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
 	p.contents = nil
+	// A file written again after it was removed must not match the version it had before
+	p.generation++
 	return nil
 }
 
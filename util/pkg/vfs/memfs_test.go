@@ -18,9 +18,11 @@ package vfs
 
 import (
 	"bytes"
+	"errors"
 	"os"
 	"reflect"
 	"sort"
+	"strings"
 	"testing"
 
 	"k8s.io/kops/pkg/testutils/testcontext"
@@ -117,10 +119,13 @@ func TestMemFsReadDir(t *testing.T) {
 }
 
 func TestMemFsReadTree(t *testing.T) {
+	ctx := testcontext.ForTest(t)
+
 	tests := []struct {
-		path     string
-		subpaths []string
-		expected []string
+		path      string
+		subpaths  []string
+		unwritten []string
+		expected  []string
 	}{
 		{
 			path: "/root/dir/",
@@ -130,6 +135,9 @@ func TestMemFsReadTree(t *testing.T) {
 				"subdir2/",
 				"subdir2/test2.data",
 			},
+			unwritten: []string{
+				"subdir/missing.data",
+			},
 			expected: []string{
 				"/root/dir/subdir/test1.data",
 				"/root/dir/subdir2/test2.data",
@@ -140,8 +148,18 @@ func TestMemFsReadTree(t *testing.T) {
 		context := NewMemFSContext()
 		memfspath := NewMemFSPath(context, test.path)
 
-		// Create sub-paths
+		// Create sub-paths, writing the files
 		for _, subpath := range test.subpaths {
+			p := memfspath.Join(subpath)
+			if !strings.HasSuffix(subpath, "/") {
+				if err := p.WriteFile(ctx, bytes.NewReader([]byte("test data")), nil); err != nil {
+					t.Fatalf("Failed writing file %s: %v", p, err)
+				}
+			}
+		}
+
+		// Paths that are joined but never written are not files
+		for _, subpath := range test.unwritten {
 			memfspath.Join(subpath)
 		}
 
@@ -167,3 +185,31 @@ func TestMemFsReadTree(t *testing.T) {
 		}
 	}
 }
+
+func TestMemFsRemoveChangesVersion(t *testing.T) {
+	ctx := testcontext.ForTest(t)
+
+	memfspath := NewMemFSPath(NewMemFSContext(), "/root/lock")
+	if err := memfspath.CreateFile(ctx, bytes.NewReader([]byte("first")), nil); err != nil {
+		t.Fatalf("Failed creating file: %v", err)
+	}
+	_, version, err := memfspath.ReadFileVersion(ctx)
+	if err != nil {
+		t.Fatalf("Failed reading file: %v", err)
+	}
+
+	if err := memfspath.Remove(); err != nil {
+		t.Fatalf("Failed removing file: %v", err)
+	}
+	if _, err := memfspath.WriteFileIfVersion(ctx, bytes.NewReader([]byte("second")), nil, version); !os.IsNotExist(err) {
+		t.Errorf("Expected to get os.ErrNotExist writing a removed file, got: %v", err)
+	}
+
+	if err := memfspath.WriteFile(ctx, bytes.NewReader([]byte("third")), nil); err != nil {
+		t.Fatalf("Failed writing file: %v", err)
+	}
+	_, err = memfspath.WriteFileIfVersion(ctx, bytes.NewReader([]byte("fourth")), nil, version)
+	if !errors.Is(err, ErrVersionConflict) {
+		t.Errorf("Expected to get ErrVersionConflict writing with the version from before the removal, got: %v", err)
+	}
+}
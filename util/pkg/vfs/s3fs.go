@@ -54,6 +54,7 @@ var (
 	_ Path          = &S3Path{}
 	_ TerraformPath = &S3Path{}
 	_ HasHash       = &S3Path{}
+	_ VersionedPath = &S3Path{}
 )
 
 // S3Acl is an ACL implementation for objects on S3
@@ -241,9 +242,17 @@ func (p *S3Path) getRequestACL(aclObj ACL) (*string, error) {
 }
 
 func (p *S3Path) WriteFile(ctx context.Context, data io.ReadSeeker, aclObj ACL) error {
+	_, err := p.writeFile(ctx, data, aclObj, "", "")
+	return err
+}
+
+// writeFile uploads the object, returning its new ETag.  If ifMatch is not empty,
+// the write is conditional on the object's current ETag matching it.  If ifNoneMatch is "*",
+// the write is conditional on the object not existing.
+func (p *S3Path) writeFile(ctx context.Context, data io.ReadSeeker, aclObj ACL, ifMatch string, ifNoneMatch string) (string, error) {
 	client, err := p.client(ctx)
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	klog.V(4).Infof("Writing file %q", p)
@@ -258,22 +267,70 @@ func (p *S3Path) WriteFile(ctx context.Context, data io.ReadSeeker, aclObj ACL)
 
 	request.ACL, err = p.getRequestACL(aclObj)
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	// We don't need Content-MD5: https://github.com/aws/aws-sdk-go/issues/208
 
-	klog.V(8).Infof("Calling S3 PutObject Bucket=%q Key=%q SSE=%q ACL=%q", p.bucket, p.key, sseLog, aws.StringValue(request.ACL))
+	klog.V(8).Infof("Calling S3 PutObject Bucket=%q Key=%q SSE=%q ACL=%q IfMatch=%q IfNoneMatch=%q", p.bucket, p.key, sseLog, aws.StringValue(request.ACL), ifMatch, ifNoneMatch)
 
-	_, err = client.PutObjectWithContext(ctx, request)
+	req, response := client.PutObjectRequest(request)
+	req.SetContext(ctx)
+	if ifMatch != "" {
+		// The SDK does not model conditional writes, so we set the header directly
+		req.HTTPRequest.Header.Set("If-Match", ifMatch)
+	}
+	if ifNoneMatch != "" {
+		req.HTTPRequest.Header.Set("If-None-Match", ifNoneMatch)
+	}
+	err = req.Send()
 	if err != nil {
+		if ifNoneMatch != "" && AWSErrorCode(err) == "PreconditionFailed" {
+			return "", os.ErrExist
+		}
+		if ifMatch != "" && AWSErrorCode(err) == "PreconditionFailed" {
+			return "", fmt.Errorf("error writing %s: %w", p, ErrVersionConflict)
+		}
+		if (ifMatch != "" || ifNoneMatch != "") && AWSErrorCode(err) == "ConditionalRequestConflict" {
+			// A concurrent conditional write to the same key is in progress
+			return "", fmt.Errorf("error writing %s: %w", p, ErrVersionConflict)
+		}
 		if request.ACL != nil {
-			return fmt.Errorf("error writing %s (with ACL=%q): %v", p, aws.StringValue(request.ACL), err)
+			return "", fmt.Errorf("error writing %s (with ACL=%q): %v", p, aws.StringValue(request.ACL), err)
 		}
-		return fmt.Errorf("error writing %s: %v", p, err)
+		return "", fmt.Errorf("error writing %s: %v", p, err)
 	}
 
-	return nil
+	return aws.StringValue(response.ETag), nil
+}
+
+// ReadFileVersion implements VersionedPath::ReadFileVersion; the version is the object's ETag.
+func (p *S3Path) ReadFileVersion(ctx context.Context) ([]byte, string, error) {
+	var b bytes.Buffer
+	response, err := p.getObject(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+	defer response.Body.Close()
+
+	if _, err := io.Copy(&b, response.Body); err != nil {
+		return nil, "", fmt.Errorf("error reading %s: %v", p, err)
+	}
+	return b.Bytes(), aws.StringValue(response.ETag), nil
+}
+
+// WriteFileIfVersion implements VersionedPath::WriteFileIfVersion, using an If-Match precondition on the ETag.
+func (p *S3Path) WriteFileIfVersion(ctx context.Context, data io.ReadSeeker, acl ACL, version string) (string, error) {
+	if version == "" {
+		return "", fmt.Errorf("version is required for conditional write to %s", p)
+	}
+	return p.writeFile(ctx, data, acl, version, "")
+}
+
+// CreateFileIfNotExists implements VersionedPath::CreateFileIfNotExists, using an If-None-Match precondition.
+// S3-compatible stores that ignore the precondition make this an unconditional write.
+func (p *S3Path) CreateFileIfNotExists(ctx context.Context, data io.ReadSeeker, acl ACL) (string, error) {
+	return p.writeFile(ctx, data, acl, "", "*")
 }
 
 // To prevent concurrent creates on the same file while maintaining atomicity of writes,
@@ -312,10 +369,25 @@ func (p *S3Path) ReadFile(ctx context.Context) ([]byte, error) {
 // WriteTo implements io.WriterTo
 func (p *S3Path) WriteTo(out io.Writer) (int64, error) {
 	ctx := context.TODO()
-	client, err := p.client(ctx)
+	response, err := p.getObject(ctx)
 	if err != nil {
 		return 0, err
 	}
+	defer response.Body.Close()
+
+	n, err := io.Copy(out, response.Body)
+	if err != nil {
+		return n, fmt.Errorf("error reading %s: %v", p, err)
+	}
+	return n, nil
+}
+
+// getObject starts reading the object; the caller must close the response body.
+func (p *S3Path) getObject(ctx context.Context) (*s3.GetObjectOutput, error) {
+	client, err := p.client(ctx)
+	if err != nil {
+		return nil, err
+	}
 
 	klog.V(4).Infof("Reading file %q", p)
 
@@ -326,17 +398,11 @@ func (p *S3Path) WriteTo(out io.Writer) (int64, error) {
 	response, err := client.GetObjectWithContext(ctx, request)
 	if err != nil {
 		if AWSErrorCode(err) == "NoSuchKey" {
-			return 0, os.ErrNotExist
+			return nil, os.ErrNotExist
 		}
-		return 0, fmt.Errorf("error fetching %s: %v", p, err)
+		return nil, fmt.Errorf("error fetching %s: %v", p, err)
 	}
-	defer response.Body.Close()
-
-	n, err := io.Copy(out, response.Body)
-	if err != nil {
-		return n, fmt.Errorf("error reading %s: %v", p, err)
-	}
-	return n, nil
+	return response, nil
 }
 
 func (p *S3Path) ReadDir() ([]Path, error) {
@@ -0,0 +1,101 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vfs
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrVersionConflict is returned (wrapped) when a conditional write fails because the file has changed.
+var ErrVersionConflict = errors.New("file has been modified since it was read")
+
+// IsVersionConflict returns true if the error was caused by a failed conditional write.
+func IsVersionConflict(err error) bool {
+	return errors.Is(err, ErrVersionConflict)
+}
+
+// ReadFileVersion returns the contents of the file, along with a token identifying the version read.
+// Paths that do not implement VersionedPath use a hash of the contents as the version.
+func ReadFileVersion(ctx context.Context, p Path) ([]byte, string, error) {
+	if vp, ok := p.(VersionedPath); ok {
+		return vp.ReadFileVersion(ctx)
+	}
+
+	data, err := p.ReadFile(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+	return data, contentVersion(data), nil
+}
+
+// WriteFileIfVersion writes the file only if it is still at the version returned by ReadFileVersion.
+// Paths that do not implement VersionedPath get a best-effort check: the file is read and compared
+// before it is written, which narrows but does not close the window for a concurrent write.
+func WriteFileIfVersion(ctx context.Context, p Path, data io.ReadSeeker, acl ACL, version string) (string, error) {
+	if vp, ok := p.(VersionedPath); ok {
+		return vp.WriteFileIfVersion(ctx, data, acl, version)
+	}
+
+	current, err := p.ReadFile(ctx)
+	if err != nil {
+		return "", err
+	}
+	if contentVersion(current) != version {
+		return "", fmt.Errorf("error writing %s: %w", p, ErrVersionConflict)
+	}
+
+	b, err := io.ReadAll(data)
+	if err != nil {
+		return "", fmt.Errorf("error reading data: %w", err)
+	}
+	if err := p.WriteFile(ctx, bytes.NewReader(b), acl); err != nil {
+		return "", err
+	}
+	return contentVersion(b), nil
+}
+
+// CreateFileIfNotExists creates the file only if it does not already exist, returning the version that was written.
+// If the file exists, err = os.ErrExist.  Paths that do not implement VersionedPath fall back to CreateFile, which
+// checks for the file before writing it, so a concurrent create can still replace the file without either writer
+// seeing an error; atomic is false for those, and callers that need a single winner must read the file back.
+func CreateFileIfNotExists(ctx context.Context, p Path, data io.ReadSeeker, acl ACL) (version string, atomic bool, err error) {
+	if vp, ok := p.(VersionedPath); ok {
+		version, err := vp.CreateFileIfNotExists(ctx, data, acl)
+		return version, true, err
+	}
+
+	b, err := io.ReadAll(data)
+	if err != nil {
+		return "", false, fmt.Errorf("error reading data: %w", err)
+	}
+	if err := p.CreateFile(ctx, bytes.NewReader(b), acl); err != nil {
+		return "", false, err
+	}
+	return contentVersion(b), false, nil
+}
+
+// contentVersion computes a version token from the contents of a file.
+func contentVersion(data []byte) string {
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:])
+}
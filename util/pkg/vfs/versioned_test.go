@@ -0,0 +1,162 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vfs
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"k8s.io/kops/pkg/testutils/testcontext"
+)
+
+func TestWriteFileIfVersion(t *testing.T) {
+	ctx := testcontext.ForTest(t)
+
+	memfsContext := NewMemFSContext()
+	fsDir := filepath.Join(t.TempDir(), "cluster")
+	grid := []struct {
+		name string
+		path Path
+		// dir is the local directory holding the file, if any
+		dir string
+	}{
+		{
+			name: "memfs",
+			path: NewMemFSPath(memfsContext, "cluster/config"),
+		},
+		{
+			name: "fs",
+			path: NewFSPath(filepath.Join(fsDir, "config")),
+			dir:  fsDir,
+		},
+	}
+
+	for _, g := range grid {
+		t.Run(g.name, func(t *testing.T) {
+			if _, _, err := ReadFileVersion(ctx, g.path); !os.IsNotExist(err) {
+				t.Fatalf("expected not-exist error reading missing file, got %v", err)
+			}
+
+			if err := g.path.WriteFile(ctx, bytes.NewReader([]byte("v1")), nil); err != nil {
+				t.Fatalf("error writing file: %v", err)
+			}
+
+			data, version, err := ReadFileVersion(ctx, g.path)
+			if err != nil {
+				t.Fatalf("error reading file: %v", err)
+			}
+			if string(data) != "v1" {
+				t.Fatalf("unexpected contents %q", data)
+			}
+
+			// Someone else writes the file
+			if err := g.path.WriteFile(ctx, bytes.NewReader([]byte("v2")), nil); err != nil {
+				t.Fatalf("error writing file: %v", err)
+			}
+
+			// Our conditional write, based on the old version, should be rejected
+			_, err = WriteFileIfVersion(ctx, g.path, bytes.NewReader([]byte("v3")), nil, version)
+			if !IsVersionConflict(err) {
+				t.Fatalf("expected version conflict, got %v", err)
+			}
+
+			// After re-reading, the write succeeds
+			_, version, err = ReadFileVersion(ctx, g.path)
+			if err != nil {
+				t.Fatalf("error reading file: %v", err)
+			}
+			newVersion, err := WriteFileIfVersion(ctx, g.path, bytes.NewReader([]byte("v3")), nil, version)
+			if err != nil {
+				t.Fatalf("unexpected error from conditional write: %v", err)
+			}
+
+			data, readVersion, err := ReadFileVersion(ctx, g.path)
+			if err != nil {
+				t.Fatalf("error reading file: %v", err)
+			}
+			if string(data) != "v3" {
+				t.Errorf("unexpected contents %q", data)
+			}
+			if readVersion != newVersion {
+				t.Errorf("expected version %q from read, got %q", newVersion, readVersion)
+			}
+
+			if g.dir != "" {
+				// The lock file should not be left behind
+				entries, err := os.ReadDir(g.dir)
+				if err != nil {
+					t.Fatalf("error listing directory: %v", err)
+				}
+				if len(entries) != 1 {
+					t.Errorf("expected only the file in the directory, got %v", entries)
+				}
+			}
+		})
+	}
+}
+
+func TestCreateFileIfNotExists(t *testing.T) {
+	ctx := testcontext.ForTest(t)
+
+	grid := []struct {
+		name string
+		path Path
+	}{
+		{
+			name: "memfs",
+			path: NewMemFSPath(NewMemFSContext(), "cluster/lock"),
+		},
+		{
+			name: "fs",
+			path: NewFSPath(filepath.Join(t.TempDir(), "lock")),
+		},
+	}
+
+	for _, g := range grid {
+		t.Run(g.name, func(t *testing.T) {
+			version, atomic, err := CreateFileIfNotExists(ctx, g.path, bytes.NewReader([]byte("v1")), nil)
+			if err != nil {
+				t.Fatalf("error creating file: %v", err)
+			}
+			if !atomic {
+				t.Errorf("expected create to be atomic")
+			}
+
+			_, readVersion, err := ReadFileVersion(ctx, g.path)
+			if err != nil {
+				t.Fatalf("error reading file: %v", err)
+			}
+			if readVersion != version {
+				t.Errorf("expected version %q from read, got %q", version, readVersion)
+			}
+
+			if _, _, err := CreateFileIfNotExists(ctx, g.path, bytes.NewReader([]byte("v2")), nil); !os.IsExist(err) {
+				t.Fatalf("expected exists error creating existing file, got %v", err)
+			}
+
+			data, err := g.path.ReadFile(ctx)
+			if err != nil {
+				t.Fatalf("error reading file: %v", err)
+			}
+			if string(data) != "v1" {
+				t.Errorf("unexpected contents %q", data)
+			}
+		})
+	}
+}
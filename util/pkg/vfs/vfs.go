@@ -81,6 +81,24 @@ type TerraformPath interface {
 	RenderTerraform(writer *terraformWriter.TerraformWriter, name string, data io.Reader, acl ACL) error
 }
 
+// VersionedPath is a Path that supports optimistic concurrency control, by conditionally writing
+// only if the file has not changed since it was read.
+type VersionedPath interface {
+	Path
+
+	// ReadFileVersion returns the contents of the file, and an opaque token identifying the version that was read.
+	// If the file did not exist, err = os.ErrNotExist
+	ReadFileVersion(ctx context.Context) ([]byte, string, error)
+
+	// WriteFileIfVersion replaces the file contents, but only if the file is still at the specified version.
+	// It returns the version that was written.  If the file has since been changed, the error wraps ErrVersionConflict.
+	WriteFileIfVersion(ctx context.Context, data io.ReadSeeker, acl ACL, version string) (string, error)
+
+	// CreateFileIfNotExists creates the file, with the check that it does not exist and the write as one atomic operation.
+	// It returns the version that was written.  If the file already exists, err = os.ErrExist
+	CreateFileIfNotExists(ctx context.Context, data io.ReadSeeker, acl ACL) (string, error)
+}
+
 type HasHash interface {
 	// Returns the hash of the file contents, with the preferred hash algorithm
 	PreferredHash() (*hashing.Hash, error)